@@ -41,6 +41,13 @@ resource "gopass_secret" "db_admin_password" {
   delete_on_remove = false
 }
 
+# Or let gopass generate the password itself (never in state either)
+resource "gopass_secret" "service_token" {
+  path                = "infrastructure/service/token"
+  generate_if_missing = true
+  generate_length     = 40
+}
+
 # -----------------------------------------------------------------------------
 # Example 2: Store an API key from another provider
 # -----------------------------------------------------------------------------
@@ -91,3 +98,20 @@ output "stored_password_path" {
   value       = gopass_secret.db_admin_password.path
   description = "Path where the generated password is stored in gopass"
 }
+
+# -----------------------------------------------------------------------------
+# Example 4: Store a TOTP seed, readable with `gopass otp`
+# -----------------------------------------------------------------------------
+
+# Added next to an existing login entry; its password and fields are kept
+variable "admin_otpauth_uri" {
+  type      = string
+  sensitive = true
+  ephemeral = true
+}
+
+resource "gopass_otp_secret" "admin" {
+  path           = "websites/idp/admin"
+  uri_wo         = var.admin_otpauth_uri
+  uri_wo_version = 1
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Outcomes recorded in the audit log.
+const (
+	auditOutcomeSuccess = "success"
+	auditOutcomeFailure = "failure"
+)
+
+// auditEntry is one line of the audit log. It never contains secret values.
+type auditEntry struct {
+	Time      string `json:"time"`
+	Operation string `json:"operation"`
+	Path      string `json:"path"`
+	Outcome   string `json:"outcome"`
+	Code      string `json:"code,omitempty"`
+}
+
+// auditLog implements clientHooks by appending a JSON line per store operation to a file.
+// The file is opened for each entry, so it can be rotated while the provider runs.
+type auditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// EnableAuditLog appends a JSON line for every get, set and remove of a secret to the file
+// at logPath, which is created with mode 0600 if missing. A leading "~/" is expanded.
+// Hooks installed before are still notified.
+func (c *GopassClient) EnableAuditLog(logPath string) error {
+	expanded, err := c.expandPath(logPath)
+	if err != nil {
+		return err
+	}
+
+	// Fail early if the file cannot be written, rather than on the first operation
+	if err := appendFile(expanded, nil); err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	c.addHooks(&auditLog{path: expanded})
+	return nil
+}
+
+// OnRead implements clientHooks.
+func (a *auditLog) OnRead(ctx context.Context, path string) {
+	a.record(ctx, auditEntry{Operation: opGet, Path: path, Outcome: auditOutcomeSuccess})
+}
+
+// OnWrite implements clientHooks.
+func (a *auditLog) OnWrite(ctx context.Context, op, path string) {
+	a.record(ctx, auditEntry{Operation: op, Path: path, Outcome: auditOutcomeSuccess})
+}
+
+// OnError implements clientHooks. Only the diagnostic code of err is recorded, not its message.
+func (a *auditLog) OnError(ctx context.Context, op, path string, err error) {
+	a.record(ctx, auditEntry{Operation: op, Path: path, Outcome: auditOutcomeFailure, Code: ErrorCode(err)})
+}
+
+// record appends entry to the log. Failures are logged, as hooks cannot fail the operation.
+func (a *auditLog) record(ctx context.Context, entry auditEntry) {
+	entry.Time = timeNow().UTC().Format(time.RFC3339Nano)
+	// An entry only holds strings, so marshaling cannot fail
+	line, _ := json.Marshal(entry)
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := appendFile(a.path, line); err != nil {
+		tflog.Warn(ctx, "Could not write audit log", map[string]interface{}{
+			"audit_log": a.path,
+			"path":      entry.Path,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// appendFile appends data to the file at path in a single write.
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
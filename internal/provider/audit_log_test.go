@@ -0,0 +1,324 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readAuditLog returns the entries of the audit log at path.
+func readAuditLog(t *testing.T, path string) []auditEntry {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid audit log line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestGopassClient_EnableAuditLog(t *testing.T) {
+	now := timeNow
+	timeNow = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = now })
+
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	store := newMockStore()
+	store.secrets["app/db"] = newMockSecret("hunter2")
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	if err := client.EnableAuditLog(logPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(logPath)
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected audit log with mode 0600, got %v, %v", info, err)
+	}
+
+	if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.SetSecret(ctx, "app/api", "token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.RemoveSecret(ctx, "app/api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetSecret(ctx, "app/missing"); err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+
+	want := []auditEntry{
+		{Time: "2026-10-16T12:00:00Z", Operation: opGet, Path: "app/db", Outcome: auditOutcomeSuccess},
+		{Time: "2026-10-16T12:00:00Z", Operation: opSet, Path: "app/api", Outcome: auditOutcomeSuccess},
+		{Time: "2026-10-16T12:00:00Z", Operation: opRemove, Path: "app/api", Outcome: auditOutcomeSuccess},
+		{Time: "2026-10-16T12:00:00Z", Operation: opGet, Path: "app/missing", Outcome: auditOutcomeFailure, Code: CodeSecretNotFound},
+	}
+	got := readAuditLog(t, logPath)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entries, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	content, _ := os.ReadFile(logPath)
+	for _, value := range []string{"hunter2", "token"} {
+		if strings.Contains(string(content), value) {
+			t.Errorf("audit log must not contain secret values, found %q", value)
+		}
+	}
+}
+
+func TestGopassClient_EnableAuditLog_EveryRead(t *testing.T) {
+	tests := map[string]func(ctx context.Context, c *GopassClient) error{
+		"GetSecret": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.GetSecret(ctx, "app/db")
+			return err
+		},
+		"GetSecretFull": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.GetSecretFull(ctx, "app/db", "")
+			return err
+		},
+		"LookupSecretField": func(ctx context.Context, c *GopassClient) error {
+			_, _, err := c.LookupSecretField(ctx, "app/db", "username")
+			return err
+		},
+		"LookupSecretFields": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.LookupSecretFields(ctx, "app/db", "username")
+			return err
+		},
+		"SecretExists": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.SecretExists(ctx, "app/db")
+			return err
+		},
+		"GetRevisionCount": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.GetRevisionCount(ctx, "app/db")
+			return err
+		},
+		"GetSecretInfo": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.GetSecretInfo(ctx, "app/db")
+			return err
+		},
+		"HasOTPSecret": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.HasOTPSecret(ctx, "app/db")
+			return err
+		},
+		"GetOTPURI": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.GetOTPURI(ctx, "app/db")
+			return err
+		},
+		"GetEnvSecrets": func(ctx context.Context, c *GopassClient) error {
+			_, err := c.GetEnvSecrets(ctx, "app")
+			return err
+		},
+		"ReadSecretTree": func(ctx context.Context, c *GopassClient) error {
+			_, _, err := c.ReadSecretTree(ctx, "app", 0)
+			return err
+		},
+		"CopySecret": func(ctx context.Context, c *GopassClient) error {
+			return c.CopySecret(ctx, "app/db", "app/copy")
+		},
+		"SetSecretField": func(ctx context.Context, c *GopassClient) error {
+			return c.SetSecretField(ctx, "app/db", "username", "admin")
+		},
+	}
+
+	for name, read := range tests {
+		t.Run(name, func(t *testing.T) {
+			logPath := filepath.Join(t.TempDir(), "audit.log")
+			client := NewGopassClient(t.TempDir())
+			client.store = storeWith(map[string]string{"app/db": "otpauth://totp/gopass?secret=JBSWY3DPEHPK3PXP"})
+			if err := client.EnableAuditLog(logPath); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := read(context.Background(), client); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, entry := range readAuditLog(t, logPath) {
+				if entry.Operation == opGet && entry.Path == "app/db" && entry.Outcome == auditOutcomeSuccess {
+					return
+				}
+			}
+			t.Errorf("expected the read of app/db to be recorded, got %v", readAuditLog(t, logPath))
+		})
+	}
+}
+
+func TestGopassClient_EnableAuditLog_KeepsHooks(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"app/db": "v"})
+	hooks := &recordingHooks{}
+	client.addHooks(hooks)
+
+	if err := client.EnableAuditLog(logPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetSecret(context.Background(), "app/db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(hooks.events) != 1 || hooks.events[0] != "read app/db" {
+		t.Errorf("expected the hooks installed before to be notified, got %v", hooks.events)
+	}
+	if entries := readAuditLog(t, logPath); len(entries) != 1 || entries[0].Path != "app/db" {
+		t.Errorf("expected the read in the audit log, got %v", entries)
+	}
+}
+
+func TestGopassClient_EnableAuditLog_Appends(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(logPath, []byte(`{"time":"earlier","operation":"get","path":"x","outcome":"success"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"app/db": "v"})
+
+	if err := client.EnableAuditLog(logPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetSecret(context.Background(), "app/db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries := readAuditLog(t, logPath)
+	if len(entries) != 2 || entries[0].Time != "earlier" || entries[1].Path != "app/db" {
+		t.Errorf("expected existing entry to be kept, got %v", entries)
+	}
+}
+
+func TestGopassClient_EnableAuditLog_Errors(t *testing.T) {
+	t.Run("missing directory", func(t *testing.T) {
+		client := NewGopassClient("")
+		err := client.EnableAuditLog(filepath.Join(t.TempDir(), "missing", "audit.log"))
+		if err == nil || !strings.Contains(err.Error(), "failed to open audit log") {
+			t.Errorf("expected open error, got %v", err)
+		}
+	})
+
+	t.Run("home expansion", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+		if err := client.EnableAuditLog("~/audit.log"); err == nil || !strings.Contains(err.Error(), "no home") {
+			t.Errorf("expected home error, got %v", err)
+		}
+	})
+
+	t.Run("home directory", func(t *testing.T) {
+		home := t.TempDir()
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return home, nil }
+		if err := client.EnableAuditLog("~/audit.log"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(home, "audit.log")); err != nil {
+			t.Errorf("expected audit log in home directory: %v", err)
+		}
+	})
+}
+
+func TestAuditLog_WriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	a := &auditLog{path: filepath.Join(dir, "gone", "audit.log")}
+
+	// Must not panic or block the operation
+	a.OnWrite(context.Background(), opSet, "app/db")
+
+	if _, err := os.Stat(a.path); !os.IsNotExist(err) {
+		t.Errorf("expected no audit log to be written, got %v", err)
+	}
+}
+
+func TestAuditLog_Concurrent(t *testing.T) {
+	a := &auditLog{path: filepath.Join(t.TempDir(), "audit.log")}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.OnRead(ctx, "app/db")
+		}()
+	}
+	wg.Wait()
+
+	if got := len(readAuditLog(t, a.path)); got != 20 {
+		t.Errorf("expected 20 entries, got %d", got)
+	}
+}
+
+func TestProviderConfigure_AuditLogPath(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "writable", path: filepath.Join(t.TempDir(), "audit.log")},
+		{name: "missing directory", path: filepath.Join(t.TempDir(), "missing", "audit.log"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"audit_log_path": tftypes.NewValue(tftypes.String, tt.path),
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, resp.Diagnostics)
+			}
+			if tt.wantErr {
+				if resp.ResourceData != nil {
+					t.Error("ResourceData should be nil when the audit log cannot be opened")
+				}
+				return
+			}
+			if _, ok := resp.ResourceData.(*GopassClient).currentHooks().(*auditLog); !ok {
+				t.Error("expected the audit log to be installed as client hooks")
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                   = &ConfigResource{}
+	_ resource.ResourceWithConfigure      = &ConfigResource{}
+	_ resource.ResourceWithValidateConfig = &ConfigResource{}
+	_ resource.ResourceWithImportState    = &ConfigResource{}
+)
+
+// configKey matches the keys gopass config files hold: a section and a name, optionally with
+// a subsection such as a mount name in between, e.g. "core.autosync" or "mounts.work.path".
+var configKey = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*\.([^\s"\\]+\.)?[A-Za-z][A-Za-z0-9-]*$`)
+
+// ConfigResource manages an entry of the gopass configuration, like `gopass config key value`.
+type ConfigResource struct {
+	client *GopassClient
+}
+
+// ConfigResourceModel describes the resource data model.
+type ConfigResourceModel struct {
+	ID    types.String `tfsdk:"id"`
+	Scope types.String `tfsdk:"scope"`
+	Key   types.String `tfsdk:"key"`
+	Value types.String `tfsdk:"value"`
+}
+
+// NewConfigResource creates a new instance.
+func NewConfigResource() resource.Resource {
+	return &ConfigResource{}
+}
+
+func (r *ConfigResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_config"
+}
+
+func (r *ConfigResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages an entry of the gopass configuration (like `gopass config core.autosync false`).",
+		MarkdownDescription: `
+Manages an entry of the gopass configuration (like ` + "`gopass config core.autosync false`" + `),
+so the behavior of gopass can be standardized across machines.
+
+The entry is written to the per-user config (` + "`~/.config/gopass/config`" + `) or, with
+` + "`scope = \"store\"`" + `, to the config of the store of the provider, which overrides it for
+that store. Other entries and comments in the file are kept. The value is verified on every
+refresh: if it was changed outside of Terraform, the next apply sets it again. On destroy, the
+entry is removed, so gopass falls back to its default. A changed entry applies to gopass
+commands run afterwards; the store the provider has already opened is not reloaded.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_config" "autosync" {
+  key   = "core.autosync"
+  value = "false"
+}
+
+resource "gopass_config" "team_mount" {
+  key   = "mounts.team.path"
+  value = "/srv/team-store"
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description:         "The scope and key of the entry, e.g. 'global:core.autosync'.",
+				MarkdownDescription: "The scope and key of the entry, e.g. `global:core.autosync`.",
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"scope": schema.StringAttribute{
+				Description: "Config file the entry is written to: 'global' for the per-user config that " +
+					"`gopass config` writes to, or 'store' for the config of the store of the provider. Defaults to 'global'.",
+				MarkdownDescription: "Config file the entry is written to: `global` for the per-user config that " +
+					"`gopass config` writes to, or `store` for the config of the store of the provider. Defaults to `global`.",
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(configScopeGlobal),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"key": schema.StringAttribute{
+				Description: "Name of the entry, e.g. 'core.autosync', 'generate.autoclip' or 'mounts.work.path'. " +
+					"Run `gopass config` for the entries gopass knows.",
+				MarkdownDescription: "Name of the entry, e.g. `core.autosync`, `generate.autoclip` or `mounts.work.path`. " +
+					"Run `gopass config` for the entries gopass knows.",
+				Required: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"value": schema.StringAttribute{
+				Description:         "Value of the entry. Booleans are written as 'true' or 'false'.",
+				MarkdownDescription: "Value of the entry. Booleans are written as `\"true\"` or `\"false\"`.",
+				Required:            true,
+			},
+		},
+	}
+}
+
+func (r *ConfigResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *ConfigResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data ConfigResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if scope := data.Scope.ValueString(); !data.Scope.IsUnknown() && !data.Scope.IsNull() &&
+		scope != configScopeGlobal && scope != configScopeStore {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("scope"),
+			"Invalid scope",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("scope must be %q or %q, got %q.", configScopeGlobal, configScopeStore, scope)),
+		)
+	}
+	if key := data.Key.ValueString(); !data.Key.IsUnknown() && !configKey.MatchString(key) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("key"),
+			"Invalid key",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("key must be a section and a name separated by a dot, "+
+				"optionally with a subsection in between, e.g. \"core.autosync\" or \"mounts.work.path\", got %q.", key)),
+		)
+	}
+	if value := data.Value.ValueString(); strings.ContainsAny(value, "\r\n") {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("value"),
+			"Invalid value",
+			codedDetail(CodeInvalidConfig, "value must be a single line, as gopass config entries cannot span lines."),
+		)
+	}
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *ConfigResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data ConfigResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	scope, key := data.Scope.ValueString(), data.Key.ValueString()
+
+	if err := r.client.SetConfig(ctx, scope, key, data.Value.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to set gopass config",
+			errorDetail(err, fmt.Sprintf("Could not set %s in the %s gopass config: %s", key, scope, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Set gopass config", map[string]interface{}{
+		"scope": scope,
+		"key":   key,
+	})
+
+	data.ID = types.StringValue(scope + ":" + key)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *ConfigResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data ConfigResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	scope, key := data.Scope.ValueString(), data.Key.ValueString()
+
+	value, exists, err := r.client.GetConfig(ctx, scope, key)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read gopass config",
+			errorDetail(err, fmt.Sprintf("Could not read %s from the %s gopass config: %s", key, scope, err.Error())),
+		)
+		return
+	}
+
+	if !exists {
+		// The entry was removed outside of Terraform
+		tflog.Warn(ctx, "Gopass config entry no longer exists, removing from state", map[string]interface{}{
+			"scope": scope,
+			"key":   key,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.Value = types.StringValue(value)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *ConfigResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data ConfigResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	scope, key := data.Scope.ValueString(), data.Key.ValueString()
+
+	if err := r.client.SetConfig(ctx, scope, key, data.Value.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to set gopass config",
+			errorDetail(err, fmt.Sprintf("Could not set %s in the %s gopass config: %s", key, scope, err.Error())),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *ConfigResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data ConfigResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	scope, key := data.Scope.ValueString(), data.Key.ValueString()
+
+	if err := r.client.UnsetConfig(ctx, scope, key); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove gopass config",
+			errorDetail(err, fmt.Sprintf("Could not remove %s from the %s gopass config: %s", key, scope, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass config", map[string]interface{}{
+		"scope": scope,
+		"key":   key,
+	})
+}
+
+// ImportState imports an entry by key, e.g. "core.autosync" for the per-user config or
+// "store:core.autosync" for the config of the store. The value is read on refresh.
+func (r *ConfigResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	scope, key := configScopeGlobal, req.ID
+	for _, s := range []string{configScopeGlobal, configScopeStore} {
+		if rest, ok := strings.CutPrefix(req.ID, s+":"); ok {
+			scope, key = s, rest
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), scope+":"+key)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("scope"), scope)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("key"), key)...)
+}
@@ -0,0 +1,350 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// configTestSetup returns a config resource whose gopass configuration lives in a
+// temporary home directory, so tests never touch the configuration of the user.
+func configTestSetup(t *testing.T) (*ConfigResource, resource.SchemaResponse) {
+	t.Helper()
+
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	return testResource(&ConfigResource{client: NewGopassClient(t.TempDir())})
+}
+
+func configValue(schemaResp resource.SchemaResponse, scope, key, value string) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":    tftypes.NewValue(tftypes.String, scope+":"+key),
+		"scope": tftypes.NewValue(tftypes.String, scope),
+		"key":   tftypes.NewValue(tftypes.String, key),
+		"value": tftypes.NewValue(tftypes.String, value),
+	})
+}
+
+func TestConfigResource_Metadata(t *testing.T) {
+	r := NewConfigResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_config" {
+		t.Errorf("expected 'gopass_config', got %q", resp.TypeName)
+	}
+}
+
+func TestConfigResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &ConfigResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &ConfigResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestConfigResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]tftypes.Value
+		invalid bool
+		wantErr string
+	}{
+		{name: "section key", config: map[string]tftypes.Value{}},
+		{name: "subsection key", config: map[string]tftypes.Value{"key": tftypes.NewValue(tftypes.String, "mounts.work.path")}},
+		{name: "store scope", config: map[string]tftypes.Value{"scope": tftypes.NewValue(tftypes.String, "store")}},
+		{name: "unknown", config: map[string]tftypes.Value{
+			"scope": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"key":   tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"value": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		}},
+		{name: "invalid scope", config: map[string]tftypes.Value{"scope": tftypes.NewValue(tftypes.String, "system")}, wantErr: "Invalid scope"},
+		{name: "key without section", config: map[string]tftypes.Value{"key": tftypes.NewValue(tftypes.String, "autosync")}, wantErr: "Invalid key"},
+		{name: "key with space", config: map[string]tftypes.Value{"key": tftypes.NewValue(tftypes.String, "mounts.my work.path")}, wantErr: "Invalid key"},
+		{name: "multi-line value", config: map[string]tftypes.Value{"value": tftypes.NewValue(tftypes.String, "a\nb")}, wantErr: "Invalid value"},
+		{name: "invalid config", invalid: true, wantErr: "Value Conversion Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &ConfigResource{}
+			schemaResp := resource.SchemaResponse{}
+			r.Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+
+			raw := invalidRaw
+			if !tc.invalid {
+				config := map[string]tftypes.Value{
+					"key":   tftypes.NewValue(tftypes.String, "core.autosync"),
+					"value": tftypes.NewValue(tftypes.String, "false"),
+				}
+				for k, v := range tc.config {
+					config[k] = v
+				}
+				raw = schemaObjectValue(schemaResp.Schema, config)
+			}
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestConfigResource_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "sets entry", key: "core.autosync"},
+		{name: "set fails", key: "autosync", wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, schemaResp := configTestSetup(t)
+			plan := configValue(schemaResp, configScopeStore, tc.key, "false")
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if value, ok, err := r.client.GetConfig(ctx, configScopeStore, tc.key); err != nil || !ok || value != "false" {
+				t.Errorf("expected the entry to be set, got %q, %v, %v", value, ok, err)
+			}
+			var state ConfigResourceModel
+			resp.State.Get(ctx, &state)
+			if state.ID.ValueString() != "store:core.autosync" {
+				t.Errorf("expected id 'store:core.autosync', got %q", state.ID.ValueString())
+			}
+		})
+	}
+}
+
+func TestConfigResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    string
+		wantValue   string
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "unchanged", existing: "false", wantValue: "false"},
+		{name: "changed externally", existing: "true", wantValue: "true"},
+		{name: "removed externally", wantRemoved: true},
+		{name: "read fails", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, schemaResp := configTestSetup(t)
+			if tc.existing != "" {
+				if err := r.client.SetConfig(ctx, configScopeGlobal, "core.autosync", tc.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.wantErr {
+				// A file in place of the config directory fails the read
+				if err := os.WriteFile(filepath.Join(os.Getenv("GOPASS_HOMEDIR"), ".config"), nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			raw := configValue(schemaResp, configScopeGlobal, "core.autosync", "false")
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.wantRemoved {
+				t.Fatalf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !tc.wantRemoved {
+				var state ConfigResourceModel
+				resp.State.Get(ctx, &state)
+				if state.Value.ValueString() != tc.wantValue {
+					t.Errorf("expected value %q, got %q", tc.wantValue, state.Value.ValueString())
+				}
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := configTestSetup(t)
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestConfigResource_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "value changed", key: "core.autosync"},
+		{name: "set fails", key: "autosync", wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, schemaResp := configTestSetup(t)
+			if err := r.client.SetConfig(ctx, configScopeGlobal, "core.autosync", "false"); err != nil {
+				t.Fatal(err)
+			}
+			plan := configValue(schemaResp, configScopeGlobal, tc.key, "true")
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: configValue(schemaResp, configScopeGlobal, "core.autosync", "false")},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if value, _, _ := r.client.GetConfig(ctx, configScopeGlobal, "core.autosync"); !tc.wantErr && value != "true" {
+				t.Errorf("expected the entry to be updated, got %q", value)
+			}
+		})
+	}
+}
+
+func TestConfigResource_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    bool
+		invalid bool
+		wantErr bool
+	}{
+		{name: "removes entry"},
+		{name: "remove fails", fail: true, wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, schemaResp := configTestSetup(t)
+			if err := r.client.SetConfig(ctx, configScopeGlobal, "core.autosync", "false"); err != nil {
+				t.Fatal(err)
+			}
+			state := configValue(schemaResp, configScopeGlobal, "core.autosync", "false")
+			if tc.fail {
+				// A file in place of the store fails the read of its config
+				r.client.storePath = filepath.Join(t.TempDir(), "store")
+				if err := os.WriteFile(r.client.storePath, nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+				state = configValue(schemaResp, configScopeStore, "core.autosync", "false")
+			}
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if _, ok, _ := r.client.GetConfig(ctx, configScopeGlobal, "core.autosync"); !tc.wantErr && ok {
+				t.Error("expected the entry to be removed")
+			}
+		})
+	}
+}
+
+func TestConfigResource_ImportState(t *testing.T) {
+	tests := []struct {
+		id        string
+		wantScope string
+		wantKey   string
+	}{
+		{id: "core.autosync", wantScope: "global", wantKey: "core.autosync"},
+		{id: "global:core.autosync", wantScope: "global", wantKey: "core.autosync"},
+		{id: "store:mounts.work.path", wantScope: "store", wantKey: "mounts.work.path"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			r, schemaResp := configTestSetup(t)
+
+			resp := &resource.ImportStateResponse{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.ImportState(context.Background(), resource.ImportStateRequest{ID: tc.id}, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			var state ConfigResourceModel
+			resp.State.Get(context.Background(), &state)
+			if state.Scope.ValueString() != tc.wantScope || state.Key.ValueString() != tc.wantKey ||
+				state.ID.ValueString() != tc.wantScope+":"+tc.wantKey {
+				t.Errorf("unexpected imported state %+v", state)
+			}
+		})
+	}
+}
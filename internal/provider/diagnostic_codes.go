@@ -0,0 +1,99 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Diagnostic codes categorize failures for automation. The detail of every diagnostic the
+// provider emits starts with its code in brackets, e.g. "[GOPASS_GPG_ERROR] Could not read ...",
+// so tools parsing `terraform plan -json` can branch on the category without matching messages.
+const (
+	// CodeStoreNotFound means the password store itself could not be found.
+	CodeStoreNotFound = "GOPASS_STORE_NOT_FOUND"
+	// CodeStoreNotInitialized means the store directory exists but was never initialized.
+	CodeStoreNotInitialized = "GOPASS_STORE_NOT_INITIALIZED"
+	// CodeSecretNotFound means there is no secret at the requested path.
+	CodeSecretNotFound = "GOPASS_SECRET_NOT_FOUND"
+	// CodeAlreadyExists means a secret is in the way of one to be created.
+	CodeAlreadyExists = "GOPASS_ALREADY_EXISTS"
+	// CodeGPGError means encryption or decryption failed, e.g. a missing key or an unplugged token.
+	CodeGPGError = "GOPASS_GPG_ERROR"
+	// CodePermission means the store or a file in it is not accessible.
+	CodePermission = "GOPASS_PERMISSION"
+	// CodeTimeout means a gopass operation exceeded its timeout.
+	CodeTimeout = "GOPASS_TIMEOUT"
+	// CodeStoreLocked means another run holds the lock of the store, see lock_timeout.
+	CodeStoreLocked = "GOPASS_STORE_LOCKED"
+	// CodeProtectedPath means a secret matching protected_paths was about to be removed.
+	CodeProtectedPath = "GOPASS_PROTECTED_PATH"
+	// CodePolicyViolation means a value was not written as it violates password_policy.
+	CodePolicyViolation = "GOPASS_POLICY_VIOLATION"
+	// CodeInvalidConfig means the configuration is invalid or incomplete.
+	CodeInvalidConfig = "GOPASS_INVALID_CONFIG"
+	// CodeDrift means a secret was changed outside of Terraform.
+	CodeDrift = "GOPASS_DRIFT"
+	// CodeExpired means a secret is past its expires_at.
+	CodeExpired = "GOPASS_EXPIRED"
+	// CodeChecksumMismatch means a secret does not have the digest the configuration expects,
+	// or not that of the value just written to it.
+	CodeChecksumMismatch = "GOPASS_CHECKSUM_MISMATCH"
+	// CodeStoreChanged means a secret was written to a different store than the provider uses now.
+	CodeStoreChanged = "GOPASS_STORE_CHANGED"
+	// CodeInternal means the provider itself is in an unexpected state, e.g. undecodable private state.
+	CodeInternal = "GOPASS_INTERNAL"
+	// CodeError is any other failure.
+	CodeError = "GOPASS_ERROR"
+)
+
+// codedError attaches a diagnostic code to an error while keeping its message.
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode returns err tagged with code, which ErrorCode then reports for it and every error wrapping it.
+func withCode(code string, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// ErrorCode returns the diagnostic code of an error returned by a GopassClient method.
+// Errors tagged by the client keep their code; others are categorized by kind and message.
+func ErrorCode(err error) string {
+	var coded *codedError
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.Is(err, ErrSecretNotFound):
+		return CodeSecretNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "permission denied"):
+		return CodePermission
+	case strings.Contains(msg, "gpg"), strings.Contains(msg, "decrypt"):
+		return CodeGPGError
+	}
+	return CodeError
+}
+
+// codedDetail returns the detail of a diagnostic, prefixed with code.
+func codedDetail(code, detail string) string {
+	return "[" + code + "] " + detail
+}
+
+// errorDetail returns the detail of a diagnostic about err, prefixed with the code of err.
+func errorDetail(err error, detail string) string {
+	return codedDetail(ErrorCode(err), detail)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "tagged", err: withCode(CodeStoreNotFound, errors.New("gopass store not found")), want: CodeStoreNotFound},
+		{name: "wrapped tag", err: fmt.Errorf("outer: %w", withCode(CodePermission, errors.New("inner"))), want: CodePermission},
+		{name: "secret not found", err: fmt.Errorf("failed to get secret: %w", &notFoundError{err: errors.New("entry is not in the password store")}), want: CodeSecretNotFound},
+		{name: "timeout", err: fmt.Errorf("failed to get secret: %w", context.DeadlineExceeded), want: CodeTimeout},
+		{name: "permission", err: errors.New("open /store/a.gpg: permission denied"), want: CodePermission},
+		{name: "gpg", err: errors.New("gpg: decryption failed: No secret key"), want: CodeGPGError},
+		{name: "decrypt", err: errors.New("failed to decrypt"), want: CodeGPGError},
+		{name: "other", err: errors.New("disk full"), want: CodeError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ErrorCode(tc.err); got != tc.want {
+				t.Errorf("ErrorCode() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCodedError_KeepsMessage(t *testing.T) {
+	inner := errors.New("permission denied")
+	err := withCode(CodePermission, inner)
+	if err.Error() != "permission denied" || !errors.Is(err, inner) {
+		t.Errorf("expected message and chain of the tagged error, got %v", err)
+	}
+}
+
+func TestErrorDetail(t *testing.T) {
+	got := errorDetail(ErrSecretNotFound, "Could not read secret at path \"a\"")
+	if got != "[GOPASS_SECRET_NOT_FOUND] Could not read secret at path \"a\"" {
+		t.Errorf("unexpected detail %q", got)
+	}
+}
+
+func TestSecretResource_Read_DiagnosticCode(t *testing.T) {
+	r := &SecretResource{}
+	store := newMockStore()
+	store.shouldFail = true
+	store.failMsg = "gpg: decryption failed: No secret key"
+	client := NewGopassClient("")
+	client.store = store
+	r.client = client
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":   tftypes.NewValue(tftypes.String, "app/db"),
+		"path": tftypes.NewValue(tftypes.String, "app/db"),
+	})
+
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+	r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error")
+	}
+	if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.HasPrefix(detail, "[GOPASS_GPG_ERROR] ") {
+		t.Errorf("expected detail to start with the GPG code, got %q", detail)
+	}
+}
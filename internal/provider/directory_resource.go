@@ -0,0 +1,302 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                = &DirectoryResource{}
+	_ resource.ResourceWithConfigure   = &DirectoryResource{}
+	_ resource.ResourceWithImportState = &DirectoryResource{}
+)
+
+// DirectoryResource manages a folder of the store, kept in place by a placeholder file
+// or a template while it holds no secrets.
+type DirectoryResource struct {
+	client *GopassClient
+}
+
+// DirectoryResourceModel describes the resource data model.
+type DirectoryResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Path            types.String `tfsdk:"path"`
+	Template        types.String `tfsdk:"template"`
+	RemoveOnDestroy types.Bool   `tfsdk:"remove_on_destroy"`
+}
+
+// NewDirectoryResource creates a new instance.
+func NewDirectoryResource() resource.Resource {
+	return &DirectoryResource{}
+}
+
+func (r *DirectoryResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_directory"
+}
+
+func (r *DirectoryResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a folder of the store, e.g. to pre-create team namespaces before they hold secrets.",
+		MarkdownDescription: `
+Manages a folder of the store, e.g. to pre-create team namespaces with a consistent structure
+before they hold secrets.
+
+gopass has no empty folders: a folder exists as long as it holds a secret. This resource keeps
+the folder in place with an empty ` + "`.gitkeep`" + ` placeholder, or with the template for new
+secrets in the folder if ` + "`template`" + ` is set, and commits it if the store is a git repository.
+
+On destroy, only the placeholder or template is removed and the secrets in the folder are kept,
+unless ` + "`remove_on_destroy`" + ` is set.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_directory" "team" {
+  for_each = toset(["payments", "search"])
+
+  path     = "teams/${each.key}"
+  template = <<-EOT
+    {{ .Content }}
+    owner: ${each.key}
+  EOT
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The folder (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Folder in the gopass store (e.g., 'teams/payments').",
+				MarkdownDescription: "Folder in the gopass store (e.g., `teams/payments`).",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"template": schema.StringAttribute{
+				Description: "Template for new secrets in the folder, using the gopass template syntax. " +
+					"If set, the template keeps the folder in place instead of a .gitkeep placeholder. " +
+					"Templates are stored unencrypted.",
+				MarkdownDescription: "Template for new secrets in the folder, using the gopass template syntax. " +
+					"If set, the template keeps the folder in place instead of a `.gitkeep` placeholder. " +
+					"Templates are stored **unencrypted**. Do not also manage it with `gopass_template`.",
+				Optional: true,
+			},
+			"remove_on_destroy": schema.BoolAttribute{
+				Description: "Whether to remove the folder with all secrets below it when the resource is destroyed " +
+					"(like 'gopass rm --recursive'). Defaults to false, which only removes the placeholder or template.",
+				MarkdownDescription: "Whether to remove the folder with **all secrets below it** when the resource is destroyed " +
+					"(like `gopass rm --recursive`). Defaults to `false`, which only removes the placeholder or template.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (r *DirectoryResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+// writePlaceholder keeps the folder in place with the template, if configured, or the
+// .gitkeep placeholder, and removes the other one if the folder had it before.
+func (r *DirectoryResource) writePlaceholder(ctx context.Context, data *DirectoryResourceModel) error {
+	dir := data.Path.ValueString()
+
+	if data.Template.IsNull() {
+		if err := r.client.CreateDirectoryPlaceholder(ctx, dir); err != nil {
+			return err
+		}
+		return r.client.RemoveTemplate(ctx, dir)
+	}
+
+	if err := r.client.SetTemplate(ctx, dir, data.Template.ValueString()); err != nil {
+		return err
+	}
+	return r.client.RemoveDirectoryPlaceholder(ctx, dir)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *DirectoryResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data DirectoryResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	if err := r.writePlaceholder(ctx, &data); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to create folder",
+			errorDetail(err, fmt.Sprintf("Could not create folder %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Created gopass folder", map[string]interface{}{
+		"path": dir,
+	})
+
+	data.ID = data.Path
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *DirectoryResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data DirectoryResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	content, hasTemplate, err := r.client.GetTemplate(ctx, dir)
+	found := hasTemplate
+	if err == nil && !hasTemplate {
+		found, err = r.client.HasDirectoryPlaceholder(ctx, dir)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read folder",
+			errorDetail(err, fmt.Sprintf("Could not read folder %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	if !found {
+		// The placeholder was removed outside of Terraform, so the folder may be gone with its last secret
+		tflog.Warn(ctx, "Folder placeholder no longer exists, removing from state", map[string]interface{}{
+			"path": dir,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Templates are not secret, so drift is detected by comparing the content
+	data.ID = data.Path
+	data.Template = types.StringNull()
+	if hasTemplate {
+		data.Template = types.StringValue(content)
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *DirectoryResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data DirectoryResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	if err := r.writePlaceholder(ctx, &data); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update folder",
+			errorDetail(err, fmt.Sprintf("Could not update folder %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Updated gopass folder", map[string]interface{}{
+		"path": dir,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *DirectoryResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data DirectoryResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+	removeAll := data.RemoveOnDestroy.ValueBool()
+
+	var err error
+	if removeAll {
+		err = r.client.RemoveDirectory(ctx, dir)
+	}
+	// The store may keep files it does not know as secrets, so the placeholders are removed in any case
+	if err == nil {
+		err = r.client.RemoveTemplate(ctx, dir)
+	}
+	if err == nil {
+		err = r.client.RemoveDirectoryPlaceholder(ctx, dir)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove folder",
+			errorDetail(err, fmt.Sprintf("Could not remove folder %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass folder", map[string]interface{}{
+		"path":              dir,
+		"remove_on_destroy": removeAll,
+	})
+}
+
+// ImportState imports a folder kept in place by a placeholder or a template.
+func (r *DirectoryResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("remove_on_destroy"), false)...)
+}
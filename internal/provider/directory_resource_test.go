@@ -0,0 +1,330 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// directoryValue builds a gopass_directory object for the folder teams/payments.
+func directoryValue(schemaResp resource.SchemaResponse, template any, removeOnDestroy bool) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":                tftypes.NewValue(tftypes.String, "teams/payments"),
+		"path":              tftypes.NewValue(tftypes.String, "teams/payments"),
+		"template":          tftypes.NewValue(tftypes.String, template),
+		"remove_on_destroy": tftypes.NewValue(tftypes.Bool, removeOnDestroy),
+	})
+}
+
+// storeFileExists reports whether the store-relative file name exists below dir.
+func storeFileExists(dir, name string) bool {
+	_, err := os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+func TestDirectoryResource_Metadata(t *testing.T) {
+	r := NewDirectoryResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_directory" {
+		t.Errorf("expected 'gopass_directory', got %q", resp.TypeName)
+	}
+}
+
+func TestDirectoryResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &DirectoryResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &DirectoryResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestDirectoryResource_Create(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     any
+		wantFile     string
+		wantNotFile  string
+		existingFile string
+	}{
+		{name: "placeholder", wantFile: "teams/payments/.gitkeep", wantNotFile: "teams/payments/.pass-template"},
+		{name: "template", template: "user:\n", wantFile: "teams/payments/.pass-template", wantNotFile: "teams/payments/.gitkeep"},
+		{
+			name: "placeholder replaces template", existingFile: "teams/payments/.pass-template",
+			wantFile: "teams/payments/.gitkeep", wantNotFile: "teams/payments/.pass-template",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.existingFile != "" {
+				writeStoreFile(t, dir, tc.existingFile, "x")
+			}
+			r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(dir)})
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{
+				Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: directoryValue(schemaResp, tc.template, false)},
+			}, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if !storeFileExists(dir, tc.wantFile) || storeFileExists(dir, tc.wantNotFile) {
+				t.Errorf("expected %s and no %s", tc.wantFile, tc.wantNotFile)
+			}
+			var state DirectoryResourceModel
+			resp.State.Get(context.Background(), &state)
+			if state.ID.ValueString() != "teams/payments" {
+				t.Errorf("expected id 'teams/payments', got %q", state.ID.ValueString())
+			}
+		})
+	}
+}
+
+func TestDirectoryResource_Create_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template any
+		blocked  string
+		broken   bool
+		invalid  bool
+	}{
+		{name: "placeholder fails", broken: true},
+		{name: "template fails", template: "user:\n", broken: true},
+		// A non-empty directory in place of the file to remove makes the removal fail
+		{name: "removing template fails", blocked: "teams/payments/.pass-template/file"},
+		{name: "removing placeholder fails", template: "user:\n", blocked: "teams/payments/.gitkeep/file"},
+		{name: "invalid plan", invalid: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.blocked != "" {
+				writeStoreFile(t, dir, tc.blocked, "x")
+			}
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(dir)})
+			plan := directoryValue(schemaResp, tc.template, false)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+			if !resp.Diagnostics.HasError() {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestDirectoryResource_Read(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		content      string
+		broken       bool
+		wantTemplate any
+		wantRemoved  bool
+		wantErr      bool
+	}{
+		{name: "placeholder", file: "teams/payments/.gitkeep"},
+		{name: "template", file: "teams/payments/.pass-template", content: "user:\n", wantTemplate: "user:\n"},
+		{name: "empty template", file: "teams/payments/.pass-template", wantTemplate: ""},
+		{name: "removed externally", wantRemoved: true},
+		{name: "read fails", broken: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.file != "" {
+				writeStoreFile(t, dir, tc.file, tc.content)
+			}
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(dir)})
+			raw := directoryValue(schemaResp, "user:\n", false)
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.wantRemoved {
+				t.Fatalf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !tc.wantRemoved {
+				var state DirectoryResourceModel
+				resp.State.Get(context.Background(), &state)
+				if tc.wantTemplate == nil && !state.Template.IsNull() {
+					t.Errorf("expected no template, got %q", state.Template.ValueString())
+				}
+				if tc.wantTemplate != nil && state.Template.ValueString() != tc.wantTemplate {
+					t.Errorf("expected template %q, got %v", tc.wantTemplate, state.Template)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(t.TempDir())})
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestDirectoryResource_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		broken  bool
+		invalid bool
+		wantErr bool
+	}{
+		{name: "placeholder to template"},
+		{name: "write fails", broken: true, wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeStoreFile(t, dir, "teams/payments/.gitkeep", "")
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(dir)})
+			plan := directoryValue(schemaResp, "user:\n", false)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: directoryValue(schemaResp, nil, false)},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr {
+				data, _ := os.ReadFile(filepath.Join(dir, "teams", "payments", ".pass-template"))
+				if string(data) != "user:\n" || storeFileExists(dir, "teams/payments/.gitkeep") {
+					t.Errorf("expected template to replace the placeholder, got template %q", data)
+				}
+			}
+		})
+	}
+}
+
+func TestDirectoryResource_Delete(t *testing.T) {
+	tests := []struct {
+		name            string
+		removeOnDestroy bool
+		failRemoveAll   bool
+		broken          bool
+		invalid         bool
+		wantSecrets     int
+		wantErr         bool
+	}{
+		{name: "keeps secrets", wantSecrets: 2},
+		{name: "removes subtree", removeOnDestroy: true, wantSecrets: 1},
+		{name: "remove subtree fails", removeOnDestroy: true, failRemoveAll: true, wantErr: true},
+		{name: "remove fails", broken: true, wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeStoreFile(t, dir, "teams/payments/.gitkeep", "")
+			writeStoreFile(t, dir, "teams/payments/.pass-template", "user:\n")
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(dir)})
+			store := storeWith(map[string]string{"teams/payments/db": "a", "teams/search/db": "b"})
+			store.shouldFail = tc.failRemoveAll
+			r.client.store = store
+			state := directoryValue(schemaResp, nil, tc.removeOnDestroy)
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if storeFileExists(dir, "teams/payments/.gitkeep") || storeFileExists(dir, "teams/payments/.pass-template") {
+				t.Error("expected placeholder and template to be removed")
+			}
+			if len(store.secrets) != tc.wantSecrets {
+				t.Errorf("expected %d secrets to remain, got %v", tc.wantSecrets, store.secrets)
+			}
+		})
+	}
+}
+
+func TestDirectoryResource_ImportState(t *testing.T) {
+	r, schemaResp := testResource(&DirectoryResource{client: NewGopassClient(t.TempDir())})
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "teams/payments"}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	var state DirectoryResourceModel
+	resp.State.Get(context.Background(), &state)
+	if state.Path.ValueString() != "teams/payments" || state.ID.ValueString() != "teams/payments" || state.RemoveOnDestroy.ValueBool() {
+		t.Errorf("unexpected imported state %+v", state)
+	}
+}
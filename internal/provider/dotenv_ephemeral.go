@@ -0,0 +1,342 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ ephemeral.EphemeralResource                   = &DotenvEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure      = &DotenvEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithValidateConfig = &DotenvEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose          = &DotenvEphemeralResource{}
+)
+
+// defaultDotenvSeparator joins the segments of nested paths into a dotenv key.
+const defaultDotenvSeparator = "_"
+
+var (
+	// dotenvKey matches the keys dotenv loaders accept.
+	dotenvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	// shellVariable matches the names a shell accepts in an export statement.
+	shellVariable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+var (
+	// dotenvEscapes escapes the characters that end or break a double-quoted dotenv value, and
+	// $, which loaders such as docker compose and python-dotenv expand in one.
+	dotenvEscapes = map[byte]string{'\\': `\\`, '"': `\"`, '\n': `\n`, '\r': `\r`, '$': `\$`}
+	// shellEscapes escapes a value for single quotes, in which a shell expands nothing: a single
+	// quote ends the quoted string, is added escaped, and a new quoted string starts.
+	shellEscapes = map[byte]string{'\'': `'\''`}
+)
+
+// DotenvEphemeralResource renders a subtree of the store as a dotenv document.
+type DotenvEphemeralResource struct {
+	client *GopassClient
+}
+
+// DotenvModel describes the data model.
+type DotenvModel struct {
+	Path             types.String `tfsdk:"path"`
+	MaxDepth         types.Int64  `tfsdk:"max_depth"`
+	Include          types.List   `tfsdk:"include"`
+	Exclude          types.List   `tfsdk:"exclude"`
+	UppercaseKeys    types.Bool   `tfsdk:"uppercase_keys"`
+	KeyPrefix        types.String `tfsdk:"key_prefix"`
+	FlattenSeparator types.String `tfsdk:"flatten_separator"`
+	Export           types.Bool   `tfsdk:"export"`
+	FailOnError      types.Bool   `tfsdk:"fail_on_error"`
+	Timeouts         types.Object `tfsdk:"timeouts"`
+	Content          types.String `tfsdk:"content"`
+	Keys             types.List   `tfsdk:"keys"`
+}
+
+// NewDotenvEphemeralResource creates a new instance.
+func NewDotenvEphemeralResource() ephemeral.EphemeralResource {
+	return &DotenvEphemeralResource{}
+}
+
+func (r *DotenvEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_dotenv"
+}
+
+func (r *DotenvEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Renders all secrets under a path as a dotenv document of KEY=\"value\" lines.",
+		MarkdownDescription: `
+Renders all secrets under a path as a dotenv document of ` + "`KEY=\"value\"`" + ` lines, for resources
+and provisioners that expect a single blob, e.g. a ` + "`.env`" + ` file for a container or a
+` + "`local-exec`" + ` provisioner.
+
+Secrets are selected and named like with the ` + "`gopass_env`" + ` ephemeral resource, except that nested
+paths are always flattened, by default with ` + "`_`" + `: ` + "`API/v2/KEY`" + ` becomes ` + "`API_v2_KEY`" + `.
+Lines are sorted by key. Values are double-quoted, with backslashes, double quotes, line breaks and
+dollar signs escaped as ` + "`\\\\`" + `, ` + "`\\\"`" + `, ` + "`\\n`" + `, ` + "`\\r`" + ` and ` + "`\\$`" + `. With ` + "`export`" + `, values are
+single-quoted instead, with ` + "`'`" + ` written as ` + "`'\\''`" + `, so a shell sourcing the document takes
+them literally and expands no ` + "`$`" + ` or backticks in them; line breaks are kept as they are.
+
+## Example Usage
+
+` + "```hcl" + `
+ephemeral "gopass_dotenv" "app" {
+  path           = "env/app/production"
+  uppercase_keys = true
+}
+
+resource "terraform_data" "deploy" {
+  provisioner "local-exec" {
+    command = "./deploy.sh"
+    environment = {
+      APP_DOTENV = ephemeral.gopass_dotenv.app.content
+    }
+  }
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description:         "Path prefix in the gopass store (e.g., 'env/app/production').",
+				MarkdownDescription: "Path prefix in the gopass store (e.g., `env/app/production`).",
+				Required:            true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"max_depth": schema.Int64Attribute{
+				Description: "Maximum number of levels below path to read, e.g. 1 for the immediate children only. " +
+					"Defaults to no limit.",
+				MarkdownDescription: "Maximum number of levels below `path` to read, e.g. `1` for the immediate children only. " +
+					"Defaults to no limit.",
+				Optional: true,
+			},
+			"include": schema.ListAttribute{
+				Description: "Glob patterns selecting which secrets to render, matched against the key relative to path. " +
+					"'*' matches within a path segment, '**' matches any number of segments. Defaults to all secrets.",
+				MarkdownDescription: "Glob patterns selecting which secrets to render, matched against the key relative to `path`. " +
+					"`*` matches within a path segment, `**` matches any number of segments. Defaults to all secrets.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"exclude": schema.ListAttribute{
+				Description:         "Glob patterns of secrets to leave out. Applied after include.",
+				MarkdownDescription: "Glob patterns of secrets to leave out. Applied after `include`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"uppercase_keys": schema.BoolAttribute{
+				Description:         "Convert all keys to upper case.",
+				MarkdownDescription: "Convert all keys to upper case.",
+				Optional:            true,
+			},
+			"key_prefix": schema.StringAttribute{
+				Description:         "Prefix prepended to every key, e.g. 'APP_'.",
+				MarkdownDescription: "Prefix prepended to every key, e.g. `APP_`.",
+				Optional:            true,
+			},
+			"flatten_separator": schema.StringAttribute{
+				Description:         "Separator joining the segments of nested paths into a key. Defaults to '_'.",
+				MarkdownDescription: "Separator joining the segments of nested paths into a key. Defaults to `_`.",
+				Optional:            true,
+			},
+			"export": schema.BoolAttribute{
+				Description: "Prefix every line with 'export ' and single-quote the values, so the document can be " +
+					"sourced by a shell. Keys must then be valid shell variable names. Defaults to false.",
+				MarkdownDescription: "Prefix every line with `export ` and single-quote the values, so the document can be " +
+					"sourced by a shell. Keys must then be valid shell variable names. Defaults to `false`.",
+				Optional: true,
+			},
+			"fail_on_error": schema.BoolAttribute{
+				Description: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to false.",
+				MarkdownDescription: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to `false`.",
+				Optional: true,
+			},
+			"timeouts": ephemeralTimeoutsAttribute(),
+			"content": schema.StringAttribute{
+				Description:         "The dotenv document, one KEY=\"value\" line per secret, sorted by key; export KEY='value' with export.",
+				MarkdownDescription: "The dotenv document, one `KEY=\"value\"` line per secret, sorted by key; `export KEY='value'` with `export`.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"keys": schema.ListAttribute{
+				Description:         "The keys in the document, sorted. Keys are not secret, so they can be used e.g. in outputs.",
+				MarkdownDescription: "The keys in the document, sorted. Keys are not secret, so they can be used e.g. in outputs.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *DotenvEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+func (r *DotenvEphemeralResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data DotenvModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePrefixConfig(&resp.Diagnostics, data.MaxDepth, data.FlattenSeparator, "dotenv keys")
+}
+
+func (r *DotenvEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data DotenvModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	opts := envKeyOptions{
+		uppercase:        data.UppercaseKeys.ValueBool(),
+		keyPrefix:        data.KeyPrefix.ValueString(),
+		flattenSeparator: defaultDotenvSeparator,
+	}
+	if !data.FlattenSeparator.IsNull() {
+		opts.flattenSeparator = data.FlattenSeparator.ValueString()
+	}
+	values, ok := readFlatPrefix(ctx, r.client, &resp.Diagnostics, prefixRead{
+		path:        basePath,
+		maxDepth:    int(data.MaxDepth.ValueInt64()),
+		timeouts:    data.Timeouts,
+		include:     data.Include,
+		exclude:     data.Exclude,
+		keys:        opts,
+		failOnError: data.FailOnError.ValueBool(),
+		leftOutOf:   "content",
+	})
+	if !ok {
+		return
+	}
+	defer wipeValues(values)
+
+	content, keys, err := formatDotenv(values, data.Export.ValueBool())
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid dotenv keys",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not render secrets under path %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	data.Content = types.StringValue(content)
+	keyList, diags := types.ListValueFrom(ctx, types.StringType, keys)
+	resp.Diagnostics.Append(diags...)
+	data.Keys = keyList
+
+	// Set result - NEVER written to state
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+
+	openCloseState(ctx, r.client, resp, &closeState{Prefixes: []string{basePath}})
+
+	tflog.Debug(ctx, "Successfully rendered dotenv secrets from gopass", map[string]interface{}{
+		"path":  basePath,
+		"count": len(keys),
+	})
+}
+
+// Close is called once Terraform no longer needs the document. A caching client still holds
+// the decrypted secrets it was rendered from, which are dropped.
+func (r *DotenvEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	ctx = r.client.logContext(ctx)
+
+	closeSecrets(ctx, r.client, req, resp)
+
+	tflog.Debug(ctx, "Closed ephemeral gopass dotenv")
+}
+
+// formatDotenv renders values as dotenv lines sorted by key, and returns the keys in that
+// order. With export, every line starts with "export " and values are single-quoted, as a
+// shell would expand $ and backticks in a double-quoted value and run what they contain. It
+// fails on keys a dotenv loader or, with export, a shell would not accept, naming them but not
+// their values. The document is built in a buffer that is wiped, so only the returned string
+// holds the values.
+func formatDotenv(values map[string][]byte, export bool) (string, []string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	valid, kind := dotenvKey, "dotenv keys"
+	if export {
+		valid, kind = shellVariable, "shell variable names"
+	}
+
+	var invalid []string
+	var b []byte
+	defer func() { wipe(b) }()
+	for _, key := range keys {
+		if !valid.MatchString(key) {
+			invalid = append(invalid, fmt.Sprintf("%q", key))
+			continue
+		}
+		if export {
+			b = appendWiped(b, "export "+key+"='")
+			b = appendEscaped(b, values[key], shellEscapes)
+			b = appendWiped(b, "'\n")
+			continue
+		}
+		b = appendWiped(b, key+"=\"")
+		b = appendEscaped(b, values[key], dotenvEscapes)
+		b = appendWiped(b, "\"\n")
+	}
+	if len(invalid) > 0 {
+		return "", nil, fmt.Errorf("%s are not valid %s; rename the secrets, or leave them out with exclude", strings.Join(invalid, ", "), kind)
+	}
+	return string(b), keys, nil
+}
+
+// appendEscaped appends value to b like appendWiped, with the bytes in escapes replaced.
+func appendEscaped(b, value []byte, escapes map[byte]string) []byte {
+	for i, c := range value {
+		if escaped, ok := escapes[c]; ok {
+			b = appendWiped(b, escaped)
+			continue
+		}
+		b = appendWiped(b, value[i:i+1])
+	}
+	return b
+}
@@ -0,0 +1,444 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// openDotenv opens a gopass_dotenv with the given configuration and returns the response and result.
+func openDotenv(t *testing.T, r *DotenvEphemeralResource, values map[string]tftypes.Value) (*ephemeral.OpenResponse, DotenvModel) {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	resp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, values)},
+	}, resp)
+
+	var result DotenvModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.Result.Get(ctx, &result)...)
+	}
+	return resp, result
+}
+
+// dotenvWrongConfig returns a configuration whose path does not match the schema, to fail Config.Get.
+func dotenvWrongConfig() tftypes.Value {
+	return tftypes.NewValue(tftypes.Object{
+		AttributeTypes: map[string]tftypes.Type{"path": tftypes.Number},
+	}, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.Number, 123),
+	})
+}
+
+func TestDotenvEphemeralResource_Metadata(t *testing.T) {
+	resp := &ephemeral.MetadataResponse{}
+	NewDotenvEphemeralResource().Metadata(context.Background(), ephemeral.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_dotenv" {
+		t.Errorf("expected TypeName 'gopass_dotenv', got %q", resp.TypeName)
+	}
+}
+
+func TestDotenvEphemeralResource_Schema(t *testing.T) {
+	resp := &ephemeral.SchemaResponse{}
+	NewDotenvEphemeralResource().Schema(context.Background(), ephemeral.SchemaRequest{}, resp)
+
+	if content := resp.Schema.Attributes["content"]; !content.IsSensitive() || !content.IsComputed() {
+		t.Error("expected content to be computed and sensitive")
+	}
+	if keys := resp.Schema.Attributes["keys"]; keys.IsSensitive() {
+		t.Error("expected keys not to be sensitive")
+	}
+}
+
+func TestDotenvEphemeralResource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name    string
+		data    any
+		wantErr bool
+	}{
+		{name: "client", data: client},
+		{name: "nil", data: nil},
+		{name: "wrong type", data: "client", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &DotenvEphemeralResource{}
+			resp := &ephemeral.ConfigureResponse{}
+			r.Configure(context.Background(), ephemeral.ConfigureRequest{ProviderData: tc.data}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr && tc.data != nil && r.client != client {
+				t.Error("expected client to be set")
+			}
+		})
+	}
+}
+
+func TestDotenvEphemeralResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]tftypes.Value
+		wrongConfig bool
+		wantErr     string
+	}{
+		{name: "path only", config: map[string]tftypes.Value{}},
+		{
+			name:   "all options",
+			config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 2), "flatten_separator": tftypes.NewValue(tftypes.String, "__")},
+		},
+		{
+			name:   "unknown options",
+			config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue), "flatten_separator": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)},
+		},
+		{name: "max_depth zero", config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 0)}, wantErr: "Invalid max_depth"},
+		{name: "empty separator", config: map[string]tftypes.Value{"flatten_separator": tftypes.NewValue(tftypes.String, "")}, wantErr: "Invalid flatten_separator"},
+		{name: "invalid config", wrongConfig: true, wantErr: "Value Conversion Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &DotenvEphemeralResource{}
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+
+			raw := dotenvWrongConfig()
+			if !tc.wrongConfig {
+				config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/app")}
+				for k, v := range tc.config {
+					config[k] = v
+				}
+				raw = schemaObjectValue(schemaResp.Schema, config)
+			}
+
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestDotenvEphemeralResource_Open(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]tftypes.Value
+		wantContent string
+		wantKeys    []string
+	}{
+		{
+			name:        "nested paths flattened",
+			config:      map[string]tftypes.Value{},
+			wantContent: "API_v2_KEY=\"ak\"\nREGION=\"eu\"\nnote=\"line one\\nsays \\\"hi\\\" C:\\\\tmp\"\n",
+			wantKeys:    []string{"API_v2_KEY", "REGION", "note"},
+		},
+		{
+			name: "key options",
+			config: map[string]tftypes.Value{
+				"include":           tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "API/**"), tftypes.NewValue(tftypes.String, "REGION")}),
+				"exclude":           tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "REGION")}),
+				"uppercase_keys":    tftypes.NewValue(tftypes.Bool, true),
+				"key_prefix":        tftypes.NewValue(tftypes.String, "APP_"),
+				"flatten_separator": tftypes.NewValue(tftypes.String, "__"),
+			},
+			wantContent: "APP_API__V2__KEY=\"ak\"\n",
+			wantKeys:    []string{"APP_API__V2__KEY"},
+		},
+		{
+			name: "export",
+			config: map[string]tftypes.Value{
+				"max_depth": tftypes.NewValue(tftypes.Number, 1),
+				"export":    tftypes.NewValue(tftypes.Bool, true),
+			},
+			wantContent: "export REGION='eu'\nexport note='line one\nsays \"hi\" C:\\tmp'\n",
+			wantKeys:    []string{"REGION", "note"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			store.secrets["env/app/API/v2/KEY"] = newMockSecret("ak")
+			store.secrets["env/app/REGION"] = newMockSecret("eu")
+			store.secrets["env/app/note"] = newMockSecret("line one\nsays \"hi\" C:\\tmp")
+			client := NewGopassClient("")
+			client.store = store
+			r := &DotenvEphemeralResource{client: client}
+
+			config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/app")}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			resp, result := openDotenv(t, r, config)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := result.Content.ValueString(); got != tc.wantContent {
+				t.Errorf("expected content %q, got %q", tc.wantContent, got)
+			}
+			var keys []string
+			result.Keys.ElementsAs(context.Background(), &keys, false)
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tc.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestDotenvEphemeralResource_Open_Warnings(t *testing.T) {
+	t.Run("no secrets", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = newMockStore()
+		r := &DotenvEphemeralResource{client: client}
+
+		resp, result := openDotenv(t, r, map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/empty")})
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("expected a single warning, got %v", resp.Diagnostics)
+		}
+		if result.Content.ValueString() != "" || len(result.Keys.Elements()) != 0 {
+			t.Errorf("expected empty document, got %q and %v", result.Content.ValueString(), result.Keys)
+		}
+	})
+
+	t.Run("unreadable secret left out", func(t *testing.T) {
+		store := newMockStoreWithSelectiveFailure()
+		store.secrets["env/app/A"] = newMockSecret("a")
+		store.secrets["env/app/B"] = newMockSecret("b")
+		store.failOnGet["env/app/B"] = true
+		client := NewGopassClient("")
+		client.store = store
+		r := &DotenvEphemeralResource{client: client}
+
+		resp, result := openDotenv(t, r, map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/app")})
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("expected a single warning, got %v", resp.Diagnostics)
+		}
+		if got := result.Content.ValueString(); got != "A=\"a\"\n" {
+			t.Errorf("expected only A, got %q", got)
+		}
+	})
+}
+
+func TestDotenvEphemeralResource_Open_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		store       func() gopass.Store
+		config      map[string]tftypes.Value
+		openTimeout string
+		wrongConfig bool
+		wantErr     string
+	}{
+		{
+			name: "fail on error",
+			store: func() gopass.Store {
+				store := newMockStoreWithSelectiveFailure()
+				store.secrets["env/app/A"] = newMockSecret("a")
+				store.failOnGet["env/app/A"] = true
+				return store
+			},
+			config:  map[string]tftypes.Value{"fail_on_error": tftypes.NewValue(tftypes.Bool, true)},
+			wantErr: "Failed to read secrets",
+		},
+		{
+			name: "store fails",
+			store: func() gopass.Store {
+				return &mockStore{shouldFail: true, failMsg: "permission denied"}
+			},
+			wantErr: "Failed to read secrets",
+		},
+		{
+			name:        "invalid timeouts",
+			openTimeout: "soon",
+			wantErr:     "Invalid timeouts",
+		},
+		{
+			name:    "unknown include",
+			config:  map[string]tftypes.Value{"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue)},
+			wantErr: "Value Conversion Error",
+		},
+		{
+			name:    "invalid pattern",
+			config:  map[string]tftypes.Value{"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "[")})},
+			wantErr: "Invalid key options",
+		},
+		{
+			name:    "invalid key",
+			config:  map[string]tftypes.Value{"key_prefix": tftypes.NewValue(tftypes.String, "1")},
+			wantErr: "Invalid dotenv keys",
+		},
+		{
+			name:    "invalid shell variable",
+			config:  map[string]tftypes.Value{"export": tftypes.NewValue(tftypes.Bool, true)},
+			wantErr: "Invalid dotenv keys",
+		},
+		{
+			name:        "invalid config",
+			wrongConfig: true,
+			wantErr:     "Value Conversion Error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			if tc.store != nil {
+				client.store = tc.store()
+			} else {
+				store := newMockStore()
+				store.secrets["env/app/db-password"] = newMockSecret("secret")
+				client.store = store
+			}
+			r := &DotenvEphemeralResource{client: client}
+
+			config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/app")}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+
+			ctx := context.Background()
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+			if tc.openTimeout != "" {
+				config["timeouts"] = timeoutsRaw(schemaResp.Schema, timeoutOpen, tc.openTimeout)
+			}
+			raw := schemaObjectValue(schemaResp.Schema, config)
+			if tc.wrongConfig {
+				raw = dotenvWrongConfig()
+			}
+
+			resp := &ephemeral.OpenResponse{
+				Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.Open(ctx, ephemeral.OpenRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestDotenvEphemeralResource_Close(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"env/app/KEY": "s1", "env/app/api/TOKEN": "s2", "other/KEY": "s3"})
+	client.EnableCache()
+	if _, err := client.GetSecret(ctx, "other/KEY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &DotenvEphemeralResource{client: client}
+	openResp, _ := openDotenv(t, r, map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/app")})
+	if openResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", openResp.Diagnostics)
+	}
+	if n := len(client.cache.entries); n != 3 {
+		t.Fatalf("expected the rendered and the other secret to be cached, got %d entries", n)
+	}
+
+	resp := &ephemeral.CloseResponse{}
+	r.Close(ctx, ephemeral.CloseRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if _, _, ok := client.cache.get(secretCacheKey{path: "other/KEY", revision: "latest"}); !ok || len(client.cache.entries) != 1 {
+		t.Errorf("expected Close to release only the secrets below the path, got %d entries", len(client.cache.entries))
+	}
+}
+
+func TestFormatDotenv_NamesInvalidKeys(t *testing.T) {
+	_, _, err := formatDotenv(byteValues(map[string]string{"ok": "1", "has space": "2", "a=b": "3"}), false)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if want := `"a=b", "has space" are not valid dotenv keys`; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("expected error starting with %q, got %q", want, err.Error())
+	}
+	if strings.Contains(err.Error(), "2") || strings.Contains(err.Error(), "3") {
+		t.Errorf("expected values to be left out of the error, got %q", err.Error())
+	}
+}
+
+func TestFormatDotenv_EscapesDollar(t *testing.T) {
+	content, _, err := formatDotenv(byteValues(map[string]string{"PASSWORD": "pa$FOO${BAR}$$ss"}), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "PASSWORD=\"pa\\$FOO\\${BAR}\\$\\$ss\"\n"; content != want {
+		t.Errorf("expected content %q, got %q", want, content)
+	}
+}
+
+func TestFormatDotenv_ExportIsSourcedLiterally(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to source the document with")
+	}
+
+	values := map[string]string{
+		"SUBST":     "pa$(echo INJECTED)ss",
+		"BACKTICK":  "pa`echo INJECTED`ss",
+		"VAR":       "$HOME and ${HOME}",
+		"QUOTES":    `it's "quoted" \ 'twice'`,
+		"MULTILINE": "line one\nline two\\n",
+	}
+	content, keys, err := formatDotenv(byteValues(values), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Print every variable NUL-terminated, so values with line breaks are compared whole.
+	script := `. "$1"; shift; for k in "$@"; do eval "printf '%s\\0' \"\$$k\""; done`
+	out, err := exec.Command(sh, append([]string{"-c", script, "sh", file}, keys...)...).Output()
+	if err != nil {
+		t.Fatalf("sourcing the document failed: %v", err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if len(got) != len(keys) {
+		t.Fatalf("expected %d values, got %q", len(keys), got)
+	}
+	for i, key := range keys {
+		if got[i] != values[key] {
+			t.Errorf("expected %s to be %q, got %q", key, values[key], got[i])
+		}
+	}
+}
@@ -6,17 +6,26 @@ package provider
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-// Ensure implementation satisfies interface.
-var _ ephemeral.EphemeralResource = &EnvEphemeralResource{}
+// Ensure implementation satisfies interfaces.
+var (
+	_ ephemeral.EphemeralResource          = &EnvEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithRenew = &EnvEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose = &EnvEphemeralResource{}
+
+	_ ephemeral.EphemeralResourceWithValidateConfig = &EnvEphemeralResource{}
+)
 
 // EnvEphemeralResource reads a subtree from gopass as environment variables.
 type EnvEphemeralResource struct {
@@ -25,8 +34,54 @@ type EnvEphemeralResource struct {
 
 // EnvModel describes the data model.
 type EnvModel struct {
-	Path        types.String  `tfsdk:"path"`
-	Credentials types.Dynamic `tfsdk:"credentials"`
+	Path             types.String  `tfsdk:"path"`
+	Paths            types.List    `tfsdk:"paths"`
+	Conflict         types.String  `tfsdk:"conflict"`
+	Store            types.String  `tfsdk:"store"`
+	Snapshot         types.String  `tfsdk:"snapshot"`
+	MaxDepth         types.Int64   `tfsdk:"max_depth"`
+	Include          types.List    `tfsdk:"include"`
+	Exclude          types.List    `tfsdk:"exclude"`
+	UppercaseKeys    types.Bool    `tfsdk:"uppercase_keys"`
+	KeyPrefix        types.String  `tfsdk:"key_prefix"`
+	FlattenSeparator types.String  `tfsdk:"flatten_separator"`
+	ValuesSeparator  types.String  `tfsdk:"values_separator"`
+	RenewInterval    types.String  `tfsdk:"renew_interval"`
+	Timeouts         types.Object  `tfsdk:"timeouts"`
+	FailOnError      types.Bool    `tfsdk:"fail_on_error"`
+	Credentials      types.Dynamic `tfsdk:"credentials"`
+	ValuesFlat       types.Map     `tfsdk:"values_flat"`
+	Values           types.Map     `tfsdk:"values"`
+	Errors           types.Map     `tfsdk:"errors"`
+	Keys             types.List    `tfsdk:"keys"`
+}
+
+// envKeyOptions controls which secrets gopass_env returns and how their keys are named.
+type envKeyOptions struct {
+	include          []string
+	exclude          []string
+	uppercase        bool
+	keyPrefix        string
+	flattenSeparator string
+}
+
+// Separators joining the folders of a key in the values attribute of gopass_env.
+const (
+	valuesSeparatorDot        = "."
+	valuesSeparatorUnderscore = "__"
+)
+
+// Policies of gopass_env for a key present under several of its paths.
+const (
+	envConflictError = "error"
+	envConflictFirst = "first"
+	envConflictLast  = "last"
+)
+
+// envCollision is a relative key present under several merged paths, in the order of paths.
+type envCollision struct {
+	Key   string
+	Paths []string
 }
 
 // NewEnvEphemeralResource creates a new instance.
@@ -91,27 +146,205 @@ provider "aws" {
 }
 ` + "```" + `
 
+**Filtering and key transformation (` + "`TF_VAR_`" + `-style names):**
+
+` + "```hcl" + `
+ephemeral "gopass_env" "tf_vars" {
+  path              = "env/terraform/aws"
+  include           = ["API/**", "REGION"]
+  exclude           = ["**/LEGACY_*"]
+  flatten_separator = "_"
+  uppercase_keys    = true
+  key_prefix        = "TF_VAR_"
+}
+
+# API/v2/ACCESS_KEY becomes credentials.TF_VAR_API_V2_ACCESS_KEY
+` + "```" + `
+
+**Shared base plus overrides (later paths win):**
+
+` + "```hcl" + `
+ephemeral "gopass_env" "app1" {
+  paths    = ["env/common", "env/app1"]
+  conflict = "last"
+}
+
+# DATABASE_URL from env/app1 if it exists there, otherwise from env/common
+` + "```" + `
+
+**Historical snapshot (git tag):**
+
+` + "```hcl" + `
+ephemeral "gopass_env" "aws_release" {
+  path     = "env/terraform/aws"
+  snapshot = "release-2024-06"
+}
+` + "```" + `
+
 ## Notes
 
-- **Recursive**: All secrets under the path are included, regardless of depth
+- **Recursive**: All secrets under the path are included, regardless of depth, unless
+  ` + "`max_depth`" + ` limits it (` + "`1`" + ` reads the immediate children only)
 - Each secret's first line is used as the value (gopass password convention)
 - Nested paths use dot-notation: ` + "`API/v2/KEY`" + ` becomes ` + "`credentials.API.v2.KEY`" + `
+- The type of ` + "`credentials`" + ` follows the secrets read; ` + "`values`" + ` is always a ` + "`map(string)`" + `,
+  keyed e.g. ` + "`API.v2.KEY`" + `, for configurations where secrets come and go between plan and apply
 - Supports mixed flat and nested structures in the same tree
 - No subprocess spawning - direct library access for better performance
+- ` + "`include`" + `/` + "`exclude`" + ` patterns match the slash-separated key relative to ` + "`path`" + `
+  (` + "`*`" + ` within a segment, ` + "`**`" + ` across segments) and are applied before key transformation
+- Key transformation order: ` + "`flatten_separator`" + `, then ` + "`uppercase_keys`" + `, then ` + "`key_prefix`" + `
+- With ` + "`paths`" + `, the trees are merged by relative key before filtering and key transformation;
+  ` + "`conflict`" + ` decides the value of a key present under several paths. If it is unset, the last
+  path wins with a warning listing the keys
+- ` + "`snapshot`" + ` enumerates the tree from the store's git history, so the whole environment
+  is read as it existed at that ref. Reading old values requires ` + "`mode = \"cli\"`" + `
+- Secrets that cannot be read, e.g. because they are not encrypted for an available key, are left
+  out with a warning and listed in ` + "`errors`" + `; set ` + "`fail_on_error = true`" + ` to fail instead
 `,
 
 		Attributes: map[string]schema.Attribute{
 			"path": schema.StringAttribute{
-				Description:         "Path prefix in the gopass store (e.g., 'env/terraform/scaleway/acme').",
-				MarkdownDescription: "Path prefix in the gopass store (e.g., `env/terraform/scaleway/acme`).",
-				Required:            true,
+				Description:         "Path prefix in the gopass store (e.g., 'env/terraform/scaleway/acme'). Exactly one of path and paths is required.",
+				MarkdownDescription: "Path prefix in the gopass store (e.g., `env/terraform/scaleway/acme`). Exactly one of `path` and `paths` is required.",
+				Optional:            true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"paths": schema.ListAttribute{
+				Description: "Path prefixes whose secrets are merged into one environment. By default a key present under " +
+					"several paths takes the value of the last one, so shared values can come first and overrides last.",
+				MarkdownDescription: "Path prefixes whose secrets are merged into one environment. By default a key present under " +
+					"several paths takes the value of the last one, so shared values can come first and overrides last.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"conflict": schema.StringAttribute{
+				Description: "How a key present under several of paths is merged: 'last' takes the value of the last path, " +
+					"'first' the value of the first one, and 'error' fails listing the keys. If unset, the last path wins " +
+					"with a warning listing the keys.",
+				MarkdownDescription: "How a key present under several of `paths` is merged: `last` takes the value of the last path, " +
+					"`first` the value of the first one, and `error` fails listing the keys. If unset, the last path wins " +
+					"with a warning listing the keys.",
+				Optional: true,
+			},
+			"store": storeAttribute(),
+			"snapshot": schema.StringAttribute{
+				Description: "Git ref (tag, branch or commit) to read the whole tree from, enabling reproducible " +
+					"re-deploys of historical configurations. Requires a git-backed store and mode = \"cli\".",
+				MarkdownDescription: "Git ref (tag, branch or commit) to read the whole tree from, enabling reproducible " +
+					"re-deploys of historical configurations. Requires a git-backed store and `mode = \"cli\"`.",
+				Optional: true,
+				Validators: []validator.String{
+					validSnapshotRef(),
+				},
+			},
+			"max_depth": schema.Int64Attribute{
+				Description: "Maximum number of levels below path to read, e.g. 1 for the immediate children only. " +
+					"Deeper secrets are not decrypted. Defaults to no limit.",
+				MarkdownDescription: "Maximum number of levels below `path` to read, e.g. `1` for the immediate children only. " +
+					"Deeper secrets are not decrypted. Defaults to no limit.",
+				Optional: true,
+			},
+			"include": schema.ListAttribute{
+				Description: "Glob patterns selecting which secrets to return, matched against the key relative to path. " +
+					"'*' matches within a path segment, '**' matches any number of segments. Defaults to all secrets.",
+				MarkdownDescription: "Glob patterns selecting which secrets to return, matched against the key relative to `path`. " +
+					"`*` matches within a path segment, `**` matches any number of segments. Defaults to all secrets.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"exclude": schema.ListAttribute{
+				Description:         "Glob patterns of secrets to leave out. Applied after include.",
+				MarkdownDescription: "Glob patterns of secrets to leave out. Applied after `include`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
 			},
+			"uppercase_keys": schema.BoolAttribute{
+				Description:         "Convert all keys to upper case.",
+				MarkdownDescription: "Convert all keys to upper case.",
+				Optional:            true,
+			},
+			"key_prefix": schema.StringAttribute{
+				Description:         "Prefix prepended to every top-level key, e.g. 'TF_VAR_'.",
+				MarkdownDescription: "Prefix prepended to every top-level key, e.g. `TF_VAR_`.",
+				Optional:            true,
+			},
+			"flatten_separator": schema.StringAttribute{
+				Description: "If set, nested paths are flattened into a single key joined with this separator " +
+					"(e.g. '_' turns API/v2/KEY into API_v2_KEY) instead of nested objects.",
+				MarkdownDescription: "If set, nested paths are flattened into a single key joined with this separator " +
+					"(e.g. `_` turns `API/v2/KEY` into `API_v2_KEY`) instead of nested objects.",
+				Optional: true,
+			},
+			"values_separator": schema.StringAttribute{
+				Description:         "Separator joining the folders of a key in values: '.' (default) or '__'.",
+				MarkdownDescription: "Separator joining the folders of a key in `values`: `.` (default) or `__`.",
+				Optional:            true,
+			},
+			"renew_interval": schema.StringAttribute{
+				Description: "If set (e.g. '10m'), Terraform periodically re-reads the secrets during long operations " +
+					"and warns if any of them changed in the meantime. The values already handed out are not replaced.",
+				MarkdownDescription: "If set (e.g. `10m`), Terraform periodically re-reads the secrets during long operations " +
+					"and warns if any of them changed in the meantime. The values already handed out are not replaced.",
+				Optional: true,
+			},
+			"fail_on_error": schema.BoolAttribute{
+				Description: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to false.",
+				MarkdownDescription: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to `false`.",
+				Optional: true,
+			},
+			"timeouts": ephemeralTimeoutsAttribute(),
 			"credentials": schema.DynamicAttribute{
 				Description:         "Object with secret names as attributes (accessible via dot-notation).",
 				MarkdownDescription: "Object with secret names as attributes (accessible via dot-notation).",
 				Computed:            true,
 				Sensitive:           true,
 			},
+			"values_flat": schema.MapAttribute{
+				Description: "The same secrets as a flat map keyed by slash-joined path (e.g. 'API/v2/ACCESS_KEY'), " +
+					"convenient for for expressions.",
+				MarkdownDescription: "The same secrets as a flat `map(string)` keyed by slash-joined path " +
+					"(e.g. `API/v2/ACCESS_KEY`), convenient for `for` expressions.",
+				ElementType: types.StringType,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"values": schema.MapAttribute{
+				Description: "The same secrets as a flat map keyed by path joined with values_separator (e.g. 'API.v2.ACCESS_KEY'). " +
+					"Unlike credentials, its type stays map(string) when secrets are added or removed between plan and apply.",
+				MarkdownDescription: "The same secrets as a flat `map(string)` keyed by path joined with `values_separator` " +
+					"(e.g. `API.v2.ACCESS_KEY`). Unlike `credentials`, its type stays `map(string)` when secrets are added " +
+					"or removed between plan and apply.",
+				ElementType: types.StringType,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"keys": schema.ListAttribute{
+				Description: "The keys of values_flat, sorted. Keys are not secret, so they can be used e.g. in " +
+					"preconditions checking that all expected secrets were found, or in outputs.",
+				MarkdownDescription: "The keys of `values_flat`, sorted. Keys are not secret, so they can be used e.g. in " +
+					"preconditions checking that all expected secrets were found, or in outputs.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"errors": schema.MapAttribute{
+				Description: "Secrets that could not be read and were left out, keyed by full path, with the reason " +
+					"prefixed by its diagnostic code. Only secrets selected by include and exclude are listed.",
+				MarkdownDescription: "Secrets that could not be read and were left out, keyed by full path, with the reason " +
+					"prefixed by its diagnostic code. Only secrets selected by `include` and `exclude` are listed.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -125,7 +358,7 @@ func (r *EnvEphemeralResource) Configure(ctx context.Context, req ephemeral.Conf
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Provider Data",
-			fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData),
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
 		)
 		return
 	}
@@ -133,7 +366,96 @@ func (r *EnvEphemeralResource) Configure(ctx context.Context, req ephemeral.Conf
 	r.client = client
 }
 
+func (r *EnvEphemeralResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data EnvModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePrefixConfig(&resp.Diagnostics, data.MaxDepth, data.FlattenSeparator, "")
+
+	var prefixes []string
+	if !data.Path.IsNull() && !data.Path.IsUnknown() {
+		prefixes = append(prefixes, data.Path.ValueString())
+	}
+	for _, elem := range data.Paths.Elements() {
+		if p, ok := elem.(types.String); ok && !p.IsUnknown() {
+			prefixes = append(prefixes, p.ValueString())
+		}
+	}
+	validateStore(&resp.Diagnostics, data.Store, prefixes)
+
+	if sep := data.ValuesSeparator; !sep.IsNull() && !sep.IsUnknown() &&
+		sep.ValueString() != valuesSeparatorDot && sep.ValueString() != valuesSeparatorUnderscore {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("values_separator"),
+			"Invalid values_separator",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("values_separator must be %q or %q, got %q.",
+				valuesSeparatorDot, valuesSeparatorUnderscore, sep.ValueString())),
+		)
+	}
+
+	if c := data.Conflict; !c.IsNull() && !c.IsUnknown() && c.ValueString() != envConflictError &&
+		c.ValueString() != envConflictFirst && c.ValueString() != envConflictLast {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("conflict"),
+			"Invalid conflict",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("conflict must be %q, %q or %q, got %q.",
+				envConflictError, envConflictFirst, envConflictLast, c.ValueString())),
+		)
+	}
+
+	switch {
+	case !data.Path.IsNull() && !data.Paths.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("paths"),
+			"Conflicting path and paths",
+			codedDetail(CodeInvalidConfig, "Both path and paths are set. Use path for a single tree, or paths to merge several."),
+		)
+	case data.Path.IsNull() && data.Paths.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("path"),
+			"Missing path",
+			codedDetail(CodeInvalidConfig, "Set path to the tree to read, or paths to merge several trees."),
+		)
+	case !data.Paths.IsNull() && !data.Paths.IsUnknown():
+		if len(data.Paths.Elements()) == 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("paths"),
+				"Missing path",
+				codedDetail(CodeInvalidConfig, "paths must contain at least one path."),
+			)
+		}
+		seen := make(map[string]bool)
+		for i, elem := range data.Paths.Elements() {
+			p, ok := elem.(types.String)
+			if !ok || p.IsNull() || p.IsUnknown() {
+				continue
+			}
+			if err := validateSecretPath(p.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("paths").AtListIndex(i),
+					"Invalid secret path",
+					codedDetail(CodeInvalidConfig, fmt.Sprintf("The value %q is not a valid gopass path: %s.", p.ValueString(), err.Error())),
+				)
+			}
+			if seen[p.ValueString()] {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("paths").AtListIndex(i),
+					"Duplicate path",
+					codedDetail(CodeInvalidConfig, fmt.Sprintf("The path %q is listed more than once.", p.ValueString())),
+				)
+			}
+			seen[p.ValueString()] = true
+		}
+	}
+}
+
 func (r *EnvEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	ctx = r.client.logContext(ctx)
+
 	var data EnvModel
 
 	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
@@ -141,27 +463,128 @@ func (r *EnvEphemeralResource) Open(ctx context.Context, req ephemeral.OpenReque
 		return
 	}
 
-	basePath := data.Path.ValueString()
+	prefixes := []string{data.Path.ValueString()}
+	if !data.Paths.IsNull() {
+		prefixes = nil
+		resp.Diagnostics.Append(data.Paths.ElementsAs(ctx, &prefixes, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	for i, prefix := range prefixes {
+		mounted, err := r.client.MountedPath(ctx, data.Store.ValueString(), prefix)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("store"),
+				"Failed to read secrets",
+				errorDetail(err, fmt.Sprintf("Could not read secrets under path %q: %s", prefix, err.Error())),
+			)
+			return
+		}
+		prefixes[i] = mounted
+	}
+	basePath := strings.Join(prefixes, ", ")
+	snapshot := data.Snapshot.ValueString()
+	maxDepth := int(data.MaxDepth.ValueInt64())
+	conflict := data.Conflict.ValueString()
+
+	renewInterval, err := parseRenewInterval(data.RenewInterval)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid renew_interval",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not parse renew_interval: %s", err.Error())),
+		)
+		return
+	}
+
+	timeout, err := operationTimeout(data.Timeouts, timeoutOpen)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
 	tflog.Debug(ctx, "Reading env secrets from gopass", map[string]interface{}{
-		"path": basePath,
+		"paths":     prefixes,
+		"snapshot":  snapshot,
+		"max_depth": maxDepth,
 	})
 
 	// Use native gopass library (now returns recursive/nested paths)
-	values, err := r.client.GetEnvSecrets(ctx, basePath)
+	raw, failures, collisions, err := r.readEnv(ctx, prefixes, snapshot, maxDepth, conflict)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to read secrets",
-			fmt.Sprintf("Could not read secrets under path %q: %s", basePath, err.Error()),
+			errorDetail(err, fmt.Sprintf("Could not read secrets under path %q: %s", basePath, err.Error())),
 		)
 		return
 	}
 
-	if len(values) == 0 {
-		resp.Diagnostics.AddWarning(
-			"No secrets found",
-			fmt.Sprintf("No secrets found under path %q", basePath),
+	opts := envKeyOptions{
+		uppercase:        data.UppercaseKeys.ValueBool(),
+		keyPrefix:        data.KeyPrefix.ValueString(),
+		flattenSeparator: data.FlattenSeparator.ValueString(),
+	}
+	resp.Diagnostics.Append(data.Include.ElementsAs(ctx, &opts.include, false)...)
+	resp.Diagnostics.Append(data.Exclude.ElementsAs(ctx, &opts.exclude, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	separator := valuesSeparatorDot
+	if !data.ValuesSeparator.IsNull() {
+		separator = data.ValuesSeparator.ValueString()
+	}
+
+	values, err := shapeEnvValues(raw, opts)
+	var joined map[string]string
+	if err == nil {
+		joined, err = joinEnvKeys(values, separator)
+	}
+	if err == nil {
+		failures, err = selectEnvFailures(failures, opts)
+	}
+	if err == nil {
+		collisions, err = selectEnvCollisions(collisions, opts)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid key options",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not shape secrets under path %q: %s", basePath, err.Error())),
 		)
+		return
+	}
+
+	if len(collisions) > 0 {
+		detail := fmt.Sprintf("%d key(s) are present under several of the paths %q: %s.",
+			len(collisions), basePath, describeEnvCollisions(collisions))
+		switch {
+		case conflict == envConflictError:
+			resp.Diagnostics.AddAttributeError(
+				path.Root("paths"),
+				"Conflicting keys",
+				codedDetail(CodeInvalidConfig, detail+` Remove the duplicates, or set conflict = "first" or "last" to choose the value used.`),
+			)
+			return
+		case conflict == "":
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("paths"),
+				"Keys present under several paths",
+				codedDetail(CodeInvalidConfig, detail+` The value of the last path is used. Set conflict = "last" to confirm, `+
+					`"first" to use the value of the first path, or "error" to fail instead.`),
+			)
+		default:
+			tflog.Debug(ctx, "Merged keys present under several paths", map[string]interface{}{
+				"keys":     len(collisions),
+				"conflict": conflict,
+			})
+		}
+	}
+
+	if !reportPrefixRead(&resp.Diagnostics, basePath, failures, len(values), data.FailOnError.ValueBool(),
+		"credentials, values and values_flat") {
+		return
 	}
 
 	// Build nested object structure from slash-separated paths
@@ -172,15 +595,246 @@ func (r *EnvEphemeralResource) Open(ctx context.Context, req ephemeral.OpenReque
 	dynamicValue := types.DynamicValue(objValue)
 	data.Credentials = dynamicValue
 
+	flat, diags := types.MapValueFrom(ctx, types.StringType, values)
+	resp.Diagnostics.Append(diags...)
+	data.ValuesFlat = flat
+
+	joinedMap, diags := types.MapValueFrom(ctx, types.StringType, joined)
+	resp.Diagnostics.Append(diags...)
+	data.Values = joinedMap
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	keyList, diags := types.ListValueFrom(ctx, types.StringType, keys)
+	resp.Diagnostics.Append(diags...)
+	data.Keys = keyList
+
+	errs := make(map[string]string, len(failures))
+	for _, f := range failures {
+		errs[f.Path] = errorDetail(f.Err, f.Err.Error())
+	}
+	errMap, diags := types.MapValueFrom(ctx, types.StringType, errs)
+	resp.Diagnostics.Append(diags...)
+	data.Errors = errMap
+
 	// Set result - NEVER written to state
 	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
 
+	state := &renewState{
+		Path:     basePath,
+		Snapshot: snapshot,
+		MaxDepth: maxDepth,
+		Interval: renewInterval,
+		Timeout:  timeout,
+		Digest:   digestValues(raw),
+	}
+	if len(prefixes) > 1 {
+		state.Paths = prefixes
+		state.Conflict = conflict
+	}
+	openRenewState(ctx, resp, state)
+	openCloseState(ctx, r.client, resp, &closeState{Prefixes: prefixes})
+
 	tflog.Debug(ctx, "Successfully read env secrets from gopass", map[string]interface{}{
 		"path":  basePath,
 		"count": len(values),
 	})
 }
 
+// Renew re-reads the secrets during long-running operations and warns if any changed since Open.
+func (r *EnvEphemeralResource) Renew(ctx context.Context, req ephemeral.RenewRequest, resp *ephemeral.RenewResponse) {
+	ctx = r.client.logContext(ctx)
+
+	renewSecrets(ctx, req, resp, func(ctx context.Context, state *renewState) (map[string]string, error) {
+		ctx = uncached(ctx)
+		prefixes := state.Paths
+		if len(prefixes) == 0 {
+			prefixes = []string{state.Path}
+		}
+		values, _, _, err := r.readEnv(ctx, prefixes, state.Snapshot, state.MaxDepth, state.Conflict)
+		return values, err
+	})
+}
+
+// readEnv reads the secrets up to maxDepth levels under each of prefixes at snapshot and merges
+// them by relative key. A key present under several prefixes takes the value of the first one
+// with conflict "first", and of the last one otherwise; these keys are returned sorted. The
+// secrets that could not be read are returned in the order of prefixes.
+func (r *EnvEphemeralResource) readEnv(ctx context.Context, prefixes []string, snapshot string, maxDepth int,
+	conflict string,
+) (map[string]string, []EnvReadError, []envCollision, error) {
+	if len(prefixes) == 1 {
+		values, failures, err := r.client.ReadEnvSecretsAt(ctx, prefixes[0], snapshot, maxDepth)
+		return values, failures, nil, err
+	}
+
+	merged := make(map[string]string)
+	origins := make(map[string][]string)
+	var failures []EnvReadError
+	for _, prefix := range prefixes {
+		values, failed, err := r.client.ReadEnvSecretsAt(ctx, prefix, snapshot, maxDepth)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("path %q: %w", prefix, err)
+		}
+		for key, value := range values {
+			if _, ok := merged[key]; !ok || conflict != envConflictFirst {
+				merged[key] = value
+			}
+			origins[key] = append(origins[key], prefix)
+		}
+		failures = append(failures, failed...)
+	}
+
+	var collisions []envCollision
+	for key, paths := range origins {
+		if len(paths) > 1 {
+			collisions = append(collisions, envCollision{Key: key, Paths: paths})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool { return collisions[i].Key < collisions[j].Key })
+	return merged, failures, collisions, nil
+}
+
+// Close is called once Terraform no longer needs the secrets. Private data only holds a
+// digest of them, but a caching client still holds the decrypted secrets, which are dropped.
+func (r *EnvEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	ctx = r.client.logContext(ctx)
+
+	closeSecrets(ctx, r.client, req, resp)
+
+	tflog.Debug(ctx, "Closed ephemeral gopass env")
+}
+
+// shapeEnvValues filters secrets by the include/exclude patterns and transforms
+// their keys according to opts. Patterns are matched against the original
+// relative key. It fails if two secrets end up with the same key.
+func shapeEnvValues[V any](values map[string]V, opts envKeyOptions) (map[string]V, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]V, len(values))
+	origins := make(map[string]string, len(values))
+
+	for _, key := range keys {
+		selected, err := opts.selects(key)
+		if err != nil {
+			return nil, err
+		}
+		if !selected {
+			continue
+		}
+
+		newKey := key
+		if opts.flattenSeparator != "" {
+			newKey = strings.ReplaceAll(newKey, "/", opts.flattenSeparator)
+		}
+		if opts.uppercase {
+			newKey = strings.ToUpper(newKey)
+		}
+		newKey = opts.keyPrefix + newKey
+
+		if origin, exists := origins[newKey]; exists {
+			return nil, fmt.Errorf("secrets %q and %q both map to key %q", origin, key, newKey)
+		}
+		origins[newKey] = key
+		result[newKey] = values[key]
+	}
+
+	return result, nil
+}
+
+// joinEnvKeys returns values with the slashes of their keys replaced by separator.
+// It fails if two keys end up the same, e.g. "a.b" and "a/b" with ".".
+func joinEnvKeys(values map[string]string, separator string) (map[string]string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]string, len(values))
+	origins := make(map[string]string, len(values))
+	for _, key := range keys {
+		joined := strings.ReplaceAll(key, "/", separator)
+		if origin, exists := origins[joined]; exists {
+			return nil, fmt.Errorf("secrets %q and %q both map to key %q of values, choose another values_separator", origin, key, joined)
+		}
+		origins[joined] = key
+		result[joined] = values[key]
+	}
+	return result, nil
+}
+
+// selects reports whether the secret at key, relative to the path, passes the include and
+// exclude patterns.
+func (opts *envKeyOptions) selects(key string) (bool, error) {
+	if len(opts.include) > 0 {
+		included, err := matchAnyGlob(opts.include, key)
+		if err != nil || !included {
+			return false, err
+		}
+	}
+
+	excluded, err := matchAnyGlob(opts.exclude, key)
+	return err == nil && !excluded, err
+}
+
+// selectEnvFailures returns the failures of secrets selected by the include and exclude
+// patterns, as secrets the configuration leaves out do not matter.
+func selectEnvFailures(failures []EnvReadError, opts envKeyOptions) ([]EnvReadError, error) {
+	var selected []EnvReadError
+	for _, f := range failures {
+		ok, err := opts.selects(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, f)
+		}
+	}
+	return selected, nil
+}
+
+// selectEnvCollisions keeps the collisions of keys selected by the include and exclude patterns.
+func selectEnvCollisions(collisions []envCollision, opts envKeyOptions) ([]envCollision, error) {
+	var selected []envCollision
+	for _, c := range collisions {
+		ok, err := opts.selects(c.Key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
+// describeEnvCollisions lists the keys of collisions with the paths they are present under.
+// Keys are not secret, so they can be named in diagnostics.
+func describeEnvCollisions(collisions []envCollision) string {
+	parts := make([]string, 0, len(collisions))
+	for _, c := range collisions {
+		parts = append(parts, fmt.Sprintf("%s (%s)", c.Key, strings.Join(c.Paths, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeEnvFailures lists the paths of failures with their reasons for a diagnostic.
+func describeEnvFailures(failures []EnvReadError) string {
+	parts := make([]string, 0, len(failures))
+	for _, f := range failures {
+		parts = append(parts, fmt.Sprintf("%s (%s)", f.Path, f.Err.Error()))
+	}
+	return strings.Join(parts, ", ")
+}
+
 // buildNestedObject converts a flat map with slash-separated keys into a nested object structure.
 // For example:
 //
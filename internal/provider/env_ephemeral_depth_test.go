@@ -0,0 +1,134 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestWithinDepth(t *testing.T) {
+	tests := []struct {
+		key      string
+		maxDepth int
+		want     bool
+	}{
+		{key: "KEY", maxDepth: 0, want: true},
+		{key: "API/v2/KEY", maxDepth: 0, want: true},
+		{key: "KEY", maxDepth: 1, want: true},
+		{key: "API/KEY", maxDepth: 1, want: false},
+		{key: "API/KEY", maxDepth: 2, want: true},
+		{key: "API/v2/KEY", maxDepth: 2, want: false},
+	}
+
+	for _, tc := range tests {
+		if got := withinDepth(tc.key, tc.maxDepth); got != tc.want {
+			t.Errorf("withinDepth(%q, %d) = %v, want %v", tc.key, tc.maxDepth, got, tc.want)
+		}
+	}
+}
+
+// depthTestStore returns a store with secrets at three levels below env/app. The secret at
+// the deepest level cannot be read, so reading it shows up as a failure.
+func depthTestStore() *mockStoreWithSelectiveFailure {
+	store := newMockStoreWithSelectiveFailure()
+	store.secrets["env/app/KEY"] = newMockSecret("top")
+	store.secrets["env/app/API/KEY"] = newMockSecret("nested")
+	store.secrets["env/app/API/v2/KEY"] = newMockSecret("deep")
+	store.failOnGet["env/app/API/v2/KEY"] = true
+	return store
+}
+
+func TestGopassClient_ReadEnvSecretsAt_MaxDepth(t *testing.T) {
+	tests := []struct {
+		maxDepth     int
+		wantValues   map[string]string
+		wantFailures int
+	}{
+		{maxDepth: 0, wantValues: map[string]string{"KEY": "top", "API/KEY": "nested"}, wantFailures: 1},
+		{maxDepth: 1, wantValues: map[string]string{"KEY": "top"}},
+		{maxDepth: 2, wantValues: map[string]string{"KEY": "top", "API/KEY": "nested"}},
+	}
+
+	for _, tc := range tests {
+		client := NewGopassClient("")
+		client.store = depthTestStore()
+
+		values, failures, err := client.ReadEnvSecretsAt(context.Background(), "env/app", "", tc.maxDepth)
+		if err != nil {
+			t.Fatalf("max depth %d: unexpected error: %v", tc.maxDepth, err)
+		}
+		if !reflect.DeepEqual(values, tc.wantValues) || len(failures) != tc.wantFailures {
+			t.Errorf("max depth %d: got %v with %d failures, want %v with %d failures",
+				tc.maxDepth, values, len(failures), tc.wantValues, tc.wantFailures)
+		}
+	}
+}
+
+func TestEnvEphemeralResource_Open_MaxDepth(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = depthTestStore()
+	r := &EnvEphemeralResource{client: client}
+
+	resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"paths":     pathsValue("env/app"),
+		"max_depth": tftypes.NewValue(tftypes.Number, 2),
+	})
+	if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 0 {
+		t.Fatalf("expected no diagnostics, got %v", resp.Diagnostics)
+	}
+
+	values := map[string]string{}
+	resp.Diagnostics.Append(result.ValuesFlat.ElementsAs(context.Background(), &values, false)...)
+	if want := map[string]string{"KEY": "top", "API/KEY": "nested"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("expected %v, got %v", want, values)
+	}
+}
+
+func TestEnvEphemeralResource_Renew_MaxDepth(t *testing.T) {
+	store := depthTestStore()
+	client := NewGopassClient("")
+	client.store = store
+	r := &EnvEphemeralResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+	openResp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":           tftypes.NewValue(tftypes.String, "env/app"),
+				"max_depth":      tftypes.NewValue(tftypes.Number, 1),
+				"renew_interval": tftypes.NewValue(tftypes.String, "1m"),
+			}),
+		},
+	}, openResp)
+	if openResp.Diagnostics.HasError() || openResp.RenewAt.IsZero() {
+		t.Fatalf("expected successful open with renewal, got %v", openResp.Diagnostics)
+	}
+
+	// Secrets below max_depth are not read on renewal either
+	store.secrets["env/app/API/KEY"] = newMockSecret("rotated")
+	resp := &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if len(resp.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics for a change below max_depth, got %v", resp.Diagnostics)
+	}
+
+	store.secrets["env/app/KEY"] = newMockSecret("rotated")
+	resp = &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected change warning, got %v", resp.Diagnostics)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// envErrorsTestResource returns a gopass_env resource over env/app, where reading
+// env/app/db/PASSWORD and env/app/legacy/TOKEN fails.
+func envErrorsTestResource() *EnvEphemeralResource {
+	store := newMockStoreWithSelectiveFailure()
+	store.secrets["env/app/API_KEY"] = newMockSecret("key")
+	store.secrets["env/app/db/PASSWORD"] = newMockSecret("hunter2")
+	store.secrets["env/app/legacy/TOKEN"] = newMockSecret("old")
+	store.failOnGet["env/app/db/PASSWORD"] = true
+	store.failOnGet["env/app/legacy/TOKEN"] = true
+	client := NewGopassClient("")
+	client.store = store
+	return &EnvEphemeralResource{client: client}
+}
+
+func TestEnvEphemeralResource_Open_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		values      map[string]tftypes.Value
+		wantErrors  []string
+		wantFailure bool
+	}{
+		{
+			name:       "reported",
+			values:     map[string]tftypes.Value{},
+			wantErrors: []string{"env/app/db/PASSWORD", "env/app/legacy/TOKEN"},
+		},
+		{
+			name:       "excluded",
+			values:     map[string]tftypes.Value{"exclude": pathsValue("legacy/**")},
+			wantErrors: []string{"env/app/db/PASSWORD"},
+		},
+		{
+			name:   "not included",
+			values: map[string]tftypes.Value{"include": pathsValue("API_KEY")},
+		},
+		{
+			name:   "fail_on_error without failures",
+			values: map[string]tftypes.Value{"include": pathsValue("API_KEY"), "fail_on_error": tftypes.NewValue(tftypes.Bool, true)},
+		},
+		{
+			name:        "fail_on_error",
+			values:      map[string]tftypes.Value{"fail_on_error": tftypes.NewValue(tftypes.Bool, true)},
+			wantFailure: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.values["path"] = tftypes.NewValue(tftypes.String, "env/app")
+			resp, result := openEnvWithConfig(t, envErrorsTestResource(), tc.values)
+
+			if tc.wantFailure {
+				if !resp.Diagnostics.HasError() {
+					t.Fatal("expected error")
+				}
+				detail := resp.Diagnostics.Errors()[0].Detail()
+				if !strings.HasPrefix(detail, "["+CodeError+`] Could not read 2 secret(s) under path "env/app": env/app/db/PASSWORD (`) ||
+					!strings.Contains(detail, "env/app/legacy/TOKEN") {
+					t.Errorf("unexpected detail %q", detail)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			if warned := resp.Diagnostics.WarningsCount() == 1; warned != (len(tc.wantErrors) > 0) {
+				t.Errorf("expected warning=%v, got %v", len(tc.wantErrors) > 0, resp.Diagnostics)
+			}
+			errs := result.Errors.Elements()
+			if len(errs) != len(tc.wantErrors) {
+				t.Fatalf("expected errors for %v, got %v", tc.wantErrors, errs)
+			}
+			for _, p := range tc.wantErrors {
+				v, ok := errs[p].(types.String)
+				if !ok || !strings.HasPrefix(v.ValueString(), "["+CodeError+"] failed to get secret "+`"`+p+`": selective failure`) {
+					t.Errorf("expected error for %s, got %v", p, errs[p])
+				}
+			}
+			if _, ok := result.ValuesFlat.Elements()["API_KEY"]; !ok {
+				t.Errorf("expected API_KEY to be read, got %v", result.ValuesFlat)
+			}
+		})
+	}
+}
+
+func TestEnvEphemeralResource_Open_ErrorsPaths(t *testing.T) {
+	r := envErrorsTestResource()
+	r.client.store.(*mockStoreWithSelectiveFailure).secrets["env/common/LOG_LEVEL"] = newMockSecret("info")
+
+	resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"paths": pathsValue("env/common", "env/app"),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if got := len(result.Errors.Elements()); got != 2 {
+		t.Errorf("expected 2 errors, got %v", result.Errors)
+	}
+}
+
+func TestEnvEphemeralResource_Open_ErrorsInvalidPattern(t *testing.T) {
+	// No secret can be read, so only the failures are matched against the patterns
+	store := newMockStoreWithSelectiveFailure()
+	store.secrets["env/app/API_KEY"] = newMockSecret("key")
+	store.failOnGet["env/app/API_KEY"] = true
+	client := NewGopassClient("")
+	client.store = store
+
+	resp, _ := openEnvWithConfig(t, &EnvEphemeralResource{client: client}, map[string]tftypes.Value{
+		"path":    tftypes.NewValue(tftypes.String, "env/app"),
+		"include": pathsValue("["),
+	})
+	if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Invalid key options" {
+		t.Errorf("expected invalid key options error, got %v", resp.Diagnostics)
+	}
+}
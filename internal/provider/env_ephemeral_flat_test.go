@@ -0,0 +1,151 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestEnvEphemeralResource_Open_ValuesFlat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   map[string]tftypes.Value
+		want     map[string]string
+		wantKeys []string
+	}{
+		{
+			name: "slash-joined keys",
+			config: map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, "env/test"),
+			},
+			want:     map[string]string{"API/v2/ACCESS_KEY": "ak", "region": "eu"},
+			wantKeys: []string{"API/v2/ACCESS_KEY", "region"},
+		},
+		{
+			name: "follows key options",
+			config: map[string]tftypes.Value{
+				"path":              tftypes.NewValue(tftypes.String, "env/test"),
+				"uppercase_keys":    tftypes.NewValue(tftypes.Bool, true),
+				"flatten_separator": tftypes.NewValue(tftypes.String, "_"),
+			},
+			want:     map[string]string{"API_V2_ACCESS_KEY": "ak", "REGION": "eu"},
+			wantKeys: []string{"API_V2_ACCESS_KEY", "REGION"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			mockStore.secrets["env/test/API/v2/ACCESS_KEY"] = newMockSecret("ak")
+			mockStore.secrets["env/test/region"] = newMockSecret("eu")
+			client := NewGopassClient("")
+			client.store = mockStore
+			r := &EnvEphemeralResource{client: client}
+
+			resp, result := openEnvWithConfig(t, r, tc.config)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			got := map[string]string{}
+			resp.Diagnostics.Append(result.ValuesFlat.ElementsAs(context.Background(), &got, false)...)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("failed to decode values_flat: %v", resp.Diagnostics)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected values_flat %v, got %v", tc.want, got)
+			}
+
+			var keys []string
+			resp.Diagnostics.Append(result.Keys.ElementsAs(context.Background(), &keys, false)...)
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tc.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestEnvEphemeralResource_Open_Values(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  map[string]tftypes.Value
+		secrets map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "dots",
+			config:  map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/test")},
+			secrets: map[string]string{"env/test/API/v2/ACCESS_KEY": "ak", "env/test/region": "eu"},
+			want:    map[string]string{"API.v2.ACCESS_KEY": "ak", "region": "eu"},
+		},
+		{
+			name: "double underscores",
+			config: map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, "env/test"),
+				"values_separator": tftypes.NewValue(tftypes.String, "__"),
+			},
+			secrets: map[string]string{"env/test/API/v2/ACCESS_KEY": "ak", "env/test/region": "eu"},
+			want:    map[string]string{"API__v2__ACCESS_KEY": "ak", "region": "eu"},
+		},
+		{
+			name:    "collision",
+			config:  map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/test")},
+			secrets: map[string]string{"env/test/API/KEY": "a", "env/test/API.KEY": "b"},
+			wantErr: "Invalid key options",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			for path, value := range tc.secrets {
+				mockStore.secrets[path] = newMockSecret(value)
+			}
+			client := NewGopassClient("")
+			client.store = mockStore
+			r := &EnvEphemeralResource{client: client}
+
+			resp, result := openEnvWithConfig(t, r, tc.config)
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			got := map[string]string{}
+			resp.Diagnostics.Append(result.Values.ElementsAs(context.Background(), &got, false)...)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected values %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEnvEphemeralResource_Open_ValuesFlatEmpty(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	r := &EnvEphemeralResource{client: client}
+
+	resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "env/empty"),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if result.ValuesFlat.IsNull() || len(result.ValuesFlat.Elements()) != 0 {
+		t.Errorf("expected empty values_flat map, got %v", result.ValuesFlat)
+	}
+	if result.Keys.IsNull() || len(result.Keys.Elements()) != 0 {
+		t.Errorf("expected empty keys list, got %v", result.Keys)
+	}
+}
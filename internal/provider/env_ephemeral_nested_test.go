@@ -58,22 +58,12 @@ func TestEnvEphemeralResource_Open_NestedPaths(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":        tftypes.NewValue(tftypes.String, "env/test"),
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -148,22 +138,12 @@ func TestEnvEphemeralResource_Open_DeepNesting(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":        tftypes.NewValue(tftypes.String, "env/deep"),
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -222,22 +202,12 @@ func TestEnvEphemeralResource_Open_MixedDepths(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":        tftypes.NewValue(tftypes.String, "env/mixed"),
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
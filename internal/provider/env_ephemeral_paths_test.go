@@ -0,0 +1,382 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func pathsValue(paths ...string) tftypes.Value {
+	elements := make([]tftypes.Value, 0, len(paths))
+	for _, p := range paths {
+		elements = append(elements, tftypes.NewValue(tftypes.String, p))
+	}
+	return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, elements)
+}
+
+func TestEnvEphemeralResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]tftypes.Value
+		invalid bool
+		wantErr string
+	}{
+		{name: "path", values: map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "env/app")}},
+		{name: "paths", values: map[string]tftypes.Value{"paths": pathsValue("env/common", "env/app")}},
+		{
+			name:   "unknown paths",
+			values: map[string]tftypes.Value{"paths": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue)},
+		},
+		{
+			name: "unknown element",
+			values: map[string]tftypes.Value{"paths": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+				tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			})},
+		},
+		{
+			name: "both",
+			values: map[string]tftypes.Value{
+				"path":  tftypes.NewValue(tftypes.String, "env/app"),
+				"paths": pathsValue("env/common"),
+			},
+			wantErr: "Conflicting path and paths",
+		},
+		{name: "neither", values: map[string]tftypes.Value{}, wantErr: "Missing path"},
+		{name: "empty paths", values: map[string]tftypes.Value{"paths": pathsValue()}, wantErr: "Missing path"},
+		{name: "invalid element", values: map[string]tftypes.Value{"paths": pathsValue("env/common", "../app")}, wantErr: "Invalid secret path"},
+		{
+			name: "max_depth",
+			values: map[string]tftypes.Value{
+				"path":      tftypes.NewValue(tftypes.String, "env/app"),
+				"max_depth": tftypes.NewValue(tftypes.Number, 1),
+			},
+		},
+		{
+			name: "max_depth zero",
+			values: map[string]tftypes.Value{
+				"path":      tftypes.NewValue(tftypes.String, "env/app"),
+				"max_depth": tftypes.NewValue(tftypes.Number, 0),
+			},
+			wantErr: "Invalid max_depth",
+		},
+		{
+			name: "values_separator",
+			values: map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, "env/app"),
+				"values_separator": tftypes.NewValue(tftypes.String, "__"),
+			},
+		},
+		{
+			name: "invalid values_separator",
+			values: map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, "env/app"),
+				"values_separator": tftypes.NewValue(tftypes.String, "-"),
+			},
+			wantErr: "Invalid values_separator",
+		},
+		{name: "duplicate path", values: map[string]tftypes.Value{"paths": pathsValue("env/common", "env/app", "env/common")}, wantErr: "Duplicate path"},
+		{
+			name: "conflict",
+			values: map[string]tftypes.Value{
+				"paths":    pathsValue("env/common", "env/app"),
+				"conflict": tftypes.NewValue(tftypes.String, "first"),
+			},
+		},
+		{
+			name: "invalid conflict",
+			values: map[string]tftypes.Value{
+				"paths":    pathsValue("env/common", "env/app"),
+				"conflict": tftypes.NewValue(tftypes.String, "merge"),
+			},
+			wantErr: "Invalid conflict",
+		},
+		{name: "invalid config", invalid: true, wantErr: "Value Conversion Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &EnvEphemeralResource{}
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+			raw := schemaObjectValue(schemaResp.Schema, tc.values)
+			if tc.invalid {
+				raw = invalidRaw
+			}
+
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestEnvEphemeralResource_Open_Paths(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/common/DATABASE_URL"] = newMockSecret("postgres://shared")
+	mockStore.secrets["env/common/LOG_LEVEL"] = newMockSecret("info")
+	mockStore.secrets["env/app1/DATABASE_URL"] = newMockSecret("postgres://app1")
+	mockStore.secrets["env/app1/API_TOKEN"] = newMockSecret("token")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+
+	resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"paths": pathsValue("env/common", "env/app1"),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	want := map[string]string{
+		"DATABASE_URL": "postgres://app1",
+		"LOG_LEVEL":    "info",
+		"API_TOKEN":    "token",
+	}
+	flat := result.ValuesFlat.Elements()
+	if len(flat) != len(want) {
+		t.Fatalf("expected %d keys, got %v", len(want), flat)
+	}
+	for key, value := range want {
+		if v, ok := flat[key].(types.String); !ok || v.ValueString() != value {
+			t.Errorf("expected %s=%q, got %v", key, value, flat[key])
+		}
+	}
+}
+
+func TestEnvEphemeralResource_Open_PathsReadError(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.shouldFail = true
+	mockStore.failMsg = "store locked"
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+
+	resp, _ := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"paths": pathsValue("env/common", "env/app1"),
+	})
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error")
+	}
+	if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, `path "env/common"`) {
+		t.Errorf("expected the failing path in the detail, got %q", detail)
+	}
+}
+
+func TestEnvEphemeralResource_Open_PathsInvalidList(t *testing.T) {
+	r := &EnvEphemeralResource{client: NewGopassClient("")}
+
+	resp, _ := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"paths": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, nil),
+		}),
+	})
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for a null element in paths")
+	}
+}
+
+func TestEnvEphemeralResource_Renew_Paths(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/common/KEY"] = newMockSecret("shared")
+	mockStore.secrets["env/app1/OTHER"] = newMockSecret("v1")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+	openResp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"paths":          pathsValue("env/common", "env/app1"),
+				"renew_interval": tftypes.NewValue(tftypes.String, "1m"),
+			}),
+		},
+	}, openResp)
+	if openResp.Diagnostics.HasError() || openResp.RenewAt.IsZero() {
+		t.Fatalf("expected successful open with renewal, got %v", openResp.Diagnostics)
+	}
+
+	resp := &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if len(resp.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", resp.Diagnostics)
+	}
+
+	// An override added under a later path changes the merged values
+	mockStore.secrets["env/app1/KEY"] = newMockSecret("override")
+	resp = &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected change warning after an override was added, got %v", resp.Diagnostics)
+	}
+}
+
+func TestEnvEphemeralResource_Open_PathsConflict(t *testing.T) {
+	tests := []struct {
+		name        string
+		conflict    any
+		exclude     []string
+		want        map[string]string
+		wantErr     bool
+		wantWarning bool
+	}{
+		{
+			name:        "unset",
+			want:        map[string]string{"DATABASE_URL": "postgres://app1", "LOG_LEVEL": "debug", "API_TOKEN": "token"},
+			wantWarning: true,
+		},
+		{
+			name:     "last",
+			conflict: "last",
+			want:     map[string]string{"DATABASE_URL": "postgres://app1", "LOG_LEVEL": "debug", "API_TOKEN": "token"},
+		},
+		{
+			name:     "first",
+			conflict: "first",
+			want:     map[string]string{"DATABASE_URL": "postgres://shared", "LOG_LEVEL": "info", "API_TOKEN": "token"},
+		},
+		{name: "error", conflict: "error", wantErr: true},
+		{
+			name:     "error without selected duplicates",
+			conflict: "error",
+			exclude:  []string{"DATABASE_URL", "LOG_LEVEL"},
+			want:     map[string]string{"API_TOKEN": "token"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			mockStore.secrets["env/common/DATABASE_URL"] = newMockSecret("postgres://shared")
+			mockStore.secrets["env/common/LOG_LEVEL"] = newMockSecret("info")
+			mockStore.secrets["env/app1/LOG_LEVEL"] = newMockSecret("debug")
+			mockStore.secrets["env/app1/DATABASE_URL"] = newMockSecret("postgres://app1")
+			mockStore.secrets["env/app1/API_TOKEN"] = newMockSecret("token")
+			client := NewGopassClient("")
+			client.store = mockStore
+			r := &EnvEphemeralResource{client: client}
+
+			exclude := make([]tftypes.Value, 0, len(tc.exclude))
+			for _, pattern := range tc.exclude {
+				exclude = append(exclude, tftypes.NewValue(tftypes.String, pattern))
+			}
+			resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+				"paths":    pathsValue("env/common", "env/app1"),
+				"conflict": tftypes.NewValue(tftypes.String, tc.conflict),
+				"exclude":  tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, exclude),
+			})
+
+			wantDetail := `2 key(s) are present under several of the paths "env/common, env/app1": ` +
+				"DATABASE_URL (env/common, env/app1), LOG_LEVEL (env/common, env/app1)."
+			if tc.wantErr {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Conflicting keys" {
+					t.Fatalf("expected Conflicting keys error, got %v", resp.Diagnostics)
+				}
+				if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, wantDetail) {
+					t.Errorf("expected the colliding keys in %q", detail)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := resp.Diagnostics.WarningsCount() == 1; got != tc.wantWarning {
+				t.Fatalf("expected warning = %v, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+			if tc.wantWarning {
+				detail := resp.Diagnostics.Warnings()[0].Detail()
+				if !strings.Contains(detail, wantDetail) || strings.Contains(detail, "postgres://") {
+					t.Errorf("expected the colliding keys without values in %q", detail)
+				}
+			}
+
+			flat := result.ValuesFlat.Elements()
+			if len(flat) != len(tc.want) {
+				t.Fatalf("expected %d keys, got %v", len(tc.want), flat)
+			}
+			for key, value := range tc.want {
+				if v, ok := flat[key].(types.String); !ok || v.ValueString() != value {
+					t.Errorf("expected %s=%q, got %v", key, value, flat[key])
+				}
+			}
+		})
+	}
+}
+
+func TestEnvEphemeralResource_Renew_PathsConflictFirst(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/common/KEY"] = newMockSecret("shared")
+	mockStore.secrets["env/app1/KEY"] = newMockSecret("override")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+	openResp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"paths":          pathsValue("env/common", "env/app1"),
+				"conflict":       tftypes.NewValue(tftypes.String, "first"),
+				"renew_interval": tftypes.NewValue(tftypes.String, "1m"),
+			}),
+		},
+	}, openResp)
+	if len(openResp.Diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", openResp.Diagnostics)
+	}
+
+	// Renewal merges with the same policy, so the values read are unchanged
+	resp := &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if len(resp.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", resp.Diagnostics)
+	}
+
+	// A change of the value that loses the conflict goes unnoticed
+	mockStore.secrets["env/app1/KEY"] = newMockSecret("changed")
+	resp = &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if len(resp.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", resp.Diagnostics)
+	}
+}
+
+func TestSelectEnvCollisions_InvalidPattern(t *testing.T) {
+	collisions := []envCollision{{Key: "KEY", Paths: []string{"env/common", "env/app1"}}}
+	if _, err := selectEnvCollisions(collisions, envKeyOptions{exclude: []string{"["}}); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
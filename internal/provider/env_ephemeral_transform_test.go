@@ -0,0 +1,243 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestShapeEnvValues(t *testing.T) {
+	values := map[string]string{
+		"REGION":             "eu",
+		"API/v2/ACCESS_KEY":  "ak",
+		"API/v2/LEGACY_KEY":  "lk",
+		"database/prod/HOST": "db",
+	}
+
+	testCases := []struct {
+		name     string
+		opts     envKeyOptions
+		expected map[string]string
+	}{
+		{
+			name:     "no options",
+			opts:     envKeyOptions{},
+			expected: values,
+		},
+		{
+			name: "include",
+			opts: envKeyOptions{include: []string{"API/**", "REGION"}},
+			expected: map[string]string{
+				"REGION":            "eu",
+				"API/v2/ACCESS_KEY": "ak",
+				"API/v2/LEGACY_KEY": "lk",
+			},
+		},
+		{
+			name: "include and exclude",
+			opts: envKeyOptions{include: []string{"API/**"}, exclude: []string{"**/LEGACY_*"}},
+			expected: map[string]string{
+				"API/v2/ACCESS_KEY": "ak",
+			},
+		},
+		{
+			name: "flatten uppercase prefix",
+			opts: envKeyOptions{
+				exclude:          []string{"**/LEGACY_*"},
+				flattenSeparator: "_",
+				uppercase:        true,
+				keyPrefix:        "TF_VAR_",
+			},
+			expected: map[string]string{
+				"TF_VAR_REGION":             "eu",
+				"TF_VAR_API_V2_ACCESS_KEY":  "ak",
+				"TF_VAR_DATABASE_PROD_HOST": "db",
+			},
+		},
+		{
+			name: "prefix without flattening",
+			opts: envKeyOptions{include: []string{"database/**"}, keyPrefix: "APP_"},
+			expected: map[string]string{
+				"APP_database/prod/HOST": "db",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := shapeEnvValues(values, tc.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestShapeEnvValues_Collision(t *testing.T) {
+	values := map[string]string{
+		"db_host": "a",
+		"DB/HOST": "b",
+	}
+
+	_, err := shapeEnvValues(values, envKeyOptions{flattenSeparator: "_", uppercase: true})
+	if err == nil {
+		t.Fatal("expected collision error")
+	}
+	if !strings.Contains(err.Error(), `both map to key "DB_HOST"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestShapeEnvValues_InvalidPattern(t *testing.T) {
+	values := map[string]string{"KEY": "v"}
+
+	if _, err := shapeEnvValues(values, envKeyOptions{include: []string{"["}}); err == nil {
+		t.Error("expected error for invalid include pattern")
+	}
+	if _, err := shapeEnvValues(values, envKeyOptions{exclude: []string{"["}}); err == nil {
+		t.Error("expected error for invalid exclude pattern")
+	}
+}
+
+func openEnvWithConfig(t *testing.T, r *EnvEphemeralResource, values map[string]tftypes.Value) (*ephemeral.OpenResponse, EnvModel) {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	req := ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    schemaObjectValue(schemaResp.Schema, values),
+		},
+	}
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{
+			Schema: schemaResp.Schema,
+			Raw:    schemaNullValue(schemaResp.Schema),
+		},
+	}
+
+	r.Open(ctx, req, resp)
+
+	var result EnvModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.Result.Get(ctx, &result)...)
+	}
+	return resp, result
+}
+
+func TestEnvEphemeralResource_Open_KeyTransformation(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/test/API/v2/ACCESS_KEY"] = newMockSecret("ak")
+	mockStore.secrets["env/test/API/v2/LEGACY_KEY"] = newMockSecret("lk")
+	mockStore.secrets["env/test/region"] = newMockSecret("eu")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+
+	stringList := tftypes.List{ElementType: tftypes.String}
+	resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "env/test"),
+		"exclude": tftypes.NewValue(stringList, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "**/LEGACY_*"),
+		}),
+		"uppercase_keys":    tftypes.NewValue(tftypes.Bool, true),
+		"key_prefix":        tftypes.NewValue(tftypes.String, "TF_VAR_"),
+		"flatten_separator": tftypes.NewValue(tftypes.String, "_"),
+	})
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	obj, ok := result.Credentials.UnderlyingValue().(types.Object)
+	if !ok {
+		t.Fatalf("expected object credentials, got %T", result.Credentials.UnderlyingValue())
+	}
+	attrs := obj.Attributes()
+	if len(attrs) != 2 {
+		t.Fatalf("expected 2 keys, got %v", attrs)
+	}
+	if v, ok := attrs["TF_VAR_API_V2_ACCESS_KEY"].(types.String); !ok || v.ValueString() != "ak" {
+		t.Errorf("expected TF_VAR_API_V2_ACCESS_KEY=ak, got %v", attrs["TF_VAR_API_V2_ACCESS_KEY"])
+	}
+	if v, ok := attrs["TF_VAR_REGION"].(types.String); !ok || v.ValueString() != "eu" {
+		t.Errorf("expected TF_VAR_REGION=eu, got %v", attrs["TF_VAR_REGION"])
+	}
+}
+
+func TestEnvEphemeralResource_Open_KeyCollision(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/test/db/host"] = newMockSecret("a")
+	mockStore.secrets["env/test/DB_HOST"] = newMockSecret("b")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+
+	resp, _ := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"path":              tftypes.NewValue(tftypes.String, "env/test"),
+		"uppercase_keys":    tftypes.NewValue(tftypes.Bool, true),
+		"flatten_separator": tftypes.NewValue(tftypes.String, "_"),
+	})
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for colliding keys")
+	}
+	if resp.Diagnostics[0].Summary() != "Invalid key options" {
+		t.Errorf("unexpected diagnostic: %v", resp.Diagnostics)
+	}
+}
+
+func TestEnvEphemeralResource_Open_IncludeMatchesNothing(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/test/KEY"] = newMockSecret("v")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &EnvEphemeralResource{client: client}
+
+	resp, _ := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "env/test"),
+		"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "OTHER"),
+		}),
+	})
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected a warning when no secrets match, got %v", resp.Diagnostics)
+	}
+}
+
+func TestEnvEphemeralResource_Open_UnknownInclude(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	client.store.(*mockStore).secrets["env/test/KEY"] = newMockSecret("v")
+	r := &EnvEphemeralResource{client: client}
+
+	resp, _ := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "env/test"),
+		"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		}),
+	})
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for unknown include pattern")
+	}
+}
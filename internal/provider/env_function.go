@@ -0,0 +1,101 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ function.Function = &EnvFunction{}
+
+// functionClient returns the client shared by all provider functions. Terraform calls
+// functions without configuring the provider, so it uses the gopass configuration and
+// PASSWORD_STORE_DIR instead of the provider arguments.
+var functionClient = sync.OnceValue(func() *GopassClient {
+	return NewGopassClient("")
+})
+
+// EnvFunction implements provider::gopass::env, reading the immediate children of a
+// folder as a map, like the flat form of the gopass_env ephemeral resource.
+type EnvFunction struct {
+	client *GopassClient
+}
+
+// NewEnvFunction creates a new instance.
+func NewEnvFunction() function.Function {
+	return &EnvFunction{client: functionClient()}
+}
+
+func (f *EnvFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "env"
+}
+
+func (f *EnvFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Read the secrets directly below a folder as a map",
+		Description: "Returns the passwords of the secrets directly below prefix, keyed by their names, " +
+			"like the flat form of the gopass_env ephemeral resource. Subfolders are not included. " +
+			"Function results are not ephemeral: values end up in plan and state wherever they are used in resources. " +
+			"Functions cannot see the provider configuration, so the store comes from the gopass configuration " +
+			"or PASSWORD_STORE_DIR.",
+		MarkdownDescription: "Returns the passwords of the secrets directly below `prefix`, keyed by their names, " +
+			"like the flat form of the `gopass_env` ephemeral resource. Subfolders are not included.\n\n" +
+			"**Function results are not ephemeral**: values end up in plan and state wherever they are used in resources. " +
+			"Prefer `ephemeral \"gopass_env\"` for credentials. Functions cannot see the provider configuration, " +
+			"so the store comes from the gopass configuration or `PASSWORD_STORE_DIR`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "prefix",
+				Description:         "Folder in the gopass store, e.g. 'env/app'.",
+				MarkdownDescription: "Folder in the gopass store, e.g. `env/app`.",
+			},
+		},
+		Return: function.MapReturn{
+			ElementType: types.StringType,
+		},
+	}
+}
+
+func (f *EnvFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var prefix string
+
+	resp.Error = req.Arguments.Get(ctx, &prefix)
+	if resp.Error != nil {
+		return
+	}
+	if err := validateSecretPath(prefix); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, codedDetail(CodeInvalidConfig, fmt.Sprintf("Invalid prefix %q: %s", prefix, err.Error())))
+		return
+	}
+
+	tflog.Debug(ctx, "Reading secrets for provider function env", map[string]interface{}{
+		"prefix": prefix,
+	})
+
+	secretPaths, err := f.client.ListSecrets(ctx, prefix)
+	if err != nil {
+		resp.Error = function.NewFuncError(errorDetail(err, fmt.Sprintf("Could not list secrets under %q: %s", prefix, err.Error())))
+		return
+	}
+
+	values := make(map[string]string, len(secretPaths))
+	for _, secretPath := range secretPaths {
+		value, err := f.client.GetSecret(ctx, secretPath)
+		if err != nil {
+			resp.Error = function.NewFuncError(errorDetail(err, fmt.Sprintf("Could not read secret %q: %s", secretPath, err.Error())))
+			return
+		}
+		values[path.Base(secretPath)] = value
+	}
+
+	resp.Error = resp.Result.Set(ctx, values)
+}
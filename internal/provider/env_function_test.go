@@ -0,0 +1,99 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEnvFunction_Metadata(t *testing.T) {
+	f := NewEnvFunction()
+	if f.(*EnvFunction).client != functionClient() {
+		t.Error("expected functions to share a client")
+	}
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+	if resp.Name != "env" {
+		t.Errorf("expected name env, got %q", resp.Name)
+	}
+
+	defResp := &function.DefinitionResponse{}
+	f.Definition(context.Background(), function.DefinitionRequest{}, defResp)
+	if len(defResp.Definition.Parameters) != 1 || defResp.Definition.Return == nil {
+		t.Errorf("unexpected definition %+v", defResp.Definition)
+	}
+}
+
+func TestEnvFunction_Run(t *testing.T) {
+	mountStores(t, "env")
+	tests := []struct {
+		name    string
+		arg     attr.Value
+		fail    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "immediate children",
+			arg:  types.StringValue("env/app"),
+			want: map[string]string{"DB_PASSWORD": "hunter2", "API_KEY": "key"},
+		},
+		{name: "mount", arg: types.StringValue("env:app"), want: map[string]string{"DB_PASSWORD": "hunter2", "API_KEY": "key"}},
+		{name: "empty folder", arg: types.StringValue("env/none"), want: map[string]string{}},
+		{name: "invalid prefix", arg: types.StringValue("env//app"), wantErr: `[GOPASS_INVALID_CONFIG] Invalid prefix "env//app"`},
+		{name: "wrong argument", arg: types.BoolValue(true), wantErr: "Value Conversion Error"},
+		{name: "list fails", arg: types.StringValue("env/app"), fail: "list", wantErr: `Could not list secrets under "env/app"`},
+		{name: "read fails", arg: types.StringValue("env/app"), fail: "get", wantErr: `Could not read secret "env/app/API_KEY"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStoreWithSelectiveFailure()
+			for p, v := range map[string]string{
+				"env/app/DB_PASSWORD":     "hunter2",
+				"env/app/API_KEY":         "key",
+				"env/app/nested/TOKEN":    "nested",
+				"env/application/UNKNOWN": "other",
+			} {
+				store.secrets[p] = newMockSecret(v)
+			}
+			switch tc.fail {
+			case "list":
+				store.shouldFail, store.failMsg = true, "list failed"
+			case "get":
+				store.failOnGet["env/app/API_KEY"] = true
+			}
+			client := NewGopassClient("")
+			client.store = store
+			f := &EnvFunction{client: client}
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.MapUnknown(types.StringType))}
+			f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{tc.arg})}, resp)
+
+			if tc.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Error)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %v", resp.Error)
+			}
+
+			got := map[string]string{}
+			resp.Result.Value().(types.Map).ElementsAs(context.Background(), &got, false)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
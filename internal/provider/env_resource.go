@@ -0,0 +1,595 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                   = &EnvResource{}
+	_ resource.ResourceWithConfigure      = &EnvResource{}
+	_ resource.ResourceWithValidateConfig = &EnvResource{}
+	_ resource.ResourceWithImportState    = &EnvResource{}
+)
+
+// EnvResource writes a map of environment variables to gopass, one secret per key.
+// It is the writing counterpart of the gopass_env ephemeral resource.
+type EnvResource struct {
+	client *GopassClient
+}
+
+// EnvResourceModel describes the resource data model.
+type EnvResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Path            types.String `tfsdk:"path"`
+	ValuesWO        types.Map    `tfsdk:"values_wo"`
+	ValuesWOVersion types.Int64  `tfsdk:"values_wo_version"`
+	DeleteOnRemove  types.Bool   `tfsdk:"delete_on_remove"`
+	Keys            types.Set    `tfsdk:"keys"`
+	Timeouts        types.Object `tfsdk:"timeouts"`
+}
+
+// NewEnvResource creates a new instance.
+func NewEnvResource() resource.Resource {
+	return &EnvResource{}
+}
+
+func (r *EnvResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_env"
+}
+
+func (r *EnvResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Writes a map of environment variables to gopass, one secret per key under a base path. " +
+			"The values are never stored in Terraform state.",
+		MarkdownDescription: `
+Writes a map of environment variables to gopass, one secret per key under a base path.
+This is the writing counterpart of the ` + "`gopass_env`" + ` ephemeral resource: what this resource
+writes to ` + "`path`" + `, the ephemeral resource reads back as a map.
+
+The values (` + "`values_wo`" + `) are **write-only** and never stored in state. Only the names of the
+managed keys are tracked, so keys removed from ` + "`values_wo`" + ` are deleted from gopass on the next update.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_env" "app" {
+  path              = "env/production/app"
+  values_wo_version = 1
+
+  values_wo = {
+    DATABASE_URL = ephemeral.random_password.db.result
+    API_TOKEN    = scaleway_iam_api_key.app.secret_key
+  }
+}
+
+# Read them back elsewhere
+ephemeral "gopass_env" "app" {
+  path = "env/production/app"
+}
+` + "```" + `
+
+## Write-Only Behavior
+
+- All values are written on create and whenever ` + "`values_wo_version`" + ` changes
+- Keys that were removed from ` + "`values_wo`" + ` are deleted on that update, unless ` + "`delete_on_remove`" + ` is ` + "`false`" + `
+- Secrets of managed keys that were removed outside of Terraform are reported on refresh
+
+## Import
+
+Importing a path adopts all secrets below it as managed keys, without reading their values:
+
+` + "```shell" + `
+terraform import gopass_env.app env/production/app
+` + "```" + `
+
+The first apply writes ` + "`values_wo`" + ` and removes keys missing from it, unless ` + "`delete_on_remove`" + ` is ` + "`false`" + `.
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The base path (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Base path in the gopass store. Each key is written to path/KEY.",
+				MarkdownDescription: "Base path in the gopass store. Each key is written to `path/KEY`.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"values_wo": schema.MapAttribute{
+				Description: "Map of KEY to value to write. Keys may contain slashes for nested paths. " +
+					"This is a write-only attribute - it will never be stored in state or plan files. Accepts ephemeral values.",
+				MarkdownDescription: "Map of `KEY` to value to write. Keys may contain slashes for nested paths. " +
+					"This is a **write-only** attribute - it will never be stored in state or plan files. Accepts ephemeral values.",
+				ElementType: types.StringType,
+				Required:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+			},
+			"values_wo_version": schema.Int64Attribute{
+				Description: "Version number for the write-only values. Increment this to write " +
+					"changes of values_wo to gopass.",
+				MarkdownDescription: "Version number for the write-only values. **Increment this** to write " +
+					"changes of `values_wo` to gopass.",
+				Required: true,
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description: "Whether to delete the secrets from gopass when the resource is destroyed " +
+					"or a key is removed from values_wo. Defaults to true.",
+				MarkdownDescription: "Whether to delete the secrets from gopass when the resource is destroyed " +
+					"or a key is removed from `values_wo`. Defaults to `true`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+			"keys": schema.SetAttribute{
+				Description:         "Names of the keys managed by this resource.",
+				MarkdownDescription: "Names of the keys managed by this resource.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"timeouts": resourceTimeoutsAttribute(),
+		},
+	}
+}
+
+func (r *EnvResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+// ValidateConfig rejects keys that would not form valid gopass paths below path.
+func (r *EnvResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config EnvResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateValueKeys(config.ValuesWO)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *EnvResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data EnvResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutCreate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	tflog.Debug(ctx, "Creating gopass env", map[string]interface{}{
+		"path": basePath,
+	})
+
+	// Get write-only values from config (not plan, as write-only values are only in config)
+	var config EnvResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	values, diags := envValues(config.ValuesWO)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	written, err := r.writeValues(ctx, basePath, values)
+	data.ID = data.Path
+	data.Keys = envKeySet(written)
+	if err != nil {
+		// Record the keys written so far, so the tainted resource cleans them up
+		resp.Diagnostics.AddError(
+			"Failed to create env",
+			errorDetail(err, fmt.Sprintf("Could not write env secrets to gopass at %q: %s", basePath, err.Error())),
+		)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *EnvResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data EnvResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutRead)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	tflog.Debug(ctx, "Reading gopass env", map[string]interface{}{
+		"path": basePath,
+	})
+
+	existing, err := r.existingKeys(ctx, basePath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read env",
+			errorDetail(err, fmt.Sprintf("Could not list secrets at %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	// Only check which managed keys still exist - we never read the values back
+	var kept, missing []string
+	for _, key := range envKeys(data.Keys) {
+		if existing[key] {
+			kept = append(kept, key)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(kept) == 0 && len(missing) > 0 {
+		// All secrets were deleted outside of Terraform
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	if len(missing) > 0 {
+		resp.Diagnostics.AddWarning(
+			"Secrets removed outside of Terraform",
+			codedDetail(CodeDrift, fmt.Sprintf(
+				"The secrets of the keys %s below %q were removed outside of Terraform. "+
+					"Consider incrementing values_wo_version to write them again.",
+				strings.Join(missing, ", "), basePath,
+			)),
+		)
+	}
+
+	data.Keys = envKeySet(kept)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *EnvResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data EnvResourceModel
+	var state EnvResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutUpdate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	data.Keys = state.Keys
+
+	// Values are only written when values_wo_version changes
+	if data.ValuesWOVersion.Equal(state.ValuesWOVersion) {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	tflog.Debug(ctx, "Updating gopass env", map[string]interface{}{
+		"path":        basePath,
+		"old_version": state.ValuesWOVersion.ValueInt64(),
+		"new_version": data.ValuesWOVersion.ValueInt64(),
+	})
+
+	var config EnvResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	values, diags := envValues(config.ValuesWO)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	previous := envKeys(state.Keys)
+	written, err := r.writeValues(ctx, basePath, values)
+	if err != nil {
+		// Keep tracking the previous keys, as none of them is pruned yet
+		data.Keys = envKeySet(append(previous, written...))
+		resp.Diagnostics.AddError(
+			"Failed to update env",
+			errorDetail(err, fmt.Sprintf("Could not write env secrets to gopass at %q: %s", basePath, err.Error())),
+		)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	// Prune keys that were removed from values_wo
+	var stale []string
+	for _, key := range previous {
+		if _, ok := values[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	remaining, err := r.removeKeys(ctx, basePath, stale, data.DeleteOnRemove.ValueBool())
+	data.Keys = envKeySet(append(written, remaining...))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update env",
+			errorDetail(err, fmt.Sprintf("Could not remove stale env secrets from gopass at %q: %s", basePath, err.Error())),
+		)
+	}
+
+	tflog.Info(ctx, "Updated gopass env (values_wo_version changed)", map[string]interface{}{
+		"path":    basePath,
+		"written": len(written),
+		"stale":   len(stale),
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *EnvResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data EnvResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	if _, err := r.removeKeys(ctx, basePath, envKeys(data.Keys), data.DeleteOnRemove.ValueBool()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove env",
+			errorDetail(err, fmt.Sprintf("Could not remove env secrets from gopass at %q: %s", basePath, err.Error())),
+		)
+	}
+}
+
+// ImportState adopts the secrets below the path given as ID as managed keys.
+func (r *EnvResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	keys, diags := r.importKeys(ctx, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("delete_on_remove"), true)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("keys"), envKeySet(keys))...)
+}
+
+// importKeys returns the keys of all secrets below basePath, for importing it. No value is
+// read, so values_wo_version stays null and the first apply writes values_wo.
+func (r *EnvResource) importKeys(ctx context.Context, basePath string) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if err := validateSecretPath(basePath); err != nil {
+		diags.AddError(
+			"Invalid import ID",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("The import ID %q is not a valid gopass path: %s.", basePath, err.Error())),
+		)
+		return nil, diags
+	}
+
+	tflog.Debug(ctx, "Importing gopass secrets below path", map[string]interface{}{
+		"path": basePath,
+	})
+
+	existing, err := r.existingKeys(ctx, basePath)
+	if err != nil {
+		diags.AddError(
+			"Failed to import secrets",
+			errorDetail(err, fmt.Sprintf("Could not list secrets at %q: %s", basePath, err.Error())),
+		)
+		return nil, diags
+	}
+	if len(existing) == 0 {
+		diags.AddError(
+			"Secrets not found",
+			codedDetail(CodeSecretNotFound, fmt.Sprintf("No secrets exist below path %q in gopass", basePath)),
+		)
+		return nil, diags
+	}
+
+	keys := make([]string, 0, len(existing))
+	for key := range existing {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, diags
+}
+
+// writeValues writes each value to basePath/KEY in key order and returns the keys written.
+// On error, the keys written before the failure are returned along with it.
+func (r *EnvResource) writeValues(ctx context.Context, basePath string, values map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	written := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if err := r.client.SetSecret(ctx, basePath+"/"+key, values[key]); err != nil {
+			return written, err
+		}
+		written = append(written, key)
+	}
+	return written, nil
+}
+
+// removeKeys deletes the secrets of keys below basePath, unless deleteOnRemove is false.
+// Secrets already deleted externally are skipped. On error, the keys not removed yet are
+// returned along with it.
+func (r *EnvResource) removeKeys(ctx context.Context, basePath string, keys []string, deleteOnRemove bool) ([]string, error) {
+	if !deleteOnRemove {
+		if len(keys) > 0 {
+			tflog.Info(ctx, "Keeping gopass env secrets (delete_on_remove=false)", map[string]interface{}{
+				"path":  basePath,
+				"count": len(keys),
+			})
+		}
+		return nil, nil
+	}
+
+	for i, key := range keys {
+		secretPath := basePath + "/" + key
+		if err := r.client.RemoveSecret(ctx, secretPath); err != nil {
+			// Ignore "not found" errors - the secret may have been deleted externally
+			if !isNotFoundError(err) {
+				return keys[i:], err
+			}
+			tflog.Debug(ctx, "Secret already deleted externally", map[string]interface{}{
+				"path": secretPath,
+			})
+			continue
+		}
+		tflog.Info(ctx, "Removed gopass secret", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+	return nil, nil
+}
+
+// existingKeys returns the keys of all secrets below basePath.
+func (r *EnvResource) existingKeys(ctx context.Context, basePath string) (map[string]bool, error) {
+	secretPaths, err := r.client.ListSecretsRecursive(ctx, basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]bool, len(secretPaths))
+	for _, secretPath := range secretPaths {
+		keys[strings.TrimPrefix(secretPath, basePath+"/")] = true
+	}
+	return keys, nil
+}
+
+// validateValueKeys rejects keys of the values_wo map that would not form valid gopass paths.
+func validateValueKeys(valuesWO types.Map) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for key := range valuesWO.Elements() {
+		if err := validateSecretPath(key); err != nil {
+			diags.AddAttributeError(
+				path.Root("values_wo").AtMapKey(key),
+				"Invalid key",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("The key %q is not a valid gopass path: %s.", key, err.Error())),
+			)
+		}
+	}
+	return diags
+}
+
+// envValues returns the values of the write-only values_wo map. Every key needs a known value.
+func envValues(valuesWO types.Map) (map[string]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	result := make(map[string]string, len(valuesWO.Elements()))
+	for key, v := range valuesWO.Elements() {
+		value, ok := v.(types.String)
+		if !ok || value.IsNull() || value.IsUnknown() {
+			diags.AddAttributeError(
+				path.Root("values_wo").AtMapKey(key),
+				"Missing value",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("The value of key %q is not known. Every key of values_wo needs a value.", key)),
+			)
+			continue
+		}
+		result[key] = value.ValueString()
+	}
+	return result, diags
+}
+
+// envKeys returns the sorted elements of a keys set. Null or unknown sets yield no keys.
+func envKeys(set types.Set) []string {
+	keys := make([]string, 0, len(set.Elements()))
+	for _, v := range set.Elements() {
+		keys = append(keys, stringAttr(v))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// envKeySet returns keys as a set value, dropping duplicates.
+func envKeySet(keys []string) types.Set {
+	seen := make(map[string]bool, len(keys))
+	elements := make([]attr.Value, 0, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			elements = append(elements, types.StringValue(key))
+		}
+	}
+	return types.SetValueMust(types.StringType, elements)
+}
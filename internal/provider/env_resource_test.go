@@ -0,0 +1,526 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// envFailStore fails Set and Remove for the listed secret paths.
+type envFailStore struct {
+	*mockStore
+	failSet    map[string]bool
+	failRemove map[string]bool
+}
+
+func (s *envFailStore) Set(ctx context.Context, name string, secret gopass.Byter) error {
+	if s.failSet[name] {
+		return errors.New("disk full")
+	}
+	return s.mockStore.Set(ctx, name, secret)
+}
+
+func (s *envFailStore) Remove(ctx context.Context, name string) error {
+	if s.failRemove[name] {
+		return errors.New("permission denied")
+	}
+	return s.mockStore.Remove(ctx, name)
+}
+
+// envValue builds a gopass_env object at env/app. Pass nil values for a plan or state, and
+// nil keys for a plan that has not computed them yet.
+func envValue(schemaResp resource.SchemaResponse, values map[string]string, version int64, keys []string, deleteOnRemove bool) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":                tftypes.NewValue(tftypes.String, "env/app"),
+		"path":              tftypes.NewValue(tftypes.String, "env/app"),
+		"values_wo":         stringMapValue(values),
+		"values_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove":  tftypes.NewValue(tftypes.Bool, deleteOnRemove),
+		"keys":              stringSetValue(keys),
+	})
+}
+
+func envStateKeys(t *testing.T, state tfsdk.State) []string {
+	t.Helper()
+
+	var data EnvResourceModel
+	if diags := state.Get(context.Background(), &data); diags.HasError() {
+		t.Fatalf("failed to read state: %v", diags)
+	}
+	if data.ID.ValueString() != "env/app" {
+		t.Errorf("expected id 'env/app', got %q", data.ID.ValueString())
+	}
+	return envKeys(data.Keys)
+}
+
+func TestEnvResource_Metadata(t *testing.T) {
+	r := NewEnvResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_env" {
+		t.Errorf("expected 'gopass_env', got %q", resp.TypeName)
+	}
+}
+
+func TestEnvResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &EnvResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &EnvResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestEnvResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "valid keys", values: map[string]string{"API_TOKEN": "a", "db/PASSWORD": "b"}},
+		{name: "parent key", values: map[string]string{"../OTHER": "a"}, wantErr: true},
+		{name: "empty key", values: map[string]string{"": "a"}, wantErr: true},
+		{name: "invalid config", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&EnvResource{client: clientWith(newMockStore())})
+			raw := envValue(schemaResp, tc.values, 1, []string{}, true)
+			if tc.invalid {
+				raw = invalidRaw
+			}
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestEnvResource_Create(t *testing.T) {
+	tests := []struct {
+		name          string
+		failSet       string
+		invalid       bool
+		invalidConfig bool
+		nullKey       bool
+		wantKeys      []string
+		wantErr       bool
+	}{
+		{name: "writes all keys", wantKeys: []string{"API_TOKEN", "DB_PASSWORD", "nested/KEY"}},
+		{name: "write fails", failSet: "env/app/DB_PASSWORD", wantKeys: []string{"API_TOKEN"}, wantErr: true},
+		{name: "null value", nullKey: true, wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+		{name: "invalid config", invalidConfig: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &envFailStore{mockStore: newMockStore(), failSet: map[string]bool{tc.failSet: true}}
+			r, schemaResp := testResource(&EnvResource{client: clientWith(store)})
+			values := map[string]string{"API_TOKEN": "token", "DB_PASSWORD": "hunter2", "nested/KEY": "value"}
+			plan := envValue(schemaResp, nil, 1, nil, true)
+			config := envValue(schemaResp, values, 1, nil, true)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+			if tc.invalidConfig {
+				config = invalidRaw
+			}
+			if tc.nullKey {
+				config = schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"path": tftypes.NewValue(tftypes.String, "env/app"),
+					"values_wo": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+						"API_TOKEN": tftypes.NewValue(tftypes.String, nil),
+					}),
+				})
+			}
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantKeys == nil {
+				return
+			}
+			if got := envStateKeys(t, resp.State); !reflect.DeepEqual(got, tc.wantKeys) {
+				t.Errorf("keys = %q, want %q", got, tc.wantKeys)
+			}
+			for _, key := range tc.wantKeys {
+				if got := store.secrets["env/app/"+key].Password(); got != values[key] {
+					t.Errorf("expected %s=%q in gopass, got %q", key, values[key], got)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    []string
+		failList    bool
+		wantKeys    []string
+		wantWarning bool
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "all present", existing: []string{"A", "B"}, wantKeys: []string{"A", "B"}},
+		{name: "unmanaged keys ignored", existing: []string{"A", "B", "C"}, wantKeys: []string{"A", "B"}},
+		{name: "some removed", existing: []string{"B"}, wantKeys: []string{"B"}, wantWarning: true},
+		{name: "all removed", wantRemoved: true},
+		{name: "list fails", failList: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			for _, key := range tc.existing {
+				store.secrets["env/app/"+key] = newMockSecret("v")
+			}
+			if tc.failList {
+				store.shouldFail = true
+				store.failMsg = "store locked"
+			}
+			r, schemaResp := testResource(&EnvResource{client: clientWith(store)})
+			raw := envValue(schemaResp, nil, 1, []string{"A", "B"}, true)
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if warned := resp.Diagnostics.WarningsCount() > 0; warned != tc.wantWarning {
+				t.Errorf("expected warning=%v, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.wantRemoved {
+				t.Fatalf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !tc.wantRemoved {
+				if got := envStateKeys(t, resp.State); !reflect.DeepEqual(got, tc.wantKeys) {
+					t.Errorf("keys = %q, want %q", got, tc.wantKeys)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&EnvResource{client: clientWith(newMockStore())})
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestEnvResource_Update(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        int64
+		deleteOnRemove bool
+		failSet        string
+		failRemove     string
+		nullKey        bool
+		invalid        bool
+		invalidConfig  bool
+		wantKeys       []string
+		wantSecrets    map[string]string
+		wantErr        bool
+	}{
+		{
+			name:        "version unchanged",
+			version:     1,
+			wantKeys:    []string{"A", "OLD"},
+			wantSecrets: map[string]string{"A": "old-a", "OLD": "old"},
+		},
+		{
+			name:           "writes and prunes",
+			version:        2,
+			deleteOnRemove: true,
+			wantKeys:       []string{"A", "NEW"},
+			wantSecrets:    map[string]string{"A": "new-a", "NEW": "new"},
+		},
+		{
+			name:        "keeps pruned secrets without delete_on_remove",
+			version:     2,
+			wantKeys:    []string{"A", "NEW"},
+			wantSecrets: map[string]string{"A": "new-a", "NEW": "new", "OLD": "old"},
+		},
+		{
+			name:           "write fails",
+			version:        2,
+			deleteOnRemove: true,
+			failSet:        "env/app/NEW",
+			wantKeys:       []string{"A", "OLD"},
+			wantSecrets:    map[string]string{"A": "new-a", "OLD": "old"},
+			wantErr:        true,
+		},
+		{
+			name:           "prune fails",
+			version:        2,
+			deleteOnRemove: true,
+			failRemove:     "env/app/OLD",
+			wantKeys:       []string{"A", "NEW", "OLD"},
+			wantSecrets:    map[string]string{"A": "new-a", "NEW": "new", "OLD": "old"},
+			wantErr:        true,
+		},
+		{name: "null value", version: 2, nullKey: true, wantErr: true},
+		{name: "invalid plan", version: 2, invalid: true, wantErr: true},
+		{name: "invalid config", version: 2, invalidConfig: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &envFailStore{
+				mockStore:  storeWith(map[string]string{"env/app/A": "old-a", "env/app/OLD": "old"}),
+				failSet:    map[string]bool{tc.failSet: true},
+				failRemove: map[string]bool{tc.failRemove: true},
+			}
+			r, schemaResp := testResource(&EnvResource{client: clientWith(store)})
+			plan := envValue(schemaResp, nil, tc.version, nil, tc.deleteOnRemove)
+			config := envValue(schemaResp, map[string]string{"A": "new-a", "NEW": "new"}, tc.version, nil, tc.deleteOnRemove)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+			if tc.invalidConfig {
+				config = invalidRaw
+			}
+			if tc.nullKey {
+				config = schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"values_wo": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+						"A": tftypes.NewValue(tftypes.String, nil),
+					}),
+				})
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: envValue(schemaResp, nil, 1, []string{"A", "OLD"}, tc.deleteOnRemove)},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantKeys == nil {
+				return
+			}
+			if got := envStateKeys(t, resp.State); !reflect.DeepEqual(got, tc.wantKeys) {
+				t.Errorf("keys = %q, want %q", got, tc.wantKeys)
+			}
+			got := map[string]string{}
+			for name, secret := range store.secrets {
+				got[strings.TrimPrefix(name, "env/app/")] = secret.Password()
+			}
+			if !reflect.DeepEqual(got, tc.wantSecrets) {
+				t.Errorf("secrets = %v, want %v", got, tc.wantSecrets)
+			}
+		})
+	}
+}
+
+func TestEnvResource_ImportState(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		failList bool
+		wantKeys []string
+		wantErr  string
+	}{
+		{name: "adopts children", id: "env/app", wantKeys: []string{"A", "nested/B"}},
+		{name: "invalid id", id: "env/app/", wantErr: "Invalid import ID"},
+		{name: "no secrets", id: "env/missing", wantErr: "Secrets not found"},
+		{name: "list fails", id: "env/app", failList: true, wantErr: "Failed to import secrets"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"env/app/A": "a", "env/app/nested/B": "b", "env/other/C": "c"})
+			if tc.failList {
+				store.shouldFail = true
+				store.failMsg = "store locked"
+			}
+			r, schemaResp := testResource(&EnvResource{client: clientWith(store)})
+
+			resp := &resource.ImportStateResponse{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.ImportState(context.Background(), resource.ImportStateRequest{ID: tc.id}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := envStateKeys(t, resp.State); !reflect.DeepEqual(got, tc.wantKeys) {
+				t.Errorf("keys = %q, want %q", got, tc.wantKeys)
+			}
+		})
+	}
+}
+
+// TestEnvResource_ImportThenApply checks that the first apply after an import writes the
+// configured values and prunes the adopted keys missing from them.
+func TestEnvResource_ImportThenApply(t *testing.T) {
+	ctx := context.Background()
+	store := storeWith(map[string]string{"env/app/A": "old-a", "env/app/OLD": "old"})
+	r, schemaResp := testResource(&EnvResource{client: clientWith(store)})
+
+	imported := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "env/app"}, imported)
+	if imported.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", imported.Diagnostics)
+	}
+
+	resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: envValue(schemaResp, nil, 1, nil, true)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: envValue(schemaResp, map[string]string{"A": "new-a"}, 1, nil, true)},
+		State:  imported.State,
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	if got := envStateKeys(t, resp.State); !reflect.DeepEqual(got, []string{"A"}) {
+		t.Errorf("keys = %q, want [A]", got)
+	}
+	if got := treeContent(store); !reflect.DeepEqual(got, map[string]string{"env/app/A": "new-a"}) {
+		t.Errorf("secrets = %v, want only the new A", got)
+	}
+}
+
+func TestEnvResource_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteOnRemove bool
+		failRemove     string
+		invalid        bool
+		wantRemaining  []string
+		wantErr        bool
+	}{
+		{name: "removes secrets", deleteOnRemove: true, wantRemaining: []string{"env/app/UNMANAGED"}},
+		{name: "keeps secrets", wantRemaining: []string{"env/app/A", "env/app/UNMANAGED"}},
+		{name: "remove fails", deleteOnRemove: true, failRemove: "env/app/A", wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &envFailStore{
+				mockStore:  storeWith(map[string]string{"env/app/A": "a", "env/app/UNMANAGED": "u"}),
+				failRemove: map[string]bool{tc.failRemove: true},
+			}
+			r, schemaResp := testResource(&EnvResource{client: clientWith(store)})
+			// B was already deleted externally
+			state := envValue(schemaResp, nil, 1, []string{"A", "B"}, tc.deleteOnRemove)
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			var remaining []string
+			for name := range store.secrets {
+				remaining = append(remaining, name)
+			}
+			sort.Strings(remaining)
+			if !reflect.DeepEqual(remaining, tc.wantRemaining) {
+				t.Errorf("remaining = %q, want %q", remaining, tc.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestEnvResource_InvalidTimeouts(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp := testResource(&EnvResource{client: clientWith(newMockStore())})
+	s := schemaResp.Schema
+
+	raw := func(op string) tftypes.Value {
+		return schemaObjectValue(s, map[string]tftypes.Value{
+			"path":              tftypes.NewValue(tftypes.String, "env/app"),
+			"values_wo_version": tftypes.NewValue(tftypes.Number, 1),
+			"delete_on_remove":  tftypes.NewValue(tftypes.Bool, true),
+			"timeouts":          timeoutsRaw(s, op, "never"),
+		})
+	}
+
+	createResp := &resource.CreateResponse{State: tfsdk.State{Schema: s}}
+	r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: s, Raw: raw(timeoutCreate)}}, createResp)
+	assertInvalidTimeouts(t, createResp.Diagnostics.Errors())
+
+	readResp := &resource.ReadResponse{State: tfsdk.State{Schema: s}}
+	r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutRead)}}, readResp)
+	assertInvalidTimeouts(t, readResp.Diagnostics.Errors())
+
+	updateResp := &resource.UpdateResponse{State: tfsdk.State{Schema: s}}
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:  tfsdk.Plan{Schema: s, Raw: raw(timeoutUpdate)},
+		State: tfsdk.State{Schema: s, Raw: raw(timeoutUpdate)},
+	}, updateResp)
+	assertInvalidTimeouts(t, updateResp.Diagnostics.Errors())
+
+	deleteResp := &resource.DeleteResponse{State: tfsdk.State{Schema: s}}
+	r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutDelete)}}, deleteResp)
+	assertInvalidTimeouts(t, deleteResp.Diagnostics.Errors())
+}
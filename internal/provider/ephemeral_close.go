@@ -0,0 +1,56 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// closeStateKey is the private data key holding the closeState of an ephemeral resource.
+const closeStateKey = "close"
+
+// closeState is kept in ephemeral private data between Open and Close. It names the secrets
+// an ephemeral resource read, so Close can drop them from the client cache.
+type closeState struct {
+	Paths    []string `json:"paths,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// openCloseState records the secrets read by Open for Close. It does nothing if the client
+// does not cache secrets, as Close then has nothing to release.
+func openCloseState(ctx context.Context, client *GopassClient, resp *ephemeral.OpenResponse, state *closeState) {
+	if client.cache == nil {
+		return
+	}
+	data, _ := json.Marshal(state) //nolint:errcheck // a struct of strings always marshals
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, closeStateKey, data)...)
+}
+
+// closeSecrets drops the secrets recorded by openCloseState from the client cache and wipes
+// them. The renew state needs no release: it holds no plaintext, and Terraform discards the
+// private data of an ephemeral resource with it after Close.
+func closeSecrets(ctx context.Context, client *GopassClient, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	data, diags := req.Private.GetKey(ctx, closeStateKey)
+	resp.Diagnostics.Append(diags...)
+	if data == nil {
+		return
+	}
+
+	var state closeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		resp.Diagnostics.AddError("Failed to decode close state", codedDetail(CodeInternal, err.Error()))
+		return
+	}
+	released := client.ReleaseCached(ctx, state.Paths, state.Prefixes)
+
+	tflog.Debug(ctx, "Released cached gopass secrets", map[string]interface{}{
+		"paths":    state.Paths,
+		"prefixes": state.Prefixes,
+		"count":    released,
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// validatePrefixConfig checks the max_depth and flatten_separator of the ephemeral resources
+// reading every secret under a path. flatKeys names the keys of a resource that always
+// flattens nested paths, e.g. "dotenv keys", and which therefore needs a separator; it is
+// empty for resources that keep nested keys.
+func validatePrefixConfig(diags *diag.Diagnostics, maxDepth types.Int64, flattenSeparator types.String, flatKeys string) {
+	if !maxDepth.IsNull() && !maxDepth.IsUnknown() && maxDepth.ValueInt64() < 1 {
+		diags.AddAttributeError(
+			path.Root("max_depth"),
+			"Invalid max_depth",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("max_depth must be at least 1, got %d.", maxDepth.ValueInt64())),
+		)
+	}
+	if flatKeys != "" && !flattenSeparator.IsNull() && !flattenSeparator.IsUnknown() && flattenSeparator.ValueString() == "" {
+		diags.AddAttributeError(
+			path.Root("flatten_separator"),
+			"Invalid flatten_separator",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("flatten_separator must not be empty, as %s cannot be nested.", flatKeys)),
+		)
+	}
+}
+
+// prefixRead is a read of every secret under a path, selected and named as configured.
+type prefixRead struct {
+	path        string
+	maxDepth    int
+	timeouts    types.Object
+	include     types.List
+	exclude     types.List
+	keys        envKeyOptions
+	failOnError bool
+	// leftOutOf names the attributes the secrets that could not be read are left out of.
+	leftOutOf string
+}
+
+// readFlatPrefix reads the secrets of read within its open timeout, shapes their keys, and
+// reports the secrets it could not read and an empty result. It returns false if Open
+// must stop. The caller owns the values and wipes them, see wipeValues.
+func readFlatPrefix(ctx context.Context, client *GopassClient, diags *diag.Diagnostics, read prefixRead) (map[string][]byte, bool) {
+	timeout, err := operationTimeout(read.timeouts, timeoutOpen)
+	if err != nil {
+		diags.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return nil, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tflog.Debug(ctx, "Reading secrets under a path from gopass", map[string]interface{}{
+		"path":      read.path,
+		"max_depth": read.maxDepth,
+	})
+
+	raw, failures, err := client.ReadEnvSecretBytesAt(ctx, read.path, "", read.maxDepth)
+	if err != nil {
+		diags.AddError(
+			"Failed to read secrets",
+			errorDetail(err, fmt.Sprintf("Could not read secrets under path %q: %s", read.path, err.Error())),
+		)
+		return nil, false
+	}
+	// The values returned are copies, so every value read is wiped
+	defer wipeValues(raw)
+
+	opts := read.keys
+	diags.Append(read.include.ElementsAs(ctx, &opts.include, false)...)
+	diags.Append(read.exclude.ElementsAs(ctx, &opts.exclude, false)...)
+	if diags.HasError() {
+		return nil, false
+	}
+
+	values, err := shapeEnvValues(raw, opts)
+	if err == nil {
+		failures, err = selectEnvFailures(failures, opts)
+	}
+	if err != nil {
+		diags.AddError(
+			"Invalid key options",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not shape secrets under path %q: %s", read.path, err.Error())),
+		)
+		return nil, false
+	}
+
+	if !reportPrefixRead(diags, read.path, failures, len(values), read.failOnError, read.leftOutOf) {
+		return nil, false
+	}
+	for key, value := range values {
+		values[key] = bytes.Clone(value)
+	}
+	return values, true
+}
+
+// reportPrefixRead reports the selected secrets under basePath that could not be read, as an
+// error with failOnError and as a warning naming leftOutOf otherwise, and warns if count
+// secrets is none. It returns false if Open must stop.
+func reportPrefixRead(diags *diag.Diagnostics, basePath string, failures []EnvReadError, count int, failOnError bool, leftOutOf string) bool {
+	if len(failures) > 0 {
+		detail := errorDetail(failures[0].Err, fmt.Sprintf(
+			"Could not read %d secret(s) under path %q: %s", len(failures), basePath, describeEnvFailures(failures),
+		))
+		if failOnError {
+			diags.AddError("Failed to read secrets", detail)
+			return false
+		}
+		diags.AddWarning("Some secrets could not be read", detail+
+			fmt.Sprintf(" They are left out of %s. Set fail_on_error = true to fail instead.", leftOutOf))
+	}
+
+	if count == 0 {
+		diags.AddWarning(
+			"No secrets found",
+			codedDetail(CodeSecretNotFound, fmt.Sprintf("No secrets found under path %q", basePath)),
+		)
+	}
+	return true
+}
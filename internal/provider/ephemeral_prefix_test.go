@@ -0,0 +1,83 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestValidatePrefixConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxDepth  types.Int64
+		separator types.String
+		flatKeys  string
+		wantErrs  []string
+	}{
+		{name: "unset", maxDepth: types.Int64Null(), separator: types.StringNull(), flatKeys: "dotenv keys"},
+		{name: "unknown", maxDepth: types.Int64Unknown(), separator: types.StringUnknown(), flatKeys: "dotenv keys"},
+		{name: "valid", maxDepth: types.Int64Value(1), separator: types.StringValue("_"), flatKeys: "dotenv keys"},
+		{name: "nested keys accept empty separator", maxDepth: types.Int64Null(), separator: types.StringValue("")},
+		{
+			name: "both invalid", maxDepth: types.Int64Value(0), separator: types.StringValue(""), flatKeys: "dotenv keys",
+			wantErrs: []string{"max_depth must be at least 1, got 0.", "flatten_separator must not be empty, as dotenv keys cannot be nested."},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			validatePrefixConfig(&diags, tc.maxDepth, tc.separator, tc.flatKeys)
+
+			errs := diags.Errors()
+			if len(errs) != len(tc.wantErrs) {
+				t.Fatalf("expected %d errors, got %v", len(tc.wantErrs), diags)
+			}
+			for i, want := range tc.wantErrs {
+				if !strings.HasSuffix(errs[i].Detail(), want) {
+					t.Errorf("expected error ending with %q, got %q", want, errs[i].Detail())
+				}
+			}
+		})
+	}
+}
+
+func TestReportPrefixRead(t *testing.T) {
+	failures := []EnvReadError{{Path: "env/app/KEY", Key: "KEY", Err: errors.New("no key")}}
+
+	t.Run("failures as warning", func(t *testing.T) {
+		var diags diag.Diagnostics
+		if !reportPrefixRead(&diags, "env/app", failures, 1, false, "content") {
+			t.Fatal("expected Open to continue")
+		}
+		if len(diags) != 1 || diags.HasError() || !strings.Contains(diags[0].Detail(), "They are left out of content.") {
+			t.Errorf("expected a warning naming content, got %v", diags)
+		}
+	})
+
+	t.Run("failures as error", func(t *testing.T) {
+		var diags diag.Diagnostics
+		if reportPrefixRead(&diags, "env/app", failures, 1, true, "content") {
+			t.Fatal("expected Open to stop")
+		}
+		if len(diags) != 1 || diags[0].Summary() != "Failed to read secrets" {
+			t.Errorf("expected a read error, got %v", diags)
+		}
+	})
+
+	t.Run("no secrets", func(t *testing.T) {
+		var diags diag.Diagnostics
+		if !reportPrefixRead(&diags, "env/app", nil, 0, true, "content") {
+			t.Fatal("expected Open to continue")
+		}
+		if len(diags) != 1 || diags[0].Summary() != "No secrets found" {
+			t.Errorf("expected a no secrets warning, got %v", diags)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// renewStateKey is the private data key holding the renewState of an ephemeral resource.
+const renewStateKey = "renew"
+
+// privateState is the part of the framework's ephemeral private data used by this provider.
+// The framework type lives in an internal package, so it is accessed through this interface.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// renewState is kept in ephemeral private data between Open and Renew.
+// It holds only what is needed to re-read the secrets and a digest of the
+// values handed out by Open - never the plaintext itself.
+type renewState struct {
+	Path     string        `json:"path"`
+	Paths    []string      `json:"paths,omitempty"`
+	Key      string        `json:"key,omitempty"`
+	Return   string        `json:"return,omitempty"`
+	Snapshot string        `json:"snapshot,omitempty"`
+	MaxDepth int           `json:"max_depth,omitempty"`
+	Conflict string        `json:"conflict,omitempty"`
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout"`
+	Digest   string        `json:"digest"`
+}
+
+// parseRenewInterval parses the renew_interval attribute. A null value disables renewal.
+func parseRenewInterval(v types.String) (time.Duration, error) {
+	if v.IsNull() {
+		return 0, nil
+	}
+
+	interval, err := time.ParseDuration(v.ValueString())
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", v.ValueString(), err)
+	}
+	if interval <= 0 {
+		return 0, errors.New("duration must be positive")
+	}
+	return interval, nil
+}
+
+// digestValues returns a hex-encoded SHA-256 digest over all keys and values,
+// independent of map iteration order.
+func digestValues(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(values[key]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// save stores the renew state in private data.
+func (s *renewState) save(ctx context.Context, private privateState) diag.Diagnostics {
+	data, _ := json.Marshal(s) //nolint:errcheck // a struct of strings and integers always marshals
+	return private.SetKey(ctx, renewStateKey, data)
+}
+
+// loadRenewState reads the renew state from private data. It returns nil if none was stored.
+func loadRenewState(ctx context.Context, private privateState) (*renewState, diag.Diagnostics) {
+	data, diags := private.GetKey(ctx, renewStateKey)
+	if diags.HasError() || data == nil {
+		return nil, diags
+	}
+
+	var s renewState
+	if err := json.Unmarshal(data, &s); err != nil {
+		diags.AddError("Failed to decode renew state", codedDetail(CodeInternal, err.Error()))
+		return nil, diags
+	}
+	return &s, diags
+}
+
+// openRenewState records what Renew needs in private data and schedules the first renewal.
+// It does nothing if renewal is disabled (interval is zero).
+func openRenewState(ctx context.Context, resp *ephemeral.OpenResponse, state *renewState) {
+	if state.Interval == 0 {
+		return
+	}
+	resp.Diagnostics.Append(state.save(ctx, resp.Private)...)
+	resp.RenewAt = time.Now().Add(state.Interval)
+}
+
+// renewSecrets re-reads secrets via read and compares them to the digest recorded at Open.
+// The read is bounded by the open timeout recorded at Open.
+// Ephemeral results cannot be replaced once opened, so a change is reported as a warning
+// telling the user that the rest of the operation still uses the previous value.
+// Read failures are reported as warnings as well, since the opened value remains usable.
+func renewSecrets(ctx context.Context, req ephemeral.RenewRequest, resp *ephemeral.RenewResponse,
+	read func(ctx context.Context, state *renewState) (map[string]string, error),
+) {
+	state, diags := loadRenewState(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	if state == nil {
+		return
+	}
+
+	tflog.Debug(ctx, "Renewing ephemeral gopass secrets", map[string]interface{}{
+		"path": state.Path,
+	})
+
+	readCtx, cancel := context.WithTimeout(ctx, state.Timeout)
+	defer cancel()
+
+	values, err := read(readCtx, state)
+	switch {
+	case err != nil:
+		resp.Diagnostics.AddWarning(
+			"Failed to renew secret",
+			errorDetail(err, fmt.Sprintf("Could not re-read secrets at path %q: %s", state.Path, err.Error())),
+		)
+	case digestValues(values) != state.Digest:
+		resp.Diagnostics.AddWarning(
+			"Secret changed during operation",
+			codedDetail(CodeDrift, fmt.Sprintf("The secrets at path %q were modified after they were opened. "+
+				"The remainder of this operation continues to use the previously read values; "+
+				"re-run the operation to pick up the new values.", state.Path)),
+		)
+	}
+
+	resp.RenewAt = time.Now().Add(state.Interval)
+}
@@ -0,0 +1,384 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// withPrivateData initializes the Private field of a request or response the way the
+// framework server does. The field type is internal to the framework, so it is
+// created via reflection.
+func withPrivateData[T any](resp *T) *T {
+	field := reflect.ValueOf(resp).Elem().FieldByName("Private")
+	field.Set(reflect.New(field.Type().Elem()))
+	return resp
+}
+
+// rawPrivateState is a privateState serving fixed bytes.
+type rawPrivateState []byte
+
+func (p rawPrivateState) GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics) {
+	return p, nil
+}
+
+func (p rawPrivateState) SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics {
+	return nil
+}
+
+func TestParseRenewInterval(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   types.String
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "null", value: types.StringNull(), want: 0},
+		{name: "valid", value: types.StringValue("10m"), want: 10 * time.Minute},
+		{name: "invalid", value: types.StringValue("soon"), wantErr: true},
+		{name: "zero", value: types.StringValue("0s"), wantErr: true},
+		{name: "negative", value: types.StringValue("-1m"), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseRenewInterval(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseRenewInterval(%v) error = %v, wantErr %v", tc.value, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDigestValues(t *testing.T) {
+	a := digestValues(map[string]string{"A": "1", "B": "2"})
+	b := digestValues(map[string]string{"B": "2", "A": "1"})
+	if a != b {
+		t.Error("expected digest to be independent of map order")
+	}
+	if a == digestValues(map[string]string{"A": "1", "B": "3"}) {
+		t.Error("expected digest to change when a value changes")
+	}
+	if digestValues(map[string]string{"AB": ""}) == digestValues(map[string]string{"A": "B"}) {
+		t.Error("expected keys and values to be separated in the digest")
+	}
+}
+
+func TestLoadRenewState_InvalidJSON(t *testing.T) {
+	_, diags := loadRenewState(context.Background(), rawPrivateState("[]"))
+	if !diags.HasError() {
+		t.Error("expected error for malformed renew state")
+	}
+}
+
+func openSecretWithRenew(t *testing.T, r *SecretEphemeralResource, interval string) *ephemeral.OpenResponse {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	req := ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":           tftypes.NewValue(tftypes.String, "test/secret"),
+				"renew_interval": tftypes.NewValue(tftypes.String, interval),
+			}),
+		},
+	}
+	resp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{
+			Schema: schemaResp.Schema,
+			Raw:    schemaNullValue(schemaResp.Schema),
+		},
+	})
+
+	r.Open(ctx, req, resp)
+	return resp
+}
+
+func TestSecretEphemeralResource_Renew(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["test/secret"] = newMockSecret("v1")
+	client := NewGopassClient("")
+	client.store = mockStore
+	// Renewals decrypt again, so the cache must not hide a rotation
+	client.EnableCache()
+	r := &SecretEphemeralResource{client: client}
+	ctx := context.Background()
+
+	openResp := openSecretWithRenew(t, r, "5m")
+	if openResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", openResp.Diagnostics)
+	}
+	if openResp.RenewAt.IsZero() {
+		t.Fatal("expected RenewAt to be set")
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		resp := &ephemeral.RenewResponse{}
+		r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+		if len(resp.Diagnostics) != 0 {
+			t.Errorf("expected no diagnostics, got %v", resp.Diagnostics)
+		}
+		if resp.RenewAt.IsZero() {
+			t.Error("expected next RenewAt to be set")
+		}
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		mockStore.secrets["test/secret"] = newMockSecret("v2")
+		resp := &ephemeral.RenewResponse{}
+		r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+		if resp.Diagnostics.WarningsCount() != 1 || resp.Diagnostics[0].Summary() != "Secret changed during operation" {
+			t.Errorf("expected rotation warning, got %v", resp.Diagnostics)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		delete(mockStore.secrets, "test/secret")
+		resp := &ephemeral.RenewResponse{}
+		r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Errorf("expected read failures to be warnings, got %v", resp.Diagnostics)
+		}
+		if resp.Diagnostics.WarningsCount() != 1 || resp.Diagnostics[0].Summary() != "Failed to renew secret" {
+			t.Errorf("expected renew failure warning, got %v", resp.Diagnostics)
+		}
+	})
+}
+
+func TestSecretEphemeralResource_Renew_NoState(t *testing.T) {
+	r := &SecretEphemeralResource{client: NewGopassClient("")}
+	resp := &ephemeral.RenewResponse{}
+
+	r.Renew(context.Background(), ephemeral.RenewRequest{}, resp)
+
+	if len(resp.Diagnostics) != 0 || !resp.RenewAt.IsZero() {
+		t.Errorf("expected renew without state to be a no-op, got %v", resp)
+	}
+}
+
+func TestSecretEphemeralResource_Open_InvalidRenewInterval(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["test/secret"] = newMockSecret("v1")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretEphemeralResource{client: client}
+
+	resp := openSecretWithRenew(t, r, "often")
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics[0].Summary() != "Invalid renew_interval" {
+		t.Errorf("expected invalid renew_interval error, got %v", resp.Diagnostics)
+	}
+}
+
+func TestEnvEphemeralResource_Renew(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["env/test/KEY"] = newMockSecret("v1")
+	client := NewGopassClient("")
+	client.store = mockStore
+	client.EnableCache()
+	r := &EnvEphemeralResource{client: client}
+	ctx := context.Background()
+
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+	openReq := ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":           tftypes.NewValue(tftypes.String, "env/test"),
+				"renew_interval": tftypes.NewValue(tftypes.String, "1m"),
+				"key_prefix":     tftypes.NewValue(tftypes.String, "TF_VAR_"),
+			}),
+		},
+	}
+	openResp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, openReq, openResp)
+	if openResp.Diagnostics.HasError() || openResp.RenewAt.IsZero() {
+		t.Fatalf("expected successful open with renewal, got %v", openResp.Diagnostics)
+	}
+
+	// Key transformation must not affect change detection
+	resp := &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if len(resp.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", resp.Diagnostics)
+	}
+
+	// Renewals decrypt again, so the cache must not hide a rotation
+	mockStore.secrets["env/test/KEY"] = newMockSecret("v2")
+	resp = &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected change warning after a secret was rotated, got %v", resp.Diagnostics)
+	}
+
+	mockStore.secrets["env/test/NEW_KEY"] = newMockSecret("added")
+	resp = &ephemeral.RenewResponse{}
+	r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected change warning after a secret was added, got %v", resp.Diagnostics)
+	}
+}
+
+func TestEnvEphemeralResource_Open_InvalidRenewInterval(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	r := &EnvEphemeralResource{client: client}
+
+	resp, _ := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"path":           tftypes.NewValue(tftypes.String, "env/test"),
+		"renew_interval": tftypes.NewValue(tftypes.String, "0s"),
+	})
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics[0].Summary() != "Invalid renew_interval" {
+		t.Errorf("expected invalid renew_interval error, got %v", resp.Diagnostics)
+	}
+}
+
+func TestEphemeralResources_Close(t *testing.T) {
+	ctx := context.Background()
+	secretResp := &ephemeral.CloseResponse{}
+	(&SecretEphemeralResource{}).Close(ctx, ephemeral.CloseRequest{}, secretResp)
+	envResp := &ephemeral.CloseResponse{}
+	(&EnvEphemeralResource{}).Close(ctx, ephemeral.CloseRequest{}, envResp)
+
+	if secretResp.Diagnostics.HasError() || envResp.Diagnostics.HasError() {
+		t.Error("expected Close to succeed")
+	}
+}
+
+func TestEphemeralResources_Close_ReleasesCache(t *testing.T) {
+	ctx := context.Background()
+	mockStore := storeWith(map[string]string{"test/secret": "v1", "env/test/KEY": "v2", "other/KEY": "v3"})
+	client := NewGopassClient("")
+	client.store = mockStore
+	client.EnableCache()
+	cached := func(path string) bool {
+		_, _, ok := client.cache.get(secretCacheKey{path: path, revision: "latest"})
+		return ok
+	}
+
+	secret := &SecretEphemeralResource{client: client}
+	secretOpen := openSecretWithRenew(t, secret, "5m")
+	env := &EnvEphemeralResource{client: client}
+	schemaResp := &ephemeral.SchemaResponse{}
+	env.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+	envOpen := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	env.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path": tftypes.NewValue(tftypes.String, "env/test"),
+		})},
+	}, envOpen)
+	if _, err := client.GetSecret(ctx, "other/KEY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secretOpen.Diagnostics.HasError() || envOpen.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v %v", secretOpen.Diagnostics, envOpen.Diagnostics)
+	}
+	if !cached("test/secret") || !cached("env/test/KEY") {
+		t.Fatal("expected the secrets read by Open to be cached")
+	}
+
+	resp := &ephemeral.CloseResponse{}
+	secret.Close(ctx, ephemeral.CloseRequest{Private: secretOpen.Private}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if cached("test/secret") || !cached("env/test/KEY") {
+		t.Error("expected Close of the secret to release only its own secret")
+	}
+
+	resp = &ephemeral.CloseResponse{}
+	env.Close(ctx, ephemeral.CloseRequest{Private: envOpen.Private}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if cached("env/test/KEY") || !cached("other/KEY") {
+		t.Error("expected Close of the env to release only the secrets below its path")
+	}
+}
+
+func TestEphemeralResources_Close_State(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not recorded without cache", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = storeWith(map[string]string{"test/secret": "v1"})
+		resp := openSecretWithRenew(t, &SecretEphemeralResource{client: client}, "5m")
+		if data, _ := resp.Private.GetKey(ctx, closeStateKey); data != nil {
+			t.Errorf("expected no close state without a cache, got %s", data)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		req := withPrivateData(&ephemeral.CloseRequest{})
+		req.Private.SetKey(ctx, closeStateKey, []byte(`{"paths": 1}`))
+		resp := &ephemeral.CloseResponse{}
+		(&EnvEphemeralResource{client: NewGopassClient("")}).Close(ctx, *req, resp)
+		if !resp.Diagnostics.HasError() || resp.Diagnostics[0].Summary() != "Failed to decode close state" {
+			t.Errorf("expected decode error, got %v", resp.Diagnostics)
+		}
+	})
+}
+
+func TestGopassClient_ReleaseCached(t *testing.T) {
+	mountStores(t, "work")
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{
+		"app/db": "a", "app/db.part1": "b", "app/dbx": "c", "env/app/KEY": "d", "env/application": "e", "work/db": "f",
+	})
+	client.EnableCache()
+	for _, p := range []string{"app/db", "app/db.part1", "app/dbx", "env/app/KEY", "env/application", "work/db"} {
+		if _, err := client.GetSecret(ctx, p); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The buffers of the cache, to check which are wiped
+	buffers := make(map[string][]byte)
+	for key, content := range client.cache.entries {
+		buffers[key.path] = content
+	}
+
+	if released := client.ReleaseCached(ctx, []string{"app/db", "work:db"}, []string{"env/app/"}); released != 4 {
+		t.Errorf("expected 4 secrets to be released, got %d", released)
+	}
+	for _, p := range []string{"app/db", "app/db.part1", "env/app/KEY", "work/db"} {
+		if !isWiped(buffers[p]) {
+			t.Errorf("expected the released %s to be wiped, got %q", p, buffers[p])
+		}
+	}
+	for _, p := range []string{"app/dbx", "env/application"} {
+		if _, _, ok := client.cache.get(secretCacheKey{path: p, revision: "latest"}); !ok {
+			t.Errorf("expected %s to stay cached", p)
+		}
+		if isWiped(buffers[p]) {
+			t.Errorf("expected the kept %s not to be wiped", p)
+		}
+	}
+	if released := NewGopassClient("").ReleaseCached(ctx, []string{"app/db"}, nil); released != 0 {
+		t.Errorf("expected nothing to be released without a cache, got %d", released)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestSecretEphemeralResource_Open_Snapshot(t *testing.T) {
+	r := &SecretEphemeralResource{}
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["test/secret"] = newMockSecret("tagged-password")
+	client := NewGopassClient("")
+	client.store = store
+	client.readsRevisions = true
+	r.client = client
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	req := ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":     tftypes.NewValue(tftypes.String, "test/secret"),
+				"snapshot": tftypes.NewValue(tftypes.String, "release-1.0"),
+			}),
+		},
+	}
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{
+			Schema: schemaResp.Schema,
+			Raw:    schemaNullValue(schemaResp.Schema),
+		},
+	}
+
+	r.Open(ctx, req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var result SecretModel
+	resp.Diagnostics.Append(resp.Result.Get(ctx, &result)...)
+	if result.Value.ValueString() != "tagged-password" {
+		t.Errorf("expected 'tagged-password', got %q", result.Value.ValueString())
+	}
+	if len(store.requested) != 1 || store.requested[0] != "release-1.0" {
+		t.Errorf("expected snapshot 'release-1.0' to be requested, got %v", store.requested)
+	}
+}
+
+func TestEnvEphemeralResource_Open_Snapshot(t *testing.T) {
+	r := &EnvEphemeralResource{}
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["env/test/KEY1"] = newMockSecret("value1")
+	client := NewGopassClient("/store")
+	client.store = store
+	client.readsRevisions = true
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("env/test/KEY1.gpg\nenv/test/OLD_KEY.gpg\n", nil, &gotDir, &gotArgs)
+	store.secrets["env/test/OLD_KEY"] = newMockSecret("old")
+	r.client = client
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	req := ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":     tftypes.NewValue(tftypes.String, "env/test"),
+				"snapshot": tftypes.NewValue(tftypes.String, "v2"),
+			}),
+		},
+	}
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{
+			Schema: schemaResp.Schema,
+			Raw:    schemaNullValue(schemaResp.Schema),
+		},
+	}
+
+	r.Open(ctx, req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	if len(store.requested) != 2 {
+		t.Fatalf("expected 2 reads, got %v", store.requested)
+	}
+	for _, rev := range store.requested {
+		if rev != "v2" {
+			t.Errorf("expected all reads at snapshot 'v2', got %q", rev)
+		}
+	}
+}
+
+func TestSecretEphemeralResource_Open_Revision(t *testing.T) {
+	tests := []struct {
+		name      string
+		revision  string
+		library   bool
+		wantAt    string
+		wantError string
+	}{
+		{name: "hash", revision: "c1", wantAt: "c1"},
+		{name: "relative", revision: "-1", wantAt: "c1"},
+		{name: "out of range", revision: "-2", wantError: "Could not resolve revision \"-2\" of secret at path \"test/secret\""},
+		// The library would return the latest value instead of the one at the revision
+		{name: "hash in library mode", revision: "c1", library: true, wantError: `set mode = "cli"`},
+		{name: "relative in library mode", revision: "-1", library: true, wantError: `set mode = "cli"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretEphemeralResource{}
+			store := &revisionRecordingStore{mockStore: newMockStore()}
+			store.secrets["test/secret"] = newMockSecret("old-password")
+			store.revisions["test/secret"] = []string{"c2", "c1"}
+			client := NewGopassClient("")
+			client.store = store
+			client.readsRevisions = !tc.library
+			r.client = client
+
+			ctx := context.Background()
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+			req := ephemeral.OpenRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"path":     tftypes.NewValue(tftypes.String, "test/secret"),
+						"revision": tftypes.NewValue(tftypes.String, tc.revision),
+					}),
+				},
+			}
+			resp := &ephemeral.OpenResponse{
+				Result: tfsdk.EphemeralResultData{
+					Schema: schemaResp.Schema,
+					Raw:    schemaNullValue(schemaResp.Schema),
+				},
+			}
+
+			r.Open(ctx, req, resp)
+
+			if tc.wantError != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantError) {
+					t.Fatalf("expected error %q, got %v", tc.wantError, resp.Diagnostics)
+				}
+				if len(store.requested) != 0 {
+					t.Errorf("expected no secret to be read, got %v", store.requested)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if len(store.requested) != 1 || store.requested[0] != tc.wantAt {
+				t.Errorf("expected revision %q to be requested, got %v", tc.wantAt, store.requested)
+			}
+		})
+	}
+}
+
+func TestSecretEphemeralResource_ValidateConfig_Revision(t *testing.T) {
+	r := &SecretEphemeralResource{}
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name     string
+		snapshot any
+		revision any
+		wantErr  string
+	}{
+		{name: "no revision"},
+		{name: "unknown revision", revision: tftypes.UnknownValue},
+		{name: "hash", revision: "3f2a9c1"},
+		{name: "relative", revision: "-1"},
+		{name: "invalid relative", revision: "-0", wantErr: "Invalid revision"},
+		{name: "with snapshot", snapshot: "release-1.0", revision: "-1", wantErr: "Conflicting snapshot and revision"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":     tftypes.NewValue(tftypes.String, "test/secret"),
+				"snapshot": tftypes.NewValue(tftypes.String, tc.snapshot),
+				"revision": tftypes.NewValue(tftypes.String, tc.revision),
+			})
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// storeAttribute returns the schema of the store attribute of ephemeral resources, which
+// picks the mounted store their paths are read from.
+func storeAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "Name of a mounted store (see `gopass mounts`) to read from. Paths are then relative to it, " +
+			"like the 'store:path' form. Defaults to the root store.",
+		MarkdownDescription: "Name of a mounted store (see `gopass mounts`) to read from. Paths are then relative to it, " +
+			"like the `store:path` form. Defaults to the root store.",
+		Optional: true,
+	}
+}
+
+// validateStore adds an error to diags if store is not a mount name, or if one of paths
+// already names a mount itself.
+func validateStore(diags *diag.Diagnostics, store types.String, paths []string) {
+	if store.IsNull() || store.IsUnknown() {
+		return
+	}
+	name := store.ValueString()
+	if name == "" || strings.ContainsAny(name, "/"+mountSeparator) {
+		diags.AddAttributeError(
+			path.Root("store"),
+			"Invalid store",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("store must be the name of a mount, without %q or %q, got %q.", "/", mountSeparator, name)),
+		)
+		return
+	}
+	for _, p := range paths {
+		if _, _, ok := splitMountPath(p); ok {
+			diags.AddAttributeError(
+				path.Root("store"),
+				"Conflicting store and path",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("The path %q names a mount already. Remove the mount from the path, or store.", p)),
+			)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// mountWork mounts a store as "work" in a gopass config of its own for the test.
+func mountWork(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	if err := NewGopassClient("").SetConfig(context.Background(), configScopeGlobal, "mounts.work.path", "/srv/work"); err != nil {
+		t.Fatalf("SetConfig() error: %v", err)
+	}
+}
+
+func TestEphemeralResources_ValidateConfig_Store(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   any
+		path    any
+		paths   []string
+		wantErr string
+	}{
+		{name: "mount", store: "work", path: "ci/token"},
+		{name: "unset", path: "work:ci/token"},
+		{name: "unknown store", store: tftypes.UnknownValue, path: "ci/token"},
+		{name: "unknown path", store: "work", path: tftypes.UnknownValue},
+		{name: "empty store", store: "", path: "ci/token", wantErr: "Invalid store"},
+		{name: "nested store", store: "work/team", path: "ci/token", wantErr: "Invalid store"},
+		{name: "store with separator", store: "work:", path: "ci/token", wantErr: "Invalid store"},
+		{name: "path with mount", store: "work", path: "team:ci/token", wantErr: "Conflicting store and path"},
+		{name: "paths with mount", store: "work", paths: []string{"env/common", "team:env/app"}, wantErr: "Conflicting store and path"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			values := map[string]tftypes.Value{
+				"store": tftypes.NewValue(tftypes.String, tc.store),
+				"path":  tftypes.NewValue(tftypes.String, tc.path),
+			}
+
+			secret := &SecretEphemeralResource{}
+			secretSchema := &ephemeral.SchemaResponse{}
+			secret.Schema(ctx, ephemeral.SchemaRequest{}, secretSchema)
+			env := &EnvEphemeralResource{}
+			envSchema := &ephemeral.SchemaResponse{}
+			env.Schema(ctx, ephemeral.SchemaRequest{}, envSchema)
+
+			var responses []*ephemeral.ValidateConfigResponse
+			if tc.paths == nil {
+				resp := &ephemeral.ValidateConfigResponse{}
+				secret.ValidateConfig(ctx, ephemeral.ValidateConfigRequest{
+					Config: tfsdk.Config{Schema: secretSchema.Schema, Raw: schemaObjectValue(secretSchema.Schema, values)},
+				}, resp)
+				responses = append(responses, resp)
+			} else {
+				delete(values, "path")
+				values["paths"] = pathsValue(tc.paths...)
+			}
+			resp := &ephemeral.ValidateConfigResponse{}
+			env.ValidateConfig(ctx, ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: envSchema.Schema, Raw: schemaObjectValue(envSchema.Schema, values)},
+			}, resp)
+			responses = append(responses, resp)
+
+			for _, resp := range responses {
+				if tc.wantErr == "" {
+					if resp.Diagnostics.HasError() {
+						t.Errorf("unexpected error: %v", resp.Diagnostics)
+					}
+					continue
+				}
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Errorf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretEphemeralResource_Open_Store(t *testing.T) {
+	tests := []struct {
+		name    string
+		store   string
+		want    string
+		wantErr bool
+	}{
+		{name: "mounted store", store: "work", want: "work-token"},
+		{name: "root store", want: "root-token"},
+		{name: "not mounted", store: "team", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mountWork(t)
+			ctx := context.Background()
+			client := NewGopassClient("")
+			client.store = storeWith(map[string]string{"ci/token": "root-token", "work/ci/token": "work-token"})
+			r := &SecretEphemeralResource{client: client}
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+			store := tftypes.NewValue(tftypes.String, nil)
+			if tc.store != "" {
+				store = tftypes.NewValue(tftypes.String, tc.store)
+			}
+			resp := &ephemeral.OpenResponse{
+				Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.Open(ctx, ephemeral.OpenRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"path":  tftypes.NewValue(tftypes.String, "ci/token"),
+					"store": store,
+				})},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			var result SecretModel
+			resp.Result.Get(ctx, &result)
+			if result.Value.ValueString() != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, result.Value.ValueString())
+			}
+		})
+	}
+}
+
+func TestEnvEphemeralResource_Open_Store(t *testing.T) {
+	mountWork(t)
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{
+		"env/app/API_TOKEN":      "root",
+		"work/env/common/REGION": "fr-par",
+		"work/env/app/API_TOKEN": "work",
+	})
+	r := &EnvEphemeralResource{client: client}
+
+	resp, result := openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"store": tftypes.NewValue(tftypes.String, "work"),
+		"paths": pathsValue("env/common", "env/app"),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	flat := result.ValuesFlat.Elements()
+	if v, ok := flat["API_TOKEN"].(types.String); !ok || v.ValueString() != "work" || len(flat) != 2 {
+		t.Errorf("expected the secrets of the mounted store, got %v", flat)
+	}
+
+	resp, _ = openEnvWithConfig(t, r, map[string]tftypes.Value{
+		"store": tftypes.NewValue(tftypes.String, "team"),
+		"path":  tftypes.NewValue(tftypes.String, "env/app"),
+	})
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected an error for a store that is not mounted")
+	}
+}
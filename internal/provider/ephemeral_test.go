@@ -5,6 +5,7 @@ package provider
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/gopasspw/gopass/pkg/gopass/secrets"
@@ -131,23 +132,13 @@ func TestSecretEphemeralResource_Open(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":  tftypes.String,
-			"value": tftypes.String,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":  tftypes.NewValue(tftypes.String, "test/secret"),
 		"value": tftypes.NewValue(tftypes.String, nil),
 	})
 
 	// Initialize Result properly with the schema
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":  tftypes.String,
-			"value": tftypes.String,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -191,12 +182,7 @@ func TestSecretEphemeralResource_Open_ConfigGetError(t *testing.T) {
 		"value": tftypes.NewValue(tftypes.String, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":  tftypes.String,
-			"value": tftypes.String,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -231,22 +217,12 @@ func TestSecretEphemeralResource_Open_NotFound(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":  tftypes.String,
-			"value": tftypes.String,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":  tftypes.NewValue(tftypes.String, "nonexistent"),
 		"value": tftypes.NewValue(tftypes.String, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":  tftypes.String,
-			"value": tftypes.String,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -268,6 +244,107 @@ func TestSecretEphemeralResource_Open_NotFound(t *testing.T) {
 	}
 }
 
+func TestSecretEphemeralResource_Open_Key(t *testing.T) {
+	mockStore := newMockStore()
+	secret := newMockSecret("test-password")
+	secret.fields["username"] = "admin"
+	mockStore.secrets["test/secret"] = secret
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretEphemeralResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	for _, tc := range []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "username", want: "admin"},
+		{key: "url", wantErr: true},
+	} {
+		key, want := tc.key, tc.want
+		req := ephemeral.OpenRequest{
+			Config: tfsdk.Config{
+				Schema: schemaResp.Schema,
+				Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"path": tftypes.NewValue(tftypes.String, "test/secret"),
+					"key":  tftypes.NewValue(tftypes.String, key),
+				}),
+			},
+		}
+		resp := &ephemeral.OpenResponse{
+			Result: tfsdk.EphemeralResultData{
+				Schema: schemaResp.Schema,
+				Raw:    schemaNullValue(schemaResp.Schema),
+			},
+		}
+
+		r.Open(ctx, req, resp)
+
+		if tc.wantErr {
+			if !resp.Diagnostics.HasError() {
+				t.Errorf("%s: expected error for missing field", key)
+			}
+			continue
+		}
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("%s: unexpected error: %v", key, resp.Diagnostics)
+		}
+		var result SecretModel
+		resp.Result.Get(ctx, &result)
+		if result.Value.ValueString() != want {
+			t.Errorf("%s: expected %q, got %q", key, want, result.Value.ValueString())
+		}
+	}
+}
+
+func TestSecretEphemeralResource_Open_Fields(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["test/secret"] = secrets.ParseAKV([]byte("test-password\nusername: admin\nsome notes\n"))
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretEphemeralResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	req := ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, "test/secret"),
+				"key":  tftypes.NewValue(tftypes.String, "username"),
+			}),
+		},
+	}
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{
+			Schema: schemaResp.Schema,
+			Raw:    schemaNullValue(schemaResp.Schema),
+		},
+	}
+
+	r.Open(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var result SecretModel
+	resp.Result.Get(ctx, &result)
+	if result.Value.ValueString() != "admin" || result.Password.ValueString() != "test-password" || result.Body.ValueString() != "some notes\n" {
+		t.Errorf("unexpected value %q, password %q, body %q", result.Value.ValueString(), result.Password.ValueString(), result.Body.ValueString())
+	}
+	fields := map[string]string{}
+	result.Fields.ElementsAs(ctx, &fields, false)
+	if !reflect.DeepEqual(fields, map[string]string{"username": "admin"}) {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
 // ============ EnvEphemeralResource Tests ============
 
 func TestEnvEphemeralResource_NewEnvEphemeralResource(t *testing.T) {
@@ -303,10 +380,10 @@ func TestEnvEphemeralResource_Schema(t *testing.T) {
 		t.Error("expected 'credentials' attribute in schema")
 	}
 
-	// Verify path is required
+	// Verify path is optional, as paths can be used instead
 	pathAttr := resp.Schema.Attributes["path"]
-	if !pathAttr.IsRequired() {
-		t.Error("expected 'path' to be required")
+	if !pathAttr.IsOptional() {
+		t.Error("expected 'path' to be optional")
 	}
 
 	// Verify credentials is computed and sensitive
@@ -390,22 +467,12 @@ func TestEnvEphemeralResource_Open(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":        tftypes.NewValue(tftypes.String, "env/test"),
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -439,22 +506,12 @@ func TestEnvEphemeralResource_Open_Empty(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":        tftypes.NewValue(tftypes.String, "empty/path"),
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -496,22 +553,12 @@ func TestEnvEphemeralResource_Open_GetEnvSecretsError(t *testing.T) {
 	schemaResp := &ephemeral.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"path":        tftypes.NewValue(tftypes.String, "env/test"),
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -556,12 +603,7 @@ func TestEnvEphemeralResource_Open_ConfigGetError(t *testing.T) {
 		"credentials": tftypes.NewValue(tftypes.DynamicPseudoType, nil),
 	})
 
-	resultRaw := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":        tftypes.String,
-			"credentials": tftypes.DynamicPseudoType,
-		},
-	}, nil)
+	resultRaw := schemaNullValue(schemaResp.Schema)
 
 	req := ephemeral.OpenRequest{
 		Config: tfsdk.Config{
@@ -0,0 +1,77 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ function.Function = &ExistsFunction{}
+
+// ExistsFunction implements provider::gopass::exists, reporting whether a secret exists
+// without returning anything of it.
+type ExistsFunction struct {
+	client *GopassClient
+}
+
+// NewExistsFunction creates a new instance.
+func NewExistsFunction() function.Function {
+	return &ExistsFunction{client: functionClient()}
+}
+
+func (f *ExistsFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "exists"
+}
+
+func (f *ExistsFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Check whether a secret exists",
+		Description: "Returns true if a secret is stored at path, e.g. for conditionals and preconditions. " +
+			"The value is never returned, so the result can end up in plan and state safely. " +
+			"Functions cannot see the provider configuration, so the store comes from the gopass configuration " +
+			"or PASSWORD_STORE_DIR.",
+		MarkdownDescription: "Returns `true` if a secret is stored at `path`, e.g. for conditionals and preconditions. " +
+			"The value is never returned, so the result can end up in plan and state safely. " +
+			"Functions cannot see the provider configuration, so the store comes from the gopass configuration " +
+			"or `PASSWORD_STORE_DIR`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "path",
+				Description:         "Path of the secret in the gopass store, e.g. 'app/db'.",
+				MarkdownDescription: "Path of the secret in the gopass store, e.g. `app/db`.",
+			},
+		},
+		Return: function.BoolReturn{},
+	}
+}
+
+func (f *ExistsFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var secretPath string
+
+	resp.Error = req.Arguments.Get(ctx, &secretPath)
+	if resp.Error != nil {
+		return
+	}
+	if err := validateSecretPath(secretPath); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, codedDetail(CodeInvalidConfig, fmt.Sprintf("Invalid path %q: %s", secretPath, err.Error())))
+		return
+	}
+
+	tflog.Debug(ctx, "Checking secret for provider function exists", map[string]interface{}{
+		"path": secretPath,
+	})
+
+	exists, err := f.client.SecretExists(ctx, secretPath)
+	if err != nil {
+		resp.Error = function.NewFuncError(errorDetail(err, fmt.Sprintf("Could not check secret %q: %s", secretPath, err.Error())))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, exists)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestExistsFunction_Metadata(t *testing.T) {
+	f := NewExistsFunction()
+	if f.(*ExistsFunction).client != functionClient() {
+		t.Error("expected functions to share a client")
+	}
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+	if resp.Name != "exists" {
+		t.Errorf("expected name exists, got %q", resp.Name)
+	}
+
+	defResp := &function.DefinitionResponse{}
+	f.Definition(context.Background(), function.DefinitionRequest{}, defResp)
+	if len(defResp.Definition.Parameters) != 1 || defResp.Definition.Return == nil {
+		t.Errorf("unexpected definition %+v", defResp.Definition)
+	}
+}
+
+func TestExistsFunction_Run(t *testing.T) {
+	mountStores(t, "app")
+	tests := []struct {
+		name    string
+		arg     attr.Value
+		fail    string
+		want    bool
+		wantErr string
+	}{
+		{name: "exists", arg: types.StringValue("app/db"), want: true},
+		{name: "mount", arg: types.StringValue("app:db"), want: true},
+		{name: "missing", arg: types.StringValue("app/api"), want: false},
+		{name: "folder", arg: types.StringValue("app"), want: false},
+		{name: "invalid path", arg: types.StringValue("app//db"), wantErr: `[GOPASS_INVALID_CONFIG] Invalid path "app//db"`},
+		{name: "wrong argument", arg: types.BoolValue(true), wantErr: "Value Conversion Error"},
+		{name: "read fails", arg: types.StringValue("app/db"), fail: "get", wantErr: `Could not check secret "app/db"`},
+		{name: "store missing", arg: types.StringValue("app/db"), fail: "store", wantErr: "[GOPASS_STORE_NOT_FOUND]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStoreWithSelectiveFailure()
+			store.secrets["app/db"] = newMockSecret("hunter2")
+			client := NewGopassClient("")
+			client.store = store
+			switch tc.fail {
+			case "get":
+				store.failOnGet["app/db"] = true
+			case "store":
+				client = NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+			}
+			f := &ExistsFunction{client: client}
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.BoolUnknown())}
+			f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{tc.arg})}, resp)
+
+			if tc.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Error)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %v", resp.Error)
+			}
+			if got := resp.Result.Value().(types.Bool).ValueBool(); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
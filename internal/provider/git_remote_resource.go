@@ -0,0 +1,264 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                = &GitRemoteResource{}
+	_ resource.ResourceWithConfigure   = &GitRemoteResource{}
+	_ resource.ResourceWithImportState = &GitRemoteResource{}
+)
+
+// GitRemoteResource configures a git remote of a store, like `gopass git remote add`.
+type GitRemoteResource struct {
+	client *GopassClient
+}
+
+// GitRemoteResourceModel describes the resource data model.
+type GitRemoteResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	StorePath      types.String `tfsdk:"store_path"`
+	Name           types.String `tfsdk:"name"`
+	URL            types.String `tfsdk:"url"`
+	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
+}
+
+// NewGitRemoteResource creates a new instance.
+func NewGitRemoteResource() resource.Resource {
+	return &GitRemoteResource{}
+}
+
+func (r *GitRemoteResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_git_remote"
+}
+
+func (r *GitRemoteResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Configures a git remote of the store (like `gopass git remote add origin ...`), so it can be synced.",
+		MarkdownDescription: `
+Configures a git remote of the store (like ` + "`gopass git remote add origin ...`" + `), so that a
+freshly provisioned store, e.g. on a CI runner, is fully wired for ` + "`gopass sync`" + `.
+
+The remote URL is verified on every refresh: if it was changed outside of Terraform, the next
+apply points it back at ` + "`url`" + `. An existing remote with the same name is taken over.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_git_remote" "origin" {
+  url = "git@github.com:example/password-store.git"
+}
+
+# Wire a store created by gopass_store_init
+resource "gopass_git_remote" "ci" {
+  store_path = gopass_store_init.ci.path
+  name       = "upstream"
+  url        = "https://git.example.com/team/store.git"
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The name of the remote (same as name attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"store_path": schema.StringAttribute{
+				Description: "Directory of the store whose git repository is configured. A leading '~/' is expanded. " +
+					"Defaults to the store of the provider.",
+				MarkdownDescription: "Directory of the store whose git repository is configured. A leading `~/` is expanded. " +
+					"Defaults to the store of the provider.",
+				Optional: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Description:         "Name of the remote. Defaults to 'origin', which gopass uses for syncing.",
+				MarkdownDescription: "Name of the remote. Defaults to `origin`, which gopass uses for syncing.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(defaultGitRemote),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"url": schema.StringAttribute{
+				Description: "URL of the remote repository.",
+				Required:    true,
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description:         "Whether to remove the remote from the repository when the resource is destroyed. Defaults to true.",
+				MarkdownDescription: "Whether to remove the remote from the repository when the resource is destroyed. Defaults to `true`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+		},
+	}
+}
+
+func (r *GitRemoteResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *GitRemoteResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data GitRemoteResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	if err := r.client.SetGitRemote(ctx, data.StorePath.ValueString(), name, data.URL.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to configure git remote",
+			errorDetail(err, fmt.Sprintf("Could not configure git remote %q: %s", name, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Configured git remote of gopass store", map[string]interface{}{
+		"remote": name,
+	})
+
+	data.ID = data.Name
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *GitRemoteResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data GitRemoteResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	url, exists, err := r.client.GetGitRemote(ctx, data.StorePath.ValueString(), name)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read git remote",
+			errorDetail(err, fmt.Sprintf("Could not read git remote %q: %s", name, err.Error())),
+		)
+		return
+	}
+
+	if !exists {
+		// The remote was removed outside of Terraform
+		tflog.Warn(ctx, "Git remote no longer exists, removing from state", map[string]interface{}{
+			"remote": name,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.URL = types.StringValue(url)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *GitRemoteResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data GitRemoteResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	if err := r.client.SetGitRemote(ctx, data.StorePath.ValueString(), name, data.URL.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to configure git remote",
+			errorDetail(err, fmt.Sprintf("Could not configure git remote %q: %s", name, err.Error())),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *GitRemoteResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data GitRemoteResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	if !data.DeleteOnRemove.ValueBool() {
+		tflog.Info(ctx, "Keeping git remote (delete_on_remove=false)", map[string]interface{}{
+			"remote": name,
+		})
+		return
+	}
+
+	if err := r.client.RemoveGitRemote(ctx, data.StorePath.ValueString(), name); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove git remote",
+			errorDetail(err, fmt.Sprintf("Could not remove git remote %q: %s", name, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed git remote of gopass store", map[string]interface{}{
+		"remote": name,
+	})
+}
+
+// ImportState imports a remote of the provider's store by name. The URL is read on refresh.
+func (r *GitRemoteResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("delete_on_remove"), true)...)
+}
@@ -0,0 +1,261 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// gitRemoteTestSetup returns a git remote resource of the store at /store whose git
+// commands are answered by fake.
+func gitRemoteTestSetup(t *testing.T, fake *fakeRemotes) (*GitRemoteResource, resource.SchemaResponse) {
+	t.Helper()
+
+	client := NewGopassClient("/store")
+	client.execCommand = fake.run
+	return testResource(&GitRemoteResource{client: client})
+}
+
+func gitRemoteValue(schemaResp resource.SchemaResponse, url string, deleteOnRemove bool) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "origin"),
+		"name":             tftypes.NewValue(tftypes.String, "origin"),
+		"url":              tftypes.NewValue(tftypes.String, url),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, deleteOnRemove),
+	})
+}
+
+func TestGitRemoteResource_Metadata(t *testing.T) {
+	r := NewGitRemoteResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_git_remote" {
+		t.Errorf("expected 'gopass_git_remote', got %q", resp.TypeName)
+	}
+}
+
+func TestGitRemoteResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &GitRemoteResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &GitRemoteResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestGitRemoteResource_Create(t *testing.T) {
+	tests := []struct {
+		name    string
+		failOn  string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "adds remote"},
+		{name: "git fails", failOn: "remote add", wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(nil)
+			fake.failOn = tc.failOn
+			r, schemaResp := gitRemoteTestSetup(t, fake)
+			plan := gitRemoteValue(schemaResp, "git@example.com:store.git", true)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if fake.urls["origin"] != "git@example.com:store.git" {
+				t.Errorf("expected origin to be added, got %v", fake.urls)
+			}
+			var state GitRemoteResourceModel
+			resp.State.Get(context.Background(), &state)
+			if state.ID.ValueString() != "origin" {
+				t.Errorf("expected id 'origin', got %q", state.ID.ValueString())
+			}
+		})
+	}
+}
+
+func TestGitRemoteResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    map[string]string
+		failOn      string
+		wantURL     string
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "unchanged", existing: map[string]string{"origin": "git@example.com:store.git"}, wantURL: "git@example.com:store.git"},
+		{name: "changed externally", existing: map[string]string{"origin": "git@evil.example.com:store.git"}, wantURL: "git@evil.example.com:store.git"},
+		{name: "removed externally", wantRemoved: true},
+		{name: "git fails", failOn: "remote", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(tc.existing)
+			fake.failOn = tc.failOn
+			r, schemaResp := gitRemoteTestSetup(t, fake)
+			raw := gitRemoteValue(schemaResp, "git@example.com:store.git", true)
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.wantRemoved {
+				t.Fatalf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !tc.wantRemoved {
+				var state GitRemoteResourceModel
+				resp.State.Get(context.Background(), &state)
+				if state.URL.ValueString() != tc.wantURL {
+					t.Errorf("expected url %q, got %q", tc.wantURL, state.URL.ValueString())
+				}
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := gitRemoteTestSetup(t, newFakeRemotes(nil))
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestGitRemoteResource_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		failOn  string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "url changed"},
+		{name: "git fails", failOn: "remote set-url", wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(map[string]string{"origin": "git@example.com:store.git"})
+			fake.failOn = tc.failOn
+			r, schemaResp := gitRemoteTestSetup(t, fake)
+			plan := gitRemoteValue(schemaResp, "https://example.com/store.git", true)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: gitRemoteValue(schemaResp, "git@example.com:store.git", true)},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr && fake.urls["origin"] != "https://example.com/store.git" {
+				t.Errorf("expected origin to be updated, got %v", fake.urls)
+			}
+		})
+	}
+}
+
+func TestGitRemoteResource_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteOnRemove bool
+		failOn         string
+		invalid        bool
+		wantRemaining  bool
+		wantErr        bool
+	}{
+		{name: "removes remote", deleteOnRemove: true},
+		{name: "keeps remote", wantRemaining: true},
+		{name: "git fails", deleteOnRemove: true, failOn: "remote remove", wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(map[string]string{"origin": "git@example.com:store.git"})
+			fake.failOn = tc.failOn
+			r, schemaResp := gitRemoteTestSetup(t, fake)
+			state := gitRemoteValue(schemaResp, "git@example.com:store.git", tc.deleteOnRemove)
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if _, remaining := fake.urls["origin"]; remaining != tc.wantRemaining {
+				t.Errorf("expected remaining=%v, got %v", tc.wantRemaining, fake.urls)
+			}
+		})
+	}
+}
+
+func TestGitRemoteResource_ImportState(t *testing.T) {
+	r, schemaResp := gitRemoteTestSetup(t, newFakeRemotes(nil))
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "upstream"}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	var state GitRemoteResourceModel
+	resp.State.Get(context.Background(), &state)
+	if state.Name.ValueString() != "upstream" || state.ID.ValueString() != "upstream" || !state.DeleteOnRemove.ValueBool() {
+		t.Errorf("unexpected imported state %+v", state)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// matchGlob reports whether a slash-separated secret path matches a glob pattern.
+//
+// Patterns use path.Match syntax within a single path segment ("*", "?", "[a-z]").
+// In addition, a "**" segment matches zero or more whole segments, so
+// "prod/**" matches "prod/db" and "prod/api/v2/KEY".
+func matchGlob(pattern, name string) (bool, error) {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try to match the rest of the pattern at every remaining position.
+			for i := 0; i <= len(name); i++ {
+				matched, err := matchSegments(pattern[1:], name[i:])
+				if err != nil || matched {
+					return matched, err
+				}
+			}
+			return false, nil
+		}
+
+		if len(name) == 0 {
+			return false, nil
+		}
+
+		matched, err := path.Match(pattern[0], name[0])
+		if err != nil {
+			return false, fmt.Errorf("invalid glob pattern segment %q: %w", pattern[0], err)
+		}
+		if !matched {
+			return false, nil
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0, nil
+}
+
+// matchAnyGlob reports whether name matches at least one of the patterns.
+func matchAnyGlob(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := matchGlob(pattern, name)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// validateGlob reports an error if pattern is malformed, without matching it against a path.
+func validateGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern segment %q: %w", segment, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "KEY", name: "KEY", want: true},
+		{pattern: "KEY", name: "OTHER", want: false},
+		{pattern: "API_*", name: "API_KEY", want: true},
+		{pattern: "*", name: "a/b", want: false},
+		{pattern: "a/*", name: "a/b", want: true},
+		{pattern: "a/*", name: "a", want: false},
+		{pattern: "**", name: "a/b/c", want: true},
+		{pattern: "a/**", name: "a", want: true},
+		{pattern: "a/**", name: "a/b/c", want: true},
+		{pattern: "**/KEY", name: "x/y/KEY", want: true},
+		{pattern: "**/KEY", name: "KEY", want: true},
+		{pattern: "**/KEY", name: "x/OTHER", want: false},
+		{pattern: "a/**/c", name: "a/b/d", want: false},
+		{pattern: "a/b", name: "a/b/c", want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+"_"+tc.name, func(t *testing.T) {
+			got, err := matchGlob(tc.pattern, tc.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMatchGlob_InvalidPattern(t *testing.T) {
+	if _, err := matchGlob("a/[", "a/b"); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+	if _, err := matchGlob("**/[", "a/b"); err == nil {
+		t.Error("expected error for invalid pattern after **")
+	}
+}
+
+func TestMatchAnyGlob(t *testing.T) {
+	matched, err := matchAnyGlob([]string{"x/*", "a/*"}, "a/b")
+	if err != nil || !matched {
+		t.Errorf("expected match, got %v (err %v)", matched, err)
+	}
+
+	matched, err = matchAnyGlob([]string{"x/*"}, "a/b")
+	if err != nil || matched {
+		t.Errorf("expected no match, got %v (err %v)", matched, err)
+	}
+
+	matched, err = matchAnyGlob(nil, "a/b")
+	if err != nil || matched {
+		t.Errorf("expected no match for empty patterns, got %v (err %v)", matched, err)
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	for pattern, wantErr := range map[string]bool{
+		"prod/**":  false,
+		"*/db":     false,
+		"a/[a-z]?": false,
+		"a/[":      true,
+		"**/[/x":   true,
+		"prod/\\":  true,
+	} {
+		if err := validateGlob(pattern); (err != nil) != wantErr {
+			t.Errorf("validateGlob(%q): expected error = %v, got %v", pattern, wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// globsValidator validates list attributes holding glob patterns of secret paths.
+type globsValidator struct{}
+
+// validGlobs returns a validator rejecting malformed glob patterns at plan time, instead of
+// failing once the patterns are matched against the secrets of the store.
+func validGlobs() validator.List {
+	return globsValidator{}
+}
+
+func (v globsValidator) Description(ctx context.Context) string {
+	return "each pattern must be a valid glob, e.g. 'prod/**' or 'app/*'"
+}
+
+func (v globsValidator) MarkdownDescription(ctx context.Context) string {
+	return "each pattern must be a valid glob, e.g. `prod/**` or `app/*`"
+}
+
+func (v globsValidator) ValidateList(ctx context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	for i, element := range req.ConfigValue.Elements() {
+		pattern, ok := element.(types.String)
+		if !ok || pattern.IsNull() || pattern.IsUnknown() {
+			continue
+		}
+		if err := validateGlob(pattern.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				req.Path.AtListIndex(i),
+				"Invalid glob pattern",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("Pattern %q is not a valid glob: %s.", pattern.ValueString(), err.Error())),
+			)
+		}
+	}
+}
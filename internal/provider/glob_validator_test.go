@@ -0,0 +1,83 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	ephemeralschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestGlobsValidator_ValidateList(t *testing.T) {
+	ctx := context.Background()
+	v := validGlobs()
+
+	list := func(elements ...attr.Value) types.List {
+		return types.ListValueMust(types.StringType, elements)
+	}
+
+	testCases := []struct {
+		name      string
+		value     types.List
+		wantPaths []path.Path
+	}{
+		{name: "valid", value: list(types.StringValue("prod/**"), types.StringValue("app/[a-z]*"))},
+		{
+			name:      "malformed",
+			value:     list(types.StringValue("prod/**"), types.StringValue("app/["), types.StringValue("db/\\")),
+			wantPaths: []path.Path{path.Root("include").AtListIndex(1), path.Root("include").AtListIndex(2)},
+		},
+		{name: "unknown element", value: list(types.StringUnknown())},
+		{name: "null", value: types.ListNull(types.StringType)},
+		{name: "unknown", value: types.ListUnknown(types.StringType)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := validator.ListRequest{Path: path.Root("include"), ConfigValue: tc.value}
+			resp := &validator.ListResponse{}
+			v.ValidateList(ctx, req, resp)
+
+			if resp.Diagnostics.ErrorsCount() != len(tc.wantPaths) {
+				t.Fatalf("expected %d errors, got %v", len(tc.wantPaths), resp.Diagnostics)
+			}
+			for i, d := range resp.Diagnostics.Errors() {
+				withPath, ok := d.(interface{ Path() path.Path })
+				if !ok || !withPath.Path().Equal(tc.wantPaths[i]) {
+					t.Errorf("expected error %d at %s, got %v", i, tc.wantPaths[i], d)
+				}
+			}
+		})
+	}
+
+	if v.Description(ctx) == "" || v.MarkdownDescription(ctx) == "" {
+		t.Error("expected non-empty descriptions")
+	}
+}
+
+func TestEphemeralResources_GlobValidators(t *testing.T) {
+	resources := map[string]ephemeral.EphemeralResource{
+		"gopass_env":               NewEnvEphemeralResource(),
+		"gopass_dotenv":            NewDotenvEphemeralResource(),
+		"gopass_kubernetes_secret": NewKubernetesSecretEphemeralResource(),
+		"gopass_secret_tree":       NewSecretTreeEphemeralResource(),
+	}
+
+	for name, r := range resources {
+		resp := &ephemeral.SchemaResponse{}
+		r.Schema(context.Background(), ephemeral.SchemaRequest{}, resp)
+		for _, attribute := range []string{"include", "exclude"} {
+			list, ok := resp.Schema.Attributes[attribute].(ephemeralschema.ListAttribute)
+			if !ok || len(list.Validators) != 1 {
+				t.Errorf("expected %s.%s to validate its glob patterns", name, attribute)
+			}
+		}
+	}
+}
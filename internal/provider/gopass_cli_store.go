@@ -0,0 +1,207 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// Provider modes selecting how GopassClient talks to the password store.
+const (
+	modeLibrary = "library"
+	modeCLI     = "cli"
+)
+
+// defaultGopassBinary is the gopass executable used in CLI mode.
+const defaultGopassBinary = "gopass"
+
+// cliStore implements gopass.Store by executing the gopass binary.
+// It honors everything the library API does not: on-disk configs, pinentry setups and plugins.
+// Secrets are passed on stdin and stdout only, never as command line arguments.
+type cliStore struct {
+	binary    string
+	extraArgs []string                                                                                           // global flags put before every subcommand
+	env       []string                                                                                           // environment variables added to every command
+	run       func(ctx context.Context, env []string, stdin []byte, name string, args ...string) ([]byte, error) // injectable for testing
+}
+
+var _ gopass.Store = &cliStore{}
+
+// newCLIStore verifies that binary can be executed and returns a store using it.
+// Checking up front keeps a missing binary from being mistaken for a missing secret.
+// A non-empty dir selects the store through PASSWORD_STORE_DIR of the gopass processes only.
+// extraArgs are passed to every command before the subcommand, e.g. --yes.
+func newCLIStore(ctx context.Context, binary, dir string, extraArgs []string,
+	run func(ctx context.Context, env []string, stdin []byte, name string, args ...string) ([]byte, error),
+) (*cliStore, error) {
+	s := &cliStore{binary: binary, extraArgs: extraArgs, run: run}
+	if dir != "" {
+		s.env = []string{"PASSWORD_STORE_DIR=" + dir}
+	}
+	if _, err := s.gopass(ctx, nil, "version"); err != nil {
+		return nil, fmt.Errorf("gopass binary %q is not usable: %w", binary, err)
+	}
+	return s, nil
+}
+
+// runCommandWithInput executes an external command with stdin and returns its standard output.
+// The variables in env are added to the environment of the process.
+// On failure the standard error output is included in the error, so gopass messages like
+// "entry is not in the password store" can be classified.
+func runCommandWithInput(ctx context.Context, env []string, stdin []byte, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(processEnv(), env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// gopass runs a gopass subcommand, after the extra arguments of the provider configuration.
+func (s *cliStore) gopass(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	return s.run(ctx, s.env, stdin, s.binary, append(slices.Clone(s.extraArgs), args...)...)
+}
+
+// String implements gopass.Store.
+func (s *cliStore) String() string {
+	return "gopass-cli"
+}
+
+// List implements gopass.Store.
+func (s *cliStore) List(ctx context.Context) ([]string, error) {
+	out, err := s.gopass(ctx, nil, "ls", "--flat")
+	if err != nil {
+		return nil, fmt.Errorf("gopass ls failed: %w", err)
+	}
+	return nonEmptyLines(out), nil
+}
+
+// Get implements gopass.Store.
+func (s *cliStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	args := []string{"show", "--unsafe", "--noparsing"}
+	if revision != "" && revision != "latest" {
+		args = append(args, "--revision", revision)
+	}
+	out, err := s.gopass(ctx, nil, append(args, "--", name)...)
+	if err != nil {
+		return nil, fmt.Errorf("gopass show failed: %w", err)
+	}
+	// The parsed secret holds copies, so the output of gopass is not kept
+	defer wipe(out)
+	return secrets.ParseAKV(out), nil
+}
+
+// Set implements gopass.Store.
+func (s *cliStore) Set(ctx context.Context, name string, sec gopass.Byter) error {
+	content := sec.Bytes()
+	defer wipe(content)
+	if _, err := s.gopass(ctx, content, "insert", "--force", "--", name); err != nil {
+		return fmt.Errorf("gopass insert failed: %w", err)
+	}
+	return nil
+}
+
+// Revisions implements gopass.Store. Each line of `gopass history` starts with the
+// revision hash, followed by author, date and subject.
+func (s *cliStore) Revisions(ctx context.Context, name string) ([]string, error) {
+	out, err := s.gopass(ctx, nil, "history", "--", name)
+	if err != nil {
+		return nil, fmt.Errorf("gopass history failed: %w", err)
+	}
+	lines := nonEmptyLines(out)
+	revisions := make([]string, 0, len(lines))
+	for _, line := range lines {
+		revisions = append(revisions, strings.Fields(line)[0])
+	}
+	return revisions, nil
+}
+
+// Remove implements gopass.Store.
+func (s *cliStore) Remove(ctx context.Context, name string) error {
+	if _, err := s.gopass(ctx, nil, "rm", "--force", "--", name); err != nil {
+		return fmt.Errorf("gopass rm failed: %w", err)
+	}
+	return nil
+}
+
+// RemoveAll implements gopass.Store.
+func (s *cliStore) RemoveAll(ctx context.Context, prefix string) error {
+	if _, err := s.gopass(ctx, nil, "rm", "--force", "--recursive", "--", prefix); err != nil {
+		return fmt.Errorf("gopass rm failed: %w", err)
+	}
+	return nil
+}
+
+// Rename implements gopass.Store.
+func (s *cliStore) Rename(ctx context.Context, src, dest string) error {
+	if _, err := s.gopass(ctx, nil, "mv", "--force", "--", src, dest); err != nil {
+		return fmt.Errorf("gopass mv failed: %w", err)
+	}
+	return nil
+}
+
+// Sync implements gopass.Store.
+func (s *cliStore) Sync(ctx context.Context) error {
+	if _, err := s.gopass(ctx, nil, "sync"); err != nil {
+		return fmt.Errorf("gopass sync failed: %w", err)
+	}
+	return nil
+}
+
+// Close implements gopass.Store. Every command is a separate process, so there is nothing to release.
+func (s *cliStore) Close(ctx context.Context) error {
+	return nil
+}
+
+// nonEmptyLines splits command output into lines, dropping blank ones.
+func nonEmptyLines(out []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// UseCLI makes the client execute the gopass binary instead of linking the gopass library,
+// passing extraArgs to every command. It must be called before the store is first accessed.
+func (c *GopassClient) UseCLI(binary string, extraArgs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readsRevisions = true
+	c.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		s, err := newCLIStore(ctx, binary, dir, extraArgs, runCommandWithInput)
+		if err != nil {
+			return nil, err
+		}
+		s.env = append(s.env, c.gpgEnv()...)
+		return s, nil
+	}
+}
+
+// validateExtraArgs returns an error if extraArgs cannot be passed to gopass in mode.
+func validateExtraArgs(mode string, extraArgs []string) error {
+	if mode != modeCLI {
+		return fmt.Errorf("extra_args are only passed to the gopass binary, set mode = %q", modeCLI)
+	}
+	for i, arg := range extraArgs {
+		if arg == "" {
+			return fmt.Errorf("argument %d is empty", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// fakeGopass records gopass invocations and answers them from outputs, keyed by subcommand.
+type fakeGopass struct {
+	calls   [][]string
+	env     []string
+	stdin   []byte
+	outputs map[string]string
+	failOn  string
+	// passed and returned are the buffers of the last call, to check that they are wiped
+	passed, returned []byte
+}
+
+func (f *fakeGopass) run(ctx context.Context, env []string, stdin []byte, name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	f.env = env
+	f.stdin = bytes.Clone(stdin)
+	f.passed = stdin
+	if args[0] == f.failOn {
+		return nil, errors.New("exit status 1: entry is not in the password store")
+	}
+	f.returned = []byte(f.outputs[args[0]])
+	return f.returned, nil
+}
+
+func (f *fakeGopass) lastCall() []string {
+	return f.calls[len(f.calls)-1]
+}
+
+func newFakeCLIStore(t *testing.T, f *fakeGopass) *cliStore {
+	t.Helper()
+	s, err := newCLIStore(context.Background(), "gopass", "", nil, f.run)
+	if err != nil {
+		t.Fatalf("newCLIStore() error = %v", err)
+	}
+	return s
+}
+
+func TestNewCLIStore(t *testing.T) {
+	f := &fakeGopass{}
+	s := newFakeCLIStore(t, f)
+	if s.String() != "gopass-cli" {
+		t.Errorf("String() = %q", s.String())
+	}
+	if !reflect.DeepEqual(f.lastCall(), []string{"gopass", "version"}) {
+		t.Errorf("unexpected call %v", f.lastCall())
+	}
+
+	_, err := newCLIStore(context.Background(), "gopass", "", nil, (&fakeGopass{failOn: "version"}).run)
+	if err == nil || !strings.Contains(err.Error(), `gopass binary "gopass" is not usable`) {
+		t.Errorf("expected unusable binary error, got %v", err)
+	}
+}
+
+func TestNewCLIStore_ExtraArgs(t *testing.T) {
+	f := &fakeGopass{}
+	s, err := newCLIStore(context.Background(), "gopass", "", []string{"--yes", "--config", "/etc/gopass"}, f.run)
+	if err != nil {
+		t.Fatalf("newCLIStore() error = %v", err)
+	}
+	if !reflect.DeepEqual(f.lastCall(), []string{"gopass", "--yes", "--config", "/etc/gopass", "version"}) {
+		t.Errorf("unexpected call %v", f.lastCall())
+	}
+
+	if err := s.Remove(context.Background(), "a/b"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if !reflect.DeepEqual(f.lastCall(), []string{"gopass", "--yes", "--config", "/etc/gopass", "rm", "--force", "--", "a/b"}) {
+		t.Errorf("unexpected call %v", f.lastCall())
+	}
+	if !reflect.DeepEqual(s.extraArgs, []string{"--yes", "--config", "/etc/gopass"}) {
+		t.Errorf("expected the extra args to be kept, got %v", s.extraArgs)
+	}
+}
+
+func TestValidateExtraArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		extraArgs []string
+		wantErr   string
+	}{
+		{name: "cli", mode: "cli", extraArgs: []string{"--yes"}},
+		{name: "none", mode: "cli"},
+		{name: "library", mode: "library", extraArgs: []string{"--yes"}, wantErr: `set mode = "cli"`},
+		{name: "empty", mode: "cli", extraArgs: []string{"--yes", ""}, wantErr: "argument 1 is empty"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateExtraArgs(tc.mode, tc.extraArgs)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCLIStore_Commands(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		call     func(s *cliStore) error
+		wantArgs []string
+	}{
+		{
+			name:     "remove",
+			call:     func(s *cliStore) error { return s.Remove(ctx, "a/b") },
+			wantArgs: []string{"rm", "--force", "--", "a/b"},
+		},
+		{
+			name:     "remove all",
+			call:     func(s *cliStore) error { return s.RemoveAll(ctx, "a") },
+			wantArgs: []string{"rm", "--force", "--recursive", "--", "a"},
+		},
+		{
+			name:     "rename",
+			call:     func(s *cliStore) error { return s.Rename(ctx, "a", "b") },
+			wantArgs: []string{"mv", "--force", "--", "a", "b"},
+		},
+		{
+			name:     "sync",
+			call:     func(s *cliStore) error { return s.Sync(ctx) },
+			wantArgs: []string{"sync"},
+		},
+		{
+			name: "set",
+			call: func(s *cliStore) error {
+				return s.Set(ctx, "a/b", secrets.ParseAKV([]byte("pw\nuser: me\n")))
+			},
+			wantArgs: []string{"insert", "--force", "--", "a/b"},
+		},
+		{
+			name: "list",
+			call: func(s *cliStore) error {
+				_, err := s.List(ctx)
+				return err
+			},
+			wantArgs: []string{"ls", "--flat"},
+		},
+		{
+			name: "get",
+			call: func(s *cliStore) error {
+				_, err := s.Get(ctx, "a/b", "latest")
+				return err
+			},
+			wantArgs: []string{"show", "--unsafe", "--noparsing", "--", "a/b"},
+		},
+		{
+			name: "get revision",
+			call: func(s *cliStore) error {
+				_, err := s.Get(ctx, "a/b", "abc123")
+				return err
+			},
+			wantArgs: []string{"show", "--unsafe", "--noparsing", "--revision", "abc123", "--", "a/b"},
+		},
+		{
+			name: "revisions",
+			call: func(s *cliStore) error {
+				_, err := s.Revisions(ctx, "a/b")
+				return err
+			},
+			wantArgs: []string{"history", "--", "a/b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeGopass{}
+			s := newFakeCLIStore(t, f)
+			if err := tt.call(s); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := append([]string{"gopass"}, tt.wantArgs...)
+			if !reflect.DeepEqual(f.lastCall(), want) {
+				t.Errorf("call = %v, want %v", f.lastCall(), want)
+			}
+
+			f.failOn = tt.wantArgs[0]
+			err := tt.call(s)
+			if err == nil || !strings.Contains(err.Error(), "gopass "+tt.wantArgs[0]+" failed") {
+				t.Errorf("expected %s error, got %v", tt.wantArgs[0], err)
+			}
+		})
+	}
+}
+
+func TestCLIStore_Output(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeGopass{outputs: map[string]string{
+		"ls":      "a/b\n\nc\n",
+		"show":    "s3cret\nuser: me\n",
+		"history": "abc123 - Jane <j@x> - 2024-01-02T03:04:05Z - Save\ndef456 - Jane <j@x> - 2024-01-01T03:04:05Z - Add\n",
+	}}
+	s := newFakeCLIStore(t, f)
+
+	list, err := s.List(ctx)
+	if err != nil || !reflect.DeepEqual(list, []string{"a/b", "c"}) {
+		t.Errorf("List() = %v, %v", list, err)
+	}
+
+	sec, err := s.Get(ctx, "a/b", "")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if sec.Password() != "s3cret" {
+		t.Errorf("Password() = %q", sec.Password())
+	}
+	if user, _ := sec.Get("user"); user != "me" {
+		t.Errorf("user = %q", user)
+	}
+	if !isWiped(f.returned) {
+		t.Errorf("expected the output of show to be wiped, got %q", f.returned)
+	}
+
+	revs, err := s.Revisions(ctx, "a/b")
+	if err != nil || !reflect.DeepEqual(revs, []string{"abc123", "def456"}) {
+		t.Errorf("Revisions() = %v, %v", revs, err)
+	}
+
+	if err := s.Set(ctx, "a/b", secrets.ParseAKV([]byte("pw\nuser: me\n"))); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if string(f.stdin) != "pw\nuser: me\n" {
+		t.Errorf("stdin = %q", f.stdin)
+	}
+	if !isWiped(f.passed) {
+		t.Errorf("expected the input of insert to be wiped, got %q", f.passed)
+	}
+
+	if err := s.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestCLIStore_NotFoundClassified(t *testing.T) {
+	f := &fakeGopass{failOn: "show"}
+	c := NewGopassClient("")
+	c.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return newCLIStore(ctx, "gopass", dir, nil, f.run)
+	}
+
+	exists, err := c.SecretExists(context.Background(), "missing")
+	if err != nil || exists {
+		t.Errorf("SecretExists() = %v, %v; want false, nil", exists, err)
+	}
+}
+
+func TestCLIStore_StorePath(t *testing.T) {
+	dir := t.TempDir()
+	f := &fakeGopass{}
+	c := NewGopassClient(dir)
+	c.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return newCLIStore(ctx, "gopass", dir, nil, f.run)
+	}
+
+	if _, err := c.SecretExists(context.Background(), "app/db"); err != nil {
+		t.Fatalf("SecretExists() error = %v", err)
+	}
+	if want := []string{"PASSWORD_STORE_DIR=" + dir}; !reflect.DeepEqual(f.env, want) {
+		t.Errorf("expected env %q, got %q", want, f.env)
+	}
+}
+
+func TestRunCommandWithInput(t *testing.T) {
+	ctx := context.Background()
+
+	out, err := runCommandWithInput(ctx, nil, []byte("hello"), "cat")
+	if err != nil || string(out) != "hello" {
+		t.Errorf("runCommandWithInput(cat) = %q, %v", out, err)
+	}
+
+	out, err = runCommandWithInput(ctx, []string{"PASSWORD_STORE_DIR=/stores/a"}, nil, "sh", "-c", "echo $PASSWORD_STORE_DIR")
+	if err != nil || strings.TrimSpace(string(out)) != "/stores/a" {
+		t.Errorf("expected the environment to be passed, got %q, %v", out, err)
+	}
+
+	_, err = runCommandWithInput(ctx, nil, nil, "sh", "-c", "echo 'entry is not in the password store' >&2; exit 1")
+	if err == nil || !strings.Contains(err.Error(), "entry is not in the password store") {
+		t.Errorf("expected stderr in error, got %v", err)
+	}
+}
+
+func TestGopassClient_UseCLI(t *testing.T) {
+	c := NewGopassClient("")
+	c.UseCLI("/nonexistent/gopass", nil)
+
+	_, err := c.apiNew(context.Background(), "")
+	if err == nil || !strings.Contains(err.Error(), `gopass binary "/nonexistent/gopass" is not usable`) {
+		t.Errorf("expected unusable binary error, got %v", err)
+	}
+}
@@ -5,15 +5,23 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
 	"github.com/gopasspw/gopass/pkg/gopass/api"
 	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/gopasspw/gopass/pkg/pwgen"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
@@ -23,23 +31,202 @@ type GopassClient struct {
 	store       gopass.Store
 	storePath   string
 	mu          sync.Mutex
-	userHomeDir func() (string, error)                          // injectable for testing
-	apiNew      func(ctx context.Context) (gopass.Store, error) // injectable for testing
+	userHomeDir func() (string, error)                                                      // injectable for testing
+	apiNew      func(ctx context.Context, dir string) (gopass.Store, error)                 // injectable for testing
+	execCommand func(ctx context.Context, dir, name string, args ...string) ([]byte, error) // injectable for testing
+	pwgen       func(length int, symbols bool) (string, error)                              // injectable for testing
+	pwrule      func(ctx context.Context, length int, domain string) string                 // injectable for testing
+	removeAll   func(path string) error                                                     // injectable for testing
+	writeFile   func(name string, data []byte, perm os.FileMode) error                      // injectable for testing
+	makeFIFO    func(path string) error                                                     // injectable for testing
+	hooks       clientHooks
+
+	readsRevisions bool // the store returns secrets as they were at a revision, see UseCLI
+
+	notFoundPatterns []string // additional patterns, see AddNotFoundPatterns
+	revisionTracking string   // default revision tracking of resources, see SetRevisionTracking
+	hidePaths        bool     // mask secret paths in log output, see SetLogPaths
+
+	runLock     bool          // hold the lock of the store for the rest of the run on the first write, see SetLockTimeout
+	lockTimeout time.Duration // how long to wait for the lock of another run
+
+	autoInitRecipients []string        // recipients of an empty store initialized on first use, see EnableAutoInit
+	lookupPaths        []string        // stores searched after the one at storePath, see SetLookupStores
+	pathPrefix         string          // folder all paths are relative to, see SetPathPrefix
+	protectedPaths     []string        // glob patterns of secrets never removed, see SetProtectedPaths
+	policy             *PasswordPolicy // nil unless configured, see SetPasswordPolicy
+
+	passphraseEnv  string          // environment variable holding the GPG passphrase in loopback mode, see SetPinentryLoopback
+	passphrasePipe *passphrasePipe // passes the passphrase to GPG while the store is open
+
+	metrics *clientMetrics // nil unless enabled, see EnableMetrics
+	cache   *secretCache   // nil unless enabled, see EnableCache
+	clone   *storeClone    // nil unless the store was cloned, see CloneStore
+
+	capsMu sync.Mutex
+	caps   map[string]storeCapabilities // probed capabilities by first path component, see capabilities
 }
 
 // NewGopassClient creates a new gopass client.
 // The store is lazily initialized on first access.
 // If storePath is non-empty, it will be used instead of the default gopass configuration.
 func NewGopassClient(storePath string) *GopassClient {
-	return &GopassClient{
+	c := &GopassClient{
 		storePath:   storePath,
 		userHomeDir: os.UserHomeDir,
-		apiNew:      func(ctx context.Context) (gopass.Store, error) { return api.New(ctx) },
+		execCommand: runCommand,
+		pwgen:       pwgen.GeneratePasswordWithAllClasses,
+		pwrule:      generateForDomain,
+		removeAll:   os.RemoveAll,
+		writeFile:   os.WriteFile,
+		makeFIFO:    makeFIFO,
 	}
+	c.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return newLibraryStore(ctx, dir, c.gpgEnv())
+	}
+	return c
+}
+
+// generateForDomain returns a password following the password rules of domain, or "" if
+// none was found within the tries of the generator.
+func generateForDomain(ctx context.Context, length int, domain string) string {
+	return pwgen.NewCrypticForDomain(ctx, length, domain).Password()
+}
+
+// runCommand executes an external command in dir and returns its standard output.
+func runCommand(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = processEnv()
+	return cmd.Output()
+}
+
+// expandPath expands a leading "~/" to the user's home directory.
+func (c *GopassClient) expandPath(p string) (string, error) {
+	if !strings.HasPrefix(p, "~/") {
+		return p, nil
+	}
+	home, err := c.userHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand home directory: %w", err)
+	}
+	return filepath.Join(home, p[2:]), nil
+}
+
+// storeDir returns the root directory of the password store on disk.
+// It honors the configured store path, then PASSWORD_STORE_DIR, and finally
+// falls back to the gopass default location.
+func (c *GopassClient) storeDir() (string, error) {
+	if c.storePath != "" {
+		return c.expandPath(c.storePath)
+	}
+	if dirs := filepath.SplitList(lookupStoreDirEnv()); len(dirs) > 0 {
+		// Of several stores, the first is the one written to
+		return dirs[0], nil
+	}
+	home, err := c.userHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "gopass", "stores", "root"), nil
+}
+
+// storeDirEnvMu serializes the construction of library stores across all clients, and the
+// reads of the store environment against it, including those of started processes.
+var storeDirEnvMu sync.Mutex
+
+// lookupStoreDirEnv returns PASSWORD_STORE_DIR while no library store is being constructed.
+func lookupStoreDirEnv() string {
+	storeDirEnvMu.Lock()
+	defer storeDirEnvMu.Unlock()
+	return os.Getenv("PASSWORD_STORE_DIR")
+}
+
+// processEnv returns the environment for an external command while no library store is being
+// constructed, so git and gopass processes never inherit the variables set for another store.
+func processEnv() []string {
+	storeDirEnvMu.Lock()
+	defer storeDirEnvMu.Unlock()
+	return os.Environ()
+}
+
+// storeConfigEnv returns the environment that sets the root store to dir through the gopass
+// config override of mounts.path. It is placed in front of the overrides already in the
+// environment, since gopass uses the first value of a key. gopass prefers PASSWORD_STORE_DIR
+// over mounts.path, so the variable is unset, listed by its bare name. Must be called with
+// storeDirEnvMu held.
+func storeConfigEnv(dir string) []string {
+	env := []string{
+		"PASSWORD_STORE_DIR",
+		"GOPASS_CONFIG_KEY_0=mounts.path",
+		"GOPASS_CONFIG_VALUE_0=" + dir,
+	}
+
+	count, err := strconv.Atoi(os.Getenv("GOPASS_CONFIG_COUNT"))
+	if err != nil || count < 0 {
+		count = 0
+	}
+	var kept []string
+	for i := 0; i < count; i++ {
+		key := os.Getenv(fmt.Sprintf("GOPASS_CONFIG_KEY_%d", i))
+		value, found := os.LookupEnv(fmt.Sprintf("GOPASS_CONFIG_VALUE_%d", i))
+		if key == "" || !found {
+			// gopass ignores all overrides if one is malformed, so there are none to keep
+			kept = nil
+			break
+		}
+		kept = append(kept,
+			fmt.Sprintf("GOPASS_CONFIG_KEY_%d=%s", i+1, key),
+			fmt.Sprintf("GOPASS_CONFIG_VALUE_%d=%s", i+1, value),
+		)
+	}
+	env = append(env, "GOPASS_CONFIG_COUNT="+strconv.Itoa(len(kept)/2+1))
+	return append(env, kept...)
+}
+
+// newLibraryStore opens the store in dir with the gopass library, or the store of the
+// gopass configuration if dir is empty. The variables in env, e.g. the GPG options of the
+// crypto backend, are set while the store is constructed; a bare name unsets the variable.
+// The library loads its configuration only while the store is constructed, from the config
+// files and the config overrides in the environment, and offers no other way to pass it.
+// The store root is passed as such an override of mounts.path, so it takes precedence over
+// the user's configuration. The variables are set for just that duration and restored
+// after, so providers with different store paths can be used concurrently in one process.
+func newLibraryStore(ctx context.Context, dir string, env []string) (gopass.Store, error) {
+	storeDirEnvMu.Lock()
+	defer storeDirEnvMu.Unlock()
+
+	if dir != "" {
+		env = append(storeConfigEnv(dir), env...)
+	}
+	for _, kv := range env {
+		key, value, ok := strings.Cut(kv, "=")
+		previous, set := os.LookupEnv(key)
+		if ok {
+			os.Setenv(key, value)
+		} else {
+			os.Unsetenv(key)
+		}
+		defer func() {
+			if set {
+				os.Setenv(key, previous)
+			} else {
+				os.Unsetenv(key)
+			}
+		}()
+	}
+
+	return api.New(ctx)
 }
 
 // ensureStore initializes the gopass store if not already done.
 func (c *GopassClient) ensureStore(ctx context.Context) error {
+	// Every operation starts here, so once Terraform cancels, loops over many secrets stop
+	// at the next one instead of waiting for the store to notice
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("gopass operation canceled: %w", err)
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -51,51 +238,65 @@ func (c *GopassClient) ensureStore(ctx context.Context) error {
 		"configured_path": c.storePath,
 	})
 
-	// If a custom store path is configured, set PASSWORD_STORE_DIR
-	// This is the standard way to tell gopass/pass where to find the store
-	if c.storePath != "" {
-		// Expand ~ if present
-		expandedPath := c.storePath
-		if strings.HasPrefix(expandedPath, "~/") {
-			home, err := c.userHomeDir()
-			if err != nil {
-				return fmt.Errorf("failed to expand home directory: %w", err)
-			}
-			expandedPath = filepath.Join(home, expandedPath[2:])
-		}
+	// Custom store paths are passed to the store constructor, which tells gopass where to find
+	// the store without changing the environment of the process
+	dirs, err := c.storeDirs()
+	if err != nil {
+		return err
+	}
+	if len(dirs) > 0 {
+		tflog.Debug(ctx, "Using configured store path", map[string]interface{}{
+			"path": dirs[0],
+		})
+	}
 
-		// Verify the path exists
-		if _, err := os.Stat(expandedPath); os.IsNotExist(err) {
-			return fmt.Errorf("gopass store not found at configured path: %s\n\n"+
-				"Please verify the path exists and contains a valid gopass/pass store, "+
-				"or remove the store_path configuration to use gopass defaults", expandedPath)
+	if len(c.autoInitRecipients) > 0 {
+		if err := c.autoInitStore(ctx); err != nil {
+			return err
 		}
-
-		tflog.Debug(ctx, "Setting PASSWORD_STORE_DIR", map[string]interface{}{
-			"path": expandedPath,
-		})
-		os.Setenv("PASSWORD_STORE_DIR", expandedPath)
 	}
 
-	store, err := c.apiNew(ctx)
+	if err := c.openPassphrasePipe(); err != nil {
+		return err
+	}
+	store, err := c.openStores(ctx, dirs)
 	if err != nil {
+		c.closePassphrasePipe(ctx)
 		// Provide helpful error message
 		return c.wrapStoreError(err)
 	}
 
-	c.store = store
+	if c.passphrasePipe != nil {
+		store = &serializedStore{store: store}
+	}
+	c.store = c.withPathPrefix(store)
+	registerOpenStore(c)
 	tflog.Debug(ctx, "Gopass store initialized successfully")
 	return nil
 }
 
-// wrapStoreError provides helpful context for common gopass initialization errors.
+// wrapStoreError provides helpful context for common gopass initialization errors
+// and tags them with their diagnostic code.
 func (c *GopassClient) wrapStoreError(err error) error {
 	errStr := err.Error()
 
+	if errors.Is(err, api.ErrNotInitialized) || strings.Contains(errStr, "not initialized") {
+		return withCode(CodeStoreNotInitialized, fmt.Errorf("gopass store not initialized: %w\n\n"+
+			"The password store has no recipients yet. Possible solutions:\n\n"+
+			"1. Initialize the store:\n"+
+			"   gopass init --path /path/to/store <gpg-key-id>\n\n"+
+			"2. Let the provider initialize an empty store on first use:\n"+
+			"   provider \"gopass\" {\n"+
+			"     auto_init            = true\n"+
+			"     auto_init_recipients = [\"<gpg-key-id>\"]\n"+
+			"   }\n\n"+
+			"3. Initialize it with the gopass_store_init resource", err))
+	}
+
 	// Check for common error patterns and provide helpful messages
 	if strings.Contains(errStr, "no such file or directory") ||
 		strings.Contains(errStr, "does not exist") {
-		return fmt.Errorf("gopass store not found: %w\n\n"+
+		return withCode(CodeStoreNotFound, fmt.Errorf("gopass store not found: %w\n\n"+
 			"No gopass password store was found. Possible solutions:\n\n"+
 			"1. Initialize a new store:\n"+
 			"   gopass init\n\n"+
@@ -106,21 +307,25 @@ func (c *GopassClient) wrapStoreError(err error) error {
 			"3. Set the PASSWORD_STORE_DIR environment variable:\n"+
 			"   export PASSWORD_STORE_DIR=/path/to/store\n\n"+
 			"4. Check your gopass configuration:\n"+
-			"   cat ~/.config/gopass/config", err)
+			"   cat ~/.config/gopass/config", err))
 	}
 
 	if strings.Contains(errStr, "permission denied") {
-		return fmt.Errorf("gopass store access denied: %w\n\n"+
+		return withCode(CodePermission, fmt.Errorf("gopass store access denied: %w\n\n"+
 			"Unable to access the gopass store due to permission issues.\n"+
-			"Please check file permissions on your password store directory.", err)
+			"Please check file permissions on your password store directory.", err))
+	}
+
+	if isPinentryError(err) {
+		return wrapPinentryError(err)
 	}
 
 	if strings.Contains(errStr, "gpg") || strings.Contains(errStr, "GPG") {
-		return fmt.Errorf("GPG error during gopass initialization: %w\n\n"+
+		return withCode(CodeGPGError, fmt.Errorf("GPG error during gopass initialization: %w\n\n"+
 			"There was a problem with GPG. Please ensure:\n"+
 			"- gpg-agent is running\n"+
 			"- Your GPG key is available\n"+
-			"- If using a hardware token, it is connected", err)
+			"- If using a hardware token, it is connected", err))
 	}
 
 	// Generic error with context
@@ -131,38 +336,210 @@ func (c *GopassClient) wrapStoreError(err error) error {
 		"  }", err)
 }
 
+// CheckStore initializes the store eagerly and, if probe is set, decrypts the secret at probe.
+// Misconfigured stores or GPG agents then fail early with the same helpful errors
+// instead of in the middle of an apply.
+func (c *GopassClient) CheckStore(ctx context.Context, probe string) error {
+	probe = c.resolveMountPath(ctx, probe)
+	if err := c.ensureStore(ctx); err != nil {
+		return err
+	}
+	if probe == "" {
+		return nil
+	}
+
+	tflog.Debug(ctx, "Checking decryption", map[string]interface{}{
+		"path": probe,
+	})
+
+	if _, err := c.GetSecret(ctx, probe); err != nil {
+		return fmt.Errorf("test decryption of %q failed: %w", probe, err)
+	}
+	return nil
+}
+
+// defaultPreflightWritePath is the folder CheckWritable checks, unless preflight_write_path
+// is set.
+const defaultPreflightWritePath = "terraform-preflight"
+
+// CheckWritable checks that secrets could be written below the store folder dir, so a store
+// that is read-only, e.g. for missing filesystem permissions, fails before an apply gets
+// halfway through. It writes no secret and commits nothing, as it runs during plan: the
+// closest existing folder of dir must accept new files, and for a git-backed store, the git
+// directory must too, and no other git process may hold the index lock.
+func (c *GopassClient) CheckWritable(ctx context.Context, dir string) error {
+	root, rel, err := c.resolve(ctx, dir)
+	if err != nil {
+		return err
+	}
+	folder := filepath.Join(root, filepath.FromSlash(rel))
+	tflog.Debug(ctx, "Checking write access", map[string]interface{}{
+		"path": folder,
+	})
+
+	// A missing folder is created by the first write, within its closest existing parent
+	for {
+		info, err := os.Stat(folder)
+		if err == nil && !info.IsDir() {
+			return fmt.Errorf("%s is not a folder", folder)
+		}
+		if err == nil {
+			break
+		}
+		// A file in the way is reported as not a folder once the walk reaches it
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) || folder == root {
+			return fmt.Errorf("failed to access store folder %s: %w", folder, err)
+		}
+		folder = filepath.Dir(folder)
+	}
+	if err := c.checkFolderWritable(folder); err != nil {
+		return err
+	}
+
+	gitDir := filepath.Join(root, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "index.lock")); err == nil {
+		return fmt.Errorf("the git repository of the store is locked: %s exists, "+
+			"as another git process is running or one was interrupted", filepath.Join(gitDir, "index.lock"))
+	}
+	return c.checkFolderWritable(gitDir)
+}
+
+// checkFolderWritable creates a file in folder and removes it again. The file is named after
+// the process, so concurrent runs do not remove each other's files.
+func (c *GopassClient) checkFolderWritable(folder string) error {
+	probe := filepath.Join(folder, fmt.Sprintf(".terraform-preflight-%d-%d", os.Getpid(), time.Now().UnixNano()))
+	if err := c.writeFile(probe, nil, 0o600); err != nil {
+		return fmt.Errorf("%s is not writable: %w", folder, err)
+	}
+	if err := c.removeAll(probe); err != nil {
+		return fmt.Errorf("failed to remove test file %s: %w", probe, err)
+	}
+	return nil
+}
+
 // GetSecret retrieves a single secret by path.
 // Returns the password (first line) of the secret.
 func (c *GopassClient) GetSecret(ctx context.Context, path string) (string, error) {
-	if err := c.ensureStore(ctx); err != nil {
-		return "", err
-	}
+	return c.GetSecretAt(ctx, path, "")
+}
+
+// GetSecretAt retrieves a single secret as it existed at a git ref (tag, branch
+// or commit). An empty snapshot reads the latest revision.
+// The snapshot is passed to the store as the requested revision, which only the gopass
+// binary honors, so a snapshot is an error unless the client uses the CLI.
+func (c *GopassClient) GetSecretAt(ctx context.Context, path, snapshot string) (string, error) {
+	return c.GetSecretFieldAt(ctx, path, "", snapshot)
+}
 
+// GetSecretFieldAt is like GetSecretAt but returns the value of the field key (e.g. "username")
+// instead of the password, like `gopass show path key`. An empty key returns the password,
+// joined from its parts if the secret is chunked.
+// A secret without the field is an error.
+func (c *GopassClient) GetSecretFieldAt(ctx context.Context, path, key, snapshot string) (string, error) {
+	path = c.resolveMountPath(ctx, path)
 	tflog.Debug(ctx, "Reading secret", map[string]interface{}{
-		"path": path,
+		"path":     path,
+		"key":      key,
+		"snapshot": snapshot,
 	})
 
-	// Get secret with "latest" revision
-	secret, err := c.store.Get(ctx, path, "latest")
+	secret, err := c.getSecretAt(ctx, path, snapshot)
 	if err != nil {
-		return "", fmt.Errorf("failed to get secret %q: %w", path, err)
+		return "", err
 	}
 
-	// Password() returns the first line (the actual password)
-	password := secret.Password()
+	var value string
+	if key != "" {
+		var ok bool
+		if value, ok = secret.Get(key); !ok {
+			return "", c.notifyError(ctx, opGet, path, fmt.Errorf("secret %q has no key %q", path, key))
+		}
+	} else {
+		password, err := c.joinChunks(ctx, path, snapshot, secret)
+		if err != nil {
+			return "", err
+		}
+		value = string(password)
+		wipe(password)
+	}
 
 	tflog.Debug(ctx, "Successfully read secret", map[string]interface{}{
 		"path": path,
 	})
 
-	return password, nil
+	return value, nil
+}
+
+// getSecretValueAt returns the password of the secret at the resolved path at snapshot, joined
+// from its parts if the secret is chunked. The caller owns the returned bytes and wipes them.
+func (c *GopassClient) getSecretValueAt(ctx context.Context, path, snapshot string) ([]byte, error) {
+	tflog.Debug(ctx, "Reading secret", map[string]interface{}{
+		"path":     path,
+		"snapshot": snapshot,
+	})
+
+	secret, err := c.getSecretAt(ctx, path, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return c.joinChunks(ctx, path, snapshot, secret)
+}
+
+// readSecret decrypts the secret at path at revision, or returns it from the cache, and reports
+// the read to the hooks. Every read of a secret goes through it, so the audit log misses none.
+func (c *GopassClient) readSecret(ctx context.Context, path, revision string) (gopass.Secret, error) {
+	secret, err := c.cachedGet(ctx, path, revision)
+	if err == nil && secret != nil {
+		c.notifyRead(ctx, path)
+	}
+	return secret, err
+}
+
+// getSecretAt decrypts the secret at the resolved path as it existed at snapshot, or its
+// latest revision if snapshot is empty. Failures are reported to the hooks.
+func (c *GopassClient) getSecretAt(ctx context.Context, path, snapshot string) (gopass.Secret, error) {
+	if err := c.ensureStore(ctx); err != nil {
+		return nil, c.notifyError(ctx, opGet, path, err)
+	}
+
+	revision := snapshot
+	if revision == "" {
+		revision = "latest"
+	} else if err := validateSnapshotRef(snapshot); err != nil {
+		return nil, c.notifyError(ctx, opGet, path, withCode(CodeInvalidConfig, fmt.Errorf("invalid snapshot %q: %w", snapshot, err)))
+	} else if err := c.checkReadsRevisions(path, snapshot); err != nil {
+		return nil, c.notifyError(ctx, opGet, path, err)
+	}
+
+	secret, err := c.readSecret(ctx, path, revision)
+	if err != nil {
+		return nil, c.notifyError(ctx, opGet, path, fmt.Errorf("failed to get secret %q: %w", path, c.classifyNotFound(err)))
+	}
+	return secret, nil
+}
+
+// checkReadsRevisions returns an error unless the store can read path as it was at revision.
+// The gopass library ignores the revision it is passed and returns the latest value, which
+// would be mistaken for the old one, so only the gopass binary reads older revisions.
+func (c *GopassClient) checkReadsRevisions(path, revision string) error {
+	if c.readsRevisions {
+		return nil
+	}
+	return withCode(CodeInvalidConfig, fmt.Errorf(
+		"cannot read %q at %q: the gopass library only reads the latest revision of a secret, set mode = %q to read older ones",
+		path, revision, modeCLI))
 }
 
-// ListSecrets lists all secrets under a given prefix.
-// Returns only immediate children (not recursive).
+// ListSecrets lists all secrets under a given prefix in lexical order.
+// Returns only immediate children (not recursive). The parts of a chunked secret are
+// left out, see withoutChunkParts.
 func (c *GopassClient) ListSecrets(ctx context.Context, prefix string) ([]string, error) {
+	prefix = c.resolveMountPath(ctx, prefix)
 	if err := c.ensureStore(ctx); err != nil {
-		return nil, err
+		return nil, c.notifyError(ctx, opList, prefix, err)
 	}
 
 	// Normalize prefix
@@ -175,7 +552,7 @@ func (c *GopassClient) ListSecrets(ctx context.Context, prefix string) ([]string
 	// List all secrets
 	allSecrets, err := c.store.List(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list secrets: %w", err)
+		return nil, c.notifyError(ctx, opList, prefix, fmt.Errorf("failed to list secrets: %w", err))
 	}
 
 	// Filter to immediate children of prefix
@@ -198,6 +575,9 @@ func (c *GopassClient) ListSecrets(ctx context.Context, prefix string) ([]string
 
 		results = append(results, secretPath)
 	}
+	results = c.withoutChunkParts(ctx, results, "")
+	// Backends may list in map-iteration order; sort for stable results
+	sort.Strings(results)
 
 	tflog.Debug(ctx, "Listed secrets", map[string]interface{}{
 		"prefix": prefix,
@@ -208,10 +588,12 @@ func (c *GopassClient) ListSecrets(ctx context.Context, prefix string) ([]string
 }
 
 // ListSecretsRecursive lists all secrets under a given prefix recursively.
-// Returns all secrets at any depth under the prefix.
+// Returns all secrets at any depth under the prefix, in lexical order, leaving out the
+// parts of chunked secrets like ListSecrets.
 func (c *GopassClient) ListSecretsRecursive(ctx context.Context, prefix string) ([]string, error) {
+	prefix = c.resolveMountPath(ctx, prefix)
 	if err := c.ensureStore(ctx); err != nil {
-		return nil, err
+		return nil, c.notifyError(ctx, opList, prefix, err)
 	}
 
 	// Normalize prefix
@@ -224,7 +606,7 @@ func (c *GopassClient) ListSecretsRecursive(ctx context.Context, prefix string)
 	// List all secrets
 	allSecrets, err := c.store.List(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list secrets: %w", err)
+		return nil, c.notifyError(ctx, opList, prefix, fmt.Errorf("failed to list secrets: %w", err))
 	}
 
 	// Filter to all secrets under prefix (recursive)
@@ -239,6 +621,8 @@ func (c *GopassClient) ListSecretsRecursive(ctx context.Context, prefix string)
 
 		results = append(results, secretPath)
 	}
+	results = c.withoutChunkParts(ctx, results, "")
+	sort.Strings(results)
 
 	tflog.Debug(ctx, "Listed secrets recursively", map[string]interface{}{
 		"prefix": prefix,
@@ -248,44 +632,195 @@ func (c *GopassClient) ListSecretsRecursive(ctx context.Context, prefix string)
 	return results, nil
 }
 
+// ListSecretsRecursiveAt lists all secrets under a given prefix as they existed
+// at a git ref (tag, branch or commit). An empty snapshot lists the current store.
+func (c *GopassClient) ListSecretsRecursiveAt(ctx context.Context, prefix, snapshot string) ([]string, error) {
+	prefix = c.resolveMountPath(ctx, prefix)
+	if snapshot == "" {
+		return c.ListSecretsRecursive(ctx, prefix)
+	}
+
+	results, err := c.listSnapshot(ctx, prefix, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	results = c.withoutChunkParts(ctx, results, snapshot)
+	sort.Strings(results)
+
+	tflog.Debug(ctx, "Listed secrets at snapshot", map[string]interface{}{
+		"prefix":   prefix,
+		"snapshot": snapshot,
+		"count":    len(results),
+	})
+
+	return results, nil
+}
+
+// listSnapshot lists the secrets below prefix tracked in the git history at ref of the store
+// holding prefix, which is that of a mount for a prefix within one.
+// The gopass API can only list the current state, so this reads the git tree directly.
+// The ref is verified to name a commit first, and passed after --end-of-options so git
+// never reads it as an option.
+func (c *GopassClient) listSnapshot(ctx context.Context, prefix, ref string) ([]string, error) {
+	if err := validateSnapshotRef(ref); err != nil {
+		return nil, withCode(CodeInvalidConfig, fmt.Errorf("invalid snapshot %q: %w", ref, err))
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	dir, rel, err := c.resolve(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.execCommand(ctx, dir, "git", "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("snapshot %q is not a commit in the store: %w", ref, err)
+	}
+	out, err := c.execCommand(ctx, dir, "git", "ls-tree", "-r", "--name-only", "--end-of-options", ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets at snapshot %q: %w", ref, err)
+	}
+
+	var names []string
+	for _, file := range strings.Split(string(out), "\n") {
+		name, ok := secretNameFromFile(file)
+		if !ok {
+			continue
+		}
+		if rel != "" {
+			if name, ok = strings.CutPrefix(name, rel+"/"); !ok {
+				continue
+			}
+		}
+		names = append(names, joinPathPrefix(prefix, name))
+	}
+	return names, nil
+}
+
+// secretNameFromFile converts an encrypted file path in the store to a secret name.
+// Files that are not encrypted secrets (e.g. .gpg-id, templates) are rejected.
+func secretNameFromFile(file string) (string, bool) {
+	if strings.HasPrefix(filepath.Base(file), ".") {
+		return "", false
+	}
+	for _, ext := range []string{".gpg", ".age"} {
+		if strings.HasSuffix(file, ext) {
+			return strings.TrimSuffix(file, ext), true
+		}
+	}
+	return "", false
+}
+
 // GetEnvSecrets reads all secrets under a path (recursively) and returns them as a map.
 // The map keys are the secret paths relative to the prefix (with slashes preserved),
 // and values are the passwords.
 func (c *GopassClient) GetEnvSecrets(ctx context.Context, prefix string) (map[string]string, error) {
-	secretPaths, err := c.ListSecretsRecursive(ctx, prefix)
+	return c.GetEnvSecretsAt(ctx, prefix, "")
+}
+
+// GetEnvSecretsAt is like GetEnvSecrets but reads the tree as it existed at a
+// git ref (tag, branch or commit). An empty snapshot reads the latest state.
+// Secrets that cannot be read are skipped.
+func (c *GopassClient) GetEnvSecretsAt(ctx context.Context, prefix, snapshot string) (map[string]string, error) {
+	result, _, err := c.ReadEnvSecretsAt(ctx, prefix, snapshot, 0)
+	return result, err
+}
+
+// EnvReadError describes a secret below a prefix that could not be read.
+type EnvReadError struct {
+	Path string // full path of the secret
+	Key  string // path relative to the prefix
+	Err  error
+}
+
+// ReadEnvSecretsAt is like GetEnvSecretsAt but also returns the secrets that could not
+// be read, e.g. because they are not encrypted for any available key, in lexical order.
+// If maxDepth is positive, only secrets at most maxDepth levels below prefix are read,
+// so 1 reads the immediate children; deeper secrets are neither decrypted nor returned.
+// It only fails if the tree itself cannot be listed, if the store cannot read secrets at
+// snapshot, or if ctx is canceled while reading.
+func (c *GopassClient) ReadEnvSecretsAt(ctx context.Context, prefix, snapshot string, maxDepth int) (map[string]string, []EnvReadError, error) {
+	values, failures, err := c.ReadEnvSecretBytesAt(ctx, prefix, snapshot, maxDepth)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = string(value)
+	}
+	wipeValues(values)
+	return result, failures, nil
+}
+
+// ReadEnvSecretBytesAt is like ReadEnvSecretsAt but returns the values as byte slices, which
+// the caller owns and wipes once done, see wipeValues. A caller that transforms the values
+// before handing them to Terraform, e.g. to encode them, keeps no plaintext string of them.
+func (c *GopassClient) ReadEnvSecretBytesAt(ctx context.Context, prefix, snapshot string, maxDepth int) (map[string][]byte, []EnvReadError, error) {
+	prefix = c.resolveMountPath(ctx, prefix)
+	if snapshot != "" {
+		// Otherwise every secret would fail to read, and be skipped
+		if err := c.checkReadsRevisions(prefix, snapshot); err != nil {
+			return nil, nil, err
+		}
+	}
+	secretPaths, err := c.ListSecretsRecursiveAt(ctx, prefix, snapshot)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	prefix = strings.TrimSuffix(prefix, "/")
-	result := make(map[string]string)
+	result := make(map[string][]byte)
+	var failures []EnvReadError
 
 	for _, fullPath := range secretPaths {
 		// Extract key name from path (relative path with slashes preserved)
 		key := strings.TrimPrefix(fullPath, prefix+"/")
+		if !withinDepth(key, maxDepth) {
+			continue
+		}
 
 		// Get the secret value
-		value, err := c.GetSecret(ctx, fullPath)
+		value, err := c.getSecretValueAt(ctx, fullPath, snapshot)
 		if err != nil {
+			// Once canceled, every remaining secret would fail the same way
+			if ctx.Err() != nil {
+				wipeValues(result)
+				return nil, nil, err
+			}
 			tflog.Warn(ctx, "Failed to read secret, skipping", map[string]interface{}{
 				"path":  fullPath,
 				"error": err.Error(),
 			})
+			failures = append(failures, EnvReadError{Path: fullPath, Key: key, Err: err})
 			continue
 		}
 
 		result[key] = value
 	}
 
-	return result, nil
+	return result, failures, nil
+}
+
+// withinDepth reports whether the secret at key, relative to a prefix, is at most maxDepth
+// levels below it. A maxDepth of zero or less does not limit the depth.
+func withinDepth(key string, maxDepth int) bool {
+	return maxDepth <= 0 || strings.Count(key, "/") < maxDepth
 }
 
 // SetSecret writes a secret to the gopass store.
 // The value becomes the first line (password) of the secret.
 func (c *GopassClient) SetSecret(ctx context.Context, path, value string) error {
-	if err := c.ensureStore(ctx); err != nil {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.checkPolicy(ctx, path, value); err != nil {
 		return err
 	}
+	return c.setSecret(ctx, path, value)
+}
+
+// setSecret writes value as the password of the secret at path, whether it meets the password
+// policy or not.
+func (c *GopassClient) setSecret(ctx context.Context, path, value string) error {
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
 
 	tflog.Debug(ctx, "Writing secret", map[string]interface{}{
 		"path": path,
@@ -295,11 +830,75 @@ func (c *GopassClient) SetSecret(ctx context.Context, path, value string) error
 	secret := secrets.New()
 	secret.SetPassword(value)
 
-	// Set the secret in the store
+	return c.writeSecret(ctx, path, secret)
+}
+
+// SetSecretPassword replaces only the password (first line) of the secret at path.
+// Other fields and the body of an existing secret, e.g. a username or notes added
+// by humans, are kept. If the secret does not exist yet, it is created.
+func (c *GopassClient) SetSecretPassword(ctx context.Context, path, value string) error {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.checkPolicy(ctx, path, value); err != nil {
+		return err
+	}
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
+
+	tflog.Debug(ctx, "Updating secret password", map[string]interface{}{
+		"path": path,
+	})
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil && !c.isNotFound(err) {
+		return c.notifyError(ctx, opSet, path, fmt.Errorf("failed to read secret %q: %w", path, err))
+	}
+	if secret == nil {
+		secret = secrets.New()
+	}
+	secret.SetPassword(value)
+
+	return c.writeSecret(ctx, path, secret)
+}
+
+// SetSecretContent writes content as the whole secret at path, like `gopass insert --multiline`.
+// The first line becomes the password, and the rest is kept as is, e.g. a PEM certificate
+// or a kubeconfig file. An existing secret is replaced. The password policy applies to the
+// password only, as for every other write; the rest is not a password.
+func (c *GopassClient) SetSecretContent(ctx context.Context, path string, content []byte) error {
+	path = c.resolveMountPath(ctx, path)
+	secret := secrets.ParseAKV(content)
+	if err := c.checkPolicy(ctx, path, secret.Password()); err != nil {
+		return err
+	}
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
+
+	tflog.Debug(ctx, "Writing secret content", map[string]interface{}{
+		"path":  path,
+		"bytes": len(content),
+	})
+
+	return c.writeSecret(ctx, path, secret)
+}
+
+// writeSecret stores secret at path and notifies the hooks.
+func (c *GopassClient) writeSecret(ctx context.Context, path string, secret gopass.Byter) error {
+	unlock, err := c.lockWrites(ctx, path)
+	if err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
+	defer unlock()
+	// Cached secrets may be outdated once the store was written to
+	defer c.cache.clear()
+
 	if err := c.store.Set(ctx, path, secret); err != nil {
-		return fmt.Errorf("failed to write secret %q: %w", path, err)
+		return c.notifyError(ctx, opSet, path, fmt.Errorf("failed to write secret %q: %w", path, err))
 	}
 
+	c.notifyWrite(ctx, opSet, path)
+
 	tflog.Debug(ctx, "Successfully wrote secret", map[string]interface{}{
 		"path": path,
 	})
@@ -307,20 +906,42 @@ func (c *GopassClient) SetSecret(ctx context.Context, path, value string) error
 	return nil
 }
 
-// RemoveSecret removes a secret from the gopass store.
+// RemoveSecret removes a secret from the gopass store. Secrets matching protected_paths are
+// not removed.
 func (c *GopassClient) RemoveSecret(ctx context.Context, path string) error {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.checkRemovable(path); err != nil {
+		return c.notifyError(ctx, opRemove, path, err)
+	}
+	return c.removeSecret(ctx, path)
+}
+
+// removeSecret removes a secret from the gopass store, whether it is protected or not.
+func (c *GopassClient) removeSecret(ctx context.Context, path string) error {
 	if err := c.ensureStore(ctx); err != nil {
-		return err
+		return c.notifyError(ctx, opRemove, path, err)
+	}
+	if err := c.checkRemoveSupported(ctx, path); err != nil {
+		return c.notifyError(ctx, opRemove, path, err)
 	}
 
 	tflog.Debug(ctx, "Removing secret", map[string]interface{}{
 		"path": path,
 	})
 
+	unlock, err := c.lockWrites(ctx, path)
+	if err != nil {
+		return c.notifyError(ctx, opRemove, path, err)
+	}
+	defer unlock()
+	defer c.cache.clear()
+
 	if err := c.store.Remove(ctx, path); err != nil {
-		return fmt.Errorf("failed to remove secret %q: %w", path, err)
+		return c.notifyError(ctx, opRemove, path, fmt.Errorf("failed to remove secret %q: %w", path, c.classifyNotFound(err)))
 	}
 
+	c.notifyWrite(ctx, opRemove, path)
+
 	tflog.Debug(ctx, "Successfully removed secret", map[string]interface{}{
 		"path": path,
 	})
@@ -330,15 +951,16 @@ func (c *GopassClient) RemoveSecret(ctx context.Context, path string) error {
 
 // SecretExists checks if a secret exists at the given path.
 func (c *GopassClient) SecretExists(ctx context.Context, path string) (bool, error) {
+	path = c.resolveMountPath(ctx, path)
 	if err := c.ensureStore(ctx); err != nil {
 		return false, err
 	}
 
-	exists, err := c.store.Get(ctx, path, "latest")
+	exists, err := c.readSecret(ctx, path, "latest")
 	if err != nil {
 		// If the error indicates the secret doesn't exist, that's not an error condition
 		// for this function - it just means the secret doesn't exist
-		if strings.Contains(err.Error(), "not found") {
+		if c.isNotFound(err) {
 			return false, nil
 		}
 		return false, fmt.Errorf("failed to check if secret %q exists: %w", path, err)
@@ -358,16 +980,17 @@ func (c *GopassClient) SecretExists(ctx context.Context, path string) (bool, err
 // Errors from the Revisions() call are logged but not returned - we fall back to
 // existence check in that case, as not all backends support revision history.
 func (c *GopassClient) GetRevisionCount(ctx context.Context, path string) (int64, error) {
+	path = c.resolveMountPath(ctx, path)
 	if err := c.ensureStore(ctx); err != nil {
 		return 0, err
 	}
 
 	// First check if secret exists
-	exists, err := c.store.Get(ctx, path, "latest")
+	exists, err := c.readSecret(ctx, path, "latest")
 	if err != nil {
 		// If the error indicates the secret doesn't exist, that's not an error condition
 		// for this function - it just means the secret doesn't exist
-		if strings.Contains(err.Error(), "not found") {
+		if c.isNotFound(err) {
 			return 0, nil
 		}
 		return 0, fmt.Errorf("failed to check if secret %q exists: %w", path, err)
@@ -376,6 +999,17 @@ func (c *GopassClient) GetRevisionCount(ctx context.Context, path string) (int64
 		return 0, nil
 	}
 
+	return c.revisionCount(ctx, path), nil
+}
+
+// revisionCount returns the number of revisions of an existing secret,
+// or 1 if the backend does not report revisions.
+func (c *GopassClient) revisionCount(ctx context.Context, path string) int64 {
+	// Backends known to keep no history are not asked
+	if !c.supportsRevisions(ctx, path) {
+		return 1
+	}
+
 	// Try to get revision count - not all backends support this.
 	// Currently, this is also not yet implemented in the API.
 	revisions, err := c.store.Revisions(ctx, path)
@@ -386,13 +1020,84 @@ func (c *GopassClient) GetRevisionCount(ctx context.Context, path string) (int64
 			"path":  path,
 			"error": err.Error(),
 		})
-		return 1, nil
+		return 1
 	}
 
 	if len(revisions) == 0 {
 		// Secret exists but no revisions reported - treat as 1
-		return 1, nil
+		return 1
+	}
+
+	return int64(len(revisions))
+}
+
+// RevisionInfo describes the most recent commit that touched a secret in a git-backed store.
+type RevisionInfo struct {
+	Hash      string // full commit hash
+	Timestamp string // author date in RFC 3339 format
+	Author    string // "Name <email>"
+}
+
+// GetRevisionInfo returns metadata about the last commit that modified the secret at path.
+// It reads the git history of the store holding the secret, which may be a mount, directly,
+// since the gopass API does not expose revision metadata. Returns nil without error if the secret has no history,
+// or if the store keeps no revisions, e.g. a plain filesystem store, which is then not asked.
+func (c *GopassClient) GetRevisionInfo(ctx context.Context, path string) (*RevisionInfo, error) {
+	if !c.supportsRevisions(ctx, path) {
+		return nil, nil
+	}
+	dir, rel, err := c.resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	path = c.storeName(ctx, path)
+
+	out, err := c.execCommand(ctx, dir, "git", "log", "-1", "--format=%H%x1f%aI%x1f%an <%ae>",
+		"--", rel+".gpg", rel+".age")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision info for %q: %w", path, err)
 	}
 
-	return int64(len(revisions)), nil
+	line := strings.TrimSpace(string(out))
+	if line == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(line, "\x1f")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected git log output for %q", path)
+	}
+
+	return &RevisionInfo{
+		Hash:      fields[0],
+		Timestamp: fields[1],
+		Author:    fields[2],
+	}, nil
+}
+
+// SupportsRevisions reports whether the store backend provides revision history for the secret at path.
+// Stores without versioning (e.g. plain filesystem stores) report false, in which case
+// GetRevisionCount falls back to 1 for existing secrets. Backends known to keep no history
+// are reported without asking the store.
+func (c *GopassClient) SupportsRevisions(ctx context.Context, path string) (bool, error) {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return false, err
+	}
+
+	if !c.supportsRevisions(ctx, path) {
+		return false, nil
+	}
+	_, err := c.store.Revisions(ctx, path)
+	return err == nil, nil
+}
+
+// GeneratePassword creates a random password of the given length using gopass's generator.
+// The password contains upper and lower case letters and digits, plus symbols if requested.
+func (c *GopassClient) GeneratePassword(length int, symbols bool) (string, error) {
+	password, err := c.pwgen(length, symbols)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return password, nil
 }
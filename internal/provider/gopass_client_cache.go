@@ -0,0 +1,178 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets/secparse"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// secretCache keeps the secrets a client decrypted, so a path referenced by several blocks
+// or modules is decrypted only once per provider process, e.g. with a hardware token that
+// requires a touch for every decryption. Writes through the client clear it. The methods
+// are no-ops on nil, so clients without a cache don't need to check.
+type secretCache struct {
+	mu      sync.Mutex
+	entries map[secretCacheKey][]byte
+	// generation is incremented by every clear, so a decryption that raced with a write
+	// does not put the value from before the write back into the cache
+	generation uint64
+}
+
+// uncachedKey marks contexts whose reads bypass the secret cache, see uncached.
+type uncachedKey struct{}
+
+// uncached returns a context whose reads through the client decrypt the secret again instead
+// of using the cache, for reads whose point is to notice changes in the store, such as the
+// renewal of an ephemeral resource. A cached copy of a secret read this way is dropped.
+func uncached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncachedKey{}, true)
+}
+
+// secretCacheKey identifies a cached secret by its path in the store and its revision.
+type secretCacheKey struct {
+	path, revision string
+}
+
+// EnableCache lets the client keep decrypted secrets in memory for the lifetime of the
+// provider process, instead of decrypting a path again for every block that reads it.
+// Clients do not cache unless this is called; the provider calls it unless disable_cache is set.
+func (c *GopassClient) EnableCache() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = &secretCache{entries: make(map[secretCacheKey][]byte)}
+}
+
+// cachedGet returns the secret at path from the cache, or decrypts it with the store and
+// caches it. Each hit returns a secret of its own, so callers may modify it.
+func (c *GopassClient) cachedGet(ctx context.Context, path, revision string) (gopass.Secret, error) {
+	key := secretCacheKey{path: path, revision: revision}
+	if ctx.Value(uncachedKey{}) != nil {
+		c.cache.forget(key)
+		return c.storeGet(ctx, path, revision)
+	}
+	content, generation, ok := c.cache.get(key)
+	if ok {
+		tflog.Debug(ctx, "Using cached gopass secret", map[string]interface{}{
+			"path": path,
+		})
+		// The content was parsed by gopass once already, so only the secret it parsed to is of interest
+		secret, _ := secparse.Parse(content)
+		wipe(content)
+		return secret, nil
+	}
+
+	secret, err := c.storeGet(ctx, path, revision)
+	if err == nil && secret != nil {
+		c.cache.put(key, generation, secret.Bytes())
+	}
+	return secret, err
+}
+
+// ReleaseCached drops the cached secrets at paths, including the parts of chunked ones, and
+// the ones below prefixes, in any revision, and wipes them. Ephemeral resources call it on
+// Close, so the secrets they read do not stay in memory for the rest of the provider process.
+// It returns the number of cached secrets dropped.
+func (c *GopassClient) ReleaseCached(ctx context.Context, paths, prefixes []string) int {
+	// Cached secrets are keyed by their resolved path
+	resolvedPaths := make([]string, 0, len(paths))
+	for _, p := range paths {
+		resolvedPaths = append(resolvedPaths, c.resolveMountPath(ctx, p))
+	}
+	resolvedPrefixes := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		resolvedPrefixes = append(resolvedPrefixes, strings.TrimSuffix(c.resolveMountPath(ctx, prefix), "/")+"/")
+	}
+	return c.cache.release(func(path string) bool {
+		for _, p := range resolvedPaths {
+			if path == p || strings.HasPrefix(path, p+".part") {
+				return true
+			}
+		}
+		for _, prefix := range resolvedPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// get returns a copy of the cached content of key, which the caller wipes once parsed, and
+// the generation of the cache. The cached content itself may be wiped by a concurrent clear.
+func (s *secretCache) get(key secretCacheKey) ([]byte, uint64, bool) {
+	if s == nil {
+		return nil, 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content, ok := s.entries[key]
+	return bytes.Clone(content), s.generation, ok
+}
+
+// put caches content for key, unless the cache was cleared since generation was returned by
+// get. The cache owns content from then on, and wipes it if it is not cached.
+func (s *secretCache) put(key secretCacheKey, generation uint64, content []byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation != generation {
+		wipe(content)
+		return
+	}
+	// A concurrent read of the same secret may have cached it already
+	wipe(s.entries[key])
+	s.entries[key] = content
+}
+
+// forget drops the cached content of key, if any, and wipes it.
+func (s *secretCache) forget(key secretCacheKey) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wipe(s.entries[key])
+	delete(s.entries, key)
+}
+
+// release drops the cached secrets whose path matches and wipes them. It returns their number.
+func (s *secretCache) release(match func(path string) bool) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	released := 0
+	for key, content := range s.entries {
+		if match(key.path) {
+			wipe(content)
+			delete(s.entries, key)
+			released++
+		}
+	}
+	return released
+}
+
+// clear forgets all cached secrets, as a write may have changed any of them, and wipes them.
+func (s *secretCache) clear() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, content := range s.entries {
+		wipe(content)
+	}
+	s.entries = make(map[secretCacheKey][]byte)
+	s.generation++
+}
@@ -0,0 +1,194 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestGopassClient_Cache(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		between   func(ctx context.Context, c *GopassClient) error
+		uncached  bool
+		wantGets  int
+		wantValue string
+	}{
+		{name: "disabled", wantGets: 2, wantValue: "hunter2"},
+		{name: "enabled", enabled: true, wantGets: 1, wantValue: "hunter2"},
+		{name: "set clears", enabled: true, wantGets: 2, wantValue: "rotated", between: func(ctx context.Context, c *GopassClient) error {
+			return c.SetSecret(ctx, "app/db", "rotated")
+		}},
+		{name: "remove of other secret clears", enabled: true, wantGets: 2, wantValue: "hunter2", between: func(ctx context.Context, c *GopassClient) error {
+			return c.RemoveSecret(ctx, "app/api")
+		}},
+		{name: "remove of folder clears", enabled: true, wantGets: 2, wantValue: "hunter2", between: func(ctx context.Context, c *GopassClient) error {
+			return c.RemoveDirectory(ctx, "other")
+		}},
+		{name: "outside change is not noticed", enabled: true, wantGets: 1, wantValue: "hunter2", between: rotateOutside},
+		{name: "uncached read notices outside change", enabled: true, uncached: true, wantGets: 2, wantValue: "rotated", between: rotateOutside},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &revisionRecordingStore{mockStore: storeWith(map[string]string{"app/db": "hunter2", "app/api": "key", "other/db": "x"})}
+			client := NewGopassClient("")
+			client.store = store
+			if tc.enabled {
+				client.EnableCache()
+			}
+
+			if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.between != nil {
+				if err := tc.between(ctx, client); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			readCtx := ctx
+			if tc.uncached {
+				readCtx = uncached(ctx)
+			}
+			value, err := client.GetSecret(readCtx, "app/db")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if value != tc.wantValue {
+				t.Errorf("expected %q, got %q", tc.wantValue, value)
+			}
+			if len(store.requested) != tc.wantGets {
+				t.Errorf("expected %d decryptions, got %d", tc.wantGets, len(store.requested))
+			}
+		})
+	}
+}
+
+// rotateOutside changes app/db in the store without going through the client.
+func rotateOutside(ctx context.Context, c *GopassClient) error {
+	c.store.(*revisionRecordingStore).secrets["app/db"] = newMockSecret("rotated")
+	return nil
+}
+
+func TestGopassClient_Cache_Uncached(t *testing.T) {
+	ctx := context.Background()
+	store := &revisionRecordingStore{mockStore: storeWith(map[string]string{"app/db": "hunter2"})}
+	client := NewGopassClient("")
+	client.store = store
+	client.EnableCache()
+
+	if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetSecret(uncached(ctx), "app/db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The uncached read dropped the cached copy instead of replacing it
+	if _, _, ok := client.cache.get(secretCacheKey{path: "app/db", revision: "latest"}); ok {
+		t.Error("expected the cached copy to be dropped by an uncached read")
+	}
+	if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.requested) != 3 {
+		t.Errorf("expected 3 decryptions, got %d", len(store.requested))
+	}
+}
+
+func TestGopassClient_Cache_Keys(t *testing.T) {
+	ctx := context.Background()
+	store := &revisionRecordingStore{mockStore: storeWith(map[string]string{"app/db": "hunter2"})}
+	client := NewGopassClient("")
+	client.store = store
+	client.EnableCache()
+
+	// Revisions are cached separately, and missing secrets are not cached at all
+	for _, revision := range []string{"latest", "abc123", "latest", "abc123"} {
+		if _, err := client.cachedGet(ctx, "app/db", revision); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	for range 2 {
+		if _, err := client.cachedGet(ctx, "app/missing", "latest"); err == nil {
+			t.Fatal("expected an error for a missing secret")
+		}
+	}
+	if len(store.requested) != 4 {
+		t.Errorf("expected 4 decryptions, got %d", len(store.requested))
+	}
+}
+
+func TestGopassClient_Cache_Copies(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"app/db": "hunter2"})
+	client.EnableCache()
+
+	for range 3 {
+		secret, err := client.cachedGet(ctx, "app/db", "latest")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := secret.Password(); got != "hunter2" {
+			t.Fatalf("expected the cached secret to be unchanged, got %q", got)
+		}
+		// Callers may modify the secret they got, e.g. before writing it back
+		secret.SetPassword("modified")
+	}
+}
+
+func TestSecretCache(t *testing.T) {
+	key := secretCacheKey{path: "app/db", revision: "latest"}
+
+	var disabled *secretCache
+	disabled.put(key, 0, []byte("hunter2"))
+	disabled.forget(key)
+	disabled.clear()
+	if _, _, ok := disabled.get(key); ok {
+		t.Error("expected a nil cache to hold nothing")
+	}
+
+	cache := &secretCache{entries: make(map[secretCacheKey][]byte)}
+	_, generation, _ := cache.get(key)
+	// A write clears the cache while the secret is decrypted
+	cache.clear()
+	stale := []byte("hunter2")
+	cache.put(key, generation, stale)
+	if _, _, ok := cache.get(key); ok {
+		t.Error("expected a value decrypted before the clear not to be cached")
+	}
+	if !isWiped(stale) {
+		t.Errorf("expected a value that was not cached to be wiped, got %q", stale)
+	}
+
+	_, generation, _ = cache.get(key)
+	cached := []byte("hunter2")
+	cache.put(key, generation, cached)
+	content, _, ok := cache.get(key)
+	if !ok || string(content) != "hunter2" {
+		t.Errorf("expected the value to be cached, got %q, %v", content, ok)
+	}
+	// Readers get a copy, so wiping it leaves the cached value intact
+	wipe(content)
+	if content, _, _ := cache.get(key); string(content) != "hunter2" {
+		t.Errorf("expected the cached value to be unchanged, got %q", content)
+	}
+
+	cache.forget(key)
+	if !isWiped(cached) {
+		t.Errorf("expected a forgotten value to be wiped, got %q", cached)
+	}
+
+	_, generation, _ = cache.get(key)
+	cached = []byte("hunter2")
+	cache.put(key, generation, cached)
+	cache.clear()
+	if !isWiped(cached) {
+		t.Errorf("expected the cached value to be wiped by a clear, got %q", cached)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// storeCapabilities describe the operations a storage backend supports.
+type storeCapabilities struct {
+	Revisions bool // keeps the history of every secret
+	Remove    bool // removes secrets
+	Sync      bool // pushes to and pulls from a remote
+}
+
+// backendCapabilities map the storage backends of gopass to what they support.
+var backendCapabilities = map[string]storeCapabilities{
+	storageGitFS:    {Revisions: true, Remove: true, Sync: true},
+	storageFossilFS: {Revisions: true, Remove: true, Sync: true},
+	storageFS:       {Remove: true},
+}
+
+// unknownCapabilities are assumed of stores whose backend cannot be determined, e.g. when
+// stores are layered. Every operation is attempted then, and failures are handled as errors.
+var unknownCapabilities = storeCapabilities{Revisions: true, Remove: true, Sync: true}
+
+// capabilities returns what the backend of the store holding the secret at path supports:
+// that of the store mounted under the first component of path, if any, or else that of the
+// store of the client. The result is probed from the files of the store once and then kept.
+func (c *GopassClient) capabilities(ctx context.Context, path string) storeCapabilities {
+	first, _, _ := strings.Cut(c.storeName(ctx, path), "/")
+
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	if caps, ok := c.caps[first]; ok {
+		return caps
+	}
+	if c.caps == nil {
+		c.caps = make(map[string]storeCapabilities)
+	}
+
+	caps, ok := c.caps[""]
+	if dir, mounted, err := c.GetConfig(ctx, configScopeGlobal, "mounts."+first+".path"); err == nil && mounted {
+		caps = c.probeCapabilities(ctx, first, dir)
+	} else if !ok {
+		caps = c.probeCapabilities(ctx, "", "")
+		c.caps[""] = caps
+	}
+	c.caps[first] = caps
+	return caps
+}
+
+// probeCapabilities determines the capabilities of the store mounted as mount at dir, or of
+// the store of the client if mount is empty, from its storage backend.
+func (c *GopassClient) probeCapabilities(ctx context.Context, mount, dir string) storeCapabilities {
+	var info *StoreInfo
+	var err error
+	if mount == "" {
+		if dirs, dirsErr := c.storeDirs(); dirsErr != nil || len(dirs) > 1 {
+			// Layered stores may each have another backend
+			return unknownCapabilities
+		}
+		info, err = c.GetStoreInfo(ctx)
+	} else {
+		info, err = c.storeInfoAt(ctx, dir)
+	}
+	if err != nil {
+		tflog.Debug(ctx, "Could not probe store capabilities, assuming all are supported", map[string]interface{}{
+			"mount": mount,
+			"error": err.Error(),
+		})
+		return unknownCapabilities
+	}
+
+	caps := backendCapabilities[info.Storage]
+	tflog.Debug(ctx, "Probed store capabilities", map[string]interface{}{
+		"mount":     mount,
+		"storage":   info.Storage,
+		"revisions": caps.Revisions,
+		"remove":    caps.Remove,
+		"sync":      caps.Sync,
+	})
+	return caps
+}
+
+// supportsRevisions reports whether the backend of the store holding the secret at path
+// keeps revisions, so stores known to lack them are not asked for any.
+func (c *GopassClient) supportsRevisions(ctx context.Context, path string) bool {
+	return c.capabilities(ctx, path).Revisions
+}
+
+// supportsRemove reports whether the backend of the store holding the secret at path
+// removes secrets.
+func (c *GopassClient) supportsRemove(ctx context.Context, path string) bool {
+	return c.capabilities(ctx, path).Remove
+}
+
+// checkRemoveSupported returns an error if the backend of the store holding path does not
+// remove secrets, before anything is locked or changed.
+func (c *GopassClient) checkRemoveSupported(ctx context.Context, path string) error {
+	if !c.supportsRemove(ctx, path) {
+		return fmt.Errorf("cannot remove %q: the storage backend of the store does not remove secrets", path)
+	}
+	return nil
+}
+
+// supportsSync reports whether the backend of the store of the client syncs with a remote.
+func (c *GopassClient) supportsSync(ctx context.Context) bool {
+	return c.capabilities(ctx, "").Sync
+}
@@ -0,0 +1,117 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGopassClient_Capabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		marker string
+		lookup bool
+		want   storeCapabilities
+	}{
+		{name: "gitfs", marker: ".git/HEAD", want: storeCapabilities{Revisions: true, Remove: true, Sync: true}},
+		{name: "fossilfs", marker: ".fslckout", want: storeCapabilities{Revisions: true, Remove: true, Sync: true}},
+		{name: "fs", marker: ".gpg-id", want: storeCapabilities{Remove: true}},
+		{name: "missing store", want: unknownCapabilities},
+		{name: "layered stores", marker: ".gpg-id", lookup: true, want: unknownCapabilities},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+			dir := filepath.Join(t.TempDir(), "store")
+			if tc.marker != "" {
+				writeStoreFile(t, dir, tc.marker, "id\n")
+			}
+			client := NewGopassClient(dir)
+			if tc.lookup {
+				client.SetLookupStores([]string{t.TempDir()})
+			}
+
+			if got := client.capabilities(context.Background(), "app/db"); got != tc.want {
+				t.Errorf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGopassClient_Capabilities_Mounts(t *testing.T) {
+	ctx := context.Background()
+	home := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", home)
+	root, mounted := t.TempDir(), t.TempDir()
+	writeStoreFile(t, root, ".git/HEAD", "ref: refs/heads/main\n")
+	writeStoreFile(t, mounted, ".gpg-id", "id\n")
+	writeStoreFile(t, home, ".config/gopass/config", "[mounts \"work\"]\n\tpath = "+mounted+"\n"+
+		"[mounts \"home\"]\n\tpath = ~/.password-store\n")
+
+	client := NewGopassClient(root)
+	if !client.supportsRevisions(ctx, "app/db") || !client.supportsSync(ctx) {
+		t.Error("expected the git-backed root store to keep revisions and sync")
+	}
+	if client.supportsRevisions(ctx, "work:ci/token") || !client.supportsRemove(ctx, "work/ci/token") {
+		t.Error("expected the mounted fs store to remove secrets but keep no revisions")
+	}
+
+	// A mount whose store cannot be found is assumed to support everything
+	client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+	if got := client.capabilities(ctx, "home/db"); got != unknownCapabilities {
+		t.Errorf("expected unknown capabilities, got %+v", got)
+	}
+
+	// Capabilities are probed once
+	if err := os.RemoveAll(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	if !client.supportsRevisions(ctx, "app/db") || !client.supportsRevisions(ctx, "other/db") {
+		t.Error("expected the probed capabilities of the root store to be kept")
+	}
+}
+
+func TestGopassClient_Capabilities_SkipUnsupported(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".gpg-id", "id\n")
+
+	store := storeWith(map[string]string{"app/db": "s3cret", "app/api": "token"})
+	store.revisions["app/db"] = []string{"3", "2", "1"}
+	client := NewGopassClient(dir)
+	client.store = store
+
+	// The revisions of the store are never asked for
+	if count, err := client.GetRevisionCount(ctx, "app/db"); err != nil || count != 1 {
+		t.Errorf("expected revision count 1 of a store without history, got %d, %v", count, err)
+	}
+	if supported, err := client.SupportsRevisions(ctx, "app/db"); err != nil || supported {
+		t.Errorf("expected no revision support, got %v, %v", supported, err)
+	}
+	if _, err := client.ListRevisions(ctx, "app/db"); err == nil || !strings.Contains(err.Error(), "keeps no history") {
+		t.Errorf("expected listing revisions to fail, got %v", err)
+	}
+	if err := client.SetGitRemote(ctx, "", "origin", "git@example.com:store.git"); err == nil || !strings.Contains(err.Error(), "does not sync") {
+		t.Errorf("expected configuring a remote to fail, got %v", err)
+	}
+
+	// A backend that does not remove secrets fails before anything is changed
+	client.caps["app"] = storeCapabilities{}
+	if err := client.RemoveSecret(ctx, "app/db"); err == nil || !strings.Contains(err.Error(), "does not remove secrets") {
+		t.Errorf("expected removing a secret to fail, got %v", err)
+	}
+	if err := client.RemoveDirectory(ctx, "app"); err == nil || !strings.Contains(err.Error(), "does not remove secrets") {
+		t.Errorf("expected removing a folder to fail, got %v", err)
+	}
+	if len(store.secrets) != 2 {
+		t.Errorf("expected the secrets to be kept, got %d", len(store.secrets))
+	}
+}
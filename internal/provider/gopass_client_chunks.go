@@ -0,0 +1,189 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// chunksField marks a secret whose value is split into chunks. It holds the number of
+// parts, stored as the passwords of <path>.part1 to <path>.partN; the password of the
+// secret itself is empty.
+const chunksField = "chunks"
+
+// chunkPath returns the path of the nth part of the secret at path, counting from 1.
+func chunkPath(path string, n int) string {
+	return fmt.Sprintf("%s.part%d", path, n)
+}
+
+// splitChunks splits value into parts of at most size bytes. UTF-8 characters are not
+// split, so a part may be shorter; a size below the length of a character still fits one.
+func splitChunks(value string, size int) []string {
+	var parts []string
+	for len(value) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(value[end]) {
+			end--
+		}
+		if end == 0 {
+			_, end = utf8.DecodeRuneInString(value)
+		}
+		parts = append(parts, value[:end])
+		value = value[end:]
+	}
+	if value == "" && len(parts) > 0 {
+		return parts
+	}
+	return append(parts, value)
+}
+
+// SetSecretChunked writes value as the password of the secret at path like SetSecret. If the
+// value is longer than size bytes, it is split into parts of at most size bytes instead, and
+// the secret at path only records their number in the chunks field. Parts left over from a
+// previous, longer value are removed. The password policy applies to the whole value, not to
+// its parts.
+func (c *GopassClient) SetSecretChunked(ctx context.Context, path, value string, size int) error {
+	return c.UpdateSecret(ctx, path, SecretUpdate{Value: &value, ChunkSize: size})
+}
+
+// RemoveSecretChunks removes the parts of the secret at path after the first keep ones.
+// The parts are found by listing the store, so nothing is decrypted.
+func (c *GopassClient) RemoveSecretChunks(ctx context.Context, path string, keep int) error {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opRemove, path, err)
+	}
+
+	names, err := c.store.List(ctx)
+	if err != nil {
+		return c.notifyError(ctx, opRemove, path, fmt.Errorf("failed to list parts of secret %q: %w", path, err))
+	}
+
+	partName := regexp.MustCompile(`^` + regexp.QuoteMeta(path) + `\.part([1-9][0-9]*)$`)
+	for _, name := range names {
+		match := partName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		// The pattern only matches numbers
+		if n, _ := strconv.Atoi(match[1]); n <= keep {
+			continue
+		}
+		// Parts are only left over once the secret at path was rewritten or removed, which
+		// protected_paths already guard
+		if err := c.removeSecret(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnchunkSecret stores the value of the chunked secret at path as its password again, keeping
+// its other fields, and removes all of its parts. The parts next to a secret that is not
+// chunked, or missing, are removed as well, as left over by writing it without chunk_size.
+func (c *GopassClient) UnchunkSecret(ctx context.Context, path string) error {
+	return c.UpdateSecret(ctx, path, SecretUpdate{Unchunk: true})
+}
+
+// chunkCount returns the number of parts of the secret at path, or 0 if it is not chunked.
+func (c *GopassClient) chunkCount(ctx context.Context, path string, secret gopass.Secret) (int, error) {
+	count, ok := secret.Get(chunksField)
+	if !ok || secret.Password() != "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return 0, c.notifyError(ctx, opGet, path, fmt.Errorf("secret %q has an invalid %s field %q", path, chunksField, count))
+	}
+	return n, nil
+}
+
+// joinChunks returns the value of the secret at path from its parts at snapshot, if the
+// secret records them in the chunks field and has no password of its own, see chunkCount.
+// Otherwise it returns the password of the secret. The caller owns the returned bytes and
+// wipes them once done.
+func (c *GopassClient) joinChunks(ctx context.Context, path, snapshot string, secret gopass.Secret) ([]byte, error) {
+	n, err := c.chunkCount(ctx, path, secret)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return []byte(secret.Password()), nil
+	}
+
+	tflog.Debug(ctx, "Joining chunked secret", map[string]interface{}{
+		"path":  path,
+		"parts": n,
+	})
+
+	var value []byte
+	for i := 1; i <= n; i++ {
+		part, err := c.getSecretAt(ctx, chunkPath(path, i), snapshot)
+		if err != nil {
+			wipe(value)
+			return nil, fmt.Errorf("failed to read part %d of %d of secret %q: %w", i, n, path, err)
+		}
+		value = appendWiped(value, part.Password())
+	}
+	return value, nil
+}
+
+// chunkPartName matches the name of a part of a chunked secret, see chunkPath.
+var chunkPartName = regexp.MustCompile(`^(.+)\.part([1-9][0-9]*)$`)
+
+// withoutChunkParts returns names without the parts of the chunked secrets among them, so
+// a listing shows a chunked secret once, at the path of the secret recording its parts. A
+// name only counts as a part if that secret is listed as well and its chunks field covers
+// the part; parts left over beyond it stay listed. Only secrets with parts listed next to
+// them are decrypted, at snapshot; one that cannot be read keeps its parts listed.
+func (c *GopassClient) withoutChunkParts(ctx context.Context, names []string, snapshot string) []string {
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[name] = true
+	}
+
+	counts := make(map[string]int)
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		match := chunkPartName.FindStringSubmatch(name)
+		if match == nil || !listed[match[1]] {
+			kept = append(kept, name)
+			continue
+		}
+		count, ok := counts[match[1]]
+		if !ok {
+			count = c.listedChunkCount(ctx, match[1], snapshot)
+			counts[match[1]] = count
+		}
+		// The pattern only matches numbers
+		if n, _ := strconv.Atoi(match[2]); n > count {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// listedChunkCount returns the number of parts of the secret at path at snapshot, or 0 if it
+// is not chunked or cannot be read, which only leaves its parts in a listing.
+func (c *GopassClient) listedChunkCount(ctx context.Context, path, snapshot string) int {
+	secret, err := c.getSecretAt(ctx, path, snapshot)
+	if err == nil {
+		var n int
+		if n, err = c.chunkCount(ctx, path, secret); err == nil {
+			return n
+		}
+	}
+	tflog.Debug(ctx, "Could not tell whether secret is chunked, listing its parts", map[string]interface{}{
+		"path":  path,
+		"error": err.Error(),
+	})
+	return 0
+}
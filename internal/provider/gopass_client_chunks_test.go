@@ -0,0 +1,379 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		value string
+		size  int
+		want  []string
+	}{
+		{value: "", size: 4, want: []string{""}},
+		{value: "abcd", size: 4, want: []string{"abcd"}},
+		{value: "abcdefghij", size: 4, want: []string{"abcd", "efgh", "ij"}},
+		// "ä" takes two bytes and is not split
+		{value: "abcäd", size: 4, want: []string{"abc", "äd"}},
+		{value: "ää", size: 1, want: []string{"ä", "ä"}},
+	}
+
+	for _, tc := range tests {
+		if got := splitChunks(tc.value, tc.size); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitChunks(%q, %d) = %q, want %q", tc.value, tc.size, got, tc.want)
+		}
+	}
+}
+
+// storeNames returns the sorted names of the secrets in store.
+func storeNames(store *mockStore) []string {
+	names := make([]string, 0, len(store.secrets))
+	for name := range store.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGopassClient_SetSecretChunked(t *testing.T) {
+	mountStores(t, "app")
+	ctx := context.Background()
+	client := NewGopassClient("")
+	store := storeWith(map[string]string{"app/cert.partial": "unrelated", "app/cert.part0": "unrelated"})
+	client.store = store
+
+	steps := []struct {
+		value     string
+		wantNames []string
+	}{
+		{
+			value:     "abcdefghij",
+			wantNames: []string{"app/cert", "app/cert.part0", "app/cert.part1", "app/cert.part2", "app/cert.part3", "app/cert.partial"},
+		},
+		{
+			value:     "abcdefg",
+			wantNames: []string{"app/cert", "app/cert.part0", "app/cert.part1", "app/cert.part2", "app/cert.partial"},
+		},
+		{
+			value:     "abc",
+			wantNames: []string{"app/cert", "app/cert.part0", "app/cert.partial"},
+		},
+	}
+
+	for _, step := range steps {
+		if err := client.SetSecretChunked(ctx, "app:cert", step.value, 4); err != nil {
+			t.Fatalf("SetSecretChunked(%q) error = %v", step.value, err)
+		}
+		if names := storeNames(store); !reflect.DeepEqual(names, step.wantNames) {
+			t.Errorf("after writing %q: expected secrets %q, got %q", step.value, step.wantNames, names)
+		}
+
+		value, err := client.GetSecret(ctx, "app/cert")
+		if err != nil || value != step.value {
+			t.Errorf("GetSecret() = %q, %v; want %q", value, err, step.value)
+		}
+		content, err := client.GetSecretFull(ctx, "app/cert", "")
+		if err != nil || content.Password != step.value {
+			t.Errorf("GetSecretFull() password = %v, %v; want %q", content, err, step.value)
+		}
+	}
+}
+
+func TestGopassClient_SetSecretChunked_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store not found", func(t *testing.T) {
+		for _, value := range []string{"abc", "abcdefgh"} {
+			client := NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+			if err := client.SetSecretChunked(ctx, "app/cert", value, 4); ErrorCode(err) != CodeStoreNotFound {
+				t.Errorf("SetSecretChunked(%q): expected store not found, got %v", value, err)
+			}
+		}
+	})
+
+	t.Run("write fails", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = &mockStore{shouldFail: true, failMsg: "disk full"}
+		if err := client.SetSecretChunked(ctx, "app/cert", "abcdefgh", 4); err == nil || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("expected write error, got %v", err)
+		}
+	})
+
+	t.Run("manifest write fails", func(t *testing.T) {
+		client := NewGopassClient("")
+		store := newMockStore()
+		client.store = store
+		// Both parts are written first, so the write after them is the secret at path
+		client.addHooks(&failAfterWrites{store: store, writes: 2})
+		if err := client.SetSecretChunked(ctx, "app/cert", "abcdefgh", 4); err == nil || !strings.Contains(err.Error(), "disk full") {
+			t.Errorf("expected manifest write error, got %v", err)
+		}
+	})
+}
+
+// failAfterWrites makes store fail once the given number of writes succeeded.
+type failAfterWrites struct {
+	store  *mockStore
+	writes int
+}
+
+func (h *failAfterWrites) OnRead(ctx context.Context, path string) {}
+
+func (h *failAfterWrites) OnError(ctx context.Context, op, path string, err error) {}
+
+func (h *failAfterWrites) OnWrite(ctx context.Context, op, path string) {
+	h.writes--
+	if h.writes == 0 {
+		h.store.shouldFail = true
+		h.store.failMsg = "disk full"
+	}
+}
+
+func TestGopassClient_RemoveSecretChunks_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store not found", func(t *testing.T) {
+		client := NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+		if err := client.RemoveSecretChunks(ctx, "app/cert", 0); ErrorCode(err) != CodeStoreNotFound {
+			t.Errorf("expected store not found, got %v", err)
+		}
+	})
+
+	t.Run("list fails", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = &mockStore{shouldFail: true, failMsg: "permission denied"}
+		if err := client.RemoveSecretChunks(ctx, "app/cert", 0); err == nil || !strings.Contains(err.Error(), "failed to list parts") {
+			t.Errorf("expected list error, got %v", err)
+		}
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		client := NewGopassClient(t.TempDir())
+		client.store = storeWith(map[string]string{"app/cert.part1": "abcd"})
+		unlock, err := lockStore(ctx, client.storePath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer unlock()
+
+		// A deadline rather than a canceled context, which removals reject before waiting
+		expiring, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := client.RemoveSecretChunks(expiring, "app/cert", 0); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected remove error, got %v", err)
+		}
+	})
+}
+
+func TestGopassClient_JoinChunks(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifest  string
+		wantValue string
+		wantErr   string
+	}{
+		{name: "chunked", manifest: "\nchunks: 2\n", wantValue: "abcdefgh"},
+		{name: "own password", manifest: "password\nchunks: 2\n", wantValue: "password"},
+		{name: "no chunks field", manifest: "\nuser: admin\n", wantValue: ""},
+		{name: "invalid count", manifest: "\nchunks: many\n", wantErr: `invalid chunks field "many"`},
+		{name: "zero count", manifest: "\nchunks: 0\n", wantErr: `invalid chunks field "0"`},
+		{name: "missing part", manifest: "\nchunks: 3\n", wantErr: `failed to read part 3 of 3 of secret "app/cert"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			store := storeWith(map[string]string{"app/cert.part1": "abcd", "app/cert.part2": "efgh"})
+			store.secrets["app/cert"] = secrets.ParseAKV([]byte(tc.manifest))
+			client.store = store
+
+			value, err := client.GetSecret(context.Background(), "app/cert")
+			_, fullErr := client.GetSecretFull(context.Background(), "app/cert", "")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) || fullErr == nil {
+					t.Errorf("expected error %q, got %v and %v", tc.wantErr, err, fullErr)
+				}
+				return
+			}
+			if err != nil || value != tc.wantValue {
+				t.Errorf("GetSecret() = %q, %v; want %q", value, err, tc.wantValue)
+			}
+		})
+	}
+}
+
+func TestGopassClient_ChunkCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     int
+		wantErr  string
+	}{
+		{name: "chunked", manifest: "\nchunks: 2\n", want: 2},
+		{name: "own password", manifest: "password\nchunks: 2\n"},
+		{name: "no chunks field", manifest: "\nuser: admin\n"},
+		{name: "malformed", manifest: "\nchunks: 2x\n", wantErr: `invalid chunks field "2x"`},
+		{name: "negative", manifest: "\nchunks: -1\n", wantErr: `invalid chunks field "-1"`},
+		{name: "zero", manifest: "\nchunks: 0\n", wantErr: `invalid chunks field "0"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hooks := &recordingHooks{}
+			client := NewGopassClient("")
+			client.addHooks(hooks)
+
+			n, err := client.chunkCount(context.Background(), "app/cert", secrets.ParseAKV([]byte(tc.manifest)))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				if !reflect.DeepEqual(hooks.events, []string{"error get app/cert"}) {
+					t.Errorf("expected the error to be reported to the hooks, got %v", hooks.events)
+				}
+				return
+			}
+			if err != nil || n != tc.want {
+				t.Errorf("chunkCount() = %d, %v; want %d", n, err, tc.want)
+			}
+		})
+	}
+}
+
+// chunkedStore returns a store holding the chunked secret app/cert, a part left over beyond
+// its count, and secrets that merely look like parts.
+func chunkedStore() *mockStore {
+	store := storeWith(map[string]string{
+		"app/cert.part1":  "abcd",
+		"app/cert.part2":  "ef",
+		"app/cert.part3":  "stale",
+		"app/note.part1":  "no secret at app/note",
+		"app/plain":       "not chunked",
+		"app/plain.part1": "own secret",
+		"app/sub/db":      "s3cret",
+	})
+	store.secrets["app/cert"] = secrets.ParseAKV([]byte("\nchunks: 2\nuser: admin\n"))
+	return store
+}
+
+func TestGopassClient_ListWithoutChunkParts(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = chunkedStore()
+
+	names, err := client.ListSecrets(ctx, "app")
+	want := []string{"app/cert", "app/cert.part3", "app/note.part1", "app/plain", "app/plain.part1"}
+	if err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("ListSecrets() = %v, %v; want %v", names, err, want)
+	}
+
+	names, err = client.ListSecretsRecursive(ctx, "app")
+	want = append(want, "app/sub/db")
+	if err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("ListSecretsRecursive() = %v, %v; want %v", names, err, want)
+	}
+
+	nodes, err := client.ListTree(ctx, "app")
+	if err != nil {
+		t.Fatalf("ListTree() error = %v", err)
+	}
+	for _, node := range nodes {
+		if node.Path == "app/cert.part1" || node.Path == "app/cert.part2" {
+			t.Errorf("expected no node for part %s", node.Path)
+		}
+	}
+
+	values, _, err := client.ReadEnvSecretsAt(ctx, "app", "", 1)
+	if err != nil {
+		t.Fatalf("ReadEnvSecretsAt() error = %v", err)
+	}
+	if _, ok := values["cert.part1"]; ok || values["cert"] != "abcdef" {
+		t.Errorf("expected the joined value under cert only, got %v", values)
+	}
+}
+
+func TestGopassClient_ListWithoutChunkParts_Unreadable(t *testing.T) {
+	store := chunkedStore()
+	store.secrets["app/cert"] = secrets.ParseAKV([]byte("\nchunks: many\n"))
+	client := NewGopassClient("")
+	client.store = store
+
+	names, err := client.ListSecrets(context.Background(), "app")
+	if err != nil || !strings.Contains(strings.Join(names, ","), "app/cert.part1,app/cert.part2") {
+		t.Errorf("expected the parts of an invalid secret to stay listed, got %v, %v", names, err)
+	}
+}
+
+func TestGopassClient_UnchunkSecret(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("chunked", func(t *testing.T) {
+		store := chunkedStore()
+		client := NewGopassClient("")
+		client.store = store
+
+		if err := client.UnchunkSecret(ctx, "app/cert"); err != nil {
+			t.Fatalf("UnchunkSecret() error = %v", err)
+		}
+		secret := store.secrets["app/cert"]
+		if user, _ := secret.Get("user"); secret.Password() != "abcdef" || user != "admin" {
+			t.Errorf("expected the joined value with its fields, got %q", secret.Bytes())
+		}
+		if _, ok := secret.Get(chunksField); ok {
+			t.Error("expected the chunks field to be removed")
+		}
+		want := []string{"app/cert", "app/note.part1", "app/plain", "app/plain.part1", "app/sub/db"}
+		if names := storeNames(store); !reflect.DeepEqual(names, want) {
+			t.Errorf("expected secrets %v, got %v", want, names)
+		}
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		store := storeWith(map[string]string{"app/cert": "whole", "app/cert.part1": "abcd"})
+		client := NewGopassClient("")
+		client.store = store
+
+		if err := client.UnchunkSecret(ctx, "app/cert"); err != nil {
+			t.Fatalf("UnchunkSecret() error = %v", err)
+		}
+		if names := storeNames(store); len(names) != 1 || store.secrets["app/cert"].Password() != "whole" {
+			t.Errorf("expected only the rewritten secret, got %v", names)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		store := storeWith(map[string]string{"app/cert.part1": "abcd"})
+		client := NewGopassClient("")
+		client.store = store
+
+		if err := client.UnchunkSecret(ctx, "app/cert"); err != nil || len(store.secrets) != 0 {
+			t.Errorf("expected the parts to be removed, got %v, %v", storeNames(store), err)
+		}
+	})
+
+	t.Run("missing part", func(t *testing.T) {
+		store := chunkedStore()
+		delete(store.secrets, "app/cert.part2")
+		client := NewGopassClient("")
+		client.store = store
+
+		if err := client.UnchunkSecret(ctx, "app/cert"); err == nil || !strings.Contains(err.Error(), "failed to read part 2 of 2") {
+			t.Errorf("expected a read error, got %v", err)
+		}
+		if _, ok := store.secrets["app/cert.part1"]; !ok {
+			t.Error("expected the parts to be kept")
+		}
+	})
+}
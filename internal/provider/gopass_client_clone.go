@@ -0,0 +1,182 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// storeClone describes a store the provider cloned from a git remote, see CloneStore.
+type storeClone struct {
+	temporary bool // the clone is in a directory created for it, removed once pushed
+}
+
+// clonedClients are the clients working on a clone, pushed by pushClonedStores.
+var (
+	clonedClientsMu sync.Mutex
+	clonedClients   []*GopassClient
+)
+
+// CloneStore clones the git-backed store at url into the store path of the client, or into a
+// new temporary directory if the client has none, so machines without a checkout of the store
+// can use it, e.g. CI runners. An existing clone of url at the store path is updated instead.
+// Commits are pushed at the end of each RPC applying a resource change, see pushClonedStores.
+//
+// The URL is never logged or included in errors, as it may hold credentials.
+func (c *GopassClient) CloneStore(ctx context.Context, url string) error {
+	dir, temporary := c.storePath, c.storePath == ""
+	if temporary {
+		tmp, err := os.MkdirTemp("", "gopass-store-")
+		if err != nil {
+			return fmt.Errorf("failed to create a directory for the clone: %w", err)
+		}
+		dir = tmp
+	} else {
+		expanded, err := c.expandPath(dir)
+		if err != nil {
+			return err
+		}
+		dir = expanded
+	}
+
+	if err := c.cloneInto(ctx, dir, url); err != nil {
+		if temporary {
+			_ = c.removeAll(dir)
+		}
+		return err
+	}
+
+	c.mu.Lock()
+	c.storePath = dir
+	c.clone = &storeClone{temporary: temporary}
+	c.mu.Unlock()
+
+	clonedClientsMu.Lock()
+	defer clonedClientsMu.Unlock()
+	clonedClients = append(clonedClients, c)
+	return nil
+}
+
+// cloneInto clones the repository at url into dir, or pulls it if dir already is a clone of url.
+func (c *GopassClient) cloneInto(ctx context.Context, dir, url string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		current, found, err := c.gitRemote(ctx, dir, defaultGitRemote)
+		if err != nil {
+			return err
+		}
+		if !found || current != url {
+			return fmt.Errorf("%q already holds a git repository that is not a clone of git_remote", dir)
+		}
+
+		tflog.Debug(ctx, "Updating clone of gopass store", map[string]interface{}{
+			"dir": dir,
+		})
+		if _, err := c.execCommand(ctx, dir, "git", "pull", "--ff-only", "--quiet"); err != nil {
+			return fmt.Errorf("failed to update the clone in %q: %w", dir, err)
+		}
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to check for a clone in %q: %w", dir, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create clone directory %q: %w", dir, err)
+	}
+
+	tflog.Debug(ctx, "Cloning gopass store", map[string]interface{}{
+		"dir": dir,
+	})
+	if _, err := c.execCommand(ctx, dir, "git", "clone", "--quiet", "--", url, "."); err != nil {
+		return fmt.Errorf("failed to clone the store into %q: %w", dir, err)
+	}
+	return nil
+}
+
+// pushClonedStores pushes the commits not yet pushed of every store cloned by CloneStore. It
+// is called at the end of every RPC applying a resource change, see providerServer, so what
+// a resource wrote is pushed before Terraform records it and a failed push is reported on the
+// resource. Pushes are serialized, so concurrent RPCs do not push the same commits.
+func pushClonedStores(ctx context.Context) error {
+	clonedClientsMu.Lock()
+	defer clonedClientsMu.Unlock()
+
+	var errs []error
+	for _, c := range clonedClients {
+		if err := c.pushClone(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("clone in %q: %w", c.storePath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RemoveTemporaryClones removes the clones CloneStore created in temporary directories. It is
+// called once the provider server stopped, when the clones are no longer used. A clone with
+// commits that could not be pushed is kept, so nothing is lost. Failures are logged, as the
+// run is already over.
+func RemoveTemporaryClones(ctx context.Context) {
+	clonedClientsMu.Lock()
+	defer clonedClientsMu.Unlock()
+
+	for _, c := range clonedClients {
+		if !c.clone.temporary {
+			continue
+		}
+		if count, err := c.unpushedCommits(ctx); err != nil || count != "0" {
+			fields := map[string]interface{}{
+				"dir":     c.storePath,
+				"commits": count,
+			}
+			if err != nil {
+				fields["error"] = err.Error()
+			}
+			tflog.Error(ctx, "Keeping temporary clone of gopass store with commits that were not pushed", fields)
+			continue
+		}
+		if err := c.removeAll(c.storePath); err != nil {
+			tflog.Warn(ctx, "Failed to remove temporary clone of gopass store", map[string]interface{}{
+				"dir":   c.storePath,
+				"error": err.Error(),
+			})
+		}
+	}
+	clonedClients = nil
+}
+
+// pushClone pushes the commits of the clone that its upstream does not have yet.
+func (c *GopassClient) pushClone(ctx context.Context) error {
+	count, err := c.unpushedCommits(ctx)
+	if err != nil {
+		return err
+	}
+	if count == "0" {
+		return nil
+	}
+
+	if _, err := c.execCommand(ctx, c.storePath, "git", "push", "--quiet"); err != nil {
+		return fmt.Errorf("failed to push %s commit(s): %w", count, err)
+	}
+	tflog.Info(ctx, "Pushed gopass store", map[string]interface{}{
+		"dir":     c.storePath,
+		"commits": count,
+	})
+	return nil
+}
+
+// unpushedCommits returns the number of commits of the clone that its upstream does not have.
+func (c *GopassClient) unpushedCommits(ctx context.Context) (string, error) {
+	out, err := c.execCommand(ctx, c.storePath, "git", "rev-list", "--count", "@{upstream}..HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to count unpushed commits: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,329 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+// gitOrigin creates a bare repository holding one commit of a store, to be cloned in tests.
+func gitOrigin(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	work := t.TempDir()
+	writeStoreFile(t, work, ".gpg-id", "0xDEADBEEF\n")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "Initialize store"},
+	} {
+		if _, err := runCommand(ctx, work, "git", args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	origin := filepath.Join(t.TempDir(), "store.git")
+	if _, err := runCommand(ctx, work, "git", "clone", "-q", "--bare", ".", origin); err != nil {
+		t.Fatalf("git clone --bare: %v", err)
+	}
+	return origin
+}
+
+// resetClonedClients restores the registered clones after the test.
+func resetClonedClients(t *testing.T) {
+	t.Helper()
+	clients := clonedClients
+	t.Cleanup(func() { clonedClients = clients })
+	clonedClients = nil
+}
+
+func TestGopassClient_CloneStore_Git(t *testing.T) {
+	resetClonedClients(t)
+	origin := gitOrigin(t)
+	ctx := context.Background()
+
+	// A temporary clone is pushed after each change and removed on shutdown
+	client := NewGopassClient("")
+	if err := client.CloneStore(ctx, origin); err != nil {
+		t.Fatalf("CloneStore() error = %v", err)
+	}
+	dir := client.storePath
+	if _, err := os.Stat(filepath.Join(dir, ".gpg-id")); err != nil {
+		t.Fatalf("expected a clone of the store in %q: %v", dir, err)
+	}
+	writeStoreFile(t, dir, "app/db.gpg", "encrypted")
+	for _, args := range [][]string{
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "-m", "Save app/db"},
+	} {
+		if _, err := runCommand(ctx, dir, "git", args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+
+	var output bytes.Buffer
+	if err := pushClonedStores(tflogtest.RootLogger(ctx, &output)); err != nil {
+		t.Fatalf("pushClonedStores() error = %v", err)
+	}
+	if log, err := runCommand(ctx, origin, "git", "log", "--format=%s"); err != nil || !strings.Contains(string(log), "Save app/db") {
+		t.Errorf("expected the commit to be pushed, got %q, %v", log, err)
+	}
+	if !strings.Contains(output.String(), `"commits":"1"`) {
+		t.Errorf("expected the push to be logged, got %s", output.String())
+	}
+	RemoveTemporaryClones(ctx)
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the temporary clone to be removed, got %v", err)
+	}
+	if len(clonedClients) != 0 {
+		t.Errorf("expected no clones to be left, got %d", len(clonedClients))
+	}
+
+	// A clone in clone_dir is kept, and pulled by the next run
+	cloneDir := filepath.Join(t.TempDir(), "store")
+	for run := 0; run < 2; run++ {
+		client := NewGopassClient(cloneDir)
+		if err := client.CloneStore(ctx, origin); err != nil {
+			t.Fatalf("CloneStore() run %d error = %v", run, err)
+		}
+		RemoveTemporaryClones(ctx)
+		if _, err := os.Stat(filepath.Join(cloneDir, "app", "db.gpg")); err != nil {
+			t.Errorf("run %d: expected the clone to be kept up to date: %v", run, err)
+		}
+	}
+}
+
+func TestGopassClient_CloneStore_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		dir     func(t *testing.T) string
+		remotes map[string]string
+		failOn  string
+		wantErr string
+	}{
+		{
+			name: "temporary directory fails",
+			dir: func(t *testing.T) string {
+				t.Setenv("TMPDIR", filepath.Join(t.TempDir(), "missing"))
+				return ""
+			},
+			wantErr: "failed to create a directory for the clone",
+		},
+		{
+			name:    "home unknown",
+			dir:     func(t *testing.T) string { return "~/store" },
+			wantErr: "failed to expand home directory",
+		},
+		{
+			name:    "clone dir is a file",
+			dir:     brokenStorePath,
+			wantErr: "failed to check for a clone",
+		},
+		{
+			name: "clone dir cannot be created",
+			dir: func(t *testing.T) string {
+				dir := filepath.Join(t.TempDir(), "dangling")
+				if err := os.Symlink(filepath.Join(t.TempDir(), "missing"), dir); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			wantErr: "failed to create clone directory",
+		},
+		{
+			name:    "clone fails",
+			dir:     func(t *testing.T) string { return "" },
+			failOn:  "clone",
+			wantErr: "failed to clone the store into",
+		},
+		{
+			name:    "remotes fail",
+			dir:     gitStoreDir,
+			failOn:  "remote",
+			wantErr: "failed to list git remotes",
+		},
+		{
+			name:    "other repository",
+			dir:     gitStoreDir,
+			remotes: map[string]string{"origin": "git@example.com:other.git"},
+			wantErr: "already holds a git repository that is not a clone of git_remote",
+		},
+		{
+			name:    "no origin",
+			dir:     gitStoreDir,
+			remotes: map[string]string{"upstream": "git@example.com:store.git"},
+			wantErr: "already holds a git repository that is not a clone of git_remote",
+		},
+		{
+			name:    "pull fails",
+			dir:     gitStoreDir,
+			remotes: map[string]string{"origin": "git@example.com:store.git"},
+			failOn:  "pull",
+			wantErr: "failed to update the clone",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetClonedClients(t)
+			client := NewGopassClient(tc.dir(t))
+			client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+			fake := newFakeRemotes(tc.remotes)
+			fake.failOn = tc.failOn
+			client.execCommand = fake.run
+
+			err := client.CloneStore(ctx, "git@example.com:store.git")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if strings.Contains(err.Error(), "example.com") {
+				t.Errorf("expected the URL to be left out of the error, got %v", err)
+			}
+			if len(clonedClients) != 0 || client.clone != nil {
+				t.Error("expected no clone to be registered")
+			}
+			if tc.failOn == "clone" {
+				if _, err := os.Stat(fake.dirs[0]); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("expected the temporary directory to be removed, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPushClonedStores(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     string
+		failOn    string
+		wantCalls []string
+		wantErr   string
+		wantLog   string
+	}{
+		{
+			name: "nothing to push", count: "0\n",
+			wantCalls: []string{"git rev-list --count @{upstream}..HEAD"},
+		},
+		{
+			name: "pushed", count: "2\n",
+			wantCalls: []string{"git rev-list --count @{upstream}..HEAD", "git push --quiet"},
+			wantLog:   "Pushed gopass store",
+		},
+		{
+			name: "count fails", failOn: "git rev-list",
+			wantCalls: []string{"git rev-list --count @{upstream}..HEAD"},
+			wantErr:   "failed to count unpushed commits",
+		},
+		{
+			name: "push fails", count: "2\n", failOn: "git push",
+			wantCalls: []string{"git rev-list --count @{upstream}..HEAD", "git push --quiet"},
+			wantErr:   "failed to push 2 commit(s)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetClonedClients(t)
+			client := NewGopassClient(t.TempDir())
+			client.clone = &storeClone{temporary: true}
+			var calls []string
+			client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+				call := strings.Join(append([]string{name}, args...), " ")
+				calls = append(calls, call)
+				if tc.failOn != "" && strings.HasPrefix(call, tc.failOn) {
+					return nil, errors.New("git failed")
+				}
+				return []byte(tc.count), nil
+			}
+			clonedClients = []*GopassClient{client}
+
+			var output bytes.Buffer
+			err := pushClonedStores(tflogtest.RootLogger(context.Background(), &output))
+
+			if strings.Join(calls, ",") != strings.Join(tc.wantCalls, ",") {
+				t.Errorf("expected git calls %q, got %q", tc.wantCalls, calls)
+			}
+			if tc.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr) || !strings.Contains(err.Error(), client.storePath)) {
+				t.Errorf("expected error %q naming the clone, got %v", tc.wantErr, err)
+			}
+			if !strings.Contains(output.String(), tc.wantLog) {
+				t.Errorf("expected log %q, got %s", tc.wantLog, output.String())
+			}
+			if len(clonedClients) != 1 {
+				t.Error("expected the clone to be pushed again after the next change")
+			}
+		})
+	}
+}
+
+func TestRemoveTemporaryClones(t *testing.T) {
+	tests := []struct {
+		name        string
+		temporary   bool
+		count       string
+		failCount   bool
+		failRemove  bool
+		wantRemoved bool
+		wantLog     string
+	}{
+		{name: "pushed", temporary: true, count: "0\n", wantRemoved: true},
+		{name: "clone_dir", count: "0\n"},
+		{name: "not pushed", temporary: true, count: "2\n", wantLog: "Keeping temporary clone"},
+		{name: "count fails", temporary: true, failCount: true, wantLog: "failed to count unpushed commits"},
+		{name: "remove fails", temporary: true, count: "0\n", failRemove: true, wantRemoved: true, wantLog: "Failed to remove temporary clone"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetClonedClients(t)
+			client := NewGopassClient(t.TempDir())
+			client.clone = &storeClone{temporary: tc.temporary}
+			client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+				if tc.failCount {
+					return nil, errors.New("git failed")
+				}
+				return []byte(tc.count), nil
+			}
+			removed := false
+			client.removeAll = func(path string) error {
+				removed = true
+				if tc.failRemove {
+					return errors.New("busy")
+				}
+				return nil
+			}
+			clonedClients = []*GopassClient{client}
+
+			var output bytes.Buffer
+			RemoveTemporaryClones(tflogtest.RootLogger(context.Background(), &output))
+
+			if removed != tc.wantRemoved {
+				t.Errorf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !strings.Contains(output.String(), tc.wantLog) {
+				t.Errorf("expected log %q, got %s", tc.wantLog, output.String())
+			}
+			if len(clonedClients) != 0 {
+				t.Errorf("expected no clones to be left, got %d", len(clonedClients))
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// openStoreClients are the clients that opened a store, closed by CloseStores.
+var (
+	openStoreClientsMu sync.Mutex
+	openStoreClients   []*GopassClient
+)
+
+// registerOpenStore records that c opened its store, so CloseStores closes it.
+func registerOpenStore(c *GopassClient) {
+	openStoreClientsMu.Lock()
+	defer openStoreClientsMu.Unlock()
+	openStoreClients = append(openStoreClients, c)
+}
+
+// CloseStores closes the stores opened by all clients, releasing what the gopass library
+// holds, e.g. its crypto backend. It is called once the provider server stopped, before the
+// stores are pushed and unlocked. Failures are logged, as the run is already over. A client
+// used afterwards opens its store again.
+func CloseStores(ctx context.Context) {
+	openStoreClientsMu.Lock()
+	defer openStoreClientsMu.Unlock()
+
+	for _, c := range openStoreClients {
+		c.closeStore(ctx)
+	}
+	openStoreClients = nil
+}
+
+// closeStore closes the store of c, if it is open.
+func (c *GopassClient) closeStore(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.store == nil {
+		return
+	}
+	if err := c.store.Close(ctx); err != nil {
+		tflog.Warn(ctx, "Failed to close gopass store", map[string]interface{}{
+			"store_path": c.storePath,
+			"error":      err.Error(),
+		})
+	} else {
+		tflog.Debug(ctx, "Closed gopass store", map[string]interface{}{
+			"store_path": c.storePath,
+		})
+	}
+	c.store = nil
+	c.closePassphrasePipe(ctx)
+}
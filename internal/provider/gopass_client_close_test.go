@@ -0,0 +1,126 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// closeRecordingStore is a mockStore that counts how often it was closed.
+type closeRecordingStore struct {
+	*mockStore
+	closed int
+}
+
+func (s *closeRecordingStore) Close(ctx context.Context) error {
+	s.closed++
+	return s.mockStore.Close(ctx)
+}
+
+func TestCloseStores(t *testing.T) {
+	openStoreClientsMu.Lock()
+	previous := openStoreClients
+	openStoreClients = nil
+	openStoreClientsMu.Unlock()
+	t.Cleanup(func() { openStoreClients = previous })
+
+	ctx := context.Background()
+	healthy := &closeRecordingStore{mockStore: storeWith(map[string]string{"app/db": "secret"})}
+	failing := &closeRecordingStore{mockStore: &mockStore{secrets: map[string]gopass.Secret{}, failMsg: "backend gone"}}
+
+	var clients []*GopassClient
+	for _, store := range []*closeRecordingStore{healthy, failing} {
+		client := NewGopassClient("")
+		client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+			return store, nil
+		}
+		if _, err := client.SecretExists(ctx, "app/db"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		clients = append(clients, client)
+	}
+	failing.shouldFail = true
+	// A client whose store was never opened has nothing to close
+	NewGopassClient("")
+
+	CloseStores(ctx)
+	if healthy.closed != 1 || failing.closed != 1 {
+		t.Errorf("expected each store to be closed once, got %d and %d", healthy.closed, failing.closed)
+	}
+	for _, client := range clients {
+		if client.store != nil {
+			t.Error("expected the store to be released")
+		}
+	}
+
+	// Closing twice does nothing, and a client used afterwards opens its store again
+	CloseStores(ctx)
+	if healthy.closed != 1 {
+		t.Errorf("expected the store to be closed once, got %d", healthy.closed)
+	}
+	if value, err := clients[0].GetSecret(ctx, "app/db"); err != nil || value != "secret" {
+		t.Errorf("expected the store to be opened again, got %q, %v", value, err)
+	}
+	clients[0].closeStore(ctx)
+	clients[0].closeStore(ctx)
+	if healthy.closed != 2 {
+		t.Errorf("expected the reopened store to be closed once, got %d", healthy.closed)
+	}
+}
+
+func TestGopassClient_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := &revisionRecordingStore{mockStore: storeWith(map[string]string{"app/db": "secret"})}
+	client := NewGopassClient("")
+	client.store = store
+
+	if _, err := client.GetSecret(ctx, "app/db"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a canceled read to fail, got %v", err)
+	}
+	if err := client.SetSecret(ctx, "app/db", "rotated"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a canceled write to fail, got %v", err)
+	}
+	if len(store.requested) != 0 {
+		t.Errorf("expected the store not to be used, got %v", store.requested)
+	}
+	if value, _ := client.GetSecret(context.Background(), "app/db"); value != "secret" {
+		t.Errorf("expected the secret to be unchanged, got %q", value)
+	}
+}
+
+// cancelingStore is a mockStore that cancels a context once a secret was read.
+type cancelingStore struct {
+	*mockStore
+	cancel context.CancelFunc
+	gets   int
+}
+
+func (s *cancelingStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	s.gets++
+	s.cancel()
+	return s.mockStore.Get(ctx, name, revision)
+}
+
+func TestGopassClient_ReadEnvSecretsAt_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := &cancelingStore{mockStore: storeWith(map[string]string{"env/A": "a", "env/B": "b", "env/C": "c"}), cancel: cancel}
+	client := NewGopassClient("")
+	client.store = store
+
+	values, failures, err := client.ReadEnvSecretsAt(ctx, "env", "", 0)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the read to be aborted, got %v, %v, %v", values, failures, err)
+	}
+	if store.gets != 1 {
+		t.Errorf("expected no secret to be read after the cancellation, got %d reads", store.gets)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Keys of the fields written by SetSecretParts, as used by gopass and browser integrations.
+const (
+	usernameKey = "username"
+	urlKey      = "url"
+)
+
+// SecretParts are the parts a secret is assembled from by SetSecretParts.
+// Empty fields are omitted.
+type SecretParts struct {
+	Password   string
+	Username   string
+	URL        string
+	ExtraLines []string
+}
+
+// SetSecretParts writes a secret assembled from parts to path, replacing an existing secret:
+// the password as the first line, followed by the username and url fields and the extra lines.
+func (c *GopassClient) SetSecretParts(ctx context.Context, path string, parts SecretParts) error {
+	path = c.resolveMountPath(ctx, path)
+	secret, err := composeSecret(parts)
+	if err != nil {
+		return fmt.Errorf("invalid secret parts for %q: %w", path, err)
+	}
+	if err := c.checkPolicy(ctx, path, parts.Password); err != nil {
+		return err
+	}
+
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
+
+	tflog.Debug(ctx, "Writing composed secret", map[string]interface{}{
+		"path":        path,
+		"extra_lines": len(parts.ExtraLines),
+	})
+
+	return c.writeSecret(ctx, path, secret)
+}
+
+// composeSecret assembles parts in the gopass key-value format.
+func composeSecret(parts SecretParts) (*secrets.AKV, error) {
+	for _, field := range []struct{ name, value string }{
+		{"password", parts.Password},
+		{usernameKey, parts.Username},
+		{urlKey, parts.URL},
+	} {
+		if strings.ContainsAny(field.value, "\r\n") {
+			return nil, fmt.Errorf("%s must be a single line", field.name)
+		}
+	}
+
+	var b bytes.Buffer
+	defer func() { wipe(b.Bytes()) }()
+	b.WriteString(parts.Password + "\n")
+	if parts.Username != "" {
+		fmt.Fprintf(&b, "%s: %s\n", usernameKey, parts.Username)
+	}
+	if parts.URL != "" {
+		fmt.Fprintf(&b, "%s: %s\n", urlKey, parts.URL)
+	}
+	for _, line := range parts.ExtraLines {
+		b.WriteString(line + "\n")
+	}
+
+	return secrets.ParseAKV(b.Bytes()), nil
+}
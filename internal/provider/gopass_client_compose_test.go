@@ -0,0 +1,80 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGopassClient_SetSecretParts(t *testing.T) {
+	tests := []struct {
+		name      string
+		parts     SecretParts
+		wantBytes string
+	}{
+		{
+			name:      "all parts",
+			parts:     SecretParts{Password: "pw", Username: "admin", URL: "https://example.com", ExtraLines: []string{"note", "otpauth://totp/x"}},
+			wantBytes: "pw\nusername: admin\nurl: https://example.com\nnote\notpauth://totp/x\n",
+		},
+		{name: "password only", parts: SecretParts{Password: "pw"}, wantBytes: "pw\n"},
+		{name: "username only", parts: SecretParts{Username: "admin"}, wantBytes: "\nusername: admin\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			client := NewGopassClient("")
+			client.store = store
+
+			if err := client.SetSecretParts(context.Background(), "websites/example", tc.parts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			secret := store.secrets["websites/example"]
+			if string(secret.Bytes()) != tc.wantBytes {
+				t.Errorf("expected %q, got %q", tc.wantBytes, secret.Bytes())
+			}
+			if secret.Password() != tc.parts.Password {
+				t.Errorf("expected password %q, got %q", tc.parts.Password, secret.Password())
+			}
+			if got, _ := secret.Get(usernameKey); got != tc.parts.Username {
+				t.Errorf("expected username %q, got %q", tc.parts.Username, got)
+			}
+		})
+	}
+}
+
+func TestGopassClient_SetSecretParts_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		parts   SecretParts
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{name: "multi-line password", parts: SecretParts{Password: "a\nb"}, wantErr: "password must be a single line"},
+		{name: "multi-line username", parts: SecretParts{Username: "a\r"}, wantErr: "username must be a single line"},
+		{name: "multi-line url", parts: SecretParts{URL: "a\nb"}, wantErr: "url must be a single line"},
+		{name: "store init failure", setup: failingStoreInit, wantErr: "init failed"},
+		{name: "write failure", setup: failingStore("encryption failed"), wantErr: "failed to write secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			client.store = newMockStore()
+			if tc.setup != nil {
+				client.store = nil
+				tc.setup(client)
+			}
+
+			err := client.SetSecretParts(context.Background(), "websites/example", tc.parts)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gopasspw/gopass/pkg/appdir"
+	"github.com/gopasspw/gopass/pkg/gitconfig"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Scopes of gopass config entries: the per-user config, or the config of a single store that
+// overrides it for the secrets of that store.
+const (
+	configScopeGlobal = "global"
+	configScopeStore  = "store"
+)
+
+// gopassConfigFile is the name of the gopass config in the user's config directory and in stores.
+const gopassConfigFile = "config"
+
+// configPath returns the gopass config file of scope: the per-user config that `gopass config`
+// writes to, or the config in the root of the store of the provider.
+func (c *GopassClient) configPath(scope string) (string, error) {
+	if scope == configScopeStore {
+		dir, err := c.storeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, gopassConfigFile), nil
+	}
+	return filepath.Join(appdir.UserConfig(), gopassConfigFile), nil
+}
+
+// GetConfig returns the value of the gopass config key, e.g. "core.autosync", at scope.
+// The boolean result is false if the key is not set there, also if the config file is missing.
+func (c *GopassClient) GetConfig(ctx context.Context, scope, key string) (string, bool, error) {
+	file, err := c.configPath(scope)
+	if err != nil {
+		return "", false, err
+	}
+
+	tflog.Debug(ctx, "Reading gopass config", map[string]interface{}{
+		"file": file,
+		"key":  key,
+	})
+
+	cfg, err := gitconfig.LoadConfig(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, fmt.Errorf("failed to read gopass config %s: %w", file, err)
+	}
+	value, ok := cfg.Get(key)
+	return value, ok, nil
+}
+
+// SetConfig sets the gopass config key at scope to value, creating the config file if needed.
+// Comments and other keys in the file are kept.
+func (c *GopassClient) SetConfig(ctx context.Context, scope, key, value string) error {
+	file, err := c.configPath(scope)
+	if err != nil {
+		return err
+	}
+
+	tflog.Debug(ctx, "Writing gopass config", map[string]interface{}{
+		"file": file,
+		"key":  key,
+	})
+
+	unlock, err := c.lockConfig(ctx, scope, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory of gopass config %s: %w", file, err)
+	}
+	// The config is only written to a file it was loaded from, so a missing one is created empty
+	cfg, err := gitconfig.LoadConfig(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err = os.WriteFile(file, nil, 0o600); err == nil {
+			cfg, err = gitconfig.LoadConfig(file)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load gopass config %s: %w", file, err)
+	}
+	if err := cfg.Set(key, value); err != nil {
+		return fmt.Errorf("failed to set %s in gopass config %s: %w", key, file, err)
+	}
+	return nil
+}
+
+// UnsetConfig removes the gopass config key at scope, so gopass falls back to its default.
+// A key that is not set is not an error.
+func (c *GopassClient) UnsetConfig(ctx context.Context, scope, key string) error {
+	file, err := c.configPath(scope)
+	if err != nil {
+		return err
+	}
+
+	tflog.Debug(ctx, "Removing gopass config", map[string]interface{}{
+		"file": file,
+		"key":  key,
+	})
+
+	unlock, err := c.lockConfig(ctx, scope, file)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	cfg, err := gitconfig.LoadConfig(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read gopass config %s: %w", file, err)
+	}
+	// Errors name the file already
+	return cfg.Unset(key)
+}
+
+// lockConfig queues writes to the config file of scope, so concurrent resources do not
+// overwrite each other's keys. The config of a store is written like its other files.
+func (c *GopassClient) lockConfig(ctx context.Context, scope, file string) (func(), error) {
+	if scope == configScopeStore {
+		return c.lockRoot(ctx, filepath.Dir(file))
+	}
+	return lockStore(ctx, filepath.Dir(file), c.metrics.countLockWait)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGopassClient_Config(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope string
+		file  func(home, store string) string
+	}{
+		{name: "global", scope: configScopeGlobal, file: func(home, store string) string {
+			return filepath.Join(home, ".config", "gopass", gopassConfigFile)
+		}},
+		{name: "store", scope: configScopeStore, file: func(home, store string) string {
+			return filepath.Join(store, gopassConfigFile)
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			home, store := t.TempDir(), t.TempDir()
+			t.Setenv("GOPASS_HOMEDIR", home)
+			client := NewGopassClient(store)
+			file := tc.file(home, store)
+
+			if _, ok, err := client.GetConfig(ctx, tc.scope, "core.autosync"); err != nil || ok {
+				t.Fatalf("expected a missing config to have no keys, got %v, %v", ok, err)
+			}
+			if err := client.UnsetConfig(ctx, tc.scope, "core.autosync"); err != nil {
+				t.Fatalf("expected unsetting in a missing config to succeed, got %v", err)
+			}
+
+			if err := client.SetConfig(ctx, tc.scope, "core.autosync", "false"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := client.SetConfig(ctx, tc.scope, "mounts.work.path", "/srv/work"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			content, err := os.ReadFile(file)
+			if err != nil || !strings.Contains(string(content), "autosync = false") {
+				t.Fatalf("expected the key to be written to %s, got %q, %v", file, content, err)
+			}
+
+			value, ok, err := client.GetConfig(ctx, tc.scope, "mounts.work.path")
+			if err != nil || !ok || value != "/srv/work" {
+				t.Errorf("expected /srv/work, got %q, %v, %v", value, ok, err)
+			}
+
+			if err := client.UnsetConfig(ctx, tc.scope, "core.autosync"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok, _ := client.GetConfig(ctx, tc.scope, "core.autosync"); ok {
+				t.Error("expected core.autosync to be removed")
+			}
+			if _, ok, _ := client.GetConfig(ctx, tc.scope, "mounts.work.path"); !ok {
+				t.Error("expected mounts.work.path to be kept")
+			}
+		})
+	}
+}
+
+func TestGopassClient_Config_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store not found", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "")
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+
+		if _, _, err := client.GetConfig(ctx, configScopeStore, "core.autosync"); err == nil {
+			t.Error("expected GetConfig to fail")
+		}
+		if err := client.SetConfig(ctx, configScopeStore, "core.autosync", "false"); err == nil {
+			t.Error("expected SetConfig to fail")
+		}
+		if err := client.UnsetConfig(ctx, configScopeStore, "core.autosync"); err == nil {
+			t.Error("expected UnsetConfig to fail")
+		}
+	})
+
+	t.Run("store is a file", func(t *testing.T) {
+		store := filepath.Join(t.TempDir(), "store")
+		if err := os.WriteFile(store, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		client := NewGopassClient(store)
+
+		if _, _, err := client.GetConfig(ctx, configScopeStore, "core.autosync"); err == nil || !strings.Contains(err.Error(), "failed to read gopass config") {
+			t.Errorf("expected GetConfig to fail, got %v", err)
+		}
+		if err := client.SetConfig(ctx, configScopeStore, "core.autosync", "false"); err == nil || !strings.Contains(err.Error(), "failed to create directory") {
+			t.Errorf("expected SetConfig to fail, got %v", err)
+		}
+		if err := client.UnsetConfig(ctx, configScopeStore, "core.autosync"); err == nil || !strings.Contains(err.Error(), "failed to read gopass config") {
+			t.Errorf("expected UnsetConfig to fail, got %v", err)
+		}
+	})
+
+	t.Run("config cannot be loaded", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			target string
+		}{
+			{name: "symlink loop", target: gopassConfigFile},
+			{name: "dangling symlink", target: filepath.Join("missing", gopassConfigFile)},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				store := t.TempDir()
+				if err := os.Symlink(tc.target, filepath.Join(store, gopassConfigFile)); err != nil {
+					t.Fatal(err)
+				}
+				client := NewGopassClient(store)
+
+				if err := client.SetConfig(ctx, configScopeStore, "core.autosync", "false"); err == nil || !strings.Contains(err.Error(), "failed to load gopass config") {
+					t.Errorf("expected SetConfig to fail, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		client := NewGopassClient(t.TempDir())
+
+		if err := client.SetConfig(ctx, configScopeStore, "autosync", "false"); err == nil || !strings.Contains(err.Error(), "failed to set autosync") {
+			t.Errorf("expected SetConfig to fail, got %v", err)
+		}
+	})
+
+	t.Run("lock canceled", func(t *testing.T) {
+		store := t.TempDir()
+		client := NewGopassClient(store)
+		unlock, err := lockStore(ctx, store, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer unlock()
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		if err := client.SetConfig(canceled, configScopeStore, "core.autosync", "false"); err == nil {
+			t.Error("expected SetConfig to fail")
+		}
+		if err := client.UnsetConfig(canceled, configScopeStore, "core.autosync"); err == nil {
+			t.Error("expected UnsetConfig to fail")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// CopySecret copies the secret at src, including all fields and the body, to dst, like
+// `gopass cp`. The secret is encrypted for the recipients of dst, so it can be used to
+// promote secrets between mounts. The parts of a chunked secret are copied along. An
+// existing secret at dst is replaced. The password of
+// src is written to dst, so it must meet the password policy like any other written password.
+func (c *GopassClient) CopySecret(ctx context.Context, src, dst string) error {
+	return c.copySecret(ctx, src, dst, true)
+}
+
+// BackupSecret copies the secret at src to dst like CopySecret, whether its password meets
+// the password policy or not: the backup keeps a value that is in the store already.
+func (c *GopassClient) BackupSecret(ctx context.Context, src, dst string) error {
+	return c.copySecret(ctx, src, dst, false)
+}
+
+// copySecret copies the secret at src to dst, checking its password against the password
+// policy if checked is set.
+func (c *GopassClient) copySecret(ctx context.Context, src, dst string, checked bool) error {
+	src, dst = c.resolveMountPath(ctx, src), c.resolveMountPath(ctx, dst)
+	if src == dst {
+		return fmt.Errorf("cannot copy secret %q onto itself", src)
+	}
+
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, dst, err)
+	}
+
+	tflog.Debug(ctx, "Copying secret", map[string]interface{}{
+		"source":      src,
+		"destination": dst,
+	})
+
+	secret, err := c.readSecret(ctx, src, "latest")
+	if err != nil {
+		return c.notifyError(ctx, opGet, src, fmt.Errorf("failed to get secret %q: %w", src, c.classifyNotFound(err)))
+	}
+
+	if checked {
+		password, err := c.joinChunks(ctx, src, "", secret)
+		if err != nil {
+			return err
+		}
+		err = c.checkPolicy(ctx, dst, string(password))
+		wipe(password)
+		if err != nil {
+			return err
+		}
+	}
+
+	parts, err := c.chunkCount(ctx, src, secret)
+	if err != nil {
+		return err
+	}
+	for i := 1; i <= parts; i++ {
+		part, err := c.readSecret(ctx, chunkPath(src, i), "latest")
+		if err != nil {
+			return c.notifyError(ctx, opGet, src, fmt.Errorf("failed to read part %d of %d of secret %q: %w", i, parts, src, err))
+		}
+		if err := c.writeSecret(ctx, chunkPath(dst, i), part); err != nil {
+			return err
+		}
+	}
+
+	// The secret at dst is written last, so readers never find more parts than were written
+	return c.writeSecret(ctx, dst, secret)
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+func TestGopassClient_CopySecret(t *testing.T) {
+	client := NewGopassClient("")
+	mockStore := newMockStore()
+	mockStore.secrets["staging/api"] = secrets.ParseAKV([]byte("s3cret\nusername: svc\nnotes"))
+	client.store = mockStore
+
+	if err := client.CopySecret(context.Background(), "staging/api", "production/api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, exists := mockStore.secrets["production/api"]
+	if !exists {
+		t.Fatal("expected destination to be written")
+	}
+	if string(got.Bytes()) != "s3cret\nusername: svc\nnotes\n" {
+		t.Errorf("expected full secret to be copied, got %q", got.Bytes())
+	}
+	if _, exists := mockStore.secrets["staging/api"]; !exists {
+		t.Error("expected source to be kept")
+	}
+}
+
+func TestGopassClient_CopySecret_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{name: "same path", src: "production/api", setup: func(client *GopassClient) {}, wantErr: "onto itself"},
+		{name: "store init failure", src: "staging/api", setup: failingStoreInit, wantErr: "init failed"},
+		{name: "missing source", src: "staging/api", setup: func(client *GopassClient) { client.store = newMockStore() }, wantErr: "failed to get secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			tc.setup(client)
+
+			err := client.CopySecret(context.Background(), tc.src, "production/api")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGopassClient_CopySecret_Chunked(t *testing.T) {
+	store := storeWith(map[string]string{
+		"staging/api.part1": "s3c",
+		"staging/api.part2": "ret",
+	})
+	store.secrets["staging/api"] = secrets.ParseAKV([]byte("\nchunks: 2\n"))
+	client := clientWith(store)
+
+	if err := client.CopySecret(context.Background(), "staging/api", "production/api"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, part := range []string{"production/api.part1", "production/api.part2"} {
+		if _, ok := store.secrets[part]; !ok {
+			t.Errorf("expected %s to be copied", part)
+		}
+	}
+	value, err := client.GetSecret(context.Background(), "production/api")
+	if err != nil || value != "s3cret" {
+		t.Errorf("expected the copy to hold the whole value, got %q, %v", value, err)
+	}
+}
+
+func TestGopassClient_CopySecret_FailingStore(t *testing.T) {
+	chunked := map[string]string{
+		"staging/api.part1": "long eno",
+		"staging/api.part2": "ugh value",
+	}
+
+	tests := []struct {
+		name    string
+		checked bool // copied with CopySecret, checking the password policy, instead of BackupSecret
+		chunks  string
+		parts   map[string]string
+		failSet string
+		wantErr string
+	}{
+		{name: "invalid chunks field", chunks: "two", parts: chunked, wantErr: `invalid chunks field "two"`},
+		{name: "missing part", chunks: "2", parts: map[string]string{"staging/api.part1": "long eno"}, wantErr: "failed to read part 2 of 2"},
+		{name: "part write failure", chunks: "2", parts: chunked, failSet: "production/api.part1", wantErr: "disk full"},
+		{name: "secret write failure", chunks: "2", parts: chunked, failSet: "production/api", wantErr: "disk full"},
+		{
+			name:    "missing part checked against the policy",
+			checked: true,
+			chunks:  "2",
+			parts:   map[string]string{"staging/api.part1": "long eno"},
+			wantErr: "failed to read part 2 of 2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &envFailStore{mockStore: storeWith(tc.parts), failSet: map[string]bool{tc.failSet: true}}
+			store.secrets["staging/api"] = secrets.ParseAKV([]byte("\nchunks: " + tc.chunks + "\n"))
+			client := clientWith(store)
+			if err := client.SetPasswordPolicy(PasswordPolicy{MinLength: 10}); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			if tc.checked {
+				err = client.CopySecret(ctx, "staging/api", "production/api")
+			} else {
+				err = client.BackupSecret(ctx, "staging/api", "production/api")
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if _, written := store.secrets["production/api"]; written {
+				t.Error("expected the secret not to be written")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// placeholderFile keeps an otherwise empty store folder in git. gopass only lists files
+// with the extension of its crypto backend, so the placeholder never shows up as a secret.
+const placeholderFile = ".gitkeep"
+
+// placeholderPath returns the store-relative path of the placeholder file of dir.
+func placeholderPath(dir string) string {
+	return filepath.ToSlash(filepath.Join(dir, placeholderFile))
+}
+
+// HasDirectoryPlaceholder reports whether the store folder dir has a placeholder file.
+func (c *GopassClient) HasDirectoryPlaceholder(ctx context.Context, dir string) (bool, error) {
+	dir = c.storeName(ctx, dir)
+	root, err := c.storeDir()
+	if err != nil {
+		return false, err
+	}
+
+	rel := placeholderPath(dir)
+	tflog.Debug(ctx, "Checking folder placeholder", map[string]interface{}{
+		"path": rel,
+	})
+
+	if _, err := os.Stat(filepath.Join(root, rel)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check placeholder %q: %w", rel, err)
+	}
+	return true, nil
+}
+
+// CreateDirectoryPlaceholder creates the store folder dir with an empty placeholder file,
+// so the folder exists before it holds any secret, and commits it if the store is a git
+// repository.
+func (c *GopassClient) CreateDirectoryPlaceholder(ctx context.Context, dir string) error {
+	dir = c.storeName(ctx, dir)
+	root, err := c.storeDir()
+	if err != nil {
+		return err
+	}
+
+	rel := placeholderPath(dir)
+	tflog.Debug(ctx, "Writing folder placeholder", map[string]interface{}{
+		"path": rel,
+	})
+
+	unlock, err := c.lockRoot(ctx, root)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return fmt.Errorf("failed to create folder %q: %w", dir, err)
+	}
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		return fmt.Errorf("failed to write placeholder %q: %w", rel, err)
+	}
+
+	return c.commitStoreFile(ctx, root, rel, "Create folder "+dir)
+}
+
+// RemoveDirectoryPlaceholder removes the placeholder file of the store folder dir. The
+// secrets in the folder are kept. A missing placeholder is not an error.
+func (c *GopassClient) RemoveDirectoryPlaceholder(ctx context.Context, dir string) error {
+	dir = c.storeName(ctx, dir)
+	root, err := c.storeDir()
+	if err != nil {
+		return err
+	}
+
+	rel := placeholderPath(dir)
+	tflog.Debug(ctx, "Removing folder placeholder", map[string]interface{}{
+		"path": rel,
+	})
+
+	unlock, err := c.lockRoot(ctx, root)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(filepath.Join(root, rel)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove placeholder %q: %w", rel, err)
+	}
+
+	return c.commitStoreFile(ctx, root, rel, "Remove placeholder "+rel)
+}
+
+// RemoveDirectory removes the store folder dir with all secrets below it, like
+// `gopass rm --recursive`. Nothing is removed if any of them matches protected_paths.
+func (c *GopassClient) RemoveDirectory(ctx context.Context, dir string) error {
+	dir = c.resolveMountPath(ctx, dir)
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opRemove, dir, err)
+	}
+	if err := c.checkRemoveSupported(ctx, dir); err != nil {
+		return c.notifyError(ctx, opRemove, dir, err)
+	}
+
+	tflog.Debug(ctx, "Removing folder", map[string]interface{}{
+		"path": dir,
+	})
+
+	unlock, err := c.lockWrites(ctx, dir)
+	if err != nil {
+		return c.notifyError(ctx, opRemove, dir, err)
+	}
+	defer unlock()
+	defer c.cache.clear()
+
+	if err := c.checkRemovableTree(ctx, dir); err != nil {
+		return c.notifyError(ctx, opRemove, dir, err)
+	}
+	if err := c.store.RemoveAll(ctx, dir); err != nil {
+		return c.notifyError(ctx, opRemove, dir, fmt.Errorf("failed to remove folder %q: %w", dir, err))
+	}
+
+	c.notifyWrite(ctx, opRemove, dir)
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGopassClient_DirectoryPlaceholder_Lifecycle(t *testing.T) {
+	mountStores(t, "teams")
+	ctx := context.Background()
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+
+	if found, err := client.HasDirectoryPlaceholder(ctx, "teams/payments"); err != nil || found {
+		t.Fatalf("HasDirectoryPlaceholder() before create: expected (false, nil), got (%v, %v)", found, err)
+	}
+
+	if err := client.CreateDirectoryPlaceholder(ctx, "teams:payments"); err != nil {
+		t.Fatalf("CreateDirectoryPlaceholder(): unexpected error: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "teams", "payments", ".gitkeep")); err != nil || info.Size() != 0 {
+		t.Fatalf("expected empty placeholder file, got %v", err)
+	}
+	if found, err := client.HasDirectoryPlaceholder(ctx, "teams/payments"); err != nil || !found {
+		t.Errorf("HasDirectoryPlaceholder() after create: expected (true, nil), got (%v, %v)", found, err)
+	}
+
+	if err := client.RemoveDirectoryPlaceholder(ctx, "teams/payments"); err != nil {
+		t.Fatalf("RemoveDirectoryPlaceholder(): unexpected error: %v", err)
+	}
+	if err := client.RemoveDirectoryPlaceholder(ctx, "teams/payments"); err != nil {
+		t.Errorf("RemoveDirectoryPlaceholder() of missing placeholder: unexpected error: %v", err)
+	}
+}
+
+func TestGopassClient_DirectoryPlaceholder_Git(t *testing.T) {
+	ctx := context.Background()
+	dir := gitStoreDir(t)
+	client := NewGopassClient(dir)
+	var calls []string
+	client.execCommand = fakeStoreGit(&calls, "?? teams/payments/.gitkeep\n", "")
+
+	if err := client.CreateDirectoryPlaceholder(ctx, "teams/payments"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.RemoveDirectoryPlaceholder(ctx, "teams/payments"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"git commit --message Create folder teams/payments -- teams/payments/.gitkeep",
+		"git commit --message Remove placeholder teams/payments/.gitkeep -- teams/payments/.gitkeep",
+	}
+	if len(calls) != 6 || calls[2] != want[0] || calls[5] != want[1] {
+		t.Errorf("expected commits %q, got %v", want, calls)
+	}
+}
+
+func TestGopassClient_DirectoryPlaceholder_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store dir unknown", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "")
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+
+		if _, err := client.HasDirectoryPlaceholder(ctx, "a"); err == nil {
+			t.Error("HasDirectoryPlaceholder: expected error")
+		}
+		if err := client.CreateDirectoryPlaceholder(ctx, "a"); err == nil {
+			t.Error("CreateDirectoryPlaceholder: expected error")
+		}
+		if err := client.RemoveDirectoryPlaceholder(ctx, "a"); err == nil {
+			t.Error("RemoveDirectoryPlaceholder: expected error")
+		}
+	})
+
+	t.Run("store is a file", func(t *testing.T) {
+		client := NewGopassClient(brokenStorePath(t))
+
+		if _, err := client.HasDirectoryPlaceholder(ctx, "a"); err == nil || !strings.Contains(err.Error(), "failed to check placeholder") {
+			t.Errorf("HasDirectoryPlaceholder: expected check error, got %v", err)
+		}
+		if err := client.CreateDirectoryPlaceholder(ctx, "a"); err == nil || !strings.Contains(err.Error(), "failed to create folder") {
+			t.Errorf("CreateDirectoryPlaceholder: expected folder error, got %v", err)
+		}
+		if err := client.RemoveDirectoryPlaceholder(ctx, "a"); err == nil || !strings.Contains(err.Error(), "failed to remove placeholder") {
+			t.Errorf("RemoveDirectoryPlaceholder: expected remove error, got %v", err)
+		}
+	})
+
+	t.Run("placeholder is a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeStoreFile(t, dir, "a/.gitkeep/file", "x")
+		client := NewGopassClient(dir)
+
+		if err := client.CreateDirectoryPlaceholder(ctx, "a"); err == nil || !strings.Contains(err.Error(), "failed to write placeholder") {
+			t.Errorf("CreateDirectoryPlaceholder: expected write error, got %v", err)
+		}
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		dir := t.TempDir()
+		client := NewGopassClient(dir)
+		unlock, err := lockStore(ctx, dir, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer unlock()
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := client.CreateDirectoryPlaceholder(canceled, "a"); !errors.Is(err, context.Canceled) {
+			t.Errorf("CreateDirectoryPlaceholder: expected lock error, got %v", err)
+		}
+		if err := client.RemoveDirectoryPlaceholder(canceled, "a"); !errors.Is(err, context.Canceled) {
+			t.Errorf("RemoveDirectoryPlaceholder: expected lock error, got %v", err)
+		}
+	})
+}
+
+func TestGopassClient_RemoveDirectory(t *testing.T) {
+	mountStores(t, "teams")
+	ctx := context.Background()
+
+	t.Run("removes subtree", func(t *testing.T) {
+		client := NewGopassClient("")
+		store := storeWith(map[string]string{"teams/payments/db": "a", "teams/payments/api/key": "b", "teams/search/db": "c"})
+		client.store = store
+
+		if err := client.RemoveDirectory(ctx, "teams:payments"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(store.secrets) != 1 || store.secrets["teams/search/db"] == nil {
+			t.Errorf("expected only teams/search/db to remain, got %v", store.secrets)
+		}
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = &mockStore{shouldFail: true, failMsg: "permission denied"}
+
+		if err := client.RemoveDirectory(ctx, "teams/payments"); err == nil || !strings.Contains(err.Error(), "failed to remove folder") {
+			t.Errorf("expected remove error, got %v", err)
+		}
+	})
+
+	t.Run("store not found", func(t *testing.T) {
+		client := NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+
+		if err := client.RemoveDirectory(ctx, "teams/payments"); ErrorCode(err) != CodeStoreNotFound {
+			t.Errorf("expected store not found, got %v", err)
+		}
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		dir := t.TempDir()
+		client := NewGopassClient(dir)
+		client.store = newMockStore()
+		// The folder is in the store mounted as teams, which is locked instead of the root
+		unlock, err := lockStore(ctx, "/srv/teams", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer unlock()
+
+		// A deadline rather than a canceled context, which removals reject before waiting
+		expiring, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if err := client.RemoveDirectory(expiring, "teams/payments"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected lock error, got %v", err)
+		}
+	})
+}
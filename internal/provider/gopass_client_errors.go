@@ -0,0 +1,78 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// ErrSecretNotFound is wrapped by errors of GopassClient methods when there is no
+// secret at the requested path. Check for it with errors.Is.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// defaultNotFoundPatterns match the messages of not-found errors returned by gopass and
+// its storage backends. gopass's own sentinel error lives in an internal package and
+// cannot be matched with errors.Is, so its message is listed first.
+var defaultNotFoundPatterns = []string{
+	"entry is not in the password store",
+	"not found",
+	"does not exist",
+}
+
+// notFoundError marks a store error as ErrSecretNotFound while keeping its message.
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string { return e.err.Error() }
+
+func (e *notFoundError) Unwrap() []error { return []error{ErrSecretNotFound, e.err} }
+
+// AddNotFoundPatterns registers additional error message substrings that indicate a
+// missing secret, e.g. localized messages or those of custom storage backends.
+// Patterns are matched case-insensitively; empty patterns are ignored.
+func (c *GopassClient) AddNotFoundPatterns(patterns ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range patterns {
+		if p != "" {
+			c.notFoundPatterns = append(c.notFoundPatterns, strings.ToLower(p))
+		}
+	}
+}
+
+// isNotFound reports whether err, as returned by the store, means that the secret does not exist.
+func (c *GopassClient) isNotFound(err error) bool {
+	if errors.Is(err, ErrSecretNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := strings.ToLower(err.Error())
+	for _, p := range append(defaultNotFoundPatterns, c.notFoundPatterns...) {
+		if strings.Contains(msg, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifyNotFound returns err marked as ErrSecretNotFound if it means that the secret
+// does not exist, and err unchanged otherwise.
+func (c *GopassClient) classifyNotFound(err error) error {
+	if errors.Is(err, ErrSecretNotFound) || !c.isNotFound(err) {
+		return err
+	}
+	return &notFoundError{err: err}
+}
+
+// isNotFoundError reports whether err returned by a GopassClient method means that the
+// secret does not exist.
+func isNotFoundError(err error) bool {
+	return errors.Is(err, ErrSecretNotFound)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestGopassClient_IsNotFound(t *testing.T) {
+	client := NewGopassClient("")
+	client.AddNotFoundPatterns("Eintrag Existiert Nicht", "")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "gopass store error", err: errors.New("entry is not in the password store"), want: true},
+		{name: "generic not found", err: errors.New(`secret "a" not found`), want: true},
+		{name: "does not exist", err: errors.New("mount does not exist"), want: true},
+		{name: "fs sentinel", err: fmt.Errorf("open a.gpg: %w", fs.ErrNotExist), want: true},
+		{name: "client sentinel", err: fmt.Errorf("wrapped: %w", ErrSecretNotFound), want: true},
+		{name: "configured pattern", err: errors.New("eintrag existiert nicht"), want: true},
+		{name: "other error", err: errors.New("failed to decrypt"), want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := client.isNotFound(tc.err); got != tc.want {
+				t.Errorf("isNotFound(%q) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGopassClient_ClassifyNotFound(t *testing.T) {
+	client := NewGopassClient("")
+
+	storeErr := errors.New("entry is not in the password store")
+	classified := client.classifyNotFound(storeErr)
+	if !errors.Is(classified, ErrSecretNotFound) || !errors.Is(classified, storeErr) {
+		t.Errorf("expected error to match ErrSecretNotFound and the store error, got %v", classified)
+	}
+	if classified.Error() != storeErr.Error() {
+		t.Errorf("expected message %q to be kept, got %q", storeErr, classified)
+	}
+
+	if again := client.classifyNotFound(classified); again != classified {
+		t.Errorf("expected classified error to be returned unchanged, got %v", again)
+	}
+
+	otherErr := errors.New("failed to decrypt")
+	if got := client.classifyNotFound(otherErr); got != otherErr {
+		t.Errorf("expected other error to be returned unchanged, got %v", got)
+	}
+}
+
+func TestGopassClient_NotFound_CustomPattern(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.shouldFail = true
+	store.failMsg = "Eintrag existiert nicht"
+
+	client := NewGopassClient("")
+	client.store = store
+
+	if _, err := client.SecretExists(ctx, "a"); err == nil {
+		t.Error("expected unknown error message to be reported without pattern")
+	}
+
+	client.AddNotFoundPatterns("existiert nicht")
+
+	if exists, err := client.SecretExists(ctx, "a"); err != nil || exists {
+		t.Errorf("SecretExists: expected (false, nil), got (%v, %v)", exists, err)
+	}
+	if count, err := client.GetRevisionCount(ctx, "a"); err != nil || count != 0 {
+		t.Errorf("GetRevisionCount: expected (0, nil), got (%d, %v)", count, err)
+	}
+	if err := client.RemoveSecret(ctx, "a"); !isNotFoundError(err) {
+		t.Errorf("RemoveSecret: expected not-found error, got %v", err)
+	}
+}
+
+func TestGopassClient_NotFound_Sentinel(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = newMockStore()
+
+	if _, err := client.GetSecret(ctx, "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret: expected ErrSecretNotFound, got %v", err)
+	}
+	if err := client.RemoveSecret(ctx, "missing"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("RemoveSecret: expected ErrSecretNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// SecretContent is a secret split into its parts, like gopass parses it.
+type SecretContent struct {
+	Password string            // the first line
+	Body     string            // the remaining lines that are no key-value fields
+	Fields   map[string]string // the key-value fields; of repeated keys, the first value
+	Raw      string            // the whole secret as stored, like `gopass show -n`
+}
+
+// Parts of a secret SecretContent.Select can return.
+const (
+	returnPassword = "password" // the password, or the field named by key
+	returnBody     = "body"     // all lines after the password, verbatim
+	returnRaw      = "raw"      // the whole secret
+)
+
+// Select returns the part of the secret named by mode. An empty mode is returnPassword,
+// which looks up key like Lookup. The boolean result is false if the secret has no such field.
+func (s *SecretContent) Select(mode, key string) (string, bool) {
+	switch mode {
+	case returnBody:
+		_, body, _ := strings.Cut(s.Raw, "\n")
+		return body, true
+	case returnRaw:
+		return s.Raw, true
+	}
+	return s.Lookup(key)
+}
+
+// Lookup returns the field key of the secret, or the password if key is empty.
+func (s *SecretContent) Lookup(key string) (string, bool) {
+	if key == "" {
+		return s.Password, true
+	}
+	value, ok := s.Fields[key]
+	return value, ok
+}
+
+// GetSecretFull returns the password, the body and all fields of the secret at path as it
+// existed at snapshot, or of its latest revision if snapshot is empty. Unlike reading the
+// fields one by one, the secret is decrypted only once. The password of a chunked secret is
+// joined from its parts.
+func (c *GopassClient) GetSecretFull(ctx context.Context, path, snapshot string) (*SecretContent, error) {
+	path = c.resolveMountPath(ctx, path)
+	tflog.Debug(ctx, "Reading secret with all fields", map[string]interface{}{
+		"path":     path,
+		"snapshot": snapshot,
+	})
+
+	secret, err := c.getSecretAt(ctx, path, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	password, err := c.joinChunks(ctx, path, snapshot, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := secret.Bytes()
+	content := &SecretContent{
+		Password: string(password),
+		Body:     secret.Body(),
+		Fields:   make(map[string]string),
+		Raw:      string(raw),
+	}
+	wipe(password)
+	wipe(raw)
+	for _, key := range secret.Keys() {
+		// Keys only lists present keys, so the lookup cannot fail
+		content.Fields[key], _ = secret.Get(key)
+	}
+	return content, nil
+}
+
+// LookupSecretField returns the value of the field key of the secret at path.
+// The boolean result is false if the secret has no such field.
+func (c *GopassClient) LookupSecretField(ctx context.Context, path, key string) (string, bool, error) {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return "", false, c.notifyError(ctx, opGet, path, err)
+	}
+
+	tflog.Debug(ctx, "Reading secret field", map[string]interface{}{
+		"path": path,
+		"key":  key,
+	})
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil {
+		return "", false, c.notifyError(ctx, opGet, path, fmt.Errorf("failed to get secret %q: %w", path, c.classifyNotFound(err)))
+	}
+
+	value, ok := secret.Get(key)
+	return value, ok, nil
+}
+
+// LookupSecretFields returns the values of the fields keys of the secret at path, decrypting
+// it only once. Keys the secret has no field for are missing from the result.
+func (c *GopassClient) LookupSecretFields(ctx context.Context, path string, keys ...string) (map[string]string, error) {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return nil, c.notifyError(ctx, opGet, path, err)
+	}
+
+	tflog.Debug(ctx, "Reading secret fields", map[string]interface{}{
+		"path": path,
+		"keys": keys,
+	})
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil {
+		return nil, c.notifyError(ctx, opGet, path, fmt.Errorf("failed to get secret %q: %w", path, c.classifyNotFound(err)))
+	}
+
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := secret.Get(key); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// SetSecretField sets the field key of the secret at path to value, keeping the password,
+// other fields and the body. If the secret does not exist yet, it is created with an empty
+// password. Nothing is written if the field already has the value.
+func (c *GopassClient) SetSecretField(ctx context.Context, path, key, value string) error {
+	return c.SetSecretFields(ctx, path, map[string]string{key: value})
+}
+
+// SetSecretFields sets several fields of the secret at path like SetSecretField, in a single
+// write. Nothing is written if all fields already have their values.
+func (c *GopassClient) SetSecretFields(ctx context.Context, path string, fields map[string]string) error {
+	return c.UpdateSecretFields(ctx, path, fields, nil)
+}
+
+// UpdateSecretFields sets the fields of the secret at path like SetSecretFields and removes
+// the fields remove, all in a single write, so readers never see some of the changes only.
+// Fields to remove the secret does not have are ignored. Nothing is written if nothing changes.
+func (c *GopassClient) UpdateSecretFields(ctx context.Context, path string, fields map[string]string, remove []string) error {
+	return c.UpdateSecret(ctx, path, SecretUpdate{Fields: fields, Remove: remove})
+}
@@ -0,0 +1,264 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+// readOnlySecret is a secret whose fields cannot be set.
+type readOnlySecret struct {
+	*mockSecret
+}
+
+func (s *readOnlySecret) Set(key string, value any) error {
+	return errors.New("read-only")
+}
+
+func TestGopassClient_GetSecretFull(t *testing.T) {
+	mountStores(t, "work")
+	store := newMockStore()
+	store.secrets["work/app/login"] = secrets.ParseAKV([]byte("hunter2\nusername: admin\nnote one\nurl: https://example.com\nusername: other\nnote two\n"))
+	client := NewGopassClient("")
+	client.store = store
+
+	content, err := client.GetSecretFull(context.Background(), "work:app/login", "")
+	if err != nil {
+		t.Fatalf("GetSecretFull() error = %v", err)
+	}
+	want := &SecretContent{
+		Password: "hunter2",
+		Body:     "note one\nnote two\n",
+		Fields:   map[string]string{"username": "admin", "url": "https://example.com"},
+		Raw:      "hunter2\nusername: admin\nnote one\nurl: https://example.com\nusername: other\nnote two\n",
+	}
+	if !reflect.DeepEqual(content, want) {
+		t.Errorf("GetSecretFull() = %+v, want %+v", content, want)
+	}
+
+	for key, wantValue := range map[string]string{"": "hunter2", "username": "admin"} {
+		if value, ok := content.Lookup(key); !ok || value != wantValue {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", key, value, ok, wantValue)
+		}
+	}
+	if _, ok := content.Lookup("password"); ok {
+		t.Error("expected missing field")
+	}
+}
+
+func TestGopassClient_GetSecretFull_Errors(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	if _, err := client.GetSecretFull(context.Background(), "app/missing", ""); !isNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	failingStoreInit(client)
+	client.store = nil
+	if _, err := client.GetSecretFull(context.Background(), "app/db", ""); err == nil || !strings.Contains(err.Error(), "init failed") {
+		t.Errorf("expected init error, got %v", err)
+	}
+}
+
+func TestGopassClient_LookupSecretField(t *testing.T) {
+	store := newMockStore()
+	secret := newMockSecret("hunter2")
+	secret.fields["expires_at"] = "2030-01-01T00:00:00Z"
+	store.secrets["app/db"] = secret
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	value, ok, err := client.LookupSecretField(ctx, "app/db", "expires_at")
+	if err != nil || !ok || value != "2030-01-01T00:00:00Z" {
+		t.Errorf("expected field value, got %q, %v, %v", value, ok, err)
+	}
+
+	value, ok, err = client.LookupSecretField(ctx, "app/db", "username")
+	if err != nil || ok || value != "" {
+		t.Errorf("expected missing field, got %q, %v, %v", value, ok, err)
+	}
+
+	if _, _, err := client.LookupSecretField(ctx, "app/missing", "expires_at"); !isNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestGopassClient_LookupSecretField_StoreInitError(t *testing.T) {
+	client := NewGopassClient("")
+	failingStoreInit(client)
+
+	if _, _, err := client.LookupSecretField(context.Background(), "app/db", "expires_at"); err == nil || !strings.Contains(err.Error(), "init failed") {
+		t.Errorf("expected init error, got %v", err)
+	}
+}
+
+func TestGopassClient_SetSecretField(t *testing.T) {
+	store := newMockStore()
+	store.secrets["app/db"] = newMockSecret("hunter2")
+	store.revisions["app/db"] = []string{"1"}
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	if err := client.SetSecretField(ctx, "app/db", "expires_at", "2030-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret := store.secrets["app/db"]
+	if v, _ := secret.Get("expires_at"); v != "2030-01-01T00:00:00Z" || secret.Password() != "hunter2" {
+		t.Errorf("expected field set and password kept, got %q and %q", v, secret.Password())
+	}
+
+	// Setting the same value again does not write a new revision
+	if err := client.SetSecretField(ctx, "app/db", "expires_at", "2030-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(store.revisions["app/db"]); got != 2 {
+		t.Errorf("expected 2 revisions, got %d", got)
+	}
+
+	// A missing secret is created
+	if err := client.SetSecretField(ctx, "app/new", "expires_at", "2031-01-01T00:00:00Z"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, ok := store.secrets["app/new"].Get("expires_at"); !ok || v != "2031-01-01T00:00:00Z" {
+		t.Errorf("expected new secret with field, got %q", v)
+	}
+}
+
+func TestGopassClient_SetSecretField_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{name: "store init failure", setup: failingStoreInit, wantErr: "init failed"},
+		{name: "read failure", setup: failingStore("decryption failed"), wantErr: "failed to read secret"},
+		{
+			name: "set failure",
+			setup: func(client *GopassClient) {
+				store := newMockStore()
+				store.secrets["app/db"] = &readOnlySecret{newMockSecret("hunter2")}
+				client.store = store
+			},
+			wantErr: `failed to set key "expires_at"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			tc.setup(client)
+
+			err := client.SetSecretField(context.Background(), "app/db", "expires_at", "2030-01-01T00:00:00Z")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+var _ gopass.Secret = &readOnlySecret{}
+
+func TestGopassClient_LookupSecretFields(t *testing.T) {
+	store := newMockStore()
+	store.secrets["app/db"] = secrets.ParseAKV([]byte("hunter2\nusername: admin\nurl: https://example.com\n"))
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	values, err := client.LookupSecretFields(ctx, "app/db", "username", "url", "expires_at")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"username": "admin", "url": "https://example.com"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("expected %v, got %v", want, values)
+	}
+
+	if _, err := client.LookupSecretFields(ctx, "app/missing", "username"); !isNotFoundError(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	failingStoreInit(client)
+	client.store = nil
+	if _, err := client.LookupSecretFields(ctx, "app/db", "username"); err == nil || !strings.Contains(err.Error(), "init failed") {
+		t.Errorf("expected init error, got %v", err)
+	}
+}
+
+func TestGopassClient_SetSecretFields(t *testing.T) {
+	store := newMockStore()
+	store.secrets["app/db"] = newMockSecret("hunter2")
+	store.revisions["app/db"] = []string{"1"}
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	fields := map[string]string{"username": "admin", "url": "https://example.com"}
+	if err := client.SetSecretFields(ctx, "app/db", fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret := store.secrets["app/db"]
+	for key, want := range fields {
+		if v, _ := secret.Get(key); v != want {
+			t.Errorf("expected %s %q, got %q", key, want, v)
+		}
+	}
+	if got := len(store.revisions["app/db"]); got != 2 {
+		t.Errorf("expected both fields in a single write, got %d revisions", got)
+	}
+
+	// Fields that already have their values are not written again
+	if err := client.SetSecretFields(ctx, "app/db", fields); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(store.revisions["app/db"]); got != 2 {
+		t.Errorf("expected 2 revisions, got %d", got)
+	}
+}
+
+func TestGopassClient_UpdateSecretFields(t *testing.T) {
+	store := newMockStore()
+	existing := newMockSecret("hunter2")
+	existing.fields["host"] = "db.internal"
+	existing.fields["port"] = "5432"
+	store.secrets["app/db"] = existing
+	store.revisions["app/db"] = []string{"1"}
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	if err := client.UpdateSecretFields(ctx, "app/db", map[string]string{"host": "db.example.com"}, []string{"port", "missing"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret := store.secrets["app/db"]
+	if host, _ := secret.Get("host"); host != "db.example.com" {
+		t.Errorf("expected host %q, got %q", "db.example.com", host)
+	}
+	if _, ok := secret.Get("port"); ok {
+		t.Error("expected port to be removed")
+	}
+	if secret.Password() != "hunter2" {
+		t.Errorf("expected the password to be kept, got %q", secret.Password())
+	}
+	if got := len(store.revisions["app/db"]); got != 2 {
+		t.Errorf("expected the changes in a single write, got %d revisions", got)
+	}
+
+	// Removing fields the secret no longer has writes nothing
+	if err := client.UpdateSecretFields(ctx, "app/db", nil, []string{"port"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := len(store.revisions["app/db"]); got != 2 {
+		t.Errorf("expected 2 revisions, got %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gopasspw/gopass/pkg/pwgen"
+	"github.com/gopasspw/gopass/pkg/pwgen/pwrules"
+	"github.com/gopasspw/gopass/pkg/pwgen/xkcdgen"
+)
+
+// Generators of gopass that GenerateSecret can use.
+const (
+	// generateCryptic draws random characters of all classes, like GeneratePassword.
+	generateCryptic = "cryptic"
+	// generateMemorable joins random words with digits and, optionally, symbols.
+	generateMemorable = "memorable"
+	// generateXKCD builds an xkcd-style passphrase of dictionary words.
+	generateXKCD = "xkcd"
+	// generateRule follows the published password rules of a domain.
+	generateRule = "rule"
+)
+
+// GenerateSpec selects a gopass password generator and its options.
+type GenerateSpec struct {
+	Type       string // generateCryptic (default), generateMemorable, generateXKCD or generateRule
+	Length     int    // length, the minimum length for generateMemorable; unused by generateXKCD
+	Symbols    bool   // include symbols, for generateCryptic and generateMemorable
+	Words      int    // number of words, for generateXKCD
+	Separator  string // between words, for generateXKCD
+	Lang       string // word list, for generateXKCD
+	Capitalize bool   // capitalize words, for generateMemorable and generateXKCD
+	Numbers    bool   // append numbers to words, for generateXKCD
+	Domain     string // whose password rules to follow, for generateRule
+}
+
+// GenerateSecret creates a random value with the gopass generator selected by spec.
+func (c *GopassClient) GenerateSecret(ctx context.Context, spec GenerateSpec) (string, error) {
+	switch spec.Type {
+	case generateMemorable:
+		return pwgen.GenerateMemorablePassword(spec.Length, spec.Symbols, spec.Capitalize), nil
+	case generateXKCD:
+		password, err := xkcdgen.RandomLengthDelim(spec.Words, spec.Separator, spec.Lang, spec.Capitalize, spec.Numbers)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate passphrase: %w", err)
+		}
+		return password, nil
+	case generateRule:
+		// Without known rules gopass silently falls back to its defaults, which may violate the policy
+		if _, found := pwrules.LookupRule(ctx, spec.Domain); !found {
+			return "", fmt.Errorf("no password rules known for domain %q", spec.Domain)
+		}
+		password := c.pwrule(ctx, spec.Length, spec.Domain)
+		if password == "" {
+			return "", fmt.Errorf("failed to generate a password following the rules of %q", spec.Domain)
+		}
+		return password, nil
+	default:
+		return c.GeneratePassword(spec.Length, spec.Symbols)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGopassClient_GenerateSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    GenerateSpec
+		pwrule  func(ctx context.Context, length int, domain string) string
+		check   func(t *testing.T, password string)
+		wantErr string
+	}{
+		{
+			name: "cryptic",
+			spec: GenerateSpec{Type: generateCryptic, Length: 20, Symbols: true},
+			check: func(t *testing.T, password string) {
+				if len(password) != 20 {
+					t.Errorf("expected 20 characters, got %d", len(password))
+				}
+			},
+		},
+		{
+			name: "memorable",
+			spec: GenerateSpec{Type: generateMemorable, Length: 20, Capitalize: true},
+			check: func(t *testing.T, password string) {
+				if len(password) < 20 || strings.ToLower(password) == password {
+					t.Errorf("expected at least 20 characters with a capital, got %d", len(password))
+				}
+			},
+		},
+		{
+			name: "xkcd",
+			spec: GenerateSpec{Type: generateXKCD, Words: 5, Separator: "-", Lang: "en"},
+			check: func(t *testing.T, password string) {
+				if words := strings.Split(password, "-"); len(words) != 5 {
+					t.Errorf("expected 5 words, got %d", len(words))
+				}
+			},
+		},
+		{
+			name:    "xkcd unknown language",
+			spec:    GenerateSpec{Type: generateXKCD, Words: 4, Separator: " ", Lang: "tlh"},
+			wantErr: "failed to generate passphrase",
+		},
+		{
+			name: "rule",
+			spec: GenerateSpec{Type: generateRule, Length: 32, Domain: "apple.com"},
+			check: func(t *testing.T, password string) {
+				if password == "" {
+					t.Error("expected a password")
+				}
+			},
+		},
+		{
+			name:    "rule unknown domain",
+			spec:    GenerateSpec{Type: generateRule, Length: 32, Domain: "unknown.invalid"},
+			wantErr: `no password rules known for domain "unknown.invalid"`,
+		},
+		{
+			name:    "rule not satisfied",
+			spec:    GenerateSpec{Type: generateRule, Length: 32, Domain: "apple.com"},
+			pwrule:  func(context.Context, int, string) string { return "" },
+			wantErr: `failed to generate a password following the rules of "apple.com"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			if tc.pwrule != nil {
+				client.pwrule = tc.pwrule
+			}
+
+			password, err := client.GenerateSecret(context.Background(), tc.spec)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tc.check(t, password)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+)
+
+// Operation names passed to clientHooks.
+const (
+	opGet    = "get"
+	opList   = "list"
+	opSet    = "set"
+	opRemove = "remove"
+)
+
+// clientHooks receives notifications about store operations performed by GopassClient.
+// It lets the audit log and tests observe store access without patching every client method.
+//
+// Hooks are called synchronously on the goroutine performing the operation,
+// so implementations must be fast and safe for concurrent use. Secret values
+// are never passed to hooks.
+type clientHooks interface {
+	// OnRead is called after a secret at path was read successfully.
+	OnRead(ctx context.Context, path string)
+	// OnWrite is called after a secret at path was modified successfully.
+	// op is opSet or opRemove.
+	OnWrite(ctx context.Context, op, path string)
+	// OnError is called when operation op on path failed.
+	OnError(ctx context.Context, op, path string, err error)
+}
+
+// multiHooks forwards every notification to each of its hooks, in order.
+type multiHooks []clientHooks
+
+// chainHooks returns hooks notifying first and then next. A nil first is left out.
+func chainHooks(first, next clientHooks) clientHooks {
+	if first == nil {
+		return next
+	}
+	return multiHooks{first, next}
+}
+
+// OnRead implements clientHooks.
+func (m multiHooks) OnRead(ctx context.Context, path string) {
+	for _, h := range m {
+		h.OnRead(ctx, path)
+	}
+}
+
+// OnWrite implements clientHooks.
+func (m multiHooks) OnWrite(ctx context.Context, op, path string) {
+	for _, h := range m {
+		h.OnWrite(ctx, op, path)
+	}
+}
+
+// OnError implements clientHooks.
+func (m multiHooks) OnError(ctx context.Context, op, path string, err error) {
+	for _, h := range m {
+		h.OnError(ctx, op, path, err)
+	}
+}
+
+// addHooks installs hooks in addition to the ones installed before, which keep being notified.
+func (c *GopassClient) addHooks(hooks clientHooks) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = chainHooks(c.hooks, hooks)
+}
+
+// currentHooks returns the installed hooks, or nil if none are set.
+func (c *GopassClient) currentHooks() clientHooks {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hooks
+}
+
+// notifyRead reports a successful read to the installed hooks.
+func (c *GopassClient) notifyRead(ctx context.Context, path string) {
+	c.metrics.countRead()
+	if hooks := c.currentHooks(); hooks != nil {
+		hooks.OnRead(ctx, path)
+	}
+}
+
+// notifyWrite reports a successful modification to the installed hooks.
+func (c *GopassClient) notifyWrite(ctx context.Context, op, path string) {
+	c.metrics.countWrite()
+	if hooks := c.currentHooks(); hooks != nil {
+		hooks.OnWrite(ctx, op, path)
+	}
+}
+
+// notifyError reports a failed operation to the installed hooks and returns err,
+// so it can be used inline in return statements. If the context deadline passed,
+// err is annotated with guidance for hardware token users, and if GPG could not ask for the
+// passphrase, with guidance for CI runners.
+func (c *GopassClient) notifyError(ctx context.Context, op, path string, err error) error {
+	err = wrapPinentryError(wrapTimeoutError(ctx, err))
+	c.metrics.countFailure()
+	if hooks := c.currentHooks(); hooks != nil {
+		hooks.OnError(ctx, op, path, err)
+	}
+	return err
+}
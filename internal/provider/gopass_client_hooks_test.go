@@ -0,0 +1,128 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// recordingHooks records every hook invocation as a formatted event string.
+type recordingHooks struct {
+	events []string
+}
+
+func (h *recordingHooks) OnRead(ctx context.Context, path string) {
+	h.events = append(h.events, "read "+path)
+}
+
+func (h *recordingHooks) OnWrite(ctx context.Context, op, path string) {
+	h.events = append(h.events, fmt.Sprintf("write %s %s", op, path))
+}
+
+func (h *recordingHooks) OnError(ctx context.Context, op, path string, err error) {
+	h.events = append(h.events, fmt.Sprintf("error %s %s", op, path))
+}
+
+func TestGopassClient_Hooks_Success(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	hooks := &recordingHooks{}
+	client.addHooks(hooks)
+
+	ctx := context.Background()
+
+	if err := client.SetSecret(ctx, "a/b", "value"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.GetSecret(ctx, "a/b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := client.RemoveSecret(ctx, "a/b"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"write set a/b", "read a/b", "write remove a/b"}
+	if !reflect.DeepEqual(hooks.events, expected) {
+		t.Errorf("expected events %v, got %v", expected, hooks.events)
+	}
+}
+
+func TestGopassClient_Hooks_StoreErrors(t *testing.T) {
+	client := NewGopassClient("")
+	mockStore := newMockStore()
+	mockStore.shouldFail = true
+	mockStore.failMsg = "boom"
+	client.store = mockStore
+	hooks := &recordingHooks{}
+	client.addHooks(hooks)
+
+	ctx := context.Background()
+
+	_, _ = client.GetSecret(ctx, "a/b")
+	_, _ = client.ListSecrets(ctx, "a")
+	_, _ = client.ListSecretsRecursive(ctx, "a")
+	_ = client.SetSecret(ctx, "a/b", "value")
+	_ = client.RemoveSecret(ctx, "a/b")
+
+	expected := []string{
+		"error get a/b",
+		"error list a",
+		"error list a",
+		"error set a/b",
+		"error remove a/b",
+	}
+	if !reflect.DeepEqual(hooks.events, expected) {
+		t.Errorf("expected events %v, got %v", expected, hooks.events)
+	}
+}
+
+func TestGopassClient_Hooks_EnsureStoreErrors(t *testing.T) {
+	client := NewGopassClient("/nonexistent/path/for/test")
+	hooks := &recordingHooks{}
+	client.addHooks(hooks)
+
+	ctx := context.Background()
+
+	_, _ = client.GetSecret(ctx, "a/b")
+	_, _ = client.ListSecrets(ctx, "a")
+	_, _ = client.ListSecretsRecursive(ctx, "a")
+	_ = client.SetSecret(ctx, "a/b", "value")
+	_ = client.RemoveSecret(ctx, "a/b")
+
+	expected := []string{
+		"error get a/b",
+		"error list a",
+		"error list a",
+		"error set a/b",
+		"error remove a/b",
+	}
+	if !reflect.DeepEqual(hooks.events, expected) {
+		t.Errorf("expected events %v, got %v", expected, hooks.events)
+	}
+}
+
+func TestGopassClient_AddHooks(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	first, next := &recordingHooks{}, &recordingHooks{}
+	client.addHooks(first)
+	client.addHooks(next)
+
+	ctx := context.Background()
+	_ = client.SetSecret(ctx, "a/b", "value")
+	_, _ = client.GetSecret(ctx, "a/b")
+	_, _ = client.GetSecret(ctx, "a/missing")
+	_ = client.RemoveSecret(ctx, "a/b")
+
+	// Both hooks are notified of every operation, in order
+	expected := []string{"write set a/b", "read a/b", "error get a/missing", "write remove a/b"}
+	for name, hooks := range map[string]*recordingHooks{"first": first, "next": next} {
+		if !reflect.DeepEqual(hooks.events, expected) {
+			t.Errorf("expected the %s hooks to get events %v, got %v", name, expected, hooks.events)
+		}
+	}
+}
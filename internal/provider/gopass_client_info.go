@@ -0,0 +1,91 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// SecretInfo describes a secret without its values.
+type SecretInfo struct {
+	Exists        bool
+	RevisionCount int64    // see GetRevisionCount
+	LastModified  string   // author date of the last commit in RFC 3339 format, empty if unknown
+	Keys          []string // sorted key names of the key-value fields
+}
+
+// GetSecretInfo returns metadata about the secret at path. The secret is decrypted to
+// read its key names, but no value leaves this function. A missing secret is not an
+// error; it is reported with Exists set to false.
+func (c *GopassClient) GetSecretInfo(ctx context.Context, path string) (*SecretInfo, error) {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return nil, c.notifyError(ctx, opGet, path, err)
+	}
+
+	tflog.Debug(ctx, "Reading secret info", map[string]interface{}{
+		"path": path,
+	})
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil {
+		if c.isNotFound(err) {
+			return &SecretInfo{Keys: []string{}}, nil
+		}
+		return nil, c.notifyError(ctx, opGet, path, fmt.Errorf("failed to get secret %q: %w", path, err))
+	}
+	if secret == nil {
+		return &SecretInfo{Keys: []string{}}, nil
+	}
+
+	keys := append([]string{}, secret.Keys()...)
+	sort.Strings(keys)
+
+	info := &SecretInfo{
+		Exists:        true,
+		RevisionCount: c.revisionCount(ctx, path),
+		Keys:          keys,
+	}
+
+	// Only git-backed stores have a modification history
+	revision, err := c.GetRevisionInfo(ctx, path)
+	if err != nil {
+		tflog.Debug(ctx, "Could not determine last modification", map[string]interface{}{
+			"path":  path,
+			"error": err.Error(),
+		})
+	}
+	if revision != nil {
+		info.LastModified = revision.Timestamp
+	}
+
+	return info, nil
+}
+
+// GetSecretTimes returns the author dates of the first and the last commit that touched the
+// secret at path, in RFC 3339 format. Like GetRevisionInfo, it reads the git history of the
+// store holding the secret. Both are empty if the secret has no history.
+func (c *GopassClient) GetSecretTimes(ctx context.Context, path string) (created, updated string, err error) {
+	dir, rel, err := c.resolve(ctx, path)
+	if err != nil {
+		return "", "", err
+	}
+	path = c.storeName(ctx, path)
+
+	out, err := c.execCommand(ctx, dir, "git", "log", "--format=%aI", "--", rel+".gpg", rel+".age")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read history of %q: %w", path, err)
+	}
+
+	// git log lists the latest commit first
+	dates := nonEmptyLines(out)
+	if len(dates) == 0 {
+		return "", "", nil
+	}
+	return dates[len(dates)-1], dates[0], nil
+}
@@ -0,0 +1,112 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGopassClient_GetSecretInfo(t *testing.T) {
+	store := newMockStore()
+	secret := newMockSecret("s3cret")
+	secret.fields["user"] = "admin"
+	secret.fields["url"] = "https://example.com"
+	store.secrets["prod/db"] = secret
+	store.revisions["prod/db"] = []string{"1", "2", "3"}
+
+	// A git-backed store, whose history is read with git
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".git/HEAD", "ref: refs/heads/main\n")
+	client := NewGopassClient(dir)
+	client.store = store
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("abc123\x1f2026-01-02T03:04:05+00:00\x1fAlice <alice@example.com>\n", nil, &gotDir, &gotArgs)
+
+	info, err := client.GetSecretInfo(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &SecretInfo{
+		Exists:        true,
+		RevisionCount: 3,
+		LastModified:  "2026-01-02T03:04:05+00:00",
+		Keys:          []string{"url", "user"},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+}
+
+func TestGopassClient_GetSecretInfo_NoHistory(t *testing.T) {
+	store := storeWith(map[string]string{"prod/db": "s3cret"})
+
+	client := NewGopassClient(t.TempDir())
+	client.store = store
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("", errors.New("not a git repository"), &gotDir, &gotArgs)
+
+	info, err := client.GetSecretInfo(context.Background(), "prod/db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !info.Exists || info.RevisionCount != 1 || info.LastModified != "" || len(info.Keys) != 0 {
+		t.Errorf("unexpected info for store without history: %+v", info)
+	}
+}
+
+func TestGopassClient_GetSecretInfo_Missing(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(client *GopassClient)
+	}{
+		{name: "not found", setup: func(client *GopassClient) { client.store = newMockStore() }},
+		{name: "nil secret", setup: func(client *GopassClient) {
+			client.store = &mockStoreReturningNilSecret{mockStore: newMockStore()}
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			tc.setup(client)
+
+			info, err := client.GetSecretInfo(context.Background(), "prod/db")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.Exists || info.RevisionCount != 0 || info.Keys == nil || len(info.Keys) != 0 {
+				t.Errorf("expected empty info for missing secret, got %+v", info)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GetSecretInfo_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{name: "store init failure", setup: failingStoreInit, wantErr: "init failed"},
+		{name: "read failure", setup: failingStore("decryption failed"), wantErr: "failed to get secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			tc.setup(client)
+
+			if _, err := client.GetSecretInfo(context.Background(), "prod/db"); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
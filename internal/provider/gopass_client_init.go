@@ -0,0 +1,125 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// gpgIDFile is the file listing the recipients of a pass/gopass store.
+const gpgIDFile = ".gpg-id"
+
+// InitStore creates a new pass-compatible store in dir, encrypted for the given recipients.
+// The directory is created if needed and initialized as a git repository, with gitRemote
+// registered as "origin" if non-empty. No commit is made, so no git identity is required;
+// gopass commits on the first write.
+func (c *GopassClient) InitStore(ctx context.Context, dir string, recipients []string, gitRemote string) error {
+	dir, err := c.expandPath(dir)
+	if err != nil {
+		return err
+	}
+
+	idFile := filepath.Join(dir, gpgIDFile)
+	if _, err := os.Stat(idFile); err == nil {
+		return fmt.Errorf("store already initialized at %q", dir)
+	}
+
+	tflog.Debug(ctx, "Initializing new gopass store", map[string]interface{}{
+		"dir":        dir,
+		"recipients": len(recipients),
+	})
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+	if err := os.WriteFile(idFile, []byte(strings.Join(recipients, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", gpgIDFile, err)
+	}
+
+	if _, err := c.execCommand(ctx, dir, "git", "init"); err != nil {
+		return fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+	if gitRemote != "" {
+		if _, err := c.execCommand(ctx, dir, "git", "remote", "add", "origin", gitRemote); err != nil {
+			return fmt.Errorf("failed to add git remote: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// EnableAutoInit makes the client initialize an empty store for recipients on first use,
+// instead of failing because it is not initialized. It must be called before the store is
+// first accessed.
+func (c *GopassClient) EnableAutoInit(recipients []string) {
+	c.autoInitRecipients = recipients
+}
+
+// autoInitStore initializes the store directory if it exists but is empty, like a freshly
+// created CI workspace. A directory with any content other than a git repository is left
+// alone, so a misconfigured store_path never turns unrelated files into a store.
+func (c *GopassClient) autoInitStore(ctx context.Context) error {
+	dir, err := c.storeDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check store at %q: %w", dir, err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != ".git" {
+			return nil
+		}
+	}
+
+	tflog.Info(ctx, "Initializing empty gopass store", map[string]interface{}{
+		"dir":        dir,
+		"recipients": len(c.autoInitRecipients),
+	})
+	return c.InitStore(ctx, dir, c.autoInitRecipients, "")
+}
+
+// StoreInitialized reports whether dir contains an initialized store.
+func (c *GopassClient) StoreInitialized(dir string) (bool, error) {
+	dir, err := c.expandPath(dir)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(filepath.Join(dir, gpgIDFile))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check store at %q: %w", dir, err)
+	}
+}
+
+// RemoveStore deletes the store directory dir and everything in it.
+func (c *GopassClient) RemoveStore(dir string) error {
+	dir, err := c.expandPath(dir)
+	if err != nil {
+		return err
+	}
+
+	if err := c.removeAll(dir); err != nil {
+		return fmt.Errorf("failed to remove store at %q: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// recordingGit returns an execCommand implementation recording every invocation.
+func recordingGit(calls *[][]string, failOn string) func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		call := append([]string{name}, args...)
+		*calls = append(*calls, call)
+		if failOn != "" && strings.Join(call, " ") == failOn {
+			return nil, errors.New("git failed")
+		}
+		return nil, nil
+	}
+}
+
+func TestGopassClient_InitStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+	client := NewGopassClient("")
+	var calls [][]string
+	client.execCommand = recordingGit(&calls, "")
+
+	err := client.InitStore(context.Background(), dir, []string{"0xAAAA", "0xBBBB"}, "git@example.com:store.git")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ".gpg-id"))
+	if err != nil {
+		t.Fatalf("expected .gpg-id to be written: %v", err)
+	}
+	if string(content) != "0xAAAA\n0xBBBB\n" {
+		t.Errorf("unexpected .gpg-id content %q", content)
+	}
+
+	expected := [][]string{
+		{"git", "init"},
+		{"git", "remote", "add", "origin", "git@example.com:store.git"},
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected git calls %v, got %v", expected, calls)
+	}
+
+	initialized, err := client.StoreInitialized(dir)
+	if err != nil || !initialized {
+		t.Errorf("expected store to be initialized, got %v (err %v)", initialized, err)
+	}
+}
+
+func TestGopassClient_InitStore_NoRemote(t *testing.T) {
+	client := NewGopassClient("")
+	var calls [][]string
+	client.execCommand = recordingGit(&calls, "")
+
+	if err := client.InitStore(context.Background(), t.TempDir(), []string{"0xAAAA"}, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected only 'git init', got %v", calls)
+	}
+}
+
+func TestGopassClient_InitStore_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("already initialized", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte("0xAAAA\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		client := NewGopassClient("")
+		err := client.InitStore(ctx, dir, []string{"0xBBBB"}, "")
+		if err == nil || !strings.Contains(err.Error(), "already initialized") {
+			t.Errorf("expected already initialized error, got %v", err)
+		}
+	})
+
+	t.Run("mkdir", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		client := NewGopassClient("")
+		err := client.InitStore(ctx, filepath.Join(file, "store"), []string{"0xAAAA"}, "")
+		if err == nil || !strings.Contains(err.Error(), "failed to create store directory") {
+			t.Errorf("expected mkdir error, got %v", err)
+		}
+	})
+
+	t.Run("write gpg-id", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.Symlink(filepath.Join(dir, "missing", "target"), filepath.Join(dir, ".gpg-id")); err != nil {
+			t.Fatal(err)
+		}
+		client := NewGopassClient("")
+		err := client.InitStore(ctx, dir, []string{"0xAAAA"}, "")
+		if err == nil || !strings.Contains(err.Error(), "failed to write .gpg-id") {
+			t.Errorf("expected write error, got %v", err)
+		}
+	})
+
+	t.Run("git init", func(t *testing.T) {
+		client := NewGopassClient("")
+		var calls [][]string
+		client.execCommand = recordingGit(&calls, "git init")
+		err := client.InitStore(ctx, t.TempDir(), []string{"0xAAAA"}, "")
+		if err == nil || !strings.Contains(err.Error(), "failed to initialize git repository") {
+			t.Errorf("expected git init error, got %v", err)
+		}
+	})
+
+	t.Run("git remote", func(t *testing.T) {
+		client := NewGopassClient("")
+		var calls [][]string
+		client.execCommand = recordingGit(&calls, "git remote add origin bad")
+		err := client.InitStore(ctx, t.TempDir(), []string{"0xAAAA"}, "bad")
+		if err == nil || !strings.Contains(err.Error(), "failed to add git remote") {
+			t.Errorf("expected git remote error, got %v", err)
+		}
+	})
+
+	t.Run("home expansion", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+		if err := client.InitStore(ctx, "~/store", []string{"0xAAAA"}, ""); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+}
+
+func TestGopassClient_StoreInitialized(t *testing.T) {
+	client := NewGopassClient("")
+
+	initialized, err := client.StoreInitialized(t.TempDir())
+	if err != nil || initialized {
+		t.Errorf("expected empty dir to be uninitialized, got %v (err %v)", initialized, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.StoreInitialized(file); err == nil {
+		t.Error("expected error when the store path is a file")
+	}
+
+	client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+	if _, err := client.StoreInitialized("~/store"); err == nil {
+		t.Error("expected home expansion error")
+	}
+}
+
+func TestGopassClient_RemoveStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	client := NewGopassClient("")
+
+	if err := client.RemoveStore(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected store directory to be removed, got %v", err)
+	}
+
+	client.removeAll = func(string) error { return errors.New("busy") }
+	if err := client.RemoveStore(dir); err == nil || !strings.Contains(err.Error(), "failed to remove store") {
+		t.Errorf("expected wrapped error, got %v", err)
+	}
+
+	client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+	if err := client.RemoveStore("~/store"); err == nil {
+		t.Error("expected home expansion error")
+	}
+}
+
+func TestGopassClient_AutoInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, dir string)
+		wantInit bool
+	}{
+		{name: "empty", wantInit: true},
+		{
+			name: "git repository only",
+			setup: func(t *testing.T, dir string) {
+				if err := os.Mkdir(filepath.Join(dir, ".git"), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantInit: true,
+		},
+		{
+			name: "other files",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "missing",
+			setup: func(t *testing.T, dir string) {
+				if err := os.Remove(dir); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.setup != nil {
+				tc.setup(t, dir)
+			}
+			client := NewGopassClient(dir)
+			client.EnableAutoInit([]string{"0xAAAA"})
+			var calls [][]string
+			client.execCommand = recordingGit(&calls, "")
+
+			if err := client.autoInitStore(context.Background()); err != nil {
+				t.Fatalf("autoInitStore() error = %v", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, ".gpg-id"))
+			if initialized := err == nil; initialized != tc.wantInit {
+				t.Fatalf("expected initialized=%v, got %v (calls %v)", tc.wantInit, initialized, calls)
+			}
+			if tc.wantInit && string(content) != "0xAAAA\n" {
+				t.Errorf("unexpected .gpg-id content %q", content)
+			}
+		})
+	}
+}
+
+func TestGopassClient_AutoInit_Errors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "store")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		client  func() *GopassClient
+		wantErr string
+	}{
+		{
+			name: "store dir",
+			client: func() *GopassClient {
+				client := NewGopassClient("~/store")
+				client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+				return client
+			},
+			wantErr: "no home",
+		},
+		{
+			name:    "not a directory",
+			client:  func() *GopassClient { return NewGopassClient(file) },
+			wantErr: "failed to check store",
+		},
+		{
+			name: "init fails",
+			client: func() *GopassClient {
+				client := NewGopassClient(t.TempDir())
+				var calls [][]string
+				client.execCommand = recordingGit(&calls, "git init")
+				return client
+			},
+			wantErr: "failed to initialize git repository",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := tc.client()
+			client.EnableAutoInit([]string{"0xAAAA"})
+
+			if err := client.autoInitStore(context.Background()); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGopassClient_EnsureStore_AutoInit(t *testing.T) {
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	client.EnableAutoInit([]string{"0xAAAA"})
+	var calls [][]string
+	client.execCommand = recordingGit(&calls, "")
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return newMockStore(), nil
+	}
+
+	if err := client.ensureStore(context.Background()); err != nil {
+		t.Fatalf("ensureStore() error = %v", err)
+	}
+	if initialized, _ := client.StoreInitialized(dir); !initialized {
+		t.Error("expected the store to be initialized before it is opened")
+	}
+}
+
+func TestGopassClient_EnsureStore_AutoInitError(t *testing.T) {
+	client := NewGopassClient(t.TempDir())
+	client.EnableAutoInit([]string{"0xAAAA"})
+	var calls [][]string
+	client.execCommand = recordingGit(&calls, "git init")
+
+	if err := client.ensureStore(context.Background()); err == nil || !strings.Contains(err.Error(), "failed to initialize git repository") {
+		t.Errorf("expected init error, got %v", err)
+	}
+	if client.store != nil {
+		t.Error("expected no store after a failed initialization")
+	}
+}
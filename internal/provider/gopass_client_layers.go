@@ -0,0 +1,187 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ gopass.Store = &layeredStore{}
+
+// SetLookupStores adds stores that secrets are looked up in after the store at the configured
+// path, in priority order, like a colon-separated PASSWORD_STORE_DIR. Writes and everything
+// else that works on the files of a store, e.g. templates, git remotes and snapshots, use the
+// configured store only.
+func (c *GopassClient) SetLookupStores(paths []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lookupPaths = paths
+}
+
+// storeDirs returns the directories of the stores to open in priority order, or nil to open
+// the store of the gopass configuration. Besides the configured store path and lookup stores,
+// PASSWORD_STORE_DIR may list several stores separated by the OS path list separator.
+// Every directory must exist.
+func (c *GopassClient) storeDirs() ([]string, error) {
+	var paths []string
+	switch envDirs := filepath.SplitList(lookupStoreDirEnv()); {
+	case c.storePath != "":
+		paths = append([]string{c.storePath}, c.lookupPaths...)
+	case len(envDirs) > 1:
+		paths = envDirs
+	default:
+		return nil, nil
+	}
+
+	dirs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		dir, err := c.expandPath(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, withCode(CodeStoreNotFound, fmt.Errorf("gopass store not found at configured path: %s\n\n"+
+				"Please verify the path exists and contains a valid gopass/pass store, "+
+				"or remove the store_path configuration to use gopass defaults", dir))
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// openStores opens the stores in dirs, layered in their order if there are several.
+func (c *GopassClient) openStores(ctx context.Context, dirs []string) (gopass.Store, error) {
+	if len(dirs) <= 1 {
+		var dir string
+		if len(dirs) == 1 {
+			dir = dirs[0]
+		}
+		return c.apiNew(ctx, dir)
+	}
+
+	layers := make([]gopass.Store, 0, len(dirs))
+	for _, dir := range dirs {
+		store, err := c.apiNew(ctx, dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open store at %s: %w", dir, err)
+		}
+		layers = append(layers, store)
+	}
+
+	tflog.Debug(ctx, "Layered gopass stores", map[string]interface{}{
+		"paths": dirs,
+	})
+	return &layeredStore{layers: layers}, nil
+}
+
+// layeredStore looks up secrets in several stores in priority order, like pass does with a
+// colon-separated PASSWORD_STORE_DIR: a secret is read from the first store that has it, and
+// the stores' secrets are listed together. Everything that modifies a store goes to the first.
+type layeredStore struct {
+	layers []gopass.Store
+}
+
+// String implements gopass.Store.
+func (s *layeredStore) String() string {
+	names := make([]string, len(s.layers))
+	for i, layer := range s.layers {
+		names[i] = layer.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// List implements gopass.Store. A secret in several stores is listed once.
+func (s *layeredStore) List(ctx context.Context) ([]string, error) {
+	var all []string
+	for _, layer := range s.layers {
+		names, err := layer.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, names...)
+	}
+	slices.Sort(all)
+	return slices.Compact(all), nil
+}
+
+// owner returns the first store having the secret name, or the first store if none has it,
+// so that a missing secret is reported by it.
+func (s *layeredStore) owner(ctx context.Context, name string) (gopass.Store, error) {
+	for _, layer := range s.layers {
+		names, err := layer.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(names, name) {
+			return layer, nil
+		}
+	}
+	return s.layers[0], nil
+}
+
+// Get implements gopass.Store.
+func (s *layeredStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	owner, err := s.owner(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return owner.Get(ctx, name, revision)
+}
+
+// Revisions implements gopass.Store.
+func (s *layeredStore) Revisions(ctx context.Context, name string) ([]string, error) {
+	owner, err := s.owner(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return owner.Revisions(ctx, name)
+}
+
+// Set implements gopass.Store. A secret read from a later store is shadowed by the new one.
+func (s *layeredStore) Set(ctx context.Context, name string, sec gopass.Byter) error {
+	return s.layers[0].Set(ctx, name, sec)
+}
+
+// Remove implements gopass.Store.
+func (s *layeredStore) Remove(ctx context.Context, name string) error {
+	return s.layers[0].Remove(ctx, name)
+}
+
+// RemoveAll implements gopass.Store.
+func (s *layeredStore) RemoveAll(ctx context.Context, prefix string) error {
+	return s.layers[0].RemoveAll(ctx, prefix)
+}
+
+// Rename implements gopass.Store.
+func (s *layeredStore) Rename(ctx context.Context, src, dest string) error {
+	return s.layers[0].Rename(ctx, src, dest)
+}
+
+// Sync implements gopass.Store.
+func (s *layeredStore) Sync(ctx context.Context) error {
+	var errs []error
+	for _, layer := range s.layers {
+		errs = append(errs, layer.Sync(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Close implements gopass.Store.
+func (s *layeredStore) Close(ctx context.Context) error {
+	var errs []error
+	for _, layer := range s.layers {
+		errs = append(errs, layer.Close(ctx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,227 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+func TestLayeredStore_Lookup(t *testing.T) {
+	ctx := context.Background()
+	first := storeWith(map[string]string{"app/db": "first", "app/shared": "first"})
+	second := storeWith(map[string]string{"app/shared": "second", "team/api": "second"})
+	second.revisions["team/api"] = []string{"r2", "r1"}
+	store := &layeredStore{layers: []gopass.Store{first, second}}
+
+	names, err := store.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app/db", "app/shared", "team/api"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	for name, want := range map[string]string{"app/db": "first", "app/shared": "first", "team/api": "second"} {
+		secret, err := store.Get(ctx, name, "latest")
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", name, err)
+		}
+		if secret.Password() != want {
+			t.Errorf("Get(%q) read from the %s store, want %s", name, secret.Password(), want)
+		}
+	}
+	if _, err := store.Get(ctx, "app/missing", "latest"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	revisions, err := store.Revisions(ctx, "team/api")
+	if err != nil || !reflect.DeepEqual(revisions, []string{"r2", "r1"}) {
+		t.Errorf("Revisions() = %v, %v; want the revisions of the second store", revisions, err)
+	}
+
+	if got := store.String(); got != "mock-store, mock-store" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestLayeredStore_WritesGoToFirst(t *testing.T) {
+	ctx := context.Background()
+	first := storeWith(map[string]string{"app/db": "first"})
+	second := storeWith(map[string]string{"team/api": "second", "team/old": "second"})
+	store := &layeredStore{layers: []gopass.Store{first, second}}
+
+	if err := store.Set(ctx, "team/api", newMockSecret("new")); err != nil {
+		t.Fatal(err)
+	}
+	if first.secrets["team/api"] == nil || second.secrets["team/api"].Password() != "second" {
+		t.Error("expected the secret to be written to the first store, shadowing the second")
+	}
+	if secret, _ := store.Get(ctx, "team/api", "latest"); secret.Password() != "new" {
+		t.Errorf("expected the new secret to be read, got %q", secret.Password())
+	}
+
+	if err := store.Rename(ctx, "app/db", "app/database"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove(ctx, "app/database"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove(ctx, "team/old"); err == nil {
+		t.Error("expected secrets of later stores not to be removed")
+	}
+	if err := store.RemoveAll(ctx, "team/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(first.secrets) != 0 || len(second.secrets) != 2 {
+		t.Errorf("expected only the first store to be modified, got %d and %d secrets", len(first.secrets), len(second.secrets))
+	}
+
+	if err := store.Sync(ctx); err != nil {
+		t.Errorf("Sync() error = %v", err)
+	}
+	if err := store.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestLayeredStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	failing := newMockStore()
+	failing.shouldFail = true
+	failing.failMsg = "store unavailable"
+	store := &layeredStore{layers: []gopass.Store{storeWith(map[string]string{"app/db": "first"}), failing}}
+
+	if _, err := store.List(ctx); err == nil {
+		t.Error("expected List() error")
+	}
+	if _, err := store.Get(ctx, "app/missing", "latest"); err == nil || !strings.Contains(err.Error(), "store unavailable") {
+		t.Errorf("expected Get() error of the failing store, got %v", err)
+	}
+	if _, err := store.Revisions(ctx, "app/missing"); err == nil {
+		t.Error("expected Revisions() error")
+	}
+	if err := store.Sync(ctx); err == nil {
+		t.Error("expected Sync() error")
+	}
+	if err := store.Close(ctx); err == nil {
+		t.Error("expected Close() error")
+	}
+
+	// A secret found in an earlier store does not need the later ones
+	if _, err := store.Get(ctx, "app/db", "latest"); err != nil {
+		t.Errorf("Get() error = %v", err)
+	}
+}
+
+func TestGopassClient_StoreDirs(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, "store"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		storePath string
+		lookup    []string
+		env       string
+		want      []string
+		wantErr   string
+	}{
+		{name: "gopass configuration"},
+		{name: "single env store", env: a},
+		{name: "store path", storePath: a, want: []string{a}},
+		{name: "lookup stores", storePath: "~/store", lookup: []string{b, a}, want: []string{filepath.Join(home, "store"), b, a}},
+		{name: "env stores", env: a + string(os.PathListSeparator) + b, want: []string{a, b}},
+		{name: "store path before env", storePath: b, env: a + string(os.PathListSeparator) + b, want: []string{b}},
+		{name: "missing lookup store", storePath: a, lookup: []string{"/nonexistent/store"}, wantErr: "/nonexistent/store"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("PASSWORD_STORE_DIR", tc.env)
+			client := NewGopassClient(tc.storePath)
+			client.userHomeDir = func() (string, error) { return home, nil }
+			client.SetLookupStores(tc.lookup)
+
+			dirs, err := client.storeDirs()
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) || ErrorCode(err) != CodeStoreNotFound {
+					t.Fatalf("expected store not found error for %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(dirs, tc.want) {
+				t.Errorf("storeDirs() = %v, want %v", dirs, tc.want)
+			}
+		})
+	}
+
+	client := NewGopassClient("/store")
+	client.SetLookupStores([]string{"~/other"})
+	client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+	if _, err := client.storeDirs(); err == nil {
+		t.Error("expected error expanding a lookup store")
+	}
+}
+
+func TestGopassClient_StoreDir_EnvStores(t *testing.T) {
+	t.Setenv("PASSWORD_STORE_DIR", "/stores/a"+string(os.PathListSeparator)+"/stores/b")
+
+	dir, err := NewGopassClient("").storeDir()
+	if err != nil || dir != "/stores/a" {
+		t.Errorf("storeDir() = %q, %v; want the first store", dir, err)
+	}
+}
+
+func TestGopassClient_EnsureStore_Layered(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	stores := map[string]*mockStore{
+		a: storeWith(map[string]string{"app/db": "a"}),
+		b: storeWith(map[string]string{"team/api": "b"}),
+	}
+
+	client := NewGopassClient(a)
+	client.SetLookupStores([]string{b})
+	var opened []string
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		opened = append(opened, dir)
+		return stores[dir], nil
+	}
+
+	value, err := client.GetSecret(context.Background(), "team/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "b" {
+		t.Errorf("expected the secret of the lookup store, got %q", value)
+	}
+	if !reflect.DeepEqual(opened, []string{a, b}) {
+		t.Errorf("expected stores to be opened in order, got %v", opened)
+	}
+
+	// A store failing to open fails the client
+	client = NewGopassClient(a)
+	client.SetLookupStores([]string{b})
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		if dir == b {
+			return nil, errors.New("broken")
+		}
+		return stores[dir], nil
+	}
+	if _, err := client.GetSecret(context.Background(), "team/api"); err == nil || !strings.Contains(err.Error(), "failed to open store at "+b) {
+		t.Errorf("expected error opening the lookup store, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+)
+
+// ListSecretsPage lists the secrets under prefix like ListSecrets (or ListSecretsRecursive
+// if recursive is set) and returns up to limit of them, starting at offset.
+// A limit of 0 returns all remaining secrets. The total number of secrets under prefix
+// is returned alongside, so callers can tell whether more pages follow.
+func (c *GopassClient) ListSecretsPage(ctx context.Context, prefix string, recursive bool, offset, limit int) ([]string, int, error) {
+	list := c.ListSecrets
+	if recursive {
+		list = c.ListSecretsRecursive
+	}
+
+	all, err := list(ctx, prefix)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return page(all, offset, limit), len(all), nil
+}
+
+// page returns up to limit items starting at offset. A limit of 0 means no limit.
+func page(items []string, offset, limit int) []string {
+	if offset >= len(items) {
+		return []string{}
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestGopassClient_ListSecrets_Sorted(t *testing.T) {
+	// The mock store lists in map-iteration order
+	store := storeWith(map[string]string{
+		"app/c": "3", "app/a": "1", "app/b": "2", "app/nested/d": "4", "app/nested/a": "5",
+	})
+	client := NewGopassClient("")
+	client.store = store
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		got, err := client.ListSecrets(ctx, "app")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []string{"app/a", "app/b", "app/c"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListSecrets() = %v, want %v", got, want)
+		}
+
+		got, err = client.ListSecretsRecursive(ctx, "app")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := []string{"app/a", "app/b", "app/c", "app/nested/a", "app/nested/d"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListSecretsRecursive() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGopassClient_ListSecretsPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		recursive bool
+		offset    int
+		limit     int
+		want      []string
+		wantTotal int
+	}{
+		{name: "all", want: []string{"app/a", "app/b", "app/c"}, wantTotal: 3},
+		{name: "first page", limit: 2, want: []string{"app/a", "app/b"}, wantTotal: 3},
+		{name: "last page", offset: 2, limit: 2, want: []string{"app/c"}, wantTotal: 3},
+		{name: "past the end", offset: 3, limit: 2, want: []string{}, wantTotal: 3},
+		{name: "recursive", recursive: true, offset: 3, want: []string{"app/nested/x"}, wantTotal: 4},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			client.store = storeWith(map[string]string{"app/b": "", "app/a": "", "app/c": "", "app/nested/x": ""})
+
+			got, total, err := client.ListSecretsPage(context.Background(), "app", tc.recursive, tc.offset, tc.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) || total != tc.wantTotal {
+				t.Errorf("got (%v, %d), want (%v, %d)", got, total, tc.want, tc.wantTotal)
+			}
+		})
+	}
+
+	t.Run("list fails", func(t *testing.T) {
+		store := newMockStore()
+		store.shouldFail = true
+		store.failMsg = "store locked"
+		client := NewGopassClient("")
+		client.store = store
+
+		if _, _, err := client.ListSecretsPage(context.Background(), "app", false, 0, 0); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
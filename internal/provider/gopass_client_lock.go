@@ -0,0 +1,91 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// storeWriteLocks holds a write lock per store directory, the root store and each mounted
+// store having their own. Terraform applies resources in
+// parallel, and every write to a git-backed store ends in a commit; concurrent commits
+// fail on the git index lock. The locks are shared by all clients of the process, so
+// provider aliases using the same store are serialized as well.
+var (
+	storeWriteLocksMu sync.Mutex
+	storeWriteLocks   = map[string]chan struct{}{}
+)
+
+// storeWriteLock returns the write lock of the store at root, a channel with room for one holder.
+func storeWriteLock(root string) chan struct{} {
+	storeWriteLocksMu.Lock()
+	defer storeWriteLocksMu.Unlock()
+
+	root = filepath.Clean(root)
+	lock, ok := storeWriteLocks[root]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		storeWriteLocks[root] = lock
+	}
+	return lock
+}
+
+// lockStore waits until no other write to the store at root is in progress and returns
+// the function releasing the lock. Waiting ends with an error when ctx is done, so writes
+// queued behind a slow one, e.g. one waiting for a hardware token, honor their timeouts.
+// onWait, if not nil, is called before waiting.
+func lockStore(ctx context.Context, root string, onWait func()) (func(), error) {
+	lock := storeWriteLock(root)
+	unlock := func() { <-lock }
+
+	select {
+	case lock <- struct{}{}:
+		return unlock, nil
+	default:
+	}
+
+	if onWait != nil {
+		onWait()
+	}
+	tflog.Debug(ctx, "Waiting for other writes to the gopass store", map[string]interface{}{
+		"root": root,
+	})
+	select {
+	case lock <- struct{}{}:
+		return unlock, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("gave up waiting for other writes to the store at %s: %w", root, ctx.Err())
+	}
+}
+
+// lockWrites locks the store holding path for writing, see lockStore. That is the store
+// mounted under the first component of path, if any, as it commits to a git repository of
+// its own, or else the store of the client.
+func (c *GopassClient) lockWrites(ctx context.Context, path string) (func(), error) {
+	root, _, err := c.resolve(ctx, path)
+	if err != nil {
+		// The store cannot be located, so writes are serialized by its configured path
+		return lockStore(ctx, c.storePath, c.metrics.countLockWait)
+	}
+	return c.lockRoot(ctx, root)
+}
+
+// lockRoot locks the store at root for writing, counting waits in the metrics of the client.
+// The first write also locks the store for the rest of the run, see SetLockTimeout.
+func (c *GopassClient) lockRoot(ctx context.Context, root string) (func(), error) {
+	unlock, err := lockStore(ctx, root, c.metrics.countLockWait)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.lockRun(ctx, root); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
@@ -0,0 +1,207 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockStore(t *testing.T) {
+	root := t.TempDir()
+
+	unlock, err := lockStore(context.Background(), root, nil)
+	if err != nil {
+		t.Fatalf("lockStore() error = %v", err)
+	}
+
+	// Another store can be written meanwhile
+	other, err := lockStore(context.Background(), t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("lockStore() of another store error = %v", err)
+	}
+	other()
+
+	// The same store, also when spelled differently, must wait
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := lockStore(ctx, root+"/", nil); !errors.Is(err, context.DeadlineExceeded) || ErrorCode(err) != CodeTimeout {
+		t.Fatalf("expected timeout while locked, got %v", err)
+	}
+
+	acquired := make(chan func())
+	go func() {
+		next, err := lockStore(context.Background(), root, nil)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- next
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case next := <-acquired:
+		next()
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+}
+
+func TestGopassClient_WritesQueued(t *testing.T) {
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	store := newMockStore()
+	client.store = store
+
+	unlock, err := lockStore(context.Background(), dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- client.SetSecret(context.Background(), "app/db", "secret")
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("write finished while the store was locked: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("SetSecret() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("write not finished after the store was unlocked")
+	}
+	if _, ok := store.secrets["app/db"]; !ok {
+		t.Error("expected secret to be written")
+	}
+}
+
+func TestGopassClient_WriteLockErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, client *GopassClient) error
+	}{
+		{name: "set", write: func(ctx context.Context, client *GopassClient) error {
+			return client.SetSecret(ctx, "app/db", "secret")
+		}},
+		{name: "remove", write: func(ctx context.Context, client *GopassClient) error {
+			return client.RemoveSecret(ctx, "app/db")
+		}},
+		{name: "set template", write: func(ctx context.Context, client *GopassClient) error {
+			return client.SetTemplate(ctx, "app", "template")
+		}},
+		{name: "remove template", write: func(ctx context.Context, client *GopassClient) error {
+			return client.RemoveTemplate(ctx, "app")
+		}},
+		{name: "purge", write: func(ctx context.Context, client *GopassClient) error {
+			_, err := client.PurgeSecretHistory(ctx, "app/db")
+			return err
+		}},
+		{name: "set remote", write: func(ctx context.Context, client *GopassClient) error {
+			return client.SetGitRemote(ctx, "", "backup", "git@example.com:store.git")
+		}},
+		{name: "remove remote", write: func(ctx context.Context, client *GopassClient) error {
+			return client.RemoveGitRemote(ctx, "", "origin")
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := gitStoreDir(t)
+			client := NewGopassClient(dir)
+			client.store = storeWith(map[string]string{"app/db": "secret"})
+			client.execCommand = newFakeRemotes(map[string]string{"origin": "git@example.com:store.git"}).run
+
+			unlock, err := lockStore(context.Background(), dir, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer unlock()
+
+			// A deadline rather than a canceled context, which writes reject before waiting
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err = tc.write(ctx, client)
+			if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "gave up waiting for other writes") {
+				t.Errorf("expected error waiting for the lock, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGopassClient_LockWrites_Mounts(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	resetRunLocks(t)
+	root, mounted := gitStoreDir(t), gitStoreDir(t)
+	client := NewGopassClient(root)
+	client.SetLockTimeout(time.Second)
+	if err := client.SetConfig(ctx, configScopeGlobal, "mounts.work.path", mounted); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockStore(ctx, root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	// The mounted store commits to a repository of its own and is written meanwhile
+	unlockMount, err := client.lockWrites(ctx, "work/ci/token")
+	if err != nil {
+		t.Fatalf("lockWrites() of the mount error = %v", err)
+	}
+	if _, err := os.Stat(storeLockPath(mounted)); err != nil {
+		t.Errorf("expected the run lock in the mounted store: %v", err)
+	}
+	if _, err := os.Stat(storeLockPath(root)); err == nil {
+		t.Error("expected no run lock in the root store")
+	}
+
+	// Writes to the mount wait for each other, writes to the root for the root
+	expiring, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.lockWrites(expiring, "work:ci/db"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a second write to the mount to wait, got %v", err)
+	}
+	unlockMount()
+	if _, err := client.lockWrites(expiring, "app/db"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a write to the root to wait, got %v", err)
+	}
+}
+
+func TestGopassClient_LockWrites_StoreDirError(t *testing.T) {
+	client := NewGopassClient("~/store")
+	client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+
+	unlock, err := client.lockWrites(context.Background(), "app/db")
+	if err != nil {
+		t.Fatalf("lockWrites() error = %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := lockStore(ctx, filepath.Clean("~/store"), nil); err == nil {
+		t.Error("expected the configured path to be locked")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// clientMetrics accumulates what a client did during a run, to find out why a plan is slow.
+// Only counts and durations are kept, never paths or values, and nothing leaves the process
+// except the summary logged by logMetricsSummaries. The methods are no-ops on nil, so
+// clients without metrics don't need to check.
+type clientMetrics struct {
+	reads       atomic.Int64
+	writes      atomic.Int64
+	failures    atomic.Int64
+	lockWaits   atomic.Int64
+	decryptions atomic.Int64
+	decryptTime atomic.Int64 // nanoseconds
+	summarized  int64        // operations counted when the last summary was logged
+}
+
+// metricsClients are the clients with metrics enabled, summarized by logMetricsSummaries.
+var (
+	metricsClientsMu sync.Mutex
+	metricsClients   []*GopassClient
+)
+
+// EnableMetrics lets the client count its store operations and the time spent decrypting,
+// for the summary logged by logMetricsSummaries.
+func (c *GopassClient) EnableMetrics() {
+	c.mu.Lock()
+	c.metrics = &clientMetrics{}
+	c.mu.Unlock()
+
+	metricsClientsMu.Lock()
+	defer metricsClientsMu.Unlock()
+	metricsClients = append(metricsClients, c)
+}
+
+// logMetricsSummaries logs a summary at INFO level for every client with metrics enabled that
+// used the store since its last summary, e.g. the number of store reads and the total
+// decryption time so far. It is called whenever no RPC using the stores is in flight, see
+// providerServer, so the last summary of a run covers all of it.
+func logMetricsSummaries(ctx context.Context) {
+	metricsClientsMu.Lock()
+	defer metricsClientsMu.Unlock()
+
+	for _, c := range metricsClients {
+		if operations := c.metrics.operations(); operations != c.metrics.summarized {
+			c.metrics.summarized = operations
+			c.metrics.logSummary(ctx, c.storePath)
+		}
+	}
+}
+
+// storeGet reads the secret at path from the store, accounting the time spent decrypting it.
+func (c *GopassClient) storeGet(ctx context.Context, path, revision string) (gopass.Secret, error) {
+	start := time.Now()
+	secret, err := c.store.Get(ctx, path, revision)
+	c.metrics.addDecryption(time.Since(start))
+	return secret, err
+}
+
+// countRead counts a successful read.
+func (m *clientMetrics) countRead() {
+	if m != nil {
+		m.reads.Add(1)
+	}
+}
+
+// countWrite counts a successful set or remove.
+func (m *clientMetrics) countWrite() {
+	if m != nil {
+		m.writes.Add(1)
+	}
+}
+
+// countFailure counts a failed operation.
+func (m *clientMetrics) countFailure() {
+	if m != nil {
+		m.failures.Add(1)
+	}
+}
+
+// countLockWait counts a write that had to wait for another write to the same store.
+func (m *clientMetrics) countLockWait() {
+	if m != nil {
+		m.lockWaits.Add(1)
+	}
+}
+
+// addDecryption accounts one call to the store to get a secret, which decrypts it.
+func (m *clientMetrics) addDecryption(d time.Duration) {
+	if m != nil {
+		m.decryptions.Add(1)
+		m.decryptTime.Add(int64(d))
+	}
+}
+
+// operations returns the number of operations counted so far.
+func (m *clientMetrics) operations() int64 {
+	return m.reads.Load() + m.writes.Load() + m.failures.Load() + m.lockWaits.Load() + m.decryptions.Load()
+}
+
+// logSummary logs the accumulated metrics of the client of the store at storePath.
+func (m *clientMetrics) logSummary(ctx context.Context, storePath string) {
+	tflog.Info(ctx, "gopass client metrics", map[string]interface{}{
+		"store_path":   storePath,
+		"reads":        m.reads.Load(),
+		"writes":       m.writes.Load(),
+		"failures":     m.failures.Load(),
+		"lock_waits":   m.lockWaits.Load(),
+		"decryptions":  m.decryptions.Load(),
+		"decrypt_time": time.Duration(m.decryptTime.Load()).String(),
+	})
+}
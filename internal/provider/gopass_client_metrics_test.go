@@ -0,0 +1,88 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestGopassClient_Metrics(t *testing.T) {
+	clients := metricsClients
+	t.Cleanup(func() { metricsClients = clients })
+	metricsClients = nil
+
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	client.store = storeWith(map[string]string{"app/db": "secret"})
+	client.EnableMetrics()
+	ctx := context.Background()
+
+	if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetSecret(ctx, "app/missing"); err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+
+	// A write queued behind another one counts as a lock wait
+	unlock, err := lockStore(ctx, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- client.SetSecret(ctx, "app/api", "token") }()
+	time.Sleep(20 * time.Millisecond)
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	logMetricsSummaries(tflogtest.RootLogger(ctx, &output))
+	// Nothing new to report
+	logMetricsSummaries(tflogtest.RootLogger(ctx, &output))
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected one summary, got %v", entries)
+	}
+	want := map[string]interface{}{
+		"@message":    "gopass client metrics",
+		"@level":      "info",
+		"store_path":  dir,
+		"reads":       float64(1),
+		"writes":      float64(1),
+		"failures":    float64(1),
+		"lock_waits":  float64(1),
+		"decryptions": float64(2),
+	}
+	for key, value := range want {
+		if entries[0][key] != value {
+			t.Errorf("expected %s = %v, got %v", key, value, entries[0][key])
+		}
+	}
+	if _, err := time.ParseDuration(entries[0]["decrypt_time"].(string)); err != nil {
+		t.Errorf("expected decrypt_time to be a duration, got %v", entries[0]["decrypt_time"])
+	}
+}
+
+func TestGopassClient_MetricsDisabled(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"app/db": "secret"})
+
+	if _, err := client.GetSecret(context.Background(), "app/db"); err != nil {
+		t.Fatal(err)
+	}
+	if client.metrics != nil {
+		t.Error("expected no metrics unless enabled")
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// mountSeparator separates a mount from the path within it in "mount:path" addressing.
+const mountSeparator = ":"
+
+// splitMountPath splits the "mount:path" addressing of a secret in a mounted store into the
+// mount and the path within it. "mount:" alone refers to the root of the mount. Only a
+// separator in the first path component counts, so colons in deeper components (e.g.
+// "hosts/db:5432") are kept.
+func splitMountPath(p string) (mount, rest string, ok bool) {
+	mount, rest, ok = strings.Cut(p, mountSeparator)
+	if !ok || mount == "" || strings.Contains(mount, "/") {
+		return "", "", false
+	}
+	return mount, rest, true
+}
+
+// joinMountPath returns rest in the "mount/path" form gopass resolves mounts by.
+func joinMountPath(mount, rest string) string {
+	if rest == "" {
+		return mount
+	}
+	return mount + "/" + rest
+}
+
+// resolveMountPath translates the "mount:path" addressing of a secret in a mounted store
+// into the "mount/path" form gopass resolves mounts by. Only mounts in the gopass config are
+// translated, as resolve does, so a secret named e.g. "db:5432" keeps its name. Other paths,
+// and all paths if the config cannot be read, are returned as is; the store then reports
+// the config error. Applying it twice yields the same path.
+func (c *GopassClient) resolveMountPath(ctx context.Context, p string) string {
+	mount, rest, ok := splitMountPath(p)
+	if !ok {
+		return p
+	}
+	if _, mounted, err := c.GetConfig(ctx, configScopeGlobal, "mounts."+mount+".path"); err != nil || !mounted {
+		return p
+	}
+	return joinMountPath(mount, rest)
+}
+
+// MountedPath returns p addressed in the store mounted as store, e.g. "work/ci/token" for
+// store "work" and p "ci/token". An empty store returns p as is. The mount must be in the
+// gopass config, so a typo fails instead of reading a folder of the root store.
+func (c *GopassClient) MountedPath(ctx context.Context, store, p string) (string, error) {
+	if store == "" {
+		return p, nil
+	}
+	_, ok, err := c.GetConfig(ctx, configScopeGlobal, "mounts."+store+".path")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", withCode(CodeStoreNotFound, fmt.Errorf("no store is mounted as %q, see `gopass mounts`", store))
+	}
+	return joinMountPath(store, p), nil
+}
+
+// resolve returns the directory of the store holding the secret or folder at p, and the name
+// of p within that store, for operations working on the files and git history of a store
+// directly. p is placed below the path prefix first, as the prefixedStore does, so a prefix
+// such as "work:ci" moves every path into the mount; the first component of the result then
+// selects a mounted store, as it does for gopass. Other paths belong to the root store.
+func (c *GopassClient) resolve(ctx context.Context, p string) (dir, rel string, err error) {
+	name := c.storeName(ctx, p)
+	mount, rest, _ := strings.Cut(name, "/")
+	if mount != "" {
+		mountDir, mounted, err := c.GetConfig(ctx, configScopeGlobal, "mounts."+mount+".path")
+		if err != nil {
+			return "", "", err
+		}
+		if mounted {
+			dir, err := c.expandPath(mountDir)
+			return dir, rest, err
+		}
+	}
+	dir, err = c.storeDir()
+	return dir, name, err
+}
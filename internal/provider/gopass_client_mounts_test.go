@@ -0,0 +1,333 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mountStores makes the gopass config of a new home directory mount a store under each of names.
+func mountStores(t *testing.T, names ...string) {
+	t.Helper()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	for _, name := range names {
+		if err := NewGopassClient("").SetConfig(context.Background(), configScopeGlobal, "mounts."+name+".path", "/srv/"+name); err != nil {
+			t.Fatalf("SetConfig() error: %v", err)
+		}
+	}
+}
+
+func TestGopassClient_ResolveMountPath(t *testing.T) {
+	ctx := context.Background()
+	mountStores(t, "work")
+	client := NewGopassClient("")
+
+	testCases := []struct {
+		path string
+		want string
+	}{
+		{path: "work:db/password", want: "work/db/password"},
+		{path: "work:", want: "work"},
+		{path: "work:db:replica", want: "work/db:replica"},
+		{path: "work/db/password", want: "work/db/password"},
+		{path: "db:5432", want: "db:5432"},
+		{path: "hosts/db:5432", want: "hosts/db:5432"},
+		{path: ":db", want: ":db"},
+		{path: "", want: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			got := client.resolveMountPath(ctx, tc.path)
+			if got != tc.want {
+				t.Errorf("resolveMountPath(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+			if again := client.resolveMountPath(ctx, got); again != got {
+				t.Errorf("resolveMountPath(%q) = %q, want it unchanged", got, again)
+			}
+		})
+	}
+}
+
+// unreadableConfig makes the gopass config of the test unreadable, by linking it to itself.
+func unreadableConfig(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", home)
+	dir := filepath.Join(home, ".config", "gopass")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(gopassConfigFile, filepath.Join(dir, gopassConfigFile)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGopassClient_ResolveMountPath_ConfigError(t *testing.T) {
+	unreadableConfig(t)
+
+	if got := NewGopassClient("").resolveMountPath(context.Background(), "work:ci/token"); got != "work:ci/token" {
+		t.Errorf("expected the path unchanged for an unreadable gopass config, got %q", got)
+	}
+}
+
+func TestGopassClient_UnmountedColonPath(t *testing.T) {
+	ctx := context.Background()
+	mountStores(t, "work")
+	store := newMockStore()
+	client := clientWith(store)
+
+	if err := client.SetSecret(ctx, "db:5432", "s3cret"); err != nil {
+		t.Fatalf("SetSecret() error: %v", err)
+	}
+	if _, ok := store.secrets["db:5432"]; !ok {
+		t.Fatalf("expected secret at db:5432, got %v", store.secrets)
+	}
+	if value, err := client.GetSecret(ctx, "db:5432"); err != nil || value != "s3cret" {
+		t.Errorf("GetSecret() = %q, %v", value, err)
+	}
+	if exists, err := client.SecretExists(ctx, "db/5432"); err != nil || exists {
+		t.Errorf("expected nothing at db/5432, got %v, %v", exists, err)
+	}
+}
+
+func TestGopassClient_MountPaths(t *testing.T) {
+	ctx := context.Background()
+	mountStores(t, "work")
+	store := newMockStore()
+	client := NewGopassClient("")
+	client.store = store
+
+	if err := client.SetSecret(ctx, "work:db/password", "s3cret"); err != nil {
+		t.Fatalf("SetSecret() error: %v", err)
+	}
+	if _, ok := store.secrets["work/db/password"]; !ok {
+		t.Fatalf("expected secret at work/db/password, got %v", store.secrets)
+	}
+
+	for _, p := range []string{"work:db/password", "work/db/password"} {
+		value, err := client.GetSecret(ctx, p)
+		if err != nil || value != "s3cret" {
+			t.Errorf("GetSecret(%q) = %q, %v", p, value, err)
+		}
+	}
+
+	listed, err := client.ListSecrets(ctx, "work:db")
+	if err != nil || len(listed) != 1 || listed[0] != "work/db/password" {
+		t.Errorf("ListSecrets() = %v, %v", listed, err)
+	}
+
+	env, err := client.GetEnvSecrets(ctx, "work:")
+	if err != nil || env["db/password"] != "s3cret" {
+		t.Errorf("GetEnvSecrets() = %v, %v", env, err)
+	}
+
+	if err := client.RemoveSecret(ctx, "work:db/password"); err != nil {
+		t.Fatalf("RemoveSecret() error: %v", err)
+	}
+	if exists, err := client.SecretExists(ctx, "work:db/password"); err != nil || exists {
+		t.Errorf("expected secret to be removed, got %v, %v", exists, err)
+	}
+}
+
+func TestGopassClient_MountedPath(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	client := NewGopassClient("")
+	if err := client.SetConfig(ctx, configScopeGlobal, "mounts.work.path", "/srv/work"); err != nil {
+		t.Fatalf("SetConfig() error: %v", err)
+	}
+
+	testCases := []struct {
+		store, path string
+		want        string
+		wantCode    string
+	}{
+		{store: "", path: "ci/token", want: "ci/token"},
+		{store: "work", path: "ci/token", want: "work/ci/token"},
+		{store: "work", path: "", want: "work"},
+		{store: "team", path: "ci/token", wantCode: CodeStoreNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.store+":"+tc.path, func(t *testing.T) {
+			got, err := client.MountedPath(ctx, tc.store, tc.path)
+			if tc.wantCode != "" {
+				if err == nil || ErrorCode(err) != tc.wantCode {
+					t.Fatalf("expected a %s error, got %q, %v", tc.wantCode, got, err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("MountedPath(%q, %q) = %q, %v, want %q", tc.store, tc.path, got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestGopassClient_MountedPath_ConfigError(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", home)
+	dir := filepath.Join(home, ".config", "gopass")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// A config that links to itself cannot be opened
+	if err := os.Symlink(gopassConfigFile, filepath.Join(dir, gopassConfigFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGopassClient("").MountedPath(context.Background(), "work", "ci/token"); err == nil {
+		t.Error("expected an error for an unreadable gopass config")
+	}
+}
+
+func TestGopassClient_Resolve(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	for key, value := range map[string]string{"mounts.work.path": "/srv/work", "mounts.home.path": "~/stores/home"} {
+		if err := NewGopassClient("").SetConfig(ctx, configScopeGlobal, key, value); err != nil {
+			t.Fatalf("SetConfig() error: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name, prefix, path string
+		wantDir, wantRel   string
+	}{
+		{name: "root store", path: "app/db", wantDir: "/store", wantRel: "app/db"},
+		{name: "mount", path: "work:ci/token", wantDir: "/srv/work", wantRel: "ci/token"},
+		{name: "mount in slash form", path: "work/ci/token", wantDir: "/srv/work", wantRel: "ci/token"},
+		{name: "mount root", path: "work:", wantDir: "/srv/work"},
+		{name: "mount in home", path: "home:ci/token", wantDir: "/home/user/stores/home", wantRel: "ci/token"},
+		{name: "prefix into mount", prefix: "work:ci", path: "token", wantDir: "/srv/work", wantRel: "ci/token"},
+		{name: "prefix is mount", prefix: "work", path: "", wantDir: "/srv/work"},
+		{name: "mount below prefix", prefix: "terraform/dev", path: "work:ci/token", wantDir: "/store", wantRel: "terraform/dev/work/ci/token"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("/store")
+			client.userHomeDir = func() (string, error) { return "/home/user", nil }
+			client.SetPathPrefix(ctx, tc.prefix)
+
+			dir, rel, err := client.resolve(ctx, tc.path)
+			if err != nil || dir != tc.wantDir || rel != tc.wantRel {
+				t.Errorf("resolve(%q) = %q, %q, %v, want %q, %q", tc.path, dir, rel, err, tc.wantDir, tc.wantRel)
+			}
+		})
+	}
+}
+
+func TestGopassClient_Resolve_ConfigError(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", home)
+	dir := filepath.Join(home, ".config", "gopass")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// A config that links to itself cannot be opened
+	if err := os.Symlink(gopassConfigFile, filepath.Join(dir, gopassConfigFile)); err != nil {
+		t.Fatal(err)
+	}
+	client := NewGopassClient("/store")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("", nil, &gotDir, &gotArgs)
+	ctx := context.Background()
+
+	operations := map[string]func() error{
+		"resolve":         func() error { _, _, err := client.resolve(ctx, "app/db"); return err },
+		"GetRevisionInfo": func() error { _, err := client.GetRevisionInfo(ctx, "app/db"); return err },
+		"GetSecretTimes":  func() error { _, _, err := client.GetSecretTimes(ctx, "app/db"); return err },
+		"ListSecretsRecursiveAt": func() error {
+			_, err := client.ListSecretsRecursiveAt(ctx, "app", "v1")
+			return err
+		},
+		"PurgeSecretHistory": func() error { _, err := client.PurgeSecretHistory(ctx, "app/db"); return err },
+		"GetTemplate":        func() error { _, _, err := client.GetTemplate(ctx, "app"); return err },
+		"SetTemplate":        func() error { return client.SetTemplate(ctx, "app", "user: ") },
+		"RemoveTemplate":     func() error { return client.RemoveTemplate(ctx, "app") },
+	}
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			if err := operation(); err == nil || !strings.Contains(err.Error(), "gopass config") {
+				t.Errorf("expected an error for an unreadable gopass config, got %v", err)
+			}
+		})
+	}
+	if gotArgs != nil {
+		t.Errorf("expected no git command, got %q", gotArgs)
+	}
+}
+
+func TestGopassClient_Resolve_GitCalls(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	if err := NewGopassClient("").SetConfig(ctx, configScopeGlobal, "mounts.work.path", "/srv/work"); err != nil {
+		t.Fatalf("SetConfig() error: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		output   string
+		call     func(c *GopassClient) (any, error)
+		want     any
+		wantArgs []string
+	}{
+		{
+			name:   "GetRevisionInfo",
+			output: "abc123\x1f2026-01-02T03:04:05Z\x1fCI <ci@example.com>\n",
+			call: func(c *GopassClient) (any, error) {
+				info, err := c.GetRevisionInfo(ctx, "token")
+				return info.Hash, err
+			},
+			want:     "abc123",
+			wantArgs: []string{"git", "log", "-1", "--format=%H%x1f%aI%x1f%an <%ae>", "--", "ci/token.gpg", "ci/token.age"},
+		},
+		{
+			name:   "GetSecretTimes",
+			output: "2026-02-01T00:00:00Z\n2026-01-01T00:00:00Z\n",
+			call: func(c *GopassClient) (any, error) {
+				created, _, err := c.GetSecretTimes(ctx, "token")
+				return created, err
+			},
+			want:     "2026-01-01T00:00:00Z",
+			wantArgs: []string{"git", "log", "--format=%aI", "--", "ci/token.gpg", "ci/token.age"},
+		},
+		{
+			name:   "ListSecretsRecursiveAt",
+			output: "ci/app/KEY1.gpg\nci/KEY2.gpg\nother/app/KEY3.gpg\n.gpg-id\n",
+			call: func(c *GopassClient) (any, error) {
+				return c.ListSecretsRecursiveAt(ctx, "app", "v1")
+			},
+			want:     []string{"app/KEY1"},
+			wantArgs: []string{"git", "ls-tree", "-r", "--name-only", "--end-of-options", "v1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The prefix crosses into the mount, so the git history of the mount is read
+			client := NewGopassClient("/store")
+			client.SetPathPrefix(ctx, "work:ci")
+			var gotDir string
+			var gotArgs []string
+			client.execCommand = fakeGit(tc.output, nil, &gotDir, &gotArgs)
+
+			got, err := tc.call(client)
+			if err != nil || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, %v, want %v", got, err, tc.want)
+			}
+			if gotDir != "/srv/work" || !reflect.DeepEqual(gotArgs, tc.wantArgs) {
+				t.Errorf("git ran in %q with %q, want /srv/work with %q", gotDir, gotArgs, tc.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// otpauthKey is the key of an OTP URI stored as a key-value entry ("otpauth: //totp/...").
+const otpauthKey = "otpauth"
+
+// otpauthScheme is the prefix of OTP key URIs.
+const otpauthScheme = "otpauth://"
+
+// SetOTPSecret stores an otpauth:// URI at path as a line of the secret body, the
+// format read by `gopass otp` and pass-otp. If the secret already exists, its password
+// and other fields are kept and a previous otpauth line is replaced.
+func (c *GopassClient) SetOTPSecret(ctx context.Context, path, uri string) error {
+	path = c.resolveMountPath(ctx, path)
+	if !strings.HasPrefix(uri, otpauthScheme) || strings.ContainsAny(uri, "\r\n") {
+		return fmt.Errorf("invalid OTP URI for %q: must be a single line starting with %s", path, otpauthScheme)
+	}
+
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
+
+	tflog.Debug(ctx, "Writing OTP secret", map[string]interface{}{
+		"path": path,
+	})
+
+	existing, err := c.readSecret(ctx, path, "latest")
+	if err != nil && !c.isNotFound(err) {
+		return c.notifyError(ctx, opSet, path, fmt.Errorf("failed to read secret %q: %w", path, err))
+	}
+
+	// A new secret gets an empty password line, so the URI is never mistaken for a password
+	content := "\n"
+	if existing != nil {
+		content = stripOTPLines(existing.Bytes())
+	}
+
+	return c.writeSecret(ctx, path, secrets.ParseAKV([]byte(content+uri+"\n")))
+}
+
+// HasOTPSecret reports whether the secret at path exists and contains an OTP URI.
+func (c *GopassClient) HasOTPSecret(ctx context.Context, path string) (bool, error) {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return false, c.notifyError(ctx, opGet, path, err)
+	}
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil {
+		if c.isNotFound(err) {
+			return false, nil
+		}
+		return false, c.notifyError(ctx, opGet, path, fmt.Errorf("failed to get secret %q: %w", path, err))
+	}
+	if secret == nil {
+		return false, nil
+	}
+
+	return hasOTPLine(secret), nil
+}
+
+// GetOTPURI returns the OTP URI of the secret at path, from where `gopass otp` looks for one:
+// an otpauth key-value entry, a body line starting with otpauth://, a totp entry holding
+// just the seed, or the password line.
+func (c *GopassClient) GetOTPURI(ctx context.Context, path string) (string, error) {
+	path = c.resolveMountPath(ctx, path)
+	tflog.Debug(ctx, "Reading OTP secret", map[string]interface{}{
+		"path": path,
+	})
+
+	secret, err := c.getSecretAt(ctx, path, "")
+	if err != nil {
+		return "", err
+	}
+
+	if value, found := secret.Get(otpauthKey); found {
+		if strings.HasPrefix(value, "//") {
+			value = otpauthKey + ":" + value
+		}
+		return value, nil
+	}
+	for _, line := range strings.Split(secret.Body(), "\n") {
+		if strings.HasPrefix(line, otpauthScheme) {
+			return strings.TrimSpace(line), nil
+		}
+	}
+	if seed, found := secret.Get("totp"); found {
+		return otpauthScheme + "totp/gopass?secret=" + url.QueryEscape(seed), nil
+	}
+	if password := secret.Password(); strings.HasPrefix(password, otpauthScheme) {
+		return password, nil
+	}
+	return "", fmt.Errorf("secret %q has no OTP URI", path)
+}
+
+// RemoveOTPSecret removes the OTP URI from the secret at path. The secret itself
+// is removed only if nothing else is left in it. A missing secret is not an error.
+func (c *GopassClient) RemoveOTPSecret(ctx context.Context, path string) error {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opRemove, path, err)
+	}
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil {
+		if c.isNotFound(err) {
+			return nil
+		}
+		return c.notifyError(ctx, opRemove, path, fmt.Errorf("failed to get secret %q: %w", path, err))
+	}
+	if secret == nil {
+		return nil
+	}
+
+	content := stripOTPLines(secret.Bytes())
+	if strings.TrimSpace(content) == "" {
+		return c.RemoveSecret(ctx, path)
+	}
+	return c.writeSecret(ctx, path, secrets.ParseAKV([]byte(content)))
+}
+
+// hasOTPLine reports whether secret holds an OTP URI where `gopass otp` looks for one:
+// an otpauth key-value entry or a body line starting with otpauth://.
+func hasOTPLine(secret gopass.Secret) bool {
+	if _, found := secret.Get(otpauthKey); found {
+		return true
+	}
+	for _, line := range strings.Split(secret.Body(), "\n") {
+		if strings.HasPrefix(line, otpauthScheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripOTPLines returns content without its OTP URI lines, in either the otpauth://
+// or the key-value form. The first line is the password and always kept.
+func stripOTPLines(content []byte) string {
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	kept := lines[:1]
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(strings.TrimSpace(line), otpauthKey+":") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n") + "\n"
+}
@@ -0,0 +1,238 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+const (
+	testOTPURI    = "otpauth://totp/IdP:admin?secret=JBSWY3DPEHPK3PXP&issuer=IdP"
+	testOTPURINew = "otpauth://totp/IdP:admin?secret=KRSXG5CTMVRXEZLU&issuer=IdP"
+)
+
+func TestGopassClient_SetOTPSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     string
+	}{
+		{
+			name: "new secret",
+			want: "\n" + testOTPURI + "\n",
+		},
+		{
+			name:     "keeps password and fields",
+			existing: "password\nusername: admin",
+			want:     "password\nusername: admin\n" + testOTPURI + "\n",
+		},
+		{
+			name:     "replaces previous uri",
+			existing: "password\n" + testOTPURINew + "\notpauth: //totp/old\nnotes",
+			want:     "password\nnotes\n" + testOTPURI + "\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			mockStore := newMockStore()
+			if tc.existing != "" {
+				mockStore.secrets["websites/idp"] = secrets.ParseAKV([]byte(tc.existing))
+			}
+			client.store = mockStore
+
+			if err := client.SetOTPSecret(context.Background(), "websites/idp", testOTPURI); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			secret := mockStore.secrets["websites/idp"]
+			if got := string(secret.Bytes()); got != tc.want {
+				t.Errorf("expected secret %q, got %q", tc.want, got)
+			}
+			if !hasOTPLine(secret) {
+				t.Error("expected the stored secret to contain an OTP URI")
+			}
+		})
+	}
+}
+
+func TestGopassClient_SetOTPSecret_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{
+			name:    "not an otpauth uri",
+			uri:     "JBSWY3DPEHPK3PXP",
+			setup:   func(client *GopassClient) {},
+			wantErr: "invalid OTP URI",
+		},
+		{
+			name:    "multi-line uri",
+			uri:     testOTPURI + "\nextra",
+			setup:   func(client *GopassClient) {},
+			wantErr: "invalid OTP URI",
+		},
+		{
+			name:    "store init failure",
+			uri:     testOTPURI,
+			setup:   failingStoreInit,
+			wantErr: "init failed",
+		},
+		{
+			name:    "read failure",
+			uri:     testOTPURI,
+			setup:   failingStore("decryption failed"),
+			wantErr: "failed to read secret",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			tc.setup(client)
+
+			err := client.SetOTPSecret(context.Background(), "websites/idp", tc.uri)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGopassClient_HasOTPSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{name: "missing", want: false},
+		{name: "uri line", content: "password\n" + testOTPURI, want: true},
+		{name: "key-value entry", content: "password\notpauth: //totp/x?secret=A", want: true},
+		{name: "no uri", content: "password\nusername: admin", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			mockStore := newMockStore()
+			if tc.content != "" {
+				mockStore.secrets["websites/idp"] = secrets.ParseAKV([]byte(tc.content))
+			}
+			client.store = mockStore
+
+			got, err := client.HasOTPSecret(context.Background(), "websites/idp")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGopassClient_RemoveOTPSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantExists bool
+		want       string
+	}{
+		{name: "missing"},
+		{name: "only uri", content: "\n" + testOTPURI, wantExists: false},
+		{name: "keeps other content", content: "password\n" + testOTPURI + "\nusername: admin", wantExists: true, want: "password\nusername: admin\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			mockStore := newMockStore()
+			if tc.content != "" {
+				mockStore.secrets["websites/idp"] = secrets.ParseAKV([]byte(tc.content))
+			}
+			client.store = mockStore
+
+			if err := client.RemoveOTPSecret(context.Background(), "websites/idp"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			secret, exists := mockStore.secrets["websites/idp"]
+			if exists != tc.wantExists {
+				t.Fatalf("expected exists=%v, got %v", tc.wantExists, exists)
+			}
+			if exists && string(secret.Bytes()) != tc.want {
+				t.Errorf("expected secret %q, got %q", tc.want, secret.Bytes())
+			}
+		})
+	}
+}
+
+func TestGopassClient_OTPSecret_StoreErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{name: "store init failure", setup: failingStoreInit, wantErr: "init failed"},
+		{name: "read failure", setup: failingStore("decryption failed"), wantErr: "failed to get secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			client := NewGopassClient("")
+			tc.setup(client)
+			if _, err := client.HasOTPSecret(ctx, "websites/idp"); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("HasOTPSecret: expected error containing %q, got %v", tc.wantErr, err)
+			}
+
+			client = NewGopassClient("")
+			tc.setup(client)
+			if err := client.RemoveOTPSecret(ctx, "websites/idp"); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("RemoveOTPSecret: expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGopassClient_OTPSecret_NilSecret(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = &mockStoreReturningNilSecret{mockStore: newMockStore()}
+
+	if found, err := client.HasOTPSecret(ctx, "websites/idp"); err != nil || found {
+		t.Errorf("HasOTPSecret: expected (false, nil), got (%v, %v)", found, err)
+	}
+	if err := client.RemoveOTPSecret(ctx, "websites/idp"); err != nil {
+		t.Errorf("RemoveOTPSecret: unexpected error: %v", err)
+	}
+}
+
+// failingStoreInit makes store initialization of a client fail.
+func failingStoreInit(client *GopassClient) {
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return nil, errors.New("init failed")
+	}
+}
+
+// failingStore returns a setup func that installs a store failing every operation with msg.
+func failingStore(msg string) func(client *GopassClient) {
+	return func(client *GopassClient) {
+		store := newMockStore()
+		store.shouldFail = true
+		store.failMsg = msg
+		client.store = store
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ gopass.Store = &serializedStore{}
+
+// passphrasePipeName is the name of the pipe GPG reads the loopback passphrase from.
+const passphrasePipeName = "passphrase"
+
+// passphrasePipeCloseTimeout is how long closing the pipe waits for it to be released.
+var passphrasePipeCloseTimeout = time.Second
+
+// passphrasePipe passes the loopback passphrase to GPG through a named pipe in a new directory
+// only the current user can access, as GPG takes it from a file and gopass offers no other way
+// to pass it. The passphrase is never written to disk, and only kept in memory until the pipe
+// is closed. GPG processes reading the pipe at the same time would share the passphrase, so
+// the store is accessed by one operation at a time, see serializedStore.
+type passphrasePipe struct {
+	dir        string
+	path       string
+	passphrase []byte
+	makeFIFO   func(path string) error
+	stopped    atomic.Bool
+	done       chan struct{}
+}
+
+// newPassphrasePipe creates the pipe with makeFIFO and serves passphrase to its readers until
+// it is closed. The pipe takes passphrase over and wipes it on close, or right away if it
+// cannot be created.
+func newPassphrasePipe(passphrase []byte, makeFIFO func(path string) error) (*passphrasePipe, error) {
+	dir, err := os.MkdirTemp("", "gopass-passphrase-")
+	if err != nil {
+		clear(passphrase)
+		return nil, fmt.Errorf("failed to create a directory for the passphrase: %w", err)
+	}
+	// gopass splits the GPG options on whitespace, so the path must not contain any
+	if strings.ContainsAny(dir, " \t\n") {
+		clear(passphrase)
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("temporary directory %q contains whitespace, set TMPDIR to a path without", dir)
+	}
+	p := &passphrasePipe{
+		dir:        dir,
+		path:       filepath.Join(dir, passphrasePipeName),
+		passphrase: passphrase,
+		makeFIFO:   makeFIFO,
+		done:       make(chan struct{}),
+	}
+	if err := p.makeFIFO(p.path); err != nil {
+		clear(passphrase)
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create the passphrase pipe: %w", err)
+	}
+	go p.serve()
+	return p, nil
+}
+
+// serve writes the passphrase to each reader of the pipe in turn, until the pipe is closed.
+// GPG opens the pipe once per process and reads the passphrase up to the end.
+func (p *passphrasePipe) serve() {
+	defer close(p.done)
+	for {
+		// Opening the pipe for writing waits for the next reader
+		f, err := os.OpenFile(p.path, os.O_WRONLY, 0)
+		if err != nil {
+			return
+		}
+		if p.stopped.Load() {
+			_ = f.Close()
+			return
+		}
+		// Readers opening the path from now on get a new pipe, so the passphrase is written to
+		// this one once and its reader gets the end right after
+		err = p.renew()
+		if err == nil {
+			_, _ = f.Write(p.passphrase)
+		}
+		_ = f.Close()
+		if err != nil {
+			// Without a writer, readers of the pipe would wait forever, so they fail instead
+			_ = os.Remove(p.path)
+			return
+		}
+	}
+}
+
+// renew replaces the pipe at the path with a new one, leaving the open one to its reader.
+func (p *passphrasePipe) renew() error {
+	next := p.path + ".next"
+	if err := p.makeFIFO(next); err != nil {
+		return err
+	}
+	return os.Rename(next, p.path)
+}
+
+// close stops serving the passphrase, wipes it from memory and removes the pipe with
+// removeAll.
+func (p *passphrasePipe) close(removeAll func(path string) error) error {
+	p.stopped.Store(true)
+	deadline := time.After(passphrasePipeCloseTimeout)
+	var err error
+wait:
+	for {
+		// Opening the pipe for reading releases serve waiting for the next reader
+		if r, openErr := os.OpenFile(p.path, os.O_RDONLY|openNonblock, 0); openErr == nil {
+			_ = r.Close()
+		}
+		select {
+		case <-p.done:
+			break wait
+		case <-deadline:
+			err = errors.New("the passphrase pipe was not released")
+			break wait
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	clear(p.passphrase)
+	return errors.Join(err, removeAll(p.dir))
+}
+
+// serializedStore passes one operation at a time to store, so only one GPG process at a time
+// reads the passphrase pipe.
+type serializedStore struct {
+	mu    sync.Mutex
+	store gopass.Store
+}
+
+// String implements gopass.Store.
+func (s *serializedStore) String() string {
+	return s.store.String()
+}
+
+// List implements gopass.Store.
+func (s *serializedStore) List(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.List(ctx)
+}
+
+// Get implements gopass.Store.
+func (s *serializedStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Get(ctx, name, revision)
+}
+
+// Set implements gopass.Store.
+func (s *serializedStore) Set(ctx context.Context, name string, sec gopass.Byter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Set(ctx, name, sec)
+}
+
+// Revisions implements gopass.Store.
+func (s *serializedStore) Revisions(ctx context.Context, name string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Revisions(ctx, name)
+}
+
+// Remove implements gopass.Store.
+func (s *serializedStore) Remove(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Remove(ctx, name)
+}
+
+// RemoveAll implements gopass.Store.
+func (s *serializedStore) RemoveAll(ctx context.Context, prefix string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.RemoveAll(ctx, prefix)
+}
+
+// Rename implements gopass.Store.
+func (s *serializedStore) Rename(ctx context.Context, src, dest string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Rename(ctx, src, dest)
+}
+
+// Sync implements gopass.Store.
+func (s *serializedStore) Sync(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Sync(ctx)
+}
+
+// Close implements gopass.Store.
+func (s *serializedStore) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.store.Close(ctx)
+}
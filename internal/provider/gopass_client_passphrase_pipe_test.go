@@ -0,0 +1,127 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPassphrasePipe_RenewFailure(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	created := 0
+	p, err := newPassphrasePipe([]byte("correct horse"), func(path string) error {
+		created++
+		if created > 1 {
+			return errors.New("no space left on device")
+		}
+		return makeFIFO(path)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a new pipe the reader gets nothing, and later readers find no pipe to wait on
+	content, err := os.ReadFile(p.path)
+	if err != nil || len(content) != 0 {
+		t.Errorf("expected no passphrase, got %q, %v", content, err)
+	}
+	<-p.done
+	if _, err := os.Stat(p.path); !os.IsNotExist(err) {
+		t.Errorf("expected the passphrase pipe to be removed, got %v", err)
+	}
+	if err := p.close(os.RemoveAll); err != nil {
+		t.Errorf("close() error = %v", err)
+	}
+}
+
+func TestPassphrasePipe_Missing(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	// Serving stops when there is no pipe to open
+	p, err := newPassphrasePipe([]byte("correct horse"), func(string) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-p.done
+	if err := p.close(os.RemoveAll); err != nil {
+		t.Errorf("close() error = %v", err)
+	}
+}
+
+func TestPassphrasePipe_CloseTimeout(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	timeout := passphrasePipeCloseTimeout
+	passphrasePipeCloseTimeout = 50 * time.Millisecond
+	defer func() { passphrasePipeCloseTimeout = timeout }()
+
+	p, err := newPassphrasePipe([]byte("correct horse"), makeFIFO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(p.path); err != nil || string(content) != "correct horse" {
+		t.Fatalf("expected the passphrase, got %q, %v", content, err)
+	}
+
+	// Moved away, the pipe serve waits on cannot be opened to release it
+	time.Sleep(50 * time.Millisecond)
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Rename(p.path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.close(os.RemoveAll); err == nil || !strings.Contains(err.Error(), "was not released") {
+		t.Errorf("expected the pipe not to be released, got %v", err)
+	}
+
+	if r, err := os.OpenFile(moved, os.O_RDONLY|openNonblock, 0); err == nil {
+		_ = r.Close()
+	}
+	<-p.done
+}
+
+func TestSerializedStore(t *testing.T) {
+	ctx := context.Background()
+	mock := storeWith(map[string]string{"app/db": "s3cret"})
+	store := &serializedStore{store: mock}
+
+	if store.String() != mock.String() {
+		t.Errorf("expected the name of the store, got %q", store.String())
+	}
+	if err := store.Set(ctx, "app/api", newMockSecret("token")); err != nil {
+		t.Fatal(err)
+	}
+	if secret, err := store.Get(ctx, "app/api", "latest"); err != nil || secret.Password() != "token" {
+		t.Errorf("expected the secret to be written, got %v, %v", secret, err)
+	}
+	if names, err := store.List(ctx); err != nil || len(names) != 2 {
+		t.Errorf("expected 2 secrets, got %v, %v", names, err)
+	}
+	if _, err := store.Revisions(ctx, "app/api"); err != nil {
+		t.Errorf("Revisions() error = %v", err)
+	}
+	if err := store.Rename(ctx, "app/api", "app/token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove(ctx, "app/token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RemoveAll(ctx, "app/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.secrets) != 0 {
+		t.Errorf("expected all secrets to be removed, got %d", len(mock.secrets))
+	}
+	if err := store.Sync(ctx); err != nil {
+		t.Errorf("Sync() error = %v", err)
+	}
+	if err := store.Close(ctx); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !windows
+
+package provider
+
+import "syscall"
+
+// openNonblock opens a named pipe without waiting for the other end.
+const openNonblock = syscall.O_NONBLOCK
+
+// makeFIFO creates a named pipe at path only the current user can read and write.
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0o600)
+}
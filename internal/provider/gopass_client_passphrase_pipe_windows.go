@@ -0,0 +1,17 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build windows
+
+package provider
+
+import "errors"
+
+// openNonblock opens a named pipe without waiting for the other end.
+const openNonblock = 0
+
+// makeFIFO fails, as Windows has no named pipes in the file system, so pinentry_mode = "loopback"
+// is not supported.
+func makeFIFO(path string) error {
+	return errors.New("pinentry_mode = \"loopback\" is not supported on Windows")
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Pinentry modes of the provider configuration.
+const (
+	pinentryModeDefault  = "default"
+	pinentryModeLoopback = "loopback"
+)
+
+// defaultPassphraseEnv is the environment variable holding the passphrase in loopback mode.
+const defaultPassphraseEnv = "GOPASS_GPG_PASSPHRASE"
+
+// pinentryErrorPatterns are the messages of GPG failing to ask for a passphrase because
+// no terminal or pinentry program is available, as in CI runners and containers.
+var pinentryErrorPatterns = []string{
+	"no pinentry",
+	"inappropriate ioctl for device",
+	"cannot open '/dev/tty'",
+}
+
+// pinentryError marks an error already annotated with pinentry guidance.
+type pinentryError struct {
+	err error
+}
+
+func (e *pinentryError) Error() string { return e.err.Error() }
+func (e *pinentryError) Unwrap() error { return e.err }
+
+// isPinentryError reports whether err says that GPG could not ask for the passphrase.
+func isPinentryError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range pinentryErrorPatterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapPinentryError adds guidance to err if GPG could not ask for the passphrase.
+// Other errors, and errors annotated before, are returned unchanged.
+func wrapPinentryError(err error) error {
+	var annotated *pinentryError
+	if errors.As(err, &annotated) || !isPinentryError(err) {
+		return err
+	}
+	return withCode(CodeGPGError, &pinentryError{err: fmt.Errorf("GPG could not ask for the passphrase: %w\n\n"+
+		"No pinentry program or terminal is available, e.g. in CI. Possible solutions:\n\n"+
+		"1. Pass the passphrase to GPG directly:\n"+
+		"   provider \"gopass\" {\n"+
+		"     pinentry_mode = \"loopback\"\n"+
+		"   }\n"+
+		"   export %s=<passphrase>\n\n"+
+		"2. Tell GPG which terminal to prompt on:\n"+
+		"   export GPG_TTY=$(tty)\n\n"+
+		"3. Preset the passphrase in gpg-agent, with allow-preset-passphrase in gpg-agent.conf:\n"+
+		"   /usr/lib/gnupg/gpg-preset-passphrase --preset <keygrip>", err, defaultPassphraseEnv)})
+}
+
+// loopbackPassphraseEnv returns the name of the environment variable holding the passphrase
+// passed to GPG in pinentry mode, envName or the default one, or "" if mode leaves asking for
+// it to pinentry.
+func loopbackPassphraseEnv(mode, envName string) (string, error) {
+	switch mode {
+	case "", pinentryModeDefault:
+		if envName != "" {
+			return "", fmt.Errorf("passphrase_env is only read with pinentry_mode = %q", pinentryModeLoopback)
+		}
+		return "", nil
+	case pinentryModeLoopback:
+	default:
+		return "", fmt.Errorf("pinentry_mode must be %q or %q, got %q", pinentryModeDefault, pinentryModeLoopback, mode)
+	}
+	if envName == "" {
+		envName = defaultPassphraseEnv
+	}
+	if os.Getenv(envName) == "" {
+		return "", fmt.Errorf("the environment variable %s holding the passphrase is not set", envName)
+	}
+	return envName, nil
+}
+
+// SetPinentryLoopback makes GPG read the passphrase of the store keys from the environment
+// variable envName instead of asking for it with pinentry. It must be called before the store
+// is first accessed. The variable is read each time the store is opened, so the client keeps
+// no copy of the passphrase while the store is closed.
+func (c *GopassClient) SetPinentryLoopback(envName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.passphraseEnv = envName
+}
+
+// openPassphrasePipe starts passing the loopback passphrase to GPG through a named pipe.
+// It is called with c.mu held, and the pipe is closed again by closeStore.
+func (c *GopassClient) openPassphrasePipe() error {
+	if c.passphraseEnv == "" || c.passphrasePipe != nil {
+		return nil
+	}
+	passphrase := []byte(os.Getenv(c.passphraseEnv))
+	if len(passphrase) == 0 {
+		return fmt.Errorf("the environment variable %s holding the passphrase is not set", c.passphraseEnv)
+	}
+	pipe, err := newPassphrasePipe(passphrase, c.makeFIFO)
+	if err != nil {
+		return err
+	}
+	c.passphrasePipe = pipe
+	return nil
+}
+
+// closePassphrasePipe closes the pipe opened by openPassphrasePipe, if any.
+// It is called with c.mu held.
+func (c *GopassClient) closePassphrasePipe(ctx context.Context) {
+	if c.passphrasePipe == nil {
+		return
+	}
+	if err := c.passphrasePipe.close(c.removeAll); err != nil {
+		tflog.Warn(ctx, "Failed to remove passphrase pipe", map[string]interface{}{
+			"dir":   c.passphrasePipe.dir,
+			"error": err.Error(),
+		})
+	}
+	c.passphrasePipe = nil
+}
+
+// gpgEnv returns the environment variables the crypto backend of the store is constructed
+// with. In loopback mode the GPG options of the environment are extended with the ones
+// reading the passphrase pipe. It is called with c.mu held.
+func (c *GopassClient) gpgEnv() []string {
+	if c.passphrasePipe == nil {
+		return nil
+	}
+	opts := os.Getenv("GOPASS_GPG_OPTS")
+	if opts == "" {
+		opts = os.Getenv("PASSWORD_STORE_GPG_OPTS")
+	}
+	opts = strings.TrimSpace(opts + " --pinentry-mode loopback --passphrase-file " + c.passphrasePipe.path)
+	return []string{"GOPASS_GPG_OPTS=" + opts}
+}
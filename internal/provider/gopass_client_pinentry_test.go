@@ -0,0 +1,376 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+func TestWrapPinentryError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantHint bool
+	}{
+		{name: "no pinentry", err: errors.New("gpg: public key decryption failed: No pinentry"), wantHint: true},
+		{name: "ioctl", err: errors.New("gpg: signing failed: Inappropriate ioctl for device"), wantHint: true},
+		{name: "tty", err: errors.New("gpg: cannot open '/dev/tty': No such device or address"), wantHint: true},
+		{name: "other gpg error", err: errors.New("gpg: decryption failed: No secret key")},
+		{name: "unrelated", err: errors.New("entry is not in the password store")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := wrapPinentryError(tc.err)
+			if !errors.Is(got, tc.err) {
+				t.Errorf("expected the original error to be wrapped, got %v", got)
+			}
+			hint := strings.Contains(got.Error(), `pinentry_mode = "loopback"`)
+			if hint != tc.wantHint {
+				t.Fatalf("expected guidance = %v, got %q", tc.wantHint, got.Error())
+			}
+			if !tc.wantHint {
+				return
+			}
+			for _, want := range []string{defaultPassphraseEnv, "GPG_TTY", "gpg-preset-passphrase"} {
+				if !strings.Contains(got.Error(), want) {
+					t.Errorf("expected guidance to mention %s, got %q", want, got.Error())
+				}
+			}
+			if code := ErrorCode(got); code != CodeGPGError {
+				t.Errorf("expected code %q, got %q", CodeGPGError, code)
+			}
+			if again := wrapPinentryError(got); again.Error() != got.Error() {
+				t.Errorf("expected the guidance to be added once, got %q", again.Error())
+			}
+		})
+	}
+}
+
+func TestGopassClient_PinentryErrorOnRead(t *testing.T) {
+	client := NewGopassClient("")
+	failingStore("gpg: public key decryption failed: No pinentry")(client)
+
+	_, err := client.GetSecret(context.Background(), "app/db")
+	if err == nil || strings.Count(err.Error(), "GPG could not ask for the passphrase") != 1 {
+		t.Errorf("expected pinentry guidance once, got %v", err)
+	}
+}
+
+func TestLoopbackPassphraseEnv(t *testing.T) {
+	t.Setenv(defaultPassphraseEnv, "from-default")
+	t.Setenv("CI_GPG_PASSPHRASE", "from-custom")
+	t.Setenv("EMPTY_PASSPHRASE", "")
+
+	tests := []struct {
+		name    string
+		mode    string
+		envName string
+		want    string
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "default", mode: "default"},
+		{name: "loopback", mode: "loopback", want: defaultPassphraseEnv},
+		{name: "custom variable", mode: "loopback", envName: "CI_GPG_PASSPHRASE", want: "CI_GPG_PASSPHRASE"},
+		{name: "empty variable", mode: "loopback", envName: "EMPTY_PASSPHRASE", wantErr: "EMPTY_PASSPHRASE holding the passphrase is not set"},
+		{name: "variable without loopback", envName: "CI_GPG_PASSPHRASE", wantErr: "only read with pinentry_mode"},
+		{name: "invalid mode", mode: "tty", wantErr: `got "tty"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := loopbackPassphraseEnv(tc.mode, tc.envName)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// readPassphrasePipe reads the passphrase from the pipe of client like GPG does.
+func readPassphrasePipe(t *testing.T, client *GopassClient) string {
+	t.Helper()
+	content, err := os.ReadFile(client.passphrasePipe.path)
+	if err != nil {
+		t.Fatalf("failed to read the passphrase pipe: %v", err)
+	}
+	return string(content)
+}
+
+func TestGopassClient_PinentryLoopback(t *testing.T) {
+	tests := []struct {
+		name       string
+		gopassOpts string
+		storeOpts  string
+		wantPrefix string
+	}{
+		{name: "no options"},
+		{name: "gopass options", gopassOpts: "--no-tty", storeOpts: "--ignored", wantPrefix: "--no-tty "},
+		{name: "password store options", storeOpts: "--no-tty", wantPrefix: "--no-tty "},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("TMPDIR", t.TempDir())
+			t.Setenv("GOPASS_GPG_OPTS", tc.gopassOpts)
+			t.Setenv("PASSWORD_STORE_GPG_OPTS", tc.storeOpts)
+			t.Setenv("CI_GPG_PASSPHRASE", "correct horse")
+
+			ctx := context.Background()
+			var env []string
+			client := NewGopassClient("")
+			client.SetPinentryLoopback("CI_GPG_PASSPHRASE")
+			client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+				env = client.gpgEnv()
+				return newMockStore(), nil
+			}
+
+			if err := client.ensureStore(ctx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			pipe := client.passphrasePipe.path
+			want := "GOPASS_GPG_OPTS=" + tc.wantPrefix + "--pinentry-mode loopback --passphrase-file " + pipe
+			if len(env) != 1 || env[0] != want {
+				t.Errorf("expected env %q, got %q", want, env)
+			}
+			info, err := os.Stat(pipe)
+			if err != nil || info.Mode().Type() != fs.ModeNamedPipe || info.Mode().Perm() != 0o600 {
+				t.Fatalf("expected a named pipe with mode 0600, got %v, %v", info, err)
+			}
+			// Every GPG process reads the passphrase
+			for i := 0; i < 3; i++ {
+				if got := readPassphrasePipe(t, client); got != "correct horse" {
+					t.Errorf("expected the passphrase from the pipe, got %q", got)
+				}
+			}
+
+			passphrase := client.passphrasePipe.passphrase
+			client.closeStore(ctx)
+			if _, err := os.Stat(pipe); !os.IsNotExist(err) {
+				t.Errorf("expected the passphrase pipe to be removed on close, got %v", err)
+			}
+			if strings.Trim(string(passphrase), "\x00") != "" {
+				t.Error("expected the passphrase to be wiped from memory on close")
+			}
+			if client.gpgEnv() != nil {
+				t.Error("expected no GPG options once the store is closed")
+			}
+
+			// A client used after the stores were closed reads the variable again
+			t.Setenv("CI_GPG_PASSPHRASE", "battery staple")
+			if err := client.ensureStore(ctx); err != nil {
+				t.Fatalf("unexpected error on reopen: %v", err)
+			}
+			if got := readPassphrasePipe(t, client); got != "battery staple" {
+				t.Errorf("expected the passphrase to be passed again, got %q", got)
+			}
+			client.closeStore(ctx)
+		})
+	}
+}
+
+// pipeReadingStore reads the passphrase pipe on every Get, like GPG decrypting the secret.
+type pipeReadingStore struct {
+	*mockStore
+	client *GopassClient
+}
+
+func (s *pipeReadingStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	content, err := os.ReadFile(s.client.passphrasePipe.path)
+	if err != nil {
+		return nil, err
+	}
+	return newMockSecret(string(content)), nil
+}
+
+func TestGopassClient_PinentryLoopback_Concurrent(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("CI_GPG_PASSPHRASE", "correct horse")
+	ctx := context.Background()
+
+	client := NewGopassClient("")
+	client.SetPinentryLoopback("CI_GPG_PASSPHRASE")
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return &pipeReadingStore{mockStore: newMockStore(), client: client}, nil
+	}
+	if err := client.ensureStore(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.closeStore(ctx)
+
+	// Each reader gets the whole passphrase, none shares it with another
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := client.GetSecret(ctx, "app/db"); err != nil || got != "correct horse" {
+				t.Errorf("expected the passphrase, got %q, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGopassClient_PinentryLoopback_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		tmpDir     func(t *testing.T) string
+		passphrase string
+		setup      func(client *GopassClient)
+		wantErr    string
+	}{
+		{
+			name:       "missing temporary directory",
+			tmpDir:     func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing") },
+			passphrase: "correct horse",
+			wantErr:    "failed to create a directory for the passphrase",
+		},
+		{
+			name: "whitespace in temporary directory",
+			tmpDir: func(t *testing.T) string {
+				dir := filepath.Join(t.TempDir(), "with space")
+				if err := os.Mkdir(dir, 0o700); err != nil {
+					t.Fatal(err)
+				}
+				return dir
+			},
+			passphrase: "correct horse",
+			wantErr:    "contains whitespace",
+		},
+		{
+			name:       "pipe not created",
+			passphrase: "correct horse",
+			setup: func(client *GopassClient) {
+				client.makeFIFO = func(string) error { return errors.New("operation not permitted") }
+			},
+			wantErr: "failed to create the passphrase pipe",
+		},
+		{
+			name:    "variable unset since configure",
+			wantErr: "CI_GPG_PASSPHRASE holding the passphrase is not set",
+		},
+		{
+			name:       "store failure",
+			passphrase: "correct horse",
+			setup: func(client *GopassClient) {
+				client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+					return nil, errors.New("gpg: decryption failed: Inappropriate ioctl for device")
+				}
+			},
+			wantErr: "GPG could not ask for the passphrase",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			if tc.tmpDir != nil {
+				tmp = tc.tmpDir(t)
+			}
+			t.Setenv("TMPDIR", tmp)
+			t.Setenv("CI_GPG_PASSPHRASE", tc.passphrase)
+
+			client := NewGopassClient("")
+			client.SetPinentryLoopback("CI_GPG_PASSPHRASE")
+			client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+				return newMockStore(), nil
+			}
+			if tc.setup != nil {
+				tc.setup(client)
+			}
+
+			err := client.ensureStore(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if client.passphrasePipe != nil {
+				t.Error("expected no passphrase pipe to be kept")
+			}
+			if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+				t.Errorf("expected the passphrase directory to be removed, found %v", entries)
+			}
+		})
+	}
+}
+
+func TestGopassClient_ClosePassphrasePipe_Failure(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("CI_GPG_PASSPHRASE", "correct horse")
+
+	client := NewGopassClient("")
+	client.SetPinentryLoopback("CI_GPG_PASSPHRASE")
+	client.store = newMockStore()
+	if err := client.openPassphrasePipe(); err != nil {
+		t.Fatal(err)
+	}
+	dir := client.passphrasePipe.dir
+	defer os.RemoveAll(dir)
+	client.removeAll = func(string) error { return errors.New("busy") }
+
+	client.closeStore(context.Background())
+	if client.passphrasePipe != nil {
+		t.Error("expected the passphrase pipe to be forgotten even if it could not be removed")
+	}
+}
+
+func TestNewLibraryStore_RestoresGPGOptions(t *testing.T) {
+	t.Setenv("GOPASS_GPG_OPTS", "")
+	os.Unsetenv("GOPASS_GPG_OPTS")
+
+	// There is no store in the directory, so this fails after reading the variables
+	client := NewGopassClient(t.TempDir())
+	if _, err := newLibraryStore(context.Background(), client.storePath, []string{"GOPASS_GPG_OPTS=--pinentry-mode loopback"}); err == nil {
+		t.Error("expected error for an uninitialized store")
+	}
+	if _, set := os.LookupEnv("GOPASS_GPG_OPTS"); set {
+		t.Error("expected GOPASS_GPG_OPTS to be unset again")
+	}
+
+	// The default constructor of the client passes its GPG options
+	if _, err := client.apiNew(context.Background(), client.storePath); err == nil {
+		t.Error("expected error for an uninitialized store")
+	}
+}
+
+func TestGopassClient_UseCLI_PinentryLoopback(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("GOPASS_GPG_OPTS", "")
+	t.Setenv("PASSWORD_STORE_GPG_OPTS", "")
+	t.Setenv("CI_GPG_PASSPHRASE", "correct horse")
+	fakeGopassBinary(t)
+
+	client := NewGopassClient("")
+	client.UseCLI("gopass", nil)
+	client.SetPinentryLoopback("CI_GPG_PASSPHRASE")
+	if err := client.ensureStore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.closeStore(context.Background())
+
+	s := client.store.(*serializedStore).store.(*cliStore)
+	want := "GOPASS_GPG_OPTS=--pinentry-mode loopback --passphrase-file " + client.passphrasePipe.path
+	if len(s.env) != 1 || s.env[0] != want {
+		t.Errorf("expected env %q, got %q", want, s.env)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Character classes a PasswordPolicy can require.
+const (
+	classLower  = "lower"
+	classUpper  = "upper"
+	classDigit  = "digit"
+	classSymbol = "symbol"
+)
+
+// passwordClasses tell whether a character belongs to each class a policy can require.
+var passwordClasses = map[string]func(r rune) bool{
+	classLower:  unicode.IsLower,
+	classUpper:  unicode.IsUpper,
+	classDigit:  unicode.IsDigit,
+	classSymbol: func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) },
+}
+
+// PasswordPolicy are the requirements every value written as the password of a secret must meet.
+type PasswordPolicy struct {
+	MinLength        int      // minimum number of characters
+	RequiredClasses  []string // classes of characters that must occur, e.g. "digit"
+	BannedSubstrings []string // case-insensitive substrings that must not occur, e.g. "REPLACE_ME"
+}
+
+// validate returns an error if the policy itself is invalid.
+func (p *PasswordPolicy) validate() error {
+	if p.MinLength < 0 {
+		return fmt.Errorf("min_length must not be negative, got %d", p.MinLength)
+	}
+	for _, class := range p.RequiredClasses {
+		if passwordClasses[class] == nil {
+			return fmt.Errorf("required_classes must be %q, %q, %q or %q, got %q",
+				classLower, classUpper, classDigit, classSymbol, class)
+		}
+	}
+	for i, banned := range p.BannedSubstrings {
+		if banned == "" {
+			return fmt.Errorf("banned substring %d is empty", i)
+		}
+	}
+	return nil
+}
+
+// check returns an error describing how value violates the policy, or nil for a nil policy.
+// The error never contains the value itself.
+func (p *PasswordPolicy) check(value string) error {
+	if p == nil {
+		return nil
+	}
+	var problems []string
+
+	if utf8.RuneCountInString(value) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("it is shorter than %d characters", p.MinLength))
+	}
+	for _, class := range p.RequiredClasses {
+		if strings.IndexFunc(value, passwordClasses[class]) < 0 {
+			problems = append(problems, fmt.Sprintf("it contains no %s character", class))
+		}
+	}
+	lower := strings.ToLower(value)
+	for _, banned := range p.BannedSubstrings {
+		if strings.Contains(lower, strings.ToLower(banned)) {
+			problems = append(problems, fmt.Sprintf("it contains the banned substring %q", banned))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// SetPasswordPolicy makes the client refuse to write values violating policy as the password
+// of a secret, whichever resource writes them. It returns an error if the policy is invalid.
+func (c *GopassClient) SetPasswordPolicy(policy PasswordPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policy = &policy
+	return nil
+}
+
+// checkPolicy returns an error if value, to be written to path, violates the password policy.
+func (c *GopassClient) checkPolicy(ctx context.Context, path, value string) error {
+	if err := c.policy.check(value); err != nil {
+		return c.notifyError(ctx, opSet, path, withCode(CodePolicyViolation,
+			fmt.Errorf("the value for %q violates password_policy and was not written: %w", path, err)))
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy := &PasswordPolicy{
+		MinLength:        8,
+		RequiredClasses:  []string{classLower, classUpper, classDigit, classSymbol},
+		BannedSubstrings: []string{"REPLACE_ME", "changeme"},
+	}
+
+	tests := []struct {
+		name    string
+		policy  *PasswordPolicy
+		value   string
+		wantErr []string
+	}{
+		{name: "no policy", value: ""},
+		{name: "strong", policy: policy, value: "Tr0ub4dor&3"},
+		{name: "unicode length", policy: &PasswordPolicy{MinLength: 3}, value: "äöü"},
+		{name: "short", policy: policy, value: "aB3$", wantErr: []string{"shorter than 8 characters"}},
+		{name: "missing classes", policy: policy, value: "lowercase only", wantErr: []string{
+			"no upper character", "no digit character", "no symbol character",
+		}},
+		{name: "placeholder", policy: policy, value: "Please-replace_me-1", wantErr: []string{`banned substring "REPLACE_ME"`}},
+		{name: "several banned", policy: policy, value: "CHANGEME+replace_me1", wantErr: []string{`"REPLACE_ME"`, `"changeme"`}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.check(tc.value)
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			for _, want := range tc.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got %q", want, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestGopassClient_SetPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PasswordPolicy
+		wantErr string
+	}{
+		{name: "empty"},
+		{name: "complete", policy: PasswordPolicy{MinLength: 12, RequiredClasses: []string{"digit"}, BannedSubstrings: []string{"todo"}}},
+		{name: "negative length", policy: PasswordPolicy{MinLength: -1}, wantErr: "min_length must not be negative"},
+		{name: "unknown class", policy: PasswordPolicy{RequiredClasses: []string{"emoji"}}, wantErr: `got "emoji"`},
+		{name: "empty substring", policy: PasswordPolicy{BannedSubstrings: []string{"todo", ""}}, wantErr: "banned substring 1 is empty"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			err := client.SetPasswordPolicy(tc.policy)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				if client.policy != nil {
+					t.Error("expected an invalid policy not to be set")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if client.policy == nil {
+				t.Error("expected the policy to be set")
+			}
+		})
+	}
+}
+
+func TestGopassClient_PasswordPolicy_Writes(t *testing.T) {
+	ctx := context.Background()
+	writes := []struct {
+		name  string
+		write func(c *GopassClient, value string) error
+	}{
+		{"SetSecret", func(c *GopassClient, value string) error { return c.SetSecret(ctx, "app/db", value) }},
+		{"SetSecretPassword", func(c *GopassClient, value string) error { return c.SetSecretPassword(ctx, "app/db", value) }},
+		{"SetSecretContent", func(c *GopassClient, value string) error {
+			return c.SetSecretContent(ctx, "app/db", []byte(value+"\nuser: app\n"))
+		}},
+		{"SetSecretParts", func(c *GopassClient, value string) error {
+			return c.SetSecretParts(ctx, "app/db", SecretParts{Password: value, Username: "app"})
+		}},
+		{"SetSecretChunked", func(c *GopassClient, value string) error { return c.SetSecretChunked(ctx, "app/db", value, 4) }},
+	}
+
+	for _, w := range writes {
+		t.Run(w.name, func(t *testing.T) {
+			store := newMockStore()
+			client := NewGopassClient("")
+			client.store = store
+			if err := client.SetPasswordPolicy(PasswordPolicy{MinLength: 10, BannedSubstrings: []string{"REPLACE_ME"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := w.write(client, "REPLACE_ME-please")
+			if err == nil || !strings.Contains(err.Error(), "violates password_policy") {
+				t.Fatalf("expected a policy violation, got %v", err)
+			}
+			if code := ErrorCode(err); code != CodePolicyViolation {
+				t.Errorf("expected code %q, got %q", CodePolicyViolation, code)
+			}
+			if strings.Contains(err.Error(), "please") {
+				t.Error("expected the value to be left out of the error")
+			}
+			if len(store.secrets) != 0 {
+				t.Errorf("expected nothing to be written, got %d secrets", len(store.secrets))
+			}
+
+			// The parts of a chunked value are shorter than min_length, but only the whole value counts
+			if err := w.write(client, "long enough value"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := store.secrets["app/db"]; !ok {
+				t.Error("expected the secret to be written")
+			}
+		})
+	}
+}
+
+func TestGopassClient_PasswordPolicy_SetSecretContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "short password, long body", content: "short\n-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n", wantErr: true},
+		{name: "banned substring in body", content: "long enough value\ncomment: REPLACE_ME later\n"},
+		{name: "password only", content: "long enough value"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			client := clientWith(store)
+			if err := client.SetPasswordPolicy(PasswordPolicy{MinLength: 10, BannedSubstrings: []string{"REPLACE_ME"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := client.SetSecretContent(context.Background(), "app/cert", []byte(tc.content))
+			if tc.wantErr {
+				if ErrorCode(err) != CodePolicyViolation {
+					t.Fatalf("expected a policy violation, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, ok := store.secrets["app/cert"]; !ok {
+				t.Error("expected the secret to be written")
+			}
+		})
+	}
+}
+
+func TestGopassClient_PasswordPolicy_CopySecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]string
+		wantErr bool
+	}{
+		{name: "violating password", secrets: map[string]string{"staging/api": "REPLACE_ME\nusername: svc\n"}, wantErr: true},
+		{name: "valid password", secrets: map[string]string{"staging/api": "long enough value\nusername: svc\n"}},
+		{name: "chunked value", secrets: map[string]string{
+			"staging/api":       "\nchunks: 2\n",
+			"staging/api.part1": "long eno",
+			"staging/api.part2": "ugh value",
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			for path, content := range tc.secrets {
+				store.secrets[path] = secrets.ParseAKV([]byte(content))
+			}
+			client := clientWith(store)
+			if err := client.SetPasswordPolicy(PasswordPolicy{MinLength: 10, BannedSubstrings: []string{"REPLACE_ME"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := client.CopySecret(context.Background(), "staging/api", "production/api")
+			_, written := store.secrets["production/api"]
+			if tc.wantErr {
+				if ErrorCode(err) != CodePolicyViolation {
+					t.Fatalf("expected a policy violation, got %v", err)
+				}
+				if written {
+					t.Error("expected nothing to be written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !written {
+				t.Error("expected the secret to be copied")
+			}
+		})
+	}
+}
+
+// Fields and OTP seeds are written next to the password, which they keep, so the policy does
+// not apply to them, even for secrets they create with an empty password.
+func TestGopassClient_PasswordPolicy_FieldsAndOTPExempt(t *testing.T) {
+	ctx := context.Background()
+	writes := []struct {
+		name  string
+		write func(c *GopassClient, path string) error
+	}{
+		{"SetSecretField", func(c *GopassClient, path string) error { return c.SetSecretField(ctx, path, "username", "REPLACE_ME") }},
+		{"SetOTPSecret", func(c *GopassClient, path string) error {
+			return c.SetOTPSecret(ctx, path, "otpauth://totp/app?secret=JBSWY3DPEHPK3PXP")
+		}},
+	}
+
+	for _, w := range writes {
+		t.Run(w.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"app/short": "short"})
+			client := clientWith(store)
+			if err := client.SetPasswordPolicy(PasswordPolicy{MinLength: 10, BannedSubstrings: []string{"REPLACE_ME"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, path := range []string{"app/short", "app/new"} {
+				if err := w.write(client, path); err != nil {
+					t.Fatalf("unexpected error writing %s: %v", path, err)
+				}
+			}
+			if got := store.secrets["app/short"].Password(); got != "short" {
+				t.Errorf("expected the password to be kept, got %q", got)
+			}
+			if got := store.secrets["app/new"].Password(); got != "" {
+				t.Errorf("expected a new secret to have an empty password, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ gopass.Store = &prefixedStore{}
+
+// SetPathPrefix makes the client address all secrets below prefix, e.g. "terraform/dev", so
+// the same configuration can work on separate namespaces of a store. Paths passed to the
+// client and returned by it stay relative to the prefix. A prefix addressing a mounted store
+// as "mount:path" is resolved like secret paths. It must be set before the store is used.
+func (c *GopassClient) SetPathPrefix(ctx context.Context, prefix string) {
+	prefix = strings.TrimSuffix(c.resolveMountPath(ctx, prefix), "/")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pathPrefix = prefix
+}
+
+// storeName returns the name in the store of the secret or folder at path p, i.e. p below
+// the path prefix. Operations working on the files of the store use it, as they bypass the
+// prefixedStore.
+func (c *GopassClient) storeName(ctx context.Context, p string) string {
+	return joinPathPrefix(c.pathPrefix, c.resolveMountPath(ctx, p))
+}
+
+// withPathPrefix returns store addressing its secrets below the path prefix, or store
+// itself if no prefix is set.
+func (c *GopassClient) withPathPrefix(store gopass.Store) gopass.Store {
+	if c.pathPrefix == "" {
+		return store
+	}
+	return &prefixedStore{Store: store, prefix: c.pathPrefix}
+}
+
+// joinPathPrefix returns name below prefix. An empty name refers to the prefix itself.
+func joinPathPrefix(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + "/" + name
+}
+
+// trimPathPrefix returns the names below prefix relative to it, leaving out all others.
+func trimPathPrefix(prefix string, names []string) []string {
+	if prefix == "" {
+		return names
+	}
+	var result []string
+	for _, name := range names {
+		if rel, ok := strings.CutPrefix(name, prefix+"/"); ok {
+			result = append(result, rel)
+		}
+	}
+	return result
+}
+
+// prefixedStore addresses the secrets of a store below a path prefix. Names passed to it
+// are relative to the prefix, and it lists only the secrets below the prefix.
+type prefixedStore struct {
+	gopass.Store
+	prefix string
+}
+
+// String implements gopass.Store.
+func (s *prefixedStore) String() string {
+	return s.Store.String() + " below " + s.prefix
+}
+
+// List implements gopass.Store.
+func (s *prefixedStore) List(ctx context.Context) ([]string, error) {
+	names, err := s.Store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return trimPathPrefix(s.prefix, names), nil
+}
+
+// Get implements gopass.Store.
+func (s *prefixedStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	return s.Store.Get(ctx, joinPathPrefix(s.prefix, name), revision)
+}
+
+// Revisions implements gopass.Store.
+func (s *prefixedStore) Revisions(ctx context.Context, name string) ([]string, error) {
+	return s.Store.Revisions(ctx, joinPathPrefix(s.prefix, name))
+}
+
+// Set implements gopass.Store.
+func (s *prefixedStore) Set(ctx context.Context, name string, sec gopass.Byter) error {
+	return s.Store.Set(ctx, joinPathPrefix(s.prefix, name), sec)
+}
+
+// Remove implements gopass.Store.
+func (s *prefixedStore) Remove(ctx context.Context, name string) error {
+	return s.Store.Remove(ctx, joinPathPrefix(s.prefix, name))
+}
+
+// RemoveAll implements gopass.Store.
+func (s *prefixedStore) RemoveAll(ctx context.Context, prefix string) error {
+	return s.Store.RemoveAll(ctx, joinPathPrefix(s.prefix, prefix))
+}
+
+// Rename implements gopass.Store.
+func (s *prefixedStore) Rename(ctx context.Context, src, dest string) error {
+	return s.Store.Rename(ctx, joinPathPrefix(s.prefix, src), joinPathPrefix(s.prefix, dest))
+}
@@ -0,0 +1,203 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+func TestJoinPathPrefix(t *testing.T) {
+	tests := []struct {
+		prefix, name, want string
+	}{
+		{prefix: "", name: "app/db", want: "app/db"},
+		{prefix: "terraform/dev", name: "app/db", want: "terraform/dev/app/db"},
+		{prefix: "terraform/dev", name: "", want: "terraform/dev"},
+	}
+
+	for _, tc := range tests {
+		if got := joinPathPrefix(tc.prefix, tc.name); got != tc.want {
+			t.Errorf("joinPathPrefix(%q, %q) = %q, want %q", tc.prefix, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTrimPathPrefix(t *testing.T) {
+	names := []string{"terraform/dev/app/db", "terraform/development/app/db", "terraform/prod/app/db", "terraform/dev"}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "", want: names},
+		{prefix: "terraform/dev", want: []string{"app/db"}},
+		{prefix: "team", want: nil},
+	}
+
+	for _, tc := range tests {
+		if got := trimPathPrefix(tc.prefix, names); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("trimPathPrefix(%q) = %v, want %v", tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestGopassClient_StoreName(t *testing.T) {
+	mountStores(t, "team")
+	tests := []struct {
+		name   string
+		prefix string
+		path   string
+		want   string
+	}{
+		{name: "no prefix", path: "app/db", want: "app/db"},
+		{name: "no prefix mount", path: "team:app/db", want: "team/app/db"},
+		{name: "prefix", prefix: "terraform/dev", path: "app/db", want: "terraform/dev/app/db"},
+		{name: "prefix mount", prefix: "terraform/dev", path: "team:app/db", want: "terraform/dev/team/app/db"},
+		{name: "mount prefix", prefix: "team:terraform", path: "app/db", want: "team/terraform/app/db"},
+		{name: "prefix root", prefix: "terraform/dev", path: "", want: "terraform/dev"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			if tc.prefix != "" {
+				client.SetPathPrefix(context.Background(), tc.prefix)
+			}
+			if got := client.storeName(context.Background(), tc.path); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPrefixedStore(t *testing.T) {
+	ctx := context.Background()
+	mock := storeWith(map[string]string{"terraform/dev/app/db": "hunter2", "other/app/db": "other"})
+	store := &prefixedStore{Store: mock, prefix: "terraform/dev"}
+
+	if got, want := store.String(), "mock-store below terraform/dev"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	names, err := store.List(ctx)
+	if err != nil || !reflect.DeepEqual(names, []string{"app/db"}) {
+		t.Errorf("expected [app/db], got %v, %v", names, err)
+	}
+
+	sec, err := store.Get(ctx, "app/db", "latest")
+	if err != nil || sec.Password() != "hunter2" {
+		t.Fatalf("expected the prefixed secret, got %v, %v", sec, err)
+	}
+
+	if err := store.Set(ctx, "app/api", newMockSecret("key")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mock.secrets["terraform/dev/app/api"]; !ok {
+		t.Error("expected app/api to be written below the prefix")
+	}
+
+	if revs, err := store.Revisions(ctx, "app/api"); err != nil || len(revs) != 1 {
+		t.Errorf("expected a revision of the prefixed secret, got %v, %v", revs, err)
+	}
+
+	if err := store.Rename(ctx, "app/api", "app/key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mock.secrets["terraform/dev/app/key"]; !ok {
+		t.Error("expected app/api to be renamed below the prefix")
+	}
+
+	if err := store.Remove(ctx, "app/key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mock.secrets["terraform/dev/app/key"]; ok {
+		t.Error("expected app/key to be removed below the prefix")
+	}
+
+	if err := store.RemoveAll(ctx, "app"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(mock.secrets); got != 1 {
+		t.Errorf("expected only the secret outside of the prefix to be kept, got %v", mock.secrets)
+	}
+
+	mock.shouldFail = true
+	mock.failMsg = "store unavailable"
+	if _, err := store.List(ctx); err == nil {
+		t.Error("expected List to fail")
+	}
+}
+
+func TestGopassClient_PathPrefix(t *testing.T) {
+	ctx := context.Background()
+	mock := storeWith(map[string]string{"terraform/prod/app/db": "prod"})
+	client := NewGopassClient(t.TempDir())
+	client.SetPathPrefix(ctx, "terraform/dev")
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return mock, nil
+	}
+
+	if err := client.SetSecret(ctx, "app/db", "dev"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := mock.secrets["terraform/dev/app/db"]; !ok {
+		t.Errorf("expected the secret below the prefix, got %v", mock.secrets)
+	}
+
+	value, err := client.GetSecret(ctx, "app/db")
+	if err != nil || value != "dev" {
+		t.Errorf("expected the secret below the prefix, got %q, %v", value, err)
+	}
+
+	names, err := client.ListSecretsRecursive(ctx, "app")
+	if err != nil || !reflect.DeepEqual(names, []string{"app/db"}) {
+		t.Errorf("expected paths relative to the prefix, got %v, %v", names, err)
+	}
+}
+
+func TestGopassClient_PathPrefix_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("/store")
+	client.SetPathPrefix(ctx, "terraform/dev")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit(
+		"terraform/dev/app/KEY1.gpg\n"+
+			"terraform/prod/app/KEY2.gpg\n"+
+			"app/KEY3.gpg\n",
+		nil, &gotDir, &gotArgs)
+
+	results, err := client.ListSecretsRecursiveAt(ctx, "app", "release-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(results)
+	if !reflect.DeepEqual(results, []string{"app/KEY1"}) {
+		t.Errorf("expected paths relative to the prefix, got %v", results)
+	}
+}
+
+func TestGopassClient_PathPrefix_Files(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	client.SetPathPrefix(ctx, "terraform/dev")
+
+	if err := client.CreateDirectoryPlaceholder(ctx, "app"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "terraform", "dev", "app", placeholderFile)); err != nil {
+		t.Errorf("expected the placeholder below the prefix, got %v", err)
+	}
+	if ok, err := client.HasDirectoryPlaceholder(ctx, "app"); err != nil || !ok {
+		t.Errorf("expected the placeholder to be found, got %v, %v", ok, err)
+	}
+}
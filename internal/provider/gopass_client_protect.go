@@ -0,0 +1,58 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SetProtectedPaths makes the client refuse to remove secrets matching any of the glob
+// patterns, e.g. "prod/**", also when a resource is destroyed with delete_on_remove.
+// Patterns are matched against paths relative to the path prefix.
+func (c *GopassClient) SetProtectedPaths(patterns []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.protectedPaths = patterns
+}
+
+// checkRemovable returns an error if the secret at path matches a protected pattern.
+func (c *GopassClient) checkRemovable(path string) error {
+	protected, err := matchAnyGlob(c.protectedPaths, path)
+	if err != nil {
+		return fmt.Errorf("failed to check protected_paths: %w", err)
+	}
+	if protected {
+		return withCode(CodeProtectedPath, fmt.Errorf(
+			"%q matches protected_paths and is not removed; remove the pattern from protected_paths to remove it, "+
+				"or the resource from the state with `terraform state rm` to keep it", path,
+		))
+	}
+	return nil
+}
+
+// checkRemovableTree returns an error if the folder dir or any secret below it matches a
+// protected pattern. The store is only listed if any paths are protected.
+func (c *GopassClient) checkRemovableTree(ctx context.Context, dir string) error {
+	if len(c.protectedPaths) == 0 {
+		return nil
+	}
+	if err := c.checkRemovable(dir); err != nil {
+		return err
+	}
+
+	names, err := c.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check protected_paths: %w", err)
+	}
+	for _, name := range names {
+		if dir == "" || strings.HasPrefix(name, dir+"/") {
+			if err := c.checkRemovable(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
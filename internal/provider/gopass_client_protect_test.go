@@ -0,0 +1,154 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestGopassClient_RemoveSecret_ProtectedPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		wantErr  string
+		wantCode string
+	}{
+		{name: "not protected", path: "prod/db"},
+		{name: "other pattern", patterns: []string{"prod/**"}, path: "dev/db"},
+		{name: "protected", patterns: []string{"prod/**"}, path: "prod/db", wantErr: `"prod/db" matches protected_paths`, wantCode: CodeProtectedPath},
+		{name: "protected by any pattern", patterns: []string{"ci/*", "*/db"}, path: "prod/db", wantErr: "matches protected_paths", wantCode: CodeProtectedPath},
+		{name: "mount", patterns: []string{"work/**"}, path: "work:db", wantErr: `"work/db" matches protected_paths`, wantCode: CodeProtectedPath},
+		{name: "invalid pattern", patterns: []string{"prod/["}, path: "prod/db", wantErr: "failed to check protected_paths", wantCode: CodeError},
+	}
+
+	mountStores(t, "work")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"prod/db": "a", "dev/db": "b", "work/db": "c"})
+			client := NewGopassClient("")
+			client.store = store
+			client.SetProtectedPaths(tc.patterns)
+
+			err := client.RemoveSecret(context.Background(), tc.path)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if _, ok := store.secrets[client.resolveMountPath(context.Background(), tc.path)]; ok {
+					t.Errorf("expected %s to be removed", tc.path)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if code := ErrorCode(err); code != tc.wantCode {
+				t.Errorf("expected code %s, got %s", tc.wantCode, code)
+			}
+			if len(store.secrets) != 3 {
+				t.Errorf("expected nothing to be removed, got %v", storeNames(store))
+			}
+		})
+	}
+}
+
+func TestGopassClient_RemoveDirectory_ProtectedPaths(t *testing.T) {
+	tests := []struct {
+		name      string
+		patterns  []string
+		dir       string
+		failList  bool
+		wantNames []string
+		wantErr   string
+	}{
+		{name: "not protected", dir: "app", wantNames: []string{"prod/api/key", "prod/db"}},
+		{name: "other pattern", patterns: []string{"prod/**"}, dir: "app", wantNames: []string{"prod/api/key", "prod/db"}},
+		{name: "folder protected", patterns: []string{"prod"}, dir: "prod", wantErr: `"prod" matches protected_paths`},
+		{name: "secret below protected", patterns: []string{"prod/api/*"}, dir: "prod", wantErr: `"prod/api/key" matches protected_paths`},
+		{name: "whole store", patterns: []string{"**/key"}, dir: "", wantErr: `"prod/api/key" matches protected_paths`},
+		{name: "list fails", patterns: []string{"prod/**"}, dir: "app", failList: true, wantErr: "failed to check protected_paths"},
+		{name: "invalid pattern", patterns: []string{"["}, dir: "app", wantErr: "failed to check protected_paths"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"prod/db": "a", "prod/api/key": "b", "app/db": "c"})
+			client := NewGopassClient("")
+			client.store = store
+			client.SetProtectedPaths(tc.patterns)
+			if tc.failList {
+				store.shouldFail = true
+				store.failMsg = "store unavailable"
+			}
+
+			err := client.RemoveDirectory(context.Background(), tc.dir)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := storeNames(store); !reflect.DeepEqual(got, tc.wantNames) {
+					t.Errorf("expected %v to be kept, got %v", tc.wantNames, got)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if len(store.secrets) != 3 {
+				t.Errorf("expected nothing to be removed, got %v", storeNames(store))
+			}
+		})
+	}
+}
+
+func TestGopassClient_RemoveSecretChunks_ProtectedPaths(t *testing.T) {
+	store := storeWith(map[string]string{"prod/cert": "", "prod/cert.part1": "a", "prod/cert.part2": "b"})
+	client := NewGopassClient("")
+	client.store = store
+	client.SetProtectedPaths([]string{"prod/**"})
+
+	// Leftover parts are removed when a protected secret is rewritten with fewer parts
+	if err := client.RemoveSecretChunks(context.Background(), "prod/cert", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := storeNames(store), []string{"prod/cert", "prod/cert.part1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSecretResource_Delete_ProtectedPaths(t *testing.T) {
+	store := storeWith(map[string]string{"prod/db": "secret"})
+	client := NewGopassClient("")
+	client.store = store
+	client.SetProtectedPaths([]string{"prod/**"})
+	r := &SecretResource{client: client}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(context.Background(), resource.SchemaRequest{}, schemaResp)
+
+	resp := &resource.DeleteResponse{}
+	r.Delete(context.Background(), resource.DeleteRequest{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"id":               tftypes.NewValue(tftypes.String, "prod/db"),
+			"path":             tftypes.NewValue(tftypes.String, "prod/db"),
+			"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		})},
+	}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected the removal of a protected secret to fail")
+	}
+	if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.HasPrefix(detail, "["+CodeProtectedPath+"]") {
+		t.Errorf("expected %s, got %q", CodeProtectedPath, detail)
+	}
+	if _, ok := store.secrets["prod/db"]; !ok {
+		t.Error("expected the protected secret to be kept")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// PurgeSecretHistory removes every revision of the secret at path from the git history of
+// the store, so a compromised credential cannot be recovered from old commits. The commits
+// themselves are kept with their messages as a record of the changes, only the encrypted
+// file is dropped from them. The rewritten history is then pruned from the local repository.
+// Copies of the repository, e.g. at a remote, keep the history until they are force-pushed.
+// The boolean result is false if the store keeps no git history.
+func (c *GopassClient) PurgeSecretHistory(ctx context.Context, path string) (bool, error) {
+	dir, rel, err := c.resolve(ctx, path)
+	if err != nil {
+		return false, err
+	}
+	path = c.storeName(ctx, path)
+
+	info, err := c.storeInfoAt(ctx, dir)
+	if err != nil {
+		return false, err
+	}
+	if info.Storage != storageGitFS {
+		return false, nil
+	}
+
+	tflog.Debug(ctx, "Purging secret from git history", map[string]interface{}{
+		"path": path,
+	})
+
+	unlock, err := c.lockRoot(ctx, info.Root)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	// The filter runs in a shell for every commit, so the file names are quoted
+	filter := "git rm --cached --ignore-unmatch --quiet -- " + shellQuote(rel+".gpg") + " " + shellQuote(rel+".age")
+	// filter-branch pauses to warn about its pitfalls unless told otherwise
+	if _, err := c.execCommand(ctx, info.Root, "env", "FILTER_BRANCH_SQUELCH_WARNING=1",
+		"git", "filter-branch", "--force", "--index-filter", filter, "--", "--all"); err != nil {
+		return false, fmt.Errorf("failed to rewrite git history of %q: %w", path, err)
+	}
+
+	// filter-branch keeps the original history under refs/original, which must go as well
+	out, err := c.execCommand(ctx, info.Root, "git", "for-each-ref", "--format=%(refname)", "refs/original/")
+	if err != nil {
+		return false, fmt.Errorf("failed to list original refs: %w", err)
+	}
+	for _, ref := range strings.Fields(string(out)) {
+		if _, err := c.execCommand(ctx, info.Root, "git", "update-ref", "-d", ref); err != nil {
+			return false, fmt.Errorf("failed to delete original ref %q: %w", ref, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"reflog", "expire", "--expire=now", "--all"},
+		{"gc", "--prune=now", "--quiet"},
+	} {
+		if _, err := c.execCommand(ctx, info.Root, "git", args...); err != nil {
+			return false, fmt.Errorf("failed to prune git history of %q: %w", path, err)
+		}
+	}
+
+	tflog.Info(ctx, "Purged secret from git history", map[string]interface{}{
+		"path": path,
+	})
+	return true, nil
+}
+
+// shellQuote quotes s as a single word for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,187 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// purgeExec returns an execCommand implementation recording its calls, serving the output of
+// the longest key of output a command starts with and failing commands starting with failOn.
+func purgeExec(calls *[]string, output map[string]string, failOn string) func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		call := strings.Join(append([]string{name}, args...), " ")
+		*calls = append(*calls, call)
+		if failOn != "" && strings.HasPrefix(call, failOn) {
+			return nil, errors.New("git failed")
+		}
+		match := ""
+		for prefix := range output {
+			if strings.HasPrefix(call, prefix) && len(prefix) > len(match) {
+				match = prefix
+			}
+		}
+		return []byte(output[match]), nil
+	}
+}
+
+// gitStoreDir returns a new store root with a .git directory.
+func gitStoreDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestShellQuote(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want string
+	}{
+		{in: "app/db.gpg", want: `'app/db.gpg'`},
+		{in: "it's.gpg", want: `'it'\''s.gpg'`},
+		{in: "$(rm -rf ~)", want: `'$(rm -rf ~)'`},
+	}
+
+	for _, tc := range testCases {
+		if got := shellQuote(tc.in); got != tc.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestGopassClient_PurgeSecretHistory(t *testing.T) {
+	client := NewGopassClient(gitStoreDir(t))
+	var calls []string
+	client.execCommand = purgeExec(&calls, map[string]string{
+		"git for-each-ref": "refs/original/refs/heads/main\nrefs/original/refs/heads/old\n",
+	}, "")
+
+	purged, err := client.PurgeSecretHistory(context.Background(), "work/app/db")
+	if err != nil || !purged {
+		t.Fatalf("PurgeSecretHistory() = %v, %v", purged, err)
+	}
+
+	want := []string{
+		"env FILTER_BRANCH_SQUELCH_WARNING=1 git filter-branch --force --index-filter " +
+			"git rm --cached --ignore-unmatch --quiet -- 'work/app/db.gpg' 'work/app/db.age' -- --all",
+		"git for-each-ref --format=%(refname) refs/original/",
+		"git update-ref -d refs/original/refs/heads/main",
+		"git update-ref -d refs/original/refs/heads/old",
+		"git reflog expire --expire=now --all",
+		"git gc --prune=now --quiet",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q\nwant %q", calls, want)
+	}
+}
+
+func TestGopassClient_PurgeSecretHistory_Errors(t *testing.T) {
+	testCases := []struct {
+		failOn  string
+		wantErr string
+	}{
+		{failOn: "env", wantErr: `failed to rewrite git history of "app/db"`},
+		{failOn: "git for-each-ref", wantErr: "failed to list original refs"},
+		{failOn: "git update-ref", wantErr: `failed to delete original ref "refs/original/refs/heads/main"`},
+		{failOn: "git reflog", wantErr: `failed to prune git history of "app/db"`},
+		{failOn: "git gc", wantErr: `failed to prune git history of "app/db"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.failOn, func(t *testing.T) {
+			client := NewGopassClient(gitStoreDir(t))
+			var calls []string
+			client.execCommand = purgeExec(&calls, map[string]string{
+				"git for-each-ref": "refs/original/refs/heads/main\n",
+			}, tc.failOn)
+
+			purged, err := client.PurgeSecretHistory(context.Background(), "app/db")
+			if purged || err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("PurgeSecretHistory() = %v, %v, want error %q", purged, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGopassClient_PurgeSecretHistory_NotVersioned(t *testing.T) {
+	client := NewGopassClient(t.TempDir())
+	var calls []string
+	client.execCommand = purgeExec(&calls, nil, "")
+
+	purged, err := client.PurgeSecretHistory(context.Background(), "app/db")
+	if err != nil || purged {
+		t.Errorf("PurgeSecretHistory() = %v, %v, want nothing purged", purged, err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no commands, got %q", calls)
+	}
+}
+
+func TestGopassClient_PurgeSecretHistory_StoreMissing(t *testing.T) {
+	client := NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+
+	if _, err := client.PurgeSecretHistory(context.Background(), "app/db"); ErrorCode(err) != CodeStoreNotFound {
+		t.Errorf("expected store not found, got %v", err)
+	}
+}
+
+func TestGopassClient_PurgeSecretHistory_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := runCommand(ctx, dir, "git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return string(out)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	write("app/it's.gpg", "compromised")
+	write("app/keep.gpg", "kept")
+	git("add", ".")
+	git("commit", "-q", "-m", "Save secrets")
+	git("rm", "-q", "app/it's.gpg")
+	git("commit", "-q", "-m", "Remove app/it's")
+
+	client := NewGopassClient(dir)
+	purged, err := client.PurgeSecretHistory(ctx, "app/it's")
+	if err != nil || !purged {
+		t.Fatalf("PurgeSecretHistory() = %v, %v", purged, err)
+	}
+
+	objects := git("rev-list", "--all", "--objects")
+	if strings.Contains(objects, "it's.gpg") {
+		t.Errorf("expected purged secret to be gone from history, got\n%s", objects)
+	}
+	if !strings.Contains(objects, "app/keep.gpg") {
+		t.Errorf("expected other secrets to be kept, got\n%s", objects)
+	}
+	if log := git("log", "--format=%s"); !strings.Contains(log, "Remove app/it's") {
+		t.Errorf("expected commit messages to be kept, got\n%s", log)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// ageRecipientsFile is the file listing the recipients of an age-encrypted store.
+const ageRecipientsFile = ".age-recipients"
+
+// recipientFiles are the files that may list the recipients of a store folder, in lookup order.
+var recipientFiles = []string{gpgIDFile, ageRecipientsFile}
+
+// GetRecipients returns the recipients that secrets under prefix are encrypted for,
+// together with the store-relative recipients file they were read from.
+// Like pass and gopass, it uses the recipients file closest to prefix, walking up
+// towards the store root. An empty prefix refers to the store root.
+func (c *GopassClient) GetRecipients(ctx context.Context, prefix string) ([]string, string, error) {
+	prefix = c.storeName(ctx, prefix)
+	root, err := c.storeDir()
+	if err != nil {
+		return nil, "", err
+	}
+
+	tflog.Debug(ctx, "Reading recipients", map[string]interface{}{
+		"prefix": prefix,
+	})
+
+	dir := prefix
+	for {
+		for _, name := range recipientFiles {
+			rel := filepath.ToSlash(filepath.Join(dir, name))
+			data, err := os.ReadFile(filepath.Join(root, rel))
+			if err == nil {
+				return parseRecipients(data), rel, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, "", fmt.Errorf("failed to read recipients file %q: %w", rel, err)
+			}
+		}
+
+		if dir == "" {
+			return nil, "", fmt.Errorf("no recipients file found for %q in store %q", prefix, root)
+		}
+		if i := strings.LastIndex(dir, "/"); i >= 0 {
+			dir = dir[:i]
+		} else {
+			dir = ""
+		}
+	}
+}
+
+// secretDir returns the folder of the secret at path, or "" for a secret at the store root.
+// A path addressing a mount as "mount:path" must be resolved first.
+func secretDir(path string) string {
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// parseRecipients returns the recipients listed in a recipients file,
+// one per line, skipping blank lines and comments.
+func parseRecipients(data []byte) []string {
+	recipients := []string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recipients = append(recipients, line)
+	}
+	return recipients
+}
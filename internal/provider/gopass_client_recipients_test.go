@@ -0,0 +1,124 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeStoreFile creates a file below dir, creating parent directories as needed.
+func writeStoreFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+}
+
+func TestGopassClient_GetRecipients(t *testing.T) {
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".gpg-id", "0xROOT\n")
+	writeStoreFile(t, dir, "prod/.gpg-id", "# production\n0xOPS\n\n  0xBREAKGLASS  \n")
+	writeStoreFile(t, dir, "age/.age-recipients", "age1example\n")
+
+	tests := []struct {
+		name           string
+		prefix         string
+		wantRecipients []string
+		wantSource     string
+	}{
+		{name: "root", prefix: "", wantRecipients: []string{"0xROOT"}, wantSource: ".gpg-id"},
+		{name: "folder", prefix: "prod", wantRecipients: []string{"0xOPS", "0xBREAKGLASS"}, wantSource: "prod/.gpg-id"},
+		{name: "nested secret", prefix: "prod/db/password", wantRecipients: []string{"0xOPS", "0xBREAKGLASS"}, wantSource: "prod/.gpg-id"},
+		{name: "inherits root", prefix: "dev/app", wantRecipients: []string{"0xROOT"}, wantSource: ".gpg-id"},
+		{name: "age", prefix: "age/key", wantRecipients: []string{"age1example"}, wantSource: "age/.age-recipients"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient(dir)
+
+			recipients, source, err := client.GetRecipients(context.Background(), tc.prefix)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(recipients, tc.wantRecipients) {
+				t.Errorf("expected recipients %v, got %v", tc.wantRecipients, recipients)
+			}
+			if source != tc.wantSource {
+				t.Errorf("expected source %q, got %q", tc.wantSource, source)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GetRecipients_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T) *GopassClient
+		wantErr string
+	}{
+		{
+			name: "no recipients file",
+			setup: func(t *testing.T) *GopassClient {
+				return NewGopassClient(t.TempDir())
+			},
+			wantErr: "no recipients file found",
+		},
+		{
+			name: "unreadable recipients file",
+			setup: func(t *testing.T) *GopassClient {
+				dir := t.TempDir()
+				// A directory named .gpg-id cannot be read as a file
+				if err := os.MkdirAll(filepath.Join(dir, "prod", ".gpg-id"), 0o700); err != nil {
+					t.Fatalf("failed to create directory: %v", err)
+				}
+				return NewGopassClient(dir)
+			},
+			wantErr: "failed to read recipients file",
+		},
+		{
+			name: "store dir failure",
+			setup: func(t *testing.T) *GopassClient {
+				client := NewGopassClient("~/store")
+				client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+				return client
+			},
+			wantErr: "no home",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := tc.setup(t)
+
+			_, _, err := client.GetRecipients(context.Background(), "prod/db")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSecretDir(t *testing.T) {
+	for path, want := range map[string]string{
+		"db":              "",
+		"prod/db":         "prod",
+		"prod/db/primary": "prod/db",
+		"work/db":         "work",
+	} {
+		if got := secretDir(path); got != want {
+			t.Errorf("secretDir(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
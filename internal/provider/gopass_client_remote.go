@@ -0,0 +1,123 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultGitRemote is the remote gopass pushes to and pulls from when syncing.
+const defaultGitRemote = "origin"
+
+// gitStoreDir returns the directory of the store whose git repository is configured:
+// dir if non-empty (with a leading "~/" expanded), otherwise the store of the provider.
+func (c *GopassClient) gitStoreDir(dir string) (string, error) {
+	if dir == "" {
+		return c.storeDir()
+	}
+	return c.expandPath(dir)
+}
+
+// GetGitRemote returns the URL of the git remote name of the store in dir.
+// The boolean result is false if no such remote is configured.
+// An empty dir selects the store of the provider.
+func (c *GopassClient) GetGitRemote(ctx context.Context, dir, name string) (string, bool, error) {
+	dir, err := c.gitStoreDir(dir)
+	if err != nil {
+		return "", false, err
+	}
+	return c.gitRemote(ctx, dir, name)
+}
+
+// gitRemote returns the URL of the git remote name of the repository in dir.
+func (c *GopassClient) gitRemote(ctx context.Context, dir, name string) (string, bool, error) {
+	out, err := c.execCommand(ctx, dir, "git", "remote")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list git remotes in %q: %w", dir, err)
+	}
+	found := false
+	for _, remote := range strings.Fields(string(out)) {
+		if remote == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", false, nil
+	}
+
+	out, err = c.execCommand(ctx, dir, "git", "remote", "get-url", name)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read git remote %q: %w", name, err)
+	}
+	return strings.TrimSpace(string(out)), true, nil
+}
+
+// SetGitRemote points the git remote name of the store in dir at url, adding the remote if needed.
+// An empty dir selects the store of the provider, which must have a backend that syncs.
+func (c *GopassClient) SetGitRemote(ctx context.Context, dir, name, url string) error {
+	if dir == "" && !c.supportsSync(ctx) {
+		return fmt.Errorf("cannot configure git remote %q: the storage backend of the store does not sync, "+
+			"initialize the store with git first", name)
+	}
+	dir, err := c.gitStoreDir(dir)
+	if err != nil {
+		return err
+	}
+	current, exists, err := c.gitRemote(ctx, dir, name)
+	if err != nil {
+		return err
+	}
+	if exists && current == url {
+		return nil
+	}
+
+	tflog.Debug(ctx, "Configuring git remote of gopass store", map[string]interface{}{
+		"dir":    dir,
+		"remote": name,
+	})
+
+	unlock, err := c.lockRoot(ctx, dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	action := "add"
+	if exists {
+		action = "set-url"
+	}
+	if _, err := c.execCommand(ctx, dir, "git", "remote", action, name, url); err != nil {
+		return fmt.Errorf("failed to configure git remote %q: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveGitRemote removes the git remote name from the store in dir. A missing remote is not an error.
+// An empty dir selects the store of the provider.
+func (c *GopassClient) RemoveGitRemote(ctx context.Context, dir, name string) error {
+	dir, err := c.gitStoreDir(dir)
+	if err != nil {
+		return err
+	}
+	_, exists, err := c.gitRemote(ctx, dir, name)
+	if err != nil || !exists {
+		return err
+	}
+
+	unlock, err := c.lockRoot(ctx, dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := c.execCommand(ctx, dir, "git", "remote", "remove", name); err != nil {
+		return fmt.Errorf("failed to remove git remote %q: %w", name, err)
+	}
+	return nil
+}
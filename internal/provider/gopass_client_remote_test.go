@@ -0,0 +1,208 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeRemotes simulates the remotes of a git repository for `git remote` commands.
+// A command whose arguments start with failOn fails.
+type fakeRemotes struct {
+	urls   map[string]string
+	failOn string
+	dirs   []string
+	calls  []string
+}
+
+func newFakeRemotes(urls map[string]string) *fakeRemotes {
+	if urls == nil {
+		urls = map[string]string{}
+	}
+	return &fakeRemotes{urls: urls}
+}
+
+func (f *fakeRemotes) run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	call := strings.Join(args, " ")
+	f.dirs = append(f.dirs, dir)
+	f.calls = append(f.calls, call)
+	if f.failOn != "" && strings.HasPrefix(call, f.failOn) {
+		return nil, errors.New("git failed")
+	}
+
+	switch {
+	case call == "remote":
+		names := make([]string, 0, len(f.urls))
+		for remote := range f.urls {
+			names = append(names, remote)
+		}
+		sort.Strings(names)
+		return []byte(strings.Join(names, "\n") + "\n"), nil
+	case args[1] == "get-url":
+		return []byte(f.urls[args[2]] + "\n"), nil
+	case args[1] == "add", args[1] == "set-url":
+		f.urls[args[2]] = args[3]
+	case args[1] == "remove":
+		delete(f.urls, args[2])
+	}
+	return nil, nil
+}
+
+func TestGopassClient_GetGitRemote(t *testing.T) {
+	tests := []struct {
+		name       string
+		failOn     string
+		remote     string
+		wantURL    string
+		wantExists bool
+		wantErr    string
+	}{
+		{name: "configured", remote: "origin", wantURL: "git@example.com:store.git", wantExists: true},
+		{name: "missing", remote: "upstream"},
+		{name: "list fails", remote: "origin", failOn: "remote", wantErr: "failed to list git remotes"},
+		{name: "get-url fails", remote: "origin", failOn: "remote get-url", wantErr: `failed to read git remote "origin"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(map[string]string{"origin": "git@example.com:store.git", "backup": "/srv/backup"})
+			fake.failOn = tc.failOn
+			client := NewGopassClient("/store")
+			client.execCommand = fake.run
+
+			url, exists, err := client.GetGitRemote(context.Background(), "", tc.remote)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if url != tc.wantURL || exists != tc.wantExists {
+				t.Errorf("got (%q, %v), want (%q, %v)", url, exists, tc.wantURL, tc.wantExists)
+			}
+			if fake.dirs[0] != "/store" {
+				t.Errorf("expected git to run in the provider store, got %q", fake.dirs[0])
+			}
+		})
+	}
+}
+
+func TestGopassClient_SetGitRemote(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  map[string]string
+		failOn    string
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			name:      "adds remote",
+			wantCalls: []string{"remote", "remote add origin https://example.com/store.git"},
+		},
+		{
+			name:      "changes url",
+			existing:  map[string]string{"origin": "https://old.example.com/store.git"},
+			wantCalls: []string{"remote", "remote get-url origin", "remote set-url origin https://example.com/store.git"},
+		},
+		{
+			name:      "already configured",
+			existing:  map[string]string{"origin": "https://example.com/store.git"},
+			wantCalls: []string{"remote", "remote get-url origin"},
+		},
+		{name: "list fails", failOn: "remote", wantErr: true},
+		{name: "add fails", failOn: "remote add", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(tc.existing)
+			fake.failOn = tc.failOn
+			client := NewGopassClient("")
+			client.execCommand = fake.run
+
+			err := client.SetGitRemote(context.Background(), "/ci/store", "origin", "https://example.com/store.git")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(fake.calls, tc.wantCalls) {
+				t.Errorf("calls = %q, want %q", fake.calls, tc.wantCalls)
+			}
+			if fake.urls["origin"] != "https://example.com/store.git" {
+				t.Errorf("expected origin to be configured, got %v", fake.urls)
+			}
+			if fake.dirs[0] != "/ci/store" {
+				t.Errorf("expected git to run in the given store, got %q", fake.dirs[0])
+			}
+		})
+	}
+}
+
+func TestGopassClient_RemoveGitRemote(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  map[string]string
+		failOn    string
+		wantCalls []string
+		wantErr   bool
+	}{
+		{
+			name:      "removes remote",
+			existing:  map[string]string{"origin": "https://example.com/store.git"},
+			wantCalls: []string{"remote", "remote get-url origin", "remote remove origin"},
+		},
+		{name: "already removed", wantCalls: []string{"remote"}},
+		{name: "list fails", failOn: "remote", wantErr: true},
+		{
+			name:     "remove fails",
+			existing: map[string]string{"origin": "https://example.com/store.git"},
+			failOn:   "remote remove",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeRemotes(tc.existing)
+			fake.failOn = tc.failOn
+			client := NewGopassClient("/store")
+			client.execCommand = fake.run
+
+			err := client.RemoveGitRemote(context.Background(), "", "origin")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(fake.calls, tc.wantCalls) {
+				t.Errorf("calls = %q, want %q", fake.calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GitRemote_DirError(t *testing.T) {
+	client := NewGopassClient("")
+	client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+	client.execCommand = newFakeRemotes(nil).run
+	ctx := context.Background()
+
+	if _, _, err := client.GetGitRemote(ctx, "~/store", "origin"); err == nil {
+		t.Error("GetGitRemote: expected error")
+	}
+	if err := client.SetGitRemote(ctx, "~/store", "origin", "url"); err == nil {
+		t.Error("SetGitRemote: expected error")
+	}
+	if err := client.RemoveGitRemote(ctx, "~/store", "origin"); err == nil {
+		t.Error("RemoveGitRemote: expected error")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// parseRelativeRevision parses a revision relative to the latest one, like "-1" for the
+// revision before it, as accepted by `gopass show --revision`. ok is false for other
+// revisions, e.g. commit hashes.
+func parseRelativeRevision(revision string) (back int, ok bool, err error) {
+	if !strings.HasPrefix(revision, "-") {
+		return 0, false, nil
+	}
+	back, err = strconv.Atoi(revision[1:])
+	if err != nil || back < 1 {
+		return 0, true, fmt.Errorf("relative revision %q must be a minus sign followed by a positive number, e.g. -1", revision)
+	}
+	return back, true, nil
+}
+
+// ResolveRevision returns the revision of the secret at path to pass to the store. A
+// relative revision like "-1" is resolved to the commit hash of the Nth revision before the
+// latest, using the history of the secret, which requires a store supporting revisions.
+// Other revisions, e.g. commit hashes from `gopass history`, are returned unchanged.
+func (c *GopassClient) ResolveRevision(ctx context.Context, path, revision string) (string, error) {
+	back, relative, err := parseRelativeRevision(revision)
+	if err != nil {
+		return "", err
+	}
+	if !relative {
+		return revision, nil
+	}
+
+	path = c.resolveMountPath(ctx, path)
+	revisions, err := c.ListRevisions(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if back >= len(revisions) {
+		return "", c.notifyError(ctx, opGet, path, fmt.Errorf("secret %q has %d revisions, so there is no revision %s", path, len(revisions), revision))
+	}
+
+	tflog.Debug(ctx, "Resolved relative revision", map[string]interface{}{
+		"path":     path,
+		"revision": revision,
+		"resolved": revisions[back],
+	})
+	return revisions[back], nil
+}
+
+// ListRevisions returns the revisions of the secret at path, latest first, like `git log`.
+// In git-backed stores they are commit hashes that can be passed as a revision to read the
+// secret as it was. A secret without revisions is reported as not found.
+func (c *GopassClient) ListRevisions(ctx context.Context, path string) ([]string, error) {
+	path = c.resolveMountPath(ctx, path)
+	if err := c.ensureStore(ctx); err != nil {
+		return nil, c.notifyError(ctx, opGet, path, err)
+	}
+
+	if !c.supportsRevisions(ctx, path) {
+		return nil, c.notifyError(ctx, opGet, path, fmt.Errorf("cannot list revisions of secret %q: the storage backend of the store keeps no history", path))
+	}
+
+	revisions, err := c.store.Revisions(ctx, path)
+	if err != nil {
+		return nil, c.notifyError(ctx, opGet, path, fmt.Errorf("failed to list revisions of secret %q: %w", path, c.classifyNotFound(err)))
+	}
+	if len(revisions) == 0 {
+		return nil, c.notifyError(ctx, opGet, path, fmt.Errorf("secret %q has no revisions: %w", path, ErrSecretNotFound))
+	}
+
+	tflog.Debug(ctx, "Listed secret revisions", map[string]interface{}{
+		"path":  path,
+		"count": len(revisions),
+	})
+	return revisions, nil
+}
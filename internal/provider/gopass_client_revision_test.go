@@ -0,0 +1,133 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRelativeRevision(t *testing.T) {
+	tests := []struct {
+		revision     string
+		wantBack     int
+		wantRelative bool
+		wantErr      bool
+	}{
+		{revision: "3f2a9c1"},
+		{revision: "latest"},
+		{revision: "-1", wantBack: 1, wantRelative: true},
+		{revision: "-12", wantBack: 12, wantRelative: true},
+		{revision: "-0", wantRelative: true, wantErr: true},
+		{revision: "-", wantRelative: true, wantErr: true},
+		{revision: "-one", wantRelative: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		back, relative, err := parseRelativeRevision(tc.revision)
+		if back != tc.wantBack || relative != tc.wantRelative || (err != nil) != tc.wantErr {
+			t.Errorf("parseRelativeRevision(%q) = %d, %v, %v; want %d, %v, error=%v",
+				tc.revision, back, relative, err, tc.wantBack, tc.wantRelative, tc.wantErr)
+		}
+	}
+}
+
+func TestGopassClient_ResolveRevision(t *testing.T) {
+	tests := []struct {
+		name      string
+		storePath string
+		revision  string
+		fail      bool
+		want      string
+		wantErr   string
+	}{
+		{name: "hash", revision: "3f2a9c1", want: "3f2a9c1"},
+		{name: "previous", revision: "-1", want: "c2"},
+		{name: "oldest", revision: "-2", want: "c1"},
+		{name: "out of range", revision: "-3", wantErr: `secret "app/db" has 3 revisions, so there is no revision -3`},
+		{name: "invalid", revision: "-x", wantErr: "must be a minus sign followed by a positive number"},
+		{name: "revisions fail", revision: "-1", fail: true, wantErr: "failed to list revisions of secret"},
+		{name: "store missing", storePath: "/nonexistent/store", revision: "-1", wantErr: "/nonexistent/store"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient(tc.storePath)
+			if tc.storePath == "" {
+				store := storeWith(map[string]string{"app/db": "secret"})
+				store.revisions["app/db"] = []string{"c3", "c2", "c1"}
+				store.shouldFail = tc.fail
+				store.failMsg = "history failed"
+				client.store = store
+			}
+
+			got, err := client.ResolveRevision(context.Background(), "app/db", tc.revision)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveRevision() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("ResolveRevision() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGopassClient_ListRevisions(t *testing.T) {
+	tests := []struct {
+		name      string
+		storePath string
+		revisions []string
+		fail      bool
+		want      []string
+		wantErr   string
+		wantCode  string
+	}{
+		{name: "history", revisions: []string{"c3", "c2", "c1"}, want: []string{"c3", "c2", "c1"}},
+		{name: "no history", revisions: []string{}, wantErr: `secret "app/db" has no revisions`, wantCode: CodeSecretNotFound},
+		{name: "missing secret", wantErr: "failed to list revisions of secret", wantCode: CodeError},
+		{name: "revisions fail", revisions: []string{"c1"}, fail: true, wantErr: "history failed", wantCode: CodeError},
+		{name: "store missing", storePath: "/nonexistent/store", wantErr: "/nonexistent/store", wantCode: CodeStoreNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient(tc.storePath)
+			if tc.storePath == "" {
+				store := storeWith(map[string]string{"app/db": "secret"})
+				store.revisions["app/db"] = tc.revisions
+				if tc.revisions == nil {
+					delete(store.revisions, "app/db")
+				}
+				store.shouldFail = tc.fail
+				store.failMsg = "history failed"
+				client.store = store
+			}
+
+			got, err := client.ListRevisions(context.Background(), "app/db")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				if code := ErrorCode(err); code != tc.wantCode {
+					t.Errorf("expected code %s, got %s", tc.wantCode, code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListRevisions() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ListRevisions() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
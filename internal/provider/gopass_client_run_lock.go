@@ -0,0 +1,174 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// storeLockFile is the file a run writing to a store locks, see SetLockTimeout.
+const storeLockFile = "terraform-provider-gopass.lock"
+
+// runLockPollInterval is how often the lock of another run is tried again while waiting for it.
+var runLockPollInterval = 250 * time.Millisecond
+
+// runLocks are the open lock files of the stores this process locked, by store root.
+// Their locks are released by releaseStoreLocks, or when the provider exits.
+var (
+	runLocksMu sync.Mutex
+	runLocks   = map[string]*os.File{}
+)
+
+// SetLockTimeout makes the client lock the store on its first write for the rest of the run,
+// so concurrent Terraform runs writing to the same store do not interleave their writes. A run
+// waits up to timeout for another one to release the store, and fails after. The lock is
+// advisory: gopass itself and other tools do not honor it.
+func (c *GopassClient) SetLockTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runLock = true
+	c.lockTimeout = timeout
+}
+
+// storeLockPath returns the lock file of the store at root. In a git repository it is kept
+// in the .git directory, like the locks of git itself, so it is never committed.
+func storeLockPath(root string) string {
+	if info, err := os.Stat(filepath.Join(root, ".git")); err == nil && info.IsDir() {
+		return filepath.Join(root, ".git", storeLockFile)
+	}
+	return filepath.Join(root, "."+storeLockFile)
+}
+
+// lockRun locks the store at root for the rest of the run if SetLockTimeout enabled it, and
+// does nothing if this process already holds the lock. A store that does not exist yet, e.g.
+// one about to be initialized, is locked on the first write after it was created.
+func (c *GopassClient) lockRun(ctx context.Context, root string) error {
+	if !c.runLock {
+		return nil
+	}
+
+	root = filepath.Clean(root)
+	runLocksMu.Lock()
+	_, held := runLocks[root]
+	runLocksMu.Unlock()
+	if held {
+		return nil
+	}
+
+	path := storeLockPath(root)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open lock file %s: %w", path, err)
+	}
+
+	if err := c.waitForRunLock(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	// The holder is recorded to tell waiting runs whom they wait for; the lock works without it
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(runLockHolder()), 0)
+
+	runLocksMu.Lock()
+	runLocks[root] = f
+	runLocksMu.Unlock()
+
+	tflog.Info(ctx, "Locked gopass store for this run", map[string]interface{}{
+		"root": root,
+	})
+	return nil
+}
+
+// waitForRunLock locks f, waiting up to the lock timeout of the client while another run holds it.
+// Waiting ends early with an error when ctx is done, so the timeouts of resources are honored.
+func (c *GopassClient) waitForRunLock(ctx context.Context, f *os.File) error {
+	timeout := time.NewTimer(c.lockTimeout)
+	defer timeout.Stop()
+
+	for waited := false; ; waited = true {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+		}
+		if locked {
+			return nil
+		}
+
+		holder := readRunLockHolder(f.Name())
+		if !waited {
+			c.metrics.countLockWait()
+			tflog.Info(ctx, "Waiting for another run to release the gopass store", map[string]interface{}{
+				"lock":    f.Name(),
+				"holder":  holder,
+				"timeout": c.lockTimeout.String(),
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up waiting for the store locked by %s: %w", holder, ctx.Err())
+		case <-timeout.C:
+			return withCode(CodeStoreLocked, fmt.Errorf(
+				"the store is locked by another run (%s) and was not released within the lock_timeout of %s; "+
+					"if no such run is left, the lock is released already and the run can be retried, see %s",
+				holder, c.lockTimeout, f.Name(),
+			))
+		case <-time.After(runLockPollInterval):
+		}
+	}
+}
+
+// runLockHolder describes this process for the lock file.
+func runLockHolder() string {
+	// The host name only helps to find the holder, so an unknown one is left empty
+	host, _ := os.Hostname()
+	return fmt.Sprintf("pid %d on %q since %s\n", os.Getpid(), host, timeNow().UTC().Format(time.RFC3339))
+}
+
+// readRunLockHolder returns the holder recorded in the lock file at path.
+func readRunLockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if holder := strings.TrimSpace(string(data)); err == nil && holder != "" {
+		return holder
+	}
+	return "an unknown process"
+}
+
+// releaseStoreLocks releases the locks of all stores locked by SetLockTimeout. It is called
+// when Terraform stops the provider, see providerServer; otherwise the locks are released by
+// the operating system when the provider exits, so they cover the whole run. A write after
+// the release locks the store again. The lock files are kept, as removing them would race
+// with runs that just opened them.
+func releaseStoreLocks(ctx context.Context) {
+	runLocksMu.Lock()
+	defer runLocksMu.Unlock()
+
+	for root, f := range runLocks {
+		// Closing the file releases its lock
+		if err := f.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to release gopass store lock", map[string]interface{}{
+				"root":  root,
+				"error": err.Error(),
+			})
+			continue
+		}
+		tflog.Debug(ctx, "Released gopass store lock", map[string]interface{}{
+			"root": root,
+		})
+	}
+	runLocks = map[string]*os.File{}
+}
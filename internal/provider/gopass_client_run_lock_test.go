@@ -0,0 +1,270 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// resetRunLocks releases the run locks taken by a test and restores the poll interval.
+func resetRunLocks(t *testing.T) {
+	t.Helper()
+	interval := runLockPollInterval
+	runLockPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() {
+		releaseStoreLocks(context.Background())
+		runLockPollInterval = interval
+	})
+}
+
+// holdRunLock locks the lock file of the store at root like another run, until the test ends.
+func holdRunLock(t *testing.T, root, holder string) *os.File {
+	t.Helper()
+	f, err := os.OpenFile(storeLockPath(root), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if locked, err := tryLockFile(f); err != nil || !locked {
+		t.Fatalf("expected to lock %s, got %v, %v", f.Name(), locked, err)
+	}
+	if _, err := f.WriteString(holder); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// lockedByOther reports whether another open file can not lock the lock file of the store at root.
+func lockedByOther(t *testing.T, root string) bool {
+	t.Helper()
+	f, err := os.Open(storeLockPath(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	locked, err := tryLockFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return !locked
+}
+
+func TestStoreLockPath(t *testing.T) {
+	plain := t.TempDir()
+	if got, want := storeLockPath(plain), filepath.Join(plain, ".terraform-provider-gopass.lock"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	repo := gitStoreDir(t)
+	if got, want := storeLockPath(repo), filepath.Join(repo, ".git", "terraform-provider-gopass.lock"); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestGopassClient_LockRun(t *testing.T) {
+	resetRunLocks(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	client.store = newMockStore()
+	client.SetLockTimeout(time.Minute)
+
+	if err := client.SetSecret(ctx, "app/db", "hunter2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lockedByOther(t, dir) {
+		t.Fatal("expected the store to be locked after the first write")
+	}
+	holder := readRunLockHolder(storeLockPath(dir))
+	if want := fmt.Sprintf("pid %d on ", os.Getpid()); !strings.HasPrefix(holder, want) {
+		t.Errorf("expected holder %q to start with %q", holder, want)
+	}
+
+	// Further writes keep the lock, also those of other clients of the process
+	other := NewGopassClient(dir)
+	other.store = newMockStore()
+	other.SetLockTimeout(0)
+	if err := other.SetSecret(ctx, "app/api", "key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runLocks) != 1 {
+		t.Errorf("expected a single run lock, got %v", runLocks)
+	}
+
+	releaseStoreLocks(ctx)
+	if lockedByOther(t, dir) {
+		t.Error("expected the store to be released")
+	}
+}
+
+func TestGopassClient_LockRun_Disabled(t *testing.T) {
+	resetRunLocks(t)
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	client.store = newMockStore()
+
+	if err := client.SetSecret(context.Background(), "app/db", "hunter2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(storeLockPath(dir)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no lock file, got %v", err)
+	}
+}
+
+func TestGopassClient_LockRun_Waits(t *testing.T) {
+	resetRunLocks(t)
+	dir := t.TempDir()
+	held := holdRunLock(t, dir, "pid 1 on \"ci\"")
+	client := NewGopassClient(dir)
+	client.store = newMockStore()
+	client.EnableMetrics()
+	client.SetLockTimeout(time.Minute)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		held.Close()
+	}()
+
+	if err := client.SetSecret(context.Background(), "app/db", "hunter2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := client.metrics.lockWaits.Load(); got != 1 {
+		t.Errorf("expected 1 lock wait, got %d", got)
+	}
+}
+
+func TestGopassClient_LockRun_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, client *GopassClient) context.Context
+		wantErr  string
+		wantCode string
+		wantIs   error
+	}{
+		{
+			name: "locked by another run",
+			setup: func(t *testing.T, client *GopassClient) context.Context {
+				holdRunLock(t, client.storePath, "pid 1 on \"ci\" since 2026-10-16T12:00:00Z\n")
+				client.SetLockTimeout(0)
+				return context.Background()
+			},
+			wantErr:  `locked by another run (pid 1 on "ci" since 2026-10-16T12:00:00Z) and was not released within the lock_timeout of 0s`,
+			wantCode: CodeStoreLocked,
+		},
+		{
+			name: "unknown holder",
+			setup: func(t *testing.T, client *GopassClient) context.Context {
+				holdRunLock(t, client.storePath, "")
+				client.SetLockTimeout(0)
+				return context.Background()
+			},
+			wantErr:  "locked by another run (an unknown process)",
+			wantCode: CodeStoreLocked,
+		},
+		{
+			name: "canceled while waiting",
+			setup: func(t *testing.T, client *GopassClient) context.Context {
+				holdRunLock(t, client.storePath, "pid 1")
+				client.SetLockTimeout(time.Hour)
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			wantErr:  "gave up waiting for the store locked by pid 1",
+			wantCode: CodeTimeout,
+			wantIs:   context.DeadlineExceeded,
+		},
+		{
+			name: "lock file is a directory",
+			setup: func(t *testing.T, client *GopassClient) context.Context {
+				if err := os.Mkdir(storeLockPath(client.storePath), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				client.SetLockTimeout(0)
+				return context.Background()
+			},
+			wantErr:  "failed to open lock file",
+			wantCode: CodeError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetRunLocks(t)
+			client := NewGopassClient(t.TempDir())
+			client.store = newMockStore()
+			ctx := tc.setup(t, client)
+
+			err := client.SetSecret(ctx, "app/db", "hunter2")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if code := ErrorCode(err); code != tc.wantCode {
+				t.Errorf("expected code %s, got %s", tc.wantCode, code)
+			}
+			if tc.wantIs != nil && !errors.Is(err, tc.wantIs) {
+				t.Errorf("expected %v, got %v", tc.wantIs, err)
+			}
+			if _, ok := client.store.(*mockStore).secrets["app/db"]; ok {
+				t.Error("expected nothing to be written")
+			}
+
+			// The write lock of the process was released again
+			unlock, err := lockStore(context.Background(), client.storePath, nil)
+			if err != nil {
+				t.Fatalf("expected the write lock to be released, got %v", err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestGopassClient_LockRun_MissingStore(t *testing.T) {
+	resetRunLocks(t)
+	client := NewGopassClient("")
+	client.SetLockTimeout(0)
+	root := filepath.Join(t.TempDir(), "new")
+
+	if err := client.lockRun(context.Background(), root); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runLocks) != 0 {
+		t.Errorf("expected no run lock for a missing store, got %v", runLocks)
+	}
+}
+
+func TestGopassClient_WaitForRunLock_Error(t *testing.T) {
+	client := NewGopassClient("")
+	f, err := os.Create(filepath.Join(t.TempDir(), "lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := client.waitForRunLock(context.Background(), f); err == nil || !strings.Contains(err.Error(), "failed to lock") {
+		t.Errorf("expected lock error, got %v", err)
+	}
+}
+
+func TestReleaseStoreLocks_CloseError(t *testing.T) {
+	resetRunLocks(t)
+	f, err := os.Create(filepath.Join(t.TempDir(), "lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	runLocks["/store"] = f
+
+	releaseStoreLocks(context.Background())
+	if len(runLocks) != 0 {
+		t.Errorf("expected run locks to be cleared, got %v", runLocks)
+	}
+}
@@ -0,0 +1,334 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+)
+
+// revisionRecordingStore records the revision requested on each Get.
+type revisionRecordingStore struct {
+	*mockStore
+	requested []string
+}
+
+func (m *revisionRecordingStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	m.requested = append(m.requested, revision)
+	return m.mockStore.Get(ctx, name, revision)
+}
+
+// fakeGit returns an execCommand implementation that serves the given output
+// for `git ls-tree` and records the directory and arguments it was called with.
+func fakeGit(output string, err error, gotDir *string, gotArgs *[]string) func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		*gotDir = dir
+		*gotArgs = append([]string{name}, args...)
+		return []byte(output), err
+	}
+}
+
+func TestGopassClient_GetSecretAt_Snapshot(t *testing.T) {
+	client := NewGopassClient("")
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["test/secret"] = newMockSecret("old-password")
+	client.store = store
+	client.readsRevisions = true
+
+	value, err := client.GetSecretAt(context.Background(), "test/secret", "v1.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "old-password" {
+		t.Errorf("expected 'old-password', got %q", value)
+	}
+	if len(store.requested) != 1 || store.requested[0] != "v1.0" {
+		t.Errorf("expected revision 'v1.0' to be requested, got %v", store.requested)
+	}
+}
+
+func TestGopassClient_GetSecretAt_SnapshotCLI(t *testing.T) {
+	// The binary answers with the value of the revision it is asked for
+	run := func(ctx context.Context, env []string, stdin []byte, name string, args ...string) ([]byte, error) {
+		if args[0] != "show" {
+			return nil, nil
+		}
+		for i, arg := range args {
+			if arg == "--revision" && args[i+1] == "v1.0" {
+				return []byte("old-password\n"), nil
+			}
+		}
+		return []byte("new-password\n"), nil
+	}
+	client := NewGopassClient("")
+	client.UseCLI("gopass", nil)
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return newCLIStore(ctx, "gopass", dir, nil, run)
+	}
+
+	for snapshot, want := range map[string]string{"v1.0": "old-password", "": "new-password"} {
+		value, err := client.GetSecretAt(context.Background(), "test/secret", snapshot)
+		if err != nil {
+			t.Fatalf("GetSecretAt(%q) error: %v", snapshot, err)
+		}
+		if value != want {
+			t.Errorf("GetSecretAt(%q) = %q, want %q", snapshot, value, want)
+		}
+	}
+}
+
+func TestGopassClient_GetSecretAt_SnapshotLibrary(t *testing.T) {
+	client := NewGopassClient("/store")
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["env/app/KEY1"] = newMockSecret("current")
+	client.store = store
+	client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		t.Fatalf("expected git not to run, got %v", args)
+		return nil, nil
+	}
+
+	// The library would return the current value for the old one, so reading at a snapshot fails
+	_, err := client.GetSecretAt(context.Background(), "env/app/KEY1", "v1.0")
+	if err == nil || ErrorCode(err) != CodeInvalidConfig || !strings.Contains(err.Error(), `set mode = "cli"`) {
+		t.Errorf("expected %s error asking for CLI mode, got %v", CodeInvalidConfig, err)
+	}
+	// A tree is not read either, instead of skipping every secret in it
+	if _, _, err := client.ReadEnvSecretsAt(context.Background(), "env/app", "v1.0", 0); err == nil || ErrorCode(err) != CodeInvalidConfig {
+		t.Errorf("expected %s error for the tree, got %v", CodeInvalidConfig, err)
+	}
+	if len(store.requested) != 0 {
+		t.Errorf("expected no secret to be decrypted, got %v", store.requested)
+	}
+}
+
+func TestGopassClient_GetSecretAt_Latest(t *testing.T) {
+	client := NewGopassClient("")
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["test/secret"] = newMockSecret("current")
+	client.store = store
+
+	if _, err := client.GetSecret(context.Background(), "test/secret"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(store.requested) != 1 || store.requested[0] != "latest" {
+		t.Errorf("expected revision 'latest' to be requested, got %v", store.requested)
+	}
+}
+
+func TestGopassClient_ListSecretsRecursiveAt_Snapshot(t *testing.T) {
+	client := NewGopassClient("/store")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit(
+		".gpg-id\n"+
+			"env/app/KEY1.gpg\n"+
+			"env/app/sub/KEY2.age\n"+
+			"env/app/.gpg-id\n"+
+			"env/app/README.md\n"+
+			"env/other/KEY3.gpg\n",
+		nil, &gotDir, &gotArgs)
+
+	results, err := client.ListSecretsRecursiveAt(context.Background(), "env/app/", "release-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Strings(results)
+	expected := []string{"env/app/KEY1", "env/app/sub/KEY2"}
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("expected %v, got %v", expected, results)
+	}
+
+	if gotDir != "/store" {
+		t.Errorf("expected git to run in '/store', got %q", gotDir)
+	}
+	wantArgs := []string{"git", "ls-tree", "-r", "--name-only", "--end-of-options", "release-1"}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("expected args %v, got %v", wantArgs, gotArgs)
+	}
+}
+
+func TestGopassClient_ListSecretsRecursiveAt_NoSnapshot(t *testing.T) {
+	client := NewGopassClient("")
+	mockStore := newMockStore()
+	mockStore.secrets["env/app/KEY1"] = newMockSecret("v")
+	client.store = mockStore
+	client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		t.Fatal("git must not be called without a snapshot")
+		return nil, nil
+	}
+
+	results, err := client.ListSecretsRecursiveAt(context.Background(), "env/app", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0] != "env/app/KEY1" {
+		t.Errorf("expected [env/app/KEY1], got %v", results)
+	}
+}
+
+func TestGopassClient_ListSecretsRecursiveAt_GitError(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot string
+		failOn   string
+		wantErr  string
+	}{
+		{name: "unknown ref", snapshot: "missing-tag", failOn: "rev-parse", wantErr: `snapshot "missing-tag" is not a commit in the store`},
+		{name: "ls-tree failure", snapshot: "v1", failOn: "ls-tree", wantErr: "failed to list secrets at snapshot"},
+		{name: "option", snapshot: "--output=/tmp/x", wantErr: "must not start with '-'"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("/store")
+			client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+				if tc.failOn == "" {
+					t.Fatalf("expected git not to run, got %v", args)
+				}
+				if args[0] == tc.failOn {
+					return nil, errors.New("unknown revision")
+				}
+				return nil, nil
+			}
+
+			_, err := client.ListSecretsRecursiveAt(context.Background(), "env/app", tc.snapshot)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GetSecretAt_InvalidSnapshot(t *testing.T) {
+	client := NewGopassClient("")
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["test/secret"] = newMockSecret("old-password")
+	client.store = store
+
+	_, err := client.GetSecretAt(context.Background(), "test/secret", "--output=/tmp/x")
+	if err == nil || ErrorCode(err) != CodeInvalidConfig {
+		t.Fatalf("expected %s error, got %v", CodeInvalidConfig, err)
+	}
+	if len(store.requested) != 0 {
+		t.Errorf("expected no revision to be requested, got %v", store.requested)
+	}
+}
+
+func TestGopassClient_ListSecretsRecursiveAt_StoreDirError(t *testing.T) {
+	client := NewGopassClient("~/store")
+	client.userHomeDir = func() (string, error) {
+		return "", errors.New("no home")
+	}
+
+	_, err := client.ListSecretsRecursiveAt(context.Background(), "env/app", "v1")
+	if err == nil {
+		t.Fatal("expected error but got none")
+	}
+	if !strings.Contains(err.Error(), "failed to expand home directory") {
+		t.Errorf("expected home expansion error, got %v", err)
+	}
+}
+
+func TestGopassClient_GetEnvSecretsAt_Snapshot(t *testing.T) {
+	client := NewGopassClient("/store")
+	store := &revisionRecordingStore{mockStore: newMockStore()}
+	store.secrets["env/app/KEY1"] = newMockSecret("value1")
+	client.store = store
+	client.readsRevisions = true
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("env/app/KEY1.gpg\n", nil, &gotDir, &gotArgs)
+
+	values, err := client.GetEnvSecretsAt(context.Background(), "env/app", "v2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["KEY1"] != "value1" {
+		t.Errorf("expected KEY1=value1, got %v", values)
+	}
+	if len(store.requested) != 1 || store.requested[0] != "v2" {
+		t.Errorf("expected snapshot 'v2' to be requested, got %v", store.requested)
+	}
+}
+
+func TestGopassClient_StoreDir(t *testing.T) {
+	t.Run("configured path", func(t *testing.T) {
+		client := NewGopassClient("/configured")
+		dir, err := client.storeDir()
+		if err != nil || dir != "/configured" {
+			t.Errorf("expected '/configured', got %q (err %v)", dir, err)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "/from/env")
+		client := NewGopassClient("")
+		dir, err := client.storeDir()
+		if err != nil || dir != "/from/env" {
+			t.Errorf("expected '/from/env', got %q (err %v)", dir, err)
+		}
+	})
+
+	t.Run("gopass default", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "")
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "/home/user", nil }
+		dir, err := client.storeDir()
+		want := filepath.Join("/home/user", ".local", "share", "gopass", "stores", "root")
+		if err != nil || dir != want {
+			t.Errorf("expected %q, got %q (err %v)", want, dir, err)
+		}
+	})
+
+	t.Run("home error", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "")
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+		if _, err := client.storeDir(); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+}
+
+func TestSecretNameFromFile(t *testing.T) {
+	testCases := []struct {
+		file string
+		name string
+		ok   bool
+	}{
+		{file: "a/b.gpg", name: "a/b", ok: true},
+		{file: "a/b.age", name: "a/b", ok: true},
+		{file: ".gpg-id", ok: false},
+		{file: "a/.gpg-id", ok: false},
+		{file: "a/README.md", ok: false},
+		{file: "", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.file, func(t *testing.T) {
+			name, ok := secretNameFromFile(tc.file)
+			if ok != tc.ok || name != tc.name {
+				t.Errorf("secretNameFromFile(%q) = (%q, %v), want (%q, %v)", tc.file, name, ok, tc.name, tc.ok)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	out, err := runCommand(context.Background(), t.TempDir(), "go", "env", "GOOS")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.TrimSpace(string(out)) == "" {
+		t.Error("expected output from command")
+	}
+}
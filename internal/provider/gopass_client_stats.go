@@ -0,0 +1,88 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// StoreStats summarizes the secrets under a folder of the store.
+type StoreStats struct {
+	Total    int            // number of secrets at any depth
+	Prefixes map[string]int // number of secrets by folder, see GetStoreStats
+	LastSync string         // when the store last fetched from or pushed to its remote (RFC 3339), "" if unknown
+}
+
+// GetStoreStats counts the secrets under prefix, or in the whole store if prefix is empty,
+// without decrypting any. Prefixes counts them by the folder depth components below prefix
+// they are in; secrets closer to prefix are counted under their own folder.
+func (c *GopassClient) GetStoreStats(ctx context.Context, prefix string, depth int) (*StoreStats, error) {
+	prefix = strings.TrimSuffix(c.resolveMountPath(ctx, prefix), "/")
+	if err := c.ensureStore(ctx); err != nil {
+		return nil, c.notifyError(ctx, opList, prefix, err)
+	}
+
+	all, err := c.store.List(ctx)
+	if err != nil {
+		return nil, c.notifyError(ctx, opList, prefix, fmt.Errorf("failed to list secrets: %w", err))
+	}
+
+	stats := &StoreStats{Prefixes: make(map[string]int), LastSync: c.lastSync(ctx)}
+	for _, secretPath := range all {
+		rel := secretPath
+		if prefix != "" {
+			var found bool
+			if rel, found = strings.CutPrefix(secretPath, prefix+"/"); !found {
+				continue
+			}
+		}
+		stats.Total++
+
+		folders := strings.Split(rel, "/")
+		folders = folders[:min(depth, len(folders)-1)]
+		stats.Prefixes[joinPathPrefix(prefix, strings.Join(folders, "/"))]++
+	}
+
+	tflog.Debug(ctx, "Counted secrets", map[string]interface{}{
+		"prefix":   prefix,
+		"total":    stats.Total,
+		"prefixes": len(stats.Prefixes),
+	})
+
+	return stats, nil
+}
+
+// lastSync returns when the upstream branch of the store was last updated by a fetch, pull
+// or push, from its reflog, or "" if the store does not sync or the time cannot be read.
+func (c *GopassClient) lastSync(ctx context.Context) string {
+	if !c.supportsSync(ctx) {
+		return ""
+	}
+	dir, err := c.storeDir()
+	if err != nil {
+		return ""
+	}
+
+	// Each reflog entry is printed as e.g. origin/main@{2026-10-16T12:00:00+02:00}
+	out, err := c.execCommand(ctx, dir, "git", "log", "--walk-reflogs", "-1", "--format=%gd", "--date=iso-strict", "@{upstream}", "--")
+	if err != nil {
+		tflog.Debug(ctx, "Could not read the last sync of the store", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return ""
+	}
+
+	entry := strings.TrimSpace(string(out))
+	_, at, found := strings.Cut(entry, "@{")
+	at = strings.TrimSuffix(at, "}")
+	if _, err := time.Parse(time.RFC3339, at); !found || err != nil {
+		return ""
+	}
+	return at
+}
@@ -0,0 +1,126 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGopassClient_GetStoreStats(t *testing.T) {
+	mountStores(t, "infra")
+	secrets := map[string]string{
+		"root":                  "x",
+		"teams/a/db":            "x",
+		"teams/a/api/key":       "x",
+		"teams/b/db":            "x",
+		"teams/readme":          "x",
+		"infra/dns/token":       "x",
+		"teamsters/unrelated/x": "x",
+	}
+
+	tests := []struct {
+		name      string
+		prefix    string
+		depth     int
+		wantTotal int
+		want      map[string]int
+	}{
+		{
+			name: "store", depth: 1, wantTotal: 7,
+			want: map[string]int{"": 1, "teams": 4, "infra": 1, "teamsters": 1},
+		},
+		{
+			name: "deeper", depth: 2, wantTotal: 7,
+			want: map[string]int{"": 1, "teams/a": 2, "teams/b": 1, "teams": 1, "infra/dns": 1, "teamsters/unrelated": 1},
+		},
+		{
+			name: "folder", prefix: "teams/", depth: 1, wantTotal: 4,
+			want: map[string]int{"teams/a": 2, "teams/b": 1, "teams": 1},
+		},
+		{name: "mount", prefix: "infra:dns", depth: 1, wantTotal: 1, want: map[string]int{"infra/dns": 1}},
+		{name: "empty folder", prefix: "none", depth: 1, want: map[string]int{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("/store")
+			client.store = storeWith(secrets)
+			client.caps = map[string]storeCapabilities{"": {}}
+
+			stats, err := client.GetStoreStats(context.Background(), tc.prefix, tc.depth)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if stats.Total != tc.wantTotal || !reflect.DeepEqual(stats.Prefixes, tc.want) {
+				t.Errorf("expected %d secrets by %v, got %d by %v", tc.wantTotal, tc.want, stats.Total, stats.Prefixes)
+			}
+			if stats.LastSync != "" {
+				t.Errorf("expected no last sync of a store that does not sync, got %q", stats.LastSync)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GetStoreStats_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	client := NewGopassClient("")
+	client.store = &mockStore{shouldFail: true, failMsg: "index unreadable"}
+	if _, err := client.GetStoreStats(ctx, "", 1); err == nil || !strings.Contains(err.Error(), "failed to list secrets") {
+		t.Errorf("expected a list error, got %v", err)
+	}
+
+	client = NewGopassClient("")
+	client.store = newMockStore()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := client.GetStoreStats(cancelled, "", 1); err == nil {
+		t.Error("expected an error when cancelled")
+	}
+}
+
+func TestGopassClient_LastSync(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		err       error
+		storePath string
+		want      string
+	}{
+		{name: "synced", output: "origin/main@{2026-10-16T12:00:00+02:00}\n", want: "2026-10-16T12:00:00+02:00"},
+		{name: "no upstream", err: errors.New("exit status 128: no upstream configured")},
+		{name: "no reflog", output: "\n"},
+		{name: "unexpected date", output: "origin/main@{3 days ago}\n"},
+		{name: "store dir error", storePath: "~/store"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storePath := tc.storePath
+			if storePath == "" {
+				storePath = "/store"
+			}
+			client := NewGopassClient(storePath)
+			client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+			client.caps = map[string]storeCapabilities{"": unknownCapabilities}
+			var gotDir string
+			var gotArgs []string
+			client.execCommand = fakeGit(tc.output, tc.err, &gotDir, &gotArgs)
+
+			if got := client.lastSync(context.Background()); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+			if tc.name == "synced" {
+				wantArgs := []string{"git", "log", "--walk-reflogs", "-1", "--format=%gd", "--date=iso-strict", "@{upstream}", "--"}
+				if gotDir != "/store" || !reflect.DeepEqual(gotArgs, wantArgs) {
+					t.Errorf("expected %v in /store, got %v in %q", wantArgs, gotArgs, gotDir)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Storage backends of a password store, named as in the gopass configuration.
+const (
+	storageGitFS    = "gitfs"
+	storageFossilFS = "fossilfs"
+	storageFS       = "fs"
+)
+
+// Crypto backends of a password store, named as in the gopass configuration.
+const (
+	cryptoGPG   = "gpg"
+	cryptoAge   = "age"
+	cryptoPlain = "plain"
+)
+
+// storageMarkers map the entries marking a versioned store root to its storage backend, in lookup order.
+var storageMarkers = []struct{ name, backend string }{
+	{".git", storageGitFS},
+	{".fslckout", storageFossilFS},
+}
+
+// cryptoMarkers map the recipients files in a store root to its crypto backend, in lookup order.
+// .age-ids is the name older gopass versions used for age recipients.
+var cryptoMarkers = []struct{ name, backend string }{
+	{gpgIDFile, cryptoGPG},
+	{ageRecipientsFile, cryptoAge},
+	{".age-ids", cryptoAge},
+	{".plain-id", cryptoPlain},
+}
+
+// StoreInfo describes the backends of the password store.
+type StoreInfo struct {
+	Root    string // directory of the store on disk
+	Storage string // storage backend: gitfs, fossilfs or fs
+	Crypto  string // crypto backend: gpg, age or plain; empty if the root has no recipients file
+}
+
+// Versioned reports whether the storage backend keeps a history of secrets.
+func (i *StoreInfo) Versioned() bool {
+	return i.Storage != storageFS
+}
+
+// GetStoreInfo determines the backends of the password store from the files in its root.
+// The gopass library does not report its backends, and nothing is decrypted to find them,
+// so this works without access to the keys of the store.
+func (c *GopassClient) GetStoreInfo(ctx context.Context) (*StoreInfo, error) {
+	root, err := c.storeDir()
+	if err != nil {
+		return nil, err
+	}
+	return c.storeInfoAt(ctx, root)
+}
+
+// storeInfoAt determines the backends of the password store in root, like GetStoreInfo.
+func (c *GopassClient) storeInfoAt(ctx context.Context, root string) (*StoreInfo, error) {
+	root, err := c.expandPath(root)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.Debug(ctx, "Reading store info", map[string]interface{}{
+		"root": root,
+	})
+
+	if _, err := os.Stat(root); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, withCode(CodeStoreNotFound, fmt.Errorf("gopass store not found at %s", root))
+		}
+		return nil, fmt.Errorf("failed to access store %q: %w", root, err)
+	}
+
+	info := &StoreInfo{Root: root, Storage: storageFS}
+	for _, marker := range storageMarkers {
+		if _, err := os.Stat(filepath.Join(root, marker.name)); err == nil {
+			info.Storage = marker.backend
+			break
+		}
+	}
+	for _, marker := range cryptoMarkers {
+		if _, err := os.Stat(filepath.Join(root, marker.name)); err == nil {
+			info.Crypto = marker.backend
+			break
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGopassClient_GetStoreInfo(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         []string
+		dirs          []string
+		wantStorage   string
+		wantCrypto    string
+		wantVersioned bool
+	}{
+		{name: "git and gpg", dirs: []string{".git"}, files: []string{".gpg-id"}, wantStorage: "gitfs", wantCrypto: "gpg", wantVersioned: true},
+		{name: "fossil and age", files: []string{".fslckout", ".age-recipients"}, wantStorage: "fossilfs", wantCrypto: "age", wantVersioned: true},
+		{name: "old age recipients", files: []string{".age-ids"}, wantStorage: "fs", wantCrypto: "age"},
+		{name: "plain", files: []string{".plain-id"}, wantStorage: "fs", wantCrypto: "plain"},
+		{name: "empty", wantStorage: "fs"},
+		{name: "nested recipients ignored", files: []string{"prod/.gpg-id"}, wantStorage: "fs"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tc.files {
+				writeStoreFile(t, dir, name, "id\n")
+			}
+			for _, name := range tc.dirs {
+				if err := os.Mkdir(filepath.Join(dir, name), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			info, err := NewGopassClient(dir).GetStoreInfo(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.Root != dir || info.Storage != tc.wantStorage || info.Crypto != tc.wantCrypto {
+				t.Errorf("expected %s/%s/%s, got %+v", dir, tc.wantStorage, tc.wantCrypto, info)
+			}
+			if info.Versioned() != tc.wantVersioned {
+				t.Errorf("expected versioned=%v", tc.wantVersioned)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GetStoreInfo_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeStoreFile(t, dir, "file", "")
+
+	t.Run("missing store", func(t *testing.T) {
+		_, err := NewGopassClient(filepath.Join(dir, "missing")).GetStoreInfo(context.Background())
+		if err == nil || ErrorCode(err) != CodeStoreNotFound {
+			t.Errorf("expected store not found, got %v", err)
+		}
+	})
+
+	t.Run("inaccessible store", func(t *testing.T) {
+		_, err := NewGopassClient(filepath.Join(dir, "file", "store")).GetStoreInfo(context.Background())
+		if err == nil || ErrorCode(err) == CodeStoreNotFound {
+			t.Errorf("expected access error, got %v", err)
+		}
+	})
+
+	t.Run("no home directory", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "")
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+		if _, err := client.GetStoreInfo(context.Background()); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
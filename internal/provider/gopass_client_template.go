@@ -0,0 +1,134 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// templateFile is the file holding the template for new secrets in a store folder.
+// gopass uses the template closest to a new secret, walking up towards the store root.
+const templateFile = ".pass-template"
+
+// templatePath returns the store-relative path of the template file of dir.
+// An empty dir refers to the store root.
+func templatePath(dir string) string {
+	return filepath.ToSlash(filepath.Join(dir, templateFile))
+}
+
+// GetTemplate returns the template of the store folder dir and whether it exists. The template
+// of a folder in a mount is read from the store mounted there. Templates are not encrypted,
+// so reading them never needs a hardware token.
+func (c *GopassClient) GetTemplate(ctx context.Context, dir string) (string, bool, error) {
+	root, dir, err := c.resolve(ctx, dir)
+	if err != nil {
+		return "", false, err
+	}
+
+	rel := templatePath(dir)
+	tflog.Debug(ctx, "Reading template", map[string]interface{}{
+		"path": rel,
+	})
+
+	data, err := os.ReadFile(filepath.Join(root, rel))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read template %q: %w", rel, err)
+	}
+	return string(data), true, nil
+}
+
+// SetTemplate writes content as the template of the store folder dir, like
+// `gopass templates edit`, and commits it if the store is a git repository.
+func (c *GopassClient) SetTemplate(ctx context.Context, dir, content string) error {
+	root, dir, err := c.resolve(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	rel := templatePath(dir)
+	tflog.Debug(ctx, "Writing template", map[string]interface{}{
+		"path": rel,
+	})
+
+	unlock, err := c.lockRoot(ctx, root)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return fmt.Errorf("failed to create folder for template %q: %w", rel, err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("failed to write template %q: %w", rel, err)
+	}
+
+	return c.commitStoreFile(ctx, root, rel, "Save template "+rel)
+}
+
+// RemoveTemplate removes the template of the store folder dir, like
+// `gopass templates remove`. A missing template is not an error.
+func (c *GopassClient) RemoveTemplate(ctx context.Context, dir string) error {
+	root, dir, err := c.resolve(ctx, dir)
+	if err != nil {
+		return err
+	}
+
+	rel := templatePath(dir)
+	tflog.Debug(ctx, "Removing template", map[string]interface{}{
+		"path": rel,
+	})
+
+	unlock, err := c.lockRoot(ctx, root)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(filepath.Join(root, rel)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to remove template %q: %w", rel, err)
+	}
+
+	return c.commitStoreFile(ctx, root, rel, "Remove template "+rel)
+}
+
+// commitStoreFile commits the current state of the store-relative file rel, if the
+// store at root is a git repository and the file changed. Other changes are left alone.
+func (c *GopassClient) commitStoreFile(ctx context.Context, root, rel, message string) error {
+	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+		return nil
+	}
+
+	status, err := c.execCommand(ctx, root, "git", "status", "--porcelain", "--", rel)
+	if err != nil {
+		return fmt.Errorf("failed to check git status of %q: %w", rel, err)
+	}
+	if len(bytes.TrimSpace(status)) == 0 {
+		// Unchanged, e.g. the same content was written again
+		return nil
+	}
+
+	if _, err := c.execCommand(ctx, root, "git", "add", "--all", "--", rel); err != nil {
+		return fmt.Errorf("failed to stage %q: %w", rel, err)
+	}
+	if _, err := c.execCommand(ctx, root, "git", "commit", "--message", message, "--", rel); err != nil {
+		return fmt.Errorf("failed to commit %q: %w", rel, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeStoreGit records git calls and reports status as the output of `git status`.
+// The command whose arguments start with failOn fails.
+func fakeStoreGit(calls *[]string, status, failOn string) func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	return func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		call := strings.Join(append([]string{name}, args...), " ")
+		*calls = append(*calls, call)
+		if failOn != "" && strings.HasPrefix(call, failOn) {
+			return nil, errors.New("git failed")
+		}
+		if strings.HasPrefix(call, "git status") {
+			return []byte(status), nil
+		}
+		return nil, nil
+	}
+}
+
+func TestGopassClient_Template_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+
+	for _, folder := range []string{"", "databases/prod"} {
+		if _, found, err := client.GetTemplate(ctx, folder); err != nil || found {
+			t.Fatalf("GetTemplate(%q) before create: expected (false, nil), got (%v, %v)", folder, found, err)
+		}
+
+		if err := client.SetTemplate(ctx, folder, "{{ .Content }}\nuser:\n"); err != nil {
+			t.Fatalf("SetTemplate(%q): unexpected error: %v", folder, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, folder, ".pass-template"))
+		if err != nil || string(data) != "{{ .Content }}\nuser:\n" {
+			t.Fatalf("SetTemplate(%q): expected template file, got %q (%v)", folder, data, err)
+		}
+
+		content, found, err := client.GetTemplate(ctx, folder)
+		if err != nil || !found || content != "{{ .Content }}\nuser:\n" {
+			t.Errorf("GetTemplate(%q): unexpected result (%q, %v, %v)", folder, content, found, err)
+		}
+
+		if err := client.RemoveTemplate(ctx, folder); err != nil {
+			t.Fatalf("RemoveTemplate(%q): unexpected error: %v", folder, err)
+		}
+		if err := client.RemoveTemplate(ctx, folder); err != nil {
+			t.Errorf("RemoveTemplate(%q) of missing template: unexpected error: %v", folder, err)
+		}
+	}
+}
+
+func TestGopassClient_Template_Git(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		failOn    string
+		wantErr   string
+		wantCalls int
+	}{
+		{name: "changed", status: "?? databases/.pass-template\n", wantCalls: 3},
+		{name: "unchanged", status: "", wantCalls: 1},
+		{name: "status fails", failOn: "git status", wantErr: "failed to check git status", wantCalls: 1},
+		{name: "add fails", status: " M databases/.pass-template\n", failOn: "git add", wantErr: "failed to stage", wantCalls: 2},
+		{name: "commit fails", status: " M databases/.pass-template\n", failOn: "git commit", wantErr: "failed to commit", wantCalls: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, ".git"), 0o700); err != nil {
+				t.Fatal(err)
+			}
+			client := NewGopassClient(dir)
+			var calls []string
+			client.execCommand = fakeStoreGit(&calls, tc.status, tc.failOn)
+
+			err := client.SetTemplate(context.Background(), "databases", "user:\n")
+
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if len(calls) != tc.wantCalls {
+				t.Errorf("expected %d git calls, got %v", tc.wantCalls, calls)
+			}
+		})
+	}
+
+	t.Run("remove commits", func(t *testing.T) {
+		dir := t.TempDir()
+		writeStoreFile(t, dir, "databases/.pass-template", "user:\n")
+		if err := os.Mkdir(filepath.Join(dir, ".git"), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		client := NewGopassClient(dir)
+		var calls []string
+		client.execCommand = fakeStoreGit(&calls, " D databases/.pass-template\n", "")
+
+		if err := client.RemoveTemplate(context.Background(), "databases"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := "git commit --message Remove template databases/.pass-template -- databases/.pass-template"
+		if len(calls) != 3 || calls[2] != want {
+			t.Errorf("expected commit %q, got %v", want, calls)
+		}
+	})
+}
+
+func TestGopassClient_Template_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("store dir unknown", func(t *testing.T) {
+		t.Setenv("PASSWORD_STORE_DIR", "")
+		client := NewGopassClient("")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+
+		if _, _, err := client.GetTemplate(ctx, "a"); err == nil {
+			t.Error("GetTemplate: expected error")
+		}
+		if err := client.SetTemplate(ctx, "a", "x"); err == nil {
+			t.Error("SetTemplate: expected error")
+		}
+		if err := client.RemoveTemplate(ctx, "a"); err == nil {
+			t.Error("RemoveTemplate: expected error")
+		}
+	})
+
+	t.Run("store is a file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "store")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		client := NewGopassClient(file)
+
+		if _, _, err := client.GetTemplate(ctx, "a"); err == nil || !strings.Contains(err.Error(), "failed to read template") {
+			t.Errorf("GetTemplate: expected read error, got %v", err)
+		}
+		if err := client.SetTemplate(ctx, "a", "x"); err == nil || !strings.Contains(err.Error(), "failed to create folder") {
+			t.Errorf("SetTemplate: expected folder error, got %v", err)
+		}
+		if err := client.RemoveTemplate(ctx, "a"); err == nil || !strings.Contains(err.Error(), "failed to remove template") {
+			t.Errorf("RemoveTemplate: expected remove error, got %v", err)
+		}
+	})
+
+	t.Run("template is a directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeStoreFile(t, dir, "a/.pass-template/file", "x")
+		client := NewGopassClient(dir)
+
+		if err := client.SetTemplate(ctx, "a", "x"); err == nil || !strings.Contains(err.Error(), "failed to write template") {
+			t.Errorf("SetTemplate: expected write error, got %v", err)
+		}
+	})
+}
@@ -7,11 +7,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/api"
 	"github.com/gopasspw/gopass/pkg/gopass/secrets"
 )
 
@@ -30,6 +36,13 @@ func newMockStore() *mockStore {
 	}
 }
 
+// clientWith returns a client that reads and writes store instead of a gopass store on disk.
+func clientWith(store gopass.Store) *GopassClient {
+	client := NewGopassClient("")
+	client.store = store
+	return client
+}
+
 func (m *mockStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
 	if m.shouldFail {
 		return nil, errors.New(m.failMsg)
@@ -479,31 +492,55 @@ func TestGopassClient_WrapStoreError(t *testing.T) {
 		name           string
 		inputError     error
 		expectedSubstr string
+		expectedCode   string
 	}{
 		{
 			name:           "file not found",
 			inputError:     errors.New("no such file or directory"),
 			expectedSubstr: "gopass store not found",
+			expectedCode:   CodeStoreNotFound,
 		},
 		{
 			name:           "does not exist",
 			inputError:     errors.New("does not exist"),
 			expectedSubstr: "gopass store not found",
+			expectedCode:   CodeStoreNotFound,
 		},
 		{
 			name:           "permission denied",
 			inputError:     errors.New("permission denied"),
 			expectedSubstr: "gopass store access denied",
+			expectedCode:   CodePermission,
 		},
 		{
 			name:           "gpg error",
 			inputError:     errors.New("gpg: error"),
 			expectedSubstr: "GPG error during gopass initialization",
+			expectedCode:   CodeGPGError,
+		},
+		{
+			name:           "pinentry unavailable",
+			inputError:     errors.New("gpg: decryption failed: No pinentry"),
+			expectedSubstr: "GPG could not ask for the passphrase",
+			expectedCode:   CodeGPGError,
+		},
+		{
+			name:           "not initialized",
+			inputError:     api.ErrNotInitialized,
+			expectedSubstr: "gopass store not initialized",
+			expectedCode:   CodeStoreNotInitialized,
+		},
+		{
+			name:           "mount not initialized",
+			inputError:     errors.New("password store work is not initialized"),
+			expectedSubstr: "auto_init",
+			expectedCode:   CodeStoreNotInitialized,
 		},
 		{
 			name:           "generic error",
 			inputError:     errors.New("some other error"),
 			expectedSubstr: "failed to initialize gopass store",
+			expectedCode:   CodeError,
 		},
 	}
 
@@ -513,80 +550,229 @@ func TestGopassClient_WrapStoreError(t *testing.T) {
 			if !strings.Contains(wrappedErr.Error(), tc.expectedSubstr) {
 				t.Errorf("expected error to contain %q, got %q", tc.expectedSubstr, wrappedErr.Error())
 			}
+			if code := ErrorCode(wrappedErr); code != tc.expectedCode {
+				t.Errorf("expected code %q, got %q", tc.expectedCode, code)
+			}
 		})
 	}
 }
 
 func TestGopassClient_EnsureStore_WithStorePath(t *testing.T) {
-	// Save and restore environment variable
-	originalEnv := os.Getenv("PASSWORD_STORE_DIR")
-	defer func() {
-		if originalEnv != "" {
-			os.Setenv("PASSWORD_STORE_DIR", originalEnv)
-		} else {
-			os.Unsetenv("PASSWORD_STORE_DIR")
-		}
-	}()
+	t.Setenv("PASSWORD_STORE_DIR", "/from/env")
 
 	// Create a temporary directory for testing
-	tempDir := os.TempDir()
+	tempDir := t.TempDir()
 
+	var gotDir string
 	client := NewGopassClient(tempDir)
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		gotDir = dir
+		return newMockStore(), nil
+	}
 
-	ctx := context.Background()
-
-	// This will fail because we don't have a real gopass store, but we can test the path expansion logic
-	err := client.ensureStore(ctx)
-	if err == nil {
-		t.Errorf("expected error due to missing gopass store")
+	if err := client.ensureStore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotDir != tempDir {
+		t.Errorf("expected store dir %q, got %q", tempDir, gotDir)
 	}
 
-	// Check that PASSWORD_STORE_DIR was set
-	envValue := os.Getenv("PASSWORD_STORE_DIR")
-	if envValue != tempDir {
-		t.Errorf("expected PASSWORD_STORE_DIR to be %q, got %q", tempDir, envValue)
+	// The process environment is left alone
+	if envValue := os.Getenv("PASSWORD_STORE_DIR"); envValue != "/from/env" {
+		t.Errorf("expected PASSWORD_STORE_DIR to stay %q, got %q", "/from/env", envValue)
 	}
 }
 
 func TestGopassClient_EnsureStore_HomeExpansion(t *testing.T) {
-	// Save and restore environment variable
-	originalEnv := os.Getenv("PASSWORD_STORE_DIR")
-	defer func() {
-		if originalEnv != "" {
-			os.Setenv("PASSWORD_STORE_DIR", originalEnv)
-		} else {
-			os.Unsetenv("PASSWORD_STORE_DIR")
-		}
-	}()
+	home := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, "store"), 0o700); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		t.Skipf("cannot get home directory: %v", err)
+	var gotDir string
+	client := NewGopassClient("~/store")
+	client.userHomeDir = func() (string, error) { return home, nil }
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		gotDir = dir
+		return newMockStore(), nil
+	}
+
+	if err := client.ensureStore(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expectedPath := filepath.Join(home, "store"); gotDir != expectedPath {
+		t.Errorf("expected store dir %q, got %q", expectedPath, gotDir)
 	}
+}
 
-	// Create a temporary directory that exists for testing path expansion
-	testDir := homeDir + "/test-path-temp"
-	err = os.MkdirAll(testDir, 0o755)
+func TestNewLibraryStore_RestoresEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		count string
+		set   bool
+	}{
+		{name: "set", count: "0", set: true},
+		{name: "unset"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GOPASS_CONFIG_COUNT", tc.count)
+			if !tc.set {
+				os.Unsetenv("GOPASS_CONFIG_COUNT")
+			}
+			t.Setenv("PASSWORD_STORE_DIR", "/from/env")
+
+			// There is no store in the directory, so this fails after reading the variables
+			if _, err := newLibraryStore(context.Background(), t.TempDir(), nil); err == nil {
+				t.Error("expected error for an uninitialized store")
+			}
+
+			got, set := os.LookupEnv("GOPASS_CONFIG_COUNT")
+			if set != tc.set || got != tc.count {
+				t.Errorf("expected GOPASS_CONFIG_COUNT to be restored to %q (set=%v), got %q (set=%v)", tc.count, tc.set, got, set)
+			}
+			if _, set := os.LookupEnv("GOPASS_CONFIG_KEY_0"); set {
+				t.Error("expected GOPASS_CONFIG_KEY_0 to be unset again")
+			}
+			if got := os.Getenv("PASSWORD_STORE_DIR"); got != "/from/env" {
+				t.Errorf("expected PASSWORD_STORE_DIR to be restored, got %q", got)
+			}
+		})
+	}
+}
+
+func TestNewLibraryStore_OpensStoreOverUserConfig(t *testing.T) {
+	// The user's configuration and environment point at another store
+	home := t.TempDir()
+	t.Setenv("GOPASS_HOMEDIR", home)
+	writeStoreFile(t, home, ".config/gopass/config", "[mounts]\n\tpath = "+t.TempDir()+"\n")
+	t.Setenv("PASSWORD_STORE_DIR", t.TempDir())
+
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".gpg-id", "0xDEADBEEF\n")
+	writeStoreFile(t, dir, "app/db.gpg", "ciphertext")
+
+	store, err := newLibraryStore(context.Background(), dir, nil)
 	if err != nil {
-		t.Fatalf("failed to create test directory: %v", err)
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "app/db" {
+		t.Errorf("expected the secrets of %s, got %v", dir, names)
 	}
-	defer os.RemoveAll(testDir)
+}
 
-	client := NewGopassClient("~/test-path-temp")
+func TestStoreConfigEnv(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{
+			name: "no overrides",
+			want: []string{"PASSWORD_STORE_DIR", "GOPASS_CONFIG_KEY_0=mounts.path", "GOPASS_CONFIG_VALUE_0=/store", "GOPASS_CONFIG_COUNT=1"},
+		},
+		{
+			name: "existing overrides are kept behind",
+			env: map[string]string{
+				"GOPASS_CONFIG_COUNT":   "1",
+				"GOPASS_CONFIG_KEY_0":   "mounts.path",
+				"GOPASS_CONFIG_VALUE_0": "/other",
+			},
+			want: []string{
+				"PASSWORD_STORE_DIR", "GOPASS_CONFIG_KEY_0=mounts.path", "GOPASS_CONFIG_VALUE_0=/store", "GOPASS_CONFIG_COUNT=2",
+				"GOPASS_CONFIG_KEY_1=mounts.path", "GOPASS_CONFIG_VALUE_1=/other",
+			},
+		},
+		{
+			name: "malformed count",
+			env:  map[string]string{"GOPASS_CONFIG_COUNT": "many"},
+			want: []string{"PASSWORD_STORE_DIR", "GOPASS_CONFIG_KEY_0=mounts.path", "GOPASS_CONFIG_VALUE_0=/store", "GOPASS_CONFIG_COUNT=1"},
+		},
+		{
+			name: "malformed override",
+			env:  map[string]string{"GOPASS_CONFIG_COUNT": "1", "GOPASS_CONFIG_KEY_0": "core.autosync"},
+			want: []string{"PASSWORD_STORE_DIR", "GOPASS_CONFIG_KEY_0=mounts.path", "GOPASS_CONFIG_VALUE_0=/store", "GOPASS_CONFIG_COUNT=1"},
+		},
+	}
 
-	ctx := context.Background()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GOPASS_CONFIG_COUNT", "")
+			os.Unsetenv("GOPASS_CONFIG_COUNT")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
 
-	// This will still fail because we don't have a real gopass store,
-	// but it should set the environment variable first
-	err = client.ensureStore(ctx)
-	if err == nil {
-		t.Error("expected error due to missing gopass store")
+			if got := storeConfigEnv("/store"); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("storeConfigEnv() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewLibraryStore_Concurrent(t *testing.T) {
+	t.Setenv("PASSWORD_STORE_DIR", "/from/env")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = newLibraryStore(context.Background(), t.TempDir(), nil)
+		}()
+		go func() {
+			defer wg.Done()
+			if dir, err := NewGopassClient("").storeDir(); err != nil || dir != "/from/env" {
+				t.Errorf("storeDir() = %q, %v; want the store of PASSWORD_STORE_DIR", dir, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, set := os.LookupEnv("GOPASS_CONFIG_COUNT"); set {
+		t.Error("expected GOPASS_CONFIG_COUNT to be unset again")
+	}
+}
+
+func TestProcessEnv_WaitsForLibraryStore(t *testing.T) {
+	ctx := context.Background()
+	commands := map[string]func() ([]byte, error){
+		"runCommand": func() ([]byte, error) {
+			return runCommand(ctx, "", "sh", "-c", "echo $PASSWORD_STORE_DIR")
+		},
+		"runCommandWithInput": func() ([]byte, error) {
+			return runCommandWithInput(ctx, nil, nil, "sh", "-c", "echo $PASSWORD_STORE_DIR")
+		},
 	}
 
-	expectedPath := homeDir + "/test-path-temp"
-	envValue := os.Getenv("PASSWORD_STORE_DIR")
-	if envValue != expectedPath {
-		t.Errorf("expected PASSWORD_STORE_DIR to be %q, got %q", expectedPath, envValue)
+	for name, command := range commands {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("PASSWORD_STORE_DIR", "/from/env")
+
+			// Like newLibraryStore, change the environment for another store while holding the lock
+			storeDirEnvMu.Lock()
+			os.Setenv("PASSWORD_STORE_DIR", "/other/store")
+			done := make(chan string)
+			go func() {
+				out, err := command()
+				if err != nil {
+					t.Errorf("%s() error: %v", name, err)
+				}
+				done <- strings.TrimSpace(string(out))
+			}()
+			time.Sleep(50 * time.Millisecond)
+			os.Setenv("PASSWORD_STORE_DIR", "/from/env")
+			storeDirEnvMu.Unlock()
+
+			if got := <-done; got != "/from/env" {
+				t.Errorf("expected the process to get PASSWORD_STORE_DIR of the provider, got %q", got)
+			}
+		})
 	}
 }
 
@@ -655,7 +841,7 @@ func TestGopassClient_EnsureStore_SuccessfulInit(t *testing.T) {
 
 	// Inject a mock apiNew that returns a simple mock store
 	injectedMockStore := newMockStore()
-	client.apiNew = func(ctx context.Context) (gopass.Store, error) {
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
 		return injectedMockStore, nil
 	}
 
@@ -752,6 +938,47 @@ func TestGopassClient_GetEnvSecrets_PartialFailure(t *testing.T) {
 	}
 }
 
+func TestGopassClient_ReadEnvSecretsAt_Failures(t *testing.T) {
+	client := NewGopassClient("")
+	mockStore := newMockStoreWithSelectiveFailure()
+	client.store = mockStore
+	mockStore.secrets["env/test/KEY1"] = newMockSecret("value1")
+	mockStore.secrets["env/test/db/PASSWORD"] = newMockSecret("value2")
+	mockStore.secrets["env/test/KEY3"] = newMockSecret("value3")
+	mockStore.failOnGet["env/test/db/PASSWORD"] = true
+	mockStore.failOnGet["env/test/KEY3"] = true
+
+	values, failures, err := client.ReadEnvSecretsAt(context.Background(), "env/test/", "", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(values) != 1 || values["KEY1"] != "value1" {
+		t.Errorf("expected only KEY1, got %v", values)
+	}
+
+	want := []EnvReadError{
+		{Path: "env/test/KEY3", Key: "KEY3"},
+		{Path: "env/test/db/PASSWORD", Key: "db/PASSWORD"},
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("expected %d failures, got %v", len(want), failures)
+	}
+	for i, f := range failures {
+		if f.Path != want[i].Path || f.Key != want[i].Key || !strings.Contains(f.Err.Error(), "selective failure") {
+			t.Errorf("failure %d = %+v, want %s", i, f, want[i].Path)
+		}
+	}
+}
+
+func TestGopassClient_ReadEnvSecretsAt_ListError(t *testing.T) {
+	client := NewGopassClient("")
+	failingStore("store locked")(client)
+
+	if _, _, err := client.ReadEnvSecretsAt(context.Background(), "env/test", "", 0); err == nil {
+		t.Error("expected list error")
+	}
+}
+
 func TestGopassClient_SecretExists_OtherError(t *testing.T) {
 	client := NewGopassClient("")
 	mockStore := newMockStore()
@@ -922,3 +1149,172 @@ func TestGopassClient_GetRevisionCount_EnsureStoreError(t *testing.T) {
 		t.Error("expected error but got none")
 	}
 }
+
+func TestGopassClient_GetSecretFieldAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		key     string
+		want    string
+		wantErr string
+	}{
+		{name: "password", path: "websites/app", want: "hunter2"},
+		{name: "field", path: "websites/app", key: "username", want: "admin"},
+		{name: "missing field", path: "websites/app", key: "url", wantErr: `secret "websites/app" has no key "url"`},
+		{name: "missing secret", path: "websites/other", key: "username", wantErr: "failed to get secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			secret := newMockSecret("hunter2")
+			secret.fields["username"] = "admin"
+			store.secrets["websites/app"] = secret
+			client := NewGopassClient("")
+			client.store = store
+
+			got, err := client.GetSecretFieldAt(context.Background(), tc.path, tc.key, "")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGopassClient_CheckWritable(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       string
+		setup     func(t *testing.T, root string)
+		failWrite bool
+		failClean bool
+		wantErr   string
+	}{
+		{name: "existing folder", dir: "ci", setup: func(t *testing.T, root string) { writeStoreFile(t, root, "ci/token.gpg", "") }},
+		{name: "missing folder", dir: "ci/preflight"},
+		{name: "git store", dir: "ci", setup: func(t *testing.T, root string) { writeStoreFile(t, root, ".git/HEAD", "ref: refs/heads/main\n") }},
+		{name: "git worktree", dir: "ci", setup: func(t *testing.T, root string) { writeStoreFile(t, root, ".git", "gitdir: /elsewhere\n") }},
+		{name: "folder is a file", dir: "ci/preflight", setup: func(t *testing.T, root string) { writeStoreFile(t, root, "ci", "") }, wantErr: "is not a folder"},
+		{name: "store missing", dir: "ci", setup: func(t *testing.T, root string) { _ = os.Remove(root) }, wantErr: "failed to access store folder"},
+		{name: "not writable", dir: "ci", failWrite: true, wantErr: "is not writable: read-only file system"},
+		{name: "not removable", dir: "ci", failClean: true, wantErr: "failed to remove test file"},
+		{
+			name: "git index locked",
+			dir:  "ci",
+			setup: func(t *testing.T, root string) {
+				writeStoreFile(t, root, ".git/index.lock", "")
+			},
+			wantErr: "the git repository of the store is locked",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			if tc.setup != nil {
+				tc.setup(t, root)
+			}
+			client := NewGopassClient(root)
+			if tc.failWrite {
+				client.writeFile = func(name string, data []byte, perm os.FileMode) error { return errors.New("read-only file system") }
+			}
+			if tc.failClean {
+				client.removeAll = func(path string) error { return errors.New("device busy") }
+			}
+
+			err := client.CheckWritable(context.Background(), tc.dir)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// Nothing is left behind, and no folder was created for the check
+			var files []string
+			_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if strings.Contains(path, ".terraform-preflight-") {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if len(files) != 0 {
+				t.Errorf("expected the test files to be removed, got %v", files)
+			}
+			if _, err := os.Stat(filepath.Join(root, "ci", "preflight")); err == nil {
+				t.Error("expected the missing folder not to be created")
+			}
+		})
+	}
+}
+
+func TestGopassClient_CheckWritable_Mount(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GOPASS_HOMEDIR", t.TempDir())
+	work := t.TempDir()
+	writeStoreFile(t, work, "ci", "")
+	if err := NewGopassClient("").SetConfig(ctx, configScopeGlobal, "mounts.work.path", work); err != nil {
+		t.Fatalf("SetConfig() error: %v", err)
+	}
+
+	// The folder is checked in the mount, where it is a file
+	err := NewGopassClient(t.TempDir()).CheckWritable(ctx, "work:ci/preflight")
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(work, "ci")+" is not a folder") {
+		t.Errorf("expected the mount to be checked, got %v", err)
+	}
+}
+
+func TestGopassClient_CheckWritable_ConfigError(t *testing.T) {
+	unreadableConfig(t)
+
+	// Whether ci is a mount cannot be told, so the folder is not checked anywhere
+	err := NewGopassClient(t.TempDir()).CheckWritable(context.Background(), "ci/preflight")
+	if err == nil || !strings.Contains(err.Error(), gopassConfigFile) {
+		t.Errorf("expected the config error, got %v", err)
+	}
+}
+
+func TestGopassClient_CheckStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		storePath string
+		probe     string
+		wantErr   string
+	}{
+		{name: "store only"},
+		{name: "probe decrypts", probe: "test/secret"},
+		{name: "probe fails", probe: "test/missing", wantErr: `test decryption of "test/missing" failed`},
+		{name: "store fails", storePath: "/nonexistent/path/for/test", wantErr: "gopass store not found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient(tc.storePath)
+			if tc.storePath == "" {
+				client.store = storeWith(map[string]string{"test/secret": "s3cret"})
+			}
+
+			err := client.CheckStore(context.Background(), tc.probe)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// TreeNode is a secret or folder below a listed prefix.
+type TreeNode struct {
+	// Path is the full path of the node.
+	Path string
+	// Depth is the number of path components below the prefix, 1 for immediate children.
+	Depth int
+	// HasChildren is true for folders, i.e. nodes with secrets below them.
+	HasChildren bool
+	// IsSecret is true if a secret is stored at Path. A node can be both a secret and a folder.
+	IsSecret bool
+}
+
+// ListTree returns the secrets and folders below prefix at any depth, in lexical order of
+// their paths. Only secrets with parts listed next to them are decrypted, to leave out the
+// parts of chunked ones.
+func (c *GopassClient) ListTree(ctx context.Context, prefix string) ([]TreeNode, error) {
+	prefix = c.resolveMountPath(ctx, prefix)
+	secretPaths, err := c.ListSecretsRecursive(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return buildTree(strings.TrimSuffix(prefix, "/"), secretPaths), nil
+}
+
+// buildTree returns the nodes formed by secretPaths below prefix, adding a folder node for
+// every intermediate path.
+func buildTree(prefix string, secretPaths []string) []TreeNode {
+	nodes := make(map[string]*TreeNode)
+	node := func(p string) *TreeNode {
+		n, ok := nodes[p]
+		if !ok {
+			rel := strings.TrimPrefix(p, prefix+"/")
+			n = &TreeNode{Path: p, Depth: strings.Count(rel, "/") + 1}
+			nodes[p] = n
+		}
+		return n
+	}
+
+	for _, secretPath := range secretPaths {
+		node(secretPath).IsSecret = true
+
+		// Every ancestor below prefix is a folder
+		for p := secretPath; ; {
+			i := strings.LastIndex(p, "/")
+			p = p[:i]
+			if p == prefix {
+				break
+			}
+			node(p).HasChildren = true
+		}
+	}
+
+	result := make([]TreeNode, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, *n)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// ReadSecretTree returns the password, body and fields of every secret below prefix, keyed
+// by path relative to prefix, and the secrets that could not be read, in lexical order.
+// maxDepth limits the depth as for ReadEnvSecretsAt. Unreadable secrets do not fail the
+// read; an error is only returned if prefix cannot be listed or ctx is canceled.
+func (c *GopassClient) ReadSecretTree(ctx context.Context, prefix string, maxDepth int) (map[string]*SecretContent, []EnvReadError, error) {
+	prefix = c.resolveMountPath(ctx, prefix)
+	secretPaths, err := c.ListSecretsRecursive(ctx, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	result := make(map[string]*SecretContent)
+	var failures []EnvReadError
+
+	for _, fullPath := range secretPaths {
+		key := strings.TrimPrefix(fullPath, prefix+"/")
+		if !withinDepth(key, maxDepth) {
+			continue
+		}
+
+		content, err := c.GetSecretFull(ctx, fullPath, "")
+		if err != nil {
+			// Once canceled, every remaining secret would fail the same way
+			if ctx.Err() != nil {
+				return nil, nil, err
+			}
+			tflog.Warn(ctx, "Failed to read secret, skipping", map[string]interface{}{
+				"path":  fullPath,
+				"error": err.Error(),
+			})
+			failures = append(failures, EnvReadError{Path: fullPath, Key: key, Err: err})
+			continue
+		}
+
+		result[key] = content
+	}
+
+	return result, failures, nil
+}
@@ -0,0 +1,172 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+func TestBuildTree(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		paths  []string
+		want   []TreeNode
+	}{
+		{name: "empty", prefix: "app", want: []TreeNode{}},
+		{
+			name:   "flat",
+			prefix: "app",
+			paths:  []string{"app/a", "app/b"},
+			want: []TreeNode{
+				{Path: "app/a", Depth: 1, IsSecret: true},
+				{Path: "app/b", Depth: 1, IsSecret: true},
+			},
+		},
+		{
+			name:   "nested",
+			prefix: "app",
+			paths:  []string{"app/db/password", "app/db/replica/password", "app/token"},
+			want: []TreeNode{
+				{Path: "app/db", Depth: 1, HasChildren: true},
+				{Path: "app/db/password", Depth: 2, IsSecret: true},
+				{Path: "app/db/replica", Depth: 2, HasChildren: true},
+				{Path: "app/db/replica/password", Depth: 3, IsSecret: true},
+				{Path: "app/token", Depth: 1, IsSecret: true},
+			},
+		},
+		{
+			name:   "secret and folder",
+			prefix: "app",
+			paths:  []string{"app/db", "app/db/user"},
+			want: []TreeNode{
+				{Path: "app/db", Depth: 1, HasChildren: true, IsSecret: true},
+				{Path: "app/db/user", Depth: 2, IsSecret: true},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := buildTree(tc.prefix, tc.paths); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("buildTree() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGopassClient_ListTree(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"app/db/password": "1", "app/token": "2", "other/x": "3"})
+
+	got, err := client.ListTree(context.Background(), "app/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TreeNode{
+		{Path: "app/db", Depth: 1, HasChildren: true},
+		{Path: "app/db/password", Depth: 2, IsSecret: true},
+		{Path: "app/token", Depth: 1, IsSecret: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListTree() = %+v, want %+v", got, want)
+	}
+
+	failingStore("store locked")(client)
+	if _, err := client.ListTree(context.Background(), "app"); err == nil {
+		t.Error("expected error when listing fails")
+	}
+}
+
+func TestGopassClient_ReadSecretTree(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxDepth     int
+		wantKeys     []string
+		wantFailures []string
+	}{
+		{name: "whole tree", wantKeys: []string{"db/password", "token"}, wantFailures: []string{"broken"}},
+		{name: "max depth", maxDepth: 1, wantKeys: []string{"token"}, wantFailures: []string{"broken"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStoreWithSelectiveFailure()
+			store.secrets["app/db/password"] = newMockSecret("s3cret")
+			store.secrets["app/token"] = newMockSecret("t0ken")
+			store.secrets["app/broken"] = newMockSecret("x")
+			store.failOnGet["app/broken"] = true
+			client := NewGopassClient("")
+			client.store = store
+
+			contents, failures, err := client.ReadSecretTree(context.Background(), "app/", tc.maxDepth)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var keys []string
+			for key := range contents {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tc.wantKeys, keys)
+			}
+			var failed []string
+			for _, f := range failures {
+				failed = append(failed, f.Key)
+			}
+			if !reflect.DeepEqual(failed, tc.wantFailures) {
+				t.Errorf("expected failures %v, got %v", tc.wantFailures, failed)
+			}
+			if got := contents["token"]; got.Password != "t0ken" {
+				t.Errorf("expected password of token, got %q", got.Password)
+			}
+		})
+	}
+
+	t.Run("fields and body", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = newMockStore()
+		client.store.(*mockStore).secrets["app/db"] = secrets.ParseAKV([]byte("s3cret\nuser: admin\nsome notes\n"))
+
+		contents, _, err := client.ReadSecretTree(context.Background(), "app", 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := contents["db"]
+		if got.Password != "s3cret" || got.Fields["user"] != "admin" || got.Body != "some notes\n" {
+			t.Errorf("unexpected content %+v", got)
+		}
+	})
+
+	t.Run("list error", func(t *testing.T) {
+		client := NewGopassClient("")
+		failingStore("store locked")(client)
+		if _, _, err := client.ReadSecretTree(context.Background(), "app", 0); err == nil {
+			t.Error("expected error when listing fails")
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		store := &cancelingStore{mockStore: storeWith(map[string]string{"app/A": "a", "app/B": "b"}), cancel: cancel}
+		client := NewGopassClient("")
+		client.store = store
+
+		if _, _, err := client.ReadSecretTree(ctx, "app", 0); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the read to be aborted, got %v", err)
+		}
+		if store.gets != 1 {
+			t.Errorf("expected no secret to be read after the cancellation, got %d reads", store.gets)
+		}
+	})
+}
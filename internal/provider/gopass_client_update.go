@@ -0,0 +1,207 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// SecretUpdate is a set of changes to a secret that UpdateSecret writes at once, so readers
+// never see some of them only. At most one of Value, Content and Parts is set; without any
+// of them, the fields of the existing secret are changed, or of a new one with an empty
+// password.
+type SecretUpdate struct {
+	// Value replaces the password, and the rest of the secret unless KeepFields is set.
+	Value *string
+	// KeepFields keeps the fields and body of the existing secret when Value is written.
+	KeepFields bool
+	// ChunkSize splits a Value longer than this many bytes into parts, like SetSecretChunked,
+	// if positive. Parts left over from a previous, longer value are removed.
+	ChunkSize int
+	// Content replaces the whole secret, like SetSecretContent.
+	Content []byte
+	// Parts replace the whole secret, like SetSecretParts.
+	Parts *SecretParts
+	// Unchunk joins the parts of a chunked secret into its password like UnchunkSecret, unless
+	// Value, Content or Parts replace it, and removes the parts not written by this update.
+	Unchunk bool
+	// Fields are set in the secret, after the changes above.
+	Fields map[string]string
+	// Remove are the fields removed from the secret. Fields it does not have are ignored.
+	Remove []string
+}
+
+// replaces reports whether u replaces the password of the secret.
+func (u *SecretUpdate) replaces() bool {
+	return u.Value != nil || u.Content != nil || u.Parts != nil
+}
+
+// empty reports whether u changes nothing.
+func (u *SecretUpdate) empty() bool {
+	return !u.replaces() && !u.Unchunk && len(u.Fields) == 0 && len(u.Remove) == 0
+}
+
+// UpdateSecret applies update to the secret at path in a single write. The password policy
+// applies to a new password before anything is written. Only the parts of a chunked value
+// are written separately, before the secret recording them. Nothing is written if nothing
+// changes.
+func (c *GopassClient) UpdateSecret(ctx context.Context, path string, update SecretUpdate) error {
+	path = c.resolveMountPath(ctx, path)
+	if update.empty() {
+		return nil
+	}
+
+	var replacement gopass.Secret
+	switch {
+	case update.Parts != nil:
+		composed, err := composeSecret(*update.Parts)
+		if err != nil {
+			return fmt.Errorf("invalid secret parts for %q: %w", path, err)
+		}
+		replacement = composed
+	case update.Content != nil:
+		replacement = secrets.ParseAKV(update.Content)
+	case update.Value != nil && !update.KeepFields:
+		replacement = secrets.New()
+		replacement.SetPassword(*update.Value)
+	}
+	if update.replaces() {
+		var password string
+		if replacement != nil {
+			password = replacement.Password()
+		} else {
+			password = *update.Value
+		}
+		if err := c.checkPolicy(ctx, path, password); err != nil {
+			return err
+		}
+	}
+	if err := c.ensureStore(ctx); err != nil {
+		return c.notifyError(ctx, opSet, path, err)
+	}
+
+	tflog.Debug(ctx, "Updating secret", map[string]interface{}{
+		"path":     path,
+		"replaced": update.replaces(),
+		"keys":     sortedKeys(update.Fields),
+		"removed":  update.Remove,
+	})
+
+	secret, parts, changed, err := c.updateBase(ctx, path, &update, replacement)
+	if err != nil {
+		return err
+	}
+	fieldsChanged, err := applyFields(secret, update.Fields, update.Remove)
+	if err != nil {
+		return c.notifyError(ctx, opSet, path, fmt.Errorf("failed to update secret %q: %w", path, err))
+	}
+
+	if changed || fieldsChanged {
+		if err := c.writeSecret(ctx, path, secret); err != nil {
+			return err
+		}
+	}
+	if (update.Value != nil && update.ChunkSize > 0) || update.Unchunk {
+		return c.RemoveSecretChunks(ctx, path, parts)
+	}
+	return nil
+}
+
+// updateBase returns the secret update changes the fields of: replacement, the manifest of a
+// chunked Value whose parts it writes first, or the existing secret, with a new password or
+// joined parts. It also returns the number of parts written and whether the secret changed.
+func (c *GopassClient) updateBase(ctx context.Context, path string, update *SecretUpdate, replacement gopass.Secret) (gopass.Secret, int, bool, error) {
+	if update.Value != nil && update.ChunkSize > 0 {
+		chunks := splitChunks(*update.Value, update.ChunkSize)
+		if len(chunks) > 1 {
+			tflog.Debug(ctx, "Writing chunked secret", map[string]interface{}{
+				"path":  path,
+				"parts": len(chunks),
+			})
+			for i, chunk := range chunks {
+				if err := c.setSecret(ctx, chunkPath(path, i+1), chunk); err != nil {
+					return nil, 0, false, err
+				}
+			}
+			// The secret at path is written last, so readers never find more parts than were written
+			manifest := secrets.ParseAKV([]byte(fmt.Sprintf("\n%s: %d\n", chunksField, len(chunks))))
+			return manifest, len(chunks), true, nil
+		}
+	}
+	if replacement != nil {
+		return replacement, 0, true, nil
+	}
+
+	secret, err := c.readSecret(ctx, path, "latest")
+	if err != nil && !c.isNotFound(err) {
+		return nil, 0, false, c.notifyError(ctx, opSet, path, fmt.Errorf("failed to read secret %q: %w", path, err))
+	}
+	if secret == nil {
+		secret = secrets.New()
+	}
+
+	if update.Value != nil {
+		secret.SetPassword(*update.Value)
+		// A value written whole is not split into parts anymore
+		secret.Del(chunksField)
+		return secret, 0, true, nil
+	}
+	if !update.Unchunk {
+		return secret, 0, false, nil
+	}
+
+	n, err := c.chunkCount(ctx, path, secret)
+	if err != nil || n == 0 {
+		return secret, 0, false, err
+	}
+	tflog.Debug(ctx, "Joining chunked secret into one", map[string]interface{}{
+		"path":  path,
+		"parts": n,
+	})
+	value, err := c.joinChunks(ctx, path, "", secret)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	secret.SetPassword(string(value))
+	wipe(value)
+	secret.Del(chunksField)
+	return secret, 0, true, nil
+}
+
+// applyFields sets fields in secret, in the order of their keys, and removes the fields remove
+// it has. It reports whether secret changed; fields that already have their values are not set.
+func applyFields(secret gopass.Secret, fields map[string]string, remove []string) (bool, error) {
+	changed := false
+	for _, key := range sortedKeys(fields) {
+		if current, ok := secret.Get(key); ok && current == fields[key] {
+			continue
+		}
+		if err := secret.Set(key, fields[key]); err != nil {
+			return changed, fmt.Errorf("failed to set key %q: %w", key, err)
+		}
+		changed = true
+	}
+	for _, key := range remove {
+		if secret.Del(key) {
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// sortedKeys returns the keys of fields in order.
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,188 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+)
+
+func TestGopassClient_UpdateSecret(t *testing.T) {
+	value := func(v string) *string { return &v }
+	fields := map[string]string{expiresAtKey: "2030-01-01T00:00:00Z", "host": "db.example.com"}
+
+	tests := []struct {
+		name          string
+		update        SecretUpdate
+		wantPassword  string
+		wantFields    map[string]string
+		wantMissing   []string
+		wantRevisions int
+	}{
+		{
+			name:          "value",
+			update:        SecretUpdate{Value: value("s3cret"), Fields: fields},
+			wantPassword:  "s3cret",
+			wantFields:    fields,
+			wantMissing:   []string{"note", "port"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "value keeping fields",
+			update:        SecretUpdate{Value: value("s3cret"), KeepFields: true, Fields: fields, Remove: []string{"port"}},
+			wantPassword:  "s3cret",
+			wantFields:    map[string]string{"host": "db.example.com", "note": "by hand"},
+			wantMissing:   []string{"port"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "content",
+			update:        SecretUpdate{Content: []byte("s3cret\nport: 5433\n"), Fields: fields},
+			wantPassword:  "s3cret",
+			wantFields:    map[string]string{"host": "db.example.com", "port": "5433"},
+			wantMissing:   []string{"note"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "parts",
+			update:        SecretUpdate{Parts: &SecretParts{Password: "s3cret", Username: "admin"}, Fields: fields},
+			wantPassword:  "s3cret",
+			wantFields:    map[string]string{"host": "db.example.com", usernameKey: "admin"},
+			wantMissing:   []string{"note", "port"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "fields only",
+			update:        SecretUpdate{Fields: fields, Remove: []string{"port", "missing"}},
+			wantPassword:  "hunter2",
+			wantFields:    map[string]string{"host": "db.example.com", "note": "by hand"},
+			wantMissing:   []string{"port"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "unchanged",
+			update:        SecretUpdate{Fields: map[string]string{"host": "db.internal"}, Remove: []string{"missing"}},
+			wantPassword:  "hunter2",
+			wantFields:    map[string]string{"host": "db.internal"},
+			wantRevisions: 1,
+		},
+		{
+			name:          "nothing",
+			wantPassword:  "hunter2",
+			wantRevisions: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			existing := secrets.ParseAKV([]byte("hunter2\nhost: db.internal\nport: 5432\nnote: by hand\n"))
+			store.secrets["app/db"] = existing
+			store.revisions["app/db"] = []string{"1"}
+			client := clientWith(store)
+
+			if err := client.UpdateSecret(context.Background(), "app/db", tc.update); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			secret := store.secrets["app/db"]
+			if secret.Password() != tc.wantPassword {
+				t.Errorf("expected password %q, got %q", tc.wantPassword, secret.Password())
+			}
+			for key, want := range tc.wantFields {
+				if got, _ := secret.Get(key); got != want {
+					t.Errorf("expected %s %q, got %q", key, want, got)
+				}
+			}
+			for _, key := range tc.wantMissing {
+				if _, ok := secret.Get(key); ok {
+					t.Errorf("expected %s to be gone", key)
+				}
+			}
+			if got := len(store.revisions["app/db"]); got != tc.wantRevisions {
+				t.Errorf("expected %d revisions, got %d", tc.wantRevisions, got)
+			}
+		})
+	}
+}
+
+func TestGopassClient_UpdateSecret_Chunked(t *testing.T) {
+	store := storeWith(map[string]string{"app/cert.part3": "left over"})
+	client := clientWith(store)
+	ctx := context.Background()
+
+	value := "abcdefgh"
+	update := SecretUpdate{Value: &value, ChunkSize: 4, Fields: map[string]string{expiresAtKey: "2030-01-01T00:00:00Z"}}
+	if err := client.UpdateSecret(ctx, "app/cert", update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret := store.secrets["app/cert"]
+	if got, _ := secret.Get(chunksField); got != "2" {
+		t.Errorf("expected 2 chunks, got %q", got)
+	}
+	if got, _ := secret.Get(expiresAtKey); got != "2030-01-01T00:00:00Z" {
+		t.Errorf("expected the expiry next to the chunks, got %q", got)
+	}
+	if got := len(store.revisions["app/cert"]); got != 1 {
+		t.Errorf("expected a single write of the secret, got %d revisions", got)
+	}
+	if _, ok := store.secrets["app/cert.part3"]; ok {
+		t.Error("expected the left over part to be removed")
+	}
+	if got, err := client.GetSecret(ctx, "app/cert"); err != nil || got != value {
+		t.Errorf("GetSecret() = %q, %v", got, err)
+	}
+
+	// Unchunking along with a field joins the parts in the same write
+	update = SecretUpdate{Unchunk: true, Fields: map[string]string{"host": "db.example.com"}}
+	if err := client.UpdateSecret(ctx, "app/cert", update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secret = store.secrets["app/cert"]
+	if secret.Password() != value {
+		t.Errorf("expected password %q, got %q", value, secret.Password())
+	}
+	if _, ok := secret.Get(chunksField); ok {
+		t.Error("expected the chunks field to be removed")
+	}
+	if got := len(store.revisions["app/cert"]); got != 2 {
+		t.Errorf("expected 2 revisions, got %d", got)
+	}
+	for _, part := range []string{"app/cert.part1", "app/cert.part2"} {
+		if _, ok := store.secrets[part]; ok {
+			t.Errorf("expected %s to be removed", part)
+		}
+	}
+}
+
+func TestGopassClient_UpdateSecret_Rejected(t *testing.T) {
+	value := "short"
+	tests := []struct {
+		name   string
+		update SecretUpdate
+	}{
+		{name: "policy", update: SecretUpdate{Value: &value, Fields: map[string]string{"host": "db.example.com"}}},
+		{name: "invalid parts", update: SecretUpdate{Parts: &SecretParts{Password: "long enough", Username: "a\nb"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			client := clientWith(store)
+			if err := client.SetPasswordPolicy(PasswordPolicy{MinLength: 10}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := client.UpdateSecret(context.Background(), "app/db", tc.update); err == nil {
+				t.Fatal("expected an error")
+			}
+			if len(store.secrets) != 0 {
+				t.Errorf("expected nothing written, got %v", store.secrets)
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                   = &JSONSecretResource{}
+	_ resource.ResourceWithConfigure      = &JSONSecretResource{}
+	_ resource.ResourceWithValidateConfig = &JSONSecretResource{}
+)
+
+// JSONSecretResource writes the top-level keys of a JSON object to gopass, one secret per key.
+// Keys are written and removed as by gopass_env, whose ImportState it inherits unchanged.
+type JSONSecretResource struct {
+	EnvResource
+}
+
+// JSONSecretResourceModel describes the resource data model.
+type JSONSecretResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Path           types.String `tfsdk:"path"`
+	JSONWO         types.String `tfsdk:"json_wo"`
+	JSONWOVersion  types.Int64  `tfsdk:"json_wo_version"`
+	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
+	Keys           types.Set    `tfsdk:"keys"`
+	Timeouts       types.Object `tfsdk:"timeouts"`
+}
+
+// NewJSONSecretResource creates a new instance.
+func NewJSONSecretResource() resource.Resource {
+	return &JSONSecretResource{}
+}
+
+func (r *JSONSecretResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_json_secret"
+}
+
+func (r *JSONSecretResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Writes the top-level keys of a JSON object to gopass, one secret per key under a base path. " +
+			"The JSON document is never stored in Terraform state.",
+		MarkdownDescription: `
+Writes the top-level keys of a JSON object to gopass, one secret per key under a base path.
+Useful for credential documents emitted by other providers, such as service account keys,
+that should be browsable in the store key by key and read back with the ` + "`gopass_env`" + `
+ephemeral resource.
+
+The document (` + "`json_wo`" + `) is **write-only** and never stored in state. Only the names of the
+managed keys are tracked, so keys removed from the document are deleted from gopass on the next update.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_json_secret" "ci_service_account" {
+  path            = "gcp/ci/service-account"
+  json_wo         = base64decode(google_service_account_key.ci.private_key)
+  json_wo_version = 1
+}
+` + "```" + `
+
+## Values
+
+- Strings are written as they are. Multi-line strings, e.g. PEM keys, are written in full,
+  but read back by ` + "`gopass_env`" + ` as their first line only
+- Numbers, booleans, objects and arrays are written as compact JSON text
+- Keys with a ` + "`null`" + ` value are skipped
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The base path (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Base path in the gopass store. Each top-level key is written to path/KEY.",
+				MarkdownDescription: "Base path in the gopass store. Each top-level key is written to `path/KEY`.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"json_wo": schema.StringAttribute{
+				Description: "JSON object whose top-level keys are written as separate secrets. " +
+					"This is a write-only attribute - it will never be stored in state or plan files. Accepts ephemeral values.",
+				MarkdownDescription: "JSON object whose top-level keys are written as separate secrets. " +
+					"This is a **write-only** attribute - it will never be stored in state or plan files. Accepts ephemeral values.",
+				Required:  true,
+				Sensitive: true,
+				WriteOnly: true,
+			},
+			"json_wo_version": schema.Int64Attribute{
+				Description: "Version number for the write-only document. Increment this to write " +
+					"changes of json_wo to gopass.",
+				MarkdownDescription: "Version number for the write-only document. **Increment this** to write " +
+					"changes of `json_wo` to gopass.",
+				Required: true,
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description: "Whether to delete the secrets from gopass when the resource is destroyed " +
+					"or a key is removed from json_wo. Defaults to true.",
+				MarkdownDescription: "Whether to delete the secrets from gopass when the resource is destroyed " +
+					"or a key is removed from `json_wo`. Defaults to `true`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+			"keys": schema.SetAttribute{
+				Description:         "Names of the keys managed by this resource.",
+				MarkdownDescription: "Names of the keys managed by this resource.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"timeouts": resourceTimeoutsAttribute(),
+		},
+	}
+}
+
+// ValidateConfig rejects a known json_wo that is not a JSON object, or has keys that would
+// not form valid gopass paths below path.
+func (r *JSONSecretResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config JSONSecretResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() || config.JSONWO.IsNull() || config.JSONWO.IsUnknown() {
+		return
+	}
+
+	if _, err := jsonValues(config.JSONWO.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("json_wo"),
+			"Invalid json_wo",
+			codedDetail(CodeInvalidConfig, err.Error()),
+		)
+	}
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *JSONSecretResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data JSONSecretResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutCreate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	tflog.Debug(ctx, "Creating gopass JSON secret", map[string]interface{}{
+		"path": basePath,
+	})
+
+	// Get the write-only document from config (not plan, as write-only values are only in config)
+	values, ok := r.configValues(ctx, req.Config, &resp.Diagnostics)
+	if !ok {
+		return
+	}
+
+	written, err := r.writeValues(ctx, basePath, values)
+	data.ID = data.Path
+	data.Keys = envKeySet(written)
+	if err != nil {
+		// Record the keys written so far, so the tainted resource cleans them up
+		resp.Diagnostics.AddError(
+			"Failed to create JSON secret",
+			errorDetail(err, fmt.Sprintf("Could not write JSON secrets to gopass at %q: %s", basePath, err.Error())),
+		)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *JSONSecretResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data JSONSecretResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutRead)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	tflog.Debug(ctx, "Reading gopass JSON secret", map[string]interface{}{
+		"path": basePath,
+	})
+
+	existing, err := r.existingKeys(ctx, basePath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read JSON secret",
+			errorDetail(err, fmt.Sprintf("Could not list secrets at %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	// Only check which managed keys still exist - we never read the values back
+	var kept, missing []string
+	for _, key := range envKeys(data.Keys) {
+		if existing[key] {
+			kept = append(kept, key)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(kept) == 0 && len(missing) > 0 {
+		// All secrets were deleted outside of Terraform
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	if len(missing) > 0 {
+		resp.Diagnostics.AddWarning(
+			"Secrets removed outside of Terraform",
+			codedDetail(CodeDrift, fmt.Sprintf(
+				"The secrets of the keys %s below %q were removed outside of Terraform. "+
+					"Consider incrementing json_wo_version to write them again.",
+				strings.Join(missing, ", "), basePath,
+			)),
+		)
+	}
+
+	data.Keys = envKeySet(kept)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *JSONSecretResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data JSONSecretResourceModel
+	var state JSONSecretResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutUpdate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	data.Keys = state.Keys
+
+	// The document is only written when json_wo_version changes
+	if data.JSONWOVersion.Equal(state.JSONWOVersion) {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	tflog.Debug(ctx, "Updating gopass JSON secret", map[string]interface{}{
+		"path":        basePath,
+		"old_version": state.JSONWOVersion.ValueInt64(),
+		"new_version": data.JSONWOVersion.ValueInt64(),
+	})
+
+	values, ok := r.configValues(ctx, req.Config, &resp.Diagnostics)
+	if !ok {
+		return
+	}
+
+	previous := envKeys(state.Keys)
+	written, err := r.writeValues(ctx, basePath, values)
+	if err != nil {
+		// Keep tracking the previous keys, as none of them is pruned yet
+		data.Keys = envKeySet(append(previous, written...))
+		resp.Diagnostics.AddError(
+			"Failed to update JSON secret",
+			errorDetail(err, fmt.Sprintf("Could not write JSON secrets to gopass at %q: %s", basePath, err.Error())),
+		)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	// Prune keys that were removed from the document
+	var stale []string
+	for _, key := range previous {
+		if _, ok := values[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	remaining, err := r.removeKeys(ctx, basePath, stale, data.DeleteOnRemove.ValueBool())
+	data.Keys = envKeySet(append(written, remaining...))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update JSON secret",
+			errorDetail(err, fmt.Sprintf("Could not remove stale JSON secrets from gopass at %q: %s", basePath, err.Error())),
+		)
+	}
+
+	tflog.Info(ctx, "Updated gopass JSON secret (json_wo_version changed)", map[string]interface{}{
+		"path":    basePath,
+		"written": len(written),
+		"stale":   len(stale),
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *JSONSecretResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data JSONSecretResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	if _, err := r.removeKeys(ctx, basePath, envKeys(data.Keys), data.DeleteOnRemove.ValueBool()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove JSON secret",
+			errorDetail(err, fmt.Sprintf("Could not remove JSON secrets from gopass at %q: %s", basePath, err.Error())),
+		)
+	}
+}
+
+// configValues returns the secrets to write from the write-only json_wo of config.
+// It reports false if the document is missing or not a valid JSON object.
+func (r *JSONSecretResource) configValues(ctx context.Context, config tfsdk.Config, diags *diag.Diagnostics) (map[string]string, bool) {
+	var data JSONSecretResourceModel
+	diags.Append(config.Get(ctx, &data)...)
+	if diags.HasError() {
+		return nil, false
+	}
+	if data.JSONWO.IsNull() || data.JSONWO.IsUnknown() {
+		diags.AddAttributeError(
+			path.Root("json_wo"),
+			"Missing json_wo",
+			codedDetail(CodeInvalidConfig, "json_wo is not known. The JSON document must be known at apply time."),
+		)
+		return nil, false
+	}
+
+	values, err := jsonValues(data.JSONWO.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("json_wo"), "Invalid json_wo", codedDetail(CodeInvalidConfig, err.Error()))
+		return nil, false
+	}
+	return values, true
+}
+
+// jsonValues returns the top-level keys of the JSON object doc with their values as secret
+// content: strings as they are, null values skipped, and anything else as compact JSON.
+// Errors never contain any part of doc except key names.
+func jsonValues(doc string) (map[string]string, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(doc), &object); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("json_wo is not valid JSON: syntax error at offset %d", syntaxErr.Offset)
+		}
+		return nil, errors.New("json_wo must be a JSON object")
+	}
+	if object == nil {
+		return nil, errors.New("json_wo must be a JSON object")
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make(map[string]string, len(object))
+	for _, key := range keys {
+		raw := object[key]
+		if err := validateSecretPath(key); err != nil {
+			return nil, fmt.Errorf("the key %q is not a valid gopass path: %w", key, err)
+		}
+
+		var s string
+		switch {
+		case string(raw) == "null":
+			continue
+		case json.Unmarshal(raw, &s) == nil:
+			values[key] = s
+		default:
+			var compact bytes.Buffer
+			// raw was validated by json.Unmarshal above, so compacting cannot fail
+			_ = json.Compact(&compact, raw)
+			values[key] = compact.String()
+		}
+	}
+	return values, nil
+}
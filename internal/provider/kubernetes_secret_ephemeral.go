@@ -0,0 +1,295 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ ephemeral.EphemeralResource                   = &KubernetesSecretEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure      = &KubernetesSecretEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithValidateConfig = &KubernetesSecretEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose          = &KubernetesSecretEphemeralResource{}
+)
+
+// defaultKubernetesSecretSeparator joins the segments of nested paths into a Kubernetes Secret key.
+const defaultKubernetesSecretSeparator = "_"
+
+// maxKubernetesSecretKeyLength is the maximum length Kubernetes accepts for a Secret key.
+const maxKubernetesSecretKeyLength = 253
+
+// kubernetesSecretKey matches the keys Kubernetes accepts in the data of a Secret.
+var kubernetesSecretKey = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// KubernetesSecretEphemeralResource renders a subtree of the store as the data of a Kubernetes Secret.
+type KubernetesSecretEphemeralResource struct {
+	client *GopassClient
+}
+
+// KubernetesSecretModel describes the data model.
+type KubernetesSecretModel struct {
+	Path             types.String `tfsdk:"path"`
+	MaxDepth         types.Int64  `tfsdk:"max_depth"`
+	Include          types.List   `tfsdk:"include"`
+	Exclude          types.List   `tfsdk:"exclude"`
+	UppercaseKeys    types.Bool   `tfsdk:"uppercase_keys"`
+	KeyPrefix        types.String `tfsdk:"key_prefix"`
+	FlattenSeparator types.String `tfsdk:"flatten_separator"`
+	FailOnError      types.Bool   `tfsdk:"fail_on_error"`
+	Timeouts         types.Object `tfsdk:"timeouts"`
+	Data             types.Map    `tfsdk:"data"`
+	Keys             types.List   `tfsdk:"keys"`
+}
+
+// NewKubernetesSecretEphemeralResource creates a new instance.
+func NewKubernetesSecretEphemeralResource() ephemeral.EphemeralResource {
+	return &KubernetesSecretEphemeralResource{}
+}
+
+func (r *KubernetesSecretEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kubernetes_secret"
+}
+
+func (r *KubernetesSecretEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Renders all secrets under a path as the base64-encoded data map of a Kubernetes Secret.",
+		MarkdownDescription: `
+Renders all secrets under a path as the ` + "`data`" + ` map of a Kubernetes Secret: one entry per
+secret, with the value base64-encoded as the Kubernetes API expects it. This replaces
+` + "`base64encode`" + ` loops over the ` + "`credentials`" + ` of a ` + "`gopass_env`" + `.
+
+Secrets are selected and named like with the ` + "`gopass_env`" + ` ephemeral resource, except that nested
+paths are always flattened, by default with ` + "`_`" + `: ` + "`API/v2/KEY`" + ` becomes ` + "`API_v2_KEY`" + `.
+Keys must be valid Kubernetes Secret keys: letters, digits, ` + "`-`" + `, ` + "`_`" + ` and ` + "`.`" + `.
+
+## Example Usage
+
+` + "```hcl" + `
+ephemeral "gopass_kubernetes_secret" "app" {
+  path = "k8s/app/production"
+}
+
+resource "kubernetes_manifest" "app" {
+  manifest = {
+    apiVersion = "v1"
+    kind       = "Secret"
+    metadata   = { name = "app", namespace = "production" }
+    type       = "Opaque"
+    data       = ephemeral.gopass_kubernetes_secret.app.data
+  }
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description:         "Path prefix in the gopass store (e.g., 'k8s/app/production').",
+				MarkdownDescription: "Path prefix in the gopass store (e.g., `k8s/app/production`).",
+				Required:            true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"max_depth": schema.Int64Attribute{
+				Description: "Maximum number of levels below path to read, e.g. 1 for the immediate children only. " +
+					"Defaults to no limit.",
+				MarkdownDescription: "Maximum number of levels below `path` to read, e.g. `1` for the immediate children only. " +
+					"Defaults to no limit.",
+				Optional: true,
+			},
+			"include": schema.ListAttribute{
+				Description: "Glob patterns selecting which secrets to render, matched against the key relative to path. " +
+					"'*' matches within a path segment, '**' matches any number of segments. Defaults to all secrets.",
+				MarkdownDescription: "Glob patterns selecting which secrets to render, matched against the key relative to `path`. " +
+					"`*` matches within a path segment, `**` matches any number of segments. Defaults to all secrets.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"exclude": schema.ListAttribute{
+				Description:         "Glob patterns of secrets to leave out. Applied after include.",
+				MarkdownDescription: "Glob patterns of secrets to leave out. Applied after `include`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"uppercase_keys": schema.BoolAttribute{
+				Description:         "Convert all keys to upper case.",
+				MarkdownDescription: "Convert all keys to upper case.",
+				Optional:            true,
+			},
+			"key_prefix": schema.StringAttribute{
+				Description:         "Prefix prepended to every key, e.g. 'APP_'.",
+				MarkdownDescription: "Prefix prepended to every key, e.g. `APP_`.",
+				Optional:            true,
+			},
+			"flatten_separator": schema.StringAttribute{
+				Description:         "Separator joining the segments of nested paths into a key. Defaults to '_'.",
+				MarkdownDescription: "Separator joining the segments of nested paths into a key. Defaults to `_`.",
+				Optional:            true,
+			},
+			"fail_on_error": schema.BoolAttribute{
+				Description: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to false.",
+				MarkdownDescription: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to `false`.",
+				Optional: true,
+			},
+			"timeouts": ephemeralTimeoutsAttribute(),
+			"data": schema.MapAttribute{
+				Description:         "The base64-encoded values by key, for the data of a Kubernetes Secret.",
+				MarkdownDescription: "The base64-encoded values by key, for the `data` of a Kubernetes Secret.",
+				ElementType:         types.StringType,
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"keys": schema.ListAttribute{
+				Description:         "The keys of data, sorted. Keys are not secret, so they can be used e.g. in outputs.",
+				MarkdownDescription: "The keys of `data`, sorted. Keys are not secret, so they can be used e.g. in outputs.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *KubernetesSecretEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+func (r *KubernetesSecretEphemeralResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data KubernetesSecretModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePrefixConfig(&resp.Diagnostics, data.MaxDepth, data.FlattenSeparator, "Kubernetes Secret keys")
+}
+
+func (r *KubernetesSecretEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data KubernetesSecretModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	opts := envKeyOptions{
+		uppercase:        data.UppercaseKeys.ValueBool(),
+		keyPrefix:        data.KeyPrefix.ValueString(),
+		flattenSeparator: defaultKubernetesSecretSeparator,
+	}
+	if !data.FlattenSeparator.IsNull() {
+		opts.flattenSeparator = data.FlattenSeparator.ValueString()
+	}
+	values, ok := readFlatPrefix(ctx, r.client, &resp.Diagnostics, prefixRead{
+		path:        basePath,
+		maxDepth:    int(data.MaxDepth.ValueInt64()),
+		timeouts:    data.Timeouts,
+		include:     data.Include,
+		exclude:     data.Exclude,
+		keys:        opts,
+		failOnError: data.FailOnError.ValueBool(),
+		leftOutOf:   "data",
+	})
+	if !ok {
+		return
+	}
+	defer wipeValues(values)
+
+	encoded, keys, err := encodeKubernetesSecretData(values)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid Kubernetes Secret keys",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not render secrets under path %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	dataMap, diags := types.MapValueFrom(ctx, types.StringType, encoded)
+	resp.Diagnostics.Append(diags...)
+	data.Data = dataMap
+	keyList, diags := types.ListValueFrom(ctx, types.StringType, keys)
+	resp.Diagnostics.Append(diags...)
+	data.Keys = keyList
+
+	// Set result - NEVER written to state
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+
+	openCloseState(ctx, r.client, resp, &closeState{Prefixes: []string{basePath}})
+
+	tflog.Debug(ctx, "Successfully rendered Kubernetes secret data from gopass", map[string]interface{}{
+		"path":  basePath,
+		"count": len(keys),
+	})
+}
+
+// Close is called once Terraform no longer needs the data. A caching client still holds the
+// decrypted secrets it was encoded from, which are dropped.
+func (r *KubernetesSecretEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	ctx = r.client.logContext(ctx)
+
+	closeSecrets(ctx, r.client, req, resp)
+
+	tflog.Debug(ctx, "Closed ephemeral gopass Kubernetes secret")
+}
+
+// encodeKubernetesSecretData returns values base64-encoded, and their keys sorted. It fails on
+// keys Kubernetes would not accept in a Secret, naming them but not their values.
+func encodeKubernetesSecretData(values map[string][]byte) (map[string]string, []string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var invalid []string
+	encoded := make(map[string]string, len(values))
+	for _, key := range keys {
+		if !kubernetesSecretKey.MatchString(key) || len(key) > maxKubernetesSecretKeyLength || key == "." || key == ".." {
+			invalid = append(invalid, fmt.Sprintf("%q", key))
+			continue
+		}
+		encoded[key] = base64.StdEncoding.EncodeToString(values[key])
+	}
+	if len(invalid) > 0 {
+		return nil, nil, fmt.Errorf("%s are not valid Kubernetes Secret keys; rename the secrets, or leave them out with exclude", strings.Join(invalid, ", "))
+	}
+	return encoded, keys, nil
+}
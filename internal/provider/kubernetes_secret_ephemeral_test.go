@@ -0,0 +1,381 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// openKubernetesSecret opens a gopass_kubernetes_secret with the given configuration and
+// returns the response and result.
+func openKubernetesSecret(t *testing.T, r *KubernetesSecretEphemeralResource, raw func(schemaResp *ephemeral.SchemaResponse) tftypes.Value) (*ephemeral.OpenResponse, KubernetesSecretModel) {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	resp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw(schemaResp)}}, resp)
+
+	var result KubernetesSecretModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.Result.Get(ctx, &result)...)
+	}
+	return resp, result
+}
+
+// kubernetesSecretConfig returns a configuration reading k8s/app with the given options and,
+// unless empty, open timeout.
+func kubernetesSecretConfig(config map[string]tftypes.Value, openTimeout string) func(schemaResp *ephemeral.SchemaResponse) tftypes.Value {
+	return func(schemaResp *ephemeral.SchemaResponse) tftypes.Value {
+		values := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "k8s/app")}
+		for k, v := range config {
+			values[k] = v
+		}
+		if openTimeout != "" {
+			values["timeouts"] = timeoutsRaw(schemaResp.Schema, timeoutOpen, openTimeout)
+		}
+		return schemaObjectValue(schemaResp.Schema, values)
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_Metadata(t *testing.T) {
+	resp := &ephemeral.MetadataResponse{}
+	NewKubernetesSecretEphemeralResource().Metadata(context.Background(), ephemeral.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_kubernetes_secret" {
+		t.Errorf("expected TypeName 'gopass_kubernetes_secret', got %q", resp.TypeName)
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_Schema(t *testing.T) {
+	resp := &ephemeral.SchemaResponse{}
+	NewKubernetesSecretEphemeralResource().Schema(context.Background(), ephemeral.SchemaRequest{}, resp)
+
+	if data := resp.Schema.Attributes["data"]; !data.IsSensitive() || !data.IsComputed() {
+		t.Error("expected data to be computed and sensitive")
+	}
+	if keys := resp.Schema.Attributes["keys"]; keys.IsSensitive() {
+		t.Error("expected keys not to be sensitive")
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name    string
+		data    any
+		wantErr bool
+	}{
+		{name: "client", data: client},
+		{name: "nil", data: nil},
+		{name: "wrong type", data: "client", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &KubernetesSecretEphemeralResource{}
+			resp := &ephemeral.ConfigureResponse{}
+			r.Configure(context.Background(), ephemeral.ConfigureRequest{ProviderData: tc.data}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr && tc.data != nil && r.client != client {
+				t.Error("expected client to be set")
+			}
+		})
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]tftypes.Value
+		wrongConfig bool
+		wantErr     string
+	}{
+		{name: "path only", config: map[string]tftypes.Value{}},
+		{
+			name:   "all options",
+			config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 2), "flatten_separator": tftypes.NewValue(tftypes.String, ".")},
+		},
+		{
+			name:   "unknown options",
+			config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue), "flatten_separator": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)},
+		},
+		{name: "max_depth zero", config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 0)}, wantErr: "Invalid max_depth"},
+		{name: "empty separator", config: map[string]tftypes.Value{"flatten_separator": tftypes.NewValue(tftypes.String, "")}, wantErr: "Invalid flatten_separator"},
+		{name: "invalid config", wrongConfig: true, wantErr: "Value Conversion Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &KubernetesSecretEphemeralResource{}
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+
+			raw := dotenvWrongConfig()
+			if !tc.wrongConfig {
+				raw = kubernetesSecretConfig(tc.config, "")(schemaResp)
+			}
+
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_Open(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]tftypes.Value
+		wantData map[string]string
+		wantKeys []string
+	}{
+		{
+			name:     "nested paths flattened",
+			config:   map[string]tftypes.Value{},
+			wantData: map[string]string{"db_password": "aHVudGVyMg==", "tls.crt": "LS0tLS1CRUdJTgo=", "api_v2_token": "dG9rZW4="},
+			wantKeys: []string{"api_v2_token", "db_password", "tls.crt"},
+		},
+		{
+			name: "key options",
+			config: map[string]tftypes.Value{
+				"include":           tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "api/**"), tftypes.NewValue(tftypes.String, "db_password")}),
+				"exclude":           tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "db_password")}),
+				"uppercase_keys":    tftypes.NewValue(tftypes.Bool, true),
+				"key_prefix":        tftypes.NewValue(tftypes.String, "APP_"),
+				"flatten_separator": tftypes.NewValue(tftypes.String, "."),
+			},
+			wantData: map[string]string{"APP_API.V2.TOKEN": "dG9rZW4="},
+			wantKeys: []string{"APP_API.V2.TOKEN"},
+		},
+		{
+			name:     "max depth",
+			config:   map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 1)},
+			wantData: map[string]string{"db_password": "aHVudGVyMg==", "tls.crt": "LS0tLS1CRUdJTgo="},
+			wantKeys: []string{"db_password", "tls.crt"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			store.secrets["k8s/app/db_password"] = newMockSecret("hunter2")
+			store.secrets["k8s/app/tls.crt"] = newMockSecret("-----BEGIN\n")
+			store.secrets["k8s/app/api/v2/token"] = newMockSecret("token")
+			client := NewGopassClient("")
+			client.store = store
+			r := &KubernetesSecretEphemeralResource{client: client}
+
+			resp, result := openKubernetesSecret(t, r, kubernetesSecretConfig(tc.config, ""))
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			data := map[string]string{}
+			result.Data.ElementsAs(context.Background(), &data, false)
+			if !reflect.DeepEqual(data, tc.wantData) {
+				t.Errorf("expected data %v, got %v", tc.wantData, data)
+			}
+			var keys []string
+			result.Keys.ElementsAs(context.Background(), &keys, false)
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tc.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_Open_Warnings(t *testing.T) {
+	t.Run("no secrets", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = newMockStore()
+		r := &KubernetesSecretEphemeralResource{client: client}
+
+		resp, result := openKubernetesSecret(t, r, kubernetesSecretConfig(nil, ""))
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("expected a single warning, got %v", resp.Diagnostics)
+		}
+		if len(result.Data.Elements()) != 0 || len(result.Keys.Elements()) != 0 {
+			t.Errorf("expected empty data, got %v and %v", result.Data, result.Keys)
+		}
+	})
+
+	t.Run("unreadable secret left out", func(t *testing.T) {
+		store := newMockStoreWithSelectiveFailure()
+		store.secrets["k8s/app/A"] = newMockSecret("a")
+		store.secrets["k8s/app/B"] = newMockSecret("b")
+		store.failOnGet["k8s/app/B"] = true
+		client := NewGopassClient("")
+		client.store = store
+		r := &KubernetesSecretEphemeralResource{client: client}
+
+		resp, result := openKubernetesSecret(t, r, kubernetesSecretConfig(nil, ""))
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("expected a single warning, got %v", resp.Diagnostics)
+		}
+		var keys []string
+		result.Keys.ElementsAs(context.Background(), &keys, false)
+		if !reflect.DeepEqual(keys, []string{"A"}) {
+			t.Errorf("expected only A, got %v", keys)
+		}
+	})
+}
+
+func TestKubernetesSecretEphemeralResource_Open_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		store       func() gopass.Store
+		config      map[string]tftypes.Value
+		openTimeout string
+		wrongConfig bool
+		wantErr     string
+	}{
+		{
+			name: "fail on error",
+			store: func() gopass.Store {
+				store := newMockStoreWithSelectiveFailure()
+				store.secrets["k8s/app/A"] = newMockSecret("a")
+				store.failOnGet["k8s/app/A"] = true
+				return store
+			},
+			config:  map[string]tftypes.Value{"fail_on_error": tftypes.NewValue(tftypes.Bool, true)},
+			wantErr: "Failed to read secrets",
+		},
+		{
+			name: "store fails",
+			store: func() gopass.Store {
+				return &mockStore{shouldFail: true, failMsg: "permission denied"}
+			},
+			wantErr: "Failed to read secrets",
+		},
+		{
+			name:        "invalid timeouts",
+			openTimeout: "soon",
+			wantErr:     "Invalid timeouts",
+		},
+		{
+			name:    "unknown include",
+			config:  map[string]tftypes.Value{"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue)},
+			wantErr: "Value Conversion Error",
+		},
+		{
+			name:    "invalid pattern",
+			config:  map[string]tftypes.Value{"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "[")})},
+			wantErr: "Invalid key options",
+		},
+		{
+			name:    "invalid key",
+			config:  map[string]tftypes.Value{"key_prefix": tftypes.NewValue(tftypes.String, "app:")},
+			wantErr: "Invalid Kubernetes Secret keys",
+		},
+		{
+			name:        "invalid config",
+			wrongConfig: true,
+			wantErr:     "Value Conversion Error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			if tc.store != nil {
+				client.store = tc.store()
+			} else {
+				store := newMockStore()
+				store.secrets["k8s/app/db_password"] = newMockSecret("secret")
+				client.store = store
+			}
+			r := &KubernetesSecretEphemeralResource{client: client}
+
+			raw := kubernetesSecretConfig(tc.config, tc.openTimeout)
+			if tc.wrongConfig {
+				raw = func(*ephemeral.SchemaResponse) tftypes.Value { return dotenvWrongConfig() }
+			}
+			resp, _ := openKubernetesSecret(t, r, raw)
+
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestKubernetesSecretEphemeralResource_Close(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"k8s/app/KEY": "s1", "k8s/app/api/TOKEN": "s2", "other/KEY": "s3"})
+	client.EnableCache()
+	if _, err := client.GetSecret(ctx, "other/KEY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &KubernetesSecretEphemeralResource{client: client}
+	openResp, _ := openKubernetesSecret(t, r, kubernetesSecretConfig(nil, ""))
+	if openResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", openResp.Diagnostics)
+	}
+	if n := len(client.cache.entries); n != 3 {
+		t.Fatalf("expected the encoded and the other secret to be cached, got %d entries", n)
+	}
+
+	resp := &ephemeral.CloseResponse{}
+	r.Close(ctx, ephemeral.CloseRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if _, _, ok := client.cache.get(secretCacheKey{path: "other/KEY", revision: "latest"}); !ok || len(client.cache.entries) != 1 {
+		t.Errorf("expected Close to release only the secrets below the path, got %d entries", len(client.cache.entries))
+	}
+}
+
+func TestEncodeKubernetesSecretData_NamesInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		wantErr string
+	}{
+		{name: "characters", values: map[string]string{"ok": "1", "has space": "2", "a:b": "3"}, wantErr: `"a:b", "has space" are not valid`},
+		{name: "dot", values: map[string]string{".": "1"}, wantErr: `"." are not valid`},
+		{name: "dot dot", values: map[string]string{"..": "1"}, wantErr: `".." are not valid`},
+		{name: "too long", values: map[string]string{strings.Repeat("a", 254): "1"}, wantErr: "are not valid"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := encodeKubernetesSecretData(byteValues(tc.values))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if strings.Contains(err.Error(), "2") || strings.Contains(err.Error(), "3") {
+				t.Errorf("expected values to be left out of the error, got %q", err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// logPathFields are the log fields holding secret paths or folders.
+var logPathFields = []string{"path", "paths", "prefix", "source", "destination"}
+
+// quotedString matches the quoted parts of log messages and errors, which is where they name
+// secret paths, e.g. `secret "app/db" not found`.
+var quotedString = regexp.MustCompile(`"[^"]*"`)
+
+// SetLogPaths sets whether secret paths appear in log output. If disabled, the fields holding
+// paths and the quoted parts of messages and errors are logged as "***".
+func (c *GopassClient) SetLogPaths(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hidePaths = !enabled
+}
+
+// logContext returns ctx with secret paths masked in log output if log_paths is disabled.
+// Every operation of a resource, data source or ephemeral resource starts with it, so the
+// logs of the client called with the returned context are masked as well.
+func (c *GopassClient) logContext(ctx context.Context) context.Context {
+	if c == nil {
+		return ctx
+	}
+	c.mu.Lock()
+	hidePaths := c.hidePaths
+	c.mu.Unlock()
+	if !hidePaths {
+		return ctx
+	}
+
+	ctx = tflog.MaskFieldValuesWithFieldKeys(ctx, logPathFields...)
+	ctx = tflog.MaskAllFieldValuesRegexes(ctx, quotedString)
+	return tflog.MaskMessageRegexes(ctx, quotedString)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+func TestGopassClient_LogContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    *GopassClient
+		logPaths  bool
+		wantPaths bool
+	}{
+		{name: "paths logged", client: NewGopassClient(""), logPaths: true, wantPaths: true},
+		{name: "paths hidden", client: NewGopassClient(""), logPaths: false, wantPaths: false},
+		{name: "no client", client: nil, wantPaths: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.client != nil {
+				tc.client.SetLogPaths(tc.logPaths)
+			}
+			var output bytes.Buffer
+			ctx := tc.client.logContext(tflogtest.RootLogger(context.Background(), &output))
+
+			tflog.Debug(ctx, `Reading secret "app/db"`, map[string]interface{}{
+				"path":        "app/db",
+				"paths":       []string{"app/db", "app/api"},
+				"prefix":      "app",
+				"source":      "app/db",
+				"destination": "app/db-copy",
+				"count":       2,
+				"error":       fmt.Errorf("failed to read secret %q: %w", "app/db", errors.New("gpg failed")).Error(),
+			})
+
+			logged := output.String()
+			if strings.Contains(logged, "app/") == !tc.wantPaths {
+				t.Errorf("expected paths logged=%v, got %s", tc.wantPaths, logged)
+			}
+			if !strings.Contains(logged, `"count":2`) || !strings.Contains(logged, "gpg failed") {
+				t.Errorf("expected other fields to be kept, got %s", logged)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Delete_LogPaths(t *testing.T) {
+	for _, logPaths := range []bool{true, false} {
+		client := NewGopassClient("")
+		client.store = storeWith(map[string]string{"team/db": "secret"})
+		client.SetLogPaths(logPaths)
+		r := &SecretResource{client: client}
+		schemaResp := &resource.SchemaResponse{}
+		r.Schema(context.Background(), resource.SchemaRequest{}, schemaResp)
+
+		var output bytes.Buffer
+		resp := &resource.DeleteResponse{}
+		r.Delete(tflogtest.RootLogger(context.Background(), &output), resource.DeleteRequest{
+			State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"id":               tftypes.NewValue(tftypes.String, "team/db"),
+				"path":             tftypes.NewValue(tftypes.String, "team/db"),
+				"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+			})},
+		}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+
+		// The resource and the client log the path
+		logged := output.String()
+		if count := strings.Count(logged, "team/db"); (count >= 2) != logPaths || (count == 0) == logPaths {
+			t.Errorf("log_paths = %v: unexpected log output %s", logPaths, logged)
+		}
+		if !logPaths && !strings.Contains(logged, `"path":"***"`) {
+			t.Errorf("expected masked paths, got %s", logged)
+		}
+	}
+}
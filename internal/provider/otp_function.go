@@ -0,0 +1,162 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // TOTP uses HMAC-SHA1 unless the URI asks for another algorithm
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ function.Function = &OTPFunction{}
+
+// totpAlgorithms are the hash functions an otpauth:// URI can name, by name.
+var totpAlgorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// OTPFunction implements provider::gopass::otp, returning the current TOTP code of a
+// secret like `gopass otp`.
+type OTPFunction struct {
+	client *GopassClient
+}
+
+// NewOTPFunction creates a new instance.
+func NewOTPFunction() function.Function {
+	return &OTPFunction{client: functionClient()}
+}
+
+func (f *OTPFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "otp"
+}
+
+func (f *OTPFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Compute the current TOTP code of a secret",
+		Description: "Returns the current time-based one-time password of the otpauth://totp/ URI stored in the secret at path, " +
+			"like gopass otp, e.g. as stored by gopass_otp_secret. HOTP URIs are rejected, as computing a code would have to advance " +
+			"the counter in the store. Function results are not ephemeral: the code ends up in plan and state wherever it is used " +
+			"in resources, and it changes every period, so plans computing it always differ. " +
+			"Functions cannot see the provider configuration, so the store comes from the gopass configuration or PASSWORD_STORE_DIR.",
+		MarkdownDescription: "Returns the current time-based one-time password of the `otpauth://totp/` URI stored in the secret at `path`, " +
+			"like `gopass otp`, e.g. as stored by `gopass_otp_secret`. HOTP URIs are rejected, as computing a code would have to advance " +
+			"the counter in the store.\n\n" +
+			"**Function results are not ephemeral**: the code ends up in plan and state wherever it is used in resources, " +
+			"and it changes every period, so plans computing it always differ. Use it in provider configurations and " +
+			"ephemeral contexts. Functions cannot see the provider configuration, so the store comes from the gopass configuration " +
+			"or `PASSWORD_STORE_DIR`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "path",
+				Description:         "Path of the secret holding the otpauth:// URI, e.g. 'websites/example.com/admin'.",
+				MarkdownDescription: "Path of the secret holding the `otpauth://` URI, e.g. `websites/example.com/admin`.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *OTPFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var secretPath string
+
+	resp.Error = req.Arguments.Get(ctx, &secretPath)
+	if resp.Error != nil {
+		return
+	}
+	if err := validateSecretPath(secretPath); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, codedDetail(CodeInvalidConfig, fmt.Sprintf("Invalid path %q: %s", secretPath, err.Error())))
+		return
+	}
+
+	tflog.Debug(ctx, "Computing OTP code for provider function otp", map[string]interface{}{
+		"path": secretPath,
+	})
+
+	uri, err := f.client.GetOTPURI(ctx, secretPath)
+	if err != nil {
+		resp.Error = function.NewFuncError(errorDetail(err, fmt.Sprintf("Could not read the OTP URI of secret %q: %s", secretPath, err.Error())))
+		return
+	}
+	code, err := totpCode(uri, timeNow())
+	if err != nil {
+		resp.Error = function.NewFuncError(errorDetail(err, fmt.Sprintf("Could not compute the OTP code of secret %q: %s", secretPath, err.Error())))
+		return
+	}
+
+	resp.Error = resp.Result.Set(ctx, code)
+}
+
+// totpCode returns the TOTP code of the otpauth://totp/ URI uri at time at, following
+// RFC 6238 with the algorithm, digits and period parameters of the URI.
+// Errors never include the URI, as it holds the seed.
+func totpCode(uri string, at time.Time) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "otpauth" {
+		return "", fmt.Errorf("invalid OTP URI: must start with %s", otpauthScheme)
+	}
+	if !strings.EqualFold(u.Host, "totp") {
+		return "", fmt.Errorf("unsupported OTP type %q: only totp codes can be computed", u.Host)
+	}
+	query := u.Query()
+
+	seed := strings.ToUpper(strings.ReplaceAll(query.Get("secret"), " ", ""))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(seed, "="))
+	if err != nil || len(key) == 0 {
+		return "", fmt.Errorf("invalid OTP URI: secret must be a base32-encoded key")
+	}
+
+	algorithm := "SHA1"
+	if v := query.Get("algorithm"); v != "" {
+		algorithm = strings.ToUpper(v)
+	}
+	newHash, ok := totpAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported OTP algorithm %q: must be SHA1, SHA256 or SHA512", algorithm)
+	}
+
+	digits := 6
+	if v := query.Get("digits"); v != "" {
+		if digits, err = strconv.Atoi(v); err != nil || digits < 6 || digits > 8 {
+			return "", fmt.Errorf("invalid OTP digits %q: must be 6, 7 or 8", v)
+		}
+	}
+
+	period := int64(30)
+	if v := query.Get("period"); v != "" {
+		if period, err = strconv.ParseInt(v, 10, 64); err != nil || period < 1 {
+			return "", fmt.Errorf("invalid OTP period %q: must be a positive number of seconds", v)
+		}
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/period))
+	mac := hmac.New(newHash, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulus := uint32(1)
+	for range digits {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%modulus), nil
+}
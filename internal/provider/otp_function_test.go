@@ -0,0 +1,197 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// rfc6238Seed is the SHA1 key of the RFC 6238 test vectors, base32-encoded.
+const rfc6238Seed = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		at      int64
+		want    string
+		wantErr string
+	}{
+		// Test vectors of RFC 6238, appendix B
+		{name: "sha1", uri: "otpauth://totp/test?digits=8&secret=" + rfc6238Seed, at: 59, want: "94287082"},
+		{name: "sha1 later", uri: "otpauth://totp/test?digits=8&secret=" + rfc6238Seed, at: 1111111109, want: "07081804"},
+		{
+			name: "sha256",
+			uri:  "otpauth://totp/test?algorithm=SHA256&digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA",
+			at:   59,
+			want: "46119246",
+		},
+		{
+			name: "sha512",
+			uri: "otpauth://totp/test?algorithm=sha512&digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" +
+				"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA",
+			at:   59,
+			want: "90693936",
+		},
+		{name: "defaults", uri: "otpauth://totp/test?secret=" + rfc6238Seed, at: 59, want: "287082"},
+		{name: "lower case seed", uri: "otpauth://totp/test?secret=" + strings.ToLower(rfc6238Seed) + "%3D%3D", at: 59, want: "287082"},
+		{name: "period", uri: "otpauth://totp/test?period=60&digits=8&secret=" + rfc6238Seed, at: 118, want: "94287082"},
+		{name: "not a uri", uri: "hunter2", wantErr: "invalid OTP URI"},
+		{name: "hotp", uri: "otpauth://hotp/test?counter=1&secret=" + rfc6238Seed, wantErr: `unsupported OTP type "hotp"`},
+		{name: "missing seed", uri: "otpauth://totp/test", wantErr: "secret must be a base32-encoded key"},
+		{name: "invalid seed", uri: "otpauth://totp/test?secret=0189", wantErr: "secret must be a base32-encoded key"},
+		{name: "algorithm", uri: "otpauth://totp/test?algorithm=MD5&secret=" + rfc6238Seed, wantErr: `unsupported OTP algorithm "MD5"`},
+		{name: "digits", uri: "otpauth://totp/test?digits=4&secret=" + rfc6238Seed, wantErr: `invalid OTP digits "4"`},
+		{name: "period zero", uri: "otpauth://totp/test?period=0&secret=" + rfc6238Seed, wantErr: `invalid OTP period "0"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := totpCode(tc.uri, time.Unix(tc.at, 0))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %q, %v", tc.wantErr, got, err)
+				}
+				if strings.Contains(err.Error(), rfc6238Seed) {
+					t.Errorf("expected the seed not to be part of the error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGopassClient_GetOTPURI(t *testing.T) {
+	uri := "otpauth://totp/example:admin?secret=" + rfc6238Seed
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{name: "body line", content: "hunter2\nusername: admin\n" + uri + "\n"},
+		{name: "key-value entry", content: "hunter2\notpauth: " + uri + "\n"},
+		{name: "password line", content: uri + "\n"},
+		{name: "short key-value entry", content: "hunter2\notpauth: //totp/example:admin?secret=" + rfc6238Seed + "\n"},
+		{name: "seed only", content: "hunter2\ntotp: " + rfc6238Seed + "\n", want: "otpauth://totp/gopass?secret=" + rfc6238Seed},
+		{name: "no uri", content: "hunter2\nusername: admin\n", wantErr: `secret "app/admin" has no OTP URI`},
+		{name: "missing", wantErr: "[GOPASS_SECRET_NOT_FOUND]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			if tc.content != "" {
+				store.secrets["app/admin"] = secrets.ParseAKV([]byte(tc.content))
+			}
+			client := NewGopassClient("")
+			client.store = store
+
+			got, err := client.GetOTPURI(context.Background(), "app/admin")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(errorDetail(err, err.Error()), tc.wantErr) {
+					t.Fatalf("expected error %q, got %q, %v", tc.wantErr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := uri
+			if tc.want != "" {
+				want = tc.want
+			}
+			if got != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		})
+	}
+}
+
+func TestOTPFunction_Metadata(t *testing.T) {
+	f := NewOTPFunction()
+	if f.(*OTPFunction).client != functionClient() {
+		t.Error("expected functions to share a client")
+	}
+
+	resp := &function.MetadataResponse{}
+	f.Metadata(context.Background(), function.MetadataRequest{}, resp)
+	if resp.Name != "otp" {
+		t.Errorf("expected name otp, got %q", resp.Name)
+	}
+
+	defResp := &function.DefinitionResponse{}
+	f.Definition(context.Background(), function.DefinitionRequest{}, defResp)
+	if len(defResp.Definition.Parameters) != 1 || defResp.Definition.Return == nil {
+		t.Errorf("unexpected definition %+v", defResp.Definition)
+	}
+}
+
+func TestOTPFunction_Run(t *testing.T) {
+	mountStores(t, "app")
+	now := timeNow
+	timeNow = func() time.Time { return time.Unix(59, 0) }
+	t.Cleanup(func() { timeNow = now })
+
+	tests := []struct {
+		name    string
+		arg     attr.Value
+		fail    string
+		want    string
+		wantErr string
+	}{
+		{name: "totp", arg: types.StringValue("app/admin"), want: "287082"},
+		{name: "mount", arg: types.StringValue("app:admin"), want: "287082"},
+		{name: "hotp", arg: types.StringValue("app/hotp"), wantErr: `Could not compute the OTP code of secret "app/hotp"`},
+		{name: "missing", arg: types.StringValue("app/api"), wantErr: "[GOPASS_SECRET_NOT_FOUND]"},
+		{name: "invalid path", arg: types.StringValue("app//admin"), wantErr: `[GOPASS_INVALID_CONFIG] Invalid path "app//admin"`},
+		{name: "wrong argument", arg: types.BoolValue(true), wantErr: "Value Conversion Error"},
+		{name: "store missing", arg: types.StringValue("app/admin"), fail: "store", wantErr: "[GOPASS_STORE_NOT_FOUND]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			store.secrets["app/admin"] = secrets.ParseAKV([]byte("hunter2\notpauth://totp/example:admin?secret=" + rfc6238Seed + "\n"))
+			store.secrets["app/hotp"] = secrets.ParseAKV([]byte("\notpauth://hotp/example:admin?counter=1&secret=" + rfc6238Seed + "\n"))
+			client := NewGopassClient("")
+			client.store = store
+			if tc.fail == "store" {
+				client = NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+			}
+			f := &OTPFunction{client: client}
+
+			resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+			f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData([]attr.Value{tc.arg})}, resp)
+
+			if tc.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Error)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %v", resp.Error)
+			}
+			if got := resp.Result.Value().(types.String).ValueString(); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource              = &OTPSecretResource{}
+	_ resource.ResourceWithConfigure = &OTPSecretResource{}
+)
+
+// OTPSecretResource stores an otpauth:// URI in gopass, readable with `gopass otp`.
+type OTPSecretResource struct {
+	client *GopassClient
+}
+
+// OTPSecretResourceModel describes the resource data model.
+type OTPSecretResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Path           types.String `tfsdk:"path"`
+	URIWO          types.String `tfsdk:"uri_wo"`
+	URIWOVersion   types.Int64  `tfsdk:"uri_wo_version"`
+	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
+}
+
+// NewOTPSecretResource creates a new instance.
+func NewOTPSecretResource() resource.Resource {
+	return &OTPSecretResource{}
+}
+
+func (r *OTPSecretResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_otp_secret"
+}
+
+func (r *OTPSecretResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Stores an otpauth:// URI (TOTP/HOTP seed) in the gopass store using a write-only attribute, " +
+			"in the format read by `gopass otp`.",
+		MarkdownDescription: `
+Stores an ` + "`otpauth://`" + ` URI (TOTP/HOTP seed) in the gopass store using a **write-only attribute**,
+in the format read by ` + "`gopass otp`" + ` and pass-otp.
+
+The URI is written as a line of the secret body. If a secret already exists at ` + "`path`" + `
+(e.g. a login entry), its password and other fields are kept and only the otpauth line is
+replaced. The URI is **never stored in Terraform state**.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_otp_secret" "admin" {
+  path           = "websites/idp/admin"
+  uri_wo         = idp_user_mfa.admin.otpauth_uri
+  uri_wo_version = 1
+}
+` + "```" + `
+
+Afterwards ` + "`gopass otp websites/idp/admin`" + ` prints the current code.
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path of the secret (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Path in the gopass store where the OTP URI will be written.",
+				MarkdownDescription: "Path in the gopass store where the OTP URI will be written (e.g., `websites/idp/admin`).",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"uri_wo": schema.StringAttribute{
+				Description: "The otpauth:// URI to write. This is a write-only attribute - " +
+					"it will never be stored in state or plan files. Accepts ephemeral values.",
+				MarkdownDescription: "The `otpauth://` URI to write. This is a **write-only** attribute - " +
+					"it will never be stored in state or plan files. Accepts ephemeral values.",
+				Required:  true,
+				Sensitive: true,
+				WriteOnly: true,
+			},
+			"uri_wo_version": schema.Int64Attribute{
+				Description:         "Version number for the write-only URI. Increment this to write a new uri_wo.",
+				MarkdownDescription: "Version number for the write-only URI. **Increment this** to write a new `uri_wo`.",
+				Optional:            true,
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description: "Whether to remove the OTP URI from gopass when the resource is destroyed. " +
+					"The secret itself is only deleted if nothing else is left in it. Defaults to true.",
+				MarkdownDescription: "Whether to remove the OTP URI from gopass when the resource is destroyed. " +
+					"The secret itself is only deleted if nothing else is left in it. Defaults to `true`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(true),
+			},
+		},
+	}
+}
+
+func (r *OTPSecretResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *OTPSecretResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data, config OTPSecretResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	// Write-only values are only available in config, not in the plan
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	if err := r.client.SetOTPSecret(ctx, secretPath, config.URIWO.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to create OTP secret",
+			errorDetail(err, fmt.Sprintf("Could not write OTP URI to gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Created gopass OTP secret", map[string]interface{}{
+		"path": secretPath,
+	})
+
+	data.ID = data.Path
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *OTPSecretResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data OTPSecretResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	found, err := r.client.HasOTPSecret(ctx, secretPath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read OTP secret",
+			errorDetail(err, fmt.Sprintf("Could not read secret from gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	if !found {
+		// The secret or its otpauth entry was removed outside of Terraform
+		tflog.Warn(ctx, "OTP secret no longer exists, removing from state", map[string]interface{}{
+			"path": secretPath,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update writes uri_wo again when uri_wo_version changes; path changes require replacement.
+//
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *OTPSecretResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data, state, config OTPSecretResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	if !data.URIWOVersion.IsNull() && !data.URIWOVersion.Equal(state.URIWOVersion) {
+		if err := r.client.SetOTPSecret(ctx, secretPath, config.URIWO.ValueString()); err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to update OTP secret",
+				errorDetail(err, fmt.Sprintf("Could not write OTP URI to gopass at %q: %s", secretPath, err.Error())),
+			)
+			return
+		}
+		tflog.Info(ctx, "Updated gopass OTP secret (uri_wo_version changed)", map[string]interface{}{
+			"path":        secretPath,
+			"new_version": data.URIWOVersion.ValueInt64(),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *OTPSecretResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data OTPSecretResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	if !data.DeleteOnRemove.ValueBool() {
+		tflog.Info(ctx, "Keeping gopass OTP secret (delete_on_remove=false)", map[string]interface{}{
+			"path": secretPath,
+		})
+		return
+	}
+
+	if err := r.client.RemoveOTPSecret(ctx, secretPath); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove OTP secret",
+			errorDetail(err, fmt.Sprintf("Could not remove OTP URI from gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass OTP secret", map[string]interface{}{
+		"path": secretPath,
+	})
+}
@@ -0,0 +1,269 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// otpValue builds a raw gopass_otp_secret object. A nil version is null.
+func otpValue(schemaResp resource.SchemaResponse, uri string, version any, deleteOnRemove bool) tftypes.Value {
+	values := map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "websites/idp"),
+		"path":             tftypes.NewValue(tftypes.String, "websites/idp"),
+		"uri_wo_version":   tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, deleteOnRemove),
+	}
+	if uri != "" {
+		values["uri_wo"] = tftypes.NewValue(tftypes.String, uri)
+	}
+	return schemaObjectValue(schemaResp.Schema, values)
+}
+
+func TestOTPSecretResource_Metadata(t *testing.T) {
+	r := NewOTPSecretResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_otp_secret" {
+		t.Errorf("expected 'gopass_otp_secret', got %q", resp.TypeName)
+	}
+}
+
+func TestOTPSecretResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &OTPSecretResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &OTPSecretResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestOTPSecretResource_Create(t *testing.T) {
+	mockStore := newMockStore()
+	r, schemaResp := testResource(&OTPSecretResource{client: clientWith(mockStore)})
+	// Write-only values are null in the plan and only present in config
+	plan := otpValue(schemaResp, "", 1, true)
+	config := otpValue(schemaResp, testOTPURI, 1, true)
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(context.Background(), resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+	}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	secret, exists := mockStore.secrets["websites/idp"]
+	if !exists || !hasOTPLine(secret) {
+		t.Errorf("expected OTP URI to be stored, got %v", secret)
+	}
+
+	var state OTPSecretResourceModel
+	resp.Diagnostics.Append(resp.State.Get(context.Background(), &state)...)
+	if !state.URIWO.IsNull() {
+		t.Error("expected uri_wo not to be stored in state")
+	}
+	if state.ID.ValueString() != "websites/idp" {
+		t.Errorf("expected id 'websites/idp', got %q", state.ID.ValueString())
+	}
+}
+
+func TestOTPSecretResource_Create_Errors(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp := testResource(&OTPSecretResource{client: clientWith(newMockStore())})
+
+	t.Run("invalid plan", func(t *testing.T) {
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(ctx, resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: invalidRaw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw},
+		}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from Plan.Get")
+		}
+	})
+
+	t.Run("invalid uri", func(t *testing.T) {
+		raw := otpValue(schemaResp, "not-a-uri", 1, true)
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(ctx, resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error for invalid OTP URI")
+		}
+	})
+}
+
+func TestOTPSecretResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		fail        bool
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "present", content: "\n" + testOTPURI},
+		{name: "uri removed externally", content: "password", wantRemoved: true},
+		{name: "secret removed externally", wantRemoved: true},
+		{name: "store failure", fail: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			r, schemaResp := testResource(&OTPSecretResource{client: clientWith(mockStore)})
+			if tc.content != "" {
+				mockStore.secrets["websites/idp"] = secrets.ParseAKV([]byte(tc.content))
+			}
+			mockStore.shouldFail = tc.fail
+			mockStore.failMsg = "decryption failed"
+			raw := otpValue(schemaResp, "", 1, true)
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if removed := resp.State.Raw.IsNull(); !tc.wantErr && removed != tc.wantRemoved {
+				t.Errorf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&OTPSecretResource{client: clientWith(newMockStore())})
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestOTPSecretResource_Update(t *testing.T) {
+	tests := []struct {
+		name         string
+		stateVersion any
+		planVersion  any
+		uri          string
+		wantWrite    bool
+		wantErr      bool
+	}{
+		{name: "version changed", stateVersion: 1, planVersion: 2, uri: testOTPURINew, wantWrite: true},
+		{name: "version added", stateVersion: nil, planVersion: 1, uri: testOTPURINew, wantWrite: true},
+		{name: "version unchanged", stateVersion: 1, planVersion: 1, uri: testOTPURINew},
+		{name: "version removed", stateVersion: 1, planVersion: nil, uri: testOTPURINew},
+		{name: "invalid uri", stateVersion: 1, planVersion: 2, uri: "not-a-uri", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			r, schemaResp := testResource(&OTPSecretResource{client: clientWith(mockStore)})
+			mockStore.secrets["websites/idp"] = secrets.ParseAKV([]byte("\n" + testOTPURI))
+			state := otpValue(schemaResp, "", tc.stateVersion, true)
+			plan := otpValue(schemaResp, "", tc.planVersion, true)
+			config := otpValue(schemaResp, tc.uri, tc.planVersion, true)
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			written := string(mockStore.secrets["websites/idp"].Bytes()) == "\n"+testOTPURINew+"\n"
+			if written != tc.wantWrite {
+				t.Errorf("expected write=%v, got %v", tc.wantWrite, written)
+			}
+		})
+	}
+
+	t.Run("invalid plan", func(t *testing.T) {
+		r, schemaResp := testResource(&OTPSecretResource{client: clientWith(newMockStore())})
+		resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Update(context.Background(), resource.UpdateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: invalidRaw},
+			State:  tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw},
+		}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from Plan.Get")
+		}
+	})
+}
+
+func TestOTPSecretResource_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteOnRemove bool
+		fail           bool
+		wantExists     bool
+		wantErr        bool
+	}{
+		{name: "remove", deleteOnRemove: true, wantExists: false},
+		{name: "keep", deleteOnRemove: false, wantExists: true},
+		{name: "store failure", deleteOnRemove: true, fail: true, wantExists: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			r, schemaResp := testResource(&OTPSecretResource{client: clientWith(mockStore)})
+			mockStore.secrets["websites/idp"] = secrets.ParseAKV([]byte("\n" + testOTPURI))
+			mockStore.shouldFail = tc.fail
+			mockStore.failMsg = "decryption failed"
+			raw := otpValue(schemaResp, "", 1, tc.deleteOnRemove)
+
+			resp := &resource.DeleteResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if _, exists := mockStore.secrets["websites/idp"]; exists != tc.wantExists {
+				t.Errorf("expected exists=%v, got %v", tc.wantExists, exists)
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&OTPSecretResource{client: clientWith(newMockStore())})
+		resp := &resource.DeleteResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
@@ -0,0 +1,44 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// pathComponentsDescription describes the path_components attribute of resources and data sources.
+const pathComponentsDescription = "The components of path, split at '/', e.g. [\"prod\", \"db\", \"password\"]. " +
+	"A path addressing a mounted store as 'mount:path' is split as the 'mount/path' it addresses."
+
+// pathComponentsMarkdownDescription is pathComponentsDescription with markdown formatting.
+const pathComponentsMarkdownDescription = "The components of `path`, split at `/`, e.g. `[\"prod\", \"db\", \"password\"]`. " +
+	"A path addressing a mounted store as `mount:path` is split as the `mount/path` it addresses."
+
+// pathComponents returns the components of the secret path p as a list value. A path
+// addressing a mount as "mount:path" must be resolved first.
+func pathComponents(p string) types.List {
+	parts := strings.Split(p, "/")
+	elements := make([]attr.Value, 0, len(parts))
+	for _, part := range parts {
+		elements = append(elements, types.StringValue(part))
+	}
+	return types.ListValueMust(types.StringType, elements)
+}
+
+// planPathComponents sets path_components from the planned path, so it is known at plan
+// time, e.g. to name other resources after the last component.
+func (r *SecretResource) planPathComponents(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var secretPath types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("path"), &secretPath)...)
+	if resp.Diagnostics.HasError() || secretPath.IsUnknown() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("path_components"), pathComponents(r.client.resolveMountPath(ctx, secretPath.ValueString())))...)
+}
@@ -0,0 +1,147 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// listStrings returns the elements of a list of strings.
+func listStrings(t *testing.T, list types.List) []string {
+	t.Helper()
+
+	var got []string
+	if diags := list.ElementsAs(context.Background(), &got, false); diags.HasError() {
+		t.Fatalf("failed to read list: %v", diags)
+	}
+	return got
+}
+
+func TestPathComponents(t *testing.T) {
+	mountStores(t, "work")
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "prod/db/password", want: []string{"prod", "db", "password"}},
+		{path: "api_key", want: []string{"api_key"}},
+		{path: "work:ci/token", want: []string{"work", "ci", "token"}},
+		{path: "db:5432", want: []string{"db:5432"}},
+		{path: "hosts/db:5432", want: []string{"hosts", "db:5432"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			if got := listStrings(t, pathComponents(NewGopassClient("").resolveMountPath(context.Background(), tc.path))); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("pathComponents(%q) = %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ModifyPlan_PathComponents(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        any
+		wantUnknown bool
+		want        []string
+	}{
+		{name: "known path", path: "prod/db/password", want: []string{"prod", "db", "password"}},
+		{name: "unknown path", path: tftypes.UnknownValue, wantUnknown: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretResource{client: NewGopassClient("")}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			plan := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, tc.path),
+				"path_components":  tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue),
+				"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+			})
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var got types.List
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("path_components"), &got)...)
+			if got.IsUnknown() != tc.wantUnknown {
+				t.Fatalf("expected path_components unknown=%v, got %v", tc.wantUnknown, got)
+			}
+			if !tc.wantUnknown && !reflect.DeepEqual(listStrings(t, got), tc.want) {
+				t.Errorf("expected %q, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Read_PathComponents(t *testing.T) {
+	r, schemaResp := testResource(&SecretResource{client: clientWith(storeWith(map[string]string{"app/key": "value"}))})
+	// State written before path_components existed has none
+	raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/key"),
+		"path":             tftypes.NewValue(tftypes.String, "app/key"),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+	})
+
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+	r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if got := listStrings(t, data.PathComponents); !reflect.DeepEqual(got, []string{"app", "key"}) {
+		t.Errorf("expected [app key], got %q", got)
+	}
+}
+
+func TestSecretResource_ImportState_PathComponents(t *testing.T) {
+	r, schemaResp := testResource(&SecretResource{client: clientWith(storeWith(map[string]string{"app/key": "value"}))})
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "app/key"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if got := listStrings(t, data.PathComponents); !reflect.DeepEqual(got, []string{"app", "key"}) {
+		t.Errorf("expected [app key], got %q", got)
+	}
+}
+
+func TestSecretInfoDataSource_Read_PathComponents(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+
+	// Also set for secrets that do not exist yet
+	resp, data := readSecretInfo(t, client, "prod/db/password")
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if got := listStrings(t, data.PathComponents); !reflect.DeepEqual(got, []string{"prod", "db", "password"}) {
+		t.Errorf("expected [prod db password], got %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// validateSecretPath checks that p is a well-formed gopass secret path.
+// gopass would reject or silently normalize such paths only when the secret is
+// accessed, so catching them early gives clearer errors at plan time.
+// Paths in "mount:path" form are checked as the "mount/path" they address.
+func validateSecretPath(p string) error {
+	if p == "" {
+		return errors.New("path must not be empty")
+	}
+	if mount, rest, ok := splitMountPath(p); ok {
+		p = joinMountPath(mount, rest)
+	}
+	if strings.HasPrefix(p, "/") || strings.HasSuffix(p, "/") {
+		return errors.New("path must not start or end with a slash")
+	}
+	for _, r := range p {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("path must not contain control characters (found %U)", r)
+		}
+	}
+	for _, segment := range strings.Split(p, "/") {
+		switch segment {
+		case "":
+			return errors.New("path must not contain empty components (\"//\")")
+		case ".", "..":
+			return fmt.Errorf("path must not contain %q components", segment)
+		}
+	}
+	return nil
+}
+
+// secretPathValidator validates string attributes holding gopass secret paths.
+type secretPathValidator struct{}
+
+// validSecretPath returns a validator rejecting malformed gopass secret paths.
+func validSecretPath() validator.String {
+	return secretPathValidator{}
+}
+
+func (v secretPathValidator) Description(ctx context.Context) string {
+	return "path must not start or end with a slash and must not contain empty, '.' or '..' components or control characters"
+}
+
+func (v secretPathValidator) MarkdownDescription(ctx context.Context) string {
+	return "path must not start or end with `/` and must not contain empty, `.` or `..` components or control characters"
+}
+
+func (v secretPathValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if err := validateSecretPath(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid secret path",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("The value %q is not a valid gopass path: %s.", req.ConfigValue.ValueString(), err.Error())),
+		)
+	}
+}
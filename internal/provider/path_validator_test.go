@@ -0,0 +1,112 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestValidateSecretPath(t *testing.T) {
+	testCases := []struct {
+		path    string
+		wantErr bool
+	}{
+		{path: "secret", wantErr: false},
+		{path: "env/app/API/v2/KEY", wantErr: false},
+		{path: "with spaces/and-dashes_and.dots", wantErr: false},
+		{path: "", wantErr: true},
+		{path: "/leading", wantErr: true},
+		{path: "trailing/", wantErr: true},
+		{path: "a//b", wantErr: true},
+		{path: "a/../b", wantErr: true},
+		{path: "a/./b", wantErr: true},
+		{path: "..", wantErr: true},
+		{path: "a/b\nc", wantErr: true},
+		{path: "tab\there", wantErr: true},
+		{path: "work:db/password", wantErr: false},
+		{path: "work:", wantErr: false},
+		{path: "hosts/db:5432", wantErr: false},
+		{path: "work:/db", wantErr: true},
+		{path: "work:db/", wantErr: true},
+		{path: "work:../db", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			err := validateSecretPath(tc.path)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateSecretPath(%q) error = %v, wantErr %v", tc.path, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretPathValidator_ValidateString(t *testing.T) {
+	ctx := context.Background()
+	v := validSecretPath()
+
+	testCases := []struct {
+		name    string
+		value   types.String
+		wantErr bool
+	}{
+		{name: "valid", value: types.StringValue("a/b"), wantErr: false},
+		{name: "invalid", value: types.StringValue("a/../b"), wantErr: true},
+		{name: "null", value: types.StringNull(), wantErr: false},
+		{name: "unknown", value: types.StringUnknown(), wantErr: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := validator.StringRequest{Path: path.Root("path"), ConfigValue: tc.value}
+			resp := &validator.StringResponse{}
+			v.ValidateString(ctx, req, resp)
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+
+	if v.Description(ctx) == "" || v.MarkdownDescription(ctx) == "" {
+		t.Error("expected non-empty descriptions")
+	}
+}
+
+func TestSchemas_PathValidator(t *testing.T) {
+	ctx := context.Background()
+
+	resourceSchema := &resource.SchemaResponse{}
+	NewSecretResource().Schema(ctx, resource.SchemaRequest{}, resourceSchema)
+
+	secretSchema := &ephemeral.SchemaResponse{}
+	NewSecretEphemeralResource().Schema(ctx, ephemeral.SchemaRequest{}, secretSchema)
+
+	envSchema := &ephemeral.SchemaResponse{}
+	NewEnvEphemeralResource().Schema(ctx, ephemeral.SchemaRequest{}, envSchema)
+
+	validators := map[string][]validator.String{
+		"gopass_secret resource":  resourceSchema.Schema.Attributes["path"].(interface{ StringValidators() []validator.String }).StringValidators(),
+		"gopass_secret ephemeral": secretSchema.Schema.Attributes["path"].(interface{ StringValidators() []validator.String }).StringValidators(),
+		"gopass_env ephemeral":    envSchema.Schema.Attributes["path"].(interface{ StringValidators() []validator.String }).StringValidators(),
+	}
+
+	for name, vs := range validators {
+		found := false
+		for _, v := range vs {
+			if _, ok := v.(secretPathValidator); ok {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s: expected path attribute to use the secret path validator", name)
+		}
+	}
+}
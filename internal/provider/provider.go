@@ -5,12 +5,17 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -18,6 +23,7 @@ import (
 var (
 	_ provider.Provider                       = &GopassProvider{}
 	_ provider.ProviderWithEphemeralResources = &GopassProvider{}
+	_ provider.ProviderWithFunctions          = &GopassProvider{}
 )
 
 // GopassProvider defines the provider implementation.
@@ -27,7 +33,30 @@ type GopassProvider struct {
 
 // GopassProviderModel describes the provider data model.
 type GopassProviderModel struct {
-	StorePath types.String `tfsdk:"store_path"`
+	StorePath           types.String `tfsdk:"store_path"`
+	StorePaths          types.List   `tfsdk:"store_paths"`
+	NotFoundPatterns    types.List   `tfsdk:"not_found_patterns"`
+	Mode                types.String `tfsdk:"mode"`
+	ExtraArgs           types.List   `tfsdk:"extra_args"`
+	PinentryMode        types.String `tfsdk:"pinentry_mode"`
+	PassphraseEnv       types.String `tfsdk:"passphrase_env"`
+	ValidateOnConfigure types.Bool   `tfsdk:"validate_on_configure"`
+	ValidateSecret      types.String `tfsdk:"validate_secret"`
+	PreflightWriteCheck types.Bool   `tfsdk:"preflight_write_check"`
+	PreflightWritePath  types.String `tfsdk:"preflight_write_path"`
+	RevisionTracking    types.String `tfsdk:"revision_tracking"`
+	AuditLogPath        types.String `tfsdk:"audit_log_path"`
+	AutoInit            types.Bool   `tfsdk:"auto_init"`
+	AutoInitRecipients  types.List   `tfsdk:"auto_init_recipients"`
+	MetricsSummary      types.Bool   `tfsdk:"metrics_summary"`
+	DisableCache        types.Bool   `tfsdk:"disable_cache"`
+	LogPaths            types.Bool   `tfsdk:"log_paths"`
+	GitRemote           types.String `tfsdk:"git_remote"`
+	CloneDir            types.String `tfsdk:"clone_dir"`
+	LockTimeout         types.String `tfsdk:"lock_timeout"`
+	PathPrefix          types.String `tfsdk:"path_prefix"`
+	ProtectedPaths      types.List   `tfsdk:"protected_paths"`
+	PasswordPolicy      types.Object `tfsdk:"password_policy"`
 }
 
 // New creates a new provider instance.
@@ -53,6 +82,8 @@ The gopass provider enables reading secrets from a [gopass](https://github.com/g
 password store as **ephemeral values**.
 
 This provider links directly against the gopass library - no subprocess spawning required.
+Set ` + "`mode = \"cli\"`" + ` to execute the ` + "`gopass`" + ` binary instead, e.g. to honor on-disk configs,
+pinentry setups or plugins the library does not support.
 
 Ephemeral values are:
 - Only available during plan/apply execution
@@ -94,6 +125,187 @@ provider "postgresql" {
 					"configuration from `~/.config/gopass/config` or the `PASSWORD_STORE_DIR` environment variable.",
 				Optional: true,
 			},
+			"store_paths": schema.ListAttribute{
+				Description: "Paths of several password stores, in priority order, instead of store_path. " +
+					"A secret is read from the first store that has it, like pass does with a colon-separated " +
+					"PASSWORD_STORE_DIR. Secrets are written to the first store. Conflicts with store_path.",
+				MarkdownDescription: "Paths of several password stores, in priority order, instead of `store_path`. " +
+					"A secret is read from the first store that has it, like pass does with a colon-separated " +
+					"`PASSWORD_STORE_DIR`. Secrets are written to the first store. Conflicts with `store_path`.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"git_remote": schema.StringAttribute{
+				Description: "URL of a git-backed store to clone when the provider is configured, e.g. on a CI runner " +
+					"without a checkout of the store. Commits not pushed by gopass are pushed after each resource change. " +
+					"Conflicts with store_path and store_paths.",
+				MarkdownDescription: "URL of a git-backed store to clone when the provider is configured, e.g. on a CI runner " +
+					"without a checkout of the store. Commits not pushed by gopass are pushed after each resource change. " +
+					"Conflicts with `store_path` and `store_paths`.",
+				Optional: true,
+			},
+			"clone_dir": schema.StringAttribute{
+				Description: "Directory to clone git_remote into. An existing clone is pulled instead. " +
+					"Defaults to a temporary directory, removed when the provider shuts down unless it holds commits that could not be pushed.",
+				MarkdownDescription: "Directory to clone `git_remote` into. An existing clone is pulled instead. " +
+					"Defaults to a temporary directory, removed when the provider shuts down unless it holds commits that could not be pushed.",
+				Optional: true,
+			},
+			"not_found_patterns": schema.ListAttribute{
+				Description: "Additional error message substrings (case-insensitive) that mean a secret does not exist, " +
+					"e.g. localized messages or those of custom storage backends. " +
+					"The messages of gopass and its built-in backends are always recognized.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"mode": schema.StringAttribute{
+				Description: "How the provider accesses the store: 'library' (default) links the gopass library, " +
+					"'cli' executes the gopass binary from PATH for every operation.",
+				MarkdownDescription: "How the provider accesses the store: `library` (default) links the gopass library, " +
+					"`cli` executes the `gopass` binary from `PATH` for every operation. " +
+					"Use `cli` if your setup depends on gopass configs, pinentry programs or plugins the library does not honor.",
+				Optional: true,
+			},
+			"extra_args": schema.ListAttribute{
+				Description: "Arguments passed to the gopass binary before every subcommand in CLI mode, e.g. ['--yes']. " +
+					"They show up in process listings, so they must not contain secrets.",
+				MarkdownDescription: "Arguments passed to the `gopass` binary before every subcommand in CLI mode, e.g. `[\"--yes\"]`. " +
+					"They show up in process listings, so they must not contain secrets. Requires `mode = \"cli\"`.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"pinentry_mode": schema.StringAttribute{
+				Description: "How GPG gets the passphrase of the store keys: 'default' asks gpg-agent, which prompts with pinentry, " +
+					"'loopback' passes the passphrase from the environment variable named by passphrase_env, " +
+					"for CI runners without a terminal or pinentry program. Not supported on Windows.",
+				MarkdownDescription: "How GPG gets the passphrase of the store keys: `default` asks gpg-agent, which prompts with pinentry, " +
+					"`loopback` passes the passphrase from the environment variable named by `passphrase_env`, " +
+					"for CI runners without a terminal or pinentry program. " +
+					"The passphrase is passed through a named pipe only the current user can read and is never written to disk. " +
+					"Not supported on Windows.",
+				Optional: true,
+			},
+			"passphrase_env": schema.StringAttribute{
+				Description: "Environment variable holding the GPG passphrase when pinentry_mode is 'loopback'. " +
+					"Defaults to '" + defaultPassphraseEnv + "'.",
+				MarkdownDescription: "Environment variable holding the GPG passphrase when `pinentry_mode` is `loopback`. " +
+					"Defaults to `" + defaultPassphraseEnv + "`.",
+				Optional: true,
+			},
+			"validate_on_configure": schema.BoolAttribute{
+				Description: "Initialize the store when the provider is configured instead of on first use, " +
+					"so a misconfigured store or GPG agent fails at plan time. Defaults to false.",
+				MarkdownDescription: "Initialize the store when the provider is configured instead of on first use, " +
+					"so a misconfigured store or GPG agent fails at plan time. Defaults to `false`.",
+				Optional: true,
+			},
+			"validate_secret": schema.StringAttribute{
+				Description: "Path of a secret to decrypt as a test when validate_on_configure is true. " +
+					"Catches missing keys or an unreachable hardware token before any resource needs them.",
+				MarkdownDescription: "Path of a secret to decrypt as a test when `validate_on_configure` is `true`. " +
+					"Catches missing keys or an unreachable hardware token before any resource needs them.",
+				Optional: true,
+			},
+			"preflight_write_check": schema.BoolAttribute{
+				Description: "Check that the store accepts new files when the provider is configured, without writing a secret, " +
+					"so a store without write access fails at plan time instead of in the middle of an apply. Defaults to false.",
+				MarkdownDescription: "Check that the store accepts new files when the provider is configured, without writing a secret, " +
+					"so a store without write access fails at plan time instead of in the middle of an apply. Defaults to `false`.",
+				Optional: true,
+			},
+			"preflight_write_path": schema.StringAttribute{
+				Description: "Store folder preflight_write_check checks. " +
+					"Defaults to '" + defaultPreflightWritePath + "'.",
+				MarkdownDescription: "Store folder `preflight_write_check` checks. " +
+					"Defaults to `" + defaultPreflightWritePath + "`.",
+				Optional: true,
+			},
+			"revision_tracking": schema.StringAttribute{
+				Description:         revisionTrackingDescription + " Applies to gopass_secret resources that do not set their own. Defaults to 'auto'.",
+				MarkdownDescription: revisionTrackingMarkdownDescription + " Applies to `gopass_secret` resources that do not set their own. Defaults to `auto`.",
+				Optional:            true,
+			},
+			"audit_log_path": schema.StringAttribute{
+				Description: "File to append a JSON line to for every get, set and remove of a secret: " +
+					"time, operation, path and outcome, never values. Created with mode 0600 if missing.",
+				MarkdownDescription: "File to append a JSON line to for every get, set and remove of a secret: " +
+					"`time`, `operation`, `path` and `outcome`, never values. Created with mode `0600` if missing.",
+				Optional: true,
+			},
+			"auto_init": schema.BoolAttribute{
+				Description: "Initialize the store for auto_init_recipients on first use if its directory exists but is empty, " +
+					"e.g. in a fresh CI workspace, instead of failing because it is not initialized. Defaults to false.",
+				MarkdownDescription: "Initialize the store for `auto_init_recipients` on first use if its directory exists but is empty, " +
+					"e.g. in a fresh CI workspace, instead of failing because it is not initialized. Defaults to `false`.",
+				Optional: true,
+			},
+			"auto_init_recipients": schema.ListAttribute{
+				Description:         "GPG key IDs or fingerprints an empty store is initialized for when auto_init is true.",
+				MarkdownDescription: "GPG key IDs or fingerprints an empty store is initialized for when `auto_init` is `true`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"log_paths": schema.BoolAttribute{
+				Description: "Whether secret paths appear in the provider's log output. If false, the fields holding paths " +
+					"and the quoted parts of log messages and errors are logged as '***'. Diagnostics are not affected. Defaults to true.",
+				MarkdownDescription: "Whether secret paths appear in the provider's log output. If `false`, the fields holding paths " +
+					"and the quoted parts of log messages and errors are logged as `***`. Diagnostics are not affected. Defaults to `true`.",
+				Optional: true,
+			},
+			"lock_timeout": schema.StringAttribute{
+				Description: "Lock the store on the first write for the rest of the run, so concurrent Terraform runs " +
+					"writing to the same store do not interleave their writes, and wait this long for another run " +
+					"to release it (e.g. '0s' or '5m'). The lock is advisory and not honored by gopass itself. " +
+					"Defaults to no lock.",
+				MarkdownDescription: "Lock the store on the first write for the rest of the run, so concurrent Terraform runs " +
+					"writing to the same store do not interleave their writes, and wait this long for another run " +
+					"to release it (e.g. `\"0s\"` or `\"5m\"`). The lock is advisory and not honored by gopass itself. " +
+					"Defaults to no lock.",
+				Optional: true,
+			},
+			"path_prefix": schema.StringAttribute{
+				Description: "Folder all secret paths of resources, data sources and ephemeral resources are relative to, " +
+					"e.g. 'terraform/${terraform.workspace}' to keep the secrets of each workspace apart. " +
+					"Paths in state and returned by listings stay relative to it. Defaults to the root of the store.",
+				MarkdownDescription: "Folder all secret paths of resources, data sources and ephemeral resources are relative to, " +
+					"e.g. `\"terraform/${terraform.workspace}\"` to keep the secrets of each workspace apart. " +
+					"Paths in state and returned by listings stay relative to it. Defaults to the root of the store.",
+				Optional: true,
+			},
+			"protected_paths": schema.ListAttribute{
+				Description: "Glob patterns of secrets the provider never removes, e.g. 'prod/**'. Destroying a resource " +
+					"that would remove a matching secret fails, also with delete_on_remove = true. '*' matches within " +
+					"a path component, '**' across components.",
+				MarkdownDescription: "Glob patterns of secrets the provider never removes, e.g. `\"prod/**\"`. Destroying a resource " +
+					"that would remove a matching secret fails, also with `delete_on_remove = true`. `*` matches within " +
+					"a path component, `**` across components.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"password_policy": passwordPolicyAttribute(),
+			"metrics_summary": schema.BoolAttribute{
+				Description: "Log a summary of store reads, writes, failures, waits for the write lock and the total " +
+					"decryption time of the run at INFO level whenever the provider is idle, e.g. to diagnose slow plans. " +
+					"Nothing is sent anywhere. Defaults to false.",
+				MarkdownDescription: "Log a summary of store reads, writes, failures, waits for the write lock and the total " +
+					"decryption time of the run at `INFO` level whenever the provider is idle, e.g. to diagnose slow plans. " +
+					"Nothing is sent anywhere. Defaults to `false`.",
+				Optional: true,
+			},
+			"disable_cache": schema.BoolAttribute{
+				Description: "Decrypt a secret every time it is read, instead of keeping decrypted secrets in memory " +
+					"for the lifetime of the provider process. By default, a path referenced by several blocks or " +
+					"modules is decrypted only once, e.g. to avoid a hardware token touch per read. Renewals of " +
+					"ephemeral resources always decrypt again. Defaults to false.",
+				MarkdownDescription: "Decrypt a secret every time it is read, instead of keeping decrypted secrets in memory " +
+					"for the lifetime of the provider process. By default, a path referenced by several blocks or " +
+					"modules is decrypted only once, e.g. to avoid a hardware token touch per read. Renewals of " +
+					"ephemeral resources always decrypt again. Defaults to `false`.",
+				Optional: true,
+			},
 		},
 	}
 }
@@ -112,8 +324,284 @@ func (p *GopassProvider) Configure(ctx context.Context, req provider.ConfigureRe
 		storePath = config.StorePath.ValueString()
 	}
 
-	// Create gopass client - uses native gopass library
+	// Of several store paths, the first is the store path and the others are searched after it
+	var lookupPaths []string
+	if !config.StorePaths.IsNull() && !config.StorePaths.IsUnknown() {
+		var storePaths []string
+		resp.Diagnostics.Append(config.StorePaths.ElementsAs(ctx, &storePaths, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !config.StorePath.IsNull() || len(storePaths) == 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("store_paths"),
+				"Invalid store_paths",
+				codedDetail(CodeInvalidConfig, "store_paths must list at least one store and cannot be combined with store_path."),
+			)
+			return
+		}
+		storePath, lookupPaths = storePaths[0], storePaths[1:]
+	}
+
+	if !config.CloneDir.IsNull() && config.GitRemote.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("clone_dir"),
+			"Missing git_remote",
+			codedDetail(CodeInvalidConfig, "clone_dir is the directory git_remote is cloned into. Set git_remote, or remove clone_dir."),
+		)
+		return
+	}
+	if !config.GitRemote.IsNull() {
+		if !config.StorePath.IsNull() || !config.StorePaths.IsNull() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("git_remote"),
+				"Conflicting git_remote and store_path",
+				codedDetail(CodeInvalidConfig, "The store of git_remote is cloned into clone_dir. Remove store_path and store_paths, or git_remote."),
+			)
+			return
+		}
+		if config.GitRemote.IsUnknown() || config.CloneDir.IsUnknown() {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("git_remote"),
+				"Unknown git_remote",
+				codedDetail(CodeInvalidConfig, "git_remote and clone_dir must be known when the provider is configured, "+
+					"as the store is cloned before any secret is read."),
+			)
+			return
+		}
+		storePath = config.CloneDir.ValueString()
+	}
+
+	mode := modeLibrary
+	if !config.Mode.IsNull() && !config.Mode.IsUnknown() {
+		mode = config.Mode.ValueString()
+	}
+	if mode != modeLibrary && mode != modeCLI {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("mode"),
+			"Invalid mode",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("mode must be %q or %q, got %q.", modeLibrary, modeCLI, mode)),
+		)
+		return
+	}
+
+	var extraArgs []string
+	if !config.ExtraArgs.IsNull() && !config.ExtraArgs.IsUnknown() {
+		resp.Diagnostics.Append(config.ExtraArgs.ElementsAs(ctx, &extraArgs, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := validateExtraArgs(mode, extraArgs); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("extra_args"),
+				"Invalid extra_args",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("extra_args cannot be used: %s.", err.Error())),
+			)
+			return
+		}
+	}
+
+	passphraseEnv, err := loopbackPassphraseEnv(config.PinentryMode.ValueString(), config.PassphraseEnv.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("pinentry_mode"),
+			"Invalid pinentry_mode",
+			codedDetail(CodeInvalidConfig, err.Error()+"."),
+		)
+		return
+	}
+
+	validateRevisionTracking(&resp.Diagnostics, path.Root("revision_tracking"), config.RevisionTracking)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Create gopass client - uses native gopass library unless cli mode is requested
 	client := NewGopassClient(storePath)
+	if !config.LogPaths.IsNull() && !config.LogPaths.IsUnknown() {
+		client.SetLogPaths(config.LogPaths.ValueBool())
+	}
+	ctx = client.logContext(ctx)
+
+	if len(lookupPaths) > 0 {
+		client.SetLookupStores(lookupPaths)
+	}
+	if mode == modeCLI {
+		client.UseCLI(defaultGopassBinary, extraArgs)
+	}
+	if passphraseEnv != "" {
+		client.SetPinentryLoopback(passphraseEnv)
+	}
+	if !config.RevisionTracking.IsNull() && !config.RevisionTracking.IsUnknown() {
+		client.SetRevisionTracking(config.RevisionTracking.ValueString())
+	}
+
+	if !config.NotFoundPatterns.IsNull() && !config.NotFoundPatterns.IsUnknown() {
+		var patterns []string
+		resp.Diagnostics.Append(config.NotFoundPatterns.ElementsAs(ctx, &patterns, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		client.AddNotFoundPatterns(patterns...)
+	}
+
+	// Unknown values are only known at apply, when the provider is configured again
+	if !config.AutoInit.IsUnknown() && !config.AutoInitRecipients.IsUnknown() &&
+		(config.AutoInit.ValueBool() || !config.AutoInitRecipients.IsNull()) {
+		var recipients []string
+		resp.Diagnostics.Append(config.AutoInitRecipients.ElementsAs(ctx, &recipients, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !config.AutoInit.ValueBool() || len(recipients) == 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("auto_init"),
+				"Incomplete auto_init configuration",
+				codedDetail(CodeInvalidConfig, "auto_init = true and a non-empty auto_init_recipients must be set together."),
+			)
+			return
+		}
+		client.EnableAutoInit(recipients)
+	}
+
+	if !config.AuditLogPath.IsNull() && !config.AuditLogPath.IsUnknown() {
+		if err := client.EnableAuditLog(config.AuditLogPath.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("audit_log_path"),
+				"Invalid audit_log_path",
+				errorDetail(err, fmt.Sprintf("Could not open the audit log: %s", err.Error())),
+			)
+			return
+		}
+	}
+
+	if config.MetricsSummary.ValueBool() {
+		client.EnableMetrics()
+	}
+
+	if !config.DisableCache.ValueBool() {
+		client.EnableCache()
+	}
+
+	if !config.LockTimeout.IsNull() && !config.LockTimeout.IsUnknown() {
+		timeout, err := time.ParseDuration(config.LockTimeout.ValueString())
+		if err != nil || timeout < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("lock_timeout"),
+				"Invalid lock_timeout",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("lock_timeout must be a non-negative duration such as \"0s\" or \"5m\", got %q.", config.LockTimeout.ValueString())),
+			)
+			return
+		}
+		client.SetLockTimeout(timeout)
+	}
+
+	if !config.PathPrefix.IsNull() && !config.PathPrefix.IsUnknown() {
+		if err := validateSecretPath(config.PathPrefix.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("path_prefix"),
+				"Invalid path_prefix",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("path_prefix is not a valid folder: %s.", err.Error())),
+			)
+			return
+		}
+		client.SetPathPrefix(ctx, config.PathPrefix.ValueString())
+	}
+
+	if !config.ProtectedPaths.IsNull() && !config.ProtectedPaths.IsUnknown() {
+		var patterns []string
+		resp.Diagnostics.Append(config.ProtectedPaths.ElementsAs(ctx, &patterns, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		for _, pattern := range patterns {
+			if err := validateGlob(pattern); err != nil {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("protected_paths"),
+					"Invalid protected_paths",
+					codedDetail(CodeInvalidConfig, fmt.Sprintf("Pattern %q is not a valid glob: %s.", pattern, err.Error())),
+				)
+				return
+			}
+		}
+		client.SetProtectedPaths(patterns)
+	}
+
+	if !config.PasswordPolicy.IsNull() && !config.PasswordPolicy.IsUnknown() {
+		policy, diags := passwordPolicy(ctx, config.PasswordPolicy)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := client.SetPasswordPolicy(policy); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("password_policy"),
+				"Invalid password_policy",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("password_policy cannot be used: %s.", err.Error())),
+			)
+			return
+		}
+	}
+
+	if !config.ValidateSecret.IsNull() && !config.ValidateOnConfigure.ValueBool() && !config.ValidateOnConfigure.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("validate_secret"),
+			"Missing validate_on_configure",
+			codedDetail(CodeInvalidConfig, "validate_secret is only used when the store is validated. Set validate_on_configure = true, or remove validate_secret."),
+		)
+		return
+	}
+
+	if !config.PreflightWritePath.IsNull() && !config.PreflightWriteCheck.ValueBool() && !config.PreflightWriteCheck.IsUnknown() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("preflight_write_path"),
+			"Missing preflight_write_check",
+			codedDetail(CodeInvalidConfig, "preflight_write_path is only used when write access is checked. Set preflight_write_check = true, or remove preflight_write_path."),
+		)
+		return
+	}
+
+	preflightWritePath := ""
+	if config.PreflightWriteCheck.ValueBool() && !config.PreflightWritePath.IsUnknown() {
+		preflightWritePath = defaultPreflightWritePath
+		if !config.PreflightWritePath.IsNull() {
+			preflightWritePath = config.PreflightWritePath.ValueString()
+		}
+		if err := validateSecretPath(preflightWritePath); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("preflight_write_path"),
+				"Invalid preflight_write_path",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("preflight_write_path is not a valid folder: %s.", err.Error())),
+			)
+			return
+		}
+	}
+
+	// The store is cloned once the whole configuration is valid, so an invalid one leaves no clone behind
+	if !config.GitRemote.IsNull() {
+		if err := client.CloneStore(ctx, config.GitRemote.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("git_remote"),
+				"Failed to clone store",
+				errorDetail(err, fmt.Sprintf("Could not clone git_remote: %s", err.Error())),
+			)
+			return
+		}
+	}
+
+	if config.ValidateOnConfigure.ValueBool() {
+		if err := client.CheckStore(ctx, config.ValidateSecret.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Invalid gopass store", errorDetail(err, err.Error()))
+			return
+		}
+	}
+
+	if preflightWritePath != "" {
+		if err := client.CheckWritable(ctx, preflightWritePath); err != nil {
+			resp.Diagnostics.AddError("gopass store is not writable", errorDetail(err, err.Error()))
+			return
+		}
+	}
 
 	// Make client available to data sources, resources, and ephemeral resources
 	resp.DataSourceData = client
@@ -125,12 +613,32 @@ func (p *GopassProvider) Configure(ctx context.Context, req provider.ConfigureRe
 func (p *GopassProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewSecretResource,
+		NewStoreInitResource,
+		NewOTPSecretResource,
+		NewSecretCopyResource,
+		NewTemplateResource,
+		NewGitRemoteResource,
+		NewEnvResource,
+		NewJSONSecretResource,
+		NewSecretRotationResource,
+		NewDirectoryResource,
+		NewConfigResource,
+		NewSecretTreeResource,
 	}
 }
 
-// DataSources returns an empty slice - this provider only provides ephemeral resources.
+// DataSources returns the data sources this provider offers.
+// Data sources only expose store metadata, never secret values.
 func (p *GopassProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewRecipientsDataSource,
+		NewSecretInfoDataSource,
+		NewSecretRevisionsDataSource,
+		NewSecretsDataSource,
+		NewStoreInfoDataSource,
+		NewStoreStatsDataSource,
+		NewTreeDataSource,
+	}
 }
 
 // EphemeralResources returns the ephemeral resources this provider offers.
@@ -138,5 +646,17 @@ func (p *GopassProvider) EphemeralResources(ctx context.Context) []func() epheme
 	return []func() ephemeral.EphemeralResource{
 		NewSecretEphemeralResource,
 		NewEnvEphemeralResource,
+		NewDotenvEphemeralResource,
+		NewKubernetesSecretEphemeralResource,
+		NewSecretTreeEphemeralResource,
+	}
+}
+
+// Functions returns the provider-defined functions this provider offers.
+func (p *GopassProvider) Functions(ctx context.Context) []func() function.Function {
+	return []func() function.Function{
+		NewEnvFunction,
+		NewExistsFunction,
+		NewOTPFunction,
 	}
 }
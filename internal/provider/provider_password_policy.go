@@ -0,0 +1,69 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// PasswordPolicyModel describes the password_policy block of the provider configuration.
+type PasswordPolicyModel struct {
+	MinLength        types.Int64 `tfsdk:"min_length"`
+	RequiredClasses  types.List  `tfsdk:"required_classes"`
+	BannedSubstrings types.List  `tfsdk:"banned_substrings"`
+}
+
+// passwordPolicyAttribute returns the password_policy attribute of the provider.
+func passwordPolicyAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Requirements every value written as the password of a secret must meet, whichever resource writes it. " +
+			"Values violating them are rejected before they reach the store, e.g. placeholders like 'REPLACE_ME'.",
+		MarkdownDescription: "Requirements every value written as the password of a secret must meet, whichever resource writes it. " +
+			"Values violating them are rejected with `GOPASS_POLICY_VIOLATION` before they reach the store, " +
+			"e.g. placeholders like `REPLACE_ME`.",
+		Optional: true,
+		Attributes: map[string]schema.Attribute{
+			"min_length": schema.Int64Attribute{
+				Description: "Minimum number of characters.",
+				Optional:    true,
+			},
+			"required_classes": schema.ListAttribute{
+				Description: "Classes of characters that must occur: 'lower', 'upper', 'digit' or 'symbol'.",
+				MarkdownDescription: "Classes of characters that must occur: `lower`, `upper`, `digit` or `symbol` " +
+					"(punctuation and other symbols).",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"banned_substrings": schema.ListAttribute{
+				Description:         "Substrings that must not occur, compared case-insensitively, e.g. ['REPLACE_ME', 'changeme'].",
+				MarkdownDescription: "Substrings that must not occur, compared case-insensitively, e.g. `[\"REPLACE_ME\", \"changeme\"]`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+		},
+	}
+}
+
+// passwordPolicy converts a configured password_policy object into a PasswordPolicy.
+func passwordPolicy(ctx context.Context, policy types.Object) (PasswordPolicy, diag.Diagnostics) {
+	var model PasswordPolicyModel
+	diags := policy.As(ctx, &model, basetypes.ObjectAsOptions{})
+	if diags.HasError() {
+		return PasswordPolicy{}, diags
+	}
+
+	result := PasswordPolicy{MinLength: int(model.MinLength.ValueInt64())}
+	if !model.RequiredClasses.IsNull() {
+		diags.Append(model.RequiredClasses.ElementsAs(ctx, &result.RequiredClasses, false)...)
+	}
+	if !model.BannedSubstrings.IsNull() {
+		diags.Append(model.BannedSubstrings.ElementsAs(ctx, &result.BannedSubstrings, false)...)
+	}
+	return result, diags
+}
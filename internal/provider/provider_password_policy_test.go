@@ -0,0 +1,21 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestPasswordPolicy_UnexpectedAttributes(t *testing.T) {
+	policy := types.ObjectValueMust(map[string]attr.Type{"other": types.StringType}, map[string]attr.Value{
+		"other": types.StringValue("x"),
+	})
+	if _, diags := passwordPolicy(context.Background(), policy); !diags.HasError() {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ tfprotov6.ProviderServer = &providerServer{}
+
+// providerServer serves the provider over protocol version 6, like the server of the
+// framework it wraps, and does the work that has to happen within the RPCs of a run.
+type providerServer struct {
+	tfprotov6.ProviderServer
+
+	mu       sync.Mutex
+	inFlight int // RPCs using the stores
+}
+
+// NewServer returns a function creating the protocol version 6 server of the provider, to be
+// served with tf6server.Serve.
+func NewServer(version string) func() tfprotov6.ProviderServer {
+	return func() tfprotov6.ProviderServer {
+		return &providerServer{ProviderServer: providerserver.NewProtocol6(New(version)())()}
+	}
+}
+
+// ApplyResourceChange applies the change, then pushes the stores cloned from git_remote, so
+// what the resource wrote is pushed before Terraform records it. A failed push is reported
+// on the resource.
+func (s *providerServer) ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error) {
+	defer s.track(ctx)()
+	resp, err := s.ProviderServer.ApplyResourceChange(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := pushClonedStores(ctx); err != nil {
+		resp.Diagnostics = append(resp.Diagnostics, &tfprotov6.Diagnostic{
+			Severity: tfprotov6.DiagnosticSeverityError,
+			Summary:  "Failed to push gopass store",
+			Detail: errorDetail(err, fmt.Sprintf(
+				"The change was committed to the clone of git_remote, but could not be pushed: %s. "+
+					"The commits are kept in the clone, and pushing them is retried after the next change.",
+				err.Error(),
+			)),
+		})
+	}
+	return resp, nil
+}
+
+// StopProvider stops the provider, and releases the locks of the stores, so a run waiting for
+// them does not have to wait until the interrupted run exits.
+func (s *providerServer) StopProvider(ctx context.Context, req *tfprotov6.StopProviderRequest) (*tfprotov6.StopProviderResponse, error) {
+	resp, err := s.ProviderServer.StopProvider(ctx, req)
+	releaseStoreLocks(ctx)
+	return resp, err
+}
+
+// track counts an RPC using the stores as in flight until the returned function is called.
+// The last RPC in flight logs the metrics summaries before it returns, as Terraform may stop
+// the provider without another RPC.
+func (s *providerServer) track(ctx context.Context) func() {
+	s.mu.Lock()
+	s.inFlight++
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		s.inFlight--
+		idle := s.inFlight == 0
+		s.mu.Unlock()
+		if idle {
+			logMetricsSummaries(ctx)
+		}
+	}
+}
+
+// ConfigureProvider configures the provider, see track.
+func (s *providerServer) ConfigureProvider(ctx context.Context, req *tfprotov6.ConfigureProviderRequest) (*tfprotov6.ConfigureProviderResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.ConfigureProvider(ctx, req)
+}
+
+// ReadResource refreshes a resource, see track.
+func (s *providerServer) ReadResource(ctx context.Context, req *tfprotov6.ReadResourceRequest) (*tfprotov6.ReadResourceResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.ReadResource(ctx, req)
+}
+
+// PlanResourceChange plans a resource change, see track.
+func (s *providerServer) PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.PlanResourceChange(ctx, req)
+}
+
+// ImportResourceState imports a resource, see track.
+func (s *providerServer) ImportResourceState(ctx context.Context, req *tfprotov6.ImportResourceStateRequest) (*tfprotov6.ImportResourceStateResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.ImportResourceState(ctx, req)
+}
+
+// ReadDataSource reads a data source, see track.
+func (s *providerServer) ReadDataSource(ctx context.Context, req *tfprotov6.ReadDataSourceRequest) (*tfprotov6.ReadDataSourceResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.ReadDataSource(ctx, req)
+}
+
+// OpenEphemeralResource opens an ephemeral resource, see track.
+func (s *providerServer) OpenEphemeralResource(ctx context.Context, req *tfprotov6.OpenEphemeralResourceRequest) (*tfprotov6.OpenEphemeralResourceResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.OpenEphemeralResource(ctx, req)
+}
+
+// RenewEphemeralResource renews an ephemeral resource, see track.
+func (s *providerServer) RenewEphemeralResource(ctx context.Context, req *tfprotov6.RenewEphemeralResourceRequest) (*tfprotov6.RenewEphemeralResourceResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.RenewEphemeralResource(ctx, req)
+}
+
+// CallFunction calls a provider function, see track.
+func (s *providerServer) CallFunction(ctx context.Context, req *tfprotov6.CallFunctionRequest) (*tfprotov6.CallFunctionResponse, error) {
+	defer s.track(ctx)()
+	return s.ProviderServer.CallFunction(ctx, req)
+}
@@ -0,0 +1,201 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+)
+
+// stubServer answers the RPCs using the stores with empty responses, and ApplyResourceChange
+// with applyResp and applyErr.
+type stubServer struct {
+	tfprotov6.ProviderServer
+	applyResp *tfprotov6.ApplyResourceChangeResponse
+	applyErr  error
+}
+
+func (s *stubServer) ConfigureProvider(ctx context.Context, req *tfprotov6.ConfigureProviderRequest) (*tfprotov6.ConfigureProviderResponse, error) {
+	return &tfprotov6.ConfigureProviderResponse{}, nil
+}
+
+func (s *stubServer) ReadResource(ctx context.Context, req *tfprotov6.ReadResourceRequest) (*tfprotov6.ReadResourceResponse, error) {
+	return &tfprotov6.ReadResourceResponse{}, nil
+}
+
+func (s *stubServer) PlanResourceChange(ctx context.Context, req *tfprotov6.PlanResourceChangeRequest) (*tfprotov6.PlanResourceChangeResponse, error) {
+	return &tfprotov6.PlanResourceChangeResponse{}, nil
+}
+
+func (s *stubServer) ApplyResourceChange(ctx context.Context, req *tfprotov6.ApplyResourceChangeRequest) (*tfprotov6.ApplyResourceChangeResponse, error) {
+	return s.applyResp, s.applyErr
+}
+
+func (s *stubServer) ImportResourceState(ctx context.Context, req *tfprotov6.ImportResourceStateRequest) (*tfprotov6.ImportResourceStateResponse, error) {
+	return &tfprotov6.ImportResourceStateResponse{}, nil
+}
+
+func (s *stubServer) ReadDataSource(ctx context.Context, req *tfprotov6.ReadDataSourceRequest) (*tfprotov6.ReadDataSourceResponse, error) {
+	return &tfprotov6.ReadDataSourceResponse{}, nil
+}
+
+func (s *stubServer) OpenEphemeralResource(ctx context.Context, req *tfprotov6.OpenEphemeralResourceRequest) (*tfprotov6.OpenEphemeralResourceResponse, error) {
+	return &tfprotov6.OpenEphemeralResourceResponse{}, nil
+}
+
+func (s *stubServer) RenewEphemeralResource(ctx context.Context, req *tfprotov6.RenewEphemeralResourceRequest) (*tfprotov6.RenewEphemeralResourceResponse, error) {
+	return &tfprotov6.RenewEphemeralResourceResponse{}, nil
+}
+
+func (s *stubServer) CallFunction(ctx context.Context, req *tfprotov6.CallFunctionRequest) (*tfprotov6.CallFunctionResponse, error) {
+	return &tfprotov6.CallFunctionResponse{}, nil
+}
+
+func TestNewServer(t *testing.T) {
+	server, ok := NewServer("test")().(*providerServer)
+	if !ok {
+		t.Fatal("expected the provider server")
+	}
+	resp, err := server.GetMetadata(context.Background(), &tfprotov6.GetMetadataRequest{})
+	if err != nil || len(resp.Resources) == 0 {
+		t.Errorf("expected the framework to serve the provider, got %v, %v", resp, err)
+	}
+}
+
+func TestProviderServer_ApplyResourceChange(t *testing.T) {
+	tests := []struct {
+		name      string
+		applyErr  error
+		pushErr   error
+		wantCalls int
+		wantDiags int
+	}{
+		{name: "pushed", wantCalls: 1},
+		{name: "push fails", pushErr: errors.New("git failed"), wantCalls: 1, wantDiags: 1},
+		{name: "apply fails", applyErr: errors.New("connection reset"), wantCalls: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetClonedClients(t)
+			client := NewGopassClient(t.TempDir())
+			client.clone = &storeClone{}
+			calls := 0
+			client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+				calls++
+				return []byte("0\n"), tc.pushErr
+			}
+			clonedClients = []*GopassClient{client}
+
+			server := &providerServer{ProviderServer: &stubServer{
+				applyResp: &tfprotov6.ApplyResourceChangeResponse{},
+				applyErr:  tc.applyErr,
+			}}
+			resp, err := server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{})
+
+			if !errors.Is(err, tc.applyErr) {
+				t.Fatalf("expected error %v, got %v", tc.applyErr, err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("expected %d git calls, got %d", tc.wantCalls, calls)
+			}
+			if len(resp.Diagnostics) != tc.wantDiags {
+				t.Fatalf("expected %d diagnostics, got %v", tc.wantDiags, resp.Diagnostics)
+			}
+			if tc.wantDiags > 0 {
+				d := resp.Diagnostics[0]
+				if d.Severity != tfprotov6.DiagnosticSeverityError || !strings.Contains(d.Detail, "could not be pushed") {
+					t.Errorf("expected an error about the push, got %+v", d)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderServer_StopProvider(t *testing.T) {
+	resetRunLocks(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	client := NewGopassClient(dir)
+	client.store = newMockStore()
+	client.SetLockTimeout(0)
+	if err := client.SetSecret(ctx, "app/db", "hunter2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := NewServer("test")()
+	if _, err := server.StopProvider(ctx, &tfprotov6.StopProviderRequest{}); err != nil {
+		t.Fatalf("StopProvider() error = %v", err)
+	}
+	if lockedByOther(t, dir) {
+		t.Error("expected the store to be released when the provider is stopped")
+	}
+}
+
+func TestProviderServer_LogsMetricsWhenIdle(t *testing.T) {
+	clients := metricsClients
+	t.Cleanup(func() { metricsClients = clients })
+	metricsClients = nil
+
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"app/db": "secret"})
+	client.EnableMetrics()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	server := &providerServer{ProviderServer: &stubServer{applyResp: &tfprotov6.ApplyResourceChangeResponse{}}}
+
+	// Every RPC using the stores logs the summary once no other one is in flight
+	rpcs := map[string]func(){
+		"ConfigureProvider": func() { _, _ = server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{}) },
+		"ReadResource":      func() { _, _ = server.ReadResource(ctx, &tfprotov6.ReadResourceRequest{}) },
+		"PlanResourceChange": func() {
+			_, _ = server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{})
+		},
+		"ApplyResourceChange": func() {
+			_, _ = server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{})
+		},
+		"ImportResourceState": func() {
+			_, _ = server.ImportResourceState(ctx, &tfprotov6.ImportResourceStateRequest{})
+		},
+		"ReadDataSource": func() { _, _ = server.ReadDataSource(ctx, &tfprotov6.ReadDataSourceRequest{}) },
+		"OpenEphemeralResource": func() {
+			_, _ = server.OpenEphemeralResource(ctx, &tfprotov6.OpenEphemeralResourceRequest{})
+		},
+		"RenewEphemeralResource": func() {
+			_, _ = server.RenewEphemeralResource(ctx, &tfprotov6.RenewEphemeralResourceRequest{})
+		},
+		"CallFunction": func() { _, _ = server.CallFunction(ctx, &tfprotov6.CallFunctionRequest{}) },
+	}
+	for name, rpc := range rpcs {
+		done := server.track(ctx)
+		if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+			t.Fatal(err)
+		}
+		output.Reset()
+		rpc()
+		if strings.Contains(output.String(), "gopass client metrics") {
+			t.Errorf("%s: expected no summary while another RPC is in flight", name)
+		}
+		done()
+		if !strings.Contains(output.String(), "gopass client metrics") {
+			t.Errorf("%s: expected a summary once no RPC is in flight", name)
+		}
+
+		if _, err := client.GetSecret(ctx, "app/db"); err != nil {
+			t.Fatal(err)
+		}
+		output.Reset()
+		rpc()
+		if !strings.Contains(output.String(), "gopass client metrics") {
+			t.Errorf("%s: expected a summary at the end of the RPC", name)
+		}
+	}
+}
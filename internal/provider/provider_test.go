@@ -5,7 +5,13 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
@@ -36,11 +42,7 @@ func TestProviderConfigure_SetsEphemeralResourceData(t *testing.T) {
 	}
 
 	// Create empty config using the schema
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"store_path": tftypes.String,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"store_path": tftypes.NewValue(tftypes.String, nil), // null value
 	})
 
@@ -135,11 +137,7 @@ func TestProviderConfigure_WithStorePath(t *testing.T) {
 	p.Schema(ctx, schemaReq, schemaResp)
 
 	// Create config with store_path set
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"store_path": tftypes.String,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"store_path": tftypes.NewValue(tftypes.String, "/tmp/test-store"),
 	})
 
@@ -170,6 +168,305 @@ func TestProviderConfigure_WithStorePath(t *testing.T) {
 	}
 }
 
+func TestProviderConfigure_NotFoundPatterns(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	configure := func(patterns ...tftypes.Value) *provider.ConfigureResponse {
+		req := provider.ConfigureRequest{
+			Config: tfsdk.Config{
+				Schema: schemaResp.Schema,
+				Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"not_found_patterns": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, patterns),
+				}),
+			},
+		}
+		resp := &provider.ConfigureResponse{}
+		p.Configure(ctx, req, resp)
+		return resp
+	}
+
+	resp := configure(tftypes.NewValue(tftypes.String, "existiert nicht"))
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+	}
+	client := resp.ResourceData.(*GopassClient)
+	if !client.isNotFound(errors.New("Eintrag existiert nicht")) {
+		t.Error("expected configured pattern to be recognized")
+	}
+
+	resp = configure(tftypes.NewValue(tftypes.String, nil))
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for null pattern")
+	}
+	if resp.ResourceData != nil {
+		t.Error("ResourceData should be nil when patterns are invalid")
+	}
+}
+
+func TestProviderConfigure_Mode(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	extraArgs := func(args ...interface{}) tftypes.Value {
+		values := make([]tftypes.Value, len(args))
+		for i, arg := range args {
+			values[i] = tftypes.NewValue(tftypes.String, arg)
+		}
+		return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, values)
+	}
+
+	tests := []struct {
+		name      string
+		mode      interface{}
+		extraArgs tftypes.Value
+		wantErr   bool
+		wantCLI   bool
+	}{
+		{name: "default", mode: nil},
+		{name: "library", mode: "library"},
+		{name: "cli", mode: "cli", wantCLI: true},
+		{name: "invalid", mode: "rpc", wantErr: true},
+		{name: "cli with extra args", mode: "cli", extraArgs: extraArgs("--yes"), wantCLI: true},
+		{name: "extra args without cli", mode: nil, extraArgs: extraArgs("--yes"), wantErr: true},
+		{name: "empty extra arg", mode: "cli", extraArgs: extraArgs(""), wantErr: true},
+		{name: "null extra arg", mode: "cli", extraArgs: extraArgs(nil), wantErr: true},
+		{name: "unknown extra args", mode: "cli", extraArgs: tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue), wantCLI: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]tftypes.Value{
+				"mode": tftypes.NewValue(tftypes.String, tt.mode),
+			}
+			if tt.extraArgs.Type() != nil {
+				values["extra_args"] = tt.extraArgs
+			}
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw:    schemaObjectValue(schemaResp.Schema, values),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if tt.wantErr {
+				if !resp.Diagnostics.HasError() {
+					t.Fatal("expected error for invalid mode")
+				}
+				if resp.ResourceData != nil {
+					t.Error("ResourceData should be nil when mode is invalid")
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+			}
+
+			if !tt.wantCLI {
+				return
+			}
+			// With an empty PATH the cli store cannot find the gopass binary
+			t.Setenv("PATH", "")
+			client := resp.ResourceData.(*GopassClient)
+			if _, err := client.apiNew(ctx, ""); err == nil || !strings.Contains(err.Error(), "is not usable") {
+				t.Errorf("expected cli store to require the gopass binary, got %v", err)
+			}
+		})
+	}
+}
+
+// fakeGopassBinary puts a gopass script on PATH that knows a single secret, "probe/ok".
+func fakeGopassBinary(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+for last; do :; done
+case "$1" in
+version) echo "gopass 1.15.14" ;;
+show) [ "$last" = "probe/ok" ] || { echo "entry is not in the password store" >&2; exit 1; }; echo "s3cret" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "gopass"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestProviderConfigure_ValidateOnConfigure(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name      string
+		storePath interface{}
+		validate  interface{}
+		probe     interface{}
+		wantErr   string
+	}{
+		{name: "disabled", storePath: "/nonexistent/store", validate: false},
+		{name: "store ok", validate: true},
+		{name: "probe ok", validate: true, probe: "probe/ok"},
+		{name: "probe fails", validate: true, probe: "probe/missing", wantErr: "test decryption"},
+		{name: "store missing", storePath: "/nonexistent/store", validate: true, wantErr: "gopass store not found"},
+		{name: "probe without validation", probe: "probe/ok", wantErr: "validate_on_configure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGopassBinary(t)
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"store_path":            tftypes.NewValue(tftypes.String, tt.storePath),
+						"mode":                  tftypes.NewValue(tftypes.String, modeCLI),
+						"validate_on_configure": tftypes.NewValue(tftypes.Bool, tt.validate),
+						"validate_secret":       tftypes.NewValue(tftypes.String, tt.probe),
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if tt.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+				}
+				if resp.ResourceData == nil {
+					t.Error("expected ResourceData to be set")
+				}
+				return
+			}
+			errs := resp.Diagnostics.Errors()
+			if len(errs) != 1 || !strings.Contains(errs[0].Detail(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, resp.Diagnostics)
+			}
+			if resp.ResourceData != nil {
+				t.Error("ResourceData should be nil when validation fails")
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_PreflightWriteCheck(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name    string
+		check   interface{}
+		dir     interface{}
+		wantErr string
+	}{
+		{name: "disabled", check: false},
+		{name: "default folder", check: true},
+		{name: "custom folder", check: true, dir: "ci/preflight"},
+		{name: "unknown folder", check: true, dir: tftypes.UnknownValue},
+		{name: "not writable", check: true, dir: "readonly/preflight", wantErr: "is not a folder"},
+		{name: "invalid folder", check: true, dir: "../preflight", wantErr: "not a valid folder"},
+		{name: "folder without check", dir: "ci/preflight", wantErr: "preflight_write_check"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeGopassBinary(t)
+			// A file where the folder would be created makes the store unwritable there
+			storePath := t.TempDir()
+			writeStoreFile(t, storePath, "readonly", "")
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"mode":                  tftypes.NewValue(tftypes.String, modeCLI),
+						"store_path":            tftypes.NewValue(tftypes.String, storePath),
+						"preflight_write_check": tftypes.NewValue(tftypes.Bool, tt.check),
+						"preflight_write_path":  tftypes.NewValue(tftypes.String, tt.dir),
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if tt.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+				}
+				return
+			}
+			errs := resp.Diagnostics.Errors()
+			if len(errs) != 1 || !strings.Contains(errs[0].Detail(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, resp.Diagnostics)
+			}
+			if resp.ResourceData != nil {
+				t.Error("ResourceData should be nil when the check fails")
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_RevisionTracking(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name    string
+		mode    interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "default", mode: nil, want: revisionTrackingAuto},
+		{name: "off", mode: "off", want: revisionTrackingOff},
+		{name: "strict", mode: "strict", want: revisionTrackingStrict},
+		{name: "invalid", mode: "never", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"revision_tracking": tftypes.NewValue(tftypes.String, tt.mode),
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if resp.Diagnostics.HasError() != tt.wantErr {
+				t.Fatalf("expected error=%v, got %v", tt.wantErr, resp.Diagnostics)
+			}
+			if tt.wantErr {
+				if resp.ResourceData != nil {
+					t.Error("ResourceData should be nil when revision_tracking is invalid")
+				}
+				return
+			}
+			if got := resp.ResourceData.(*GopassClient).RevisionTracking(); got != tt.want {
+				t.Errorf("expected revision tracking %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestProvider_Metadata(t *testing.T) {
 	ctx := context.Background()
 	p := &GopassProvider{version: "0.1.0"}
@@ -204,8 +501,9 @@ func TestProvider_DataSources(t *testing.T) {
 
 	dataSources := p.DataSources(ctx)
 
-	// May be empty if no data sources defined
-	_ = dataSources
+	if len(dataSources) == 0 {
+		t.Error("expected at least one data source")
+	}
 }
 
 func TestProvider_EphemeralResources(t *testing.T) {
@@ -248,3 +546,620 @@ func TestGopassClient_NewGopassClient(t *testing.T) {
 // 		},
 // 	})
 // }
+
+func TestProvider_Functions(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	functions := p.Functions(ctx)
+
+	if len(functions) == 0 {
+		t.Error("expected at least one function")
+	}
+}
+
+func TestProviderConfigure_AutoInit(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	recipients := func(values ...interface{}) tftypes.Value {
+		elems := make([]tftypes.Value, len(values))
+		for i, v := range values {
+			elems[i] = tftypes.NewValue(tftypes.String, v)
+		}
+		return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, elems)
+	}
+	nullRecipients := tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil)
+	unknownRecipients := tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue)
+
+	tests := []struct {
+		name       string
+		autoInit   interface{}
+		recipients tftypes.Value
+		want       []string
+		wantErr    string
+	}{
+		{name: "disabled", recipients: nullRecipients},
+		{name: "enabled", autoInit: true, recipients: recipients("0xAAAA", "0xBBBB"), want: []string{"0xAAAA", "0xBBBB"}},
+		{name: "unknown recipients", autoInit: true, recipients: unknownRecipients},
+		{name: "unknown flag", autoInit: tftypes.UnknownValue, recipients: recipients("0xAAAA")},
+		{name: "no recipients", autoInit: true, recipients: nullRecipients, wantErr: "auto_init_recipients"},
+		{name: "empty recipients", autoInit: true, recipients: recipients(), wantErr: "auto_init_recipients"},
+		{name: "recipients only", autoInit: false, recipients: recipients("0xAAAA"), wantErr: "auto_init = true"},
+		{name: "null recipient", autoInit: true, recipients: recipients(nil), wantErr: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"auto_init":            tftypes.NewValue(tftypes.Bool, tt.autoInit),
+						"auto_init_recipients": tt.recipients,
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if tt.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+			}
+			if got := resp.ResourceData.(*GopassClient).autoInitRecipients; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected auto init recipients %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_MetricsSummary(t *testing.T) {
+	clients := metricsClients
+	t.Cleanup(func() { metricsClients = clients })
+
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	for _, enabled := range []bool{false, true} {
+		metricsClients = nil
+		req := provider.ConfigureRequest{
+			Config: tfsdk.Config{
+				Schema: schemaResp.Schema,
+				Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"metrics_summary": tftypes.NewValue(tftypes.Bool, enabled),
+				}),
+			},
+		}
+		resp := &provider.ConfigureResponse{}
+		p.Configure(ctx, req, resp)
+
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+		}
+		client := resp.ResourceData.(*GopassClient)
+		if (client.metrics != nil) != enabled || (len(metricsClients) == 1) != enabled {
+			t.Errorf("metrics_summary = %v: expected metrics enabled = %v", enabled, enabled)
+		}
+	}
+}
+
+func TestProviderConfigure_DisableCache(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name        string
+		value       tftypes.Value
+		wantEnabled bool
+	}{
+		{name: "default", value: tftypes.NewValue(tftypes.Bool, nil), wantEnabled: true},
+		{name: "enabled", value: tftypes.NewValue(tftypes.Bool, false), wantEnabled: true},
+		{name: "disabled", value: tftypes.NewValue(tftypes.Bool, true)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"disable_cache": tc.value,
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+			}
+			client := resp.ResourceData.(*GopassClient)
+			if enabled := client.cache != nil; enabled != tc.wantEnabled {
+				t.Errorf("expected cache enabled = %v, got %v", tc.wantEnabled, enabled)
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_StorePaths(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	paths := func(values ...interface{}) tftypes.Value {
+		elems := make([]tftypes.Value, len(values))
+		for i, v := range values {
+			elems[i] = tftypes.NewValue(tftypes.String, v)
+		}
+		return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, elems)
+	}
+
+	tests := []struct {
+		name       string
+		storePath  interface{}
+		storePaths tftypes.Value
+		wantPath   string
+		wantLookup []string
+		wantErr    string
+	}{
+		{name: "single", storePaths: paths("/stores/a"), wantPath: "/stores/a"},
+		{name: "several", storePaths: paths("/stores/a", "/stores/b", "/stores/c"), wantPath: "/stores/a", wantLookup: []string{"/stores/b", "/stores/c"}},
+		{name: "unknown", storePath: "/stores/a", storePaths: tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue), wantPath: "/stores/a"},
+		{name: "empty", storePaths: paths(), wantErr: "at least one store"},
+		{name: "with store_path", storePath: "/stores/a", storePaths: paths("/stores/b"), wantErr: "cannot be combined with store_path"},
+		{name: "null path", storePaths: paths(nil), wantErr: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := provider.ConfigureRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"store_path":  tftypes.NewValue(tftypes.String, tt.storePath),
+						"store_paths": tt.storePaths,
+					}),
+				},
+			}
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, req, resp)
+
+			if tt.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+			}
+			client := resp.ResourceData.(*GopassClient)
+			if client.storePath != tt.wantPath || !reflect.DeepEqual(client.lookupPaths, tt.wantLookup) {
+				t.Errorf("expected store %q and lookup stores %v, got %q and %v", tt.wantPath, tt.wantLookup, client.storePath, client.lookupPaths)
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_GitRemote(t *testing.T) {
+	resetClonedClients(t)
+	origin := gitOrigin(t)
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	cloneDir := filepath.Join(t.TempDir(), "store")
+	tests := []struct {
+		name        string
+		values      map[string]tftypes.Value
+		wantPath    string
+		wantErr     string
+		wantClone   bool
+		wantNoClone bool // the configuration is rejected before clone_dir is created
+	}{
+		{
+			name: "cloned",
+			values: map[string]tftypes.Value{
+				"git_remote": tftypes.NewValue(tftypes.String, origin),
+				"clone_dir":  tftypes.NewValue(tftypes.String, cloneDir),
+			},
+			wantPath:  cloneDir,
+			wantClone: true,
+		},
+		{
+			name:    "clone_dir without git_remote",
+			values:  map[string]tftypes.Value{"clone_dir": tftypes.NewValue(tftypes.String, cloneDir)},
+			wantErr: "Missing git_remote",
+		},
+		{
+			name: "store_path",
+			values: map[string]tftypes.Value{
+				"git_remote": tftypes.NewValue(tftypes.String, origin),
+				"store_path": tftypes.NewValue(tftypes.String, "/store"),
+			},
+			wantErr: "Conflicting git_remote and store_path",
+		},
+		{
+			name: "store_paths",
+			values: map[string]tftypes.Value{
+				"git_remote":  tftypes.NewValue(tftypes.String, origin),
+				"store_paths": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "/store")}),
+			},
+			wantErr: "Conflicting git_remote and store_path",
+		},
+		{
+			name:    "unknown git_remote",
+			values:  map[string]tftypes.Value{"git_remote": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)},
+			wantErr: "Unknown git_remote",
+		},
+		{
+			name: "unknown clone_dir",
+			values: map[string]tftypes.Value{
+				"git_remote": tftypes.NewValue(tftypes.String, origin),
+				"clone_dir":  tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			},
+			wantErr: "Unknown git_remote",
+		},
+		{
+			name: "clone fails",
+			values: map[string]tftypes.Value{
+				"git_remote": tftypes.NewValue(tftypes.String, filepath.Join(t.TempDir(), "missing.git")),
+				"clone_dir":  tftypes.NewValue(tftypes.String, filepath.Join(t.TempDir(), "store")),
+			},
+			wantErr: "Failed to clone store",
+		},
+		{
+			name: "invalid lock_timeout",
+			values: map[string]tftypes.Value{
+				"git_remote":   tftypes.NewValue(tftypes.String, origin),
+				"clone_dir":    tftypes.NewValue(tftypes.String, filepath.Join(t.TempDir(), "store")),
+				"lock_timeout": tftypes.NewValue(tftypes.String, "soon"),
+			},
+			wantErr:     "Invalid lock_timeout",
+			wantNoClone: true,
+		},
+		{
+			name: "invalid preflight_write_path",
+			values: map[string]tftypes.Value{
+				"git_remote":            tftypes.NewValue(tftypes.String, origin),
+				"clone_dir":             tftypes.NewValue(tftypes.String, filepath.Join(t.TempDir(), "store")),
+				"preflight_write_check": tftypes.NewValue(tftypes.Bool, true),
+				"preflight_write_path":  tftypes.NewValue(tftypes.String, "../outside"),
+			},
+			wantErr:     "Invalid preflight_write_path",
+			wantNoClone: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			clonedClients = nil
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, provider.ConfigureRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, tc.values)},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				if tc.wantNoClone {
+					var cloneDir string
+					_ = tc.values["clone_dir"].As(&cloneDir)
+					if _, err := os.Stat(cloneDir); !os.IsNotExist(err) {
+						t.Errorf("expected nothing to be cloned into %q, got %v", cloneDir, err)
+					}
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+			}
+			client := resp.ResourceData.(*GopassClient)
+			if client.storePath != tc.wantPath || (len(clonedClients) == 1) != tc.wantClone {
+				t.Errorf("expected store %q cloned=%v, got %q with %d clones", tc.wantPath, tc.wantClone, client.storePath, len(clonedClients))
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_LogPaths(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	for _, tc := range []struct {
+		logPaths   any
+		wantHidden bool
+	}{
+		{logPaths: nil},
+		{logPaths: true},
+		{logPaths: false, wantHidden: true},
+	} {
+		resp := &provider.ConfigureResponse{}
+		p.Configure(ctx, provider.ConfigureRequest{
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"log_paths": tftypes.NewValue(tftypes.Bool, tc.logPaths),
+			})},
+		}, resp)
+
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Configure() returned errors: %v", resp.Diagnostics)
+		}
+		if hidden := resp.ResourceData.(*GopassClient).hidePaths; hidden != tc.wantHidden {
+			t.Errorf("log_paths = %v: expected paths hidden = %v, got %v", tc.logPaths, tc.wantHidden, hidden)
+		}
+	}
+}
+
+func TestProviderConfigure_LockTimeout(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	for _, tc := range []struct {
+		lockTimeout any
+		wantLock    bool
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{lockTimeout: nil},
+		{lockTimeout: tftypes.UnknownValue},
+		{lockTimeout: "0s", wantLock: true},
+		{lockTimeout: "5m", wantLock: true, wantTimeout: 5 * time.Minute},
+		{lockTimeout: "soon", wantErr: true},
+		{lockTimeout: "-1s", wantErr: true},
+	} {
+		resp := &provider.ConfigureResponse{}
+		p.Configure(ctx, provider.ConfigureRequest{
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"lock_timeout": tftypes.NewValue(tftypes.String, tc.lockTimeout),
+			})},
+		}, resp)
+
+		if resp.Diagnostics.HasError() != tc.wantErr {
+			t.Fatalf("lock_timeout = %v: expected error = %v, got %v", tc.lockTimeout, tc.wantErr, resp.Diagnostics)
+		}
+		if tc.wantErr {
+			if summary := resp.Diagnostics.Errors()[0].Summary(); summary != "Invalid lock_timeout" {
+				t.Errorf("lock_timeout = %v: unexpected summary %q", tc.lockTimeout, summary)
+			}
+			continue
+		}
+		client := resp.ResourceData.(*GopassClient)
+		if client.runLock != tc.wantLock || client.lockTimeout != tc.wantTimeout {
+			t.Errorf("lock_timeout = %v: expected lock %v with timeout %s, got %v with %s",
+				tc.lockTimeout, tc.wantLock, tc.wantTimeout, client.runLock, client.lockTimeout)
+		}
+	}
+}
+
+func TestProviderConfigure_PathPrefix(t *testing.T) {
+	mountStores(t, "team")
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	for _, tc := range []struct {
+		pathPrefix any
+		wantPrefix string
+		wantErr    bool
+	}{
+		{pathPrefix: nil},
+		{pathPrefix: tftypes.UnknownValue},
+		{pathPrefix: "terraform/dev", wantPrefix: "terraform/dev"},
+		{pathPrefix: "team:terraform", wantPrefix: "team/terraform"},
+		{pathPrefix: "terraform/", wantErr: true},
+		{pathPrefix: "terraform/../prod", wantErr: true},
+	} {
+		resp := &provider.ConfigureResponse{}
+		p.Configure(ctx, provider.ConfigureRequest{
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path_prefix": tftypes.NewValue(tftypes.String, tc.pathPrefix),
+			})},
+		}, resp)
+
+		if resp.Diagnostics.HasError() != tc.wantErr {
+			t.Fatalf("path_prefix = %v: expected error = %v, got %v", tc.pathPrefix, tc.wantErr, resp.Diagnostics)
+		}
+		if tc.wantErr {
+			if summary := resp.Diagnostics.Errors()[0].Summary(); summary != "Invalid path_prefix" {
+				t.Errorf("path_prefix = %v: unexpected summary %q", tc.pathPrefix, summary)
+			}
+			continue
+		}
+		if got := resp.ResourceData.(*GopassClient).pathPrefix; got != tc.wantPrefix {
+			t.Errorf("path_prefix = %v: expected prefix %q, got %q", tc.pathPrefix, tc.wantPrefix, got)
+		}
+	}
+}
+
+func TestProviderConfigure_ProtectedPaths(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+
+	for _, tc := range []struct {
+		name         string
+		patterns     tftypes.Value
+		wantPatterns []string
+		wantErr      string
+	}{
+		{name: "unset", patterns: tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil)},
+		{name: "unknown", patterns: tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue)},
+		{
+			name:         "valid",
+			patterns:     tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "prod/**")}),
+			wantPatterns: []string{"prod/**"},
+		},
+		{
+			name:     "invalid",
+			patterns: tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "prod/[")}),
+			wantErr:  "Invalid protected_paths",
+		},
+		{
+			name:     "unknown pattern",
+			patterns: tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}),
+			wantErr:  "Value Conversion Error",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, provider.ConfigureRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"protected_paths": tc.patterns,
+				})},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := resp.ResourceData.(*GopassClient).protectedPaths; !reflect.DeepEqual(got, tc.wantPatterns) {
+				t.Errorf("expected patterns %v, got %v", tc.wantPatterns, got)
+			}
+		})
+	}
+}
+
+func TestProviderConfigure_PinentryMode(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+	t.Setenv("CI_GPG_PASSPHRASE", "correct horse")
+	t.Setenv(defaultPassphraseEnv, "")
+
+	for _, tc := range []struct {
+		mode          any
+		passphraseEnv any
+		wantEnv       string
+		wantErr       bool
+	}{
+		{mode: nil},
+		{mode: "default"},
+		{mode: "loopback", passphraseEnv: "CI_GPG_PASSPHRASE", wantEnv: "CI_GPG_PASSPHRASE"},
+		{mode: "loopback", wantErr: true},
+		{mode: "default", passphraseEnv: "CI_GPG_PASSPHRASE", wantErr: true},
+		{mode: "tty", wantErr: true},
+	} {
+		resp := &provider.ConfigureResponse{}
+		p.Configure(ctx, provider.ConfigureRequest{
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"pinentry_mode":  tftypes.NewValue(tftypes.String, tc.mode),
+				"passphrase_env": tftypes.NewValue(tftypes.String, tc.passphraseEnv),
+			})},
+		}, resp)
+
+		if resp.Diagnostics.HasError() != tc.wantErr {
+			t.Fatalf("pinentry_mode = %v: expected error = %v, got %v", tc.mode, tc.wantErr, resp.Diagnostics)
+		}
+		if tc.wantErr {
+			if summary := resp.Diagnostics.Errors()[0].Summary(); summary != "Invalid pinentry_mode" {
+				t.Errorf("pinentry_mode = %v: unexpected summary %q", tc.mode, summary)
+			}
+			continue
+		}
+		if client := resp.ResourceData.(*GopassClient); client.passphraseEnv != tc.wantEnv {
+			t.Errorf("pinentry_mode = %v: expected the passphrase from %q, got %q", tc.mode, tc.wantEnv, client.passphraseEnv)
+		}
+	}
+}
+
+func TestProviderConfigure_PasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	p := &GopassProvider{version: "test"}
+
+	schemaResp := &provider.SchemaResponse{}
+	p.Schema(ctx, provider.SchemaRequest{}, schemaResp)
+	policyType := schemaResp.Schema.Attributes["password_policy"].GetType().TerraformType(ctx)
+	list := func(values ...any) tftypes.Value {
+		elems := make([]tftypes.Value, len(values))
+		for i, v := range values {
+			elems[i] = tftypes.NewValue(tftypes.String, v)
+		}
+		return tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, elems)
+	}
+	policy := func(minLength any, classes, banned tftypes.Value) tftypes.Value {
+		return tftypes.NewValue(policyType, map[string]tftypes.Value{
+			"min_length":        tftypes.NewValue(tftypes.Number, minLength),
+			"required_classes":  classes,
+			"banned_substrings": banned,
+		})
+	}
+	nullList := tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, nil)
+
+	for _, tc := range []struct {
+		name       string
+		policy     tftypes.Value
+		wantPolicy *PasswordPolicy
+		wantErr    string
+	}{
+		{name: "unset", policy: tftypes.NewValue(policyType, nil)},
+		{name: "unknown", policy: tftypes.NewValue(policyType, tftypes.UnknownValue)},
+		{
+			name:       "complete",
+			policy:     policy(16, list("digit", "symbol"), list("REPLACE_ME")),
+			wantPolicy: &PasswordPolicy{MinLength: 16, RequiredClasses: []string{"digit", "symbol"}, BannedSubstrings: []string{"REPLACE_ME"}},
+		},
+		{name: "only length", policy: policy(12, nullList, nullList), wantPolicy: &PasswordPolicy{MinLength: 12}},
+		{name: "invalid class", policy: policy(nil, list("emoji"), nullList), wantErr: "Invalid password_policy"},
+		{name: "null class", policy: policy(nil, list(nil), nullList), wantErr: "Value Conversion Error"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &provider.ConfigureResponse{}
+			p.Configure(ctx, provider.ConfigureRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"password_policy": tc.policy,
+				})},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			client := resp.ResourceData.(*GopassClient)
+			if !reflect.DeepEqual(client.policy, tc.wantPolicy) {
+				t.Errorf("expected policy %+v, got %+v", tc.wantPolicy, client.policy)
+			}
+		})
+	}
+}
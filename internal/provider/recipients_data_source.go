@@ -0,0 +1,148 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &RecipientsDataSource{}
+	_ datasource.DataSourceWithConfigure = &RecipientsDataSource{}
+)
+
+// RecipientsDataSource reports the recipients that can decrypt secrets under a prefix.
+type RecipientsDataSource struct {
+	client *GopassClient
+}
+
+// RecipientsDataSourceModel describes the data source data model.
+type RecipientsDataSourceModel struct {
+	ID         types.String `tfsdk:"id"`
+	Path       types.String `tfsdk:"path"`
+	Recipients types.List   `tfsdk:"recipients"`
+	Source     types.String `tfsdk:"source"`
+}
+
+// NewRecipientsDataSource creates a new instance.
+func NewRecipientsDataSource() datasource.DataSource {
+	return &RecipientsDataSource{}
+}
+
+func (d *RecipientsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_recipients"
+}
+
+func (d *RecipientsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reports which recipients (GPG key IDs or age recipients) can decrypt secrets under a path.",
+		MarkdownDescription: `
+Reports which recipients (GPG key IDs or age recipients) can decrypt secrets under a path.
+
+The recipients are read from the ` + "`.gpg-id`" + ` (or ` + "`.age-recipients`" + `) file closest to
+` + "`path`" + `, the same way gopass selects them when encrypting. Only public metadata is read;
+no secret is decrypted.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_recipients" "prod" {
+  path = "prod"
+}
+
+check "prod_break_glass" {
+  assert {
+    condition     = contains(data.gopass_recipients.prod.recipients, "0xBREAKGLASS")
+    error_message = "prod secrets must be readable with the break-glass key"
+  }
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path the recipients were looked up for.",
+				Computed:    true,
+			},
+			"path": schema.StringAttribute{
+				Description:         "Secret path or folder to check (e.g., 'prod/db'). Defaults to the store root.",
+				MarkdownDescription: "Secret path or folder to check (e.g., `prod/db`). Defaults to the store root.",
+				Optional:            true,
+				Validators:          []validator.String{validSecretPath()},
+			},
+			"recipients": schema.ListAttribute{
+				Description: "Recipients the secrets under path are encrypted for.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"source": schema.StringAttribute{
+				Description:         "Store-relative recipients file the list was read from, e.g. 'prod/.gpg-id'.",
+				MarkdownDescription: "Store-relative recipients file the list was read from, e.g. `prod/.gpg-id`.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *RecipientsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *RecipientsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	var data RecipientsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	prefix := data.Path.ValueString()
+
+	recipients, source, err := d.client.GetRecipients(ctx, prefix)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read recipients",
+			errorDetail(err, fmt.Sprintf("Could not determine recipients for path %q: %s", prefix, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Read gopass recipients", map[string]interface{}{
+		"path":       prefix,
+		"source":     source,
+		"recipients": len(recipients),
+	})
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, recipients)
+	resp.Diagnostics.Append(diags...)
+
+	data.ID = types.StringValue(prefix)
+	data.Recipients = list
+	data.Source = types.StringValue(source)
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
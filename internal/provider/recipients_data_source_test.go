@@ -0,0 +1,139 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readRecipients runs Read on the recipients data source with the given path.
+func readRecipients(t *testing.T, client *GopassClient, path tftypes.Value) (*datasource.ReadResponse, RecipientsDataSourceModel) {
+	t.Helper()
+
+	d := &RecipientsDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{"path": path}),
+		},
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data RecipientsDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func TestRecipientsDataSource_Metadata(t *testing.T) {
+	d := NewRecipientsDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_recipients" {
+		t.Errorf("expected type name 'gopass_recipients', got %q", resp.TypeName)
+	}
+}
+
+func TestRecipientsDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &RecipientsDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestRecipientsDataSource_Read(t *testing.T) {
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".gpg-id", "0xROOT\n")
+	writeStoreFile(t, dir, "prod/.gpg-id", "0xOPS\n0xBREAKGLASS\n")
+	client := NewGopassClient(dir)
+
+	tests := []struct {
+		name       string
+		path       tftypes.Value
+		wantID     string
+		wantSource string
+		wantCount  int
+	}{
+		{name: "root", path: tftypes.NewValue(tftypes.String, nil), wantID: "", wantSource: ".gpg-id", wantCount: 1},
+		{name: "prefix", path: tftypes.NewValue(tftypes.String, "prod/db"), wantID: "prod/db", wantSource: "prod/.gpg-id", wantCount: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, data := readRecipients(t, client, tc.path)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if data.ID.ValueString() != tc.wantID {
+				t.Errorf("expected id %q, got %q", tc.wantID, data.ID.ValueString())
+			}
+			if data.Source.ValueString() != tc.wantSource {
+				t.Errorf("expected source %q, got %q", tc.wantSource, data.Source.ValueString())
+			}
+			if len(data.Recipients.Elements()) != tc.wantCount {
+				t.Errorf("expected %d recipients, got %v", tc.wantCount, data.Recipients)
+			}
+		})
+	}
+}
+
+func TestRecipientsDataSource_Read_NoRecipientsFile(t *testing.T) {
+	resp, _ := readRecipients(t, NewGopassClient(t.TempDir()), tftypes.NewValue(tftypes.String, "prod"))
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error when no recipients file exists")
+	}
+}
+
+func TestRecipientsDataSource_Read_InvalidConfig(t *testing.T) {
+	d := &RecipientsDataSource{client: NewGopassClient("")}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// validateSnapshotRef checks that ref can be passed to git as a revision. Snapshots end up on
+// the git command line, where a leading dash would be read as an option, and in the
+// "<rev>:<path>" form gopass reads old revisions with, where a colon would change the path.
+func validateSnapshotRef(ref string) error {
+	if ref == "" {
+		return errors.New("ref must not be empty")
+	}
+	if strings.HasPrefix(ref, "-") {
+		return errors.New("ref must not start with '-'")
+	}
+	for _, r := range ref {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("ref must not contain whitespace or control characters (found %U)", r)
+		}
+	}
+	if strings.Contains(ref, ":") {
+		return errors.New("ref must not contain ':'")
+	}
+	return nil
+}
+
+// snapshotRefValidator validates string attributes holding git refs to read snapshots at.
+type snapshotRefValidator struct{}
+
+// validSnapshotRef returns a validator rejecting refs that git could misread.
+func validSnapshotRef() validator.String {
+	return snapshotRefValidator{}
+}
+
+func (v snapshotRefValidator) Description(ctx context.Context) string {
+	return "ref must not start with '-' and must not contain ':', whitespace or control characters"
+}
+
+func (v snapshotRefValidator) MarkdownDescription(ctx context.Context) string {
+	return "ref must not start with `-` and must not contain `:`, whitespace or control characters"
+}
+
+func (v snapshotRefValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if err := validateSnapshotRef(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			req.Path,
+			"Invalid snapshot",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("The value %q is not a valid git ref: %s.", req.ConfigValue.ValueString(), err.Error())),
+		)
+	}
+}
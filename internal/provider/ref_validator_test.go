@@ -0,0 +1,71 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestValidateSnapshotRef(t *testing.T) {
+	testCases := []struct {
+		ref     string
+		wantErr bool
+	}{
+		{ref: "release-2024-06", wantErr: false},
+		{ref: "refs/tags/v1.0", wantErr: false},
+		{ref: "0123abcd", wantErr: false},
+		{ref: "HEAD~1", wantErr: false},
+		{ref: "", wantErr: true},
+		{ref: "--output=/tmp/x", wantErr: true},
+		{ref: "-1", wantErr: true},
+		{ref: "main:secret", wantErr: true},
+		{ref: "v1 v2", wantErr: true},
+		{ref: "v1\n", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ref, func(t *testing.T) {
+			err := validateSnapshotRef(tc.ref)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validateSnapshotRef(%q) error = %v, wantErr %v", tc.ref, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestSnapshotRefValidator_ValidateString(t *testing.T) {
+	ctx := context.Background()
+	v := validSnapshotRef()
+
+	testCases := []struct {
+		name    string
+		value   types.String
+		wantErr bool
+	}{
+		{name: "valid", value: types.StringValue("v1.0"), wantErr: false},
+		{name: "option", value: types.StringValue("--output=/tmp/x"), wantErr: true},
+		{name: "null", value: types.StringNull(), wantErr: false},
+		{name: "unknown", value: types.StringUnknown(), wantErr: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := validator.StringRequest{Path: path.Root("snapshot"), ConfigValue: tc.value}
+			resp := &validator.StringResponse{}
+			v.ValidateString(ctx, req, resp)
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+
+	if v.Description(ctx) == "" || v.MarkdownDescription(ctx) == "" {
+		t.Error("expected non-empty descriptions")
+	}
+}
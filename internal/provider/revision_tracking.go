@@ -0,0 +1,66 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Revision tracking modes of the revision_tracking attribute.
+const (
+	// revisionTrackingAuto tracks revisions where the backend supports them and warns on drift.
+	revisionTrackingAuto = "auto"
+	// revisionTrackingOff never reads revisions, for backends without history.
+	revisionTrackingOff = "off"
+	// revisionTrackingStrict reports drift as an error instead of a warning.
+	revisionTrackingStrict = "strict"
+)
+
+// revisionTrackingDescription documents the revision_tracking attribute.
+const revisionTrackingDescription = "How changes made outside of Terraform are detected: 'auto' tracks revisions " +
+	"and warns on drift, 'off' never reads revisions (for backends without history, saving a read per operation), " +
+	"'strict' fails on drift instead of warning."
+
+// revisionTrackingMarkdownDescription documents the revision_tracking attribute in Markdown.
+const revisionTrackingMarkdownDescription = "How changes made outside of Terraform are detected: `auto` tracks revisions " +
+	"and warns on drift, `off` never reads revisions (for backends without history, saving a read per operation), " +
+	"`strict` fails on drift instead of warning."
+
+// validateRevisionTracking adds an error to diags if the revision_tracking value v at p is not a known mode.
+func validateRevisionTracking(diags *diag.Diagnostics, p path.Path, v types.String) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	switch v.ValueString() {
+	case revisionTrackingAuto, revisionTrackingOff, revisionTrackingStrict:
+	default:
+		diags.AddAttributeError(
+			p,
+			"Invalid revision_tracking",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("revision_tracking must be %q, %q or %q, got %q.",
+				revisionTrackingAuto, revisionTrackingOff, revisionTrackingStrict, v.ValueString())),
+		)
+	}
+}
+
+// SetRevisionTracking sets the revision tracking mode used by resources that do not configure their own.
+func (c *GopassClient) SetRevisionTracking(mode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revisionTracking = mode
+}
+
+// RevisionTracking returns the default revision tracking mode of resources.
+func (c *GopassClient) RevisionTracking() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.revisionTracking == "" {
+		return revisionTrackingAuto
+	}
+	return c.revisionTracking
+}
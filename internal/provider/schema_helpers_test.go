@@ -0,0 +1,81 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Test helpers for building raw Terraform values from framework schemas.
+
+// schemaType is satisfied by all framework schema types (provider, resource,
+// data source and ephemeral resource schemas).
+type schemaType interface {
+	Type() attr.Type
+}
+
+// schemaObjectType returns the tftypes object type of a schema.
+func schemaObjectType(s schemaType) tftypes.Object {
+	return s.Type().TerraformType(context.Background()).(tftypes.Object)
+}
+
+// schemaObjectValue builds an object value for a schema. Attributes that are
+// not present in values are set to null, so tests only need to list the
+// attributes relevant to them.
+func schemaObjectValue(s schemaType, values map[string]tftypes.Value) tftypes.Value {
+	objectType := schemaObjectType(s)
+	all := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		if v, ok := values[name]; ok {
+			all[name] = v
+			continue
+		}
+		all[name] = tftypes.NewValue(attrType, nil)
+	}
+	return tftypes.NewValue(objectType, all)
+}
+
+// schemaNullValue builds a null object value for a schema, e.g. for an
+// ephemeral result that has not been populated yet.
+func schemaNullValue(s schemaType) tftypes.Value {
+	return tftypes.NewValue(schemaObjectType(s), nil)
+}
+
+// testResource returns r together with its schema, which plan, state and
+// config values of r are built from.
+func testResource[R resource.Resource](r R) (R, resource.SchemaResponse) {
+	schemaResp := resource.SchemaResponse{}
+	r.Schema(context.Background(), resource.SchemaRequest{}, &schemaResp)
+	return r, schemaResp
+}
+
+// stringMapValue builds a map of strings. A nil map is null, as write-only
+// maps are in plan and state.
+func stringMapValue(values map[string]string) tftypes.Value {
+	if values == nil {
+		return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, nil)
+	}
+	elements := make(map[string]tftypes.Value, len(values))
+	for k, v := range values {
+		elements[k] = tftypes.NewValue(tftypes.String, v)
+	}
+	return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, elements)
+}
+
+// stringSetValue builds a set of strings. A nil slice is unknown, as computed
+// sets are in a plan before apply.
+func stringSetValue(values []string) tftypes.Value {
+	if values == nil {
+		return tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, tftypes.UnknownValue)
+	}
+	elements := make([]tftypes.Value, 0, len(values))
+	for _, v := range values {
+		elements = append(elements, tftypes.NewValue(tftypes.String, v))
+	}
+	return tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, elements)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// validateExpectSHA256 adds an error to diags if expect_sha256 is not a hex-encoded SHA-256 digest.
+func validateExpectSHA256(diags *diag.Diagnostics, v types.String) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	if digest, err := hex.DecodeString(v.ValueString()); err != nil || len(digest) != sha256.Size {
+		diags.AddAttributeError(
+			path.Root("expect_sha256"),
+			"Invalid expect_sha256",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf(
+				"expect_sha256 must be a SHA-256 digest of %d hex characters, as printed by sha256sum.", 2*sha256.Size,
+			)),
+		)
+	}
+}
+
+// matchesSHA256 reports whether the SHA-256 digest of value is the hex-encoded expected digest.
+// Upper- and lowercase hex digits are accepted.
+func matchesSHA256(value, expected string) bool {
+	sum := sha256.Sum256([]byte(value))
+	actual := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(strings.ToLower(expected))) == 1
+}
@@ -0,0 +1,181 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// sha256OfTest is the SHA-256 digest of "test".
+const sha256OfTest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+func TestValidateExpectSHA256(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   types.String
+		wantErr bool
+	}{
+		{name: "null", value: types.StringNull()},
+		{name: "unknown", value: types.StringUnknown()},
+		{name: "lowercase", value: types.StringValue(sha256OfTest)},
+		{name: "uppercase", value: types.StringValue(strings.ToUpper(sha256OfTest))},
+		{name: "too short", value: types.StringValue(sha256OfTest[:62]), wantErr: true},
+		{name: "not hex", value: types.StringValue(strings.Repeat("z", 64)), wantErr: true},
+		{name: "sha256sum output", value: types.StringValue(sha256OfTest + "  -"), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			validateExpectSHA256(&diags, tc.value)
+			if diags.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestMatchesSHA256(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		want     bool
+	}{
+		{name: "match", value: "test", expected: sha256OfTest, want: true},
+		{name: "uppercase match", value: "test", expected: strings.ToUpper(sha256OfTest), want: true},
+		{name: "trailing newline", value: "test\n", expected: sha256OfTest},
+		{name: "other value", value: "other", expected: sha256OfTest},
+		{name: "malformed digest", value: "test", expected: "abc"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := matchesSHA256(tc.value, tc.expected); got != tc.want {
+				t.Errorf("matchesSHA256() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretEphemeralResource_ValidateConfig(t *testing.T) {
+	r := &SecretEphemeralResource{}
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name    string
+		raw     tftypes.Value
+		wantErr bool
+	}{
+		{
+			name: "valid digest",
+			raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":          tftypes.NewValue(tftypes.String, "test/secret"),
+				"expect_sha256": tftypes.NewValue(tftypes.String, sha256OfTest),
+			}),
+		},
+		{
+			name: "no digest",
+			raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, "test/secret"),
+			}),
+		},
+		{
+			name: "invalid digest",
+			raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":          tftypes.NewValue(tftypes.String, "test/secret"),
+				"expect_sha256": tftypes.NewValue(tftypes.String, "md5:098f6bcd4621d373cade4e832627b4f6"),
+			}),
+			wantErr: true,
+		},
+		{name: "invalid config", raw: invalidRaw, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tc.raw},
+			}, resp)
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretEphemeralResource_Open_ExpectSHA256(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["test/secret"] = newMockSecret("test")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretEphemeralResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "match", expected: sha256OfTest},
+		{name: "mismatch", expected: strings.Repeat("0", 64), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := ephemeral.OpenRequest{
+				Config: tfsdk.Config{
+					Schema: schemaResp.Schema,
+					Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+						"path":          tftypes.NewValue(tftypes.String, "test/secret"),
+						"expect_sha256": tftypes.NewValue(tftypes.String, tc.expected),
+					}),
+				},
+			}
+			resp := &ephemeral.OpenResponse{
+				Result: tfsdk.EphemeralResultData{
+					Schema: schemaResp.Schema,
+					Raw:    schemaNullValue(schemaResp.Schema),
+				},
+			}
+
+			r.Open(ctx, req, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				detail := resp.Diagnostics.Errors()[0].Detail()
+				if !strings.HasPrefix(detail, "["+CodeChecksumMismatch+"]") {
+					t.Errorf("expected %s code, got %q", CodeChecksumMismatch, detail)
+				}
+				if strings.Contains(detail, sha256OfTest) {
+					t.Errorf("detail must not reveal the digest of the secret: %q", detail)
+				}
+				if !resp.Result.Raw.IsNull() {
+					t.Error("expected no result on mismatch")
+				}
+				return
+			}
+
+			var result SecretModel
+			resp.Result.Get(ctx, &result)
+			if result.Value.ValueString() != "test" {
+				t.Errorf("expected value %q, got %q", "test", result.Value.ValueString())
+			}
+		})
+	}
+}
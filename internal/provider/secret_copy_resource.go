@@ -0,0 +1,317 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource              = &SecretCopyResource{}
+	_ resource.ResourceWithConfigure = &SecretCopyResource{}
+)
+
+// SecretCopyResource copies a secret to another path or mount, like `gopass cp`.
+type SecretCopyResource struct {
+	client *GopassClient
+}
+
+// SecretCopyResourceModel describes the resource data model.
+type SecretCopyResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Source         types.String `tfsdk:"source"`
+	Destination    types.String `tfsdk:"destination"`
+	CopyVersion    types.Int64  `tfsdk:"copy_version"`
+	Overwrite      types.Bool   `tfsdk:"overwrite"`
+	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
+	Timeouts       types.Object `tfsdk:"timeouts"`
+}
+
+// NewSecretCopyResource creates a new instance.
+func NewSecretCopyResource() resource.Resource {
+	return &SecretCopyResource{}
+}
+
+func (r *SecretCopyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_copy"
+}
+
+func (r *SecretCopyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Copies a secret to another path or mount (like `gopass cp`) and tracks the destination.",
+		MarkdownDescription: `
+Copies a secret, including all fields and the body, to another path or mount (like ` + "`gopass cp`" + `)
+and tracks the destination. The secret never passes through Terraform state.
+
+This is useful to promote credentials from a staging mount to a production mount during releases.
+The copy is encrypted for the recipients of the destination.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_secret_copy" "api_key" {
+  source       = "staging/service/api_key"
+  destination  = "production/service/api_key"
+  copy_version = 3 # increment to promote the current staging value again
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The destination path (same as destination attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"source": schema.StringAttribute{
+				Description: "Path of the secret to copy. Changing it copies the new source to the destination.",
+				Required:    true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"destination": schema.StringAttribute{
+				Description: "Path the secret is copied to.",
+				Required:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"copy_version": schema.Int64Attribute{
+				Description:         "Version number of the copy. Increment this to copy the current source again.",
+				MarkdownDescription: "Version number of the copy. **Increment this** to copy the current source again.",
+				Optional:            true,
+			},
+			"overwrite": schema.BoolAttribute{
+				Description: "Whether an existing secret at destination may be replaced on create. " +
+					"Defaults to false, so secrets not managed by Terraform are never overwritten by accident.",
+				MarkdownDescription: "Whether an existing secret at `destination` may be replaced on create. " +
+					"Defaults to `false`, so secrets not managed by Terraform are never overwritten by accident.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description:         "Whether to delete the destination secret when the resource is destroyed. Defaults to true.",
+				MarkdownDescription: "Whether to delete the destination secret when the resource is destroyed. Defaults to `true`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"timeouts": resourceTimeoutsAttribute(),
+		},
+	}
+}
+
+func (r *SecretCopyResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretCopyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretCopyResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	source := data.Source.ValueString()
+	destination := data.Destination.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutCreate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	if !data.Overwrite.ValueBool() {
+		exists, err := r.client.SecretExists(ctx, destination)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to copy secret",
+				errorDetail(err, fmt.Sprintf("Could not check destination %q: %s", destination, err.Error())),
+			)
+			return
+		}
+		if exists {
+			resp.Diagnostics.AddError(
+				"Destination already exists",
+				codedDetail(CodeAlreadyExists, fmt.Sprintf("A secret already exists at %q. Set overwrite = true to replace it, "+
+					"or import it into a gopass_secret resource instead.", destination)),
+			)
+			return
+		}
+	}
+
+	if err := r.client.CopySecret(ctx, source, destination); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to copy secret",
+			errorDetail(err, fmt.Sprintf("Could not copy secret %q to %q: %s", source, destination, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Copied gopass secret", map[string]interface{}{
+		"source":      source,
+		"destination": destination,
+	})
+
+	data.ID = data.Destination
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretCopyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretCopyResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	destination := data.Destination.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutRead)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	exists, err := r.client.SecretExists(ctx, destination)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read secret copy",
+			errorDetail(err, fmt.Sprintf("Could not check destination %q: %s", destination, err.Error())),
+		)
+		return
+	}
+
+	if !exists {
+		// The copy was removed outside of Terraform
+		tflog.Warn(ctx, "Copied secret no longer exists, removing from state", map[string]interface{}{
+			"destination": destination,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update copies the source again if source or copy_version changed.
+//
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretCopyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data, state SecretCopyResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	source := data.Source.ValueString()
+	destination := data.Destination.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutUpdate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	if !data.Source.Equal(state.Source) || !data.CopyVersion.Equal(state.CopyVersion) {
+		// The destination is managed by this resource, so it is replaced regardless of overwrite
+		if err := r.client.CopySecret(ctx, source, destination); err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to copy secret",
+				errorDetail(err, fmt.Sprintf("Could not copy secret %q to %q: %s", source, destination, err.Error())),
+			)
+			return
+		}
+		tflog.Info(ctx, "Copied gopass secret again", map[string]interface{}{
+			"source":      source,
+			"destination": destination,
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretCopyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretCopyResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	destination := data.Destination.ValueString()
+
+	if !data.DeleteOnRemove.ValueBool() {
+		tflog.Info(ctx, "Keeping copied gopass secret (delete_on_remove=false)", map[string]interface{}{
+			"destination": destination,
+		})
+		return
+	}
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	if err := r.client.RemoveSecret(ctx, destination); err != nil && !isNotFoundError(err) {
+		resp.Diagnostics.AddError(
+			"Failed to remove secret copy",
+			errorDetail(err, fmt.Sprintf("Could not remove secret from gopass at %q: %s", destination, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed copied gopass secret", map[string]interface{}{
+		"destination": destination,
+	})
+}
@@ -0,0 +1,289 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// copyValue builds a raw gopass_secret_copy object. A nil version is null.
+func copyValue(schemaResp resource.SchemaResponse, source string, version any, overwrite, deleteOnRemove bool) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "production/api"),
+		"source":           tftypes.NewValue(tftypes.String, source),
+		"destination":      tftypes.NewValue(tftypes.String, "production/api"),
+		"copy_version":     tftypes.NewValue(tftypes.Number, version),
+		"overwrite":        tftypes.NewValue(tftypes.Bool, overwrite),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, deleteOnRemove),
+	})
+}
+
+// storeWith returns a mock store holding the given secrets, keyed by path with their passwords as values.
+func storeWith(passwords map[string]string) *mockStore {
+	store := newMockStore()
+	for path, password := range passwords {
+		store.secrets[path] = newMockSecret(password)
+	}
+	return store
+}
+
+func TestSecretCopyResource_Metadata(t *testing.T) {
+	r := NewSecretCopyResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secret_copy" {
+		t.Errorf("expected 'gopass_secret_copy', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretCopyResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &SecretCopyResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &SecretCopyResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestSecretCopyResource_Create(t *testing.T) {
+	tests := []struct {
+		name         string
+		secrets      map[string]string
+		source       string
+		overwrite    bool
+		failGet      string
+		wantErr      string
+		wantPassword string
+	}{
+		{name: "copy", secrets: map[string]string{"staging/api": "new"}, source: "staging/api", wantPassword: "new"},
+		{name: "overwrite existing", secrets: map[string]string{"staging/api": "new", "production/api": "old"}, source: "staging/api", overwrite: true, wantPassword: "new"},
+		{name: "destination exists", secrets: map[string]string{"staging/api": "new", "production/api": "old"}, source: "staging/api", wantErr: "Destination already exists", wantPassword: "old"},
+		{name: "destination check fails", secrets: map[string]string{"staging/api": "new"}, source: "staging/api", failGet: "production/api", wantErr: "Failed to copy secret"},
+		{name: "missing source", secrets: map[string]string{}, source: "staging/api", wantErr: "Failed to copy secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &mockStoreWithSelectiveFailure{mockStore: storeWith(tc.secrets), failOnGet: map[string]bool{tc.failGet: true}}
+			r, schemaResp := testResource(&SecretCopyResource{client: clientWith(store)})
+			plan := copyValue(schemaResp, tc.source, 1, tc.overwrite, true)
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+			if tc.wantErr == "" && resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if tc.wantErr != "" && (!resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr) {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantPassword != "" && store.secrets["production/api"].Password() != tc.wantPassword {
+				t.Errorf("expected destination password %q, got %q", tc.wantPassword, store.secrets["production/api"].Password())
+			}
+		})
+	}
+
+	t.Run("invalid plan", func(t *testing.T) {
+		r, schemaResp := testResource(&SecretCopyResource{client: clientWith(newMockStore())})
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from Plan.Get")
+		}
+	})
+}
+
+func TestSecretCopyResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		secrets     map[string]string
+		fail        bool
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "present", secrets: map[string]string{"production/api": "x"}},
+		{name: "removed externally", secrets: map[string]string{}, wantRemoved: true},
+		{name: "store failure", secrets: map[string]string{}, fail: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(tc.secrets)
+			store.shouldFail = tc.fail
+			store.failMsg = "decryption failed"
+			r, schemaResp := testResource(&SecretCopyResource{client: clientWith(store)})
+			raw := copyValue(schemaResp, "staging/api", 1, false, true)
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if removed := resp.State.Raw.IsNull(); !tc.wantErr && removed != tc.wantRemoved {
+				t.Errorf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&SecretCopyResource{client: clientWith(newMockStore())})
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestSecretCopyResource_Update(t *testing.T) {
+	tests := []struct {
+		name         string
+		planSource   string
+		stateVersion any
+		planVersion  any
+		wantPassword string
+		wantErr      bool
+	}{
+		{name: "version changed", planSource: "staging/api", stateVersion: 1, planVersion: 2, wantPassword: "staging"},
+		{name: "source changed", planSource: "hotfix/api", stateVersion: 1, planVersion: 1, wantPassword: "hotfix"},
+		{name: "unchanged", planSource: "staging/api", stateVersion: 1, planVersion: 1, wantPassword: "current"},
+		{name: "missing source", planSource: "missing/api", stateVersion: 1, planVersion: 1, wantPassword: "current", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"staging/api": "staging", "hotfix/api": "hotfix", "production/api": "current"})
+			r, schemaResp := testResource(&SecretCopyResource{client: clientWith(store)})
+			state := copyValue(schemaResp, "staging/api", tc.stateVersion, false, true)
+			plan := copyValue(schemaResp, tc.planSource, tc.planVersion, false, true)
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if got := store.secrets["production/api"].Password(); got != tc.wantPassword {
+				t.Errorf("expected destination password %q, got %q", tc.wantPassword, got)
+			}
+		})
+	}
+
+	t.Run("invalid plan", func(t *testing.T) {
+		r, schemaResp := testResource(&SecretCopyResource{client: clientWith(newMockStore())})
+		resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Update(context.Background(), resource.UpdateRequest{
+			Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: invalidRaw},
+			State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw},
+		}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from Plan.Get")
+		}
+	})
+}
+
+func TestSecretCopyResource_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		secrets        map[string]string
+		deleteOnRemove bool
+		fail           bool
+		wantExists     bool
+		wantErr        bool
+	}{
+		{name: "remove", secrets: map[string]string{"production/api": "x"}, deleteOnRemove: true},
+		{name: "already removed", secrets: map[string]string{}, deleteOnRemove: true},
+		{name: "keep", secrets: map[string]string{"production/api": "x"}, wantExists: true},
+		{name: "store failure", secrets: map[string]string{"production/api": "x"}, deleteOnRemove: true, fail: true, wantExists: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(tc.secrets)
+			store.shouldFail = tc.fail
+			store.failMsg = "permission denied"
+			r, schemaResp := testResource(&SecretCopyResource{client: clientWith(store)})
+			raw := copyValue(schemaResp, "staging/api", 1, false, tc.deleteOnRemove)
+
+			resp := &resource.DeleteResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if _, exists := store.secrets["production/api"]; exists != tc.wantExists {
+				t.Errorf("expected exists=%v, got %v", tc.wantExists, exists)
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&SecretCopyResource{client: clientWith(newMockStore())})
+		resp := &resource.DeleteResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestSecretCopyResource_InvalidTimeouts(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp := testResource(&SecretCopyResource{client: clientWith(newMockStore())})
+	s := schemaResp.Schema
+
+	raw := func(op string) tftypes.Value {
+		return schemaObjectValue(s, map[string]tftypes.Value{
+			"source":           tftypes.NewValue(tftypes.String, "staging/api"),
+			"destination":      tftypes.NewValue(tftypes.String, "production/api"),
+			"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+			"timeouts":         timeoutsRaw(s, op, "never"),
+		})
+	}
+
+	createResp := &resource.CreateResponse{State: tfsdk.State{Schema: s}}
+	r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: s, Raw: raw(timeoutCreate)}}, createResp)
+	assertInvalidTimeouts(t, createResp.Diagnostics.Errors())
+
+	readResp := &resource.ReadResponse{State: tfsdk.State{Schema: s}}
+	r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutRead)}}, readResp)
+	assertInvalidTimeouts(t, readResp.Diagnostics.Errors())
+
+	updateResp := &resource.UpdateResponse{State: tfsdk.State{Schema: s}}
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:  tfsdk.Plan{Schema: s, Raw: raw(timeoutUpdate)},
+		State: tfsdk.State{Schema: s, Raw: raw(timeoutUpdate)},
+	}, updateResp)
+	assertInvalidTimeouts(t, updateResp.Diagnostics.Errors())
+
+	deleteResp := &resource.DeleteResponse{State: tfsdk.State{Schema: s}}
+	r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutDelete)}}, deleteResp)
+	assertInvalidTimeouts(t, deleteResp.Diagnostics.Errors())
+}
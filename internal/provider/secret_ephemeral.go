@@ -7,14 +7,23 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-// Ensure implementation satisfies interface.
-var _ ephemeral.EphemeralResource = &SecretEphemeralResource{}
+// Ensure implementation satisfies interfaces.
+var (
+	_ ephemeral.EphemeralResource          = &SecretEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithRenew = &SecretEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose = &SecretEphemeralResource{}
+
+	_ ephemeral.EphemeralResourceWithValidateConfig = &SecretEphemeralResource{}
+)
 
 // SecretEphemeralResource reads a single secret from gopass.
 type SecretEphemeralResource struct {
@@ -23,8 +32,21 @@ type SecretEphemeralResource struct {
 
 // SecretModel describes the data model.
 type SecretModel struct {
-	Path  types.String `tfsdk:"path"`
-	Value types.String `tfsdk:"value"`
+	Path          types.String `tfsdk:"path"`
+	Store         types.String `tfsdk:"store"`
+	Key           types.String `tfsdk:"key"`
+	Return        types.String `tfsdk:"return"`
+	Snapshot      types.String `tfsdk:"snapshot"`
+	Revision      types.String `tfsdk:"revision"`
+	ExpectSHA256  types.String `tfsdk:"expect_sha256"`
+	RenewInterval types.String `tfsdk:"renew_interval"`
+	Template      types.String `tfsdk:"template"`
+	Timeouts      types.Object `tfsdk:"timeouts"`
+	Value         types.String `tfsdk:"value"`
+	Password      types.String `tfsdk:"password"`
+	Body          types.String `tfsdk:"body"`
+	Fields        types.Map    `tfsdk:"fields"`
+	Rendered      types.String `tfsdk:"rendered"`
 }
 
 // NewSecretEphemeralResource creates a new instance.
@@ -56,6 +78,48 @@ ephemeral "gopass_secret" "api_key" {
 provider "example" {
   api_key = ephemeral.gopass_secret.api_key.value
 }
+
+# Read a field instead of the password, like ` + "`gopass show websites/app/login username`" + `
+ephemeral "gopass_secret" "app_user" {
+  path = "websites/app/login"
+  key  = "username"
+}
+
+# Read the secret as it was at a tagged release
+ephemeral "gopass_secret" "api_key_v1" {
+  path     = "services/api/token"
+  snapshot = "release-1.0"
+}
+
+# Use several fields of one secret, decrypting it only once
+provider "example" {
+  username = ephemeral.gopass_secret.app_user.fields["username"]
+  password = ephemeral.gopass_secret.app_user.password
+}
+
+# Roll back to the revision before the latest, e.g. during an incident
+ephemeral "gopass_secret" "api_key_previous" {
+  path     = "services/api/token"
+  revision = "-1"
+}
+
+# Read a multi-line credential, e.g. a service account key stored as JSON below the first line
+ephemeral "gopass_secret" "gcp_sa" {
+  path   = "cloud/gcp/terraform-sa"
+  return = "body"
+}
+
+# Fail unless the secret is the expected credential
+ephemeral "gopass_secret" "deploy_key" {
+  path          = "ci/deploy/key"
+  expect_sha256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+}
+
+# Build a connection string from the password and the user and host fields
+ephemeral "gopass_secret" "db" {
+  path     = "infrastructure/db/app"
+  template = "postgres://{{.user}}:{{.password | urlquery}}@{{.host}}/app"
+}
 ` + "```" + `
 
 ## GPG/Hardware Token
@@ -69,10 +133,100 @@ Terraform operation that accesses the secret.
 				Description:         "Path to the secret in the gopass store (e.g., 'infrastructure/db/password').",
 				MarkdownDescription: "Path to the secret in the gopass store (e.g., `infrastructure/db/password`).",
 				Required:            true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"store": storeAttribute(),
+			"key": schema.StringAttribute{
+				Description: "Name of a field of the secret (e.g. 'username') to return instead of the password, " +
+					"like `gopass show path key`. Reading fails if the secret has no such field.",
+				MarkdownDescription: "Name of a field of the secret (e.g. `username`) to return instead of the password, " +
+					"like `gopass show path key`. Reading fails if the secret has no such field.",
+				Optional: true,
+			},
+			"return": schema.StringAttribute{
+				Description: "Part of the secret to return as value: 'password' for the first line (or the field named by key), " +
+					"'body' for all lines after the first one, verbatim, or 'raw' for the whole secret. " +
+					"Defaults to 'password'. Only 'password' can be combined with key.",
+				MarkdownDescription: "Part of the secret to return as `value`: `password` for the first line (or the field named by `key`), " +
+					"`body` for all lines after the first one, verbatim, or `raw` for the whole secret. " +
+					"Defaults to `password`. Only `password` can be combined with `key`.",
+				Optional: true,
 			},
+			"snapshot": schema.StringAttribute{
+				Description: "Git ref (tag, branch or commit) to read the secret from instead of the latest revision. " +
+					"Requires a git-backed store and mode = \"cli\".",
+				MarkdownDescription: "Git ref (tag, branch or commit) to read the secret from instead of the latest revision. " +
+					"Requires a git-backed store and `mode = \"cli\"`.",
+				Optional: true,
+				Validators: []validator.String{
+					validSnapshotRef(),
+				},
+			},
+			"revision": schema.StringAttribute{
+				Description: "Revision of the secret to read instead of the latest one: a commit hash as listed by " +
+					"`gopass history`, or -N for the Nth revision before the latest (e.g. -1). Conflicts with snapshot. " +
+					"Requires mode = \"cli\", as the gopass library only reads the latest revision.",
+				MarkdownDescription: "Revision of the secret to read instead of the latest one: a commit hash as listed by " +
+					"`gopass history`, or `-N` for the Nth revision before the latest (e.g. `-1`). Conflicts with `snapshot`. " +
+					"Requires `mode = \"cli\"`, as the gopass library only reads the latest revision.",
+				Optional: true,
+			},
+			"expect_sha256": schema.StringAttribute{
+				Description: "Hex-encoded SHA-256 digest the value must have, e.g. from `printf %s \"$value\" | sha256sum`. " +
+					"Opening fails if the value read from gopass does not match, without revealing it.",
+				MarkdownDescription: "Hex-encoded SHA-256 digest the value must have, e.g. from `printf %s \"$value\" | sha256sum`. " +
+					"Opening fails if the value read from gopass does not match, without revealing it.",
+				Optional: true,
+			},
+			"renew_interval": schema.StringAttribute{
+				Description: "If set (e.g. '10m'), Terraform periodically re-reads the secret during long operations " +
+					"and warns if it was rotated in the meantime. The value already handed out is not replaced.",
+				MarkdownDescription: "If set (e.g. `10m`), Terraform periodically re-reads the secret during long operations " +
+					"and warns if it was rotated in the meantime. The value already handed out is not replaced.",
+				Optional: true,
+			},
+			"template": schema.StringAttribute{
+				Description: "Go template rendered into rendered, e.g. 'postgres://{{.user}}:{{.password}}@{{.host}}'. " +
+					"It refers to the fields of the secret by key, and to the password and the body as .password and .body. " +
+					"Rendering fails if the template refers to a field the secret does not have.",
+				MarkdownDescription: "[Go template](https://pkg.go.dev/text/template) rendered into `rendered`, e.g. " +
+					"`postgres://{{.user}}:{{.password}}@{{.host}}`. It refers to the fields of the secret by key, and to the " +
+					"password and the body as `.password` and `.body`. Rendering fails if the template refers to a field the secret does not have.",
+				Optional: true,
+			},
+			"timeouts": ephemeralTimeoutsAttribute(),
 			"value": schema.StringAttribute{
-				Description:         "The secret value (password/first line of the secret).",
-				MarkdownDescription: "The secret value (password/first line of the secret).",
+				Description:         "The secret value (password/first line of the secret, or the field named by key), or the part named by return.",
+				MarkdownDescription: "The secret value (password/first line of the secret, or the field named by `key`), or the part named by `return`.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"password": schema.StringAttribute{
+				Description:         "The password (first line) of the secret, regardless of key.",
+				MarkdownDescription: "The password (first line) of the secret, regardless of `key`.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"body": schema.StringAttribute{
+				Description:         "The lines of the secret after the password that are not key-value fields, e.g. notes.",
+				MarkdownDescription: "The lines of the secret after the password that are not key-value fields, e.g. notes.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"fields": schema.MapAttribute{
+				Description: "The key-value fields of the secret (lines like 'username: admin'), by key. " +
+					"Of a key that occurs more than once, the first value.",
+				MarkdownDescription: "The key-value fields of the secret (lines like `username: admin`), by key. " +
+					"Of a key that occurs more than once, the first value.",
+				ElementType: types.StringType,
+				Computed:    true,
+				Sensitive:   true,
+			},
+			"rendered": schema.StringAttribute{
+				Description:         "The result of template, or null without a template.",
+				MarkdownDescription: "The result of `template`, or null without a `template`.",
 				Computed:            true,
 				Sensitive:           true,
 			},
@@ -89,7 +243,7 @@ func (r *SecretEphemeralResource) Configure(ctx context.Context, req ephemeral.C
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Provider Data",
-			fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData),
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
 		)
 		return
 	}
@@ -97,7 +251,80 @@ func (r *SecretEphemeralResource) Configure(ctx context.Context, req ephemeral.C
 	r.client = client
 }
 
+func (r *SecretEphemeralResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data SecretModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateExpectSHA256(&resp.Diagnostics, data.ExpectSHA256)
+	validateReturn(&resp.Diagnostics, data.Return, data.Key)
+	if !data.Template.IsNull() && !data.Template.IsUnknown() {
+		if _, err := parseSecretTemplate(data.Template.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("template"),
+				"Invalid template",
+				codedDetail(CodeInvalidConfig, err.Error()),
+			)
+		}
+	}
+	if !data.Path.IsUnknown() {
+		validateStore(&resp.Diagnostics, data.Store, []string{data.Path.ValueString()})
+	}
+
+	if data.Revision.IsNull() || data.Revision.IsUnknown() {
+		return
+	}
+	if !data.Snapshot.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("revision"),
+			"Conflicting snapshot and revision",
+			codedDetail(CodeInvalidConfig, "Both snapshot and revision are set. Use snapshot for a git ref of the whole store, "+
+				"or revision for a revision of this secret."),
+		)
+	}
+	if _, _, err := parseRelativeRevision(data.Revision.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("revision"),
+			"Invalid revision",
+			codedDetail(CodeInvalidConfig, err.Error()),
+		)
+	}
+}
+
+// validateReturn adds an error if return is not a part SecretContent.Select knows, or if a
+// part other than the password is combined with key.
+func validateReturn(diags *diag.Diagnostics, mode, key types.String) {
+	if mode.IsNull() || mode.IsUnknown() {
+		return
+	}
+
+	switch mode.ValueString() {
+	case returnPassword:
+	case returnBody, returnRaw:
+		if !key.IsNull() {
+			diags.AddAttributeError(
+				path.Root("return"),
+				"Conflicting key and return",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("key selects a field, which return = %q does not return. "+
+					"Remove key, or read the field from fields.", mode.ValueString())),
+			)
+		}
+	default:
+		diags.AddAttributeError(
+			path.Root("return"),
+			"Invalid return",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("return must be %q, %q or %q, got %q.",
+				returnPassword, returnBody, returnRaw, mode.ValueString())),
+		)
+	}
+}
+
 func (r *SecretEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	ctx = r.client.logContext(ctx)
+
 	var data SecretModel
 
 	// Read configuration
@@ -106,28 +333,157 @@ func (r *SecretEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRe
 		return
 	}
 
-	path := data.Path.ValueString()
+	key := data.Key.ValueString()
+	mode := data.Return.ValueString()
+	snapshot := data.Snapshot.ValueString()
+
+	renewInterval, err := parseRenewInterval(data.RenewInterval)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid renew_interval",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not parse renew_interval: %s", err.Error())),
+		)
+		return
+	}
+
+	timeout, err := operationTimeout(data.Timeouts, timeoutOpen)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	secretPath, err := r.client.MountedPath(ctx, data.Store.ValueString(), data.Path.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("store"),
+			"Failed to read secret",
+			errorDetail(err, fmt.Sprintf("Could not read secret at path %q: %s", data.Path.ValueString(), err.Error())),
+		)
+		return
+	}
 
 	tflog.Debug(ctx, "Reading secret from gopass", map[string]interface{}{
-		"path": path,
+		"path":     secretPath,
+		"key":      key,
+		"snapshot": snapshot,
+		"revision": data.Revision.ValueString(),
 	})
 
+	// A revision pins the secret like a snapshot. Relative revisions are resolved once,
+	// so renewals keep comparing against the same revision.
+	if !data.Revision.IsNull() {
+		snapshot, err = r.client.ResolveRevision(ctx, secretPath, data.Revision.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("revision"),
+				"Failed to read secret",
+				errorDetail(err, fmt.Sprintf("Could not resolve revision %q of secret at path %q: %s", data.Revision.ValueString(), secretPath, err.Error())),
+			)
+			return
+		}
+	}
+
 	// Use native gopass library
-	value, err := r.client.GetSecret(ctx, path)
+	content, err := r.client.GetSecretFull(ctx, secretPath, snapshot)
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to read secret",
-			fmt.Sprintf("Could not read secret at path %q: %s", path, err.Error()),
+			errorDetail(err, fmt.Sprintf("Could not read secret at path %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	value, ok := content.Select(mode, key)
+	if !ok {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("key"),
+			"Failed to read secret",
+			codedDetail(CodeError, fmt.Sprintf("Could not read secret at path %q: the secret has no key %q", secretPath, key)),
+		)
+		return
+	}
+
+	if !data.ExpectSHA256.IsNull() && !matchesSHA256(value, data.ExpectSHA256.ValueString()) {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("expect_sha256"),
+			"Secret checksum mismatch",
+			codedDetail(CodeChecksumMismatch, fmt.Sprintf(
+				"The SHA-256 digest of the secret at path %q does not match expect_sha256. "+
+					"The secret is not the expected credential, or it was rotated.", secretPath,
+			)),
 		)
 		return
 	}
 
+	if !data.Template.IsNull() {
+		rendered, err := renderSecretTemplate(data.Template.ValueString(), content)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("template"),
+				"Failed to render template",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not render the template with the secret at path %q: %s", secretPath, err.Error())),
+			)
+			return
+		}
+		data.Rendered = types.StringValue(rendered)
+	}
+
 	data.Value = types.StringValue(value)
+	data.Password = types.StringValue(content.Password)
+	data.Body = types.StringValue(content.Body)
+	fields, diags := types.MapValueFrom(ctx, types.StringType, content.Fields)
+	resp.Diagnostics.Append(diags...)
+	data.Fields = fields
 
 	// Set result - this is NEVER written to state
 	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
 
+	openRenewState(ctx, resp, &renewState{
+		Path:     secretPath,
+		Key:      key,
+		Return:   mode,
+		Snapshot: snapshot,
+		Interval: renewInterval,
+		Timeout:  timeout,
+		Digest:   digestValues(map[string]string{secretPath: value}),
+	})
+	openCloseState(ctx, r.client, resp, &closeState{Paths: []string{secretPath}})
+
 	tflog.Debug(ctx, "Successfully read secret from gopass", map[string]interface{}{
-		"path": path,
+		"path": secretPath,
+	})
+}
+
+// Renew re-reads the secret during long-running operations and warns if it changed since Open.
+func (r *SecretEphemeralResource) Renew(ctx context.Context, req ephemeral.RenewRequest, resp *ephemeral.RenewResponse) {
+	ctx = r.client.logContext(ctx)
+
+	renewSecrets(ctx, req, resp, func(ctx context.Context, state *renewState) (map[string]string, error) {
+		ctx = uncached(ctx)
+		if state.Return != "" && state.Return != returnPassword {
+			content, err := r.client.GetSecretFull(ctx, state.Path, state.Snapshot)
+			if err != nil {
+				return nil, err
+			}
+			value, _ := content.Select(state.Return, "")
+			return map[string]string{state.Path: value}, nil
+		}
+		value, err := r.client.GetSecretFieldAt(ctx, state.Path, state.Key, state.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{state.Path: value}, nil
 	})
 }
+
+// Close is called once Terraform no longer needs the secret. Private data only holds a
+// digest of it, but a caching client still holds the decrypted secret, which is dropped.
+func (r *SecretEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	ctx = r.client.logContext(ctx)
+
+	closeSecrets(ctx, r.client, req, resp)
+
+	tflog.Debug(ctx, "Closed ephemeral gopass secret")
+}
@@ -0,0 +1,216 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// serviceAccount is a multi-line credential as stored below a short first line.
+const serviceAccount = "gcp service account\n{\n  \"type\": \"service_account\",\n  \"project_id\": \"demo\"\n}\n"
+
+func TestSecretContent_Select(t *testing.T) {
+	content := &SecretContent{Password: "hunter2", Fields: map[string]string{"username": "admin"}, Raw: "hunter2\nusername: admin\n"}
+
+	tests := []struct {
+		name   string
+		mode   string
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{name: "default", want: "hunter2", wantOK: true},
+		{name: "password", mode: returnPassword, want: "hunter2", wantOK: true},
+		{name: "password field", mode: returnPassword, key: "username", want: "admin", wantOK: true},
+		{name: "missing field", key: "email"},
+		{name: "body", mode: returnBody, want: "username: admin\n", wantOK: true},
+		{name: "raw", mode: returnRaw, want: "hunter2\nusername: admin\n", wantOK: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := content.Select(tc.mode, tc.key)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("expected %q, %v, got %q, %v", tc.want, tc.wantOK, got, ok)
+			}
+		})
+	}
+
+	if got, _ := (&SecretContent{Raw: "single line"}).Select(returnBody, ""); got != "" {
+		t.Errorf("expected an empty body of a single-line secret, got %q", got)
+	}
+}
+
+func TestSecretEphemeralResource_ValidateConfig_Return(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    any
+		key     any
+		wantErr string
+	}{
+		{name: "unset"},
+		{name: "unknown", mode: tftypes.UnknownValue, key: "username"},
+		{name: "password with key", mode: "password", key: "username"},
+		{name: "body", mode: "body"},
+		{name: "raw", mode: "raw"},
+		{name: "body with key", mode: "body", key: "username", wantErr: "Conflicting key and return"},
+		{name: "raw with key", mode: "raw", key: "username", wantErr: "Conflicting key and return"},
+		{name: "invalid", mode: "fields", wantErr: "Invalid return"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretEphemeralResource{}
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"path":   tftypes.NewValue(tftypes.String, "cloud/gcp/sa"),
+					"return": tftypes.NewValue(tftypes.String, tc.mode),
+					"key":    tftypes.NewValue(tftypes.String, tc.key),
+				})},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+// openSecretWithReturn opens the secret at cloud/gcp/sa with return mode and the given
+// expect_sha256 and renew_interval.
+func openSecretWithReturn(t *testing.T, r *SecretEphemeralResource, mode, expectSHA256, interval any) *ephemeral.OpenResponse {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	resp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":           tftypes.NewValue(tftypes.String, "cloud/gcp/sa"),
+			"return":         tftypes.NewValue(tftypes.String, mode),
+			"expect_sha256":  tftypes.NewValue(tftypes.String, expectSHA256),
+			"renew_interval": tftypes.NewValue(tftypes.String, interval),
+		})},
+	}, resp)
+	return resp
+}
+
+func TestSecretEphemeralResource_Open_Return(t *testing.T) {
+	body := "{\n  \"type\": \"service_account\",\n  \"project_id\": \"demo\"\n}\n"
+	bodyDigest := sha256.Sum256([]byte(body))
+
+	tests := []struct {
+		name         string
+		mode         any
+		expectSHA256 any
+		want         string
+		wantErr      string
+	}{
+		{name: "default", want: "gcp service account"},
+		{name: "password", mode: "password", want: "gcp service account"},
+		{name: "body", mode: "body", want: body},
+		{name: "raw", mode: "raw", want: serviceAccount},
+		{name: "checksum of body", mode: "body", expectSHA256: hex.EncodeToString(bodyDigest[:]), want: body},
+		{name: "checksum of password", mode: "raw", expectSHA256: hex.EncodeToString(bodyDigest[:]), wantErr: "Secret checksum mismatch"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			store.secrets["cloud/gcp/sa"] = secrets.ParseAKV([]byte(serviceAccount))
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretEphemeralResource{client: client}
+
+			resp := openSecretWithReturn(t, r, tc.mode, tc.expectSHA256, nil)
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Errorf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var result SecretModel
+			resp.Result.Get(context.Background(), &result)
+			if result.Value.ValueString() != tc.want {
+				t.Errorf("expected value %q, got %q", tc.want, result.Value.ValueString())
+			}
+			if result.Password.ValueString() != "gcp service account" {
+				t.Errorf("expected the password regardless of return, got %q", result.Password.ValueString())
+			}
+		})
+	}
+}
+
+func TestSecretEphemeralResource_Renew_Return(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.secrets["cloud/gcp/sa"] = secrets.ParseAKV([]byte(serviceAccount))
+	client := NewGopassClient("")
+	client.store = store
+	r := &SecretEphemeralResource{client: client}
+
+	openResp := openSecretWithReturn(t, r, "body", nil, "5m")
+	if openResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", openResp.Diagnostics)
+	}
+
+	tests := []struct {
+		name        string
+		secret      string
+		wantWarning string
+	}{
+		{name: "unchanged", secret: serviceAccount},
+		{name: "password rotated", secret: "renamed account\n" + serviceAccount[len("gcp service account\n"):]},
+		{name: "body rotated", secret: "gcp service account\n{}\n", wantWarning: "Secret changed during operation"},
+		{name: "read error", wantWarning: "Failed to renew secret"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			delete(store.secrets, "cloud/gcp/sa")
+			if tc.secret != "" {
+				store.secrets["cloud/gcp/sa"] = secrets.ParseAKV([]byte(tc.secret))
+			}
+
+			resp := &ephemeral.RenewResponse{}
+			r.Renew(ctx, ephemeral.RenewRequest{Private: openResp.Private}, resp)
+			if tc.wantWarning == "" {
+				if len(resp.Diagnostics) != 0 {
+					t.Errorf("expected no diagnostics, got %v", resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.WarningsCount() != 1 || resp.Diagnostics[0].Summary() != tc.wantWarning {
+				t.Errorf("expected %q warning, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+		})
+	}
+}
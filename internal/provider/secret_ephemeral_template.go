@@ -0,0 +1,44 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+)
+
+// parseSecretTemplate parses the template attribute of gopass_secret. Referring to a field the
+// secret does not have is an error when rendering, so a typo cannot produce a broken value.
+func parseSecretTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderSecretTemplate applies the template text to content. The template refers to the fields
+// by key, e.g. {{.user}}, and to the password and the body as {{.password}} and {{.body}}, which
+// take precedence over fields of the same names.
+func renderSecretTemplate(text string, content *SecretContent) (string, error) {
+	tmpl, err := parseSecretTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := maps.Clone(content.Fields)
+	if data == nil {
+		data = make(map[string]string, 2)
+	}
+	data["password"] = content.Password
+	data["body"] = content.Body
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &SecretInfoDataSource{}
+	_ datasource.DataSourceWithConfigure = &SecretInfoDataSource{}
+)
+
+// SecretInfoDataSource exposes metadata about a secret without its values.
+type SecretInfoDataSource struct {
+	client *GopassClient
+}
+
+// SecretInfoDataSourceModel describes the data source data model.
+type SecretInfoDataSourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Path           types.String `tfsdk:"path"`
+	PathComponents types.List   `tfsdk:"path_components"`
+	Exists         types.Bool   `tfsdk:"exists"`
+	RevisionCount  types.Int64  `tfsdk:"revision_count"`
+	LastModified   types.String `tfsdk:"last_modified"`
+	Keys           types.List   `tfsdk:"keys"`
+	Recipients     types.List   `tfsdk:"recipients"`
+}
+
+// NewSecretInfoDataSource creates a new instance.
+func NewSecretInfoDataSource() datasource.DataSource {
+	return &SecretInfoDataSource{}
+}
+
+func (d *SecretInfoDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_info"
+}
+
+func (d *SecretInfoDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Exposes metadata about a secret (existence, revisions, key names) without its values.",
+		MarkdownDescription: `
+Exposes metadata about a secret - whether it exists, its revision count, when it was last
+modified and the names of its fields - **without its values**. No plaintext ever passes
+through Terraform, so this is safe to use for dependency ordering and preconditions.
+
+A missing secret is not an error; ` + "`exists`" + ` is ` + "`false`" + ` instead.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_secret_info" "db" {
+  path = "prod/db/password"
+}
+
+resource "null_resource" "deploy" {
+  lifecycle {
+    precondition {
+      condition     = data.gopass_secret_info.db.exists
+      error_message = "prod/db/password must be created in gopass first"
+    }
+  }
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path of the secret (same as path attribute).",
+				Computed:    true,
+			},
+			"path": schema.StringAttribute{
+				Description:         "Path of the secret in the gopass store (e.g., 'prod/db/password').",
+				MarkdownDescription: "Path of the secret in the gopass store (e.g., `prod/db/password`).",
+				Required:            true,
+				Validators:          []validator.String{validSecretPath()},
+			},
+			"path_components": schema.ListAttribute{
+				Description:         pathComponentsDescription,
+				MarkdownDescription: pathComponentsMarkdownDescription,
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"exists": schema.BoolAttribute{
+				Description: "Whether a secret exists at path.",
+				Computed:    true,
+			},
+			"revision_count": schema.Int64Attribute{
+				Description: "Number of revisions of the secret. 0 if it does not exist, " +
+					"1 if the store does not keep a history.",
+				Computed: true,
+			},
+			"last_modified": schema.StringAttribute{
+				Description: "Time of the last commit that modified the secret, in RFC 3339 format. " +
+					"Null if the secret does not exist or the store is not git-backed.",
+				Computed: true,
+			},
+			"keys": schema.ListAttribute{
+				Description: "Sorted names of the key-value fields of the secret. Values are never exposed.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"recipients": schema.ListAttribute{
+				Description: "Key IDs the secret is encrypted for, from the recipients file closest to its folder, " +
+					"as for gopass_recipients. Also set if the secret does not exist yet. " +
+					"Null if no recipients file is found.",
+				MarkdownDescription: "Key IDs the secret is encrypted for, from the recipients file closest to its folder, " +
+					"as for `gopass_recipients`. Also set if the secret does not exist yet. " +
+					"Null if no recipients file is found.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *SecretInfoDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *SecretInfoDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	var data SecretInfoDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	info, err := d.client.GetSecretInfo(ctx, secretPath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read secret info",
+			errorDetail(err, fmt.Sprintf("Could not read secret from gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Read gopass secret info", map[string]interface{}{
+		"path":   secretPath,
+		"exists": info.Exists,
+	})
+
+	keys, diags := types.ListValueFrom(ctx, types.StringType, info.Keys)
+	resp.Diagnostics.Append(diags...)
+
+	data.ID = data.Path
+	data.PathComponents = pathComponents(d.client.resolveMountPath(ctx, secretPath))
+	data.Exists = types.BoolValue(info.Exists)
+	data.RevisionCount = types.Int64Value(info.RevisionCount)
+	data.LastModified = types.StringNull()
+	if info.LastModified != "" {
+		data.LastModified = types.StringValue(info.LastModified)
+	}
+	data.Keys = keys
+
+	data.Recipients = types.ListNull(types.StringType)
+	if recipients, _, err := d.client.GetRecipients(ctx, secretDir(d.client.resolveMountPath(ctx, secretPath))); err != nil {
+		tflog.Debug(ctx, "No recipients for gopass secret info", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+	} else {
+		data.Recipients, diags = types.ListValueFrom(ctx, types.StringType, recipients)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,192 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readSecretInfo runs Read on the secret info data source for path.
+func readSecretInfo(t *testing.T, client *GopassClient, path string) (*datasource.ReadResponse, SecretInfoDataSourceModel) {
+	t.Helper()
+
+	d := &SecretInfoDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, path),
+			}),
+		},
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data SecretInfoDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func TestSecretInfoDataSource_Metadata(t *testing.T) {
+	d := NewSecretInfoDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secret_info" {
+		t.Errorf("expected type name 'gopass_secret_info', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretInfoDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &SecretInfoDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestSecretInfoDataSource_Read(t *testing.T) {
+	store := newMockStore()
+	secret := newMockSecret("s3cret")
+	secret.fields["user"] = "admin"
+	store.secrets["prod/db"] = secret
+	store.revisions["prod/db"] = []string{"1", "2"}
+
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".git/HEAD", "ref: refs/heads/main\n")
+	writeStoreFile(t, dir, ".gpg-id", "0xROOT\n")
+	writeStoreFile(t, dir, "prod/.gpg-id", "0xOPS\n0xBREAKGLASS\n")
+	writeStoreFile(t, dir, "prod/db/.gpg-id", "0xDBA\n")
+	client := NewGopassClient(dir)
+	client.store = store
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("abc123\x1f2026-01-02T03:04:05+00:00\x1fAlice <alice@example.com>\n", nil, &gotDir, &gotArgs)
+
+	resp, data := readSecretInfo(t, client, "prod/db")
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	if data.ID.ValueString() != "prod/db" {
+		t.Errorf("expected id 'prod/db', got %q", data.ID.ValueString())
+	}
+	if !data.Exists.ValueBool() {
+		t.Error("expected exists to be true")
+	}
+	if data.RevisionCount.ValueInt64() != 2 {
+		t.Errorf("expected revision_count 2, got %d", data.RevisionCount.ValueInt64())
+	}
+	if data.LastModified.ValueString() != "2026-01-02T03:04:05+00:00" {
+		t.Errorf("unexpected last_modified %q", data.LastModified.ValueString())
+	}
+	if len(data.Keys.Elements()) != 1 || data.Keys.Elements()[0].String() != `"user"` {
+		t.Errorf("expected keys [user], got %v", data.Keys)
+	}
+
+	// The secret prod/db is in the folder prod, not prod/db
+	var recipients []string
+	resp.Diagnostics.Append(data.Recipients.ElementsAs(context.Background(), &recipients, false)...)
+	if want := []string{"0xOPS", "0xBREAKGLASS"}; !reflect.DeepEqual(recipients, want) {
+		t.Errorf("expected recipients %v, got %v", want, recipients)
+	}
+}
+
+func TestSecretInfoDataSource_Read_Missing(t *testing.T) {
+	dir := t.TempDir()
+	writeStoreFile(t, dir, ".gpg-id", "0xROOT\n")
+	client := NewGopassClient(dir)
+	client.store = newMockStore()
+
+	resp, data := readSecretInfo(t, client, "prod/db")
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	if data.Exists.ValueBool() || data.RevisionCount.ValueInt64() != 0 {
+		t.Errorf("expected missing secret, got exists=%v revision_count=%d", data.Exists, data.RevisionCount)
+	}
+	if !data.LastModified.IsNull() {
+		t.Errorf("expected null last_modified, got %v", data.LastModified)
+	}
+	if data.Keys.IsNull() || len(data.Keys.Elements()) != 0 {
+		t.Errorf("expected empty keys, got %v", data.Keys)
+	}
+	if len(data.Recipients.Elements()) != 1 {
+		t.Errorf("expected the recipients a new secret would be encrypted for, got %v", data.Recipients)
+	}
+}
+
+func TestSecretInfoDataSource_Read_NoRecipientsFile(t *testing.T) {
+	client := NewGopassClient(t.TempDir())
+	client.store = storeWith(map[string]string{"prod/db": "s3cret"})
+
+	resp, data := readSecretInfo(t, client, "prod/db")
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if !data.Exists.ValueBool() || !data.Recipients.IsNull() {
+		t.Errorf("expected an existing secret with null recipients, got exists=%v recipients=%v", data.Exists, data.Recipients)
+	}
+}
+
+func TestSecretInfoDataSource_Read_Error(t *testing.T) {
+	client := NewGopassClient("")
+	failingStore("decryption failed")(client)
+
+	resp, _ := readSecretInfo(t, client, "prod/db")
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error when the secret cannot be read")
+	}
+}
+
+func TestSecretInfoDataSource_Read_InvalidConfig(t *testing.T) {
+	d := &SecretInfoDataSource{client: NewGopassClient("")}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
@@ -6,26 +6,35 @@ package provider
 import (
 	"context"
 	"fmt"
-	"strings"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure implementation satisfies interfaces.
 var (
-	_ resource.Resource                = &SecretResource{}
-	_ resource.ResourceWithConfigure   = &SecretResource{}
-	_ resource.ResourceWithImportState = &SecretResource{}
+	_ resource.Resource                   = &SecretResource{}
+	_ resource.ResourceWithConfigure      = &SecretResource{}
+	_ resource.ResourceWithImportState    = &SecretResource{}
+	_ resource.ResourceWithValidateConfig = &SecretResource{}
+	_ resource.ResourceWithModifyPlan     = &SecretResource{}
 )
 
+// defaultGenerateLength is the password length used by generate_if_missing unless configured otherwise.
+const defaultGenerateLength = 32
+
 // SecretResource writes secrets to gopass with write-only value support.
 type SecretResource struct {
 	client *GopassClient
@@ -33,12 +42,52 @@ type SecretResource struct {
 
 // SecretResourceModel describes the resource data model.
 type SecretResourceModel struct {
-	ID             types.String `tfsdk:"id"`
-	Path           types.String `tfsdk:"path"`
-	ValueWO        types.String `tfsdk:"value_wo"`
-	ValueWOVersion types.Int64  `tfsdk:"value_wo_version"`
-	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
-	RevisionCount  types.Int64  `tfsdk:"revision_count"`
+	ID                 types.String `tfsdk:"id"`
+	Path               types.String `tfsdk:"path"`
+	ValueWO            types.String `tfsdk:"value_wo"`
+	ValueWOVersion     types.Int64  `tfsdk:"value_wo_version"`
+	ValueFileWO        types.String `tfsdk:"value_file_wo"`
+	DeleteOnRemove     types.Bool   `tfsdk:"delete_on_remove"`
+	PurgeOnRemove      types.Bool   `tfsdk:"purge_on_remove"`
+	BackupBeforeUpdate types.Bool   `tfsdk:"backup_before_update"`
+	ConfirmOverwrite   types.Bool   `tfsdk:"confirm_overwrite_existing"`
+	OnlyIfAbsent       types.Bool   `tfsdk:"only_if_absent"`
+	VerifyAfterWrite   types.Bool   `tfsdk:"verify_after_write"`
+	BackupPrefix       types.String `tfsdk:"backup_prefix"`
+	GenerateIfMissing  types.Bool   `tfsdk:"generate_if_missing"`
+	GenerateLength     types.Int64  `tfsdk:"generate_length"`
+	GenerateSymbols    types.Bool   `tfsdk:"generate_symbols"`
+	Generate           types.Object `tfsdk:"generate"`
+	PreserveFields     types.Bool   `tfsdk:"preserve_existing_fields"`
+	Compose            types.Object `tfsdk:"compose"`
+	DataWO             types.Map    `tfsdk:"data_wo"`
+	DataWOVersion      types.Int64  `tfsdk:"data_wo_version"`
+	ValidateRegex      types.String `tfsdk:"validate_regex"`
+	MinLength          types.Int64  `tfsdk:"min_length"`
+	ForbidWhitespace   types.Bool   `tfsdk:"forbid_whitespace"`
+	Normalize          types.String `tfsdk:"normalize"`
+	ExpiresAt          types.String `tfsdk:"expires_at"`
+	Username           types.String `tfsdk:"username"`
+	URL                types.String `tfsdk:"url"`
+	Comment            types.String `tfsdk:"comment"`
+	ChunkSize          types.Int64  `tfsdk:"chunk_size"`
+	Timeouts           types.Object `tfsdk:"timeouts"`
+	RevisionTracking   types.String `tfsdk:"revision_tracking"`
+	OnExternalChange   types.String `tfsdk:"on_external_change"`
+	RevisionCount      types.Int64  `tfsdk:"revision_count"`
+	RevisionsSupported types.Bool   `tfsdk:"revisions_supported"`
+	Exists             types.Bool   `tfsdk:"exists"`
+	PathComponents     types.List   `tfsdk:"path_components"`
+	LastRevision       types.Object `tfsdk:"last_revision"`
+	CreatedAt          types.String `tfsdk:"created_at"`
+	UpdatedAt          types.String `tfsdk:"updated_at"`
+}
+
+// lastRevisionAttrTypes describes the last_revision nested attribute.
+var lastRevisionAttrTypes = map[string]attr.Type{
+	"hash":      types.StringType,
+	"timestamp": types.StringType,
+	"author":    types.StringType,
 }
 
 // NewSecretResource creates a new instance.
@@ -52,6 +101,7 @@ func (r *SecretResource) Metadata(ctx context.Context, req resource.MetadataRequ
 
 func (r *SecretResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
+		Version: secretSchemaVersion,
 		Description: "Writes a secret to the gopass store using write-only attributes. " +
 			"The secret value is never stored in Terraform state.",
 		MarkdownDescription: `
@@ -80,6 +130,36 @@ resource "gopass_secret" "db_password" {
   value_wo         = ephemeral.random_password.db.result
   value_wo_version = 1
 }
+
+# Let gopass generate a random value on create
+resource "gopass_secret" "service_token" {
+  path                = "infrastructure/service/token"
+  generate_if_missing = true
+}
+
+# Assemble a login from several ephemeral sources
+resource "gopass_secret" "app_login" {
+  path             = "websites/app/login"
+  value_wo_version = 1
+
+  compose = {
+    password = ephemeral.random_password.db.result
+    username = "app"
+    url      = "https://app.example.com"
+  }
+}
+
+# Rotate several fields of a secret at once, keeping its password
+resource "gopass_secret" "db_admin" {
+  path            = "infrastructure/database/admin"
+  data_wo_version = 1
+
+  data_wo = {
+    host     = "db.internal"
+    port     = "5432"
+    database = "app"
+  }
+}
 ` + "```" + `
 
 ## Write-Only Behavior
@@ -88,6 +168,18 @@ resource "gopass_secret" "db_password" {
 - The value is written to gopass on create and when ` + "`value_wo_version`" + ` changes
 - The value is **never** stored in Terraform state or plan files
 - Increment ` + "`value_wo_version`" + ` to trigger a secret update
+- ` + "`value_wo`" + ` and ` + "`value_wo_version`" + ` must be set together; setting only one is a plan-time error
+- With ` + "`generate_if_missing`" + `, a random value is generated on create if ` + "`value_wo`" + ` is omitted,
+  by the gopass generator selected with ` + "`generate`" + ` (e.g. ` + "`xkcd`" + ` passphrases)
+- ` + "`compose`" + ` assembles the secret from write-only parts instead of ` + "`value_wo`" + ` and follows the same rules
+- ` + "`value_file_wo`" + ` writes the content of a local file, read at apply time, instead of ` + "`value_wo`" + ` and follows the same rules
+- ` + "`data_wo`" + ` writes fields of the secret in a single write and is versioned separately by ` + "`data_wo_version`" + `
+- In git-backed stores, a version bump with the content Terraform wrote last is skipped unless the secret
+  was modified since, keeping the history free of no-op commits. Only a salted Argon2id hash of the value is kept in private state
+- ` + "`validate_regex`" + `, ` + "`min_length`" + ` and ` + "`forbid_whitespace`" + ` check ` + "`value_wo`" + ` before it is written;
+  a value failing them is rejected at apply time and gopass is left unchanged
+- ` + "`normalize = \"trim\"`" + ` removes trailing line breaks from ` + "`value_wo`" + ` first, so a value
+  that only gained a trailing newline is neither rejected nor rewritten
 
 ## Import
 
@@ -114,6 +206,9 @@ After import, set ` + "`value_wo`" + ` and ` + "`value_wo_version`" + ` in your
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.RequiresReplace(),
 				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
 			},
 			"value_wo": schema.StringAttribute{
 				Description: "The secret value to write. This is a write-only attribute - " +
@@ -124,6 +219,14 @@ After import, set ` + "`value_wo`" + ` and ` + "`value_wo_version`" + ` in your
 				Sensitive: true,
 				WriteOnly: true,
 			},
+			"value_file_wo": schema.StringAttribute{
+				Description: "Path of a local file whose content is written as the whole secret, e.g. a PEM certificate " +
+					"or a kubeconfig. The file is read at apply time; its content is never stored in state or plan files.",
+				MarkdownDescription: "Path of a local file whose content is written as the whole secret, e.g. a PEM certificate " +
+					"or a kubeconfig. The file is read at apply time; its content is **never stored** in state or plan files.",
+				Optional:  true,
+				WriteOnly: true,
+			},
 			"value_wo_version": schema.Int64Attribute{
 				Description: "Version number for the write-only value. Increment this to trigger " +
 					"a secret update when value_wo changes.",
@@ -141,6 +244,189 @@ After import, set ` + "`value_wo`" + ` and ` + "`value_wo_version`" + ` in your
 				Computed:            true,
 				Default:             booldefault.StaticBool(true),
 			},
+			"purge_on_remove": schema.BoolAttribute{
+				Description: "Whether to also remove every revision of the secret from the git history of the store when it is deleted, " +
+					"so a compromised credential cannot be recovered. Rewrites the local history; remotes keep it until force-pushed. " +
+					"Requires delete_on_remove. Defaults to false.",
+				MarkdownDescription: "Whether to also remove every revision of the secret from the git history of the store when it is deleted, " +
+					"so a compromised credential cannot be recovered. Rewrites the local history; remotes keep it until force-pushed. " +
+					"Requires `delete_on_remove`. Defaults to `false`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"backup_before_update": schema.BoolAttribute{
+				Description: "Whether to copy the current secret to <path>.tf-backup-<timestamp> before an update overwrites it, " +
+					"so a bad value pushed by automation can be recovered. Defaults to false.",
+				MarkdownDescription: "Whether to copy the current secret to `<path>.tf-backup-<timestamp>` before an update overwrites it, " +
+					"so a bad value pushed by automation can be recovered. Defaults to `false`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"confirm_overwrite_existing": schema.BoolAttribute{
+				Description: "Whether creating the resource may overwrite a secret that already exists at path, " +
+					"e.g. one stored by a human. Defaults to false, which fails instead. Use import to adopt the secret without writing it.",
+				MarkdownDescription: "Whether creating the resource may overwrite a secret that already exists at `path`, " +
+					"e.g. one stored by a human. Defaults to `false`, which fails instead. Use import to adopt the secret without writing it.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"only_if_absent": schema.BoolAttribute{
+				Description: "Write the value only if no secret exists at path, on create and on a value_wo_version bump. " +
+					"An existing value is kept, e.g. a default seeded by Terraform that humans customized since. Defaults to false.",
+				MarkdownDescription: "Write the value only if no secret exists at `path`, on create and on a `value_wo_version` bump. " +
+					"An existing value is kept, e.g. a default seeded by Terraform that humans customized since. Defaults to `false`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"verify_after_write": schema.BoolAttribute{
+				Description: "Read the secret back right after writing it and fail if the store returned something else, " +
+					"e.g. a value it truncated or whose line endings it converted. Only digests are compared. Defaults to false.",
+				MarkdownDescription: "Read the secret back right after writing it and fail if the store returned something else, " +
+					"e.g. a value it truncated or whose line endings it converted. Only digests are compared. Defaults to `false`.",
+				Optional: true,
+			},
+			"backup_prefix": schema.StringAttribute{
+				Description:         "Folder to keep the backups of backup_before_update in, e.g. 'backups'. Defaults to next to the secret.",
+				MarkdownDescription: "Folder to keep the backups of `backup_before_update` in, e.g. `backups`. Defaults to next to the secret.",
+				Optional:            true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"generate_if_missing": schema.BoolAttribute{
+				Description: "Generate a random password on create if no value_wo is provided. " +
+					"The generated value is written to gopass only and never stored in state. Defaults to false.",
+				MarkdownDescription: "Generate a random password on create if no `value_wo` is provided. " +
+					"The generated value is written to gopass only and never stored in state. Defaults to `false`.",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"generate_length": schema.Int64Attribute{
+				Description:         "Length of the generated password. Defaults to 32.",
+				MarkdownDescription: "Length of the generated password. Defaults to `32`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(defaultGenerateLength),
+			},
+			"generate_symbols": schema.BoolAttribute{
+				Description:         "Whether the generated password includes symbols. Defaults to true.",
+				MarkdownDescription: "Whether the generated password includes symbols. Defaults to `true`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"generate": generateAttribute(),
+			"preserve_existing_fields": schema.BoolAttribute{
+				Description: "If true, only the password (first line) of an existing secret is replaced and " +
+					"other fields such as username or notes are kept. Defaults to false (the whole secret is overwritten).",
+				MarkdownDescription: "If `true`, only the password (first line) of an existing secret is replaced and " +
+					"other fields such as `username` or notes are kept. Defaults to `false` (the whole secret is overwritten).",
+				Optional: true,
+				Computed: true,
+				Default:  booldefault.StaticBool(false),
+			},
+			"compose":         composeAttribute(),
+			"data_wo":         dataAttribute(),
+			"data_wo_version": dataVersionAttribute(),
+			"chunk_size": schema.Int64Attribute{
+				Description: "If set, values longer than this many bytes are split into parts stored at " +
+					"<path>.part1, <path>.part2 and so on, for backends that handle large files badly. " +
+					"The secret at path records the number of parts, and the ephemeral gopass_secret joins them transparently.",
+				MarkdownDescription: "If set, values longer than this many bytes are split into parts stored at " +
+					"`<path>.part1`, `<path>.part2` and so on, for backends that handle large files badly. " +
+					"The secret at `path` records the number of parts, and the ephemeral `gopass_secret` joins them transparently.",
+				Optional: true,
+			},
+			"validate_regex": schema.StringAttribute{
+				Description: "RE2 regular expression value_wo must match before it is written. " +
+					"The match is unanchored; use ^ and $ to match the whole value.",
+				MarkdownDescription: "[RE2](https://github.com/google/re2/wiki/Syntax) regular expression `value_wo` must match " +
+					"before it is written. The match is unanchored; use `^` and `$` to match the whole value.",
+				Optional: true,
+			},
+			"min_length": schema.Int64Attribute{
+				Description:         "Minimum number of characters of value_wo.",
+				MarkdownDescription: "Minimum number of characters of `value_wo`.",
+				Optional:            true,
+			},
+			"forbid_whitespace": schema.BoolAttribute{
+				Description:         "Reject a value_wo containing whitespace, such as a trailing newline. Defaults to false.",
+				MarkdownDescription: "Reject a `value_wo` containing whitespace, such as a trailing newline. Defaults to `false`.",
+				Optional:            true,
+			},
+			"normalize": schema.StringAttribute{
+				Description: "How value_wo is canonicalized before it is checked, compared with the last write and written: " +
+					"\"trim\" removes trailing line breaks, as left by values copied from files or other systems; " +
+					"\"none\" keeps the value as is. Defaults to \"none\".",
+				MarkdownDescription: "How `value_wo` is canonicalized before it is checked, compared with the last write and written: " +
+					"`trim` removes trailing line breaks, as left by values copied from files or other systems; " +
+					"`none` keeps the value as is. Defaults to `none`.",
+				Optional: true,
+			},
+			"expires_at": schema.StringAttribute{
+				Description: "Expiration time of the secret in RFC 3339 format, stored in its expires_at field. " +
+					"Read back from the secret if not configured. A plan warns once it has passed.",
+				MarkdownDescription: "Expiration time of the secret in RFC 3339 format, stored in its `expires_at` field. " +
+					"Read back from the secret if not configured. A plan warns once it has passed.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"username": schema.StringAttribute{
+				Description: "Username of the login, stored in the username field of the secret next to the password. " +
+					"Not sensitive, so it is shown in plans. Read back from the secret if not configured; " +
+					"a value changed outside of Terraform shows as a diff.",
+				MarkdownDescription: "Username of the login, stored in the `username` field of the secret next to the password. " +
+					"Not sensitive, so it is shown in plans. Read back from the secret if not configured; " +
+					"a value changed outside of Terraform shows as a diff.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"url": schema.StringAttribute{
+				Description: "URL of the login, stored in the url field of the secret next to the password. " +
+					"Not sensitive, so it is shown in plans. Read back from the secret if not configured; " +
+					"a value changed outside of Terraform shows as a diff.",
+				MarkdownDescription: "URL of the login, stored in the `url` field of the secret next to the password. " +
+					"Not sensitive, so it is shown in plans. Read back from the secret if not configured; " +
+					"a value changed outside of Terraform shows as a diff.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"comment": schema.StringAttribute{
+				Description: "Note on the provenance of the secret for humans browsing the store, e.g. " +
+					"'managed by terraform, module db, workspace prod', stored in the comment field of the secret. " +
+					"Not sensitive, so it is shown in plans. Read back from the secret if not configured; " +
+					"a value changed outside of Terraform shows as a diff.",
+				MarkdownDescription: "Note on the provenance of the secret for humans browsing the store, e.g. " +
+					"`\"managed by terraform, module db, workspace prod\"`, stored in the `comment` field of the secret. " +
+					"Not sensitive, so it is shown in plans. Read back from the secret if not configured; " +
+					"a value changed outside of Terraform shows as a diff.",
+				Optional: true,
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"timeouts": resourceTimeoutsAttribute(),
+			"revision_tracking": schema.StringAttribute{
+				Description:         revisionTrackingDescription + " Defaults to the provider setting.",
+				MarkdownDescription: revisionTrackingMarkdownDescription + " Defaults to the provider setting.",
+				Optional:            true,
+			},
+			"on_external_change": onExternalChangeAttribute(),
 			"revision_count": schema.Int64Attribute{
 				Description: "Number of revisions in gopass for this secret. Used for drift detection. " +
 					"A warning is shown if this changes outside of Terraform. " +
@@ -153,6 +439,77 @@ After import, set ` + "`value_wo`" + ` and ` + "`value_wo_version`" + ` in your
 					int64planmodifier.UseStateForUnknown(),
 				},
 			},
+			"revisions_supported": schema.BoolAttribute{
+				Description: "Whether the gopass backend provides revision history for this secret. " +
+					"If false, revision_count stays at 1 and drift can only be detected via last_revision.",
+				MarkdownDescription: "Whether the gopass backend provides revision history for this secret. " +
+					"If `false`, `revision_count` stays at `1` and drift can only be detected via `last_revision`.",
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"exists": schema.BoolAttribute{
+				Description: "Whether the secret exists in the store, verified on every refresh. Known at plan time once " +
+					"created, so modules can expose it and other resources can depend on it. If the secret is removed " +
+					"outside of Terraform, the refresh sets it to false and the plan replaces the resource to recreate the secret.",
+				MarkdownDescription: "Whether the secret exists in the store, verified on every refresh. Known at plan time once " +
+					"created, so modules can expose it and other resources can depend on it. If the secret is removed " +
+					"outside of Terraform, the refresh sets it to `false` and the plan replaces the resource to recreate the secret.",
+				Computed: true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path_components": schema.ListAttribute{
+				Description:         pathComponentsDescription,
+				MarkdownDescription: pathComponentsMarkdownDescription,
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"last_revision": schema.SingleNestedAttribute{
+				Description: "The last git commit that modified this secret. Null if the store is not git-backed " +
+					"or the history cannot be read. Used for drift reporting.",
+				MarkdownDescription: "The last git commit that modified this secret. `null` if the store is not git-backed " +
+					"or the history cannot be read. Used for **drift reporting**.",
+				Computed: true,
+				Attributes: map[string]schema.Attribute{
+					"hash": schema.StringAttribute{
+						Description: "Full commit hash.",
+						Computed:    true,
+					},
+					"timestamp": schema.StringAttribute{
+						Description: "Author date of the commit in RFC 3339 format.",
+						Computed:    true,
+					},
+					"author": schema.StringAttribute{
+						Description: "Commit author as \"Name <email>\".",
+						Computed:    true,
+					},
+				},
+			},
+			"created_at": schema.StringAttribute{
+				Description: "Time Terraform created the resource in RFC 3339 format (UTC). " +
+					"For imported secrets, the time of the first commit of the secret in git-backed stores.",
+				MarkdownDescription: "Time Terraform created the resource in RFC 3339 format (UTC). " +
+					"For imported secrets, the time of the first commit of the secret in git-backed stores.",
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"updated_at": schema.StringAttribute{
+				Description: "Time Terraform last wrote the secret value in RFC 3339 format (UTC), " +
+					"e.g. to rotate secrets older than 90 days. Null if Terraform has not written a value. " +
+					"For imported secrets, the time of the last commit of the secret in git-backed stores.",
+				MarkdownDescription: "Time Terraform last wrote the secret value in RFC 3339 format (UTC), " +
+					"e.g. to rotate secrets older than 90 days. `null` if Terraform has not written a value. " +
+					"For imported secrets, the time of the last commit of the secret in git-backed stores.",
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 		},
 	}
 }
@@ -166,7 +523,7 @@ func (r *SecretResource) Configure(ctx context.Context, req resource.ConfigureRe
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData),
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
 		)
 		return
 	}
@@ -174,8 +531,126 @@ func (r *SecretResource) Configure(ctx context.Context, req resource.ConfigureRe
 	r.client = client
 }
 
+// ValidateConfig rejects value_wo (or compose, or value_file_wo) without value_wo_version and
+// vice versa. Without a version, later changes to the value would never be written; without a
+// value, a version bump would have nothing to write. compose and value_file_wo replace the whole
+// secret, so they cannot be combined with value_wo, each other or preserve_existing_fields.
+func (r *SecretResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config SecretResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validateRevisionTracking(&resp.Diagnostics, path.Root("revision_tracking"), config.RevisionTracking)
+	validateValueConstraints(&resp.Diagnostics, &config)
+	validateNormalize(&resp.Diagnostics, config.Normalize)
+	validateExpiresAt(&resp.Diagnostics, config.ExpiresAt)
+	validateLoginFields(&resp.Diagnostics, &config)
+	validateChunkSize(&resp.Diagnostics, &config)
+	validateData(&resp.Diagnostics, &config)
+	validateOnExternalChange(&resp.Diagnostics, &config)
+	validateGenerate(ctx, &resp.Diagnostics, &config)
+
+	// Unknown values (e.g. from ephemeral resources) count as set
+	hasCompose := !config.Compose.IsNull()
+	hasFile := !config.ValueFileWO.IsNull()
+	hasValue := !config.ValueWO.IsNull() || hasCompose || hasFile
+	hasVersion := !config.ValueWOVersion.IsNull()
+
+	if config.OnlyIfAbsent.ValueBool() && config.ConfirmOverwrite.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("only_if_absent"),
+			"Conflicting only_if_absent and confirm_overwrite_existing",
+			codedDetail(CodeInvalidConfig, "only_if_absent keeps an existing secret, but confirm_overwrite_existing = true replaces it. "+
+				"Remove one of them."),
+		)
+	}
+
+	if config.PurgeOnRemove.ValueBool() && !config.DeleteOnRemove.IsNull() && !config.DeleteOnRemove.ValueBool() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("purge_on_remove"),
+			"Conflicting purge_on_remove and delete_on_remove",
+			codedDetail(CodeInvalidConfig, "purge_on_remove removes the history of a deleted secret, but delete_on_remove = false keeps the secret. "+
+				"Remove one of them."),
+		)
+	}
+
+	switch {
+	case hasCompose && !config.ValueWO.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("compose"),
+			"Conflicting value_wo and compose",
+			codedDetail(CodeInvalidConfig, "Both value_wo and compose are set. Use value_wo for a single value, or compose to assemble the secret from parts."),
+		)
+	case hasFile && !config.ValueWO.IsNull():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("value_file_wo"),
+			"Conflicting value_wo and value_file_wo",
+			codedDetail(CodeInvalidConfig, "Both value_wo and value_file_wo are set. Use value_wo for a value from Terraform, "+
+				"or value_file_wo to write the content of a local file."),
+		)
+	case hasFile && hasCompose:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("value_file_wo"),
+			"Conflicting compose and value_file_wo",
+			codedDetail(CodeInvalidConfig, "Both compose and value_file_wo are set. Use compose to assemble the secret from parts, "+
+				"or value_file_wo to write the content of a local file."),
+		)
+	case hasFile && config.PreserveFields.ValueBool():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("value_file_wo"),
+			"Conflicting preserve_existing_fields and value_file_wo",
+			codedDetail(CodeInvalidConfig, "value_file_wo replaces the whole secret with the content of the file, so existing fields cannot be preserved. "+
+				"Add the fields to keep to the file, or remove preserve_existing_fields."),
+		)
+	case hasCompose && config.PreserveFields.ValueBool():
+		resp.Diagnostics.AddAttributeError(
+			path.Root("compose"),
+			"Conflicting preserve_existing_fields and compose",
+			codedDetail(CodeInvalidConfig, "compose replaces the whole secret, so existing fields cannot be preserved. "+
+				"Add the fields to keep to compose, or remove preserve_existing_fields."),
+		)
+	case hasValue && !hasVersion:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("value_wo_version"),
+			"Missing value_wo_version",
+			codedDetail(CodeInvalidConfig, "value_wo, compose or value_file_wo is set but value_wo_version is not. Without a version, later changes to the value "+
+				"are never written to gopass. Set value_wo_version and increment it whenever the value changes."),
+		)
+	case hasVersion && !hasValue:
+		resp.Diagnostics.AddAttributeError(
+			path.Root("value_wo"),
+			"Missing value_wo",
+			codedDetail(CodeInvalidConfig, "value_wo_version is set but none of value_wo, compose and value_file_wo is, so there is nothing to write when the version changes. "+
+				"Set one of them, or remove value_wo_version."),
+		)
+	}
+}
+
+// ModifyPlan replaces secrets whose store changed or that were removed, warns about expired ones, plans the
+// rewrite of secrets modified outside of Terraform and plans the attributes derived from others.
+// Nothing is planned on destroy.
+func (r *SecretResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	ctx = r.client.logContext(ctx)
+
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	r.replaceOnStoreChange(ctx, req, resp)
+	r.replaceIfMissing(ctx, req, resp)
+	r.warnExpired(ctx, req, resp)
+	r.planOverwrite(ctx, req, resp)
+	r.planUpdatedAt(ctx, req, resp)
+	r.planPathComponents(ctx, req, resp)
+}
+
 //nolint:gocritic // hugeParam: Terraform framework interface requirement
 func (r *SecretResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
 	var data SecretResourceModel
 
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
@@ -185,6 +660,13 @@ func (r *SecretResource) Create(ctx context.Context, req resource.CreateRequest,
 
 	secretPath := data.Path.ValueString()
 
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutCreate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
 	tflog.Debug(ctx, "Creating gopass secret", map[string]interface{}{
 		"path": secretPath,
 	})
@@ -196,36 +678,161 @@ func (r *SecretResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	// Write the secret if value_wo is provided
-	if !config.ValueWO.IsNull() && !config.ValueWO.IsUnknown() {
-		value := config.ValueWO.ValueString()
-		if err := r.client.SetSecret(ctx, secretPath, value); err != nil {
-			resp.Diagnostics.AddError(
-				"Failed to create secret",
-				fmt.Sprintf("Could not write secret to gopass at %q: %s", secretPath, err.Error()),
-			)
+	// Write the secret from compose, value_file_wo or value_wo, or generate one if requested
+	var content []string
+	var keep bool
+	hasValue := !config.ValueWO.IsNull() && !config.ValueWO.IsUnknown()
+	if hasCompose(config.Compose) || hasValueFile(config.ValueFileWO) || hasValue || data.GenerateIfMissing.ValueBool() {
+		var diags diag.Diagnostics
+		if data.OnlyIfAbsent.ValueBool() {
+			keep, diags = r.keepExisting(ctx, &data, "Failed to create secret")
+		} else {
+			diags = r.checkOverwrite(ctx, &data)
+		}
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
 			return
 		}
-	} else {
+	}
+
+	// The value, expiry, login fields and data_wo are written together, in a single write
+	update := SecretUpdate{Fields: make(map[string]string)}
+	if keep {
+		tflog.Info(ctx, "Kept existing gopass secret (only_if_absent)", map[string]interface{}{
+			"path": secretPath,
+		})
+	} else if hasCompose(config.Compose) {
+		parts, diags := composeParts(ctx, config.Compose)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		update.Parts = &parts
+		content = composeContent(parts)
+	} else if hasValueFile(config.ValueFileWO) {
+		value, diags := readValueFile(&data, config.ValueFileWO.ValueString())
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		update.Content = []byte(value)
+		content = fileContent(value)
+	} else if hasValue || data.GenerateIfMissing.ValueBool() {
+		value := normalizeValue(&data, config.ValueWO.ValueString())
+		if hasValue {
+			if err := checkValue(&data, value); err != nil {
+				addInvalidValueError(&resp.Diagnostics, secretPath, err)
+				return
+			}
+		} else {
+			spec, diags := generateSpec(ctx, &data)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+			generated, err := r.client.GenerateSecret(ctx, spec)
+			if err != nil {
+				resp.Diagnostics.AddError(
+					"Failed to generate secret",
+					errorDetail(err, fmt.Sprintf("Could not generate a value for %q: %s", secretPath, err.Error())),
+				)
+				return
+			}
+			value = generated
+			tflog.Debug(ctx, "Generated value for gopass secret", map[string]interface{}{
+				"path":      secretPath,
+				"generator": spec.Type,
+			})
+		}
+
+		setValue(&update, &data, value)
+		content = valueContent(value)
+	} else if !hasData(config.DataWO) {
 		resp.Diagnostics.AddWarning(
 			"No value provided",
-			"The secret was created but no value_wo was provided. The secret in gopass may be empty or unchanged.",
+			codedDetail(CodeInvalidConfig, "The secret was created but no value_wo was provided. The secret in gopass may be empty or unchanged. "+
+				"Set generate_if_missing = true to generate a random value instead."),
 		)
 	}
 
-	// Get revision count for drift detection
-	revCount, err := r.client.GetRevisionCount(ctx, secretPath)
-	if err != nil {
-		// Log error but proceed with revCount=0 (disables drift detection)
-		tflog.Warn(ctx, "Could not get revision count", map[string]interface{}{
+	// Store the expiry, or read one kept in an existing secret after the write
+	expiryPlanned := !data.ExpiresAt.IsUnknown() && !data.ExpiresAt.IsNull()
+	if expiryPlanned {
+		update.Fields[expiresAtKey] = data.ExpiresAt.ValueString()
+	}
+
+	// Store the login fields, or read those kept in an existing secret after the write
+	readLogin := loginFieldUpdates(update.Fields, &data, nil)
+
+	// Store the fields of data_wo, unless an existing secret is kept
+	writeData := hasData(config.DataWO) && !keep
+	if writeData {
+		var diags diag.Diagnostics
+		update.Remove, diags = dataUpdates(ctx, update.Fields, config.DataWO, nil)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if err := r.client.UpdateSecret(ctx, secretPath, update); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to create secret",
+			errorDetail(err, fmt.Sprintf("Could not write secret to gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+	if writeData {
+		recordDataKeys(ctx, secretPath, config.DataWO, resp.Private)
+	}
+
+	if content != nil && data.VerifyAfterWrite.ValueBool() {
+		r.verifyWrite(ctx, &resp.Diagnostics, secretPath, content, &update)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Record when Terraform created the resource and wrote the value
+	data.CreatedAt = writeTimestamp()
+	data.UpdatedAt = types.StringNull()
+	if content != nil {
+		data.UpdatedAt = data.CreatedAt
+	}
+
+	if !expiryPlanned {
+		r.readExpiry(ctx, &data)
+	}
+	if readLogin {
+		r.readLoginFields(ctx, &data)
+	}
+
+	// Get revision count for drift detection; revCount=0 disables drift detection
+	revCount := r.trackRevisions(ctx, &data, 0)
+
+	if content != nil {
+		r.recordWrite(ctx, resp.Private, secretPath, content, revCount, revisionHash(data.LastRevision))
+	}
+
+	r.recordStore(ctx, resp.Private, secretPath)
+
+	// Without a value to write, the secret may still be missing. Failing to tell is not fatal,
+	// the next refresh sets exists.
+	if content != nil || keep {
+		data.Exists = types.BoolValue(true)
+	} else if exists, err := r.client.SecretExists(ctx, secretPath); err == nil {
+		data.Exists = types.BoolValue(exists)
+	} else {
+		tflog.Warn(ctx, "Could not check if gopass secret exists", map[string]interface{}{
 			"path":  secretPath,
 			"error": err.Error(),
 		})
+		data.Exists = types.BoolNull()
 	}
-	data.RevisionCount = types.Int64Value(revCount)
 
 	// Set ID to path
 	data.ID = data.Path
+	data.PathComponents = pathComponents(r.client.resolveMountPath(ctx, data.Path.ValueString()))
 
 	tflog.Debug(ctx, "Created gopass secret", map[string]interface{}{
 		"path": secretPath,
@@ -236,6 +843,8 @@ func (r *SecretResource) Create(ctx context.Context, req resource.CreateRequest,
 
 //nolint:gocritic // hugeParam: Terraform framework interface requirement
 func (r *SecretResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
 	var data SecretResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -245,6 +854,13 @@ func (r *SecretResource) Read(ctx context.Context, req resource.ReadRequest, res
 
 	secretPath := data.Path.ValueString()
 
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutRead)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
 	tflog.Debug(ctx, "Reading gopass secret", map[string]interface{}{
 		"path": secretPath,
 	})
@@ -254,18 +870,45 @@ func (r *SecretResource) Read(ctx context.Context, req resource.ReadRequest, res
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to read secret",
-			fmt.Sprintf("Could not check if secret exists at %q: %s", secretPath, err.Error()),
+			errorDetail(err, fmt.Sprintf("Could not check if secret exists at %q: %s", secretPath, err.Error())),
 		)
 		return
 	}
 
 	if !exists {
-		// Secret was deleted outside of Terraform
-		resp.State.RemoveResource(ctx)
+		// Secret was deleted outside of Terraform; the plan replaces the resource to recreate it
+		data.Exists = types.BoolValue(false)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 		return
 	}
+	data.Exists = types.BoolValue(true)
+	data.PathComponents = pathComponents(r.client.resolveMountPath(ctx, secretPath))
+
+	r.readExpiry(ctx, &data)
+	r.readLoginFields(ctx, &data)
+	r.recordStoreIfMissing(ctx, resp.Private, secretPath)
+
+	mode := r.revisionTracking(data.RevisionTracking)
+	if mode == revisionTrackingOff {
+		r.trackRevisions(ctx, &data, 0)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	// Drift is a warning, or an error with on_external_change = "fail" or in strict mode
+	action := externalChangeAction(data.OnExternalChange, mode)
+	drifted := false
+	reportDrift := func(summary, detail string) {
+		drifted = true
+		if action == externalChangeFail {
+			resp.Diagnostics.AddError(summary, detail)
+			return
+		}
+		resp.Diagnostics.AddWarning(summary, detail)
+	}
 
 	// Check for drift via revision count
+	lastRevision := r.lastRevision(ctx, secretPath)
 	currentRevCount, err := r.client.GetRevisionCount(ctx, secretPath)
 	if err != nil {
 		tflog.Warn(ctx, "Could not get revision count for drift detection", map[string]interface{}{
@@ -278,15 +921,14 @@ func (r *SecretResource) Read(ctx context.Context, req resource.ReadRequest, res
 		// Only warn if we have a meaningful comparison
 		// (storedRevCount > 0 means we had a previous count, currentRevCount > 1 means versioning is supported)
 		if storedRevCount > 0 && currentRevCount > storedRevCount {
-			resp.Diagnostics.AddWarning(
+			reportDrift(
 				"Secret modified outside of Terraform",
-				fmt.Sprintf(
+				codedDetail(CodeDrift, fmt.Sprintf(
 					"The secret at %q has %d revisions, but Terraform expected %d. "+
 						"This indicates the secret was modified outside of Terraform. "+
-						"The actual value may differ from what Terraform last wrote. "+
-						"Consider incrementing value_wo_version to overwrite with the intended value.",
-					secretPath, currentRevCount, storedRevCount,
-				),
+						"The actual value may differ from what Terraform last wrote. %s%s",
+					secretPath, currentRevCount, storedRevCount, lastWriteDetail(ctx, req.Private, lastRevision), externalChangeHint(action),
+				)),
 			)
 		}
 
@@ -294,12 +936,35 @@ func (r *SecretResource) Read(ctx context.Context, req resource.ReadRequest, res
 		data.RevisionCount = types.Int64Value(currentRevCount)
 	}
 
+	data.RevisionsSupported = r.revisionsSupported(ctx, secretPath)
+
+	// Check for drift via the last commit touching the secret
+	if stored, current := revisionHash(data.LastRevision), revisionHash(lastRevision); stored != "" && current != "" && current != stored {
+		attrs := lastRevision.Attributes()
+		reportDrift(
+			"Secret modified outside of Terraform",
+			codedDetail(CodeDrift, fmt.Sprintf(
+				"The secret at %q was last changed in commit %s by %s at %s, "+
+					"but Terraform last saw commit %s. %s%s",
+				secretPath, current, stringAttr(attrs["author"]), stringAttr(attrs["timestamp"]), stored,
+				lastWriteDetail(ctx, req.Private, lastRevision), externalChangeHint(action),
+			)),
+		)
+	}
+	data.LastRevision = lastRevision
+
+	if drifted && action == externalChangeOverwrite {
+		r.markExternalChange(ctx, resp.Private, secretPath)
+	}
+
 	// Keep existing state (with updated revision count)
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 //nolint:gocritic // hugeParam: Terraform framework interface requirement
 func (r *SecretResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
 	var data SecretResourceModel
 	var state SecretResourceModel
 
@@ -311,6 +976,13 @@ func (r *SecretResource) Update(ctx context.Context, req resource.UpdateRequest,
 
 	secretPath := data.Path.ValueString()
 
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutUpdate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
 	tflog.Debug(ctx, "Updating gopass secret", map[string]interface{}{
 		"path": secretPath,
 	})
@@ -330,47 +1002,182 @@ func (r *SecretResource) Update(ctx context.Context, req resource.UpdateRequest,
 		versionChanged = true
 	}
 
-	// Write the secret if version changed and value_wo is provided
-	if versionChanged {
-		if !config.ValueWO.IsNull() && !config.ValueWO.IsUnknown() {
-			value := config.ValueWO.ValueString()
-			if err := r.client.SetSecret(ctx, secretPath, value); err != nil {
-				resp.Diagnostics.AddError(
-					"Failed to update secret",
-					fmt.Sprintf("Could not write secret to gopass at %q: %s", secretPath, err.Error()),
-				)
+	// A secret a refresh found modified outside of Terraform is rewritten with on_external_change = "overwrite"
+	pending := externalChangePending(ctx, req.Private)
+	overwrite := pending && data.OnExternalChange.ValueString() == externalChangeOverwrite
+
+	// The value, expiry, login fields and data_wo are written together, in a single write
+	update := SecretUpdate{Fields: make(map[string]string)}
+
+	// Write the secret if version changed and compose, value_file_wo or value_wo is provided.
+	// Rewriting the content Terraform wrote last is skipped to keep the store history clean.
+	var content []string
+	if versionChanged || overwrite {
+		var parts SecretParts
+		var value string
+		switch {
+		case hasCompose(config.Compose):
+			var diags diag.Diagnostics
+			parts, diags = composeParts(ctx, config.Compose)
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+			content = composeContent(parts)
+		case hasValueFile(config.ValueFileWO):
+			var diags diag.Diagnostics
+			value, diags = readValueFile(&data, config.ValueFileWO.ValueString())
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
 				return
 			}
-			tflog.Info(ctx, "Updated gopass secret (value_wo_version changed)", map[string]interface{}{
+			content = fileContent(value)
+		case !config.ValueWO.IsNull() && !config.ValueWO.IsUnknown():
+			value = normalizeValue(&data, config.ValueWO.ValueString())
+			if err := checkValue(&data, value); err != nil {
+				addInvalidValueError(&resp.Diagnostics, secretPath, err)
+				return
+			}
+			content = valueContent(value)
+		default:
+			resp.Diagnostics.AddWarning(
+				"Version changed but no value provided",
+				codedDetail(CodeInvalidConfig, "value_wo_version was incremented but no value_wo, compose or value_file_wo was provided. The secret in gopass was not updated."),
+			)
+		}
+
+		if content != nil && data.OnlyIfAbsent.ValueBool() {
+			keep, diags := r.keepExisting(ctx, &data, "Failed to update secret")
+			resp.Diagnostics.Append(diags...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+			if keep {
+				tflog.Info(ctx, "Kept existing gopass secret (only_if_absent)", map[string]interface{}{
+					"path":        secretPath,
+					"old_version": state.ValueWOVersion.ValueInt64(),
+					"new_version": data.ValueWOVersion.ValueInt64(),
+				})
+				content = nil
+			}
+		}
+
+		if content != nil && r.unchangedSinceLastWrite(ctx, req.Private, secretPath, content) {
+			tflog.Info(ctx, "Skipped writing unchanged gopass secret", map[string]interface{}{
 				"path":        secretPath,
 				"old_version": state.ValueWOVersion.ValueInt64(),
 				"new_version": data.ValueWOVersion.ValueInt64(),
 			})
-		} else {
-			resp.Diagnostics.AddWarning(
-				"Version changed but no value provided",
-				"value_wo_version was incremented but no value_wo was provided. The secret in gopass was not updated.",
-			)
+			content = nil
+		}
+
+		if content != nil {
+			resp.Diagnostics.Append(r.backupBeforeUpdate(ctx, &data)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+
+			switch {
+			case hasCompose(config.Compose):
+				update.Parts = &parts
+			case hasValueFile(config.ValueFileWO):
+				update.Content = []byte(value)
+			default:
+				setValue(&update, &data, value)
+			}
 		}
 	}
 
-	// Update revision count after write
-	revCount, err := r.client.GetRevisionCount(ctx, secretPath)
-	if err != nil {
-		tflog.Warn(ctx, "Could not get revision count after update", map[string]interface{}{
-			"path":  secretPath,
-			"error": err.Error(),
+	// A secret no longer split into parts is joined, unless rewritten above, and its parts removed
+	update.Unchunk = !state.ChunkSize.IsNull() && data.ChunkSize.IsNull()
+
+	// Store a changed expiry, and write it again along with a rewritten value
+	if !data.ExpiresAt.IsUnknown() && !data.ExpiresAt.IsNull() && (content != nil || !data.ExpiresAt.Equal(state.ExpiresAt)) {
+		update.Fields[expiresAtKey] = data.ExpiresAt.ValueString()
+	}
+
+	// Store changed login fields, and write all of them again along with a rewritten value
+	previous := &state
+	if content != nil {
+		previous = nil
+	}
+	readLogin := loginFieldUpdates(update.Fields, &data, previous)
+
+	// Store the fields of data_wo if its version changed, and write them again along with a rewritten value
+	writeData := hasData(config.DataWO) && (content != nil || !data.DataWOVersion.Equal(state.DataWOVersion))
+	if writeData {
+		var diags diag.Diagnostics
+		update.Remove, diags = dataUpdates(ctx, update.Fields, config.DataWO, req.Private)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if err := r.client.UpdateSecret(ctx, secretPath, update); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update secret",
+			errorDetail(err, fmt.Sprintf("Could not write secret to gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+	if writeData {
+		recordDataKeys(ctx, secretPath, config.DataWO, resp.Private)
+	}
+
+	if content != nil {
+		if data.VerifyAfterWrite.ValueBool() {
+			r.verifyWrite(ctx, &resp.Diagnostics, secretPath, content, &update)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+		tflog.Info(ctx, "Updated gopass secret", map[string]interface{}{
+			"path":            secretPath,
+			"old_version":     state.ValueWOVersion.ValueInt64(),
+			"new_version":     data.ValueWOVersion.ValueInt64(),
+			"external_change": overwrite,
 		})
-		// Keep previous count if we can't get new one
-		revCount = state.RevisionCount.ValueInt64()
 	}
-	data.RevisionCount = types.Int64Value(revCount)
+
+	// Record the write, or keep the time of the previous one. A plan that found the value of
+	// an imported secret unchanged promised the previous time, which is kept even if the store
+	// changed since and the secret had to be written after all.
+	if content != nil && data.UpdatedAt.IsUnknown() {
+		data.UpdatedAt = writeTimestamp()
+	} else {
+		data.UpdatedAt = state.UpdatedAt
+	}
+	if data.CreatedAt.IsUnknown() {
+		// Resources created before created_at existed have none
+		data.CreatedAt = state.CreatedAt
+	}
+
+	if data.ExpiresAt.IsUnknown() {
+		r.readExpiry(ctx, &data)
+	}
+	if readLogin {
+		r.readLoginFields(ctx, &data)
+	}
+
+	// Update revision count after write, keeping the previous count if we can't get a new one
+	revCount := r.trackRevisions(ctx, &data, state.RevisionCount.ValueInt64())
+
+	if content != nil {
+		r.recordWrite(ctx, resp.Private, secretPath, content, revCount, revisionHash(data.LastRevision))
+	}
+	r.recordStore(ctx, resp.Private, secretPath)
+	if pending {
+		r.clearExternalChange(ctx, resp.Private, secretPath)
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 //nolint:gocritic // hugeParam: Terraform framework interface requirement
 func (r *SecretResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
 	var data SecretResourceModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -379,20 +1186,46 @@ func (r *SecretResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 
 	secretPath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
 	deleteOnRemove := data.DeleteOnRemove.ValueBool()
 
+	if !data.Exists.IsNull() && !data.Exists.ValueBool() {
+		// Removed outside of Terraform, so there is nothing left to remove
+		tflog.Debug(ctx, "Secret already deleted externally", map[string]interface{}{
+			"path": secretPath,
+		})
+		return
+	}
+
 	tflog.Debug(ctx, "Deleting gopass secret resource", map[string]interface{}{
 		"path":             secretPath,
 		"delete_on_remove": deleteOnRemove,
 	})
 
 	if deleteOnRemove {
+		// A secret replaced because its store changed lives in the previous store;
+		// the secret at the same path in the current store is not Terraform's to remove
+		if recorded, current := r.storeChanged(ctx, req.Private, secretPath); recorded != nil {
+			tflog.Info(ctx, "Keeping gopass secret in the current store (store changed)", map[string]interface{}{
+				"path":           secretPath,
+				"recorded_store": recorded.Root,
+				"current_store":  current.Root,
+			})
+			return
+		}
+
 		if err := r.client.RemoveSecret(ctx, secretPath); err != nil {
 			// Ignore "not found" errors - the secret may have been deleted externally
 			if !isNotFoundError(err) {
 				resp.Diagnostics.AddError(
 					"Failed to remove secret",
-					fmt.Sprintf("Could not remove secret from gopass at %q: %s", secretPath, err.Error()),
+					errorDetail(err, fmt.Sprintf("Could not remove secret from gopass at %q: %s", secretPath, err.Error())),
 				)
 				return
 			}
@@ -404,6 +1237,20 @@ func (r *SecretResource) Delete(ctx context.Context, req resource.DeleteRequest,
 				"path": secretPath,
 			})
 		}
+
+		if !data.ChunkSize.IsNull() {
+			if err := r.client.RemoveSecretChunks(ctx, secretPath, 0); err != nil {
+				resp.Diagnostics.AddError(
+					"Failed to remove secret",
+					errorDetail(err, fmt.Sprintf("Could not remove the parts of the secret at %q: %s", secretPath, err.Error())),
+				)
+				return
+			}
+		}
+
+		if data.PurgeOnRemove.ValueBool() {
+			resp.Diagnostics.Append(r.purgeHistory(ctx, secretPath)...)
+		}
 	} else {
 		tflog.Info(ctx, "Keeping gopass secret (delete_on_remove=false)", map[string]interface{}{
 			"path": secretPath,
@@ -411,13 +1258,9 @@ func (r *SecretResource) Delete(ctx context.Context, req resource.DeleteRequest,
 	}
 }
 
-// isNotFoundError checks if an error indicates a secret was not found.
-func isNotFoundError(err error) bool {
-	errStr := err.Error()
-	return strings.Contains(errStr, "not found") || strings.Contains(errStr, "does not exist")
-}
-
 func (r *SecretResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
 	secretPath := req.ID
 
 	tflog.Debug(ctx, "Importing gopass secret", map[string]interface{}{
@@ -429,7 +1272,7 @@ func (r *SecretResource) ImportState(ctx context.Context, req resource.ImportSta
 	if err != nil {
 		resp.Diagnostics.AddError(
 			"Failed to import secret",
-			fmt.Sprintf("Could not check if secret exists at %q: %s", secretPath, err.Error()),
+			errorDetail(err, fmt.Sprintf("Could not check if secret exists at %q: %s", secretPath, err.Error())),
 		)
 		return
 	}
@@ -437,24 +1280,173 @@ func (r *SecretResource) ImportState(ctx context.Context, req resource.ImportSta
 	if !exists {
 		resp.Diagnostics.AddError(
 			"Secret not found",
-			fmt.Sprintf("No secret exists at path %q in gopass", secretPath),
+			codedDetail(CodeSecretNotFound, fmt.Sprintf("No secret exists at path %q in gopass", secretPath)),
 		)
 		return
 	}
 
-	// Get revision count
+	// Get revision count, falling back to 1 as the secret exists
+	data := SecretResourceModel{Path: types.StringValue(secretPath)}
+	revCount := r.trackRevisions(ctx, &data, 1)
+	r.readExpiry(ctx, &data)
+	r.readLoginFields(ctx, &data)
+	r.importTimestamps(ctx, &data)
+	r.recordStore(ctx, resp.Private, secretPath)
+	r.recordImport(ctx, resp.Private, secretPath, revCount, revisionHash(data.LastRevision))
+
+	// Import with path as ID
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), secretPath)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), secretPath)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path_components"), pathComponents(r.client.resolveMountPath(ctx, secretPath)))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("delete_on_remove"), true)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("purge_on_remove"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("backup_before_update"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("generate_if_missing"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("generate_length"), int64(defaultGenerateLength))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("generate_symbols"), true)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("preserve_existing_fields"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("confirm_overwrite_existing"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("only_if_absent"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("revision_count"), revCount)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("revisions_supported"), data.RevisionsSupported)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("last_revision"), data.LastRevision)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("expires_at"), data.ExpiresAt)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("username"), data.Username)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("url"), data.URL)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("comment"), data.Comment)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("created_at"), data.CreatedAt)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("updated_at"), data.UpdatedAt)...)
+}
+
+// purgeHistory removes the secret at secretPath from the git history of the store.
+// Stores without git history have nothing to purge. If the store has a remote, a warning
+// reminds that the history there is only rewritten by a force-push.
+func (r *SecretResource) purgeHistory(ctx context.Context, secretPath string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	purged, err := r.client.PurgeSecretHistory(ctx, secretPath)
+	if err != nil {
+		diags.AddError(
+			"Failed to purge secret history",
+			errorDetail(err, fmt.Sprintf("The secret at %q was removed, but its history could not be purged: %s", secretPath, err.Error())),
+		)
+		return diags
+	}
+	if !purged {
+		tflog.Info(ctx, "Store keeps no git history, nothing to purge", map[string]interface{}{
+			"path": secretPath,
+		})
+		return diags
+	}
+
+	remote, ok, err := r.client.GetGitRemote(ctx, "", defaultGitRemote)
+	if err != nil {
+		tflog.Warn(ctx, "Could not check git remote after purge", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+		return diags
+	}
+	if ok {
+		diags.AddWarning(
+			"Secret history purged locally",
+			codedDetail(CodeError, fmt.Sprintf(
+				"The history of the secret at %q was removed from the local store, but the remote %q (%s) still has it. "+
+					"Run \"git push --force --all %s\" in the store to purge it there as well, and have other clones of the store re-clone it.",
+				secretPath, defaultGitRemote, remote, defaultGitRemote,
+			)),
+		)
+	}
+	return diags
+}
+
+// revisionTracking returns the revision tracking mode configured by v, or the provider's if v is not set.
+func (r *SecretResource) revisionTracking(v types.String) string {
+	if v.IsNull() || v.IsUnknown() {
+		return r.client.RevisionTracking()
+	}
+	return v.ValueString()
+}
+
+// trackRevisions refreshes the revision attributes of data for the secret at its path and
+// returns the revision count. If the count cannot be read, fallback is used instead.
+// With revision tracking off, nothing is read and the attributes are cleared.
+func (r *SecretResource) trackRevisions(ctx context.Context, data *SecretResourceModel, fallback int64) int64 {
+	if r.revisionTracking(data.RevisionTracking) == revisionTrackingOff {
+		data.RevisionCount = types.Int64Value(0)
+		data.RevisionsSupported = types.BoolValue(false)
+		data.LastRevision = types.ObjectNull(lastRevisionAttrTypes)
+		return 0
+	}
+
+	secretPath := data.Path.ValueString()
 	revCount, err := r.client.GetRevisionCount(ctx, secretPath)
 	if err != nil {
-		tflog.Warn(ctx, "Could not get revision count during import", map[string]interface{}{
+		tflog.Warn(ctx, "Could not get revision count", map[string]interface{}{
 			"path":  secretPath,
 			"error": err.Error(),
 		})
-		revCount = 1 // Fallback
+		revCount = fallback
 	}
+	data.RevisionCount = types.Int64Value(revCount)
+	data.RevisionsSupported = r.revisionsSupported(ctx, secretPath)
+	data.LastRevision = r.lastRevision(ctx, secretPath)
+	return revCount
+}
 
-	// Import with path as ID
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), secretPath)...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), secretPath)...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("delete_on_remove"), true)...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("revision_count"), revCount)...)
+// revisionsSupported probes whether the backend keeps revisions for the secret at secretPath.
+// Probe failures are logged and reported as unsupported.
+func (r *SecretResource) revisionsSupported(ctx context.Context, secretPath string) types.Bool {
+	supported, err := r.client.SupportsRevisions(ctx, secretPath)
+	if err != nil {
+		tflog.Warn(ctx, "Could not probe revision support", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+	}
+	return types.BoolValue(supported)
+}
+
+// setValue sets update to write value to the secret at the planned path, keeping other fields
+// of an existing secret if preserve_existing_fields is set, or split into parts if chunk_size
+// is set.
+func setValue(update *SecretUpdate, data *SecretResourceModel, value string) {
+	update.Value = &value
+	update.KeepFields = data.PreserveFields.ValueBool()
+	update.ChunkSize = int(data.ChunkSize.ValueInt64())
+}
+
+// lastRevision returns the last_revision object for the secret at secretPath.
+// Failures are logged and yield a null object, as revision metadata is informational only.
+func (r *SecretResource) lastRevision(ctx context.Context, secretPath string) types.Object {
+	info, err := r.client.GetRevisionInfo(ctx, secretPath)
+	if err != nil {
+		tflog.Debug(ctx, "Could not get revision info", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+	}
+	if info == nil {
+		return types.ObjectNull(lastRevisionAttrTypes)
+	}
+
+	return types.ObjectValueMust(lastRevisionAttrTypes, map[string]attr.Value{
+		"hash":      types.StringValue(info.Hash),
+		"timestamp": types.StringValue(info.Timestamp),
+		"author":    types.StringValue(info.Author),
+	})
+}
+
+// revisionHash returns the commit hash of a last_revision object, or "" if it is null or unknown.
+func revisionHash(obj types.Object) string {
+	return stringAttr(obj.Attributes()["hash"])
+}
+
+// stringAttr returns the string content of an attribute value, or "" if it is not a known string.
+func stringAttr(v attr.Value) string {
+	s, ok := v.(types.String)
+	if !ok {
+		return ""
+	}
+	return s.ValueString()
 }
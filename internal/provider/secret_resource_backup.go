@@ -0,0 +1,69 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// backupInfix separates the path of a secret from the time of its backup.
+const backupInfix = ".tf-backup-"
+
+// backupTimeFormat is the compact UTC timestamp in backup paths, e.g. 20261016T120000Z.
+const backupTimeFormat = "20060102T150405Z"
+
+// backupPath returns the path a secret at secretPath is backed up to at t:
+// <secretPath>.tf-backup-<timestamp>, inside the folder prefix if non-empty. Both may address
+// a mounted store as "mount:path", which is resolved before they are joined, so the secret
+// "work:app/db" is backed up to "backups/work/app/db.tf-backup-<timestamp>". Like all other
+// paths, the result is relative to the path_prefix of the provider.
+func (c *GopassClient) backupPath(ctx context.Context, prefix, secretPath string, t time.Time) string {
+	backup := c.resolveMountPath(ctx, secretPath) + backupInfix + t.UTC().Format(backupTimeFormat)
+	if prefix == "" {
+		return backup
+	}
+	return path.Join(c.resolveMountPath(ctx, prefix), backup)
+}
+
+// backupBeforeUpdate copies the current secret to a backup path if backup_before_update is
+// set, so a bad value written by automation can be recovered. The parts of a chunked secret
+// are backed up along. A missing secret has nothing to back up. If the backup fails, the
+// update must not overwrite the secret.
+func (r *SecretResource) backupBeforeUpdate(ctx context.Context, data *SecretResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !data.BackupBeforeUpdate.ValueBool() {
+		return diags
+	}
+
+	secretPath := data.Path.ValueString()
+	backup := r.client.backupPath(ctx, data.BackupPrefix.ValueString(), secretPath, timeNow())
+
+	err := r.client.BackupSecret(ctx, secretPath, backup)
+	if err != nil && ErrorCode(err) == CodeSecretNotFound {
+		tflog.Info(ctx, "No gopass secret to back up before update", map[string]interface{}{
+			"path": secretPath,
+		})
+		return diags
+	}
+	if err != nil {
+		diags.AddError(
+			"Failed to back up secret",
+			errorDetail(err, fmt.Sprintf("Could not copy the secret at %q to %q before updating it, so it was not updated: %s",
+				secretPath, backup, err.Error())),
+		)
+		return diags
+	}
+
+	tflog.Info(ctx, "Backed up gopass secret before update", map[string]interface{}{
+		"path":   secretPath,
+		"backup": r.client.storeName(ctx, backup),
+	})
+	return diags
+}
@@ -0,0 +1,195 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestBackupPath(t *testing.T) {
+	mountStores(t, "work")
+	at := time.Date(2026, 10, 16, 14, 30, 5, 0, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		prefix string
+		secret string
+		want   string
+	}{
+		{prefix: "", secret: "app/db", want: "app/db.tf-backup-20261016T123005Z"},
+		{prefix: "backups", secret: "app/db", want: "backups/app/db.tf-backup-20261016T123005Z"},
+		{prefix: "backups/", secret: "app/db", want: "backups/app/db.tf-backup-20261016T123005Z"},
+		{prefix: "", secret: "work:app/db", want: "work/app/db.tf-backup-20261016T123005Z"},
+		{prefix: "backups", secret: "work:app/db", want: "backups/work/app/db.tf-backup-20261016T123005Z"},
+		{prefix: "work:backups", secret: "app/db", want: "work/backups/app/db.tf-backup-20261016T123005Z"},
+	}
+
+	for _, tc := range tests {
+		if got := NewGopassClient("").backupPath(context.Background(), tc.prefix, tc.secret, at); got != tc.want {
+			t.Errorf("backupPath(%q, %q) = %q, want %q", tc.prefix, tc.secret, got, tc.want)
+		}
+	}
+}
+
+func TestSecretResource_Update_BackupChunked(t *testing.T) {
+	mountStores(t, "work")
+	now := timeNow
+	timeNow = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = now })
+
+	ctx := context.Background()
+	store := storeWith(map[string]string{
+		"terraform/dev/work/app/db.part1": "old-",
+		"terraform/dev/work/app/db.part2": "password",
+	})
+	store.secrets["terraform/dev/work/app/db"] = secrets.ParseAKV([]byte("\nchunks: 2\n"))
+	client := NewGopassClient(t.TempDir())
+	client.SetPathPrefix(ctx, "terraform/dev")
+	client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+		return store, nil
+	}
+	r := &SecretResource{client: client}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	value := func(version int, password any) tftypes.Value {
+		return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"id":                   tftypes.NewValue(tftypes.String, "work:app/db"),
+			"path":                 tftypes.NewValue(tftypes.String, "work:app/db"),
+			"value_wo":             tftypes.NewValue(tftypes.String, password),
+			"value_wo_version":     tftypes.NewValue(tftypes.Number, version),
+			"chunk_size":           tftypes.NewValue(tftypes.Number, 4),
+			"backup_before_update": tftypes.NewValue(tftypes.Bool, true),
+			"backup_prefix":        tftypes.NewValue(tftypes.String, "backups"),
+		})
+	}
+	resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Update(ctx, resource.UpdateRequest{
+		State:  tfsdk.State{Schema: schemaResp.Schema, Raw: value(1, nil)},
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: value(2, nil)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: value(2, "new-password")},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	// The backup is below the path prefix, with the mount resolved, and has all parts
+	backup := "terraform/dev/backups/work/app/db.tf-backup-20261016T120000Z"
+	if got, ok := store.secrets[backup].Get(chunksField); !ok || got != "2" {
+		t.Fatalf("expected the chunked backup at %s, got %v", backup, store.secrets)
+	}
+	got, err := client.GetSecret(ctx, "backups/work/app/db.tf-backup-20261016T120000Z")
+	if err != nil || got != "old-password" {
+		t.Errorf("expected the backup to hold the previous value, got %q, %v", got, err)
+	}
+}
+
+func TestSecretResource_Update_BackupBeforeUpdate(t *testing.T) {
+	now := timeNow
+	timeNow = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = now })
+
+	tests := []struct {
+		name       string
+		backup     bool
+		prefix     any
+		existing   bool
+		failOnGet  bool
+		policy     bool
+		wantBackup string
+		wantErr    string
+	}{
+		{name: "disabled", existing: true},
+		{name: "next to secret", backup: true, existing: true, wantBackup: "app/db.tf-backup-20261016T120000Z"},
+		{name: "in folder", backup: true, prefix: "backups", existing: true, wantBackup: "backups/app/db.tf-backup-20261016T120000Z"},
+		{name: "secret missing", backup: true},
+		{
+			// The backup keeps the value in the store, only the new one must meet the policy
+			name: "previous value violates the policy", backup: true, existing: true, policy: true,
+			wantBackup: "app/db.tf-backup-20261016T120000Z",
+		},
+		{
+			name: "backup fails", backup: true, existing: true, failOnGet: true,
+			wantErr: `Could not copy the secret at "app/db" to "app/db.tf-backup-20261016T120000Z" before updating it, so it was not updated`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMockStoreWithSelectiveFailure()
+			if tc.existing {
+				store.secrets["app/db"] = newMockSecret("old-password")
+				store.revisions["app/db"] = []string{"1"}
+			}
+			store.failOnGet["app/db"] = tc.failOnGet
+			client := NewGopassClient("")
+			client.store = store
+			if tc.policy {
+				if err := client.SetPasswordPolicy(PasswordPolicy{BannedSubstrings: []string{"old"}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			value := func(version int, password any) tftypes.Value {
+				return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"id":                   tftypes.NewValue(tftypes.String, "app/db"),
+					"path":                 tftypes.NewValue(tftypes.String, "app/db"),
+					"value_wo":             tftypes.NewValue(tftypes.String, password),
+					"value_wo_version":     tftypes.NewValue(tftypes.Number, version),
+					"backup_before_update": tftypes.NewValue(tftypes.Bool, tc.backup),
+					"backup_prefix":        tftypes.NewValue(tftypes.String, tc.prefix),
+				})
+			}
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(ctx, resource.UpdateRequest{
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: value(1, nil)},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: value(2, nil)},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: value(2, "new-password")},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				if store.secrets["app/db"].Password() != "old-password" {
+					t.Error("expected secret to be left unchanged")
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if store.secrets["app/db"].Password() != "new-password" {
+				t.Errorf("expected secret to be updated, got %q", store.secrets["app/db"].Password())
+			}
+
+			var backups []string
+			for p := range store.secrets {
+				if strings.Contains(p, backupInfix) {
+					backups = append(backups, p)
+				}
+			}
+			switch {
+			case tc.wantBackup == "" && len(backups) > 0:
+				t.Errorf("expected no backup, got %q", backups)
+			case tc.wantBackup != "" && (len(backups) != 1 || backups[0] != tc.wantBackup):
+				t.Errorf("expected backup %q, got %q", tc.wantBackup, backups)
+			case tc.wantBackup != "" && store.secrets[tc.wantBackup].Password() != "old-password":
+				t.Errorf("expected backup of the previous value, got %q", store.secrets[tc.wantBackup].Password())
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// validateChunkSize rejects a chunk_size below one byte, and chunk_size combined with
+// attributes writing more than the password, as the chunked secret only holds the parts,
+// or with purge_on_remove.
+func validateChunkSize(diags *diag.Diagnostics, config *SecretResourceModel) {
+	if config.ChunkSize.IsNull() {
+		return
+	}
+
+	if !config.ChunkSize.IsUnknown() && config.ChunkSize.ValueInt64() < 1 {
+		diags.AddAttributeError(
+			path.Root("chunk_size"),
+			"Invalid chunk_size",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("chunk_size must be at least 1 byte, got %d.", config.ChunkSize.ValueInt64())),
+		)
+	}
+
+	var conflict, reason string
+	switch {
+	case !config.Compose.IsNull():
+		conflict, reason = "compose", "chunk_size splits a single value into parts, but compose writes several fields"
+	case !config.ValueFileWO.IsNull():
+		conflict, reason = "value_file_wo", "chunk_size splits a single value into parts, but value_file_wo writes the whole secret"
+	case config.PreserveFields.ValueBool():
+		conflict, reason = "preserve_existing_fields", "a chunked secret only records its parts, so existing fields cannot be preserved"
+	case config.PurgeOnRemove.ValueBool():
+		conflict, reason = "purge_on_remove", "the history of the parts cannot be purged, as removed parts can no longer be found"
+	default:
+		return
+	}
+	diags.AddAttributeError(
+		path.Root("chunk_size"),
+		"Conflicting chunk_size and "+conflict,
+		codedDetail(CodeInvalidConfig, reason+". Remove one of them."),
+	)
+}
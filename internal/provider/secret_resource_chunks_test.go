@@ -0,0 +1,252 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// chunkedValues returns the values of a gopass_secret at app/cert written in parts of chunkSize bytes.
+func chunkedValues(chunkSize any) map[string]tftypes.Value {
+	return map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/cert"),
+		"path":             tftypes.NewValue(tftypes.String, "app/cert"),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, 1),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"chunk_size":       tftypes.NewValue(tftypes.Number, chunkSize),
+	}
+}
+
+func TestSecretResource_ValidateConfig_ChunkSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    any
+		extra   map[string]tftypes.Value
+		wantErr string
+	}{
+		{name: "chunked", size: 4096},
+		{name: "unknown", size: tftypes.UnknownValue},
+		{name: "zero", size: 0, wantErr: "Invalid chunk_size"},
+		{
+			name: "compose", size: 4096, extra: map[string]tftypes.Value{"compose": composeRaw("pw", "admin")},
+			wantErr: "Conflicting chunk_size and compose",
+		},
+		{
+			name: "value file", size: 4096, extra: map[string]tftypes.Value{"value_file_wo": tftypes.NewValue(tftypes.String, "cert.pem")},
+			wantErr: "Conflicting chunk_size and value_file_wo",
+		},
+		{
+			name: "preserve fields", size: 4096, extra: map[string]tftypes.Value{"preserve_existing_fields": tftypes.NewValue(tftypes.Bool, true)},
+			wantErr: "Conflicting chunk_size and preserve_existing_fields",
+		},
+		{
+			name: "purge", size: 4096, extra: map[string]tftypes.Value{"purge_on_remove": tftypes.NewValue(tftypes.Bool, true)},
+			wantErr: "Conflicting chunk_size and purge_on_remove",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(context.Background(), resource.SchemaRequest{}, schemaResp)
+
+			values := chunkedValues(tc.size)
+			values["value_wo"] = tftypes.NewValue(tftypes.String, "s3cret")
+			for name, value := range tc.extra {
+				values[name] = value
+			}
+			if _, ok := tc.extra["value_file_wo"]; ok {
+				delete(values, "value_wo")
+			}
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, values)},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_ChunkSize(t *testing.T) {
+	store := newMockStore()
+	client := NewGopassClient("")
+	client.store = store
+	r := &SecretResource{client: client}
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	values := chunkedValues(4)
+	plan := schemaObjectValue(schemaResp.Schema, values)
+	values["value_wo"] = tftypes.NewValue(tftypes.String, "-----BEGIN-----")
+	config := schemaObjectValue(schemaResp.Schema, values)
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+	}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if got, _ := store.secrets["app/cert"].Get(chunksField); got != "4" {
+		t.Errorf("expected 4 chunks, got %q", got)
+	}
+	if got := store.secrets["app/cert.part4"].Password(); got != "---" {
+		t.Errorf("expected last part %q, got %q", "---", got)
+	}
+	if value, err := client.GetSecret(ctx, "app/cert"); err != nil || value != "-----BEGIN-----" {
+		t.Errorf("GetSecret() = %q, %v", value, err)
+	}
+}
+
+func TestSecretResource_Delete_ChunkSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize any
+		failAfter int
+		wantNames []string
+		wantErr   string
+	}{
+		{name: "chunked", chunkSize: 4, wantNames: []string{"app/certificate"}},
+		{name: "not chunked", chunkSize: nil, wantNames: []string{"app/cert.part1", "app/cert.part2", "app/certificate"}},
+		{
+			name: "parts remain", chunkSize: 4, failAfter: 1,
+			wantNames: []string{"app/cert.part1", "app/cert.part2", "app/certificate"},
+			wantErr:   `Could not remove the parts of the secret at "app/cert"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"app/cert.part1": "abcd", "app/cert.part2": "ef", "app/certificate": "other"})
+			store.secrets["app/cert"] = secrets.ParseAKV([]byte("\nchunks: 2\n"))
+			client := NewGopassClient("")
+			client.store = store
+			if tc.failAfter > 0 {
+				client.addHooks(&failAfterWrites{store: store, writes: tc.failAfter})
+			}
+			r := &SecretResource{client: client}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(ctx, resource.DeleteRequest{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, chunkedValues(tc.chunkSize))},
+			}, resp)
+
+			if tc.wantErr == "" && resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if tc.wantErr != "" && (!resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantErr)) {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if names := storeNames(store); strings.Join(names, ",") != strings.Join(tc.wantNames, ",") {
+				t.Errorf("expected secrets %q, got %q", tc.wantNames, names)
+			}
+		})
+	}
+}
+
+func TestSecretEphemeralResource_Open_Chunked(t *testing.T) {
+	store := storeWith(map[string]string{"app/cert.part1": "abcd", "app/cert.part2": "ef"})
+	store.secrets["app/cert"] = secrets.ParseAKV([]byte("\nchunks: 2\n"))
+	client := NewGopassClient("")
+	client.store = store
+	r := &SecretEphemeralResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	resp := &ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, "app/cert"),
+			}),
+		},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var result SecretModel
+	resp.Result.Get(ctx, &result)
+	if result.Value.ValueString() != "abcdef" || result.Password.ValueString() != "abcdef" {
+		t.Errorf("expected joined value, got value %q, password %q", result.Value.ValueString(), result.Password.ValueString())
+	}
+}
+
+func TestSecretResource_Update_ChunkSizeRemoved(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   int
+		wantValue string
+	}{
+		{name: "joined", version: 1, wantValue: "abcdef"},
+		{name: "rewritten", version: 2, wantValue: "-----BEGIN-----"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"app/cert.part1": "abcd", "app/cert.part2": "ef"})
+			store.secrets["app/cert"] = secrets.ParseAKV([]byte("\nchunks: 2\n"))
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			state := schemaObjectValue(schemaResp.Schema, chunkedValues(4))
+			values := chunkedValues(nil)
+			values["value_wo_version"] = tftypes.NewValue(tftypes.Number, tc.version)
+			plan := schemaObjectValue(schemaResp.Schema, values)
+			values["value_wo"] = tftypes.NewValue(tftypes.String, "-----BEGIN-----")
+			config := schemaObjectValue(schemaResp.Schema, values)
+
+			resp := withPrivateData(&resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+			}, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if names := storeNames(store); len(names) != 1 || names[0] != "app/cert" {
+				t.Errorf("expected the parts to be removed, got %v", names)
+			}
+			if got := store.secrets["app/cert"].Password(); got != tc.wantValue {
+				t.Errorf("expected value %q, got %q", tc.wantValue, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// commentValue returns a gopass_secret object at app/key with the given value_wo and comment.
+func commentValue(schemaResp resource.SchemaResponse, value, comment any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/key"),
+		"path":             tftypes.NewValue(tftypes.String, "app/key"),
+		"value_wo":         tftypes.NewValue(tftypes.String, value),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, 1),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"comment":          tftypes.NewValue(tftypes.String, comment),
+	})
+}
+
+func TestSecretResource_ValidateConfig_Comment(t *testing.T) {
+	tests := []struct {
+		name     string
+		comment  any
+		wantErrs int
+	}{
+		{name: "single line", comment: "managed by terraform, module db, workspace prod"},
+		{name: "unset", comment: nil},
+		{name: "with line break", comment: "managed by terraform\npassword: x", wantErrs: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&SecretResource{client: clientWith(newMockStore())})
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: commentValue(schemaResp, "secret", tc.comment)},
+			}, resp)
+			if got := resp.Diagnostics.ErrorsCount(); got != tc.wantErrs {
+				t.Errorf("expected %d errors, got %v", tc.wantErrs, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_Comment(t *testing.T) {
+	store := newMockStore()
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+	raw := commentValue(schemaResp, "secret", "managed by terraform, module db")
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(context.Background(), resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	secret := store.secrets["app/key"]
+	if comment, _ := secret.Get(commentKey); comment != "managed by terraform, module db" {
+		t.Errorf("expected the comment in gopass, got %q", comment)
+	}
+	if password := secret.Password(); password != "secret" {
+		t.Errorf("expected password to be kept, got %q", password)
+	}
+}
+
+func TestSecretResource_Read_Comment(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		want     any
+	}{
+		{name: "unchanged", existing: "managed by terraform", want: "managed by terraform"},
+		{name: "changed externally", existing: "edited by hand", want: "edited by hand"},
+		{name: "removed externally", want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			existing := newMockSecret("value")
+			if tc.existing != "" {
+				existing.fields[commentKey] = tc.existing
+			}
+			store.secrets["app/key"] = existing
+			r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+			raw := commentValue(schemaResp, nil, "managed by terraform")
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			// A comment changed outside of Terraform shows up as drift in the next plan
+			var data SecretResourceModel
+			resp.State.Get(context.Background(), &data)
+			if tc.want == nil && !data.Comment.IsNull() || tc.want != nil && data.Comment.ValueString() != tc.want {
+				t.Errorf("expected comment %v, got %v", tc.want, data.Comment)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ImportState_Comment(t *testing.T) {
+	store := newMockStore()
+	existing := newMockSecret("value")
+	existing.fields[commentKey] = "managed by terraform"
+	store.secrets["app/key"] = existing
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "app/key"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if data.Comment.ValueString() != "managed by terraform" {
+		t.Errorf("expected the comment from gopass, got %v", data.Comment)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// SecretComposeModel describes the compose attribute of gopass_secret.
+type SecretComposeModel struct {
+	Password   types.String `tfsdk:"password"`
+	Username   types.String `tfsdk:"username"`
+	URL        types.String `tfsdk:"url"`
+	ExtraLines types.List   `tfsdk:"extra_lines"`
+}
+
+// composeAttribute returns the write-only compose attribute of gopass_secret.
+func composeAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Assembles the secret from multiple write-only parts instead of value_wo. " +
+			"Written on create and when value_wo_version changes; never stored in state.",
+		MarkdownDescription: "Assembles the secret from multiple **write-only** parts instead of `value_wo`. " +
+			"Written on create and when `value_wo_version` changes; never stored in state.",
+		Optional:  true,
+		WriteOnly: true,
+		Attributes: map[string]schema.Attribute{
+			"password": schema.StringAttribute{
+				Description: "Password, written as the first line of the secret.",
+				Optional:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+			},
+			"username": schema.StringAttribute{
+				Description:         "Username, written as the 'username' field.",
+				MarkdownDescription: "Username, written as the `username` field.",
+				Optional:            true,
+				Sensitive:           true,
+				WriteOnly:           true,
+			},
+			"url": schema.StringAttribute{
+				Description:         "URL, written as the 'url' field.",
+				MarkdownDescription: "URL, written as the `url` field.",
+				Optional:            true,
+				WriteOnly:           true,
+			},
+			"extra_lines": schema.ListAttribute{
+				Description: "Additional lines appended to the secret body, e.g. notes or 'key: value' fields.",
+				MarkdownDescription: "Additional lines appended to the secret body, e.g. notes or " +
+					"`key: value` fields.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+			},
+		},
+	}
+}
+
+// composeParts converts a configured compose object into the parts of a secret.
+func composeParts(ctx context.Context, compose types.Object) (SecretParts, diag.Diagnostics) {
+	var model SecretComposeModel
+	diags := compose.As(ctx, &model, basetypes.ObjectAsOptions{})
+	if diags.HasError() {
+		return SecretParts{}, diags
+	}
+
+	parts := SecretParts{
+		Password: model.Password.ValueString(),
+		Username: model.Username.ValueString(),
+		URL:      model.URL.ValueString(),
+	}
+	if !model.ExtraLines.IsNull() {
+		diags.Append(model.ExtraLines.ElementsAs(ctx, &parts.ExtraLines, false)...)
+	}
+	return parts, diags
+}
+
+// hasCompose reports whether a compose object is configured with a known value.
+func hasCompose(compose types.Object) bool {
+	return !compose.IsNull() && !compose.IsUnknown()
+}
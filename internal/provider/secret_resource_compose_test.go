@@ -0,0 +1,234 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var composeType = tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+	"password":    tftypes.String,
+	"username":    tftypes.String,
+	"url":         tftypes.String,
+	"extra_lines": tftypes.List{ElementType: tftypes.String},
+}}
+
+// composeRaw builds a raw compose object with a password, a username and extra lines.
+func composeRaw(password, username string, extraLines ...string) tftypes.Value {
+	lines := make([]tftypes.Value, 0, len(extraLines))
+	for _, line := range extraLines {
+		lines = append(lines, tftypes.NewValue(tftypes.String, line))
+	}
+	return tftypes.NewValue(composeType, map[string]tftypes.Value{
+		"password":    tftypes.NewValue(tftypes.String, password),
+		"username":    tftypes.NewValue(tftypes.String, username),
+		"url":         tftypes.NewValue(tftypes.String, nil),
+		"extra_lines": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, lines),
+	})
+}
+
+// composeRawNullLine builds a raw compose object whose extra lines contain a null element.
+func composeRawNullLine() tftypes.Value {
+	return tftypes.NewValue(composeType, map[string]tftypes.Value{
+		"password":    tftypes.NewValue(tftypes.String, "pw"),
+		"username":    tftypes.NewValue(tftypes.String, nil),
+		"url":         tftypes.NewValue(tftypes.String, nil),
+		"extra_lines": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, nil)}),
+	})
+}
+
+// secretRaws returns the plan and config of a gopass_secret with the given compose object.
+// Write-only values are only present in the config.
+func secretRaws(schemaResp *resource.SchemaResponse, version any, compose tftypes.Value) (tftypes.Value, tftypes.Value) {
+	values := map[string]tftypes.Value{
+		"path":             tftypes.NewValue(tftypes.String, "websites/example"),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+	}
+	plan := schemaObjectValue(schemaResp.Schema, values)
+	values["compose"] = compose
+	return plan, schemaObjectValue(schemaResp.Schema, values)
+}
+
+func TestSecretResource_Create_Compose(t *testing.T) {
+	tests := []struct {
+		name     string
+		compose  tftypes.Value
+		wantErr  bool
+		wantPass string
+	}{
+		{name: "composed", compose: composeRaw("s3cret", "admin", "recovery: abc"), wantPass: "s3cret"},
+		{name: "invalid part", compose: composeRaw("s3cret", "admin\nroot"), wantErr: true},
+		{name: "null extra line", compose: composeRawNullLine(), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+			plan, config := secretRaws(schemaResp, 1, tc.compose)
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if resp.Diagnostics.WarningsCount() != 0 {
+				t.Errorf("expected no warnings, got %v", resp.Diagnostics)
+			}
+			secret := store.secrets["websites/example"]
+			if secret.Password() != tc.wantPass {
+				t.Errorf("expected password %q, got %q", tc.wantPass, secret.Password())
+			}
+			if got, _ := secret.Get("username"); got != "admin" {
+				t.Errorf("expected username 'admin', got %q", got)
+			}
+			if got, _ := secret.Get("recovery"); got != "abc" {
+				t.Errorf("expected extra line field 'recovery', got %q", got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_Compose(t *testing.T) {
+	tests := []struct {
+		name     string
+		compose  tftypes.Value
+		wantErr  bool
+		wantPass string
+	}{
+		{name: "composed", compose: composeRaw("new", "admin"), wantPass: "new"},
+		{name: "invalid part", compose: composeRaw("new\nline", "admin"), wantErr: true, wantPass: "old"},
+		{name: "null extra line", compose: composeRawNullLine(), wantErr: true, wantPass: "old"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"websites/example": "old"})
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+			state, _ := secretRaws(schemaResp, 1, tftypes.NewValue(composeType, nil))
+			plan, config := secretRaws(schemaResp, 2, tc.compose)
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if got := store.secrets["websites/example"].Password(); got != tc.wantPass {
+				t.Errorf("expected password %q, got %q", tc.wantPass, got)
+			}
+		})
+	}
+}
+
+func TestComposeParts_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		compose types.Object
+	}{
+		{
+			name: "unexpected attributes",
+			compose: types.ObjectValueMust(map[string]attr.Type{"other": types.StringType}, map[string]attr.Value{
+				"other": types.StringValue("x"),
+			}),
+		},
+		{
+			name: "null extra line",
+			compose: types.ObjectValueMust(map[string]attr.Type{
+				"password":    types.StringType,
+				"username":    types.StringType,
+				"url":         types.StringType,
+				"extra_lines": types.ListType{ElemType: types.StringType},
+			}, map[string]attr.Value{
+				"password":    types.StringValue("pw"),
+				"username":    types.StringNull(),
+				"url":         types.StringNull(),
+				"extra_lines": types.ListValueMust(types.StringType, []attr.Value{types.StringNull()}),
+			}),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, diags := composeParts(ctx, tc.compose); !diags.HasError() {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestSecretResource_ValidateConfig_Compose(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    any
+		version  any
+		preserve any
+		wantErr  string
+	}{
+		{name: "compose with version", version: 1},
+		{name: "compose without version", wantErr: "Missing value_wo_version"},
+		{name: "compose and value_wo", value: "secret", version: 1, wantErr: "Conflicting value_wo and compose"},
+		{name: "compose and preserve_existing_fields", version: 1, preserve: true, wantErr: "Conflicting preserve_existing_fields and compose"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":                     tftypes.NewValue(tftypes.String, "websites/example"),
+				"value_wo":                 tftypes.NewValue(tftypes.String, tc.value),
+				"value_wo_version":         tftypes.NewValue(tftypes.Number, tc.version),
+				"preserve_existing_fields": tftypes.NewValue(tftypes.Bool, tc.preserve),
+				"compose":                  composeRaw("pw", "admin"),
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
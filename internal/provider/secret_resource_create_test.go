@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
@@ -29,16 +30,7 @@ func TestSecretResource_Create(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// Create plan and config values
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -47,16 +39,7 @@ func TestSecretResource_Create(t *testing.T) {
 		"revision_count":   tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
 	})
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, nil),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "test-password"),
@@ -119,16 +102,7 @@ func TestSecretResource_Create_NoValueWO(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -137,16 +111,7 @@ func TestSecretResource_Create_NoValueWO(t *testing.T) {
 		"revision_count":   tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
 	})
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, nil),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil), // No value provided
@@ -205,34 +170,18 @@ func TestSecretResource_Create_SetSecretError(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"value_wo_version": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
 		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
 		"revision_count":   tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		// Skips the existence check, which would fail the same way
+		"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
 	})
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, nil),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "test-password"),
@@ -305,34 +254,18 @@ func TestSecretResource_Create_GetRevisionCountError(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret-error"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"value_wo_version": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
 		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
 		"revision_count":   tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+		// Skips the existence check, which would fail the same way
+		"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
 	})
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, nil),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret-error"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "test-password"),
@@ -448,25 +381,49 @@ func TestSecretResource_Create_ConfigGetError(t *testing.T) {
 	// 1. Create a VALID schema and value for Plan (so Plan.Get succeeds)
 	validSchema := schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"path":             schema.StringAttribute{Required: true},
-			"id":               schema.StringAttribute{Computed: true},
-			"value_wo":         schema.StringAttribute{Optional: true},
-			"value_wo_version": schema.Int64Attribute{Optional: true},
-			"delete_on_remove": schema.BoolAttribute{Optional: true},
-			"revision_count":   schema.Int64Attribute{Computed: true},
-		},
-	}
-
-	validPlanValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":             tftypes.String,
-			"id":               tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+			"path":                       schema.StringAttribute{Required: true},
+			"id":                         schema.StringAttribute{Computed: true},
+			"value_wo":                   schema.StringAttribute{Optional: true},
+			"value_wo_version":           schema.Int64Attribute{Optional: true},
+			"delete_on_remove":           schema.BoolAttribute{Optional: true},
+			"purge_on_remove":            schema.BoolAttribute{Optional: true},
+			"value_file_wo":              schema.StringAttribute{Optional: true},
+			"backup_before_update":       schema.BoolAttribute{Optional: true},
+			"backup_prefix":              schema.StringAttribute{Optional: true},
+			"revision_count":             schema.Int64Attribute{Computed: true},
+			"generate_if_missing":        schema.BoolAttribute{Optional: true},
+			"generate_length":            schema.Int64Attribute{Optional: true},
+			"generate_symbols":           schema.BoolAttribute{Optional: true},
+			"preserve_existing_fields":   schema.BoolAttribute{Optional: true},
+			"compose":                    composeAttribute(),
+			"data_wo":                    dataAttribute(),
+			"data_wo_version":            schema.Int64Attribute{Optional: true},
+			"timeouts":                   resourceTimeoutsAttribute(),
+			"revisions_supported":        schema.BoolAttribute{Computed: true},
+			"exists":                     schema.BoolAttribute{Computed: true},
+			"path_components":            schema.ListAttribute{ElementType: types.StringType, Computed: true},
+			"comment":                    schema.StringAttribute{Optional: true, Computed: true},
+			"last_revision":              schema.ObjectAttribute{Computed: true, AttributeTypes: lastRevisionAttrTypes},
+			"revision_tracking":          schema.StringAttribute{Optional: true},
+			"on_external_change":         schema.StringAttribute{Optional: true},
+			"generate":                   generateAttribute(),
+			"validate_regex":             schema.StringAttribute{Optional: true},
+			"min_length":                 schema.Int64Attribute{Optional: true},
+			"forbid_whitespace":          schema.BoolAttribute{Optional: true},
+			"normalize":                  schema.StringAttribute{Optional: true},
+			"expires_at":                 schema.StringAttribute{Optional: true, Computed: true},
+			"username":                   schema.StringAttribute{Optional: true, Computed: true},
+			"url":                        schema.StringAttribute{Optional: true, Computed: true},
+			"created_at":                 schema.StringAttribute{Computed: true},
+			"chunk_size":                 schema.Int64Attribute{Optional: true},
+			"confirm_overwrite_existing": schema.BoolAttribute{Optional: true},
+			"only_if_absent":             schema.BoolAttribute{Optional: true},
+			"verify_after_write":         schema.BoolAttribute{Optional: true},
+			"updated_at":                 schema.StringAttribute{Computed: true},
+		},
+	}
+
+	validPlanValue := schemaObjectValue(validSchema, map[string]tftypes.Value{
 		"path":             tftypes.NewValue(tftypes.String, "some/path"),
 		"id":               tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -0,0 +1,173 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// dataKeysKey is the private state key holding the keys of data_wo Terraform wrote last.
+const dataKeysKey = "data_wo_keys"
+
+// reservedDataKeys are the fields of the secret managed by other attributes of gopass_secret.
+var reservedDataKeys = []string{usernameKey, urlKey, commentKey, expiresAtKey, chunksField}
+
+// dataAttribute returns the write-only data_wo attribute of gopass_secret.
+func dataAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		Description: "Fields to write to the secret, by key, like 'key: value' lines of gopass. " +
+			"Written in a single write on create and when data_wo_version changes, keeping the password and other fields; " +
+			"fields Terraform wrote before that are no longer in the map are removed. Never stored in state.",
+		MarkdownDescription: "Fields to write to the secret, by key, like `key: value` lines of gopass. " +
+			"Written in a single write on create and when `data_wo_version` changes, keeping the password and other fields; " +
+			"fields Terraform wrote before that are no longer in the map are removed. **Never stored** in state.",
+		ElementType: types.StringType,
+		Optional:    true,
+		Sensitive:   true,
+		WriteOnly:   true,
+	}
+}
+
+// dataVersionAttribute returns the data_wo_version attribute of gopass_secret.
+func dataVersionAttribute() schema.Int64Attribute {
+	return schema.Int64Attribute{
+		Description: "Version number for data_wo. Increment this to write the fields when data_wo changes, " +
+			"independently of value_wo_version.",
+		MarkdownDescription: "Version number for `data_wo`. **Increment this** to write the fields when `data_wo` changes, " +
+			"independently of `value_wo_version`.",
+		Optional: true,
+		PlanModifiers: []planmodifier.Int64{
+			int64planmodifier.UseStateForUnknown(),
+		},
+	}
+}
+
+// validateData adds errors to diags for data_wo without data_wo_version and vice versa, and for
+// keys that are no valid fields or are managed by other attributes. Values are never included.
+func validateData(diags *diag.Diagnostics, config *SecretResourceModel) {
+	switch {
+	case !config.DataWO.IsNull() && config.DataWOVersion.IsNull():
+		diags.AddAttributeError(
+			path.Root("data_wo_version"),
+			"Missing data_wo_version",
+			codedDetail(CodeInvalidConfig, "data_wo is set but data_wo_version is not. Without a version, later changes to the fields "+
+				"are never written to gopass. Set data_wo_version and increment it whenever data_wo changes."),
+		)
+	case config.DataWO.IsNull() && !config.DataWOVersion.IsNull():
+		diags.AddAttributeError(
+			path.Root("data_wo"),
+			"Missing data_wo",
+			codedDetail(CodeInvalidConfig, "data_wo_version is set but data_wo is not, so there are no fields to write when the version changes. "+
+				"Set data_wo, or remove data_wo_version."),
+		)
+	}
+
+	for key, value := range config.DataWO.Elements() {
+		switch {
+		case key == "" || strings.ContainsAny(key, ": \t\r\n"):
+			diags.AddAttributeError(
+				path.Root("data_wo").AtMapKey(key),
+				"Invalid data_wo key",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("%q is no valid field of a secret: keys must not be empty or contain colons or whitespace.", key)),
+			)
+		case slices.Contains(reservedDataKeys, key):
+			diags.AddAttributeError(
+				path.Root("data_wo").AtMapKey(key),
+				"Invalid data_wo key",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("The %s field is managed by another attribute of gopass_secret. Set that attribute instead.", key)),
+			)
+		default:
+			if s, ok := value.(types.String); ok && !s.IsUnknown() && strings.ContainsAny(s.ValueString(), "\r\n") {
+				diags.AddAttributeError(
+					path.Root("data_wo").AtMapKey(key),
+					"Invalid data_wo value",
+					codedDetail(CodeInvalidConfig, fmt.Sprintf("The value of %s must not contain line breaks, as a field of a secret holds a single line.", key)),
+				)
+			}
+		}
+	}
+}
+
+// hasData reports whether data_wo is configured with a known value.
+func hasData(dataWO types.Map) bool {
+	return !dataWO.IsNull() && !dataWO.IsUnknown()
+}
+
+// dataUpdates adds the fields of dataWO to fields, to be written to the secret at secretPath,
+// and returns those Terraform wrote before, according to previous, that dataWO no longer has,
+// to be removed in the same write.
+func dataUpdates(ctx context.Context, fields map[string]string, dataWO types.Map, previous privateState) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	values := make(map[string]string, len(dataWO.Elements()))
+	diags.Append(dataWO.ElementsAs(ctx, &values, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	var written []string
+	if previous != nil {
+		written, diags = loadDataKeys(ctx, previous)
+		if diags.HasError() {
+			return nil, diags
+		}
+	}
+	var remove []string
+	for _, key := range written {
+		if _, ok := values[key]; !ok {
+			remove = append(remove, key)
+		}
+	}
+
+	for key, value := range values {
+		fields[key] = value
+	}
+	return remove, diags
+}
+
+// recordDataKeys records the keys of dataWO, never the values, in private once they were
+// written, for the next write to remove those dropped from it. If that fails, fields dropped
+// from dataWO later are kept in the secret.
+func recordDataKeys(ctx context.Context, secretPath string, dataWO types.Map, private privateState) {
+	keys := make([]string, 0, len(dataWO.Elements()))
+	for key := range dataWO.Elements() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data, _ := json.Marshal(keys) //nolint:errcheck // a list of strings always marshals
+	if diags := private.SetKey(ctx, dataKeysKey, data); diags.HasError() {
+		tflog.Warn(ctx, "Could not record data_wo keys", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+}
+
+// loadDataKeys reads the keys of data_wo Terraform wrote last from private state. It returns
+// nil if none were recorded.
+func loadDataKeys(ctx context.Context, private privateState) ([]string, diag.Diagnostics) {
+	data, diags := private.GetKey(ctx, dataKeysKey)
+	if diags.HasError() || data == nil {
+		return nil, diags
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		diags.AddError("Failed to decode data_wo keys", codedDetail(CodeInternal, err.Error()))
+		return nil, diags
+	}
+	return keys, diags
+}
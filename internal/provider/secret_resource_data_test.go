@@ -0,0 +1,364 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// dataAttributes returns the attributes of a gopass_secret at app/key with the given value_wo and
+// version, and data_wo and data_wo_version. A nil data leaves data_wo unset.
+func dataAttributes(value any, version int, data map[string]any, dataVersion any) map[string]tftypes.Value {
+	mapType := tftypes.Map{ElementType: tftypes.String}
+	dataWO := tftypes.NewValue(mapType, nil)
+	if data != nil {
+		elements := make(map[string]tftypes.Value, len(data))
+		for key, v := range data {
+			elements[key] = tftypes.NewValue(tftypes.String, v)
+		}
+		dataWO = tftypes.NewValue(mapType, elements)
+	}
+	return map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/key"),
+		"path":             tftypes.NewValue(tftypes.String, "app/key"),
+		"value_wo":         tftypes.NewValue(tftypes.String, value),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"data_wo":          dataWO,
+		"data_wo_version":  tftypes.NewValue(tftypes.Number, dataVersion),
+	}
+}
+
+// dataValue returns a gopass_secret object with the attributes of dataAttributes.
+func dataValue(schemaResp resource.SchemaResponse, value any, version int, data map[string]any, dataVersion any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, dataAttributes(value, version, data, dataVersion))
+}
+
+func TestSecretResource_ValidateConfig_Data(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string]any
+		dataVersion any
+		wantErr     string
+	}{
+		{name: "unset"},
+		{name: "fields", data: map[string]any{"host": "db.example.com", "port": "5432"}, dataVersion: 1},
+		{name: "unknown value", data: map[string]any{"host": tftypes.UnknownValue}, dataVersion: 1},
+		{name: "without version", data: map[string]any{"host": "db.example.com"}, wantErr: "Missing data_wo_version"},
+		{name: "version only", dataVersion: 1, wantErr: "Missing data_wo"},
+		{name: "empty key", data: map[string]any{"": "x"}, dataVersion: 1, wantErr: "Invalid data_wo key"},
+		{name: "key with colon", data: map[string]any{"db:host": "x"}, dataVersion: 1, wantErr: "Invalid data_wo key"},
+		{name: "key with space", data: map[string]any{"db host": "x"}, dataVersion: 1, wantErr: "Invalid data_wo key"},
+		{name: "reserved key", data: map[string]any{usernameKey: "admin"}, dataVersion: 1, wantErr: "Invalid data_wo key"},
+		{name: "value with line break", data: map[string]any{"host": "db\npassword: x"}, dataVersion: 1, wantErr: "Invalid data_wo value"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&SecretResource{client: clientWith(newMockStore())})
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: dataValue(schemaResp, "secret", 1, tc.data, tc.dataVersion)},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.ErrorsCount() != 1 || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_Data(t *testing.T) {
+	tests := []struct {
+		name         string
+		value        any
+		onlyIfAbsent bool
+		existing     bool
+		wantPassword string
+		wantHost     string
+		wantKeys     string
+	}{
+		{name: "with value", value: "secret", wantPassword: "secret", wantHost: "db.example.com", wantKeys: `["host","port"]`},
+		{name: "fields only", existing: true, wantPassword: "old", wantHost: "db.example.com", wantKeys: `["host","port"]`},
+		{name: "existing kept", value: "secret", onlyIfAbsent: true, existing: true, wantPassword: "old"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMockStore()
+			if tc.existing {
+				store.secrets["app/key"] = newMockSecret("old")
+			}
+			r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+			attributes := dataAttributes(tc.value, 1, map[string]any{"host": "db.example.com", "port": "5432"}, 1)
+			attributes["only_if_absent"] = tftypes.NewValue(tftypes.Bool, tc.onlyIfAbsent)
+			raw := schemaObjectValue(schemaResp.Schema, attributes)
+
+			resp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+			if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 0 {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+
+			secret := store.secrets["app/key"]
+			if password := secret.Password(); password != tc.wantPassword {
+				t.Errorf("expected password %q, got %q", tc.wantPassword, password)
+			}
+			if host, _ := secret.Get("host"); host != tc.wantHost {
+				t.Errorf("expected host %q in gopass, got %q", tc.wantHost, host)
+			}
+			if keys, _ := resp.Private.GetKey(ctx, dataKeysKey); string(keys) != tc.wantKeys {
+				t.Errorf("expected recorded keys %s, got %s", tc.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_Data(t *testing.T) {
+	tests := []struct {
+		name          string
+		planVersion   int
+		dataVersion   int
+		previousKeys  string
+		wantPassword  string
+		wantFields    map[string]string
+		wantRevisions int
+	}{
+		{
+			name:          "unchanged",
+			planVersion:   1,
+			dataVersion:   1,
+			previousKeys:  `["host","port"]`,
+			wantPassword:  "old",
+			wantFields:    map[string]string{"host": "db.internal", "port": "5432", "note": "by hand"},
+			wantRevisions: 1,
+		},
+		{
+			name:          "version changed",
+			planVersion:   1,
+			dataVersion:   2,
+			previousKeys:  `["host","port"]`,
+			wantPassword:  "old",
+			wantFields:    map[string]string{"host": "db.example.com", "user": "app", "note": "by hand"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "no keys recorded",
+			planVersion:   1,
+			dataVersion:   2,
+			wantPassword:  "old",
+			wantFields:    map[string]string{"host": "db.example.com", "port": "5432", "user": "app", "note": "by hand"},
+			wantRevisions: 2,
+		},
+		{
+			name:          "restored after value rewrite",
+			planVersion:   2,
+			dataVersion:   1,
+			previousKeys:  `["host","port"]`,
+			wantPassword:  "new",
+			wantFields:    map[string]string{"host": "db.example.com", "user": "app"},
+			wantRevisions: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMockStore()
+			existing := newMockSecret("old")
+			existing.fields["host"] = "db.internal"
+			existing.fields["port"] = "5432"
+			existing.fields["note"] = "by hand"
+			store.secrets["app/key"] = existing
+			store.revisions["app/key"] = []string{"1"}
+			r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+			data := map[string]any{"host": "db.example.com", "user": "app"}
+			state := dataValue(schemaResp, nil, 1, nil, 1)
+			plan := dataValue(schemaResp, nil, tc.planVersion, nil, tc.dataVersion)
+			config := dataValue(schemaResp, "new", tc.planVersion, data, tc.dataVersion)
+
+			previous := withPrivateData(&resource.UpdateResponse{})
+			if tc.previousKeys != "" {
+				previous.Private.SetKey(ctx, dataKeysKey, []byte(tc.previousKeys))
+			}
+
+			resp := withPrivateData(&resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:    tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config:  tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				State:   tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+				Private: previous.Private,
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			secret := store.secrets["app/key"]
+			if password := secret.Password(); password != tc.wantPassword {
+				t.Errorf("expected password %q, got %q", tc.wantPassword, password)
+			}
+			for _, key := range []string{"host", "port", "user", "note"} {
+				got, ok := secret.Get(key)
+				want, wantOK := tc.wantFields[key]
+				if got != want || ok != wantOK {
+					t.Errorf("expected %s %q (present %v), got %q (present %v)", key, want, wantOK, got, ok)
+				}
+			}
+			if got := len(store.revisions["app/key"]); got != tc.wantRevisions {
+				t.Errorf("expected %d revisions, got %d", tc.wantRevisions, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Data_WriteFails(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.secrets["app/key"] = &readOnlySecret{newMockSecret("old")}
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+	data := map[string]any{"host": "db.example.com"}
+
+	createResp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+	raw := dataValue(schemaResp, nil, 1, data, 1)
+	r.Create(ctx, resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+	}, createResp)
+	if !createResp.Diagnostics.HasError() {
+		t.Error("expected create to fail")
+	}
+
+	updateResp := withPrivateData(&resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: dataValue(schemaResp, nil, 1, nil, 2)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: dataValue(schemaResp, nil, 1, data, 2)},
+		State:  tfsdk.State{Schema: schemaResp.Schema, Raw: dataValue(schemaResp, nil, 1, nil, 1)},
+	}, updateResp)
+	if !updateResp.Diagnostics.HasError() {
+		t.Error("expected update to fail")
+	}
+}
+
+func TestDataUpdates_Errors(t *testing.T) {
+	ctx := context.Background()
+	known := types.MapValueMust(types.StringType, map[string]attr.Value{"host": types.StringValue("db.example.com")})
+
+	tests := []struct {
+		name     string
+		dataWO   types.Map
+		previous privateState
+		wantErr  string
+	}{
+		{
+			name:    "unknown value",
+			dataWO:  types.MapValueMust(types.StringType, map[string]attr.Value{"host": types.StringUnknown()}),
+			wantErr: "Value Conversion Error",
+		},
+		{name: "corrupt keys", dataWO: known, previous: rawPrivateState("{"), wantErr: "Failed to decode data_wo keys"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fields := make(map[string]string)
+			_, diags := dataUpdates(ctx, fields, tc.dataWO, tc.previous)
+			if !diags.HasError() || diags.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected %q error, got %v", tc.wantErr, diags)
+			}
+			if len(fields) != 0 {
+				t.Errorf("expected no fields, got %v", fields)
+			}
+		})
+	}
+}
+
+func TestDataUpdates_Remove(t *testing.T) {
+	ctx := context.Background()
+	dataWO := types.MapValueMust(types.StringType, map[string]attr.Value{"host": types.StringValue("db.example.com")})
+
+	fields := map[string]string{expiresAtKey: "2030-01-01T00:00:00Z"}
+	remove, diags := dataUpdates(ctx, fields, dataWO, rawPrivateState(`["host","port"]`))
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	if len(fields) != 2 || fields["host"] != "db.example.com" {
+		t.Errorf("expected host added to the fields, got %v", fields)
+	}
+	if len(remove) != 1 || remove[0] != "port" {
+		t.Errorf("expected port removed, got %v", remove)
+	}
+
+	// Failing to record the keys is not an error
+	recordDataKeys(ctx, "app/key", dataWO, (&resource.UpdateResponse{}).Private)
+}
+
+func TestSecretResource_SingleWrite(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+	attributes := func(value any, version, dataVersion int, data map[string]any) tftypes.Value {
+		values := dataAttributes(value, version, data, dataVersion)
+		values["expires_at"] = tftypes.NewValue(tftypes.String, "2030-01-01T00:00:00Z")
+		values["username"] = tftypes.NewValue(tftypes.String, "admin")
+		values["url"] = tftypes.NewValue(tftypes.String, "https://example.com")
+		return schemaObjectValue(schemaResp.Schema, values)
+	}
+	check := func(t *testing.T, password string, revisions int) {
+		t.Helper()
+		secret := store.secrets["app/key"]
+		if secret.Password() != password {
+			t.Errorf("expected password %q, got %q", password, secret.Password())
+		}
+		for key, want := range map[string]string{expiresAtKey: "2030-01-01T00:00:00Z", usernameKey: "admin", urlKey: "https://example.com", "host": "db.example.com"} {
+			if got, _ := secret.Get(key); got != want {
+				t.Errorf("expected %s %q, got %q", key, want, got)
+			}
+		}
+		if got := len(store.revisions["app/key"]); got != revisions {
+			t.Errorf("expected %d revisions, got %d", revisions, got)
+		}
+	}
+
+	data := map[string]any{"host": "db.example.com"}
+	createResp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+	r.Create(ctx, resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: attributes(nil, 1, 1, nil)},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: attributes("s3cret", 1, 1, data)},
+	}, createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", createResp.Diagnostics)
+	}
+	t.Run("create", func(t *testing.T) { check(t, "s3cret", 1) })
+
+	updateResp := withPrivateData(&resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:    tfsdk.Plan{Schema: schemaResp.Schema, Raw: attributes(nil, 2, 2, nil)},
+		Config:  tfsdk.Config{Schema: schemaResp.Schema, Raw: attributes("rotated", 2, 2, data)},
+		State:   createResp.State,
+		Private: createResp.Private,
+	}, updateResp)
+	if updateResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", updateResp.Diagnostics)
+	}
+	t.Run("update", func(t *testing.T) { check(t, "rotated", 2) })
+}
@@ -28,16 +28,7 @@ func TestSecretResource_Delete(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/secret"),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -81,16 +72,7 @@ func TestSecretResource_Delete_KeepSecret(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/secret"),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -133,16 +115,7 @@ func TestSecretResource_Delete_AlreadyDeleted(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/secret"),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -184,16 +157,7 @@ func TestSecretResource_Delete_RemoveError(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/secret"),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
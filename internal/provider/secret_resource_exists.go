@@ -0,0 +1,45 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// replaceIfMissing plans the replacement of a secret a refresh found removed outside of
+// Terraform, so the plan shows exists going from false to true and the secret is recreated.
+func (r *SecretResource) replaceIfMissing(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var exists types.Bool
+	var secretPath types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("exists"), &exists)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("path"), &secretPath)...)
+	if resp.Diagnostics.HasError() || exists.IsNull() || exists.ValueBool() {
+		return
+	}
+
+	tflog.Info(ctx, "Gopass secret removed outside of Terraform, replacing it", map[string]interface{}{
+		"path": secretPath.ValueString(),
+	})
+
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("exists"), types.BoolUnknown())...)
+	resp.RequiresReplace = append(resp.RequiresReplace, path.Root("exists"))
+	resp.Diagnostics.AddAttributeWarning(
+		path.Root("exists"),
+		"Secret removed",
+		codedDetail(CodeSecretNotFound, fmt.Sprintf(
+			"The secret at %q was removed outside of Terraform. Terraform will replace the resource, writing the secret again.",
+			secretPath.ValueString(),
+		)),
+	)
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// existsState returns the state of a gopass_secret at app/db with the given exists value.
+func existsState(t *testing.T, r *SecretResource, exists interface{}) tfsdk.State {
+	t.Helper()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(context.Background(), resource.SchemaRequest{}, schemaResp)
+	return tfsdk.State{
+		Schema: schemaResp.Schema,
+		Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"id":               tftypes.NewValue(tftypes.String, "app/db"),
+			"path":             tftypes.NewValue(tftypes.String, "app/db"),
+			"value_wo_version": tftypes.NewValue(tftypes.Number, 1),
+			"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+			"exists":           tftypes.NewValue(tftypes.Bool, exists),
+		}),
+	}
+}
+
+func TestSecretResource_Exists_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := storeWith(map[string]string{"app/db": "s3cret"})
+	r := &SecretResource{client: clientWith(store)}
+	state := existsState(t, r, true)
+
+	// Removed outside of Terraform: the refresh keeps the resource with exists = false
+	delete(store.secrets, "app/db")
+	readResp := &resource.ReadResponse{State: state}
+	r.Read(ctx, resource.ReadRequest{State: state}, readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("Read() error: %v", readResp.Diagnostics)
+	}
+	var exists types.Bool
+	readResp.State.GetAttribute(ctx, path.Root("exists"), &exists)
+	if exists.IsNull() || exists.ValueBool() {
+		t.Fatalf("expected exists = false after the refresh, got %v", exists)
+	}
+
+	// The plan replaces the resource and exists becomes known after apply
+	plan := tfsdk.Plan{Schema: state.Schema, Raw: readResp.State.Raw}
+	planResp := &resource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, resource.ModifyPlanRequest{Plan: plan, State: readResp.State}, planResp)
+	if planResp.Diagnostics.HasError() {
+		t.Fatalf("ModifyPlan() error: %v", planResp.Diagnostics)
+	}
+	if len(planResp.RequiresReplace) != 1 || !planResp.RequiresReplace[0].Equal(path.Root("exists")) {
+		t.Errorf("expected the resource to be replaced, got %v", planResp.RequiresReplace)
+	}
+	if planResp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected a warning about the removed secret, got %v", planResp.Diagnostics)
+	}
+	planResp.Plan.GetAttribute(ctx, path.Root("exists"), &exists)
+	if !exists.IsUnknown() {
+		t.Errorf("expected exists to be unknown in the plan, got %v", exists)
+	}
+
+	// Nothing is left to remove when the replacement destroys the resource
+	store.shouldFail = true
+	store.failMsg = "store unavailable"
+	deleteResp := &resource.DeleteResponse{}
+	r.Delete(ctx, resource.DeleteRequest{State: readResp.State}, deleteResp)
+	if deleteResp.Diagnostics.HasError() {
+		t.Errorf("Delete() error: %v", deleteResp.Diagnostics)
+	}
+}
+
+func TestSecretResource_ModifyPlan_Exists(t *testing.T) {
+	tests := []struct {
+		name   string
+		exists interface{}
+	}{
+		{name: "exists", exists: true},
+		{name: "not refreshed yet", exists: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretResource{client: clientWith(newMockStore())}
+			state := existsState(t, r, tc.exists)
+
+			plan := tfsdk.Plan{Schema: state.Schema, Raw: state.Raw}
+			resp := &resource.ModifyPlanResponse{Plan: plan}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{Plan: plan, State: state}, resp)
+
+			if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 0 {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			if len(resp.RequiresReplace) != 0 {
+				t.Errorf("expected no replacement, got %v", resp.RequiresReplace)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_Exists(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		value interface{}
+		want  interface{} // nil for exists = null
+	}{
+		{name: "value written", path: "app/db", value: "s3cret", want: true},
+		{name: "no value", path: "app/db", value: nil, want: false},
+		// failGetStore fails reading this path, so whether the secret exists is unknown until the next refresh
+		{name: "check failed", path: "test/secret-error", value: nil, want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretResource{client: clientWith(&failGetStore{mockStore: newMockStore()})}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			values := map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, tc.path),
+				"value_wo":         tftypes.NewValue(tftypes.String, tc.value),
+				"value_wo_version": tftypes.NewValue(tftypes.Number, 1),
+				"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+			}
+			raw := schemaObjectValue(schemaResp.Schema, values)
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Create() error: %v", resp.Diagnostics)
+			}
+
+			var exists types.Bool
+			resp.State.GetAttribute(ctx, path.Root("exists"), &exists)
+			if tc.want == nil {
+				if !exists.IsNull() {
+					t.Errorf("expected exists to be null, got %v", exists)
+				}
+				return
+			}
+			if exists.IsNull() || exists.ValueBool() != tc.want {
+				t.Errorf("expected exists = %v, got %v", tc.want, exists)
+			}
+		})
+	}
+}
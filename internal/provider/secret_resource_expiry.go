@@ -0,0 +1,84 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// expiresAtKey is the field of a secret holding its expiration time.
+const expiresAtKey = "expires_at"
+
+// validateExpiresAt adds an error to diags if expires_at is not an RFC 3339 timestamp.
+func validateExpiresAt(diags *diag.Diagnostics, v types.String) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	if _, err := time.Parse(time.RFC3339, v.ValueString()); err != nil {
+		diags.AddAttributeError(
+			path.Root("expires_at"),
+			"Invalid expires_at",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("expires_at must be an RFC 3339 timestamp such as \"2030-01-31T00:00:00Z\", got %q.", v.ValueString())),
+		)
+	}
+}
+
+// warnExpired warns when a secret that is kept or created has expired,
+// so overdue rotations show up in every plan.
+func (r *SecretResource) warnExpired(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var expiresAt types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("expires_at"), &expiresAt)...)
+	if resp.Diagnostics.HasError() || expiresAt.IsNull() || expiresAt.IsUnknown() {
+		return
+	}
+
+	expiry, err := time.Parse(time.RFC3339, expiresAt.ValueString())
+	if err != nil {
+		// A value read from the store that is not a timestamp cannot expire
+		return
+	}
+	if now := timeNow(); now.After(expiry) {
+		var secretPath types.String
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("path"), &secretPath)...)
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("expires_at"),
+			"Secret expired",
+			codedDetail(CodeExpired, fmt.Sprintf(
+				"The secret at %q expired at %s, %s ago. Rotate it by setting a new value_wo and expires_at "+
+					"and incrementing value_wo_version.",
+				secretPath.ValueString(), expiresAt.ValueString(), now.Sub(expiry).Round(time.Minute),
+			)),
+		)
+	}
+}
+
+// readExpiry sets expires_at from the field of the secret, or null if the secret has none.
+// Failures are logged and keep the previous value, as the expiry is informational only.
+func (r *SecretResource) readExpiry(ctx context.Context, data *SecretResourceModel) {
+	secretPath := data.Path.ValueString()
+	value, ok, err := r.client.LookupSecretField(ctx, secretPath, expiresAtKey)
+	if err != nil {
+		tflog.Warn(ctx, "Could not read expiry", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+		if data.ExpiresAt.IsUnknown() {
+			data.ExpiresAt = types.StringNull()
+		}
+		return
+	}
+	if !ok {
+		data.ExpiresAt = types.StringNull()
+		return
+	}
+	data.ExpiresAt = types.StringValue(value)
+}
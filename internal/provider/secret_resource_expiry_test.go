@@ -0,0 +1,354 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// expiryValue returns a gopass_secret object at app/key with the given value_wo, version and expires_at.
+func expiryValue(schemaResp resource.SchemaResponse, value any, version int, expiresAt any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/key"),
+		"path":             tftypes.NewValue(tftypes.String, "app/key"),
+		"value_wo":         tftypes.NewValue(tftypes.String, value),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"expires_at":       tftypes.NewValue(tftypes.String, expiresAt),
+	})
+}
+
+func expiryOf(t *testing.T, store *mockStore) (string, bool) {
+	t.Helper()
+	secret, ok := store.secrets["app/key"]
+	if !ok {
+		t.Fatal("expected secret app/key")
+	}
+	return secret.Get(expiresAtKey)
+}
+
+func TestSecretResource_ValidateConfig_ExpiresAt(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresAt any
+		wantErr   bool
+	}{
+		{name: "valid", expiresAt: "2030-01-31T00:00:00Z"},
+		{name: "offset", expiresAt: "2030-01-31T00:00:00+01:00"},
+		{name: "unknown", expiresAt: tftypes.UnknownValue},
+		{name: "date only", expiresAt: "2030-01-31", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&SecretResource{client: clientWith(newMockStore())})
+			raw := expiryValue(schemaResp, "secret", 1, tc.expiresAt)
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ModifyPlan_Expiry(t *testing.T) {
+	now := timeNow
+	timeNow = func() time.Time { return time.Date(2030, 6, 1, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = now })
+
+	tests := []struct {
+		name        string
+		expiresAt   any
+		destroy     bool
+		wantWarning bool
+	}{
+		{name: "expired", expiresAt: "2030-05-01T00:00:00Z", wantWarning: true},
+		{name: "not expired", expiresAt: "2030-07-01T00:00:00Z"},
+		{name: "no expiry", expiresAt: nil},
+		{name: "unknown", expiresAt: tftypes.UnknownValue},
+		{name: "not a timestamp", expiresAt: "next year"},
+		{name: "destroy", destroy: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&SecretResource{client: clientWith(newMockStore())})
+			plan := expiryValue(schemaResp, nil, 1, tc.expiresAt)
+			if tc.destroy {
+				plan = schemaNullValue(schemaResp.Schema)
+			}
+
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+			r.ModifyPlan(context.Background(), resource.ModifyPlanRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if warned := resp.Diagnostics.WarningsCount() == 1; warned != tc.wantWarning {
+				t.Fatalf("expected warning=%v, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+			if tc.wantWarning {
+				want := "[" + CodeExpired + `] The secret at "app/key" expired at 2030-05-01T00:00:00Z, 756h0m0s ago.`
+				if detail := resp.Diagnostics.Warnings()[0].Detail(); detail[:len(want)] != want {
+					t.Errorf("unexpected detail %q", detail)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_Expiry(t *testing.T) {
+	t.Run("writes expires_at", func(t *testing.T) {
+		store := newMockStore()
+		r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+		raw := expiryValue(schemaResp, "secret", 1, "2030-01-31T00:00:00Z")
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+		if v, _ := expiryOf(t, store); v != "2030-01-31T00:00:00Z" || store.secrets["app/key"].Password() != "secret" {
+			t.Errorf("expected value and expiry in gopass, got %q", v)
+		}
+	})
+
+	t.Run("reads kept expires_at", func(t *testing.T) {
+		store := newMockStore()
+		existing := newMockSecret("old")
+		existing.fields[expiresAtKey] = "2029-01-01T00:00:00Z"
+		store.secrets["app/key"] = existing
+		r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+		config := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":                     tftypes.NewValue(tftypes.String, "app/key"),
+			"value_wo":                 tftypes.NewValue(tftypes.String, "new"),
+			"value_wo_version":         tftypes.NewValue(tftypes.Number, 1),
+			"preserve_existing_fields": tftypes.NewValue(tftypes.Bool, true),
+		})
+		plan := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":                       tftypes.NewValue(tftypes.String, "app/key"),
+			"value_wo_version":           tftypes.NewValue(tftypes.Number, 1),
+			"delete_on_remove":           tftypes.NewValue(tftypes.Bool, true),
+			"preserve_existing_fields":   tftypes.NewValue(tftypes.Bool, true),
+			"expires_at":                 tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
+		})
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+		}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+		var state SecretResourceModel
+		resp.State.Get(context.Background(), &state)
+		if state.ExpiresAt.ValueString() != "2029-01-01T00:00:00Z" {
+			t.Errorf("expected expires_at from gopass, got %v", state.ExpiresAt)
+		}
+	})
+
+	t.Run("write fails", func(t *testing.T) {
+		store := newMockStore()
+		store.secrets["app/key"] = &readOnlySecret{newMockSecret("old")}
+		r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+		raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":                       tftypes.NewValue(tftypes.String, "app/key"),
+			"value_wo":                   tftypes.NewValue(tftypes.String, "new"),
+			"value_wo_version":           tftypes.NewValue(tftypes.Number, 1),
+			"preserve_existing_fields":   tftypes.NewValue(tftypes.Bool, true),
+			"expires_at":                 tftypes.NewValue(tftypes.String, "2030-01-31T00:00:00Z"),
+			"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
+		})
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error when expires_at cannot be written")
+		}
+	})
+}
+
+func TestSecretResource_Update_Expiry(t *testing.T) {
+	tests := []struct {
+		name          string
+		stateExpiry   any
+		planExpiry    any
+		planVersion   int
+		storeExpiry   string
+		readOnly      bool
+		wantExpiry    string
+		wantRevisions int
+		wantErr       bool
+	}{
+		{
+			name:          "expiry changed",
+			stateExpiry:   "2030-01-31T00:00:00Z",
+			planExpiry:    "2031-01-31T00:00:00Z",
+			planVersion:   1,
+			storeExpiry:   "2030-01-31T00:00:00Z",
+			wantExpiry:    "2031-01-31T00:00:00Z",
+			wantRevisions: 2,
+		},
+		{
+			name:          "unchanged",
+			stateExpiry:   "2030-01-31T00:00:00Z",
+			planExpiry:    "2030-01-31T00:00:00Z",
+			planVersion:   1,
+			storeExpiry:   "2030-01-31T00:00:00Z",
+			wantExpiry:    "2030-01-31T00:00:00Z",
+			wantRevisions: 1,
+		},
+		{
+			name:          "restored after value rewrite",
+			stateExpiry:   "2030-01-31T00:00:00Z",
+			planExpiry:    "2030-01-31T00:00:00Z",
+			planVersion:   2,
+			storeExpiry:   "2030-01-31T00:00:00Z",
+			wantExpiry:    "2030-01-31T00:00:00Z",
+			wantRevisions: 2,
+		},
+		{
+			name:          "unknown read from gopass",
+			stateExpiry:   nil,
+			planExpiry:    tftypes.UnknownValue,
+			planVersion:   1,
+			storeExpiry:   "2029-01-01T00:00:00Z",
+			wantExpiry:    "2029-01-01T00:00:00Z",
+			wantRevisions: 1,
+		},
+		{
+			name:        "write fails",
+			stateExpiry: "2030-01-31T00:00:00Z",
+			planExpiry:  "2031-01-31T00:00:00Z",
+			planVersion: 1,
+			readOnly:    true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			existing := newMockSecret("old")
+			if tc.storeExpiry != "" {
+				existing.fields[expiresAtKey] = tc.storeExpiry
+			}
+			store.secrets["app/key"] = existing
+			if tc.readOnly {
+				store.secrets["app/key"] = &readOnlySecret{existing}
+			}
+			store.revisions["app/key"] = []string{"1"}
+			r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+			state := expiryValue(schemaResp, nil, 1, tc.stateExpiry)
+			plan := expiryValue(schemaResp, nil, tc.planVersion, tc.planExpiry)
+			config := expiryValue(schemaResp, "new", tc.planVersion, tc.planExpiry)
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if v, _ := expiryOf(t, store); v != tc.wantExpiry {
+				t.Errorf("expected expiry %q in gopass, got %q", tc.wantExpiry, v)
+			}
+			var data SecretResourceModel
+			resp.State.Get(context.Background(), &data)
+			if data.ExpiresAt.ValueString() != tc.wantExpiry {
+				t.Errorf("expected expiry %q in state, got %v", tc.wantExpiry, data.ExpiresAt)
+			}
+			if got := len(store.revisions["app/key"]); got != tc.wantRevisions {
+				t.Errorf("expected %d revisions, got %d", tc.wantRevisions, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Read_Expiry(t *testing.T) {
+	store := newMockStore()
+	existing := newMockSecret("value")
+	existing.fields[expiresAtKey] = "2030-01-31T00:00:00Z"
+	store.secrets["app/key"] = existing
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+	raw := expiryValue(schemaResp, nil, 1, nil)
+
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+	r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if data.ExpiresAt.ValueString() != "2030-01-31T00:00:00Z" {
+		t.Errorf("expected expires_at from gopass, got %v", data.ExpiresAt)
+	}
+}
+
+func TestSecretResource_ReadExpiry_Error(t *testing.T) {
+	store := newMockStore()
+	store.shouldFail = true
+	store.failMsg = "decryption failed"
+	r, _ := testResource(&SecretResource{client: clientWith(store)})
+
+	data := &SecretResourceModel{Path: types.StringValue("app/key"), ExpiresAt: types.StringValue("2030-01-31T00:00:00Z")}
+	r.readExpiry(context.Background(), data)
+	if data.ExpiresAt.ValueString() != "2030-01-31T00:00:00Z" {
+		t.Errorf("expected previous expiry to be kept, got %v", data.ExpiresAt)
+	}
+
+	data.ExpiresAt = types.StringUnknown()
+	r.readExpiry(context.Background(), data)
+	if !data.ExpiresAt.IsNull() {
+		t.Errorf("expected unknown expiry to become null, got %v", data.ExpiresAt)
+	}
+}
+
+func TestSecretResource_ImportState_Expiry(t *testing.T) {
+	store := newMockStore()
+	existing := newMockSecret("value")
+	existing.fields[expiresAtKey] = "2030-01-31T00:00:00Z"
+	store.secrets["app/key"] = existing
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "app/key"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if data.ExpiresAt.ValueString() != "2030-01-31T00:00:00Z" {
+		t.Errorf("expected expires_at from gopass, got %v", data.ExpiresAt)
+	}
+}
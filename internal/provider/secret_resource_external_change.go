@@ -0,0 +1,174 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Actions of the on_external_change attribute.
+const (
+	// externalChangeWarn reports drift as a warning.
+	externalChangeWarn = "warn"
+	// externalChangeOverwrite reports drift as a warning and rewrites the secret on the next apply.
+	externalChangeOverwrite = "overwrite"
+	// externalChangeFail reports drift as an error.
+	externalChangeFail = "fail"
+)
+
+// externalChangeKey is the private state key marking a gopass_secret modified outside of
+// Terraform with on_external_change = "overwrite", so the next plan rewrites it.
+const externalChangeKey = "external_change"
+
+// onExternalChangeAttribute returns the schema of the on_external_change attribute of gopass_secret.
+func onExternalChangeAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		Description: "What to do when a refresh finds the secret modified outside of Terraform: 'warn' reports it, " +
+			"'overwrite' also plans an update that rewrites the value from value_wo, compose or value_file_wo, " +
+			"'fail' reports it as an error. Defaults to 'fail' with revision_tracking = 'strict', and to 'warn' otherwise.",
+		MarkdownDescription: "What to do when a refresh finds the secret modified outside of Terraform: `warn` reports it, " +
+			"`overwrite` also plans an update that rewrites the value from `value_wo`, `compose` or `value_file_wo`, " +
+			"`fail` reports it as an error. Defaults to `fail` with `revision_tracking = \"strict\"`, and to `warn` otherwise.",
+		Optional: true,
+	}
+}
+
+// validateOnExternalChange adds an error to diags if on_external_change is not a known action,
+// or contradicts revision_tracking or only_if_absent.
+func validateOnExternalChange(diags *diag.Diagnostics, config *SecretResourceModel) {
+	v := config.OnExternalChange
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+
+	action := v.ValueString()
+	switch action {
+	case externalChangeWarn, externalChangeOverwrite, externalChangeFail:
+	default:
+		diags.AddAttributeError(
+			path.Root("on_external_change"),
+			"Invalid on_external_change",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("on_external_change must be %q, %q or %q, got %q.",
+				externalChangeWarn, externalChangeOverwrite, externalChangeFail, action)),
+		)
+		return
+	}
+
+	switch {
+	case config.RevisionTracking.ValueString() == revisionTrackingOff:
+		diags.AddAttributeWarning(
+			path.Root("on_external_change"),
+			"on_external_change has no effect",
+			codedDetail(CodeInvalidConfig, "revision_tracking = \"off\" never reads revisions, so changes outside of Terraform are not detected."),
+		)
+	case config.RevisionTracking.ValueString() == revisionTrackingStrict && action != externalChangeFail:
+		diags.AddAttributeError(
+			path.Root("on_external_change"),
+			"Conflicting on_external_change and revision_tracking",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("revision_tracking = \"strict\" fails on changes outside of Terraform, "+
+				"but on_external_change = %q does not. Remove one of them.", action)),
+		)
+	case action == externalChangeOverwrite && config.OnlyIfAbsent.ValueBool():
+		diags.AddAttributeError(
+			path.Root("on_external_change"),
+			"Conflicting on_external_change and only_if_absent",
+			codedDetail(CodeInvalidConfig, "only_if_absent keeps an existing secret, so on_external_change = \"overwrite\" could never rewrite it. "+
+				"Remove one of them."),
+		)
+	}
+}
+
+// externalChangeAction returns the action on drift: the configured one, or the one implied
+// by the revision tracking mode.
+func externalChangeAction(v types.String, mode string) string {
+	switch {
+	case !v.IsNull() && !v.IsUnknown():
+		return v.ValueString()
+	case mode == revisionTrackingStrict:
+		return externalChangeFail
+	default:
+		return externalChangeWarn
+	}
+}
+
+// externalChangeHint ends the drift diagnostics with what happens next under action.
+func externalChangeHint(action string) string {
+	if action == externalChangeOverwrite {
+		return "The next apply rewrites it with the value from the configuration (on_external_change = \"overwrite\")."
+	}
+	return "Consider incrementing value_wo_version to overwrite with the intended value."
+}
+
+// markExternalChange records in private state that the secret at secretPath was modified
+// outside of Terraform, so the next plan rewrites it. The drift was reported already, so
+// failures are logged rather than reported.
+func (r *SecretResource) markExternalChange(ctx context.Context, private privateState, secretPath string) {
+	if diags := private.SetKey(ctx, externalChangeKey, []byte("true")); diags.HasError() {
+		tflog.Warn(ctx, "Could not record external change", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+}
+
+// externalChangePending reports whether a refresh marked the secret for a rewrite.
+func externalChangePending(ctx context.Context, private privateState) bool {
+	data, diags := private.GetKey(ctx, externalChangeKey)
+	return !diags.HasError() && data != nil
+}
+
+// clearExternalChange removes the mark of markExternalChange after an update.
+func (r *SecretResource) clearExternalChange(ctx context.Context, private privateState, secretPath string) {
+	if diags := private.SetKey(ctx, externalChangeKey, nil); diags.HasError() {
+		tflog.Warn(ctx, "Could not clear external change", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+}
+
+// planOverwrite plans an update of a secret a refresh found modified outside of Terraform
+// with on_external_change = "overwrite", by marking updated_at as unknown. The update then
+// rewrites the value even though value_wo_version did not change.
+func (r *SecretResource) planOverwrite(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var action types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("on_external_change"), &action)...)
+	if resp.Diagnostics.HasError() || action.ValueString() != externalChangeOverwrite || !externalChangePending(ctx, req.Private) {
+		return
+	}
+
+	var config SecretResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := config.Path.ValueString()
+	if config.ValueWO.IsNull() && config.Compose.IsNull() && config.ValueFileWO.IsNull() {
+		resp.Diagnostics.AddAttributeWarning(
+			path.Root("on_external_change"),
+			"Nothing to overwrite with",
+			codedDetail(CodeDrift, fmt.Sprintf(
+				"The secret at %q was modified outside of Terraform, but none of value_wo, compose and value_file_wo is set, "+
+					"so it cannot be rewritten.", secretPath,
+			)),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Gopass secret modified outside of Terraform, planning to overwrite it", map[string]interface{}{
+		"path": secretPath,
+	})
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("updated_at"), types.StringUnknown())...)
+}
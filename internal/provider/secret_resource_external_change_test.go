@@ -0,0 +1,257 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// externalChangeValue builds a gopass_secret object at app/db for the on_external_change tests.
+func externalChangeValue(schemaResp *resource.SchemaResponse, value, action any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":                 tftypes.NewValue(tftypes.String, "app/db"),
+		"path":               tftypes.NewValue(tftypes.String, "app/db"),
+		"value_wo":           tftypes.NewValue(tftypes.String, value),
+		"value_wo_version":   tftypes.NewValue(tftypes.Number, 1),
+		"revision_count":     tftypes.NewValue(tftypes.Number, 1),
+		"updated_at":         tftypes.NewValue(tftypes.String, "2026-01-01T00:00:00Z"),
+		"on_external_change": tftypes.NewValue(tftypes.String, action),
+	})
+}
+
+func TestSecretResource_ValidateConfig_OnExternalChange(t *testing.T) {
+	tests := []struct {
+		name         string
+		action       any
+		tracking     any
+		onlyIfAbsent any
+		wantErr      string
+		wantWarning  string
+	}{
+		{name: "unset"},
+		{name: "unknown", action: tftypes.UnknownValue},
+		{name: "warn", action: "warn"},
+		{name: "overwrite", action: "overwrite"},
+		{name: "fail", action: "fail"},
+		{name: "fail in strict mode", action: "fail", tracking: "strict"},
+		{name: "invalid", action: "ignore", wantErr: "Invalid on_external_change"},
+		{name: "tracking off", action: "overwrite", tracking: "off", wantWarning: "on_external_change has no effect"},
+		{name: "warn in strict mode", action: "warn", tracking: "strict", wantErr: "Conflicting on_external_change and revision_tracking"},
+		{name: "only if absent", action: "overwrite", onlyIfAbsent: true, wantErr: "Conflicting on_external_change and only_if_absent"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":               tftypes.NewValue(tftypes.String, "app/db"),
+				"on_external_change": tftypes.NewValue(tftypes.String, tc.action),
+				"revision_tracking":  tftypes.NewValue(tftypes.String, tc.tracking),
+				"only_if_absent":     tftypes.NewValue(tftypes.Bool, tc.onlyIfAbsent),
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("unexpected error: %v", resp.Diagnostics)
+				}
+			} else if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantWarning != "" && (resp.Diagnostics.WarningsCount() != 1 || resp.Diagnostics.Warnings()[0].Summary() != tc.wantWarning) {
+				t.Errorf("expected warning %q, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Read_OnExternalChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      any
+		tracking    string
+		noPrivate   bool
+		wantErr     bool
+		wantHint    string
+		wantPending bool
+	}{
+		{name: "default", wantHint: "Consider incrementing value_wo_version"},
+		{name: "warn", action: "warn", wantHint: "Consider incrementing value_wo_version"},
+		{name: "overwrite", action: "overwrite", wantHint: "The next apply rewrites it", wantPending: true},
+		{name: "overwrite without private state", action: "overwrite", noPrivate: true, wantHint: "The next apply rewrites it"},
+		{name: "fail", action: "fail", wantErr: true},
+		{name: "strict mode", tracking: "strict", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := storeWith(map[string]string{"app/db": "changed"})
+			store.revisions["app/db"] = []string{"2", "1"}
+			client := NewGopassClient("")
+			client.store = store
+			if tc.tracking != "" {
+				client.SetRevisionTracking(tc.tracking)
+			}
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			state := externalChangeValue(schemaResp, nil, tc.action)
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}
+			if !tc.noPrivate {
+				resp = withPrivateData(resp)
+			}
+			r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if tc.wantErr {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Secret modified outside of Terraform" {
+					t.Fatalf("expected drift error, got %v", resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+				t.Fatalf("expected a drift warning, got %v", resp.Diagnostics)
+			}
+			if detail := resp.Diagnostics.Warnings()[0].Detail(); !strings.Contains(detail, tc.wantHint) {
+				t.Errorf("expected the warning to contain %q, got %q", tc.wantHint, detail)
+			}
+			if got := externalChangePending(ctx, resp.Private); got != tc.wantPending {
+				t.Errorf("expected pending=%v, got %v", tc.wantPending, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ModifyPlan_OnExternalChange(t *testing.T) {
+	tests := []struct {
+		name        string
+		create      bool
+		action      any
+		value       any
+		pending     bool
+		badConfig   bool
+		wantUnknown bool
+		wantWarning string
+	}{
+		{name: "overwrite", action: "overwrite", value: "intended", pending: true, wantUnknown: true},
+		{name: "overwrite without change", action: "overwrite", value: "intended"},
+		{name: "warn", action: "warn", value: "intended", pending: true},
+		{name: "create", create: true, action: "overwrite", value: "intended", pending: true},
+		{name: "nothing to write", action: "overwrite", pending: true, wantWarning: "Nothing to overwrite with"},
+		{name: "invalid config", action: "overwrite", pending: true, badConfig: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretResource{client: NewGopassClient("")}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			plan := externalChangeValue(schemaResp, nil, tc.action)
+			state := externalChangeValue(schemaResp, nil, tc.action)
+			if tc.create {
+				state = schemaNullValue(schemaResp.Schema)
+			}
+			req := withPrivateData(&resource.ModifyPlanRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: externalChangeValue(schemaResp, tc.value, tc.action)},
+			})
+			if tc.pending {
+				r.markExternalChange(ctx, req.Private, "app/db")
+			}
+			if tc.badConfig {
+				req.Config.Raw = tftypes.NewValue(tftypes.String, "app/db")
+			}
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+			r.ModifyPlan(ctx, *req, resp)
+			if tc.badConfig {
+				if !resp.Diagnostics.HasError() {
+					t.Error("expected an error reading the config")
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var got types.String
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("updated_at"), &got)...)
+			if got.IsUnknown() != tc.wantUnknown {
+				t.Errorf("expected updated_at unknown=%v, got %v", tc.wantUnknown, got)
+			}
+			if tc.wantWarning != "" && (resp.Diagnostics.WarningsCount() != 1 || resp.Diagnostics.Warnings()[0].Summary() != tc.wantWarning) {
+				t.Errorf("expected warning %q, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_OnExternalChange(t *testing.T) {
+	tests := []struct {
+		name          string
+		action        any
+		pending       bool
+		noRespPrivate bool
+		wantValue     string
+	}{
+		{name: "overwrite", action: "overwrite", pending: true, wantValue: "intended"},
+		{name: "overwrite without change", action: "overwrite", wantValue: "changed"},
+		{name: "warn", action: "warn", pending: true, wantValue: "changed"},
+		{name: "mark not cleared", action: "overwrite", pending: true, noRespPrivate: true, wantValue: "intended"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := storeWith(map[string]string{"app/db": "changed"})
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			state := externalChangeValue(schemaResp, nil, tc.action)
+			req := withPrivateData(&resource.UpdateRequest{
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: state},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: externalChangeValue(schemaResp, "intended", tc.action)},
+			})
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			if !tc.noRespPrivate {
+				resp = withPrivateData(resp)
+			}
+			if tc.pending {
+				r.markExternalChange(ctx, req.Private, "app/db")
+				r.markExternalChange(ctx, resp.Private, "app/db")
+			}
+			r.Update(ctx, *req, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			if got := store.secrets["app/db"].Password(); got != tc.wantValue {
+				t.Errorf("expected %q in the store, got %q", tc.wantValue, got)
+			}
+			if !tc.noRespPrivate && externalChangePending(ctx, resp.Private) {
+				t.Error("expected the external change to be cleared")
+			}
+		})
+	}
+}
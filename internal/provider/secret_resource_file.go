@@ -0,0 +1,46 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// hasValueFile reports whether value_file_wo is configured with a known value.
+func hasValueFile(file types.String) bool {
+	return !file.IsNull() && !file.IsUnknown()
+}
+
+// readValueFile reads the file named by value_file_wo at apply time and checks its content
+// like value_wo. The content is never stored in state; only the file name is configured.
+func readValueFile(data *SecretResourceModel, file string) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	secretPath := data.Path.ValueString()
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		diags.AddAttributeError(
+			path.Root("value_file_wo"),
+			"Failed to read value_file_wo",
+			errorDetail(err, fmt.Sprintf("The file for %q could not be read, so the secret was not written: %s", secretPath, err.Error())),
+		)
+		return "", diags
+	}
+	defer wipe(content)
+
+	if err := checkValue(data, string(content)); err != nil {
+		diags.AddAttributeError(
+			path.Root("value_file_wo"),
+			"Invalid value_file_wo",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("The content of %q for %q was not written: %s.", file, secretPath, err.Error())),
+		)
+		return "", diags
+	}
+	return string(content), diags
+}
@@ -0,0 +1,248 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const testPEM = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+
+// valueFile writes content to a temporary file and returns its path.
+func valueFile(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "value.pem")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// fileRaws returns the plan and config of a gopass_secret writing file.
+// Write-only values are only present in the config.
+func fileRaws(schemaResp *resource.SchemaResponse, version any, file any, minLength any) (tftypes.Value, tftypes.Value) {
+	values := map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "certs/web"),
+		"path":             tftypes.NewValue(tftypes.String, "certs/web"),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"min_length":       tftypes.NewValue(tftypes.Number, minLength),
+	}
+	plan := schemaObjectValue(schemaResp.Schema, values)
+	values["value_file_wo"] = tftypes.NewValue(tftypes.String, file)
+	return plan, schemaObjectValue(schemaResp.Schema, values)
+}
+
+func TestGopassClient_SetSecretContent(t *testing.T) {
+	store := newMockStore()
+	client := NewGopassClient("")
+	client.store = store
+
+	if err := client.SetSecretContent(context.Background(), "certs/web", []byte(testPEM)); err != nil {
+		t.Fatalf("SetSecretContent() error = %v", err)
+	}
+	if got := string(store.secrets["certs/web"].Bytes()); got != testPEM {
+		t.Errorf("expected content to be written verbatim, got %q", got)
+	}
+
+	client = NewGopassClient("/nonexistent/store")
+	if err := client.SetSecretContent(context.Background(), "certs/web", []byte(testPEM)); err == nil {
+		t.Error("expected error for missing store")
+	}
+}
+
+func TestSecretResource_Create_ValueFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      func(t *testing.T) string
+		minLength any
+		failStore bool
+		wantErr   string
+	}{
+		{name: "written", file: func(t *testing.T) string { return valueFile(t, testPEM) }},
+		{
+			name:    "missing file",
+			file:    func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.pem") },
+			wantErr: "Failed to read value_file_wo",
+		},
+		{
+			name:      "invalid content",
+			file:      func(t *testing.T) string { return valueFile(t, "short") },
+			minLength: 10,
+			wantErr:   "Invalid value_file_wo",
+		},
+		{
+			name:      "write fails",
+			file:      func(t *testing.T) string { return valueFile(t, testPEM) },
+			failStore: true,
+			wantErr:   "Failed to create secret",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			store.shouldFail = tc.failStore
+			store.failMsg = "store failure"
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+			plan, config := fileRaws(schemaResp, 1, tc.file(t), tc.minLength)
+			if tc.failStore {
+				// Skips the existence check, so the write itself fails
+				plan = withAttribute(plan, "confirm_overwrite_existing", tftypes.NewValue(tftypes.Bool, true))
+			}
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				if _, ok := store.secrets["certs/web"]; ok {
+					t.Error("expected no secret to be written")
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 0 {
+				t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+			}
+			secret := store.secrets["certs/web"]
+			if got := string(secret.Bytes()); got != testPEM {
+				t.Errorf("expected file content, got %q", got)
+			}
+			if secret.Password() != "-----BEGIN CERTIFICATE-----" {
+				t.Errorf("expected the first line as password, got %q", secret.Password())
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_ValueFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      func(t *testing.T) string
+		failStore bool
+		wantErr   string
+	}{
+		{name: "written", file: func(t *testing.T) string { return valueFile(t, testPEM) }},
+		{
+			name:    "missing file",
+			file:    func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.pem") },
+			wantErr: "Failed to read value_file_wo",
+		},
+		{
+			name:      "write fails",
+			file:      func(t *testing.T) string { return valueFile(t, testPEM) },
+			failStore: true,
+			wantErr:   "Failed to update secret",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStoreWithSelectiveFailure()
+			store.secrets["certs/web"] = newMockSecret("old")
+			store.revisions["certs/web"] = []string{"1"}
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+			state, _ := fileRaws(schemaResp, 1, nil, nil)
+			plan, config := fileRaws(schemaResp, 2, tc.file(t), nil)
+			if tc.failStore {
+				store.shouldFail = true
+				store.failMsg = "store failure"
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				if got := store.secrets["certs/web"].Password(); got != "old" {
+					t.Errorf("expected secret to be left unchanged, got %q", got)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := string(store.secrets["certs/web"].Bytes()); got != testPEM {
+				t.Errorf("expected file content, got %q", got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ValidateConfig_ValueFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    any
+		version  any
+		preserve any
+		compose  tftypes.Value
+		wantErr  string
+	}{
+		{name: "file with version", version: 1},
+		{name: "file without version", wantErr: "Missing value_wo_version"},
+		{name: "file and value_wo", value: "secret", version: 1, wantErr: "Conflicting value_wo and value_file_wo"},
+		{name: "file and compose", version: 1, compose: composeRaw("pw", "admin"), wantErr: "Conflicting compose and value_file_wo"},
+		{name: "file and preserve_existing_fields", version: 1, preserve: true, wantErr: "Conflicting preserve_existing_fields and value_file_wo"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			values := map[string]tftypes.Value{
+				"path":                     tftypes.NewValue(tftypes.String, "certs/web"),
+				"value_wo":                 tftypes.NewValue(tftypes.String, tc.value),
+				"value_wo_version":         tftypes.NewValue(tftypes.Number, tc.version),
+				"value_file_wo":            tftypes.NewValue(tftypes.String, "cert.pem"),
+				"preserve_existing_fields": tftypes.NewValue(tftypes.Bool, tc.preserve),
+			}
+			if !tc.compose.IsNull() {
+				values["compose"] = tc.compose
+			}
+			raw := schemaObjectValue(schemaResp.Schema, values)
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// storeFingerprintKey is the private state key holding the storeFingerprint of gopass_secret.
+const storeFingerprintKey = "store_fingerprint"
+
+// storeFingerprint identifies the store a secret was written to. It is kept in resource
+// private state, so a secret whose provider now points at a different store is recognized
+// instead of silently being read from and written to the other store.
+type storeFingerprint struct {
+	Root    string `json:"root"`
+	Storage string `json:"storage"`
+	Crypto  string `json:"crypto,omitempty"`
+}
+
+// String describes the store for diagnostics, e.g. "/home/me/.password-store (gitfs, gpg)".
+func (f *storeFingerprint) String() string {
+	backends := f.Storage
+	if f.Crypto != "" {
+		backends += ", " + f.Crypto
+	}
+	return fmt.Sprintf("%s (%s)", f.Root, backends)
+}
+
+// loadStoreFingerprint reads the fingerprint from private state. It returns nil if none was stored.
+func loadStoreFingerprint(ctx context.Context, private privateState) (*storeFingerprint, diag.Diagnostics) {
+	data, diags := private.GetKey(ctx, storeFingerprintKey)
+	if diags.HasError() || data == nil {
+		return nil, diags
+	}
+
+	var f storeFingerprint
+	if err := json.Unmarshal(data, &f); err != nil {
+		diags.AddError("Failed to decode store fingerprint", codedDetail(CodeInternal, err.Error()))
+		return nil, diags
+	}
+	return &f, diags
+}
+
+// currentStore returns the fingerprint of the store the provider uses, or nil if it cannot
+// be determined. Fingerprints only guard against a changed store, so failures are logged.
+func (r *SecretResource) currentStore(ctx context.Context, secretPath string) *storeFingerprint {
+	info, err := r.client.GetStoreInfo(ctx)
+	if err != nil {
+		tflog.Debug(ctx, "Could not determine store fingerprint", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	return &storeFingerprint{Root: info.Root, Storage: info.Storage, Crypto: info.Crypto}
+}
+
+// recordStore remembers the store the provider uses as the one holding the secret at secretPath.
+func (r *SecretResource) recordStore(ctx context.Context, private privateState, secretPath string) {
+	f := r.currentStore(ctx, secretPath)
+	if f == nil {
+		return
+	}
+
+	data, _ := json.Marshal(f) //nolint:errcheck // a struct of strings always marshals
+	if diags := private.SetKey(ctx, storeFingerprintKey, data); diags.HasError() {
+		tflog.Warn(ctx, "Could not record store fingerprint", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+}
+
+// recordStoreIfMissing records the store for secrets from before fingerprints were kept.
+// A recorded fingerprint is never replaced on read, so a changed store stays visible to the plan.
+func (r *SecretResource) recordStoreIfMissing(ctx context.Context, private privateState, secretPath string) {
+	if recorded, diags := loadStoreFingerprint(ctx, private); recorded != nil || diags.HasError() {
+		return
+	}
+	r.recordStore(ctx, private, secretPath)
+}
+
+// storeChanged returns the recorded and the current store of the secret at secretPath if
+// they differ. It returns nils if they match or either of them is not known.
+func (r *SecretResource) storeChanged(ctx context.Context, private privateState, secretPath string) (recorded, current *storeFingerprint) {
+	recorded, diags := loadStoreFingerprint(ctx, private)
+	if diags.HasError() {
+		tflog.Warn(ctx, "Could not load store fingerprint", map[string]interface{}{
+			"path": secretPath,
+		})
+		return nil, nil
+	}
+	if recorded == nil {
+		return nil, nil
+	}
+
+	current = r.currentStore(ctx, secretPath)
+	if current == nil || *current == *recorded {
+		return nil, nil
+	}
+	return recorded, current
+}
+
+// replaceOnStoreChange plans the replacement of a secret written to a different store than
+// the provider uses now, e.g. after store_path was changed. The secret is then written to
+// the current store, and the one in the previous store is left alone.
+func (r *SecretResource) replaceOnStoreChange(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var secretPath types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("path"), &secretPath)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	recorded, current := r.storeChanged(ctx, req.Private, secretPath.ValueString())
+	if recorded == nil {
+		return
+	}
+
+	tflog.Info(ctx, "Store of gopass secret changed, replacing it", map[string]interface{}{
+		"path":           secretPath.ValueString(),
+		"recorded_store": recorded.Root,
+		"current_store":  current.Root,
+	})
+
+	resp.RequiresReplace = append(resp.RequiresReplace, path.Root("path"))
+	resp.Diagnostics.AddAttributeWarning(
+		path.Root("path"),
+		"Store changed",
+		codedDetail(CodeStoreChanged, fmt.Sprintf(
+			"The secret at %q was written to the store at %s, but the provider now uses the store at %s. "+
+				"Terraform will replace the resource, writing the secret to the current store. "+
+				"The secret in the previous store is left unchanged.",
+			secretPath.ValueString(), recorded, current,
+		)),
+	)
+}
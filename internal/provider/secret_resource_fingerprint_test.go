@@ -0,0 +1,309 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// fingerprintStoreDir returns a new store root containing the given marker files.
+func fingerprintStoreDir(t *testing.T, markers ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, marker := range markers {
+		if err := os.WriteFile(filepath.Join(dir, marker), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// fingerprintTestSetup returns a secret resource using the store at dir, backed by store.
+func fingerprintTestSetup(t *testing.T, dir string, store *mockStore) (*SecretResource, resource.SchemaResponse) {
+	t.Helper()
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+	r.client.storePath = dir
+	return r, schemaResp
+}
+
+// createWithFingerprint creates app/key and returns the create response holding its private state.
+func createWithFingerprint(t *testing.T, r *SecretResource, schemaResp resource.SchemaResponse) *resource.CreateResponse {
+	t.Helper()
+	raw := expiryValue(schemaResp, "secret", 1, nil)
+	resp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+	r.Create(context.Background(), resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+	}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Create() error: %v", resp.Diagnostics)
+	}
+	return resp
+}
+
+func TestStoreFingerprint_String(t *testing.T) {
+	tests := []struct {
+		name string
+		f    storeFingerprint
+		want string
+	}{
+		{name: "with crypto", f: storeFingerprint{Root: "/s", Storage: storageGitFS, Crypto: cryptoGPG}, want: "/s (gitfs, gpg)"},
+		{name: "without crypto", f: storeFingerprint{Root: "/s", Storage: storageFS}, want: "/s (fs)"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.f.String(); got != tc.want {
+				t.Errorf("String() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretResource_StoreFingerprint_Create(t *testing.T) {
+	dir := fingerprintStoreDir(t, ".git", gpgIDFile)
+	r, schemaResp := fingerprintTestSetup(t, dir, newMockStore())
+	resp := createWithFingerprint(t, r, schemaResp)
+
+	recorded, diags := loadStoreFingerprint(context.Background(), resp.Private)
+	if diags.HasError() || recorded == nil {
+		t.Fatalf("expected recorded fingerprint, got %v, %v", recorded, diags)
+	}
+	want := storeFingerprint{Root: dir, Storage: storageGitFS, Crypto: cryptoGPG}
+	if *recorded != want {
+		t.Errorf("recorded %+v, want %+v", *recorded, want)
+	}
+}
+
+func TestSecretResource_ModifyPlan_StoreChanged(t *testing.T) {
+	tests := []struct {
+		name        string
+		changeDir   bool
+		changeCrypt bool
+		wantReplace bool
+	}{
+		{name: "same store"},
+		{name: "different root", changeDir: true, wantReplace: true},
+		{name: "different backend", changeCrypt: true, wantReplace: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := fingerprintStoreDir(t, ".git", gpgIDFile)
+			store := newMockStore()
+			r, schemaResp := fingerprintTestSetup(t, dir, store)
+			createResp := createWithFingerprint(t, r, schemaResp)
+
+			if tc.changeDir {
+				r.client.storePath = fingerprintStoreDir(t, ".git", gpgIDFile)
+			}
+			if tc.changeCrypt {
+				if err := os.Rename(filepath.Join(dir, gpgIDFile), filepath.Join(dir, ageRecipientsFile)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plan := expiryValue(schemaResp, nil, 1, nil)
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{
+				Plan:    tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:   createResp.State,
+				Private: createResp.Private,
+			}, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			replaced := len(resp.RequiresReplace) == 1 && resp.RequiresReplace[0].Equal(path.Root("path"))
+			if replaced != tc.wantReplace {
+				t.Fatalf("expected replace=%v, got %v", tc.wantReplace, resp.RequiresReplace)
+			}
+			if (resp.Diagnostics.WarningsCount() == 1) != tc.wantReplace {
+				t.Fatalf("expected warning=%v, got %v", tc.wantReplace, resp.Diagnostics)
+			}
+
+			// Replacing must not remove the secret at the path in the current store
+			deleteResp := &resource.DeleteResponse{}
+			r.Delete(ctx, resource.DeleteRequest{State: createResp.State, Private: createResp.Private}, deleteResp)
+			if deleteResp.Diagnostics.HasError() {
+				t.Fatalf("Delete() error: %v", deleteResp.Diagnostics)
+			}
+			if _, kept := store.secrets["app/key"]; kept != tc.wantReplace {
+				t.Errorf("expected secret kept=%v", tc.wantReplace)
+			}
+
+			if tc.wantReplace {
+				detail := resp.Diagnostics.Warnings()[0].Detail()
+				if !strings.HasPrefix(detail, "["+CodeStoreChanged+`] The secret at "app/key" was written to the store at `+dir+" (gitfs, gpg)") {
+					t.Errorf("unexpected detail %q", detail)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretResource_ModifyPlan_StoreUnknown(t *testing.T) {
+	ctx := context.Background()
+	dir := fingerprintStoreDir(t, ".git")
+	r, schemaResp := fingerprintTestSetup(t, dir, newMockStore())
+	createResp := createWithFingerprint(t, r, schemaResp)
+	plan := expiryValue(schemaResp, nil, 1, nil)
+
+	tests := []struct {
+		name    string
+		setup   func() *SecretResource
+		private func() resource.ModifyPlanRequest
+	}{
+		{
+			name:  "create",
+			setup: func() *SecretResource { return r },
+			private: func() resource.ModifyPlanRequest {
+				return resource.ModifyPlanRequest{Private: createResp.Private}
+			},
+		},
+		{
+			name:  "unconfigured",
+			setup: func() *SecretResource { return &SecretResource{} },
+			private: func() resource.ModifyPlanRequest {
+				return resource.ModifyPlanRequest{State: createResp.State, Private: createResp.Private}
+			},
+		},
+		{
+			name:  "nothing recorded",
+			setup: func() *SecretResource { return r },
+			private: func() resource.ModifyPlanRequest {
+				return resource.ModifyPlanRequest{State: createResp.State}
+			},
+		},
+		{
+			name: "store missing",
+			setup: func() *SecretResource {
+				missing, _ := fingerprintTestSetup(t, filepath.Join(dir, "missing"), newMockStore())
+				return missing
+			},
+			private: func() resource.ModifyPlanRequest {
+				return resource.ModifyPlanRequest{State: createResp.State, Private: createResp.Private}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := tc.private()
+			req.Plan = tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}
+			resp := &resource.ModifyPlanResponse{Plan: req.Plan}
+			tc.setup().ModifyPlan(ctx, req, resp)
+
+			if len(resp.Diagnostics) != 0 || len(resp.RequiresReplace) != 0 {
+				t.Errorf("expected no replacement, got %v, %v", resp.RequiresReplace, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ModifyPlan_StoreStateError(t *testing.T) {
+	r, schemaResp := fingerprintTestSetup(t, t.TempDir(), newMockStore())
+	plan := expiryValue(schemaResp, nil, 1, nil)
+	state := tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"path": tftypes.Number}},
+		map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.Number, 1)})
+
+	resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+	r.ModifyPlan(context.Background(), resource.ModifyPlanRequest{
+		Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+	}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid state")
+	}
+}
+
+func TestSecretResource_StoreFingerprint_Read(t *testing.T) {
+	ctx := context.Background()
+	dir := fingerprintStoreDir(t)
+	store := newMockStore()
+	store.secrets["app/key"] = newMockSecret("secret")
+	r, schemaResp := fingerprintTestSetup(t, dir, store)
+	state := expiryValue(schemaResp, nil, 1, nil)
+
+	read := func(req *resource.ReadRequest) *resource.ReadResponse {
+		t.Helper()
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}, Private: req.Private}
+		r.Read(ctx, *req, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("Read() error: %v", resp.Diagnostics)
+		}
+		return resp
+	}
+
+	t.Run("records missing fingerprint", func(t *testing.T) {
+		resp := read(withPrivateData(&resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}))
+		recorded, _ := loadStoreFingerprint(ctx, resp.Private)
+		if recorded == nil || recorded.Root != dir || recorded.Storage != storageFS {
+			t.Errorf("expected fingerprint of %s, got %+v", dir, recorded)
+		}
+	})
+
+	t.Run("keeps recorded fingerprint", func(t *testing.T) {
+		req := withPrivateData(&resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}})
+		r.client.storePath = fingerprintStoreDir(t)
+		r.recordStore(ctx, req.Private, "app/key")
+		r.client.storePath = dir
+
+		resp := read(req)
+		recorded, _ := loadStoreFingerprint(ctx, resp.Private)
+		if recorded == nil || recorded.Root == dir {
+			t.Errorf("expected the previous fingerprint to be kept, got %+v", recorded)
+		}
+	})
+}
+
+func TestSecretResource_StoreFingerprint_Import(t *testing.T) {
+	dir := fingerprintStoreDir(t, ".git")
+	store := newMockStore()
+	store.secrets["app/key"] = newMockSecret("secret")
+	r, schemaResp := fingerprintTestSetup(t, dir, store)
+
+	resp := withPrivateData(&resource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)}})
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "app/key"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("ImportState() error: %v", resp.Diagnostics)
+	}
+
+	recorded, _ := loadStoreFingerprint(context.Background(), resp.Private)
+	if recorded == nil || recorded.Root != dir || recorded.Storage != storageGitFS {
+		t.Errorf("expected fingerprint of %s, got %+v", dir, recorded)
+	}
+}
+
+func TestSecretResource_StoreFingerprint_PrivateErrors(t *testing.T) {
+	ctx := context.Background()
+	r, _ := fingerprintTestSetup(t, fingerprintStoreDir(t), newMockStore())
+
+	t.Run("undecodable", func(t *testing.T) {
+		if _, diags := loadStoreFingerprint(ctx, rawPrivateState("not json")); !diags.HasError() {
+			t.Error("expected decode error")
+		}
+		if recorded, _ := r.storeChanged(ctx, rawPrivateState("not json"), "app/key"); recorded != nil {
+			t.Errorf("expected no change for undecodable fingerprint, got %+v", recorded)
+		}
+		// Must not be overwritten
+		r.recordStoreIfMissing(ctx, rawPrivateState("not json"), "app/key")
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		// Must not panic or report anything
+		r.recordStoreIfMissing(ctx, failingPrivateState{}, "app/key")
+	})
+}
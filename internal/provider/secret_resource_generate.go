@@ -0,0 +1,208 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+)
+
+// Defaults of the xkcd generator, the same as for gopass generate --xkcd.
+const (
+	defaultGenerateWords     = 4
+	defaultGenerateSeparator = " "
+	defaultGenerateLang      = "en"
+)
+
+// SecretGenerateModel describes the generate attribute of gopass_secret.
+type SecretGenerateModel struct {
+	Type       types.String `tfsdk:"type"`
+	Words      types.Int64  `tfsdk:"words"`
+	Separator  types.String `tfsdk:"separator"`
+	Lang       types.String `tfsdk:"lang"`
+	Capitalize types.Bool   `tfsdk:"capitalize"`
+	Numbers    types.Bool   `tfsdk:"numbers"`
+	Domain     types.String `tfsdk:"domain"`
+}
+
+// generateOptionTypes lists the generator types each option of the generate attribute applies to.
+var generateOptionTypes = []struct {
+	name  string
+	types []string
+}{
+	{"words", []string{generateXKCD}},
+	{"separator", []string{generateXKCD}},
+	{"lang", []string{generateXKCD}},
+	{"capitalize", []string{generateMemorable, generateXKCD}},
+	{"numbers", []string{generateXKCD}},
+	{"domain", []string{generateRule}},
+}
+
+// generateAttribute returns the schema of the generate attribute of gopass_secret.
+func generateAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Description: "Selects the gopass generator used by generate_if_missing: 'cryptic' (default) random characters, " +
+			"'memorable' words with digits, 'xkcd' a passphrase of dictionary words, or 'rule' the password rules of a domain. " +
+			"generate_length and generate_symbols apply to the types they make sense for.",
+		MarkdownDescription: "Selects the gopass generator used by `generate_if_missing`: `cryptic` (default) random characters, " +
+			"`memorable` words with digits, `xkcd` a passphrase of dictionary words, or `rule` the password rules of a domain. " +
+			"`generate_length` and `generate_symbols` apply to the types they make sense for.",
+		Optional: true,
+		Attributes: map[string]schema.Attribute{
+			"type": schema.StringAttribute{
+				Description:         "Generator: 'cryptic', 'memorable', 'xkcd' or 'rule'. Defaults to 'cryptic'.",
+				MarkdownDescription: "Generator: `cryptic`, `memorable`, `xkcd` or `rule`. Defaults to `cryptic`.",
+				Optional:            true,
+			},
+			"words": schema.Int64Attribute{
+				Description:         "Number of words of an xkcd passphrase. Defaults to 4.",
+				MarkdownDescription: "Number of words of an `xkcd` passphrase. Defaults to `4`.",
+				Optional:            true,
+			},
+			"separator": schema.StringAttribute{
+				Description:         "Separator between the words of an xkcd passphrase. Defaults to a space; empty capitalizes the words instead.",
+				MarkdownDescription: "Separator between the words of an `xkcd` passphrase. Defaults to a space; `\"\"` capitalizes the words instead.",
+				Optional:            true,
+			},
+			"lang": schema.StringAttribute{
+				Description:         "Word list of an xkcd passphrase, e.g. 'en' or 'de'. Defaults to 'en'.",
+				MarkdownDescription: "Word list of an `xkcd` passphrase, e.g. `en` or `de`. Defaults to `en`.",
+				Optional:            true,
+			},
+			"capitalize": schema.BoolAttribute{
+				Description:         "Capitalize words of memorable and xkcd values.",
+				MarkdownDescription: "Capitalize words of `memorable` and `xkcd` values.",
+				Optional:            true,
+			},
+			"numbers": schema.BoolAttribute{
+				Description:         "Add random numbers to the words of an xkcd passphrase.",
+				MarkdownDescription: "Add random numbers to the words of an `xkcd` passphrase.",
+				Optional:            true,
+			},
+			"domain": schema.StringAttribute{
+				Description:         "Domain whose password rules a 'rule' password follows, e.g. 'apple.com'. Required with type 'rule'.",
+				MarkdownDescription: "Domain whose password rules a `rule` password follows, e.g. `apple.com`. Required with type `rule`.",
+				Optional:            true,
+			},
+		},
+	}
+}
+
+// validateGenerate adds errors to diags if the generate attribute selects an unknown generator,
+// sets options its generator ignores, or is set without generate_if_missing.
+func validateGenerate(ctx context.Context, diags *diag.Diagnostics, config *SecretResourceModel) {
+	if config.Generate.IsNull() || config.Generate.IsUnknown() {
+		return
+	}
+
+	var model SecretGenerateModel
+	diags.Append(config.Generate.As(ctx, &model, basetypes.ObjectAsOptions{})...)
+	if diags.HasError() {
+		return
+	}
+
+	if !config.GenerateIfMissing.IsUnknown() && !config.GenerateIfMissing.ValueBool() {
+		diags.AddAttributeWarning(
+			path.Root("generate"),
+			"generate has no effect",
+			codedDetail(CodeInvalidConfig, "generate only selects the generator of generate_if_missing, which is not set."),
+		)
+	}
+
+	if model.Type.IsUnknown() {
+		return
+	}
+	genType := model.Type.ValueString()
+	if model.Type.IsNull() {
+		genType = generateCryptic
+	}
+	switch genType {
+	case generateCryptic, generateMemorable, generateXKCD, generateRule:
+	default:
+		diags.AddAttributeError(
+			path.Root("generate").AtName("type"),
+			"Invalid generate type",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("type must be %q, %q, %q or %q, got %q.",
+				generateCryptic, generateMemorable, generateXKCD, generateRule, genType)),
+		)
+		return
+	}
+
+	options := map[string]attr.Value{
+		"words": model.Words, "separator": model.Separator, "lang": model.Lang,
+		"numbers": model.Numbers, "capitalize": model.Capitalize, "domain": model.Domain,
+	}
+	for _, option := range generateOptionTypes {
+		if options[option.name].IsNull() || slices.Contains(option.types, genType) {
+			continue
+		}
+		diags.AddAttributeError(
+			path.Root("generate").AtName(option.name),
+			"Invalid generate option",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("%s does not apply to type = %q.", option.name, genType)),
+		)
+	}
+
+	if !model.Words.IsNull() && !model.Words.IsUnknown() && model.Words.ValueInt64() < 1 {
+		diags.AddAttributeError(
+			path.Root("generate").AtName("words"),
+			"Invalid generate option",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("words must be at least 1, got %d.", model.Words.ValueInt64())),
+		)
+	}
+	if genType == generateRule && model.Domain.IsNull() {
+		diags.AddAttributeError(
+			path.Root("generate").AtName("domain"),
+			"Missing generate domain",
+			codedDetail(CodeInvalidConfig, "type = \"rule\" follows the password rules of a domain. Set domain, e.g. \"apple.com\"."),
+		)
+	}
+}
+
+// generateSpec returns the generator settings of data, from its generate attribute and
+// generate_length and generate_symbols.
+func generateSpec(ctx context.Context, data *SecretResourceModel) (GenerateSpec, diag.Diagnostics) {
+	spec := GenerateSpec{
+		Type:      generateCryptic,
+		Length:    int(data.GenerateLength.ValueInt64()),
+		Symbols:   data.GenerateSymbols.ValueBool(),
+		Words:     defaultGenerateWords,
+		Separator: defaultGenerateSeparator,
+		Lang:      defaultGenerateLang,
+	}
+	if data.Generate.IsNull() {
+		return spec, nil
+	}
+
+	var model SecretGenerateModel
+	diags := data.Generate.As(ctx, &model, basetypes.ObjectAsOptions{})
+	if diags.HasError() {
+		return spec, diags
+	}
+
+	if !model.Type.IsNull() {
+		spec.Type = model.Type.ValueString()
+	}
+	if !model.Words.IsNull() {
+		spec.Words = int(model.Words.ValueInt64())
+	}
+	if !model.Separator.IsNull() {
+		spec.Separator = model.Separator.ValueString()
+	}
+	if !model.Lang.IsNull() {
+		spec.Lang = model.Lang.ValueString()
+	}
+	spec.Capitalize = model.Capitalize.ValueBool()
+	spec.Numbers = model.Numbers.ValueBool()
+	spec.Domain = model.Domain.ValueString()
+	return spec, diags
+}
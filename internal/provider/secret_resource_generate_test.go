@@ -0,0 +1,364 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// createWithGenerate runs Create without value_wo and with the given generate settings.
+func createWithGenerate(t *testing.T, client *GopassClient, generate map[string]tftypes.Value) *resource.CreateResponse {
+	t.Helper()
+
+	r := &SecretResource{client: client}
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	values := map[string]tftypes.Value{
+		"path":             tftypes.NewValue(tftypes.String, "test/generated"),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+	}
+	for k, v := range generate {
+		values[k] = v
+	}
+	raw := schemaObjectValue(schemaResp.Schema, values)
+
+	req := resource.CreateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+	}
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, req, resp)
+	return resp
+}
+
+func TestSecretResource_Create_GenerateIfMissing(t *testing.T) {
+	mockStore := newMockStore()
+	client := NewGopassClient("")
+	client.store = mockStore
+	var gotLength int
+	var gotSymbols bool
+	client.pwgen = func(length int, symbols bool) (string, error) {
+		gotLength, gotSymbols = length, symbols
+		return "generated-password", nil
+	}
+
+	resp := createWithGenerate(t, client, map[string]tftypes.Value{
+		"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+		"generate_length":     tftypes.NewValue(tftypes.Number, 48),
+		"generate_symbols":    tftypes.NewValue(tftypes.Bool, false),
+	})
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if resp.Diagnostics.WarningsCount() != 0 {
+		t.Errorf("expected no warnings, got %v", resp.Diagnostics)
+	}
+	if gotLength != 48 || gotSymbols {
+		t.Errorf("expected generator called with (48, false), got (%d, %v)", gotLength, gotSymbols)
+	}
+	secret, exists := mockStore.secrets["test/generated"]
+	if !exists || secret.Password() != "generated-password" {
+		t.Errorf("expected generated password to be stored, got %v", secret)
+	}
+}
+
+func TestSecretResource_Create_GenerateIfMissing_Disabled(t *testing.T) {
+	mockStore := newMockStore()
+	client := NewGopassClient("")
+	client.store = mockStore
+	client.pwgen = func(length int, symbols bool) (string, error) {
+		t.Fatal("generator must not be called when generate_if_missing is false")
+		return "", nil
+	}
+
+	resp := createWithGenerate(t, client, map[string]tftypes.Value{
+		"generate_if_missing": tftypes.NewValue(tftypes.Bool, false),
+	})
+
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("expected a 'No value provided' warning, got %v", resp.Diagnostics)
+	}
+	if _, exists := mockStore.secrets["test/generated"]; exists {
+		t.Error("expected no secret to be written")
+	}
+}
+
+func TestSecretResource_Create_GenerateError(t *testing.T) {
+	mockStore := newMockStore()
+	client := NewGopassClient("")
+	client.store = mockStore
+	client.pwgen = func(length int, symbols bool) (string, error) {
+		return "", errors.New("max tries exceeded")
+	}
+
+	resp := createWithGenerate(t, client, map[string]tftypes.Value{
+		"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+		"generate_length":     tftypes.NewValue(tftypes.Number, 2),
+	})
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error but got none")
+	}
+	if resp.Diagnostics[0].Summary() != "Failed to generate secret" {
+		t.Errorf("unexpected diagnostic: %v", resp.Diagnostics)
+	}
+	if _, exists := mockStore.secrets["test/generated"]; exists {
+		t.Error("expected no secret to be written")
+	}
+}
+
+func TestGopassClient_GeneratePassword(t *testing.T) {
+	client := NewGopassClient("")
+
+	password, err := client.GeneratePassword(24, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(password) != 24 {
+		t.Errorf("expected password of length 24, got %d", len(password))
+	}
+
+	client.pwgen = func(length int, symbols bool) (string, error) {
+		return "", errors.New("boom")
+	}
+	if _, err := client.GeneratePassword(24, true); err == nil || !strings.Contains(err.Error(), "failed to generate password") {
+		t.Errorf("expected wrapped error, got %v", err)
+	}
+}
+
+func TestSecretResource_ImportState_GenerateDefaults(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["app/db"] = newMockSecret("secret")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "app/db"}, resp)
+
+	var length types.Int64
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("generate_length"), &length)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if length.ValueInt64() != defaultGenerateLength {
+		t.Errorf("expected generate_length %d, got %v", defaultGenerateLength, length)
+	}
+}
+
+// generateValue builds a value of the generate attribute of gopass_secret; unset options are null.
+func generateValue(schemaResp *resource.SchemaResponse, options map[string]tftypes.Value) tftypes.Value {
+	objectType := schemaResp.Schema.Attributes["generate"].GetType().TerraformType(context.Background()).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(objectType.AttributeTypes))
+	for name, attrType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+		if v, ok := options[name]; ok {
+			values[name] = v
+		}
+	}
+	return tftypes.NewValue(objectType, values)
+}
+
+func TestSecretResource_Create_Generate(t *testing.T) {
+	mockStore := newMockStore()
+	client := NewGopassClient("")
+	client.store = mockStore
+	schemaResp := &resource.SchemaResponse{}
+	(&SecretResource{}).Schema(context.Background(), resource.SchemaRequest{}, schemaResp)
+
+	resp := createWithGenerate(t, client, map[string]tftypes.Value{
+		"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+		"generate": generateValue(schemaResp, map[string]tftypes.Value{
+			"type":      tftypes.NewValue(tftypes.String, "xkcd"),
+			"words":     tftypes.NewValue(tftypes.Number, 3),
+			"separator": tftypes.NewValue(tftypes.String, "."),
+			"lang":      tftypes.NewValue(tftypes.String, "en"),
+		}),
+	})
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	secret, exists := mockStore.secrets["test/generated"]
+	if !exists {
+		t.Fatal("expected the generated secret to be stored")
+	}
+	if words := strings.Split(secret.Password(), "."); len(words) != 3 {
+		t.Errorf("expected a passphrase of 3 words, got %d", len(words))
+	}
+
+	// An unknown generator is reported like a failing one
+	delete(mockStore.secrets, "test/generated")
+	resp = createWithGenerate(t, client, map[string]tftypes.Value{
+		"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+		"generate": generateValue(schemaResp, map[string]tftypes.Value{
+			"type":   tftypes.NewValue(tftypes.String, "rule"),
+			"domain": tftypes.NewValue(tftypes.String, "unknown.invalid"),
+		}),
+	})
+	if !resp.Diagnostics.HasError() || resp.Diagnostics[0].Summary() != "Failed to generate secret" {
+		t.Errorf("expected a generate error, got %v", resp.Diagnostics)
+	}
+}
+
+func TestSecretResource_Create_GenerateInvalidObject(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	r := &SecretResource{client: client}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	// A generate object of another type than the model expects
+	s := schemaResp.Schema
+	s.Attributes = maps.Clone(s.Attributes)
+	s.Attributes["generate"] = schema.ObjectAttribute{Optional: true, AttributeTypes: map[string]attr.Type{"type": types.Int64Type}}
+	raw := schemaObjectValue(s, map[string]tftypes.Value{
+		"path":                tftypes.NewValue(tftypes.String, "test/generated"),
+		"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+		"generate_length":     tftypes.NewValue(tftypes.Number, 32),
+		"generate": tftypes.NewValue(tftypes.Object{AttributeTypes: map[string]tftypes.Type{"type": tftypes.Number}},
+			map[string]tftypes.Value{"type": tftypes.NewValue(tftypes.Number, 1)}),
+	})
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: s}}
+	r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: s, Raw: raw}, Config: tfsdk.Config{Schema: s, Raw: raw}}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error reading generate")
+	}
+}
+
+func TestSecretResource_ValidateConfig_Generate(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     map[string]tftypes.Value
+		unknown     bool
+		disabled    bool
+		wantErr     string
+		wantWarning string
+	}{
+		{name: "default type", options: map[string]tftypes.Value{}},
+		{name: "unknown", unknown: true},
+		{name: "unknown type", options: map[string]tftypes.Value{"type": tftypes.NewValue(tftypes.String, tftypes.UnknownValue)}},
+		{
+			name: "xkcd",
+			options: map[string]tftypes.Value{
+				"type":       tftypes.NewValue(tftypes.String, "xkcd"),
+				"words":      tftypes.NewValue(tftypes.Number, 4),
+				"lang":       tftypes.NewValue(tftypes.String, "de"),
+				"capitalize": tftypes.NewValue(tftypes.Bool, true),
+			},
+		},
+		{
+			name: "rule",
+			options: map[string]tftypes.Value{
+				"type":   tftypes.NewValue(tftypes.String, "rule"),
+				"domain": tftypes.NewValue(tftypes.String, "apple.com"),
+			},
+		},
+		{
+			name:    "invalid type",
+			options: map[string]tftypes.Value{"type": tftypes.NewValue(tftypes.String, "diceware")},
+			wantErr: "Invalid generate type",
+		},
+		{
+			name: "option of another type",
+			options: map[string]tftypes.Value{
+				"type":  tftypes.NewValue(tftypes.String, "memorable"),
+				"words": tftypes.NewValue(tftypes.Number, 4),
+			},
+			wantErr: "Invalid generate option",
+		},
+		{
+			name: "no words",
+			options: map[string]tftypes.Value{
+				"type":  tftypes.NewValue(tftypes.String, "xkcd"),
+				"words": tftypes.NewValue(tftypes.Number, 0),
+			},
+			wantErr: "Invalid generate option",
+		},
+		{
+			name:    "rule without domain",
+			options: map[string]tftypes.Value{"type": tftypes.NewValue(tftypes.String, "rule")},
+			wantErr: "Missing generate domain",
+		},
+		{
+			name:        "without generate_if_missing",
+			options:     map[string]tftypes.Value{"type": tftypes.NewValue(tftypes.String, "memorable")},
+			disabled:    true,
+			wantWarning: "generate has no effect",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			generate := generateValue(schemaResp, tc.options)
+			if tc.unknown {
+				generate = tftypes.NewValue(generate.Type(), tftypes.UnknownValue)
+			}
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":                tftypes.NewValue(tftypes.String, "app/db"),
+				"generate_if_missing": tftypes.NewValue(tftypes.Bool, !tc.disabled),
+				"generate":            generate,
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("unexpected error: %v", resp.Diagnostics)
+				}
+			} else if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantWarning != "" && (resp.Diagnostics.WarningsCount() != 1 || resp.Diagnostics.Warnings()[0].Summary() != tc.wantWarning) {
+				t.Errorf("expected warning %q, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestGenerateSpec_InvalidObject(t *testing.T) {
+	ctx := context.Background()
+	data := &SecretResourceModel{
+		GenerateIfMissing: types.BoolValue(true),
+		Generate:          types.ObjectValueMust(map[string]attr.Type{"type": types.Int64Type}, map[string]attr.Value{"type": types.Int64Value(1)}),
+	}
+
+	if _, diags := generateSpec(ctx, data); !diags.HasError() {
+		t.Error("expected generateSpec to fail on an object of another type")
+	}
+	var diags diag.Diagnostics
+	validateGenerate(ctx, &diags, data)
+	if !diags.HasError() {
+		t.Error("expected validateGenerate to fail on an object of another type")
+	}
+}
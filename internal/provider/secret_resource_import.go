@@ -0,0 +1,75 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// recordImport remembers the value of an imported secret in private state like a write by
+// Terraform, so a value_wo that equals it is not written again by the first apply. The value
+// is decrypted once for its hash and never stored. The record only saves a write, so failures
+// are logged rather than reported.
+func (r *SecretResource) recordImport(ctx context.Context, private privateState, secretPath string,
+	revisionCount int64, revision string,
+) {
+	value, err := r.client.GetSecret(ctx, secretPath)
+	if err != nil {
+		tflog.Warn(ctx, "Could not record the value of the imported secret", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+		return
+	}
+	r.saveLastWrite(ctx, private, secretPath, valueContent(value), lastWrite{
+		RevisionCount: revisionCount,
+		Revision:      revision,
+		Imported:      true,
+	})
+}
+
+// storeHolds reports whether the password of the secret at secretPath is the one in the value
+// content. Read failures are reported as a mismatch, so the secret is written.
+func (r *SecretResource) storeHolds(ctx context.Context, secretPath string, content []string) bool {
+	value, err := r.client.GetSecret(uncached(ctx), secretPath)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(value), []byte(content[1])) == 1
+}
+
+// importedValuePlanned reports whether the plan of an imported secret changes value_wo_version
+// to a value_wo the secret already had when it was imported, with no modification in the store
+// since according to the refreshed state. The update then only records the version.
+func (r *SecretResource) importedValuePlanned(ctx context.Context, req resource.ModifyPlanRequest) bool {
+	w, diags := loadLastWrite(ctx, req.Private)
+	if diags.HasError() || w == nil || !w.Imported || w.Revision == "" {
+		return false
+	}
+
+	var config, state SecretResourceModel
+	diags = req.Config.Get(ctx, &config)
+	diags.Append(req.State.Get(ctx, &state)...)
+	if diags.HasError() {
+		return false
+	}
+	if config.ValueWO.IsNull() || config.ValueWO.IsUnknown() || hasCompose(config.Compose) || hasValueFile(config.ValueFileWO) {
+		return false
+	}
+	if state.RevisionCount.ValueInt64() != w.RevisionCount || revisionHash(state.LastRevision) != w.Revision {
+		return false
+	}
+	if !w.matches(valueContent(normalizeValue(&config, config.ValueWO.ValueString()))) {
+		return false
+	}
+
+	tflog.Info(ctx, "Configured value matches the imported gopass secret, no write planned", map[string]interface{}{
+		"path": state.Path.ValueString(),
+	})
+	return true
+}
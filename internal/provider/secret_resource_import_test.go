@@ -5,13 +5,17 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/gopasspw/gopass/pkg/gopass"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
@@ -107,6 +111,7 @@ func TestSecretResource_ImportState_ExistsError(t *testing.T) {
 	}
 }
 
+// flakyGetStoreImport fails on the third call to Get, after the exists check and the expiry lookup
 type flakyGetStoreImport struct {
 	*mockStore
 	calls int
@@ -114,7 +119,7 @@ type flakyGetStoreImport struct {
 
 func (m *flakyGetStoreImport) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
 	m.calls++
-	if m.calls == 2 {
+	if m.calls == 3 {
 		return nil, fmt.Errorf("flaky failure")
 	}
 	return m.mockStore.Get(ctx, name, revision)
@@ -196,3 +201,274 @@ func TestSecretResource_ImportState_NotFound(t *testing.T) {
 		t.Error("expected error for non-existent secret")
 	}
 }
+
+func TestSecretResource_ImportState_RecordsValue(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore()
+	store.secrets["app/db"] = newMockSecret("s3cret")
+	client := NewGopassClient("")
+	client.store = store
+	r := &SecretResource{client: client}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	resp := withPrivateData(&resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "app/db"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	raw, _ := resp.Private.GetKey(ctx, lastWriteKey)
+	if strings.Contains(string(raw), "s3cret") {
+		t.Error("expected the value to be left out of private state")
+	}
+	w, diags := loadLastWrite(ctx, resp.Private)
+	if diags.HasError() || w == nil {
+		t.Fatalf("expected a record, got %+v, %v", w, diags)
+	}
+	if !w.Imported || w.WrittenAt != "" || w.Length != 6 || !w.matches(valueContent("s3cret")) {
+		t.Errorf("unexpected record %+v", w)
+	}
+}
+
+func TestSecretResource_RecordImport_Unreadable(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	failingStore("gpg: decryption failed: No secret key")(client)
+	r := &SecretResource{client: client}
+
+	private := withPrivateData(&resource.ImportStateResponse{}).Private
+	r.recordImport(ctx, private, "app/db", 1, "")
+	if w, _ := loadLastWrite(ctx, private); w != nil {
+		t.Errorf("expected no record for an unreadable secret, got %+v", w)
+	}
+}
+
+func TestSecretResource_ImportThenApply(t *testing.T) {
+	tests := []struct {
+		name          string
+		gitBacked     bool
+		value         string
+		changedBefore bool // modified outside of Terraform after the import, before the plan
+		changedAfter  bool // modified outside of Terraform after the plan
+		wantPlanned   bool // updated_at unknown in the plan, announcing a write
+		wantWritten   bool
+	}{
+		{name: "same value", gitBacked: true, value: "s3cret"},
+		{name: "new value", gitBacked: true, value: "changed", wantPlanned: true, wantWritten: true},
+		{name: "modified before the plan", gitBacked: true, value: "s3cret", changedBefore: true, wantPlanned: true, wantWritten: true},
+		{name: "modified after the plan", gitBacked: true, value: "s3cret", changedAfter: true, wantWritten: true},
+		{name: "same value without git", value: "s3cret", wantPlanned: true},
+		{name: "new value without git", value: "changed", wantPlanned: true, wantWritten: true},
+		{name: "modified without git", value: "s3cret", changedAfter: true, wantPlanned: true, wantWritten: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMockStore()
+			store.secrets["app/db"] = newMockSecret("s3cret")
+			store.revisions["app/db"] = []string{"1"}
+			client := NewGopassClient("")
+			client.store = store
+			var dir string
+			var args []string
+			var gitErr error
+			if !tc.gitBacked {
+				gitErr = errors.New("not a git repository")
+			}
+			client.execCommand = fakeGit(testGitLogLine, gitErr, &dir, &args)
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+			s := schemaResp.Schema
+
+			imported := withPrivateData(&resource.ImportStateResponse{State: tfsdk.State{Schema: s, Raw: schemaNullValue(s)}})
+			r.ImportState(ctx, resource.ImportStateRequest{ID: "app/db"}, imported)
+			if imported.Diagnostics.HasError() {
+				t.Fatalf("ImportState() error: %v", imported.Diagnostics)
+			}
+
+			var model SecretResourceModel
+			imported.Diagnostics.Append(imported.State.Get(ctx, &model)...)
+			if tc.changedBefore {
+				store.secrets["app/db"] = newMockSecret("other")
+				store.revisions["app/db"] = append(store.revisions["app/db"], "2")
+				model.RevisionCount = types.Int64Value(2)
+				imported.Diagnostics.Append(imported.State.Set(ctx, &model)...)
+			}
+			model.ValueWOVersion = types.Int64Value(1)
+			state := tfsdk.State{Schema: s}
+			imported.Diagnostics.Append(state.Set(ctx, &model)...)
+			planned := state.Raw
+			model.ValueWO = types.StringValue(tc.value)
+			imported.Diagnostics.Append(state.Set(ctx, &model)...)
+			config := tfsdk.Config{Schema: s, Raw: state.Raw}
+
+			planResp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: s, Raw: planned}}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{
+				Plan:    tfsdk.Plan{Schema: s, Raw: planned},
+				State:   imported.State,
+				Config:  config,
+				Private: imported.Private,
+			}, planResp)
+			if planResp.Diagnostics.HasError() {
+				t.Fatalf("ModifyPlan() error: %v", planResp.Diagnostics)
+			}
+			var updatedAt types.String
+			planResp.Diagnostics.Append(planResp.Plan.GetAttribute(ctx, path.Root("updated_at"), &updatedAt)...)
+			if updatedAt.IsUnknown() != tc.wantPlanned {
+				t.Errorf("expected a planned write = %v, got updated_at %v", tc.wantPlanned, updatedAt)
+			}
+
+			if tc.changedAfter {
+				store.secrets["app/db"] = newMockSecret("other")
+				store.revisions["app/db"] = append(store.revisions["app/db"], "2")
+			}
+			revisionsBefore := len(store.revisions["app/db"])
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: s}, Private: imported.Private}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:    planResp.Plan,
+				State:   imported.State,
+				Config:  config,
+				Private: imported.Private,
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Update() error: %v", resp.Diagnostics)
+			}
+
+			if written := len(store.revisions["app/db"]) > revisionsBefore; written != tc.wantWritten {
+				t.Errorf("written = %v, want %v", written, tc.wantWritten)
+			}
+			if got := store.secrets["app/db"].Password(); got != tc.value {
+				t.Errorf("expected password %q, got %q", tc.value, got)
+			}
+			var result SecretResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &result)...)
+			if !updatedAt.IsUnknown() && !result.UpdatedAt.Equal(updatedAt) {
+				t.Errorf("expected the planned updated_at %v, got %v", updatedAt, result.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ImportedValuePlanned_Skipped(t *testing.T) {
+	ctx := context.Background()
+	r := &SecretResource{client: NewGopassClient("")}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	tests := []struct {
+		name   string
+		config tftypes.Value
+	}{
+		{name: "undecodable config", config: tftypes.NewValue(tftypes.String, "invalid")},
+		{name: "no value_wo", config: schemaObjectValue(s, map[string]tftypes.Value{
+			"path": tftypes.NewValue(tftypes.String, "app/db"),
+		})},
+		{name: "unknown value_wo", config: schemaObjectValue(s, map[string]tftypes.Value{
+			"path":     tftypes.NewValue(tftypes.String, "app/db"),
+			"value_wo": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		})},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			private := withPrivateData(&resource.ModifyPlanResponse{}).Private
+			w, _ := newLastWrite(valueContent("s3cret"))
+			w.Imported, w.Revision = true, "0123abcd"
+			w.save(ctx, private)
+
+			if r.importedValuePlanned(ctx, resource.ModifyPlanRequest{
+				Config: tfsdk.Config{Schema: s, Raw: tc.config},
+				State: tfsdk.State{Schema: s, Raw: schemaObjectValue(s, map[string]tftypes.Value{
+					"path": tftypes.NewValue(tftypes.String, "app/db"),
+				})},
+				Private: private,
+			}) {
+				t.Error("expected the write to be planned")
+			}
+		})
+	}
+}
+
+func TestSecretResource_StoreHolds_Unreadable(t *testing.T) {
+	client := NewGopassClient("")
+	failingStore("gpg: decryption failed: No secret key")(client)
+	r := &SecretResource{client: client}
+
+	if r.storeHolds(context.Background(), "app/db", valueContent("s3cret")) {
+		t.Error("expected an unreadable secret to be reported as different")
+	}
+}
+
+func TestSecretResource_ImportThenPlan(t *testing.T) {
+	ctx := context.Background()
+	store := storeWith(map[string]string{"app/db": "s3cret"})
+	r := &SecretResource{client: clientWith(store)}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	imported := withPrivateData(&resource.ImportStateResponse{State: tfsdk.State{Schema: s, Raw: schemaNullValue(s)}})
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "app/db"}, imported)
+	if imported.Diagnostics.HasError() {
+		t.Fatalf("ImportState() error: %v", imported.Diagnostics)
+	}
+
+	// A configuration setting only path plans the schema defaults of every other attribute
+	plan := tfsdk.Plan{Schema: s, Raw: imported.State.Raw}
+	for name, attribute := range s.Attributes {
+		var value interface{}
+		switch a := attribute.(type) {
+		case schema.BoolAttribute:
+			if a.Default == nil {
+				continue
+			}
+			resp := &defaults.BoolResponse{}
+			a.Default.DefaultBool(ctx, defaults.BoolRequest{}, resp)
+			value = resp.PlanValue
+		case schema.Int64Attribute:
+			if a.Default == nil {
+				continue
+			}
+			resp := &defaults.Int64Response{}
+			a.Default.DefaultInt64(ctx, defaults.Int64Request{}, resp)
+			value = resp.PlanValue
+		default:
+			continue
+		}
+		if diags := plan.SetAttribute(ctx, path.Root(name), value); diags.HasError() {
+			t.Fatalf("SetAttribute(%s) error: %v", name, diags)
+		}
+	}
+	config := tfsdk.Config{Schema: s, Raw: schemaObjectValue(s, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "app/db"),
+	})}
+
+	planResp := &resource.ModifyPlanResponse{Plan: plan}
+	r.ModifyPlan(ctx, resource.ModifyPlanRequest{
+		Plan:    plan,
+		State:   imported.State,
+		Config:  config,
+		Private: imported.Private,
+	}, planResp)
+	if planResp.Diagnostics.HasError() {
+		t.Fatalf("ModifyPlan() error: %v", planResp.Diagnostics)
+	}
+	if len(planResp.RequiresReplace) != 0 {
+		t.Errorf("expected no replacement, got %v", planResp.RequiresReplace)
+	}
+
+	diffs, err := planResp.Plan.Raw.Diff(imported.State.Raw)
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	for _, d := range diffs {
+		t.Errorf("expected no change after the import, got %s: %v -> %v", d.Path, d.Value1, d.Value2)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// commentKey is the field of the secret holding the comment attribute of gopass_secret.
+const commentKey = "comment"
+
+// loginField is an attribute of gopass_secret stored in a field of the secret.
+type loginField struct {
+	attr  string        // the attribute name
+	key   string        // the field of the secret
+	value *types.String // the attribute in the model
+}
+
+// loginFields returns the attributes of data stored in fields of the secret: the login and
+// the comment on its provenance.
+func loginFields(data *SecretResourceModel) []loginField {
+	return []loginField{
+		{attr: "username", key: usernameKey, value: &data.Username},
+		{attr: "url", key: urlKey, value: &data.URL},
+		{attr: "comment", key: commentKey, value: &data.Comment},
+	}
+}
+
+// validateLoginFields adds an error to diags for each login attribute spanning several
+// lines, as a field of a secret holds a single line.
+func validateLoginFields(diags *diag.Diagnostics, data *SecretResourceModel) {
+	for _, field := range loginFields(data) {
+		if field.value.IsNull() || field.value.IsUnknown() || !strings.ContainsAny(field.value.ValueString(), "\r\n") {
+			continue
+		}
+		diags.AddAttributeError(
+			path.Root(field.attr),
+			"Invalid "+field.attr,
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("%s is stored in the %s field of the secret and must not contain line breaks.", field.attr, field.key)),
+		)
+	}
+}
+
+// loginFieldUpdates adds the planned login attributes to fields, to be written to the secret.
+// Unless previous is set, all of them are added, as writes of the value replace the whole
+// secret; otherwise only those changed since previous. It reports whether attributes without
+// a planned value have to be read back from the secret after the write, see readLoginFields.
+func loginFieldUpdates(fields map[string]string, data, previous *SecretResourceModel) bool {
+	var prev []loginField
+	if previous != nil {
+		prev = loginFields(previous)
+	}
+
+	read := false
+	for i, field := range loginFields(data) {
+		switch {
+		case field.value.IsUnknown() || field.value.IsNull():
+			read = true
+		case prev == nil || !field.value.Equal(*prev[i].value):
+			fields[field.key] = field.value.ValueString()
+		}
+	}
+	return read
+}
+
+// readLoginFields sets the login attributes from the fields of the secret, null for those it
+// has none of. Values changed outside of Terraform thereby show up as a diff to the
+// configuration. Failures are logged and keep the previous values.
+func (r *SecretResource) readLoginFields(ctx context.Context, data *SecretResourceModel) {
+	secretPath := data.Path.ValueString()
+	fields := loginFields(data)
+
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = field.key
+	}
+
+	values, err := r.client.LookupSecretFields(ctx, secretPath, keys...)
+	if err != nil {
+		tflog.Warn(ctx, "Could not read login fields", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+		for _, field := range fields {
+			if field.value.IsUnknown() {
+				*field.value = types.StringNull()
+			}
+		}
+		return
+	}
+
+	for _, field := range fields {
+		*field.value = types.StringNull()
+		if value, ok := values[field.key]; ok {
+			*field.value = types.StringValue(value)
+		}
+	}
+}
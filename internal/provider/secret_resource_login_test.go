@@ -0,0 +1,321 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// loginValue returns a gopass_secret object at app/key with the given value_wo, version, username and url.
+func loginValue(schemaResp resource.SchemaResponse, value any, version int, username, url any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/key"),
+		"path":             tftypes.NewValue(tftypes.String, "app/key"),
+		"value_wo":         tftypes.NewValue(tftypes.String, value),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"username":         tftypes.NewValue(tftypes.String, username),
+		"url":              tftypes.NewValue(tftypes.String, url),
+	})
+}
+
+// loginOf returns the username and url fields of the secret at app/key.
+func loginOf(t *testing.T, store *mockStore) (string, string) {
+	t.Helper()
+	secret, ok := store.secrets["app/key"]
+	if !ok {
+		t.Fatal("expected secret app/key")
+	}
+	username, _ := secret.Get(usernameKey)
+	url, _ := secret.Get(urlKey)
+	return username, url
+}
+
+func TestSecretResource_ValidateConfig_Login(t *testing.T) {
+	tests := []struct {
+		name     string
+		username any
+		url      any
+		wantErrs int
+	}{
+		{name: "valid", username: "admin", url: "https://example.com"},
+		{name: "unset", username: nil, url: nil},
+		{name: "unknown", username: tftypes.UnknownValue, url: tftypes.UnknownValue},
+		{name: "username with newline", username: "admin\nurl: https://evil.example", url: nil, wantErrs: 1},
+		{name: "both with line breaks", username: "admin\r", url: "https://example.com\n", wantErrs: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&SecretResource{client: clientWith(newMockStore())})
+			raw := loginValue(schemaResp, "secret", 1, tc.username, tc.url)
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+			if got := resp.Diagnostics.ErrorsCount(); got != tc.wantErrs {
+				t.Errorf("expected %d errors, got %v", tc.wantErrs, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_Login(t *testing.T) {
+	t.Run("writes username and url", func(t *testing.T) {
+		store := newMockStore()
+		r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+		raw := loginValue(schemaResp, "secret", 1, "admin", "https://example.com")
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+		if username, url := loginOf(t, store); username != "admin" || url != "https://example.com" {
+			t.Errorf("expected login fields in gopass, got %q and %q", username, url)
+		}
+		if password := store.secrets["app/key"].Password(); password != "secret" {
+			t.Errorf("expected password to be kept, got %q", password)
+		}
+		if got := len(store.revisions["app/key"]); got != 1 {
+			t.Errorf("expected the value and both fields in a single write, got %d revisions", got)
+		}
+	})
+
+	t.Run("reads kept fields", func(t *testing.T) {
+		store := newMockStore()
+		existing := newMockSecret("old")
+		existing.fields[usernameKey] = "admin"
+		store.secrets["app/key"] = existing
+		r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+		config := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":                     tftypes.NewValue(tftypes.String, "app/key"),
+			"value_wo":                 tftypes.NewValue(tftypes.String, "new"),
+			"value_wo_version":         tftypes.NewValue(tftypes.Number, 1),
+			"preserve_existing_fields": tftypes.NewValue(tftypes.Bool, true),
+			"url":                      tftypes.NewValue(tftypes.String, "https://example.com"),
+		})
+		plan := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":                       tftypes.NewValue(tftypes.String, "app/key"),
+			"value_wo_version":           tftypes.NewValue(tftypes.Number, 1),
+			"delete_on_remove":           tftypes.NewValue(tftypes.Bool, true),
+			"preserve_existing_fields":   tftypes.NewValue(tftypes.Bool, true),
+			"username":                   tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"url":                        tftypes.NewValue(tftypes.String, "https://example.com"),
+			"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
+		})
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+		}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+		var state SecretResourceModel
+		resp.State.Get(context.Background(), &state)
+		if state.Username.ValueString() != "admin" || state.URL.ValueString() != "https://example.com" {
+			t.Errorf("expected username from gopass and configured url, got %v and %v", state.Username, state.URL)
+		}
+	})
+
+	t.Run("write fails", func(t *testing.T) {
+		store := newMockStore()
+		store.secrets["app/key"] = &readOnlySecret{newMockSecret("old")}
+		r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+		raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":                       tftypes.NewValue(tftypes.String, "app/key"),
+			"value_wo":                   tftypes.NewValue(tftypes.String, "new"),
+			"value_wo_version":           tftypes.NewValue(tftypes.Number, 1),
+			"preserve_existing_fields":   tftypes.NewValue(tftypes.Bool, true),
+			"username":                   tftypes.NewValue(tftypes.String, "admin"),
+			"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
+		})
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error when the login fields cannot be written")
+		}
+	})
+}
+
+func TestSecretResource_Update_Login(t *testing.T) {
+	tests := []struct {
+		name          string
+		stateUsername any
+		planUsername  any
+		planVersion   int
+		storeUsername string
+		readOnly      bool
+		wantUsername  string
+		wantRevisions int
+		wantErr       bool
+	}{
+		{
+			name:          "username changed",
+			stateUsername: "admin",
+			planUsername:  "root",
+			planVersion:   1,
+			storeUsername: "admin",
+			wantUsername:  "root",
+			wantRevisions: 2,
+		},
+		{
+			name:          "changed outside of Terraform",
+			stateUsername: "admin",
+			planUsername:  "admin",
+			planVersion:   1,
+			storeUsername: "root",
+			wantUsername:  "root",
+			wantRevisions: 1,
+		},
+		{
+			name:          "restored after value rewrite",
+			stateUsername: "admin",
+			planUsername:  "admin",
+			planVersion:   2,
+			storeUsername: "admin",
+			wantUsername:  "admin",
+			wantRevisions: 2,
+		},
+		{
+			name:          "unknown read from gopass",
+			stateUsername: nil,
+			planUsername:  tftypes.UnknownValue,
+			planVersion:   1,
+			storeUsername: "admin",
+			wantUsername:  "admin",
+			wantRevisions: 1,
+		},
+		{
+			name:          "write fails",
+			stateUsername: "admin",
+			planUsername:  "root",
+			planVersion:   1,
+			readOnly:      true,
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			existing := newMockSecret("old")
+			existing.fields[urlKey] = "https://example.com"
+			if tc.storeUsername != "" {
+				existing.fields[usernameKey] = tc.storeUsername
+			}
+			store.secrets["app/key"] = existing
+			if tc.readOnly {
+				store.secrets["app/key"] = &readOnlySecret{existing}
+			}
+			store.revisions["app/key"] = []string{"1"}
+			r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+			state := loginValue(schemaResp, nil, 1, tc.stateUsername, "https://example.com")
+			plan := loginValue(schemaResp, nil, tc.planVersion, tc.planUsername, "https://example.com")
+			config := loginValue(schemaResp, "new", tc.planVersion, tc.planUsername, "https://example.com")
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if username, url := loginOf(t, store); username != tc.wantUsername || url != "https://example.com" {
+				t.Errorf("expected username %q and url in gopass, got %q and %q", tc.wantUsername, username, url)
+			}
+			if got := len(store.revisions["app/key"]); got != tc.wantRevisions {
+				t.Errorf("expected %d revisions, got %d", tc.wantRevisions, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Read_Login(t *testing.T) {
+	store := newMockStore()
+	existing := newMockSecret("value")
+	existing.fields[usernameKey] = "root"
+	store.secrets["app/key"] = existing
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+	raw := loginValue(schemaResp, nil, 1, "admin", "https://example.com")
+
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+	r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	// The changed username and the removed url show up as drift in the next plan
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if data.Username.ValueString() != "root" || !data.URL.IsNull() {
+		t.Errorf("expected login fields from gopass, got %v and %v", data.Username, data.URL)
+	}
+}
+
+func TestSecretResource_ReadLoginFields_Error(t *testing.T) {
+	store := newMockStore()
+	store.shouldFail = true
+	store.failMsg = "decryption failed"
+	r, _ := testResource(&SecretResource{client: clientWith(store)})
+
+	data := &SecretResourceModel{
+		Path:     types.StringValue("app/key"),
+		Username: types.StringValue("admin"),
+		URL:      types.StringUnknown(),
+	}
+	r.readLoginFields(context.Background(), data)
+	if data.Username.ValueString() != "admin" {
+		t.Errorf("expected previous username to be kept, got %v", data.Username)
+	}
+	if !data.URL.IsNull() {
+		t.Errorf("expected unknown url to become null, got %v", data.URL)
+	}
+}
+
+func TestSecretResource_ImportState_Login(t *testing.T) {
+	store := newMockStore()
+	existing := newMockSecret("value")
+	existing.fields[usernameKey] = "admin"
+	existing.fields[urlKey] = "https://example.com"
+	store.secrets["app/key"] = existing
+	r, schemaResp := testResource(&SecretResource{client: clientWith(store)})
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(context.Background(), resource.ImportStateRequest{ID: "app/key"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.State.Get(context.Background(), &data)
+	if data.Username.ValueString() != "admin" || data.URL.ValueString() != "https://example.com" {
+		t.Errorf("expected login fields from gopass, got %v and %v", data.Username, data.URL)
+	}
+}
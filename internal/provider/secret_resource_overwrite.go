@@ -0,0 +1,65 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// checkOverwrite fails the creation of a secret that would overwrite an existing one,
+// unless confirm_overwrite_existing is set. The existing revision count is reported, so the
+// user can tell a fresh leftover from a secret maintained by hand for a while.
+func (r *SecretResource) checkOverwrite(ctx context.Context, data *SecretResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if data.ConfirmOverwrite.ValueBool() {
+		return diags
+	}
+
+	secretPath := data.Path.ValueString()
+	revCount, err := r.client.GetRevisionCount(ctx, secretPath)
+	if err != nil {
+		diags.AddError(
+			"Failed to create secret",
+			errorDetail(err, fmt.Sprintf("Could not check for an existing secret at %q: %s", secretPath, err.Error())),
+		)
+		return diags
+	}
+	if revCount == 0 {
+		return diags
+	}
+
+	tflog.Warn(ctx, "Refusing to overwrite existing secret", map[string]interface{}{
+		"path":           secretPath,
+		"revision_count": revCount,
+	})
+	diags.AddAttributeError(
+		path.Root("path"),
+		"Secret already exists",
+		codedDetail(CodeAlreadyExists, fmt.Sprintf("A secret with %d revision(s) already exists at %q, e.g. stored by hand. "+
+			"Import it to adopt the secret as it is, or set confirm_overwrite_existing = true to replace it.", revCount, secretPath)),
+	)
+	return diags
+}
+
+// keepExisting reports whether the value of the secret at the path of data is kept because it
+// exists and only_if_absent is set. summary is the summary of the error if that cannot be told.
+func (r *SecretResource) keepExisting(ctx context.Context, data *SecretResourceModel, summary string) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	secretPath := data.Path.ValueString()
+	exists, err := r.client.SecretExists(ctx, secretPath)
+	if err != nil {
+		diags.AddError(
+			summary,
+			errorDetail(err, fmt.Sprintf("Could not check for an existing secret at %q: %s", secretPath, err.Error())),
+		)
+		return false, diags
+	}
+	return exists, diags
+}
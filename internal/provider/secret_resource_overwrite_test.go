@@ -0,0 +1,270 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestSecretResource_Create_ConfirmOverwriteExisting(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		confirm  any
+		values   map[string]tftypes.Value
+		failGet  bool
+		wantErr  string
+		wantPass string
+	}{
+		{name: "new secret", confirm: nil, wantPass: "new-password"},
+		{name: "existing refused", existing: true, confirm: nil, wantErr: `A secret with 1 revision(s) already exists at "test/generated"`, wantPass: "old-password"},
+		{name: "existing refused explicitly", existing: true, confirm: false, wantErr: "[GOPASS_ALREADY_EXISTS]", wantPass: "old-password"},
+		{name: "existing confirmed", existing: true, confirm: true, wantPass: "new-password"},
+		{
+			name: "generated value refused", existing: true, confirm: nil, wantErr: "already exists", wantPass: "old-password",
+			values: map[string]tftypes.Value{
+				"value_wo":            tftypes.NewValue(tftypes.String, nil),
+				"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+			},
+		},
+		{
+			name: "nothing written", existing: true, confirm: nil, wantPass: "old-password",
+			values: map[string]tftypes.Value{"value_wo": tftypes.NewValue(tftypes.String, nil)},
+		},
+		{name: "check fails", failGet: true, confirm: nil, wantErr: `Could not check for an existing secret at "test/generated"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			if tc.existing {
+				store.secrets["test/generated"] = newMockSecret("old-password")
+			}
+			client := NewGopassClient("")
+			client.store = store
+			if tc.failGet {
+				client.store = &mockStoreWithSelectiveFailure{mockStore: store, failOnGet: map[string]bool{"test/generated": true}}
+			}
+
+			values := map[string]tftypes.Value{
+				"value_wo":                   tftypes.NewValue(tftypes.String, "new-password"),
+				"value_wo_version":           tftypes.NewValue(tftypes.Number, 1),
+				"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, tc.confirm),
+			}
+			for name, value := range tc.values {
+				values[name] = value
+			}
+			resp := createWithGenerate(t, client, values)
+
+			if tc.wantErr == "" && resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+			}
+			if tc.wantPass != "" {
+				if got := store.secrets["test/generated"].Password(); got != tc.wantPass {
+					t.Errorf("expected password %q, got %q", tc.wantPass, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_OnlyIfAbsent(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		values   map[string]tftypes.Value
+		failGet  bool
+		wantErr  string
+		wantPass string
+		wantWarn bool
+	}{
+		{name: "new secret", wantPass: "new-password"},
+		{name: "existing kept", existing: true, wantPass: "old-password"},
+		{
+			name: "existing kept instead of generating", existing: true, wantPass: "old-password",
+			values: map[string]tftypes.Value{
+				"value_wo":            tftypes.NewValue(tftypes.String, nil),
+				"generate_if_missing": tftypes.NewValue(tftypes.Bool, true),
+			},
+		},
+		{
+			name: "nothing to write", existing: true, wantPass: "old-password", wantWarn: true,
+			values: map[string]tftypes.Value{"value_wo": tftypes.NewValue(tftypes.String, nil)},
+		},
+		{name: "check fails", failGet: true, wantErr: `Could not check for an existing secret at "test/generated"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			if tc.existing {
+				store.secrets["test/generated"] = newMockSecret("old-password")
+			}
+			client := NewGopassClient("")
+			client.store = store
+			if tc.failGet {
+				client.store = &mockStoreWithSelectiveFailure{mockStore: store, failOnGet: map[string]bool{"test/generated": true}}
+			}
+
+			values := map[string]tftypes.Value{
+				"value_wo":         tftypes.NewValue(tftypes.String, "new-password"),
+				"value_wo_version": tftypes.NewValue(tftypes.Number, 1),
+				"only_if_absent":   tftypes.NewValue(tftypes.Bool, true),
+			}
+			for name, value := range tc.values {
+				values[name] = value
+			}
+			resp := createWithGenerate(t, client, values)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := store.secrets["test/generated"].Password(); got != tc.wantPass {
+				t.Errorf("expected password %q, got %q", tc.wantPass, got)
+			}
+			if warned := resp.Diagnostics.WarningsCount() > 0; warned != tc.wantWarn {
+				t.Errorf("expected warning=%v, got %v", tc.wantWarn, resp.Diagnostics)
+			}
+
+			var state SecretResourceModel
+			resp.Diagnostics.Append(resp.State.Get(context.Background(), &state)...)
+			if wrote := !state.UpdatedAt.IsNull(); wrote != (tc.wantPass == "new-password") {
+				t.Errorf("expected updated_at only after a write, got %v", state.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_OnlyIfAbsent(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		failGet  bool
+		wantErr  string
+		wantPass string
+	}{
+		{name: "customized value kept", existing: true, wantPass: "customized"},
+		{name: "removed secret written", wantPass: "new-password"},
+		{name: "check fails", existing: true, failGet: true, wantErr: `Could not check for an existing secret at "test/seeded"`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			if tc.existing {
+				store.secrets["test/seeded"] = newMockSecret("customized")
+			}
+			client := NewGopassClient("")
+			client.store = store
+			if tc.failGet {
+				client.store = &mockStoreWithSelectiveFailure{mockStore: store, failOnGet: map[string]bool{"test/seeded": true}}
+			}
+			r := &SecretResource{client: client}
+
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			values := func(version int) map[string]tftypes.Value {
+				return map[string]tftypes.Value{
+					"id":               tftypes.NewValue(tftypes.String, "test/seeded"),
+					"path":             tftypes.NewValue(tftypes.String, "test/seeded"),
+					"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+					"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+					"only_if_absent":   tftypes.NewValue(tftypes.Bool, true),
+				}
+			}
+			state := schemaObjectValue(schemaResp.Schema, values(1))
+			plan := values(2)
+			config := values(2)
+			config["value_wo"] = tftypes.NewValue(tftypes.String, "new-password")
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, plan)},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, config)},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := store.secrets["test/seeded"].Password(); got != tc.wantPass {
+				t.Errorf("expected password %q, got %q", tc.wantPass, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ValidateConfig_OnlyIfAbsent(t *testing.T) {
+	tests := []struct {
+		name    string
+		confirm any
+		wantErr bool
+	}{
+		{name: "alone"},
+		{name: "overwrite not confirmed", confirm: false},
+		{name: "overwrite confirmed", confirm: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":                       tftypes.NewValue(tftypes.String, "test/secret"),
+				"only_if_absent":             tftypes.NewValue(tftypes.Bool, true),
+				"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, tc.confirm),
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if !tc.wantErr {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Conflicting only_if_absent and confirm_overwrite_existing" {
+				t.Errorf("expected conflict error, got %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+// withAttribute returns the object raw with the attribute name set to value.
+func withAttribute(raw tftypes.Value, name string, value tftypes.Value) tftypes.Value {
+	var values map[string]tftypes.Value
+	if err := raw.As(&values); err != nil {
+		panic(err)
+	}
+	values[name] = value
+	return tftypes.NewValue(raw.Type(), values)
+}
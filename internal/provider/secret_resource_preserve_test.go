@@ -0,0 +1,165 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// newSecretWithFields returns a secret with a password and human-maintained fields.
+func newSecretWithFields(password string) gopass.Secret {
+	secret := secrets.New()
+	secret.SetPassword(password)
+	_ = secret.Set("username", "admin") //nolint:errcheck // setting a field on a fresh secret cannot fail
+	_ = secret.Set("notes", "rotate quarterly")
+	return secret
+}
+
+func TestGopassClient_SetSecretPassword_KeepsFields(t *testing.T) {
+	client := NewGopassClient("")
+	mockStore := newMockStore()
+	mockStore.secrets["test/secret"] = newSecretWithFields("old-password")
+	client.store = mockStore
+
+	if err := client.SetSecretPassword(context.Background(), "test/secret", "new-password"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret := mockStore.secrets["test/secret"]
+	if secret.Password() != "new-password" {
+		t.Errorf("expected password 'new-password', got %q", secret.Password())
+	}
+	for key, want := range map[string]string{"username": "admin", "notes": "rotate quarterly"} {
+		if got, _ := secret.Get(key); got != want {
+			t.Errorf("expected field %q to be %q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestGopassClient_SetSecretPassword_CreatesMissing(t *testing.T) {
+	client := NewGopassClient("")
+	mockStore := newMockStore()
+	client.store = mockStore
+
+	if err := client.SetSecretPassword(context.Background(), "test/new", "password123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	secret, exists := mockStore.secrets["test/new"]
+	if !exists || secret.Password() != "password123" {
+		t.Errorf("expected secret to be created, got %v", secret)
+	}
+}
+
+func TestGopassClient_SetSecretPassword_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(client *GopassClient)
+		wantErr string
+	}{
+		{
+			name: "store init failure",
+			setup: func(client *GopassClient) {
+				client.apiNew = func(ctx context.Context, dir string) (gopass.Store, error) {
+					return nil, errors.New("init failed")
+				}
+			},
+			wantErr: "init failed",
+		},
+		{
+			name: "read failure",
+			setup: func(client *GopassClient) {
+				store := newMockStore()
+				store.shouldFail = true
+				store.failMsg = "decryption failed"
+				client.store = store
+			},
+			wantErr: "failed to read secret",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			tc.setup(client)
+
+			err := client.SetSecretPassword(context.Background(), "test/secret", "password123")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_PreserveExistingFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		preserve   bool
+		wantFields bool
+	}{
+		{name: "preserve", preserve: true, wantFields: true},
+		{name: "overwrite", preserve: false, wantFields: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			mockStore.secrets["test/generated"] = newSecretWithFields("old-password")
+			client := NewGopassClient("")
+			client.store = mockStore
+
+			resp := createWithGenerate(t, client, map[string]tftypes.Value{
+				"value_wo":                   tftypes.NewValue(tftypes.String, "new-password"),
+				"preserve_existing_fields":   tftypes.NewValue(tftypes.Bool, tc.preserve),
+				"confirm_overwrite_existing": tftypes.NewValue(tftypes.Bool, true),
+			})
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			secret := mockStore.secrets["test/generated"]
+			if secret.Password() != "new-password" {
+				t.Errorf("expected password 'new-password', got %q", secret.Password())
+			}
+			if got, _ := secret.Get("username"); (got == "admin") != tc.wantFields {
+				t.Errorf("preserve=%v: unexpected username field %q", tc.preserve, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ImportState_PreserveExistingFieldsDefault(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["test/imported"] = newSecretWithFields("password")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "test/imported"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var data SecretResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	if data.PreserveFields.IsNull() || data.PreserveFields.ValueBool() {
+		t.Errorf("expected preserve_existing_fields=false after import, got %v", data.PreserveFields)
+	}
+}
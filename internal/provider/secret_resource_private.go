@@ -0,0 +1,218 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/argon2"
+)
+
+// lastWriteKey is the private state key holding the lastWrite record of gopass_secret.
+const lastWriteKey = "last_write"
+
+// randRead fills a byte slice with random data.
+var randRead = rand.Read // injectable for testing
+
+// lastWriteKDF names the hash the fingerprint of a lastWrite record is derived with. A record
+// with another or none, e.g. one written by an older version as an HMAC, never matches, so the
+// next write goes through and replaces it.
+const lastWriteKDF = "argon2id"
+
+// Argon2id parameters of the fingerprint, the minimum OWASP recommends for password storage.
+// Changing them requires a new lastWriteKDF name, as records do not store them.
+const (
+	lastWriteTime    = 2
+	lastWriteMemory  = 19 * 1024 // KiB
+	lastWriteThreads = 1
+	lastWriteSaltLen = 16
+	lastWriteHashLen = 32
+)
+
+// lastWrite is kept in resource private state and describes the last value Terraform
+// wrote to a secret. The value itself is never stored, only a fingerprint derived from it
+// with Argon2id under a random per-write salt. The salt has to be kept next to it, as later
+// runs compare with it, so the slow hash is what makes trying candidate values against the
+// state expensive. The revision fields record the store state right after the write, so
+// later external modifications can be recognized; time and length make drift warnings
+// easier to debug.
+type lastWrite struct {
+	KDF           string `json:"kdf"`
+	Salt          []byte `json:"salt"`
+	Hash          []byte `json:"hash"`
+	RevisionCount int64  `json:"revision_count"`
+	Revision      string `json:"revision,omitempty"`
+	WrittenAt     string `json:"written_at,omitempty"`
+	Length        int    `json:"length"`
+	Imported      bool   `json:"imported,omitempty"` // the value the secret had when it was imported, see recordImport
+}
+
+// valueContent returns the write content of a plain value_wo or generated value.
+// The password is always the second element, see passwordLength.
+func valueContent(value string) []string {
+	return []string{"value", value}
+}
+
+// fileContent returns the write content of a value_file_wo file.
+func fileContent(data string) []string {
+	return []string{"file", data}
+}
+
+// composeContent returns the write content of compose parts.
+func composeContent(parts SecretParts) []string {
+	return append([]string{"compose", parts.Password, parts.Username, parts.URL}, parts.ExtraLines...)
+}
+
+// passwordLength returns the length in characters of the password in write content.
+func passwordLength(content []string) int {
+	return utf8.RuneCountInString(content[1])
+}
+
+// contentHash returns the Argon2id hash of content under salt. Every element is
+// length-prefixed, so different splits of the same bytes never collide.
+func contentHash(salt []byte, content []string) []byte {
+	var buf bytes.Buffer
+	for _, part := range content {
+		_ = binary.Write(&buf, binary.BigEndian, uint64(len(part))) //nolint:errcheck // buffer writes never fail
+		buf.WriteString(part)
+	}
+	defer wipe(buf.Bytes())
+	return argon2.IDKey(buf.Bytes(), salt, lastWriteTime, lastWriteMemory, lastWriteThreads, lastWriteHashLen)
+}
+
+// newLastWrite returns a record of content under a fresh random salt.
+func newLastWrite(content []string) (*lastWrite, error) {
+	salt := make([]byte, lastWriteSaltLen)
+	if _, err := randRead(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return &lastWrite{KDF: lastWriteKDF, Salt: salt, Hash: contentHash(salt, content)}, nil
+}
+
+// matches reports whether content is what was recorded. Records of another hash, or
+// missing their salt or hash, never match.
+func (w *lastWrite) matches(content []string) bool {
+	if w.KDF != lastWriteKDF || len(w.Salt) < lastWriteSaltLen || len(w.Hash) != lastWriteHashLen {
+		return false
+	}
+	return subtle.ConstantTimeCompare(w.Hash, contentHash(w.Salt, content)) == 1
+}
+
+// save stores the record in private state.
+func (w *lastWrite) save(ctx context.Context, private privateState) diag.Diagnostics {
+	data, _ := json.Marshal(w) //nolint:errcheck // a struct of byte slices, strings and integers always marshals
+	return private.SetKey(ctx, lastWriteKey, data)
+}
+
+// loadLastWrite reads the record from private state. It returns nil if none was stored.
+func loadLastWrite(ctx context.Context, private privateState) (*lastWrite, diag.Diagnostics) {
+	data, diags := private.GetKey(ctx, lastWriteKey)
+	if diags.HasError() || data == nil {
+		return nil, diags
+	}
+
+	var w lastWrite
+	if err := json.Unmarshal(data, &w); err != nil {
+		diags.AddError("Failed to decode last write", codedDetail(CodeInternal, err.Error()))
+		return nil, diags
+	}
+	return &w, diags
+}
+
+// recordWrite remembers content as the last value written to secretPath, together with
+// the revision count and commit hash of the secret after the write. The record is only
+// used to skip no-op writes and to explain drift, so failures are logged rather than reported.
+func (r *SecretResource) recordWrite(ctx context.Context, private privateState, secretPath string,
+	content []string, revisionCount int64, revision string,
+) {
+	r.saveLastWrite(ctx, private, secretPath, content, lastWrite{
+		RevisionCount: revisionCount,
+		Revision:      revision,
+		WrittenAt:     time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// saveLastWrite completes w with the hash of content under a fresh salt and the length of its
+// password, and stores it in private state. Failures are logged.
+func (r *SecretResource) saveLastWrite(ctx context.Context, private privateState, secretPath string,
+	content []string, w lastWrite,
+) {
+	fresh, err := newLastWrite(content)
+	if err != nil {
+		tflog.Warn(ctx, "Could not record last write", map[string]interface{}{
+			"path":  secretPath,
+			"error": err.Error(),
+		})
+		return
+	}
+	w.KDF, w.Salt, w.Hash = fresh.KDF, fresh.Salt, fresh.Hash
+	w.Length = passwordLength(content)
+
+	if diags := w.save(ctx, private); diags.HasError() {
+		tflog.Warn(ctx, "Could not record last write", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+}
+
+// unchangedSinceLastWrite reports whether writing content to secretPath would be a no-op:
+// Terraform wrote the same content last, or the secret had it when it was imported, and the
+// store shows no modification since. Written content is only considered in git-backed stores,
+// as changes elsewhere cannot be detected and there is no history to keep clean. An imported
+// value is compared with the store itself there instead, so taking over a secret never
+// rewrites it.
+func (r *SecretResource) unchangedSinceLastWrite(ctx context.Context, private privateState, secretPath string,
+	content []string,
+) bool {
+	w, diags := loadLastWrite(ctx, private)
+	if diags.HasError() {
+		tflog.Warn(ctx, "Could not load last write, writing secret", map[string]interface{}{
+			"path": secretPath,
+		})
+		return false
+	}
+	if w == nil || !w.matches(content) {
+		return false
+	}
+	if w.Revision == "" {
+		return w.Imported && r.storeHolds(ctx, secretPath, content)
+	}
+
+	revCount, err := r.client.GetRevisionCount(ctx, secretPath)
+	if err != nil || revCount != w.RevisionCount {
+		return false
+	}
+	return revisionHash(r.lastRevision(ctx, secretPath)) == w.Revision
+}
+
+// lastWriteDetail describes the last Terraform write recorded in private state for drift
+// warnings, including when the store changed according to lastRevision. It returns ""
+// if no write was recorded.
+func lastWriteDetail(ctx context.Context, private privateState, lastRevision types.Object) string {
+	w, diags := loadLastWrite(ctx, private)
+	if diags.HasError() || w == nil || w.WrittenAt == "" {
+		return ""
+	}
+
+	detail := fmt.Sprintf("Terraform last wrote it on %s (%d characters", w.WrittenAt, w.Length)
+	if w.Revision != "" {
+		detail += ", commit " + w.Revision
+	}
+	detail += ")"
+	if changed := stringAttr(lastRevision.Attributes()["timestamp"]); changed != "" {
+		detail += ", the store changed on " + changed
+	}
+	return detail + ". "
+}
@@ -0,0 +1,369 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestLastWrite_Matches(t *testing.T) {
+	w, err := newLastWrite(valueContent("s3cret"))
+	if err != nil {
+		t.Fatalf("newLastWrite() error = %v", err)
+	}
+	if bytes.Contains(w.Hash, []byte("s3cret")) || bytes.Contains(w.Salt, []byte("s3cret")) {
+		t.Error("record must not contain the value")
+	}
+	if w.KDF != "argon2id" || len(w.Salt) != lastWriteSaltLen || len(w.Hash) != lastWriteHashLen {
+		t.Errorf("unexpected record %+v", w)
+	}
+
+	testCases := []struct {
+		name    string
+		content []string
+		want    bool
+	}{
+		{name: "same value", content: valueContent("s3cret"), want: true},
+		{name: "other value", content: valueContent("other")},
+		{name: "compose with same password", content: composeContent(SecretParts{Password: "s3cret"})},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := w.matches(tc.content); got != tc.want {
+				t.Errorf("matches() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	// Length prefixes keep different splits of the same bytes apart
+	salt := []byte("0123456789abcdef")
+	if bytes.Equal(contentHash(salt, []string{"ab", "c"}), contentHash(salt, []string{"a", "bc"})) {
+		t.Error("expected different hashes for different splits")
+	}
+}
+
+func TestLastWrite_MatchesFailsClosed(t *testing.T) {
+	content := valueContent("s3cret")
+	w, err := newLastWrite(content)
+	if err != nil {
+		t.Fatalf("newLastWrite() error = %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(w *lastWrite)
+	}{
+		{name: "other kdf", modify: func(w *lastWrite) { w.KDF = "hmac-sha256" }},
+		{name: "no kdf", modify: func(w *lastWrite) { w.KDF = "" }},
+		{name: "short salt", modify: func(w *lastWrite) { w.Salt = w.Salt[:4] }},
+		{name: "no hash", modify: func(w *lastWrite) { w.Hash = nil }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record := *w
+			tc.modify(&record)
+			if record.matches(content) {
+				t.Error("expected no match")
+			}
+		})
+	}
+
+	// A record of an earlier version, an HMAC with its key, is decoded without a hash
+	ctx := context.Background()
+	resp := withPrivateData(&resource.CreateResponse{})
+	resp.Private.SetKey(ctx, lastWriteKey, []byte(`{"key":"a2V5","mac":"bWFj","revision_count":1,"revision":"abc123","length":6}`))
+	old, diags := loadLastWrite(ctx, resp.Private)
+	if diags.HasError() || old == nil {
+		t.Fatalf("expected a record, got %+v, %v", old, diags)
+	}
+	if old.matches(content) {
+		t.Error("expected an HMAC record never to match")
+	}
+}
+
+func TestNewLastWrite_RandError(t *testing.T) {
+	orig := randRead
+	defer func() { randRead = orig }()
+	randRead = func(b []byte) (int, error) { return 0, errors.New("no entropy") }
+
+	if _, err := newLastWrite(valueContent("x")); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Recording is best effort: the failure leaves private state untouched
+	r := &SecretResource{}
+	resp := withPrivateData(&resource.CreateResponse{})
+	r.recordWrite(context.Background(), resp.Private, "test/path", valueContent("x"), 1, "abc123")
+	if w, _ := loadLastWrite(context.Background(), resp.Private); w != nil {
+		t.Errorf("expected no record, got %+v", w)
+	}
+}
+
+func TestLoadLastWrite(t *testing.T) {
+	ctx := context.Background()
+
+	resp := withPrivateData(&resource.CreateResponse{})
+	w, diags := loadLastWrite(ctx, resp.Private)
+	if diags.HasError() || w != nil {
+		t.Errorf("expected no record, got %+v, %v", w, diags)
+	}
+
+	r := &SecretResource{}
+	r.recordWrite(ctx, resp.Private, "test/path", valueContent("x"), 3, "abc123")
+	w, diags = loadLastWrite(ctx, resp.Private)
+	if diags.HasError() || w == nil {
+		t.Fatalf("expected record, got %+v, %v", w, diags)
+	}
+	if w.RevisionCount != 3 || w.Revision != "abc123" || !w.matches(valueContent("x")) {
+		t.Errorf("unexpected record %+v", w)
+	}
+
+	if _, diags := loadLastWrite(ctx, rawPrivateState("[]")); !diags.HasError() {
+		t.Error("expected decode error")
+	}
+}
+
+func TestSecretResource_UnchangedSinceLastWrite(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name       string
+		private    privateState
+		revision   string
+		revCount   int64
+		gitOutput  string
+		storeFails bool
+		want       bool
+	}{
+		{name: "unchanged", revision: "0123abcd", revCount: 2, gitOutput: testGitLogLine, want: true},
+		{name: "no record", private: rawPrivateState(nil)},
+		{name: "corrupt record", private: rawPrivateState("[]")},
+		{name: "not git-backed", revCount: 2},
+		{name: "new revision", revision: "0123abcd", revCount: 1, gitOutput: testGitLogLine},
+		{name: "other commit", revision: "def456", revCount: 2, gitOutput: testGitLogLine},
+		{name: "store error", revision: "0123abcd", revCount: 2, gitOutput: testGitLogLine, storeFails: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"test/path": "s3cret"})
+			store.revisions["test/path"] = []string{"1", "2"}
+			store.shouldFail = tc.storeFails
+			store.failMsg = "backend unavailable"
+			client := NewGopassClient("")
+			client.store = store
+			var dir string
+			var args []string
+			client.execCommand = fakeGit(tc.gitOutput, nil, &dir, &args)
+			r := &SecretResource{client: client}
+
+			private := tc.private
+			if private == nil {
+				resp := withPrivateData(&resource.UpdateResponse{})
+				r.recordWrite(ctx, resp.Private, "test/path", valueContent("s3cret"), tc.revCount, tc.revision)
+				private = resp.Private
+			}
+
+			if got := r.unchangedSinceLastWrite(ctx, private, "test/path", valueContent("s3cret")); got != tc.want {
+				t.Errorf("unchangedSinceLastWrite() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_SkipsUnchangedValue(t *testing.T) {
+	testCases := []struct {
+		name        string
+		newValue    string
+		external    bool
+		wantWritten bool
+	}{
+		{name: "same value", newValue: "s3cret"},
+		{name: "new value", newValue: "changed", wantWritten: true},
+		{name: "modified outside of Terraform", newValue: "s3cret", external: true, wantWritten: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMockStore()
+			client := NewGopassClient("")
+			client.store = store
+			var dir string
+			var args []string
+			client.execCommand = fakeGit(testGitLogLine, nil, &dir, &args)
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raws := func(value string, version int) (tftypes.Value, tftypes.Value) {
+				values := map[string]tftypes.Value{
+					"path":             tftypes.NewValue(tftypes.String, "test/path"),
+					"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+					"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+				}
+				plan := schemaObjectValue(schemaResp.Schema, values)
+				values["value_wo"] = tftypes.NewValue(tftypes.String, value)
+				return plan, schemaObjectValue(schemaResp.Schema, values)
+			}
+
+			plan, config := raws("s3cret", 1)
+			createResp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, createResp)
+			if createResp.Diagnostics.HasError() {
+				t.Fatalf("Create() error: %v", createResp.Diagnostics)
+			}
+			if tc.external {
+				store.revisions["test/path"] = append(store.revisions["test/path"], "2")
+			}
+			revisionsBefore := len(store.revisions["test/path"])
+
+			plan, config = raws(tc.newValue, 2)
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}, Private: createResp.Private}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:    tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:   createResp.State,
+				Config:  tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				Private: createResp.Private,
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Update() error: %v", resp.Diagnostics)
+			}
+
+			written := len(store.revisions["test/path"]) > revisionsBefore
+			if written != tc.wantWritten {
+				t.Errorf("written = %v, want %v", written, tc.wantWritten)
+			}
+			if got := store.secrets["test/path"].Password(); got != tc.newValue {
+				t.Errorf("expected password %q, got %q", tc.newValue, got)
+			}
+
+			var state SecretResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+			if state.ValueWOVersion.ValueInt64() != 2 {
+				t.Errorf("expected value_wo_version 2 in state, got %d", state.ValueWOVersion.ValueInt64())
+			}
+		})
+	}
+}
+
+func TestLastWriteDetail(t *testing.T) {
+	ctx := context.Background()
+	lastRevision := types.ObjectValueMust(lastRevisionAttrTypes, map[string]attr.Value{
+		"hash":      types.StringValue("0123abcd"),
+		"timestamp": types.StringValue("2026-01-02T03:04:05+01:00"),
+		"author":    types.StringValue("Jane Doe <jane@example.com>"),
+	})
+
+	testCases := []struct {
+		name         string
+		private      func() privateState
+		lastRevision types.Object
+		want         []string
+		wantEmpty    bool
+	}{
+		{
+			name: "git-backed",
+			private: func() privateState {
+				resp := withPrivateData(&resource.UpdateResponse{})
+				(&SecretResource{}).recordWrite(ctx, resp.Private, "app/db", valueContent("pässword"), 1, "ffff0000")
+				return resp.Private
+			},
+			lastRevision: lastRevision,
+			want:         []string{"Terraform last wrote it on ", "(8 characters, commit ffff0000)", "the store changed on 2026-01-02T03:04:05+01:00. "},
+		},
+		{
+			name: "not git-backed",
+			private: func() privateState {
+				resp := withPrivateData(&resource.UpdateResponse{})
+				(&SecretResource{}).recordWrite(ctx, resp.Private, "app/db", valueContent("pw"), 1, "")
+				return resp.Private
+			},
+			lastRevision: types.ObjectNull(lastRevisionAttrTypes),
+			want:         []string{"(2 characters)."},
+		},
+		{
+			name:         "no record",
+			private:      func() privateState { return rawPrivateState(nil) },
+			lastRevision: lastRevision,
+			wantEmpty:    true,
+		},
+		{
+			name:         "corrupt record",
+			private:      func() privateState { return rawPrivateState("[]") },
+			lastRevision: lastRevision,
+			wantEmpty:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			detail := lastWriteDetail(ctx, tc.private(), tc.lastRevision)
+			if tc.wantEmpty != (detail == "") {
+				t.Fatalf("unexpected detail %q", detail)
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(detail, want) {
+					t.Errorf("expected detail to contain %q, got %q", want, detail)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretResource_Read_DriftShowsLastWrite(t *testing.T) {
+	ctx := context.Background()
+	store := storeWith(map[string]string{"app/db": "s3cret"})
+	store.revisions["app/db"] = []string{"1", "2"}
+	client := NewGopassClient("")
+	client.store = store
+	var dir string
+	var args []string
+	client.execCommand = fakeGit(testGitLogLine, nil, &dir, &args)
+	r := &SecretResource{client: client}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	lastRevisionType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"hash": tftypes.String, "timestamp": tftypes.String, "author": tftypes.String,
+	}}
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/db"),
+		"path":             tftypes.NewValue(tftypes.String, "app/db"),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"revision_count":   tftypes.NewValue(tftypes.Number, 1),
+		"last_revision": tftypes.NewValue(lastRevisionType, map[string]tftypes.Value{
+			"hash":      tftypes.NewValue(tftypes.String, "ffff0000"),
+			"timestamp": tftypes.NewValue(tftypes.String, "2025-01-01T00:00:00Z"),
+			"author":    tftypes.NewValue(tftypes.String, "Terraform <tf@example.com>"),
+		}),
+	})
+
+	req := withPrivateData(&resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}})
+	r.recordWrite(ctx, req.Private, "app/db", valueContent("s3cret"), 1, "ffff0000")
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}, Private: req.Private}
+	r.Read(ctx, *req, resp)
+
+	if resp.Diagnostics.WarningsCount() != 2 {
+		t.Fatalf("expected two drift warnings, got %v", resp.Diagnostics)
+	}
+	for _, d := range resp.Diagnostics {
+		if !strings.Contains(d.Detail(), "(6 characters, commit ffff0000), the store changed on 2026-01-02T03:04:05+01:00") {
+			t.Errorf("expected last write in warning, got %q", d.Detail())
+		}
+	}
+}
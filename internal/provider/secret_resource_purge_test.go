@@ -0,0 +1,133 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// purgeState returns a gopass_secret state at app/db with the given delete_on_remove and purge_on_remove.
+func purgeState(schemaResp *resource.SchemaResponse, deleteOnRemove, purgeOnRemove any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/db"),
+		"path":             tftypes.NewValue(tftypes.String, "app/db"),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, deleteOnRemove),
+		"purge_on_remove":  tftypes.NewValue(tftypes.Bool, purgeOnRemove),
+	})
+}
+
+func TestSecretResource_ValidateConfig_PurgeOnRemove(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteOnRemove any
+		purgeOnRemove  any
+		wantErr        bool
+	}{
+		{name: "purge", deleteOnRemove: nil, purgeOnRemove: true},
+		{name: "purge and delete", deleteOnRemove: true, purgeOnRemove: true},
+		{name: "keep without purge", deleteOnRemove: false, purgeOnRemove: false},
+		{name: "purge but keep", deleteOnRemove: false, purgeOnRemove: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(context.Background(), resource.SchemaRequest{}, schemaResp)
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: purgeState(schemaResp, tc.deleteOnRemove, tc.purgeOnRemove)},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr && resp.Diagnostics.Errors()[0].Summary() != "Conflicting purge_on_remove and delete_on_remove" {
+				t.Errorf("unexpected error %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Delete_PurgeOnRemove(t *testing.T) {
+	tests := []struct {
+		name          string
+		versioned     bool
+		purgeOnRemove bool
+		output        map[string]string
+		failOn        string
+		wantPurge     bool
+		wantErr       string
+		wantWarning   string
+	}{
+		{name: "no purge", versioned: true},
+		{name: "not versioned", purgeOnRemove: true},
+		{name: "purged", versioned: true, purgeOnRemove: true, wantPurge: true},
+		{
+			name: "purged with remote", versioned: true, purgeOnRemove: true, wantPurge: true,
+			output:      map[string]string{"git remote get-url": "git@example.com:store.git\n", "git remote": "origin\n"},
+			wantWarning: `the remote "origin" (git@example.com:store.git) still has it`,
+		},
+		{name: "remote check fails", versioned: true, purgeOnRemove: true, wantPurge: true, failOn: "git remote"},
+		{
+			name: "purge fails", versioned: true, purgeOnRemove: true, wantPurge: true, failOn: "env",
+			wantErr: `The secret at "app/db" was removed, but its history could not be purged`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			if tc.versioned {
+				dir = gitStoreDir(t)
+			}
+			store := newMockStore()
+			store.secrets["app/db"] = newMockSecret("compromised")
+			client := NewGopassClient(dir)
+			client.store = store
+			var calls []string
+			client.execCommand = purgeExec(&calls, tc.output, tc.failOn)
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(ctx, resource.DeleteRequest{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: purgeState(schemaResp, true, tc.purgeOnRemove)},
+			}, resp)
+
+			if _, exists := store.secrets["app/db"]; exists {
+				t.Error("expected secret to be removed")
+			}
+			purged := len(calls) > 0 && strings.Contains(calls[0], "filter-branch")
+			if purged != tc.wantPurge {
+				t.Errorf("expected purge=%v, got calls %q", tc.wantPurge, calls)
+			}
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Detail(), tc.wantErr) {
+					t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if (resp.Diagnostics.WarningsCount() == 1) != (tc.wantWarning != "") {
+				t.Fatalf("expected warning %q, got %v", tc.wantWarning, resp.Diagnostics)
+			}
+			if tc.wantWarning != "" && !strings.Contains(resp.Diagnostics.Warnings()[0].Detail(), tc.wantWarning) {
+				t.Errorf("unexpected warning %v", resp.Diagnostics)
+			}
+		})
+	}
+}
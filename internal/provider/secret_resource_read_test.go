@@ -31,16 +31,7 @@ func TestSecretResource_Read(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/secret"),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -78,6 +69,10 @@ func TestSecretResource_Read(t *testing.T) {
 	if state.RevisionCount.ValueInt64() != 2 {
 		t.Errorf("expected revision count 2, got %d", state.RevisionCount.ValueInt64())
 	}
+	// State written before exists was added has it null
+	if !state.Exists.ValueBool() {
+		t.Errorf("expected exists to be true, got %v", state.Exists)
+	}
 }
 
 func TestSecretResource_Read_ExistsError(t *testing.T) {
@@ -94,16 +89,7 @@ func TestSecretResource_Read_ExistsError(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/secret"),
 		"path":             tftypes.NewValue(tftypes.String, "test/secret"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -144,16 +130,7 @@ func TestSecretResource_Read_NotFound(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "nonexistent"),
 		"path":             tftypes.NewValue(tftypes.String, "nonexistent"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -181,9 +158,14 @@ func TestSecretResource_Read_NotFound(t *testing.T) {
 		t.Errorf("unexpected error: %v", resp.Diagnostics)
 	}
 
-	// State should be removed when secret doesn't exist
-	if !resp.State.Raw.IsNull() {
-		t.Error("expected state to be removed for non-existent secret")
+	// State is kept with exists = false, so the plan replaces the resource
+	var state SecretResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("failed to get state: %v", resp.Diagnostics)
+	}
+	if state.Exists.IsNull() || state.Exists.ValueBool() {
+		t.Errorf("expected exists to be false for a non-existent secret, got %v", state.Exists)
 	}
 }
 
@@ -242,7 +224,7 @@ func TestSecretResource_Read_StateGetError(t *testing.T) {
 	}
 }
 
-// flakyGetStore fails on the second call to Get
+// flakyGetStore fails on the fourth call to Get, after the exists check and the expiry and login lookups
 type flakyGetStore struct {
 	*mockStore
 	calls int
@@ -250,7 +232,7 @@ type flakyGetStore struct {
 
 func (m *flakyGetStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
 	m.calls++
-	if m.calls == 2 {
+	if m.calls == 4 {
 		return nil, fmt.Errorf("flaky failure")
 	}
 	return m.mockStore.Get(ctx, name, revision)
@@ -272,16 +254,7 @@ func TestSecretResource_Read_GetRevisionCountError(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/flaky"),
 		"path":             tftypes.NewValue(tftypes.String, "test/flaky"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -328,16 +301,7 @@ func TestSecretResource_Read_DriftWarning(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// State has 1 revision
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/drift"),
 		"path":             tftypes.NewValue(tftypes.String, "test/drift"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -0,0 +1,246 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+const testGitLogLine = "0123abcd\x1f2026-01-02T03:04:05+01:00\x1fJane Doe <jane@example.com>\n"
+
+func TestGopassClient_GetRevisionInfo(t *testing.T) {
+	client := NewGopassClient("/store")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit(testGitLogLine, nil, &gotDir, &gotArgs)
+
+	info, err := client.GetRevisionInfo(context.Background(), "app/db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &RevisionInfo{
+		Hash:      "0123abcd",
+		Timestamp: "2026-01-02T03:04:05+01:00",
+		Author:    "Jane Doe <jane@example.com>",
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+	if gotDir != "/store" {
+		t.Errorf("expected git to run in '/store', got %q", gotDir)
+	}
+	wantArgs := []string{"git", "log", "-1", "--format=%H%x1f%aI%x1f%an <%ae>", "--", "app/db.gpg", "app/db.age"}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("expected args %v, got %v", wantArgs, gotArgs)
+	}
+}
+
+func TestGopassClient_GetRevisionInfo_NoHistory(t *testing.T) {
+	client := NewGopassClient("/store")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("\n", nil, &gotDir, &gotArgs)
+
+	info, err := client.GetRevisionInfo(context.Background(), "app/db")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info != nil {
+		t.Errorf("expected nil info, got %+v", info)
+	}
+}
+
+func TestGopassClient_GetRevisionInfo_NoRevisions(t *testing.T) {
+	client := NewGopassClient("/store")
+	client.caps = map[string]storeCapabilities{"": {Remove: true}, "app": {Remove: true}}
+	client.execCommand = func(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+		t.Errorf("expected git not to run for a store without revisions, got %s %v", name, args)
+		return nil, nil
+	}
+
+	info, err := client.GetRevisionInfo(context.Background(), "app/db")
+	if err != nil || info != nil {
+		t.Errorf("expected no revision info and no error, got %+v, %v", info, err)
+	}
+}
+
+func TestGopassClient_GetRevisionInfo_Errors(t *testing.T) {
+	t.Run("git error", func(t *testing.T) {
+		client := NewGopassClient("/store")
+		var gotDir string
+		var gotArgs []string
+		client.execCommand = fakeGit("", errors.New("not a git repository"), &gotDir, &gotArgs)
+
+		_, err := client.GetRevisionInfo(context.Background(), "app/db")
+		if err == nil || !strings.Contains(err.Error(), "failed to read revision info") {
+			t.Errorf("expected wrapped error, got %v", err)
+		}
+	})
+
+	t.Run("malformed output", func(t *testing.T) {
+		client := NewGopassClient("/store")
+		var gotDir string
+		var gotArgs []string
+		client.execCommand = fakeGit("garbage\n", nil, &gotDir, &gotArgs)
+
+		_, err := client.GetRevisionInfo(context.Background(), "app/db")
+		if err == nil || !strings.Contains(err.Error(), "unexpected git log output") {
+			t.Errorf("expected parse error, got %v", err)
+		}
+	})
+
+	t.Run("store dir error", func(t *testing.T) {
+		client := NewGopassClient("~/store")
+		client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+
+		if _, err := client.GetRevisionInfo(context.Background(), "app/db"); err == nil {
+			t.Error("expected error but got none")
+		}
+	})
+}
+
+func TestSecretResource_LastRevision(t *testing.T) {
+	client := NewGopassClient("/store")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit(testGitLogLine, nil, &gotDir, &gotArgs)
+	r := &SecretResource{client: client}
+
+	obj := r.lastRevision(context.Background(), "app/db")
+	if revisionHash(obj) != "0123abcd" {
+		t.Errorf("expected hash '0123abcd', got %v", obj)
+	}
+	if stringAttr(obj.Attributes()["author"]) != "Jane Doe <jane@example.com>" {
+		t.Errorf("unexpected author in %v", obj)
+	}
+}
+
+func TestSecretResource_LastRevision_Error(t *testing.T) {
+	client := NewGopassClient("/store")
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit("", errors.New("git not found"), &gotDir, &gotArgs)
+	r := &SecretResource{client: client}
+
+	if obj := r.lastRevision(context.Background(), "app/db"); !obj.IsNull() {
+		t.Errorf("expected null object, got %v", obj)
+	}
+}
+
+func TestStringAttr_NotString(t *testing.T) {
+	if got := stringAttr(types.Int64Value(1)); got != "" {
+		t.Errorf("expected empty string, got %q", got)
+	}
+	if got := revisionHash(types.ObjectNull(lastRevisionAttrTypes)); got != "" {
+		t.Errorf("expected empty hash for null object, got %q", got)
+	}
+}
+
+func readWithLastRevision(t *testing.T, storedHash string) *resource.ReadResponse {
+	t.Helper()
+
+	mockStore := newMockStore()
+	mockStore.secrets["app/db"] = newMockSecret("secret")
+	client := NewGopassClient("/store")
+	client.store = mockStore
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit(testGitLogLine, nil, &gotDir, &gotArgs)
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	lastRevisionType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"hash": tftypes.String, "timestamp": tftypes.String, "author": tftypes.String,
+	}}
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/db"),
+		"path":             tftypes.NewValue(tftypes.String, "app/db"),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"revision_count":   tftypes.NewValue(tftypes.Number, 1),
+		"last_revision": tftypes.NewValue(lastRevisionType, map[string]tftypes.Value{
+			"hash":      tftypes.NewValue(tftypes.String, storedHash),
+			"timestamp": tftypes.NewValue(tftypes.String, "2025-01-01T00:00:00Z"),
+			"author":    tftypes.NewValue(tftypes.String, "Terraform <tf@example.com>"),
+		}),
+	})
+
+	req := resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}}
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}}
+	r.Read(ctx, req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	return resp
+}
+
+func TestSecretResource_Read_LastRevisionDrift(t *testing.T) {
+	resp := readWithLastRevision(t, "ffff0000")
+
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Fatalf("expected one drift warning, got %v", resp.Diagnostics)
+	}
+	detail := resp.Diagnostics[0].Detail()
+	if !strings.Contains(detail, "commit 0123abcd by Jane Doe <jane@example.com>") || !strings.Contains(detail, "ffff0000") {
+		t.Errorf("unexpected warning detail: %s", detail)
+	}
+
+	var state SecretResourceModel
+	resp.Diagnostics.Append(resp.State.Get(context.Background(), &state)...)
+	if revisionHash(state.LastRevision) != "0123abcd" {
+		t.Errorf("expected state to be updated to the current commit, got %v", state.LastRevision)
+	}
+}
+
+func TestSecretResource_Read_LastRevisionUnchanged(t *testing.T) {
+	resp := readWithLastRevision(t, "0123abcd")
+
+	if resp.Diagnostics.WarningsCount() != 0 {
+		t.Errorf("expected no warnings, got %v", resp.Diagnostics)
+	}
+}
+
+func TestSecretResource_ImportState_LastRevision(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["app/db"] = newMockSecret("secret")
+	client := NewGopassClient("/store")
+	client.store = mockStore
+	var gotDir string
+	var gotArgs []string
+	client.execCommand = fakeGit(testGitLogLine, nil, &gotDir, &gotArgs)
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "app/db"}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var lastRevision types.Object
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("last_revision"), &lastRevision)...)
+	if got := stringAttr(lastRevision.Attributes()["timestamp"]); got != "2026-01-02T03:04:05+01:00" {
+		t.Errorf("unexpected timestamp %q", got)
+	}
+}
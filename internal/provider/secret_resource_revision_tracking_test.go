@@ -0,0 +1,166 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestGopassClient_RevisionTracking(t *testing.T) {
+	client := NewGopassClient("")
+	if got := client.RevisionTracking(); got != revisionTrackingAuto {
+		t.Errorf("expected default %q, got %q", revisionTrackingAuto, got)
+	}
+	client.SetRevisionTracking(revisionTrackingOff)
+	if got := client.RevisionTracking(); got != revisionTrackingOff {
+		t.Errorf("expected %q, got %q", revisionTrackingOff, got)
+	}
+}
+
+func TestSecretResource_ValidateConfig_RevisionTracking(t *testing.T) {
+	tests := []struct {
+		mode    any
+		wantErr bool
+	}{
+		{mode: nil},
+		{mode: tftypes.UnknownValue},
+		{mode: "auto"},
+		{mode: "off"},
+		{mode: "strict"},
+		{mode: "sometimes", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		r := &SecretResource{}
+		ctx := context.Background()
+		schemaResp := &resource.SchemaResponse{}
+		r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+		raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path":              tftypes.NewValue(tftypes.String, "test/secret"),
+			"revision_tracking": tftypes.NewValue(tftypes.String, tc.mode),
+		})
+		resp := &resource.ValidateConfigResponse{}
+		r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+		if resp.Diagnostics.HasError() != tc.wantErr {
+			t.Errorf("mode %v: expected error=%v, got %v", tc.mode, tc.wantErr, resp.Diagnostics)
+		}
+		if tc.wantErr && resp.Diagnostics.Errors()[0].Summary() != "Invalid revision_tracking" {
+			t.Errorf("unexpected error %v", resp.Diagnostics)
+		}
+	}
+}
+
+// readWithRevisionTracking reads a secret whose state records one revision while the store has two.
+func readWithRevisionTracking(t *testing.T, providerMode string, resourceMode any) (*resource.ReadResponse, SecretResourceModel) {
+	t.Helper()
+
+	mockStore := newMockStore()
+	mockStore.secrets["test/drift"] = newMockSecret("test")
+	mockStore.revisions["test/drift"] = []string{"1", "2"}
+	client := NewGopassClient("")
+	client.store = mockStore
+	if providerMode != "" {
+		client.SetRevisionTracking(providerMode)
+	}
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":                tftypes.NewValue(tftypes.String, "test/drift"),
+		"path":              tftypes.NewValue(tftypes.String, "test/drift"),
+		"delete_on_remove":  tftypes.NewValue(tftypes.Bool, true),
+		"revision_count":    tftypes.NewValue(tftypes.Number, 1),
+		"revision_tracking": tftypes.NewValue(tftypes.String, resourceMode),
+	})
+
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}}
+	r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}}, resp)
+
+	var state SecretResourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.State.Get(ctx, &state)
+	}
+	return resp, state
+}
+
+func TestSecretResource_Read_RevisionTracking(t *testing.T) {
+	tests := []struct {
+		name         string
+		providerMode string
+		resourceMode any
+		wantWarnings int
+		wantErr      bool
+		wantCount    int64
+	}{
+		{name: "auto warns", wantWarnings: 1, wantCount: 2},
+		{name: "off in provider", providerMode: "off", wantCount: 0},
+		{name: "off in resource", providerMode: "strict", resourceMode: "off", wantCount: 0},
+		{name: "strict in provider", providerMode: "strict", wantErr: true},
+		{name: "strict in resource", resourceMode: "strict", wantErr: true},
+		{name: "resource overrides strict provider", providerMode: "strict", resourceMode: "auto", wantWarnings: 1, wantCount: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, state := readWithRevisionTracking(t, tc.providerMode, tc.resourceMode)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				if resp.Diagnostics.Errors()[0].Summary() != "Secret modified outside of Terraform" {
+					t.Errorf("expected drift error, got %v", resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.WarningsCount() != tc.wantWarnings {
+				t.Errorf("expected %d warnings, got %v", tc.wantWarnings, resp.Diagnostics)
+			}
+			if state.RevisionCount.ValueInt64() != tc.wantCount {
+				t.Errorf("expected revision count %d, got %d", tc.wantCount, state.RevisionCount.ValueInt64())
+			}
+			if tc.wantCount == 0 && (state.RevisionsSupported.ValueBool() || !state.LastRevision.IsNull()) {
+				t.Errorf("expected revision attributes to be cleared, got %+v", state)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ImportState_RevisionTrackingOff(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["app/db"] = newMockSecret("secret")
+	mockStore.revisions["app/db"] = []string{"1", "2", "3"}
+	client := NewGopassClient("")
+	client.store = mockStore
+	client.SetRevisionTracking(revisionTrackingOff)
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	resp := &resource.ImportStateResponse{
+		State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "app/db"}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var state SecretResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	if state.RevisionCount.ValueInt64() != 0 || state.RevisionsSupported.ValueBool() || !state.LastRevision.IsNull() {
+		t.Errorf("expected no revision tracking, got %+v", state)
+	}
+}
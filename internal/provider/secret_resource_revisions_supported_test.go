@@ -0,0 +1,87 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestGopassClient_SupportsRevisions(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["versioned"] = newMockSecret("v")
+	mockStore.revisions["versioned"] = []string{"1", "2"}
+	mockStore.secrets["plain"] = newMockSecret("v")
+	client := NewGopassClient("")
+	client.store = mockStore
+
+	ctx := context.Background()
+
+	supported, err := client.SupportsRevisions(ctx, "versioned")
+	if err != nil || !supported {
+		t.Errorf("expected revisions to be supported, got %v (err %v)", supported, err)
+	}
+
+	supported, err = client.SupportsRevisions(ctx, "plain")
+	if err != nil || supported {
+		t.Errorf("expected revisions to be unsupported, got %v (err %v)", supported, err)
+	}
+}
+
+func TestGopassClient_SupportsRevisions_EnsureStoreError(t *testing.T) {
+	client := NewGopassClient("/nonexistent/path/for/test")
+
+	if _, err := client.SupportsRevisions(context.Background(), "any"); err == nil {
+		t.Error("expected error but got none")
+	}
+}
+
+func TestSecretResource_RevisionsSupported_ProbeError(t *testing.T) {
+	r := &SecretResource{client: NewGopassClient("/nonexistent/path/for/test")}
+
+	if got := r.revisionsSupported(context.Background(), "any"); got.ValueBool() {
+		t.Error("expected false when the probe fails")
+	}
+}
+
+func TestSecretResource_Read_RevisionsSupported(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["fs/secret"] = newMockSecret("v")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":                  tftypes.NewValue(tftypes.String, "fs/secret"),
+		"path":                tftypes.NewValue(tftypes.String, "fs/secret"),
+		"delete_on_remove":    tftypes.NewValue(tftypes.Bool, true),
+		"revision_count":      tftypes.NewValue(tftypes.Number, 1),
+		"revisions_supported": tftypes.NewValue(tftypes.Bool, true),
+	})
+
+	req := resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}}
+	resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: stateValue}}
+	r.Read(ctx, req, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var state SecretResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	if state.RevisionsSupported.IsNull() || state.RevisionsSupported.ValueBool() {
+		t.Errorf("expected revisions_supported=false for a store without history, got %v", state.RevisionsSupported)
+	}
+	if state.RevisionCount.ValueInt64() != 1 {
+		t.Errorf("expected revision_count 1, got %d", state.RevisionCount.ValueInt64())
+	}
+}
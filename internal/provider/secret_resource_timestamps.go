@@ -0,0 +1,59 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// writeTimestamp returns the current time as stored in created_at and updated_at.
+func writeTimestamp() types.String {
+	return types.StringValue(timeNow().UTC().Format(time.RFC3339))
+}
+
+// planUpdatedAt marks updated_at as unknown when value_wo_version changes, as the update
+// writes the secret then. Otherwise the previous value is kept by UseStateForUnknown, also
+// when the new value_wo is the one an imported secret already has, which is not written again.
+func (r *SecretResource) planUpdatedAt(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+
+	var planned, current types.Int64
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("value_wo_version"), &planned)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("value_wo_version"), &current)...)
+	if resp.Diagnostics.HasError() || planned.IsNull() || planned.Equal(current) {
+		return
+	}
+	if r.importedValuePlanned(ctx, req) {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("updated_at"), types.StringUnknown())...)
+}
+
+// importTimestamps sets created_at and updated_at of an imported secret to the times of the
+// first and the last commit of the secret, or null if the store has no history of it.
+func (r *SecretResource) importTimestamps(ctx context.Context, data *SecretResourceModel) {
+	data.CreatedAt = types.StringNull()
+	data.UpdatedAt = types.StringNull()
+
+	created, updated, err := r.client.GetSecretTimes(ctx, data.Path.ValueString())
+	if err != nil {
+		tflog.Debug(ctx, "Could not read the history of the imported secret", map[string]interface{}{
+			"path":  data.Path.ValueString(),
+			"error": err.Error(),
+		})
+		return
+	}
+	if created != "" {
+		data.CreatedAt = types.StringValue(created)
+		data.UpdatedAt = types.StringValue(updated)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// fixTimeNow makes timeNow return at for the duration of the test.
+func fixTimeNow(t *testing.T, at time.Time) {
+	t.Helper()
+	now := timeNow
+	timeNow = func() time.Time { return at }
+	t.Cleanup(func() { timeNow = now })
+}
+
+// timestampValue builds a gopass_secret object for the timestamp tests.
+func timestampValue(schemaResp *resource.SchemaResponse, value, version, createdAt, updatedAt any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "app/db"),
+		"path":             tftypes.NewValue(tftypes.String, "app/db"),
+		"value_wo":         tftypes.NewValue(tftypes.String, value),
+		"value_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"created_at":       tftypes.NewValue(tftypes.String, createdAt),
+		"updated_at":       tftypes.NewValue(tftypes.String, updatedAt),
+	})
+}
+
+func TestGopassClient_GetSecretTimes(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		err         error
+		storePath   string
+		wantCreated string
+		wantUpdated string
+		wantErr     bool
+	}{
+		{
+			name:        "history",
+			output:      "2026-03-01T10:00:00+01:00\n2026-02-01T10:00:00+01:00\n2026-01-01T10:00:00+01:00\n",
+			wantCreated: "2026-01-01T10:00:00+01:00",
+			wantUpdated: "2026-03-01T10:00:00+01:00",
+		},
+		{name: "single commit", output: "2026-01-01T10:00:00+01:00\n", wantCreated: "2026-01-01T10:00:00+01:00", wantUpdated: "2026-01-01T10:00:00+01:00"},
+		{name: "no history", output: "\n"},
+		{name: "git error", err: errors.New("not a git repository"), wantErr: true},
+		{name: "store dir error", storePath: "~/store", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			storePath := tc.storePath
+			if storePath == "" {
+				storePath = "/store"
+			}
+			client := NewGopassClient(storePath)
+			client.userHomeDir = func() (string, error) { return "", errors.New("no home") }
+			var gotDir string
+			var gotArgs []string
+			client.execCommand = fakeGit(tc.output, tc.err, &gotDir, &gotArgs)
+
+			created, updated, err := client.GetSecretTimes(context.Background(), "work/app/db")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("GetSecretTimes() error = %v, want error %v", err, tc.wantErr)
+			}
+			if created != tc.wantCreated || updated != tc.wantUpdated {
+				t.Errorf("GetSecretTimes() = %q, %q; want %q, %q", created, updated, tc.wantCreated, tc.wantUpdated)
+			}
+			if tc.name == "history" {
+				wantArgs := []string{"git", "log", "--format=%aI", "--", "work/app/db.gpg", "work/app/db.age"}
+				if gotDir != "/store" || !reflect.DeepEqual(gotArgs, wantArgs) {
+					t.Errorf("expected %v in /store, got %v in %q", wantArgs, gotArgs, gotDir)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretResource_Create_Timestamps(t *testing.T) {
+	fixTimeNow(t, time.Date(2026, 10, 16, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60)))
+
+	tests := []struct {
+		name        string
+		value       any
+		wantUpdated types.String
+	}{
+		{name: "written", value: "secret", wantUpdated: types.StringValue("2026-10-16T12:00:00Z")},
+		{name: "no value", wantUpdated: types.StringNull()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := NewGopassClient("")
+			client.store = newMockStore()
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			version := any(1)
+			if tc.value == nil {
+				version = nil
+			}
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: timestampValue(schemaResp, nil, version, tftypes.UnknownValue, tftypes.UnknownValue)},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: timestampValue(schemaResp, tc.value, version, nil, nil)},
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var state SecretResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+			if state.CreatedAt.ValueString() != "2026-10-16T12:00:00Z" {
+				t.Errorf("expected created_at of the create, got %v", state.CreatedAt)
+			}
+			if !state.UpdatedAt.Equal(tc.wantUpdated) {
+				t.Errorf("expected updated_at %v, got %v", tc.wantUpdated, state.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_Timestamps(t *testing.T) {
+	fixTimeNow(t, time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name          string
+		planVersion   int
+		planCreated   any
+		planUpdated   any
+		stateCreated  any
+		stateUpdated  any
+		wantCreated   types.String
+		wantUpdated   types.String
+		configValue   any
+		configVersion int
+	}{
+		{
+			name: "written", planVersion: 2, planCreated: "2026-01-01T00:00:00Z", planUpdated: tftypes.UnknownValue,
+			stateCreated: "2026-01-01T00:00:00Z", stateUpdated: "2026-01-01T00:00:00Z", configValue: "new", configVersion: 2,
+			wantCreated: types.StringValue("2026-01-01T00:00:00Z"), wantUpdated: types.StringValue("2026-10-16T12:00:00Z"),
+		},
+		{
+			name: "not written", planVersion: 1, planCreated: "2026-01-01T00:00:00Z", planUpdated: "2026-02-01T00:00:00Z",
+			stateCreated: "2026-01-01T00:00:00Z", stateUpdated: "2026-02-01T00:00:00Z", configVersion: 1,
+			wantCreated: types.StringValue("2026-01-01T00:00:00Z"), wantUpdated: types.StringValue("2026-02-01T00:00:00Z"),
+		},
+		{
+			name: "created before timestamps", planVersion: 1, planCreated: tftypes.UnknownValue, planUpdated: tftypes.UnknownValue,
+			configVersion: 1, wantCreated: types.StringNull(), wantUpdated: types.StringNull(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := NewGopassClient("")
+			client.store = storeWith(map[string]string{"app/db": "old"})
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(ctx, resource.UpdateRequest{
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: timestampValue(schemaResp, nil, 1, tc.stateCreated, tc.stateUpdated)},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: timestampValue(schemaResp, nil, tc.planVersion, tc.planCreated, tc.planUpdated)},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: timestampValue(schemaResp, tc.configValue, tc.configVersion, nil, nil)},
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var state SecretResourceModel
+			resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+			if !state.CreatedAt.Equal(tc.wantCreated) || !state.UpdatedAt.Equal(tc.wantUpdated) {
+				t.Errorf("expected created_at %v and updated_at %v, got %v and %v",
+					tc.wantCreated, tc.wantUpdated, state.CreatedAt, state.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ModifyPlan_UpdatedAt(t *testing.T) {
+	const updated = "2026-01-01T00:00:00Z"
+
+	tests := []struct {
+		name         string
+		create       bool
+		stateVersion any
+		planVersion  any
+		wantUnknown  bool
+	}{
+		{name: "create", create: true, planVersion: 1},
+		{name: "version unchanged", stateVersion: 1, planVersion: 1},
+		{name: "version bumped", stateVersion: 1, planVersion: 2, wantUnknown: true},
+		{name: "version added", planVersion: 1, wantUnknown: true},
+		{name: "no version", stateVersion: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretResource{client: NewGopassClient("")}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			plan := timestampValue(schemaResp, nil, tc.planVersion, updated, updated)
+			state := timestampValue(schemaResp, nil, tc.stateVersion, updated, updated)
+			if tc.create {
+				state = schemaNullValue(schemaResp.Schema)
+			}
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var got types.String
+			resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root("updated_at"), &got)...)
+			if got.IsUnknown() != tc.wantUnknown {
+				t.Errorf("expected updated_at unknown=%v, got %v", tc.wantUnknown, got)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ImportTimestamps(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		err         error
+		wantCreated types.String
+		wantUpdated types.String
+	}{
+		{
+			name:        "history",
+			output:      "2026-03-01T10:00:00+01:00\n2026-01-01T10:00:00+01:00\n",
+			wantCreated: types.StringValue("2026-01-01T10:00:00+01:00"),
+			wantUpdated: types.StringValue("2026-03-01T10:00:00+01:00"),
+		},
+		{name: "no history", wantCreated: types.StringNull(), wantUpdated: types.StringNull()},
+		{name: "git error", err: errors.New("not a git repository"), wantCreated: types.StringNull(), wantUpdated: types.StringNull()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("/store")
+			var gotDir string
+			var gotArgs []string
+			client.execCommand = fakeGit(tc.output, tc.err, &gotDir, &gotArgs)
+			r := &SecretResource{client: client}
+
+			data := SecretResourceModel{Path: types.StringValue("app/db")}
+			r.importTimestamps(context.Background(), &data)
+			if !data.CreatedAt.Equal(tc.wantCreated) || !data.UpdatedAt.Equal(tc.wantUpdated) {
+				t.Errorf("expected %v and %v, got %v and %v", tc.wantCreated, tc.wantUpdated, data.CreatedAt, data.UpdatedAt)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
@@ -32,16 +33,7 @@ func TestSecretResource_Update(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// State: version 1
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/update"),
 		"path":             tftypes.NewValue(tftypes.String, "test/update"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -51,16 +43,7 @@ func TestSecretResource_Update(t *testing.T) {
 	})
 
 	// Plan: version 2
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/update"),
 		"path":             tftypes.NewValue(tftypes.String, "test/update"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue), // Unknown in plan?
@@ -70,16 +53,7 @@ func TestSecretResource_Update(t *testing.T) {
 	})
 
 	// Config: has value
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/update"),
 		"path":             tftypes.NewValue(tftypes.String, "test/update"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "new-password"),
@@ -135,16 +109,7 @@ func TestSecretResource_Update_NoChange(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// State: version 1
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/no-change"),
 		"path":             tftypes.NewValue(tftypes.String, "test/no-change"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -159,16 +124,7 @@ func TestSecretResource_Update_NoChange(t *testing.T) {
 	// But critical part is ValueWOVersion is 1 in both
 
 	// Config: value provided, but version same
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/no-change"),
 		"path":             tftypes.NewValue(tftypes.String, "test/no-change"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "new-password-ignored"),
@@ -214,16 +170,7 @@ func TestSecretResource_Update_Warning(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// State: version 1
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/warn"),
 		"path":             tftypes.NewValue(tftypes.String, "test/warn"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -233,16 +180,7 @@ func TestSecretResource_Update_Warning(t *testing.T) {
 	})
 
 	// Plan: version 2
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/warn"),
 		"path":             tftypes.NewValue(tftypes.String, "test/warn"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -252,16 +190,7 @@ func TestSecretResource_Update_Warning(t *testing.T) {
 	})
 
 	// Config: NO value
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/warn"),
 		"path":             tftypes.NewValue(tftypes.String, "test/warn"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil), // Null
@@ -327,16 +256,7 @@ func TestSecretResource_Update_GetRevisionCountError(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// State: version 1, rev count 1
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/rev-fail"),
 		"path":             tftypes.NewValue(tftypes.String, "test/rev-fail"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -346,16 +266,7 @@ func TestSecretResource_Update_GetRevisionCountError(t *testing.T) {
 	})
 
 	// Plan: version 2
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/rev-fail"),
 		"path":             tftypes.NewValue(tftypes.String, "test/rev-fail"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -365,16 +276,7 @@ func TestSecretResource_Update_GetRevisionCountError(t *testing.T) {
 	})
 
 	// Config
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/rev-fail"),
 		"path":             tftypes.NewValue(tftypes.String, "test/rev-fail"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "new"),
@@ -425,16 +327,7 @@ func TestSecretResource_Update_SetSecretError(t *testing.T) {
 	schemaResp := &resource.SchemaResponse{}
 	r.Schema(ctx, schemaReq, schemaResp)
 
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/err"),
 		"path":             tftypes.NewValue(tftypes.String, "test/err"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -443,16 +336,7 @@ func TestSecretResource_Update_SetSecretError(t *testing.T) {
 		"revision_count":   tftypes.NewValue(tftypes.Number, 1),
 	})
 
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/err"),
 		"path":             tftypes.NewValue(tftypes.String, "test/err"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -461,16 +345,7 @@ func TestSecretResource_Update_SetSecretError(t *testing.T) {
 		"revision_count":   tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
 	})
 
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/err"),
 		"path":             tftypes.NewValue(tftypes.String, "test/err"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "new"),
@@ -541,25 +416,49 @@ func TestSecretResource_Update_ConfigGetError(t *testing.T) {
 
 	validSchema := schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"path":             schema.StringAttribute{Required: true},
-			"id":               schema.StringAttribute{Computed: true},
-			"value_wo":         schema.StringAttribute{Optional: true},
-			"value_wo_version": schema.Int64Attribute{Optional: true},
-			"delete_on_remove": schema.BoolAttribute{Optional: true},
-			"revision_count":   schema.Int64Attribute{Computed: true},
+			"path":                       schema.StringAttribute{Required: true},
+			"id":                         schema.StringAttribute{Computed: true},
+			"value_wo":                   schema.StringAttribute{Optional: true},
+			"value_wo_version":           schema.Int64Attribute{Optional: true},
+			"delete_on_remove":           schema.BoolAttribute{Optional: true},
+			"purge_on_remove":            schema.BoolAttribute{Optional: true},
+			"value_file_wo":              schema.StringAttribute{Optional: true},
+			"backup_before_update":       schema.BoolAttribute{Optional: true},
+			"backup_prefix":              schema.StringAttribute{Optional: true},
+			"revision_count":             schema.Int64Attribute{Computed: true},
+			"generate_if_missing":        schema.BoolAttribute{Optional: true},
+			"generate_length":            schema.Int64Attribute{Optional: true},
+			"generate_symbols":           schema.BoolAttribute{Optional: true},
+			"preserve_existing_fields":   schema.BoolAttribute{Optional: true},
+			"compose":                    composeAttribute(),
+			"data_wo":                    dataAttribute(),
+			"data_wo_version":            schema.Int64Attribute{Optional: true},
+			"timeouts":                   resourceTimeoutsAttribute(),
+			"revisions_supported":        schema.BoolAttribute{Computed: true},
+			"exists":                     schema.BoolAttribute{Computed: true},
+			"path_components":            schema.ListAttribute{ElementType: types.StringType, Computed: true},
+			"comment":                    schema.StringAttribute{Optional: true, Computed: true},
+			"last_revision":              schema.ObjectAttribute{Computed: true, AttributeTypes: lastRevisionAttrTypes},
+			"revision_tracking":          schema.StringAttribute{Optional: true},
+			"on_external_change":         schema.StringAttribute{Optional: true},
+			"generate":                   generateAttribute(),
+			"validate_regex":             schema.StringAttribute{Optional: true},
+			"min_length":                 schema.Int64Attribute{Optional: true},
+			"forbid_whitespace":          schema.BoolAttribute{Optional: true},
+			"normalize":                  schema.StringAttribute{Optional: true},
+			"expires_at":                 schema.StringAttribute{Optional: true, Computed: true},
+			"username":                   schema.StringAttribute{Optional: true, Computed: true},
+			"url":                        schema.StringAttribute{Optional: true, Computed: true},
+			"created_at":                 schema.StringAttribute{Computed: true},
+			"chunk_size":                 schema.Int64Attribute{Optional: true},
+			"confirm_overwrite_existing": schema.BoolAttribute{Optional: true},
+			"only_if_absent":             schema.BoolAttribute{Optional: true},
+			"verify_after_write":         schema.BoolAttribute{Optional: true},
+			"updated_at":                 schema.StringAttribute{Computed: true},
 		},
 	}
 
-	validValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":             tftypes.String,
-			"id":               tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	validValue := schemaObjectValue(validSchema, map[string]tftypes.Value{
 		"path":             tftypes.NewValue(tftypes.String, "path"),
 		"id":               tftypes.NewValue(tftypes.String, "id"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -605,25 +504,49 @@ func TestSecretResource_Update_StateGetError(t *testing.T) {
 
 	validSchema := schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"path":             schema.StringAttribute{Required: true},
-			"id":               schema.StringAttribute{Computed: true},
-			"value_wo":         schema.StringAttribute{Optional: true},
-			"value_wo_version": schema.Int64Attribute{Optional: true},
-			"delete_on_remove": schema.BoolAttribute{Optional: true},
-			"revision_count":   schema.Int64Attribute{Computed: true},
+			"path":                       schema.StringAttribute{Required: true},
+			"id":                         schema.StringAttribute{Computed: true},
+			"value_wo":                   schema.StringAttribute{Optional: true},
+			"value_wo_version":           schema.Int64Attribute{Optional: true},
+			"delete_on_remove":           schema.BoolAttribute{Optional: true},
+			"purge_on_remove":            schema.BoolAttribute{Optional: true},
+			"value_file_wo":              schema.StringAttribute{Optional: true},
+			"backup_before_update":       schema.BoolAttribute{Optional: true},
+			"backup_prefix":              schema.StringAttribute{Optional: true},
+			"revision_count":             schema.Int64Attribute{Computed: true},
+			"generate_if_missing":        schema.BoolAttribute{Optional: true},
+			"generate_length":            schema.Int64Attribute{Optional: true},
+			"generate_symbols":           schema.BoolAttribute{Optional: true},
+			"preserve_existing_fields":   schema.BoolAttribute{Optional: true},
+			"compose":                    composeAttribute(),
+			"data_wo":                    dataAttribute(),
+			"data_wo_version":            schema.Int64Attribute{Optional: true},
+			"timeouts":                   resourceTimeoutsAttribute(),
+			"revisions_supported":        schema.BoolAttribute{Computed: true},
+			"exists":                     schema.BoolAttribute{Computed: true},
+			"path_components":            schema.ListAttribute{ElementType: types.StringType, Computed: true},
+			"comment":                    schema.StringAttribute{Optional: true, Computed: true},
+			"last_revision":              schema.ObjectAttribute{Computed: true, AttributeTypes: lastRevisionAttrTypes},
+			"revision_tracking":          schema.StringAttribute{Optional: true},
+			"on_external_change":         schema.StringAttribute{Optional: true},
+			"generate":                   generateAttribute(),
+			"validate_regex":             schema.StringAttribute{Optional: true},
+			"min_length":                 schema.Int64Attribute{Optional: true},
+			"forbid_whitespace":          schema.BoolAttribute{Optional: true},
+			"normalize":                  schema.StringAttribute{Optional: true},
+			"expires_at":                 schema.StringAttribute{Optional: true, Computed: true},
+			"username":                   schema.StringAttribute{Optional: true, Computed: true},
+			"url":                        schema.StringAttribute{Optional: true, Computed: true},
+			"created_at":                 schema.StringAttribute{Computed: true},
+			"chunk_size":                 schema.Int64Attribute{Optional: true},
+			"confirm_overwrite_existing": schema.BoolAttribute{Optional: true},
+			"only_if_absent":             schema.BoolAttribute{Optional: true},
+			"verify_after_write":         schema.BoolAttribute{Optional: true},
+			"updated_at":                 schema.StringAttribute{Computed: true},
 		},
 	}
 
-	validValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"path":             tftypes.String,
-			"id":               tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	validValue := schemaObjectValue(validSchema, map[string]tftypes.Value{
 		"path":             tftypes.NewValue(tftypes.String, "path"),
 		"id":               tftypes.NewValue(tftypes.String, "id"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -677,16 +600,7 @@ func TestSecretResource_Update_AddVersion(t *testing.T) {
 	r.Schema(ctx, schemaReq, schemaResp)
 
 	// State: version is null (was not tracked)
-	stateValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	stateValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/add-ver"),
 		"path":             tftypes.NewValue(tftypes.String, "test/add-ver"),
 		"value_wo":         tftypes.NewValue(tftypes.String, nil),
@@ -696,16 +610,7 @@ func TestSecretResource_Update_AddVersion(t *testing.T) {
 	})
 
 	// Plan: version is set
-	planValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	planValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/add-ver"),
 		"path":             tftypes.NewValue(tftypes.String, "test/add-ver"),
 		"value_wo":         tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
@@ -715,16 +620,7 @@ func TestSecretResource_Update_AddVersion(t *testing.T) {
 	})
 
 	// Config: value provided
-	configValue := tftypes.NewValue(tftypes.Object{
-		AttributeTypes: map[string]tftypes.Type{
-			"id":               tftypes.String,
-			"path":             tftypes.String,
-			"value_wo":         tftypes.String,
-			"value_wo_version": tftypes.Number,
-			"delete_on_remove": tftypes.Bool,
-			"revision_count":   tftypes.Number,
-		},
-	}, map[string]tftypes.Value{
+	configValue := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
 		"id":               tftypes.NewValue(tftypes.String, "test/add-ver"),
 		"path":             tftypes.NewValue(tftypes.String, "test/add-ver"),
 		"value_wo":         tftypes.NewValue(tftypes.String, "new"),
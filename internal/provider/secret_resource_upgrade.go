@@ -0,0 +1,94 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// Ensure implementation satisfies interfaces.
+var _ resource.ResourceWithUpgradeState = &SecretResource{}
+
+// secretSchemaVersion is the version of the gopass_secret schema. Renaming or removing an
+// attribute, or changing its type, requires a new version and an upgrader in UpgradeState
+// from the previous one, so states written by earlier releases keep working. Giving an
+// attribute a default also does, so the state of earlier releases gets the default.
+const secretSchemaVersion = 2
+
+// UpgradeState returns the upgraders of gopass_secret state written with earlier schema
+// versions to the current one.
+func (r *SecretResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		// Version 0 is every release before the schema was versioned. Attributes were only
+		// ever added then, so its state is read with the current schema.
+		0: {StateUpgrader: r.upgradeStateDefaults(0)},
+		// Version 1 had only_if_absent without a default, so its state may hold null.
+		1: {StateUpgrader: r.upgradeStateDefaults(1)},
+	}
+}
+
+// upgradeStateDefaults returns an upgrader reading state of the given schema version with the
+// current schema, ignoring attributes it does not know. Attributes with a default that are null
+// in the state, as they were added or got their default later, are set to the default, so the
+// first plan after the upgrade shows no changes.
+func (r *SecretResource) upgradeStateDefaults(version int64) func(context.Context, resource.UpgradeStateRequest, *resource.UpgradeStateResponse) {
+	return func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+		r.upgradeState(ctx, version, req, resp)
+	}
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretResource) upgradeState(ctx context.Context, version int64, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	typ := resp.State.Schema.Type().TerraformType(ctx)
+	values, err := decodeRawState(req.RawState, typ)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to upgrade gopass_secret state",
+			codedDetail(CodeInternal, fmt.Sprintf("Could not read the state of schema version %d: %s", version, err.Error())),
+		)
+		return
+	}
+
+	for name, attr := range resp.State.Schema.GetAttributes() {
+		if !values[name].IsNull() {
+			continue
+		}
+		switch a := attr.(type) {
+		case schema.BoolAttribute:
+			if a.Default != nil {
+				defaultResp := &defaults.BoolResponse{}
+				a.Default.DefaultBool(ctx, defaults.BoolRequest{}, defaultResp)
+				values[name] = tftypes.NewValue(tftypes.Bool, defaultResp.PlanValue.ValueBool())
+			}
+		case schema.Int64Attribute:
+			if a.Default != nil {
+				defaultResp := &defaults.Int64Response{}
+				a.Default.DefaultInt64(ctx, defaults.Int64Request{}, defaultResp)
+				values[name] = tftypes.NewValue(tftypes.Number, defaultResp.PlanValue.ValueInt64())
+			}
+		}
+	}
+	resp.State.Raw = tftypes.NewValue(typ, values)
+}
+
+// decodeRawState returns the attribute values of state stored as JSON, read with the object
+// type typ. Attributes typ does not have are ignored, and those missing from the state are null.
+func decodeRawState(state *tfprotov6.RawState, typ tftypes.Type) (map[string]tftypes.Value, error) {
+	raw, err := state.UnmarshalWithOpts(typ, tfprotov6.UnmarshalOpts{
+		ValueFromJSONOpts: tftypes.ValueFromJSONOpts{IgnoreUndefinedAttributes: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]tftypes.Value
+	err = raw.As(&values)
+	return values, err
+}
@@ -0,0 +1,123 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+func TestSecretResource_UpgradeState(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int64
+		json    string
+		check   func(t *testing.T, state SecretResourceModel)
+		wantErr string
+	}{
+		{
+			name: "defaults of added attributes",
+			json: `{"id":"app/db","path":"app/db","value_wo_version":2,"revision_count":3}`,
+			check: func(t *testing.T, state SecretResourceModel) {
+				if state.Path.ValueString() != "app/db" || state.ValueWOVersion.ValueInt64() != 2 || state.RevisionCount.ValueInt64() != 3 {
+					t.Errorf("expected the stored attributes to be kept, got %+v", state)
+				}
+				if !state.DeleteOnRemove.ValueBool() || state.OnlyIfAbsent.ValueBool() ||
+					state.GenerateLength.ValueInt64() != defaultGenerateLength {
+					t.Errorf("expected missing attributes to be set to their defaults, got %+v", state)
+				}
+				// exists has no default, the refresh after the upgrade sets it
+				if !state.Compose.IsNull() || !state.ExpiresAt.IsNull() || !state.Exists.IsNull() {
+					t.Errorf("expected missing attributes without default to be null, got %+v", state)
+				}
+			},
+		},
+		{
+			name: "stored values win over defaults",
+			json: `{"id":"app/db","path":"app/db","delete_on_remove":false,"generate_length":64}`,
+			check: func(t *testing.T, state SecretResourceModel) {
+				if state.DeleteOnRemove.ValueBool() || state.GenerateLength.ValueInt64() != 64 {
+					t.Errorf("expected the stored values to be kept, got %+v", state)
+				}
+			},
+		},
+		{
+			name: "removed attributes are dropped",
+			json: `{"id":"app/db","path":"app/db","no_longer_supported":"x"}`,
+			check: func(t *testing.T, state SecretResourceModel) {
+				if state.ID.ValueString() != "app/db" {
+					t.Errorf("expected the state to be upgraded, got %+v", state)
+				}
+			},
+		},
+		{
+			name:    "only_if_absent without default",
+			version: 1,
+			json:    `{"id":"app/db","path":"app/db","only_if_absent":null,"confirm_overwrite_existing":false}`,
+			check: func(t *testing.T, state SecretResourceModel) {
+				if state.OnlyIfAbsent.IsNull() || state.OnlyIfAbsent.ValueBool() {
+					t.Errorf("expected only_if_absent to be set to its default, got %v", state.OnlyIfAbsent)
+				}
+			},
+		},
+		{name: "invalid JSON", json: `{"id":`, wantErr: "Failed to upgrade gopass_secret state"},
+		{name: "wrong type", json: `{"id":"app/db","revision_count":"three"}`, wantErr: "Failed to upgrade gopass_secret state"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := &SecretResource{}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			upgrader, ok := r.UpgradeState(ctx)[tc.version]
+			if !ok {
+				t.Fatalf("expected an upgrader from schema version %d", tc.version)
+			}
+			resp := &resource.UpgradeStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			upgrader.StateUpgrader(ctx, resource.UpgradeStateRequest{
+				RawState: &tfprotov6.RawState{JSON: []byte(tc.json)},
+			}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			var state SecretResourceModel
+			if diags := resp.State.Get(ctx, &state); diags.HasError() {
+				t.Fatalf("upgraded state does not match the schema: %v", diags)
+			}
+			tc.check(t, state)
+		})
+	}
+}
+
+func TestSecretResource_SchemaVersion(t *testing.T) {
+	ctx := context.Background()
+	r := &SecretResource{}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	// Every earlier version needs an upgrader to the current one
+	upgraders := r.UpgradeState(ctx)
+	for version := int64(0); version < schemaResp.Schema.Version; version++ {
+		if _, ok := upgraders[version]; !ok {
+			t.Errorf("missing upgrader from schema version %d", version)
+		}
+	}
+	if _, ok := upgraders[schemaResp.Schema.Version]; ok {
+		t.Error("an upgrader from the current schema version is never called")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestSecretResource_ValidateConfig(t *testing.T) {
+	testCases := []struct {
+		name    string
+		value   any
+		version any
+		wantErr string
+	}{
+		{name: "both set", value: "secret", version: 1},
+		{name: "neither set", value: nil, version: nil},
+		{name: "unknown value with version", value: tftypes.UnknownValue, version: 1},
+		{name: "value without version", value: "secret", version: nil, wantErr: "Missing value_wo_version"},
+		{name: "unknown value without version", value: tftypes.UnknownValue, version: nil, wantErr: "Missing value_wo_version"},
+		{name: "version without value", value: nil, version: 2, wantErr: "Missing value_wo"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, "test/secret"),
+				"value_wo":         tftypes.NewValue(tftypes.String, tc.value),
+				"value_wo_version": tftypes.NewValue(tftypes.Number, tc.version),
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ValidateConfig_InvalidConfig(t *testing.T) {
+	r := &SecretResource{}
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	resp := &resource.ValidateConfigResponse{}
+	r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
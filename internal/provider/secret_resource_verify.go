@@ -0,0 +1,81 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// verifyWrite reads the secret at secretPath back right after content was written by update and
+// adds an error to diags if the store returns something else, e.g. a value it truncated or whose
+// line endings it converted. Only SHA-256 digests of the values are compared.
+func (r *SecretResource) verifyWrite(ctx context.Context, diags *diag.Diagnostics, secretPath string, content []string, update *SecretUpdate) {
+	stored, err := r.client.GetSecretFull(uncached(ctx), secretPath, "")
+	if err != nil {
+		diags.AddError(
+			"Failed to verify secret",
+			errorDetail(err, fmt.Sprintf("Could not read back secret at %q after writing it: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	// A value replaces the password only; a file or compose replaces the whole secret
+	readBack := stored.Password
+	if content[0] != "value" {
+		readBack = stored.Raw
+	}
+	written := writtenSecret(content, update.Fields, update.Remove)
+	writtenSum, readSum := sha256.Sum256([]byte(written)), sha256.Sum256([]byte(readBack))
+	if subtle.ConstantTimeCompare(writtenSum[:], readSum[:]) == 1 {
+		tflog.Debug(ctx, "Verified gopass secret after write", map[string]interface{}{
+			"path": secretPath,
+		})
+		return
+	}
+
+	diags.AddError(
+		"Secret changed by the store",
+		codedDetail(CodeChecksumMismatch, fmt.Sprintf(
+			"The secret at %q reads back differently than it was written (%d bytes written, %d bytes read), "+
+				"e.g. because the store converted its line endings or truncated it. Check it with gopass show "+
+				"before relying on it.", secretPath, len(written), len(readBack),
+		)),
+	)
+}
+
+// writtenSecret returns what reading back content should return: the password of a value,
+// or the whole secret of a file or compose, with the fields written along with it.
+func writtenSecret(content []string, fields map[string]string, remove []string) string {
+	if content[0] == "value" {
+		return content[1]
+	}
+	if content[0] == "file" && len(fields) == 0 && len(remove) == 0 {
+		return content[1]
+	}
+
+	var secret gopass.Secret
+	if content[0] == "file" {
+		secret = secrets.ParseAKV([]byte(content[1]))
+	} else {
+		composed, err := composeSecret(SecretParts{Password: content[1], Username: content[2], URL: content[3], ExtraLines: content[4:]})
+		if err != nil {
+			// Parts that cannot be composed were never written
+			return ""
+		}
+		secret = composed
+	}
+	if _, err := applyFields(secret, fields, remove); err != nil {
+		// Fields that cannot be set were never written
+		return ""
+	}
+	return string(secret.Bytes())
+}
@@ -0,0 +1,208 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// manglingStore is a store that changes secrets on write, like backends converting line endings.
+// Once a secret was written, reads fail with getErr if set.
+type manglingStore struct {
+	*mockStore
+	mangle func([]byte) []byte
+	getErr error
+}
+
+func (m *manglingStore) Set(ctx context.Context, name string, secret gopass.Byter) error {
+	return m.mockStore.Set(ctx, name, secrets.ParseAKV(m.mangle(secret.Bytes())))
+}
+
+func (m *manglingStore) Get(ctx context.Context, name, revision string) (gopass.Secret, error) {
+	if m.getErr != nil && len(m.secrets) > 0 {
+		return nil, m.getErr
+	}
+	return m.mockStore.Get(ctx, name, revision)
+}
+
+func appendLine(b []byte) []byte { return append(b, "\n"...) }
+
+func truncate(b []byte) []byte { return b[:len(b)-2] }
+
+func TestSecretResource_Create_VerifyAfterWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(file, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]tftypes.Value
+		mangle  func([]byte) []byte
+		getErr  error
+		wantErr string
+	}{
+		{name: "value", values: map[string]tftypes.Value{"value_wo": tftypes.NewValue(tftypes.String, "s3cret")}},
+		{
+			name:    "truncated value",
+			values:  map[string]tftypes.Value{"value_wo": tftypes.NewValue(tftypes.String, "s3cret")},
+			mangle:  truncate,
+			wantErr: "Secret changed by the store",
+		},
+		{name: "file", values: map[string]tftypes.Value{"value_file_wo": tftypes.NewValue(tftypes.String, file)}},
+		{
+			name:    "file with a line appended",
+			values:  map[string]tftypes.Value{"value_file_wo": tftypes.NewValue(tftypes.String, file)},
+			mangle:  appendLine,
+			wantErr: "Secret changed by the store",
+		},
+		{
+			name: "compose",
+			values: map[string]tftypes.Value{"compose": tftypes.NewValue(composeType, map[string]tftypes.Value{
+				"password":    tftypes.NewValue(tftypes.String, "s3cret"),
+				"username":    tftypes.NewValue(tftypes.String, "admin"),
+				"url":         tftypes.NewValue(tftypes.String, nil),
+				"extra_lines": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "note")}),
+			})},
+		},
+		{
+			name:    "read back fails",
+			values:  map[string]tftypes.Value{"value_wo": tftypes.NewValue(tftypes.String, "s3cret")},
+			getErr:  errors.New("gpg: decryption failed"),
+			wantErr: "Failed to verify secret",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &manglingStore{mockStore: newMockStore(), mangle: func(b []byte) []byte { return b }, getErr: tc.getErr}
+			if tc.mangle != nil {
+				store.mangle = tc.mangle
+			}
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			values := map[string]tftypes.Value{
+				"path":               tftypes.NewValue(tftypes.String, "app/db"),
+				"value_wo_version":   tftypes.NewValue(tftypes.Number, 1),
+				"verify_after_write": tftypes.NewValue(tftypes.Bool, true),
+				"delete_on_remove":   tftypes.NewValue(tftypes.Bool, true),
+			}
+			for k, v := range tc.values {
+				values[k] = v
+			}
+			raw := schemaObjectValue(schemaResp.Schema, values)
+			resp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Fatalf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !resp.State.Raw.IsNull() {
+				t.Error("expected no state for an unverified secret")
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_VerifyAfterWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		mangle  func([]byte) []byte
+		wantErr bool
+	}{
+		{name: "verified"},
+		{name: "truncated", mangle: truncate, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := &manglingStore{mockStore: storeWith(map[string]string{"app/db": "old"}), mangle: func(b []byte) []byte { return b }}
+			if tc.mangle != nil {
+				store.mangle = tc.mangle
+			}
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			value := func(version int, v any) tftypes.Value {
+				return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"id":                 tftypes.NewValue(tftypes.String, "app/db"),
+					"path":               tftypes.NewValue(tftypes.String, "app/db"),
+					"value_wo":           tftypes.NewValue(tftypes.String, v),
+					"value_wo_version":   tftypes.NewValue(tftypes.Number, version),
+					"verify_after_write": tftypes.NewValue(tftypes.Bool, true),
+				})
+			}
+			req := withPrivateData(&resource.UpdateRequest{
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: value(1, nil)},
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: value(2, nil)},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: value(2, "rotated")},
+			})
+			resp := withPrivateData(&resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Update(ctx, *req, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestWrittenSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []string
+		fields  map[string]string
+		remove  []string
+		want    string
+	}{
+		{name: "value", content: valueContent("s3cret"), want: "s3cret"},
+		{name: "value with fields", content: valueContent("s3cret"), fields: map[string]string{"url": "x"}, want: "s3cret"},
+		{name: "file", content: fileContent("a\nb\n"), want: "a\nb\n"},
+		{name: "file with fields", content: fileContent("a\nhost: db\n"), fields: map[string]string{"url": "x"}, remove: []string{"host"}, want: "a\nurl: x\n"},
+		{name: "compose", content: composeContent(SecretParts{Password: "s3cret", Username: "admin"}), want: "s3cret\nusername: admin\n"},
+		{
+			name:    "compose with fields",
+			content: composeContent(SecretParts{Password: "s3cret", Username: "admin"}),
+			fields:  map[string]string{"url": "x", "username": "admin"},
+			want:    "s3cret\nusername: admin\nurl: x\n",
+		},
+		{name: "invalid compose", content: composeContent(SecretParts{Password: "s3cret", Username: "a\nb"})},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := writtenSecret(tc.content, tc.fields, tc.remove); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
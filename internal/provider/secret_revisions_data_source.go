@@ -0,0 +1,167 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &SecretRevisionsDataSource{}
+	_ datasource.DataSourceWithConfigure = &SecretRevisionsDataSource{}
+)
+
+// SecretRevisionsDataSource lists the revisions of a secret without its values.
+type SecretRevisionsDataSource struct {
+	client *GopassClient
+}
+
+// SecretRevisionsDataSourceModel describes the data source data model.
+type SecretRevisionsDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	Path      types.String `tfsdk:"path"`
+	Revisions types.List   `tfsdk:"revisions"`
+	Count     types.Int64  `tfsdk:"count"`
+	Latest    types.String `tfsdk:"latest"`
+	Previous  types.String `tfsdk:"previous"`
+}
+
+// NewSecretRevisionsDataSource creates a new instance.
+func NewSecretRevisionsDataSource() datasource.DataSource {
+	return &SecretRevisionsDataSource{}
+}
+
+func (d *SecretRevisionsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_revisions"
+}
+
+func (d *SecretRevisionsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the revisions of a secret, latest first, without its values.",
+		MarkdownDescription: `
+Lists the revisions of a secret, latest first, like ` + "`gopass history`" + `. In git-backed
+stores the revisions are commit hashes, which can be passed as ` + "`revision`" + ` to the
+` + "`gopass_secret`" + ` ephemeral resource to read the secret as it was. No values are read.
+
+A missing secret is an error, as it has no revisions to list.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_secret_revisions" "db" {
+  path = "prod/db/password"
+
+  lifecycle {
+    postcondition {
+      condition     = self.count <= 50
+      error_message = "prod/db/password has too many revisions, purge its history"
+    }
+  }
+}
+
+ephemeral "gopass_secret" "db_previous" {
+  path     = "prod/db/password"
+  revision = data.gopass_secret_revisions.db.previous
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path of the secret (same as path attribute).",
+				Computed:    true,
+			},
+			"path": schema.StringAttribute{
+				Description:         "Path of the secret in the gopass store (e.g., 'prod/db/password').",
+				MarkdownDescription: "Path of the secret in the gopass store (e.g., `prod/db/password`).",
+				Required:            true,
+				Validators:          []validator.String{validSecretPath()},
+			},
+			"revisions": schema.ListAttribute{
+				Description: "Revisions of the secret, latest first. Commit hashes in git-backed stores.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"count": schema.Int64Attribute{
+				Description: "Number of revisions of the secret.",
+				Computed:    true,
+			},
+			"latest": schema.StringAttribute{
+				Description: "The latest revision, i.e. the first element of revisions.",
+				Computed:    true,
+			},
+			"previous": schema.StringAttribute{
+				Description: "The revision before the latest one, i.e. the second element of revisions. " +
+					"Null if the secret has a single revision.",
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (d *SecretRevisionsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *SecretRevisionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	var data SecretRevisionsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	revisions, err := d.client.ListRevisions(ctx, secretPath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to list secret revisions",
+			errorDetail(err, fmt.Sprintf("Could not list the revisions of the secret at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Read gopass secret revisions", map[string]interface{}{
+		"path":  secretPath,
+		"count": len(revisions),
+	})
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, revisions)
+	resp.Diagnostics.Append(diags...)
+
+	data.ID = data.Path
+	data.Revisions = list
+	data.Count = types.Int64Value(int64(len(revisions)))
+	data.Latest = types.StringValue(revisions[0])
+	data.Previous = types.StringNull()
+	if len(revisions) > 1 {
+		data.Previous = types.StringValue(revisions[1])
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
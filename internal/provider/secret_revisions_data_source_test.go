@@ -0,0 +1,157 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readSecretRevisions runs Read on the secret revisions data source for path.
+func readSecretRevisions(t *testing.T, client *GopassClient, path string) (*datasource.ReadResponse, SecretRevisionsDataSourceModel) {
+	t.Helper()
+
+	d := &SecretRevisionsDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw: schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path": tftypes.NewValue(tftypes.String, path),
+			}),
+		},
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data SecretRevisionsDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func TestSecretRevisionsDataSource_Metadata(t *testing.T) {
+	d := NewSecretRevisionsDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secret_revisions" {
+		t.Errorf("expected type name 'gopass_secret_revisions', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretRevisionsDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &SecretRevisionsDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestSecretRevisionsDataSource_Read(t *testing.T) {
+	tests := []struct {
+		name         string
+		revisions    []string
+		wantPrevious string
+	}{
+		{name: "history", revisions: []string{"c3", "c2", "c1"}, wantPrevious: "c2"},
+		{name: "single revision", revisions: []string{"c1"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"prod/db": "s3cret"})
+			store.revisions["prod/db"] = tc.revisions
+			client := NewGopassClient("")
+			client.store = store
+
+			resp, data := readSecretRevisions(t, client, "prod/db")
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var revisions []string
+			data.Revisions.ElementsAs(context.Background(), &revisions, false)
+			if !reflect.DeepEqual(revisions, tc.revisions) {
+				t.Errorf("expected revisions %v, got %v", tc.revisions, revisions)
+			}
+			if data.ID.ValueString() != "prod/db" {
+				t.Errorf("expected id 'prod/db', got %q", data.ID.ValueString())
+			}
+			if data.Count.ValueInt64() != int64(len(tc.revisions)) {
+				t.Errorf("expected count %d, got %d", len(tc.revisions), data.Count.ValueInt64())
+			}
+			if data.Latest.ValueString() != tc.revisions[0] {
+				t.Errorf("expected latest %q, got %q", tc.revisions[0], data.Latest.ValueString())
+			}
+			if tc.wantPrevious == "" && !data.Previous.IsNull() {
+				t.Errorf("expected null previous, got %v", data.Previous)
+			}
+			if data.Previous.ValueString() != tc.wantPrevious {
+				t.Errorf("expected previous %q, got %q", tc.wantPrevious, data.Previous.ValueString())
+			}
+		})
+	}
+}
+
+func TestSecretRevisionsDataSource_Read_Error(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = newMockStore()
+
+	resp, _ := readSecretRevisions(t, client, "prod/db")
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for a secret without revisions")
+	}
+	if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, "failed to list revisions") {
+		t.Errorf("unexpected detail %q", detail)
+	}
+}
+
+func TestSecretRevisionsDataSource_Read_InvalidConfig(t *testing.T) {
+	d := &SecretRevisionsDataSource{client: NewGopassClient("")}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
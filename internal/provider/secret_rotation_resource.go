@@ -0,0 +1,395 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64default"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                   = &SecretRotationResource{}
+	_ resource.ResourceWithConfigure      = &SecretRotationResource{}
+	_ resource.ResourceWithValidateConfig = &SecretRotationResource{}
+	_ resource.ResourceWithModifyPlan     = &SecretRotationResource{}
+)
+
+// rotationKey is the private state key holding the rotationRecord of gopass_secret_rotation.
+const rotationKey = "rotation"
+
+// timeNow returns the current time.
+var timeNow = time.Now // injectable for testing
+
+// rotationRecord is kept in resource private state and tells when the password was last generated.
+type rotationRecord struct {
+	RotatedAt string `json:"rotated_at"`
+}
+
+// SecretRotationResource generates a password into gopass and generates a new one
+// once the rotation interval has elapsed.
+type SecretRotationResource struct {
+	client *GopassClient
+}
+
+// SecretRotationResourceModel describes the resource data model.
+type SecretRotationResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Path           types.String `tfsdk:"path"`
+	RotationDays   types.Int64  `tfsdk:"rotation_days"`
+	Length         types.Int64  `tfsdk:"length"`
+	Symbols        types.Bool   `tfsdk:"symbols"`
+	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
+	RotatedAt      types.String `tfsdk:"rotated_at"`
+}
+
+// NewSecretRotationResource creates a new instance.
+func NewSecretRotationResource() resource.Resource {
+	return &SecretRotationResource{}
+}
+
+func (r *SecretRotationResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_rotation"
+}
+
+func (r *SecretRotationResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Generates a password into gopass and plans a new one every rotation_days days. " +
+			"The password is never stored in Terraform state.",
+		MarkdownDescription: `
+Generates a password into gopass and plans a new one every ` + "`rotation_days`" + ` days, like
+` + "`time_rotating`" + ` combined with ` + "`generate_if_missing`" + `, but without a second resource.
+
+The time of the last generation is kept in private resource state. Once the interval has elapsed,
+the next plan shows ` + "`rotated_at`" + ` as known after apply, and the apply writes a fresh password to the
+same path. Only the password line is replaced, so fields such as ` + "`username`" + ` survive rotations.
+Consumers can react to a rotation with ` + "`replace_triggered_by`" + ` on ` + "`rotated_at`" + `.
+Changing ` + "`length`" + ` or ` + "`symbols`" + ` also generates a new password.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_secret_rotation" "db" {
+  path          = "infrastructure/db/app"
+  rotation_days = 90
+  length        = 40
+}
+
+ephemeral "gopass_secret" "db" {
+  path = gopass_secret_rotation.db.path
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path of the secret (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Path in the gopass store where the password will be written.",
+				MarkdownDescription: "Path in the gopass store where the password will be written (e.g., `infrastructure/db/app`).",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"rotation_days": schema.Int64Attribute{
+				Description:         "Number of days after which a new password is generated.",
+				MarkdownDescription: "Number of days after which a new password is generated. Must be at least `1`.",
+				Required:            true,
+			},
+			"length": schema.Int64Attribute{
+				Description:         "Length of the generated password. Defaults to 32.",
+				MarkdownDescription: "Length of the generated password. Defaults to `32`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             int64default.StaticInt64(defaultGenerateLength),
+			},
+			"symbols": schema.BoolAttribute{
+				Description:         "Whether the generated password includes symbols. Defaults to true.",
+				MarkdownDescription: "Whether the generated password includes symbols. Defaults to `true`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description:         "Whether to delete the secret from gopass when the resource is destroyed. Defaults to true.",
+				MarkdownDescription: "Whether to delete the secret from gopass when the resource is destroyed. Defaults to `true`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"rotated_at": schema.StringAttribute{
+				Description: "Time of the last password generation (RFC 3339). " +
+					"Unknown in a plan that rotates the password.",
+				MarkdownDescription: "Time of the last password generation (RFC 3339). " +
+					"Unknown in a plan that rotates the password.",
+				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *SecretRotationResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretRotationResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config SecretRotationResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, attr := range []struct {
+		name  string
+		value types.Int64
+	}{{"rotation_days", config.RotationDays}, {"length", config.Length}} {
+		if !attr.value.IsNull() && !attr.value.IsUnknown() && attr.value.ValueInt64() < 1 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attr.name),
+				"Invalid "+attr.name,
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("%s must be at least 1, got %d.", attr.name, attr.value.ValueInt64())),
+			)
+		}
+	}
+}
+
+// ModifyPlan plans a new password when the rotation interval has elapsed or the password
+// settings changed. The rotation happens in place: replacing the resource would delete the
+// secret before (or, with create_before_destroy, after) writing the new password.
+//
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretRotationResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	ctx = r.client.logContext(ctx)
+
+	// Nothing to rotate on create or destroy
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var plan, state SecretRotationResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || plan.RotationDays.IsUnknown() {
+		return
+	}
+
+	var reason string
+	switch {
+	case !plan.Length.Equal(state.Length) || !plan.Symbols.Equal(state.Symbols):
+		reason = "password settings changed"
+	case rotationDue(ctx, req.Private, state.RotatedAt, plan.RotationDays.ValueInt64()):
+		reason = "rotation interval elapsed"
+	default:
+		return
+	}
+
+	tflog.Info(ctx, "Planning password rotation", map[string]interface{}{
+		"path":   state.Path.ValueString(),
+		"reason": reason,
+	})
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("rotated_at"), types.StringUnknown())...)
+}
+
+// rotationDue reports whether more than days days have passed since the last rotation.
+// The time is read from private state, falling back to rotatedAt from state.
+// An unreadable time counts as due, so a password is never kept longer than intended.
+func rotationDue(ctx context.Context, private privateState, rotatedAt types.String, days int64) bool {
+	last := rotatedAt.ValueString()
+	if data, _ := private.GetKey(ctx, rotationKey); data != nil {
+		var record rotationRecord
+		if err := json.Unmarshal(data, &record); err == nil {
+			last = record.RotatedAt
+		}
+	}
+
+	t, err := time.Parse(time.RFC3339, last)
+	if err != nil {
+		tflog.Warn(ctx, "Unknown time of last rotation, rotating now", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return true
+	}
+	return !timeNow().Before(t.Add(time.Duration(days) * 24 * time.Hour))
+}
+
+// rotate writes a freshly generated password to the secret of data and records the time.
+func (r *SecretRotationResource) rotate(ctx context.Context, data *SecretRotationResourceModel, private privateState) error {
+	secretPath := data.Path.ValueString()
+
+	password, err := r.client.GeneratePassword(int(data.Length.ValueInt64()), data.Symbols.ValueBool())
+	if err != nil {
+		return err
+	}
+	if err := r.client.SetSecretPassword(ctx, secretPath, password); err != nil {
+		return err
+	}
+
+	rotatedAt := timeNow().UTC().Format(time.RFC3339)
+	record, _ := json.Marshal(rotationRecord{RotatedAt: rotatedAt}) //nolint:errcheck // a struct of strings always marshals
+	if diags := private.SetKey(ctx, rotationKey, record); diags.HasError() {
+		tflog.Warn(ctx, "Could not record rotation time", map[string]interface{}{
+			"path": secretPath,
+		})
+	}
+
+	data.ID = data.Path
+	data.RotatedAt = types.StringValue(rotatedAt)
+
+	tflog.Info(ctx, "Rotated gopass password", map[string]interface{}{
+		"path":       secretPath,
+		"rotated_at": rotatedAt,
+	})
+	return nil
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretRotationResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretRotationResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.rotate(ctx, &data, resp.Private); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to create rotated secret",
+			errorDetail(err, fmt.Sprintf("Could not write generated password to gopass at %q: %s", data.Path.ValueString(), err.Error())),
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretRotationResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretRotationResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	exists, err := r.client.SecretExists(ctx, secretPath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read rotated secret",
+			errorDetail(err, fmt.Sprintf("Could not check secret in gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	if !exists {
+		// Secret was deleted outside of Terraform; the next apply generates a new one
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update generates a new password if ModifyPlan planned a rotation; other changes only update state.
+//
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretRotationResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretRotationResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.RotatedAt.IsUnknown() {
+		if err := r.rotate(ctx, &data, resp.Private); err != nil {
+			resp.Diagnostics.AddError(
+				"Failed to rotate secret",
+				errorDetail(err, fmt.Sprintf("Could not write generated password to gopass at %q: %s", data.Path.ValueString(), err.Error())),
+			)
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretRotationResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretRotationResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	secretPath := data.Path.ValueString()
+
+	if !data.DeleteOnRemove.ValueBool() {
+		tflog.Info(ctx, "Keeping gopass secret (delete_on_remove=false)", map[string]interface{}{
+			"path": secretPath,
+		})
+		return
+	}
+
+	if err := r.client.RemoveSecret(ctx, secretPath); err != nil && !isNotFoundError(err) {
+		resp.Diagnostics.AddError(
+			"Failed to remove secret",
+			errorDetail(err, fmt.Sprintf("Could not remove secret from gopass at %q: %s", secretPath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass secret", map[string]interface{}{
+		"path": secretPath,
+	})
+}
@@ -0,0 +1,482 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// testRotationNow is the fixed current time of rotation tests.
+var testRotationNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// rotationTestSetup stops the clock at testRotationNow and returns a rotation resource
+// whose generated passwords are "generated!" with symbols and "generated" without.
+func rotationTestSetup(t *testing.T) (*SecretRotationResource, *mockStore, resource.SchemaResponse) {
+	t.Helper()
+
+	now := timeNow
+	timeNow = func() time.Time { return testRotationNow }
+	t.Cleanup(func() { timeNow = now })
+
+	store := newMockStore()
+	client := clientWith(store)
+	client.pwgen = func(length int, symbols bool) (string, error) {
+		if symbols {
+			return "generated!", nil
+		}
+		return "generated", nil
+	}
+	r, schemaResp := testResource(&SecretRotationResource{client: client})
+	return r, store, schemaResp
+}
+
+func rotationValue(schemaResp resource.SchemaResponse, days, length int64, rotatedAt any) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":               tftypes.NewValue(tftypes.String, "db/app"),
+		"path":             tftypes.NewValue(tftypes.String, "db/app"),
+		"rotation_days":    tftypes.NewValue(tftypes.Number, days),
+		"length":           tftypes.NewValue(tftypes.Number, length),
+		"symbols":          tftypes.NewValue(tftypes.Bool, true),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+		"rotated_at":       tftypes.NewValue(tftypes.String, rotatedAt),
+	})
+}
+
+// failingPrivateState is a privateState that cannot store anything.
+type failingPrivateState struct{ rawPrivateState }
+
+func (p failingPrivateState) SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics {
+	var diags diag.Diagnostics
+	diags.AddError("private state unavailable", "")
+	return diags
+}
+
+func TestSecretRotationResource_Metadata(t *testing.T) {
+	r := NewSecretRotationResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secret_rotation" {
+		t.Errorf("expected 'gopass_secret_rotation', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretRotationResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &SecretRotationResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &SecretRotationResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestSecretRotationResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    any
+		length  any
+		invalid bool
+		wantErr string
+	}{
+		{name: "valid", days: 90, length: 32},
+		{name: "unknown days", days: tftypes.UnknownValue, length: nil},
+		{name: "zero days", days: 0, length: nil, wantErr: "Invalid rotation_days"},
+		{name: "zero length", days: 30, length: 0, wantErr: "Invalid length"},
+		{name: "invalid config", invalid: true, wantErr: "Value Conversion Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, schemaResp := rotationTestSetup(t)
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":          tftypes.NewValue(tftypes.String, "db/app"),
+				"rotation_days": tftypes.NewValue(tftypes.Number, tc.days),
+				"length":        tftypes.NewValue(tftypes.Number, tc.length),
+			})
+			if tc.invalid {
+				raw = invalidRaw
+			}
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestRotationDue(t *testing.T) {
+	now := timeNow
+	timeNow = func() time.Time { return testRotationNow }
+	t.Cleanup(func() { timeNow = now })
+
+	tests := []struct {
+		name      string
+		private   privateState
+		rotatedAt types.String
+		days      int64
+		want      bool
+	}{
+		{name: "recent record", private: rawPrivateState(`{"rotated_at":"2026-02-20T12:00:00Z"}`), days: 30},
+		{name: "old record", private: rawPrivateState(`{"rotated_at":"2025-12-01T12:00:00Z"}`), days: 30, want: true},
+		{name: "exactly due", private: rawPrivateState(`{"rotated_at":"2026-02-22T12:00:00Z"}`), days: 7, want: true},
+		{
+			name:      "record wins over state",
+			private:   rawPrivateState(`{"rotated_at":"2026-02-28T12:00:00Z"}`),
+			rotatedAt: types.StringValue("2020-01-01T00:00:00Z"),
+			days:      30,
+		},
+		{name: "state fallback", private: rawPrivateState(nil), rotatedAt: types.StringValue("2026-02-28T12:00:00Z"), days: 30},
+		{name: "undecodable record", private: rawPrivateState("{"), rotatedAt: types.StringValue("2025-01-01T00:00:00Z"), days: 30, want: true},
+		{name: "unknown time", private: rawPrivateState(nil), rotatedAt: types.StringNull(), days: 30, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rotationDue(context.Background(), tc.private, tc.rotatedAt, tc.days); got != tc.want {
+				t.Errorf("rotationDue() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretRotationResource_ModifyPlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      func(resource.SchemaResponse) tftypes.Value
+		plan       func(resource.SchemaResponse) tftypes.Value
+		wantRotate bool
+		wantErr    bool
+	}{
+		{
+			// Nothing is planned for a new resource, even with an overdue time
+			name:  "create",
+			state: func(s resource.SchemaResponse) tftypes.Value { return schemaNullValue(s.Schema) },
+			plan:  func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2020-01-01T00:00:00Z") },
+		},
+		{
+			name:  "destroy",
+			state: func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2020-01-01T00:00:00Z") },
+			plan:  func(s resource.SchemaResponse) tftypes.Value { return schemaNullValue(s.Schema) },
+		},
+		{
+			name:  "not due",
+			state: func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2026-02-20T12:00:00Z") },
+			plan:  func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2026-02-20T12:00:00Z") },
+		},
+		{
+			name:       "due",
+			state:      func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2026-01-01T12:00:00Z") },
+			plan:       func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2026-01-01T12:00:00Z") },
+			wantRotate: true,
+		},
+		{
+			name:       "shorter interval makes it due",
+			state:      func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 90, 32, "2026-02-20T12:00:00Z") },
+			plan:       func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 1, 32, "2026-02-20T12:00:00Z") },
+			wantRotate: true,
+		},
+		{
+			name:       "length changed",
+			state:      func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2026-02-28T12:00:00Z") },
+			plan:       func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 64, "2026-02-28T12:00:00Z") },
+			wantRotate: true,
+		},
+		{
+			name:  "unknown interval",
+			state: func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2020-01-01T00:00:00Z") },
+			plan: func(s resource.SchemaResponse) tftypes.Value {
+				return schemaObjectValue(s.Schema, map[string]tftypes.Value{
+					"path":          tftypes.NewValue(tftypes.String, "db/app"),
+					"rotation_days": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue),
+					"length":        tftypes.NewValue(tftypes.Number, 32),
+					"symbols":       tftypes.NewValue(tftypes.Bool, true),
+					"rotated_at":    tftypes.NewValue(tftypes.String, "2020-01-01T00:00:00Z"),
+				})
+			},
+		},
+		{
+			name:    "invalid state",
+			state:   func(s resource.SchemaResponse) tftypes.Value { return invalidRaw },
+			plan:    func(s resource.SchemaResponse) tftypes.Value { return rotationValue(s, 30, 32, "2026-02-28T12:00:00Z") },
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, schemaResp := rotationTestSetup(t)
+			plan := tc.plan(schemaResp)
+
+			req := resource.ModifyPlanRequest{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: tc.state(schemaResp)},
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+			}
+			resp := &resource.ModifyPlanResponse{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}
+			r.ModifyPlan(context.Background(), req, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr || resp.Plan.Raw.IsNull() {
+				return
+			}
+			var got SecretRotationResourceModel
+			resp.Plan.Get(context.Background(), &got)
+			if rotates := got.RotatedAt.IsUnknown(); rotates != tc.wantRotate {
+				t.Errorf("expected rotation=%v, got rotated_at %v", tc.wantRotate, got.RotatedAt)
+			}
+		})
+	}
+}
+
+func TestSecretRotationResource_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		pwgenErr bool
+		setErr   bool
+		invalid  bool
+		wantErr  bool
+	}{
+		{name: "generates password"},
+		{name: "generator fails", pwgenErr: true, wantErr: true},
+		{name: "write fails", setErr: true, wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, store, schemaResp := rotationTestSetup(t)
+			if tc.pwgenErr {
+				r.client.pwgen = func(int, bool) (string, error) { return "", errors.New("max tries exceeded") }
+			}
+			if tc.setErr {
+				store.shouldFail = true
+				store.failMsg = "permission denied"
+			}
+			plan := rotationValue(schemaResp, 30, 32, tftypes.UnknownValue)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got := store.secrets["db/app"].Password(); got != "generated!" {
+				t.Errorf("expected generated password to be written, got %q", got)
+			}
+			var state SecretRotationResourceModel
+			resp.State.Get(context.Background(), &state)
+			if state.RotatedAt.ValueString() != "2026-03-01T12:00:00Z" || state.ID.ValueString() != "db/app" {
+				t.Errorf("unexpected state %+v", state)
+			}
+			record, _ := resp.Private.GetKey(context.Background(), rotationKey)
+			if string(record) != `{"rotated_at":"2026-03-01T12:00:00Z"}` {
+				t.Errorf("unexpected private record %s", record)
+			}
+		})
+	}
+}
+
+func TestSecretRotationResource_Rotate_PrivateStateError(t *testing.T) {
+	r, store, _ := rotationTestSetup(t)
+	data := SecretRotationResourceModel{
+		Path:    types.StringValue("db/app"),
+		Length:  types.Int64Value(32),
+		Symbols: types.BoolValue(false),
+	}
+
+	if err := r.rotate(context.Background(), &data, failingPrivateState{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.secrets["db/app"].Password() != "generated" || data.RotatedAt.IsNull() {
+		t.Errorf("expected rotation despite private state error, got %+v", data)
+	}
+}
+
+func TestSecretRotationResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		exists      bool
+		fail        bool
+		invalid     bool
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "exists", exists: true},
+		{name: "deleted externally", wantRemoved: true},
+		{name: "store fails", fail: true, wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, store, schemaResp := rotationTestSetup(t)
+			if tc.exists {
+				store.secrets["db/app"] = newMockSecret("old")
+			}
+			if tc.fail {
+				store.shouldFail = true
+				store.failMsg = "gpg: decryption failed"
+			}
+			raw := rotationValue(schemaResp, 30, 32, "2026-02-20T12:00:00Z")
+			if tc.invalid {
+				raw = invalidRaw
+			}
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr && resp.State.Raw.IsNull() != tc.wantRemoved {
+				t.Errorf("expected removed=%v, got %v", tc.wantRemoved, resp.State.Raw)
+			}
+		})
+	}
+}
+
+func TestSecretRotationResource_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		rotatedAt  any
+		fail       bool
+		invalid    bool
+		wantWrite  bool
+		wantRotate string
+		wantErr    bool
+	}{
+		{name: "rotation planned", rotatedAt: tftypes.UnknownValue, wantWrite: true, wantRotate: "2026-03-01T12:00:00Z"},
+		{name: "interval changed only", rotatedAt: "2026-02-20T12:00:00Z", wantRotate: "2026-02-20T12:00:00Z"},
+		{name: "write fails", rotatedAt: tftypes.UnknownValue, fail: true, wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, store, schemaResp := rotationTestSetup(t)
+			store.secrets["db/app"] = newMockSecret("old")
+			if tc.fail {
+				store.shouldFail = true
+				store.failMsg = "permission denied"
+			}
+			plan := rotationValue(schemaResp, 60, 32, tc.rotatedAt)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := withPrivateData(&resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: rotationValue(schemaResp, 30, 32, "2026-02-20T12:00:00Z")},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if written := store.secrets["db/app"].Password() != "old"; written != tc.wantWrite {
+				t.Errorf("expected write=%v, got password %q", tc.wantWrite, store.secrets["db/app"].Password())
+			}
+			var state SecretRotationResourceModel
+			resp.State.Get(context.Background(), &state)
+			if state.RotatedAt.ValueString() != tc.wantRotate || state.RotationDays.ValueInt64() != 60 {
+				t.Errorf("unexpected state %+v", state)
+			}
+		})
+	}
+}
+
+func TestSecretRotationResource_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		deleteOnRemove bool
+		exists         bool
+		fail           bool
+		invalid        bool
+		wantRemaining  bool
+		wantErr        bool
+	}{
+		{name: "removes secret", deleteOnRemove: true, exists: true},
+		{name: "keeps secret", exists: true, wantRemaining: true},
+		{name: "already deleted", deleteOnRemove: true},
+		{name: "store fails", deleteOnRemove: true, exists: true, fail: true, wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, store, schemaResp := rotationTestSetup(t)
+			if tc.exists {
+				store.secrets["db/app"] = newMockSecret("old")
+			}
+			if tc.fail {
+				store.shouldFail = true
+				store.failMsg = "permission denied"
+			}
+			state := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":             tftypes.NewValue(tftypes.String, "db/app"),
+				"delete_on_remove": tftypes.NewValue(tftypes.Bool, tc.deleteOnRemove),
+			})
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr || !tc.exists {
+				return
+			}
+			if _, remaining := store.secrets["db/app"]; remaining != tc.wantRemaining {
+				t.Errorf("expected remaining=%v", tc.wantRemaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ ephemeral.EphemeralResource                   = &SecretTreeEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithConfigure      = &SecretTreeEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithValidateConfig = &SecretTreeEphemeralResource{}
+	_ ephemeral.EphemeralResourceWithClose          = &SecretTreeEphemeralResource{}
+)
+
+// SecretTreeEphemeralResource reads every secret of a subtree with its body and fields, e.g.
+// to copy a whole tree into another secret manager.
+type SecretTreeEphemeralResource struct {
+	client *GopassClient
+}
+
+// SecretTreeModel describes the data model.
+type SecretTreeModel struct {
+	Path        types.String `tfsdk:"path"`
+	MaxDepth    types.Int64  `tfsdk:"max_depth"`
+	Include     types.List   `tfsdk:"include"`
+	Exclude     types.List   `tfsdk:"exclude"`
+	FailOnError types.Bool   `tfsdk:"fail_on_error"`
+	Timeouts    types.Object `tfsdk:"timeouts"`
+	Secrets     types.Map    `tfsdk:"secrets"`
+	Keys        types.List   `tfsdk:"keys"`
+}
+
+// SecretTreeEntryModel describes one secret in the secrets attribute.
+type SecretTreeEntryModel struct {
+	Password string            `tfsdk:"password"`
+	Fields   map[string]string `tfsdk:"fields"`
+	Body     string            `tfsdk:"body"`
+}
+
+// secretTreeEntryAttrTypes describes the secrets nested attribute.
+var secretTreeEntryAttrTypes = map[string]attr.Type{
+	"password": types.StringType,
+	"fields":   types.MapType{ElemType: types.StringType},
+	"body":     types.StringType,
+}
+
+// NewSecretTreeEphemeralResource creates a new instance.
+func NewSecretTreeEphemeralResource() ephemeral.EphemeralResource {
+	return &SecretTreeEphemeralResource{}
+}
+
+func (r *SecretTreeEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_tree"
+}
+
+func (r *SecretTreeEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reads every secret under a path with its password, fields and body.",
+		MarkdownDescription: `
+Reads every secret under a path with its password, fields and body, keyed by path relative to
+` + "`path`" + `. Unlike ` + "`gopass_env`" + `, which only returns passwords, this keeps whole secrets intact,
+e.g. for migration tooling that copies a tree into Vault or AWS SSM.
+
+Secrets are selected like with the ` + "`gopass_env`" + ` ephemeral resource; keys are never renamed.
+
+## Example Usage
+
+` + "```hcl" + `
+ephemeral "gopass_secret_tree" "legacy" {
+  path = "infra/legacy"
+}
+
+# Copy the whole tree into one Vault secret, keyed by relative path
+resource "vault_kv_secret_v2" "legacy" {
+  mount                = "secret"
+  name                 = "legacy"
+  data_json_wo         = jsonencode(ephemeral.gopass_secret_tree.legacy.secrets)
+  data_json_wo_version = 1
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				Description:         "Path prefix in the gopass store (e.g., 'infra/legacy').",
+				MarkdownDescription: "Path prefix in the gopass store (e.g., `infra/legacy`).",
+				Required:            true,
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"max_depth": schema.Int64Attribute{
+				Description: "Maximum number of levels below path to read, e.g. 1 for the immediate children only. " +
+					"Defaults to no limit.",
+				MarkdownDescription: "Maximum number of levels below `path` to read, e.g. `1` for the immediate children only. " +
+					"Defaults to no limit.",
+				Optional: true,
+			},
+			"include": schema.ListAttribute{
+				Description: "Glob patterns selecting which secrets to return, matched against the key relative to path. " +
+					"'*' matches within a path segment, '**' matches any number of segments. Defaults to all secrets.",
+				MarkdownDescription: "Glob patterns selecting which secrets to return, matched against the key relative to `path`. " +
+					"`*` matches within a path segment, `**` matches any number of segments. Defaults to all secrets.",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"exclude": schema.ListAttribute{
+				Description:         "Glob patterns of secrets to leave out. Applied after include.",
+				MarkdownDescription: "Glob patterns of secrets to leave out. Applied after `include`.",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					validGlobs(),
+				},
+			},
+			"fail_on_error": schema.BoolAttribute{
+				Description: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to false.",
+				MarkdownDescription: "Fail if any selected secret cannot be read, instead of leaving it out with a warning. " +
+					"Defaults to `false`.",
+				Optional: true,
+			},
+			"timeouts": ephemeralTimeoutsAttribute(),
+			"secrets": schema.MapNestedAttribute{
+				Description:         "The secrets by path relative to path, each with its password, fields and body.",
+				MarkdownDescription: "The secrets by path relative to `path`, each with its `password`, `fields` and `body`.",
+				Computed:            true,
+				Sensitive:           true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"password": schema.StringAttribute{
+							Description:         "The first line of the secret; the password of a chunked secret is joined from its parts.",
+							MarkdownDescription: "The first line of the secret; the password of a chunked secret is joined from its parts.",
+							Computed:            true,
+							Sensitive:           true,
+						},
+						"fields": schema.MapAttribute{
+							Description:         "The key-value fields of the secret; of repeated keys, the first value.",
+							MarkdownDescription: "The `key: value` fields of the secret; of repeated keys, the first value.",
+							ElementType:         types.StringType,
+							Computed:            true,
+							Sensitive:           true,
+						},
+						"body": schema.StringAttribute{
+							Description:         "The lines after the password that are no key-value fields.",
+							MarkdownDescription: "The lines after the password that are no `key: value` fields.",
+							Computed:            true,
+							Sensitive:           true,
+						},
+					},
+				},
+			},
+			"keys": schema.ListAttribute{
+				Description:         "The keys of secrets, sorted. Keys are not secret, so they can be used e.g. in outputs.",
+				MarkdownDescription: "The keys of `secrets`, sorted. Keys are not secret, so they can be used e.g. in outputs.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *SecretTreeEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Provider Data",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+func (r *SecretTreeEphemeralResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data SecretTreeModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	validatePrefixConfig(&resp.Diagnostics, data.MaxDepth, types.StringNull(), "")
+}
+
+func (r *SecretTreeEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretTreeModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+	maxDepth := int(data.MaxDepth.ValueInt64())
+
+	timeout, err := operationTimeout(data.Timeouts, timeoutOpen)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tflog.Debug(ctx, "Reading secret tree from gopass", map[string]interface{}{
+		"path":      basePath,
+		"max_depth": maxDepth,
+	})
+
+	contents, failures, err := r.client.ReadSecretTree(ctx, basePath, maxDepth)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read secrets",
+			errorDetail(err, fmt.Sprintf("Could not read secrets under path %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	var opts envKeyOptions
+	resp.Diagnostics.Append(data.Include.ElementsAs(ctx, &opts.include, false)...)
+	resp.Diagnostics.Append(data.Exclude.ElementsAs(ctx, &opts.exclude, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	entries, keys, err := selectSecretTree(contents, opts)
+	if err == nil {
+		failures, err = selectEnvFailures(failures, opts)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Invalid key options",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("Could not select secrets under path %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	if !reportPrefixRead(&resp.Diagnostics, basePath, failures, len(entries), data.FailOnError.ValueBool(), "secrets") {
+		return
+	}
+
+	secretsMap, diags := types.MapValueFrom(ctx, types.ObjectType{AttrTypes: secretTreeEntryAttrTypes}, entries)
+	resp.Diagnostics.Append(diags...)
+	data.Secrets = secretsMap
+	keyList, diags := types.ListValueFrom(ctx, types.StringType, keys)
+	resp.Diagnostics.Append(diags...)
+	data.Keys = keyList
+
+	// Set result - NEVER written to state
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+
+	openCloseState(ctx, r.client, resp, &closeState{Prefixes: []string{basePath}})
+
+	tflog.Debug(ctx, "Successfully read secret tree from gopass", map[string]interface{}{
+		"path":  basePath,
+		"count": len(keys),
+	})
+}
+
+// Close is called once Terraform no longer needs the secrets. A caching client still holds
+// the decrypted secrets of the tree, which are dropped.
+func (r *SecretTreeEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	ctx = r.client.logContext(ctx)
+
+	closeSecrets(ctx, r.client, req, resp)
+
+	tflog.Debug(ctx, "Closed ephemeral gopass secret tree")
+}
+
+// selectSecretTree returns the contents whose keys opts selects, and their keys in lexical order.
+func selectSecretTree(contents map[string]*SecretContent, opts envKeyOptions) (map[string]SecretTreeEntryModel, []string, error) {
+	entries := make(map[string]SecretTreeEntryModel, len(contents))
+	keys := make([]string, 0, len(contents))
+	for key, content := range contents {
+		selected, err := opts.selects(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !selected {
+			continue
+		}
+		entries[key] = SecretTreeEntryModel{Password: content.Password, Fields: content.Fields, Body: content.Body}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return entries, keys, nil
+}
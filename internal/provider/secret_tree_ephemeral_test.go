@@ -0,0 +1,351 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gopasspw/gopass/pkg/gopass"
+	"github.com/gopasspw/gopass/pkg/gopass/secrets"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// openSecretTree opens a gopass_secret_tree with the given configuration and returns the
+// response, the result and its secrets.
+func openSecretTree(t *testing.T, r *SecretTreeEphemeralResource, values map[string]tftypes.Value) (*ephemeral.OpenResponse, SecretTreeModel, map[string]SecretTreeEntryModel) {
+	t.Helper()
+
+	ctx := context.Background()
+	schemaResp := &ephemeral.SchemaResponse{}
+	r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+
+	resp := withPrivateData(&ephemeral.OpenResponse{
+		Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	})
+	r.Open(ctx, ephemeral.OpenRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, values)},
+	}, resp)
+
+	var result SecretTreeModel
+	var entries map[string]SecretTreeEntryModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.Result.Get(ctx, &result)...)
+		resp.Diagnostics.Append(result.Secrets.ElementsAs(ctx, &entries, false)...)
+	}
+	return resp, result, entries
+}
+
+func TestSecretTreeEphemeralResource_Metadata(t *testing.T) {
+	resp := &ephemeral.MetadataResponse{}
+	NewSecretTreeEphemeralResource().Metadata(context.Background(), ephemeral.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secret_tree" {
+		t.Errorf("expected TypeName 'gopass_secret_tree', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretTreeEphemeralResource_Schema(t *testing.T) {
+	resp := &ephemeral.SchemaResponse{}
+	NewSecretTreeEphemeralResource().Schema(context.Background(), ephemeral.SchemaRequest{}, resp)
+
+	if secretsAttr := resp.Schema.Attributes["secrets"]; !secretsAttr.IsSensitive() || !secretsAttr.IsComputed() {
+		t.Error("expected secrets to be computed and sensitive")
+	}
+	if keys := resp.Schema.Attributes["keys"]; keys.IsSensitive() {
+		t.Error("expected keys not to be sensitive")
+	}
+}
+
+func TestSecretTreeEphemeralResource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name    string
+		data    any
+		wantErr bool
+	}{
+		{name: "client", data: client},
+		{name: "nil", data: nil},
+		{name: "wrong type", data: "client", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretTreeEphemeralResource{}
+			resp := &ephemeral.ConfigureResponse{}
+			r.Configure(context.Background(), ephemeral.ConfigureRequest{ProviderData: tc.data}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr && tc.data != nil && r.client != client {
+				t.Error("expected client to be set")
+			}
+		})
+	}
+}
+
+func TestSecretTreeEphemeralResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]tftypes.Value
+		wrongConfig bool
+		wantErr     string
+	}{
+		{name: "path only", config: map[string]tftypes.Value{}},
+		{name: "max_depth", config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 2)}},
+		{name: "unknown max_depth", config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, tftypes.UnknownValue)}},
+		{name: "max_depth zero", config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 0)}, wantErr: "Invalid max_depth"},
+		{name: "invalid config", wrongConfig: true, wantErr: "Value Conversion Error"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretTreeEphemeralResource{}
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(context.Background(), ephemeral.SchemaRequest{}, schemaResp)
+
+			raw := dotenvWrongConfig()
+			if !tc.wrongConfig {
+				config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "infra/legacy")}
+				for k, v := range tc.config {
+					config[k] = v
+				}
+				raw = schemaObjectValue(schemaResp.Schema, config)
+			}
+
+			resp := &ephemeral.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), ephemeral.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretTreeEphemeralResource_Open(t *testing.T) {
+	stringList := tftypes.List{ElementType: tftypes.String}
+
+	tests := []struct {
+		name     string
+		config   map[string]tftypes.Value
+		wantKeys []string
+	}{
+		{name: "whole tree", config: map[string]tftypes.Value{}, wantKeys: []string{"db/main", "token"}},
+		{name: "max depth", config: map[string]tftypes.Value{"max_depth": tftypes.NewValue(tftypes.Number, 1)}, wantKeys: []string{"token"}},
+		{
+			name: "include and exclude",
+			config: map[string]tftypes.Value{
+				"include": tftypes.NewValue(stringList, []tftypes.Value{tftypes.NewValue(tftypes.String, "**")}),
+				"exclude": tftypes.NewValue(stringList, []tftypes.Value{tftypes.NewValue(tftypes.String, "token")}),
+			},
+			wantKeys: []string{"db/main"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			store.secrets["infra/legacy/db/main"] = secrets.ParseAKV([]byte("s3cret\nuser: admin\nhost: db.example.com\nrotated yearly\n"))
+			store.secrets["infra/legacy/token"] = newMockSecret("t0ken")
+			client := NewGopassClient("")
+			client.store = store
+			r := &SecretTreeEphemeralResource{client: client}
+
+			config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "infra/legacy")}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			resp, result, entries := openSecretTree(t, r, config)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var keys []string
+			result.Keys.ElementsAs(context.Background(), &keys, false)
+			if !reflect.DeepEqual(keys, tc.wantKeys) {
+				t.Errorf("expected keys %v, got %v", tc.wantKeys, keys)
+			}
+			if len(entries) != len(tc.wantKeys) {
+				t.Errorf("expected %d secrets, got %v", len(tc.wantKeys), entries)
+			}
+			if db, ok := entries["db/main"]; ok {
+				want := SecretTreeEntryModel{
+					Password: "s3cret",
+					Fields:   map[string]string{"user": "admin", "host": "db.example.com"},
+					Body:     "rotated yearly\n",
+				}
+				if !reflect.DeepEqual(db, want) {
+					t.Errorf("expected %+v, got %+v", want, db)
+				}
+			}
+			if token, ok := entries["token"]; ok && (token.Password != "t0ken" || len(token.Fields) != 0 || token.Body != "") {
+				t.Errorf("unexpected token %+v", token)
+			}
+		})
+	}
+}
+
+func TestSecretTreeEphemeralResource_Open_Warnings(t *testing.T) {
+	t.Run("no secrets", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.store = newMockStore()
+		r := &SecretTreeEphemeralResource{client: client}
+
+		resp, result, entries := openSecretTree(t, r, map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "infra/empty")})
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("expected a single warning, got %v", resp.Diagnostics)
+		}
+		if len(entries) != 0 || len(result.Keys.Elements()) != 0 {
+			t.Errorf("expected no secrets, got %v and %v", entries, result.Keys)
+		}
+	})
+
+	t.Run("unreadable secret left out", func(t *testing.T) {
+		store := newMockStoreWithSelectiveFailure()
+		store.secrets["infra/legacy/A"] = newMockSecret("a")
+		store.secrets["infra/legacy/B"] = newMockSecret("b")
+		store.failOnGet["infra/legacy/B"] = true
+		client := NewGopassClient("")
+		client.store = store
+		r := &SecretTreeEphemeralResource{client: client}
+
+		resp, _, entries := openSecretTree(t, r, map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "infra/legacy")})
+		if resp.Diagnostics.HasError() || resp.Diagnostics.WarningsCount() != 1 {
+			t.Fatalf("expected a single warning, got %v", resp.Diagnostics)
+		}
+		if _, ok := entries["B"]; ok || entries["A"].Password != "a" {
+			t.Errorf("expected only A, got %v", entries)
+		}
+	})
+}
+
+func TestSecretTreeEphemeralResource_Open_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		store       func() gopass.Store
+		config      map[string]tftypes.Value
+		openTimeout string
+		wrongConfig bool
+		wantErr     string
+	}{
+		{
+			name: "fail on error",
+			store: func() gopass.Store {
+				store := newMockStoreWithSelectiveFailure()
+				store.secrets["infra/legacy/A"] = newMockSecret("a")
+				store.failOnGet["infra/legacy/A"] = true
+				return store
+			},
+			config:  map[string]tftypes.Value{"fail_on_error": tftypes.NewValue(tftypes.Bool, true)},
+			wantErr: "Failed to read secrets",
+		},
+		{
+			name: "store fails",
+			store: func() gopass.Store {
+				return &mockStore{shouldFail: true, failMsg: "permission denied"}
+			},
+			wantErr: "Failed to read secrets",
+		},
+		{
+			name:        "invalid timeouts",
+			openTimeout: "soon",
+			wantErr:     "Invalid timeouts",
+		},
+		{
+			name:    "unknown include",
+			config:  map[string]tftypes.Value{"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, tftypes.UnknownValue)},
+			wantErr: "Value Conversion Error",
+		},
+		{
+			name:    "invalid pattern",
+			config:  map[string]tftypes.Value{"include": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "[")})},
+			wantErr: "Invalid key options",
+		},
+		{
+			name:        "invalid config",
+			wrongConfig: true,
+			wantErr:     "Value Conversion Error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			if tc.store != nil {
+				client.store = tc.store()
+			} else {
+				client.store = storeWith(map[string]string{"infra/legacy/db": "secret"})
+			}
+			r := &SecretTreeEphemeralResource{client: client}
+
+			config := map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "infra/legacy")}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+
+			ctx := context.Background()
+			schemaResp := &ephemeral.SchemaResponse{}
+			r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+			if tc.openTimeout != "" {
+				config["timeouts"] = timeoutsRaw(schemaResp.Schema, timeoutOpen, tc.openTimeout)
+			}
+			raw := schemaObjectValue(schemaResp.Schema, config)
+			if tc.wrongConfig {
+				raw = dotenvWrongConfig()
+			}
+
+			resp := &ephemeral.OpenResponse{
+				Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.Open(ctx, ephemeral.OpenRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary(), tc.wantErr) {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretTreeEphemeralResource_Close(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{"infra/legacy/db": "s1", "infra/legacy/api/key": "s2", "other/key": "s3"})
+	client.EnableCache()
+	if _, err := client.GetSecret(ctx, "other/key"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &SecretTreeEphemeralResource{client: client}
+	openResp, _, _ := openSecretTree(t, r, map[string]tftypes.Value{"path": tftypes.NewValue(tftypes.String, "infra/legacy")})
+	if openResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", openResp.Diagnostics)
+	}
+	if n := len(client.cache.entries); n != 3 {
+		t.Fatalf("expected the tree and the other secret to be cached, got %d entries", n)
+	}
+
+	resp := &ephemeral.CloseResponse{}
+	r.Close(ctx, ephemeral.CloseRequest{Private: openResp.Private}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if _, _, ok := client.cache.get(secretCacheKey{path: "other/key", revision: "latest"}); !ok || len(client.cache.entries) != 1 {
+		t.Errorf("expected Close to release only the secrets of the tree, got %d entries", len(client.cache.entries))
+	}
+}
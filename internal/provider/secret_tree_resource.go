@@ -0,0 +1,424 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                   = &SecretTreeResource{}
+	_ resource.ResourceWithConfigure      = &SecretTreeResource{}
+	_ resource.ResourceWithValidateConfig = &SecretTreeResource{}
+	_ resource.ResourceWithImportState    = &SecretTreeResource{}
+)
+
+// SecretTreeResource owns a prefix of the store: it writes a map of relative paths to
+// secrets below it and removes the whole prefix on destroy, including secrets it did not
+// write. Its paths are written, listed and removed by the helpers of the embedded EnvResource.
+type SecretTreeResource struct {
+	EnvResource
+}
+
+// SecretTreeResourceModel describes the resource data model.
+type SecretTreeResourceModel struct {
+	ID              types.String `tfsdk:"id"`
+	Path            types.String `tfsdk:"path"`
+	ValuesWO        types.Map    `tfsdk:"values_wo"`
+	ValuesWOVersion types.Int64  `tfsdk:"values_wo_version"`
+	Paths           types.Set    `tfsdk:"paths"`
+	Timeouts        types.Object `tfsdk:"timeouts"`
+}
+
+// NewSecretTreeResource creates a new instance.
+func NewSecretTreeResource() resource.Resource {
+	return &SecretTreeResource{}
+}
+
+func (r *SecretTreeResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secret_tree"
+}
+
+func (r *SecretTreeResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Owns a prefix of the gopass store: writes a map of relative paths to secrets below it, " +
+			"and removes the whole prefix on destroy. The values are never stored in Terraform state.",
+		MarkdownDescription: `
+Owns a prefix of the gopass store: writes a map of relative paths to secrets below it, prunes
+entries removed from the map, and removes the **whole prefix** on destroy, like
+` + "`gopass rm --recursive`" + `. Use it when a team or application gets a subtree of its own.
+
+The values (` + "`values_wo`" + `) are **write-only** and never stored in state. Only the managed
+relative paths are tracked.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_secret_tree" "billing" {
+  path              = "teams/billing"
+  values_wo_version = 1
+
+  values_wo = {
+    "db/password"    = ephemeral.random_password.db.result
+    "stripe/api_key" = var.stripe_api_key
+  }
+}
+` + "```" + `
+
+## Ownership
+
+- All values are written on create and whenever ` + "`values_wo_version`" + ` changes
+- Paths that were removed from ` + "`values_wo`" + ` are deleted on that update
+- On destroy, every secret below ` + "`path`" + ` is removed, including secrets written outside of
+  Terraform. Refresh warns about such secrets. Nothing is removed if any of them matches the
+  provider's ` + "`protected_paths`" + `
+
+## Import
+
+Importing a prefix adopts all secrets below it as managed paths, without reading their values:
+
+` + "```shell" + `
+terraform import gopass_secret_tree.billing teams/billing
+` + "```" + `
+
+The first apply writes ` + "`values_wo`" + ` and removes the paths missing from it.
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The prefix (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Prefix in the gopass store owned by this resource. Each entry is written to path/RELATIVE_PATH.",
+				MarkdownDescription: "Prefix in the gopass store owned by this resource. Each entry is written to `path/RELATIVE_PATH`.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"values_wo": schema.MapAttribute{
+				Description: "Map of relative path to value to write below path. " +
+					"This is a write-only attribute - it will never be stored in state or plan files. Accepts ephemeral values.",
+				MarkdownDescription: "Map of relative path to value to write below `path`. " +
+					"This is a **write-only** attribute - it will never be stored in state or plan files. Accepts ephemeral values.",
+				ElementType: types.StringType,
+				Required:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+			},
+			"values_wo_version": schema.Int64Attribute{
+				Description: "Version number for the write-only values. Increment this to write " +
+					"changes of values_wo to gopass.",
+				MarkdownDescription: "Version number for the write-only values. **Increment this** to write " +
+					"changes of `values_wo` to gopass.",
+				Required: true,
+			},
+			"paths": schema.SetAttribute{
+				Description:         "Relative paths of the secrets managed by this resource.",
+				MarkdownDescription: "Relative paths of the secrets managed by this resource.",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"timeouts": resourceTimeoutsAttribute(),
+		},
+	}
+}
+
+// ValidateConfig rejects relative paths that would not form valid gopass paths below path.
+func (r *SecretTreeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var config SecretTreeResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(validateValueKeys(config.ValuesWO)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretTreeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretTreeResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutCreate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	tflog.Debug(ctx, "Creating gopass secret tree", map[string]interface{}{
+		"path": basePath,
+	})
+
+	// Get write-only values from config (not plan, as write-only values are only in config)
+	var config SecretTreeResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	values, diags := envValues(config.ValuesWO)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	written, err := r.writeValues(ctx, basePath, values)
+	data.ID = data.Path
+	data.Paths = envKeySet(written)
+	if err != nil {
+		// Record the paths written so far, the prefix is removed with the tainted resource anyway
+		resp.Diagnostics.AddError(
+			"Failed to create secret tree",
+			errorDetail(err, fmt.Sprintf("Could not write secrets to gopass below %q: %s", basePath, err.Error())),
+		)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretTreeResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretTreeResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutRead)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	tflog.Debug(ctx, "Reading gopass secret tree", map[string]interface{}{
+		"path": basePath,
+	})
+
+	existing, err := r.existingKeys(ctx, basePath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read secret tree",
+			errorDetail(err, fmt.Sprintf("Could not list secrets at %q: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	// Only check which managed paths still exist - we never read the values back
+	var kept, missing []string
+	for _, rel := range envKeys(data.Paths) {
+		if existing[rel] {
+			kept = append(kept, rel)
+			delete(existing, rel)
+		} else {
+			missing = append(missing, rel)
+		}
+	}
+
+	if len(kept) == 0 && len(missing) > 0 {
+		// All secrets were deleted outside of Terraform
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	if len(missing) > 0 {
+		resp.Diagnostics.AddWarning(
+			"Secrets removed outside of Terraform",
+			codedDetail(CodeDrift, fmt.Sprintf(
+				"The secrets %s below %q were removed outside of Terraform. "+
+					"Consider incrementing values_wo_version to write them again.",
+				strings.Join(missing, ", "), basePath,
+			)),
+		)
+	}
+
+	// What is left was written outside of Terraform, and is removed along with the prefix
+	if len(existing) > 0 {
+		unmanaged := make([]string, 0, len(existing))
+		for rel := range existing {
+			unmanaged = append(unmanaged, rel)
+		}
+		sort.Strings(unmanaged)
+		resp.Diagnostics.AddWarning(
+			"Unmanaged secrets in secret tree",
+			codedDetail(CodeDrift, fmt.Sprintf(
+				"The secrets %s below %q are not managed by Terraform. "+
+					"They will be removed when the secret tree is destroyed.",
+				strings.Join(unmanaged, ", "), basePath,
+			)),
+		)
+	}
+
+	data.Paths = envKeySet(kept)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretTreeResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretTreeResourceModel
+	var state SecretTreeResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutUpdate)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	data.Paths = state.Paths
+
+	// Values are only written when values_wo_version changes
+	if data.ValuesWOVersion.Equal(state.ValuesWOVersion) {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	tflog.Debug(ctx, "Updating gopass secret tree", map[string]interface{}{
+		"path":        basePath,
+		"old_version": state.ValuesWOVersion.ValueInt64(),
+		"new_version": data.ValuesWOVersion.ValueInt64(),
+	})
+
+	var config SecretTreeResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	values, diags := envValues(config.ValuesWO)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	previous := envKeys(state.Paths)
+	written, err := r.writeValues(ctx, basePath, values)
+	if err != nil {
+		// Keep tracking the previous paths, as none of them is pruned yet
+		data.Paths = envKeySet(append(previous, written...))
+		resp.Diagnostics.AddError(
+			"Failed to update secret tree",
+			errorDetail(err, fmt.Sprintf("Could not write secrets to gopass below %q: %s", basePath, err.Error())),
+		)
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+
+	// Prune paths that were removed from values_wo
+	var stale []string
+	for _, rel := range previous {
+		if _, ok := values[rel]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+	remaining, err := r.removeKeys(ctx, basePath, stale, true)
+	data.Paths = envKeySet(append(written, remaining...))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update secret tree",
+			errorDetail(err, fmt.Sprintf("Could not remove stale secrets from gopass below %q: %s", basePath, err.Error())),
+		)
+	}
+
+	tflog.Info(ctx, "Updated gopass secret tree (values_wo_version changed)", map[string]interface{}{
+		"path":    basePath,
+		"written": len(written),
+		"stale":   len(stale),
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// ImportState adopts the secrets below the prefix given as ID as managed paths.
+func (r *SecretTreeResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	paths, diags := r.importKeys(ctx, req.ID)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("paths"), envKeySet(paths))...)
+}
+
+// Delete removes the whole prefix, not only the managed paths.
+//
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *SecretTreeResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data SecretTreeResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	basePath := data.Path.ValueString()
+
+	ctx, cancel, err := withTimeout(ctx, data.Timeouts, timeoutDelete)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid timeouts", codedDetail(CodeInvalidConfig, err.Error()))
+		return
+	}
+	defer cancel()
+
+	if err := r.client.RemoveDirectory(ctx, basePath); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove secret tree",
+			errorDetail(err, fmt.Sprintf("Could not remove the secrets below %q from gopass: %s", basePath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass secret tree", map[string]interface{}{
+		"path": basePath,
+	})
+}
@@ -0,0 +1,473 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// secretTreeValue builds a gopass_secret_tree object owning teams/billing, with values keyed
+// by path below it.
+func secretTreeValue(schemaResp resource.SchemaResponse, values map[string]string, version int64, paths []string) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":                tftypes.NewValue(tftypes.String, "teams/billing"),
+		"path":              tftypes.NewValue(tftypes.String, "teams/billing"),
+		"values_wo":         stringMapValue(values),
+		"values_wo_version": tftypes.NewValue(tftypes.Number, version),
+		"paths":             stringSetValue(paths),
+	})
+}
+
+func secretTreeStatePaths(t *testing.T, state tfsdk.State) []string {
+	t.Helper()
+
+	var data SecretTreeResourceModel
+	if diags := state.Get(context.Background(), &data); diags.HasError() {
+		t.Fatalf("failed to read state: %v", diags)
+	}
+	if data.ID.ValueString() != "teams/billing" {
+		t.Errorf("expected id 'teams/billing', got %q", data.ID.ValueString())
+	}
+	return envKeys(data.Paths)
+}
+
+// treeContent returns the contents of all secrets in store by name.
+func treeContent(store *mockStore) map[string]string {
+	got := map[string]string{}
+	for name, secret := range store.secrets {
+		got[name] = strings.TrimSuffix(string(secret.Bytes()), "\n")
+	}
+	return got
+}
+
+func TestSecretTreeResource_Metadata(t *testing.T) {
+	r := NewSecretTreeResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secret_tree" {
+		t.Errorf("expected 'gopass_secret_tree', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretTreeResource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+	r := &SecretTreeResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(context.Background(), resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+}
+
+func TestSecretTreeResource_ValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		invalid bool
+		wantErr bool
+	}{
+		{name: "valid paths", values: map[string]string{"db/password": "a", "stripe/api_key": "b"}},
+		{name: "parent path", values: map[string]string{"../other": "a"}, wantErr: true},
+		{name: "invalid config", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(newMockStore())}})
+			raw := secretTreeValue(schemaResp, tc.values, 1, []string{})
+			if tc.invalid {
+				raw = invalidRaw
+			}
+
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(context.Background(), resource.ValidateConfigRequest{
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretTreeResource_Create(t *testing.T) {
+	values := map[string]string{"db/password": "hunter2", "stripe/api_key": "sk"}
+
+	tests := []struct {
+		name          string
+		values        map[string]string
+		failSet       string
+		invalid       bool
+		invalidConfig bool
+		wantPaths     []string
+		wantErr       bool
+	}{
+		{name: "writes all paths", values: values, wantPaths: []string{"db/password", "stripe/api_key"}},
+		{
+			name:      "write fails",
+			values:    values,
+			failSet:   "teams/billing/stripe/api_key",
+			wantPaths: []string{"db/password"},
+			wantErr:   true,
+		},
+		{name: "invalid plan", values: values, invalid: true, wantErr: true},
+		{name: "invalid config", values: values, invalidConfig: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &envFailStore{mockStore: newMockStore(), failSet: map[string]bool{tc.failSet: true}}
+			r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(store)}})
+			plan := secretTreeValue(schemaResp, nil, 1, nil)
+			config := secretTreeValue(schemaResp, tc.values, 1, nil)
+			if tc.invalid {
+				plan = invalidRaw
+			}
+			if tc.invalidConfig {
+				config = invalidRaw
+			}
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantPaths == nil {
+				if len(store.secrets) > 0 {
+					t.Errorf("expected nothing written, got %v", store.secrets)
+				}
+				return
+			}
+			if got := secretTreeStatePaths(t, resp.State); !reflect.DeepEqual(got, tc.wantPaths) {
+				t.Errorf("paths = %q, want %q", got, tc.wantPaths)
+			}
+			content := treeContent(store.mockStore)
+			for _, rel := range tc.wantPaths {
+				if content["teams/billing/"+rel] != values[rel] {
+					t.Errorf("expected %s=%q in gopass, got %q", rel, values[rel], content["teams/billing/"+rel])
+				}
+			}
+		})
+	}
+
+	t.Run("unknown value", func(t *testing.T) {
+		store := newMockStore()
+		r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(store)}})
+		config := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path": tftypes.NewValue(tftypes.String, "teams/billing"),
+			"values_wo": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+				"db/password": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			}),
+			"values_wo_version": tftypes.NewValue(tftypes.Number, 1),
+		})
+
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(context.Background(), resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: secretTreeValue(schemaResp, nil, 1, nil)},
+			Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+		}, resp)
+		if !resp.Diagnostics.HasError() || len(store.secrets) > 0 {
+			t.Errorf("expected an error and nothing written, got %v", resp.Diagnostics)
+		}
+	})
+}
+
+func TestSecretTreeResource_Read(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     []string
+		failList     bool
+		wantPaths    []string
+		wantWarnings int
+		wantRemoved  bool
+		wantErr      bool
+	}{
+		{name: "all present", existing: []string{"db/password", "stripe/api_key"}, wantPaths: []string{"db/password", "stripe/api_key"}},
+		{name: "some removed", existing: []string{"db/password"}, wantPaths: []string{"db/password"}, wantWarnings: 1},
+		{
+			name:         "unmanaged secrets",
+			existing:     []string{"db/password", "stripe/api_key", "notes", "db/user"},
+			wantPaths:    []string{"db/password", "stripe/api_key"},
+			wantWarnings: 1,
+		},
+		{name: "some removed and unmanaged secrets", existing: []string{"db/password", "notes"}, wantPaths: []string{"db/password"}, wantWarnings: 2},
+		{name: "all removed", wantRemoved: true},
+		{name: "list fails", failList: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newMockStore()
+			for _, rel := range tc.existing {
+				store.secrets["teams/billing/"+rel] = newMockSecret("v")
+			}
+			if tc.failList {
+				store.shouldFail = true
+				store.failMsg = "store locked"
+			}
+			r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(store)}})
+			raw := secretTreeValue(schemaResp, nil, 1, []string{"db/password", "stripe/api_key"})
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got := resp.Diagnostics.WarningsCount(); got != tc.wantWarnings {
+				t.Errorf("expected %d warnings, got %v", tc.wantWarnings, resp.Diagnostics)
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.wantRemoved {
+				t.Fatalf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !tc.wantRemoved {
+				if got := secretTreeStatePaths(t, resp.State); !reflect.DeepEqual(got, tc.wantPaths) {
+					t.Errorf("paths = %q, want %q", got, tc.wantPaths)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(newMockStore())}})
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestSecretTreeResource_Update(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       int64
+		failSet       string
+		failRemove    string
+		values        map[string]string
+		invalid       bool
+		invalidConfig bool
+		wantPaths     []string
+		wantSecrets   map[string]string
+		wantErr       bool
+	}{
+		{
+			name:        "version unchanged",
+			version:     1,
+			wantPaths:   []string{"a", "old"},
+			wantSecrets: map[string]string{"teams/billing/a": "old-a", "teams/billing/old": "old"},
+		},
+		{
+			name:        "writes and prunes",
+			version:     2,
+			wantPaths:   []string{"a", "new/b"},
+			wantSecrets: map[string]string{"teams/billing/a": "new-a", "teams/billing/new/b": "b"},
+		},
+		{
+			name:        "write fails",
+			version:     2,
+			failSet:     "teams/billing/new/b",
+			wantPaths:   []string{"a", "old"},
+			wantSecrets: map[string]string{"teams/billing/a": "new-a", "teams/billing/old": "old"},
+			wantErr:     true,
+		},
+		{
+			name:        "prune fails",
+			version:     2,
+			failRemove:  "teams/billing/old",
+			wantPaths:   []string{"a", "new/b", "old"},
+			wantSecrets: map[string]string{"teams/billing/a": "new-a", "teams/billing/new/b": "b", "teams/billing/old": "old"},
+			wantErr:     true,
+		},
+		{name: "unknown value", version: 2, values: map[string]string{}, wantErr: true},
+		{name: "invalid plan", version: 2, invalid: true, wantErr: true},
+		{name: "invalid config", version: 2, invalidConfig: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &envFailStore{
+				mockStore:  storeWith(map[string]string{"teams/billing/a": "old-a", "teams/billing/old": "old"}),
+				failSet:    map[string]bool{tc.failSet: true},
+				failRemove: map[string]bool{tc.failRemove: true},
+			}
+			r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(store)}})
+			plan := secretTreeValue(schemaResp, nil, tc.version, nil)
+			config := secretTreeValue(schemaResp, map[string]string{"a": "new-a", "new/b": "b"}, tc.version, nil)
+			if tc.values != nil {
+				config = schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+					"path": tftypes.NewValue(tftypes.String, "teams/billing"),
+					"values_wo": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+						"a": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+					}),
+					"values_wo_version": tftypes.NewValue(tftypes.Number, tc.version),
+				})
+			}
+			if tc.invalid {
+				plan = invalidRaw
+			}
+			if tc.invalidConfig {
+				config = invalidRaw
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				State:  tfsdk.State{Schema: schemaResp.Schema, Raw: secretTreeValue(schemaResp, nil, 1, []string{"a", "old"})},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantPaths == nil {
+				return
+			}
+			if got := secretTreeStatePaths(t, resp.State); !reflect.DeepEqual(got, tc.wantPaths) {
+				t.Errorf("paths = %q, want %q", got, tc.wantPaths)
+			}
+			if got := treeContent(store.mockStore); !reflect.DeepEqual(got, tc.wantSecrets) {
+				t.Errorf("secrets = %v, want %v", got, tc.wantSecrets)
+			}
+		})
+	}
+}
+
+func TestSecretTreeResource_ImportState(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		wantPaths []string
+		wantErr   string
+	}{
+		{name: "adopts children", id: "teams/billing", wantPaths: []string{"a", "db/password"}},
+		{name: "invalid id", id: "", wantErr: "Invalid import ID"},
+		{name: "no secrets", id: "teams/missing", wantErr: "Secrets not found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"teams/billing/a": "a", "teams/billing/db/password": "p", "teams/other/a": "o"})
+			r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(store)}})
+
+			resp := &resource.ImportStateResponse{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.ImportState(context.Background(), resource.ImportStateRequest{ID: tc.id}, resp)
+
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+					t.Fatalf("expected %q error, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if got := secretTreeStatePaths(t, resp.State); !reflect.DeepEqual(got, tc.wantPaths) {
+				t.Errorf("paths = %q, want %q", got, tc.wantPaths)
+			}
+		})
+	}
+}
+
+func TestSecretTreeResource_Delete(t *testing.T) {
+	tests := []struct {
+		name          string
+		protected     []string
+		failRemove    bool
+		invalid       bool
+		wantRemaining map[string]string
+		wantErr       bool
+	}{
+		// Secrets written outside of Terraform are removed along with the managed ones
+		{name: "removes the prefix", wantRemaining: map[string]string{"teams/other/a": "o"}},
+		{
+			name:          "protected secret below the prefix",
+			protected:     []string{"teams/billing/notes"},
+			wantRemaining: map[string]string{"teams/billing/a": "a", "teams/billing/notes": "n", "teams/other/a": "o"},
+			wantErr:       true,
+		},
+		{name: "remove fails", failRemove: true, wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := storeWith(map[string]string{"teams/billing/a": "a", "teams/billing/notes": "n", "teams/other/a": "o"})
+			r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(store)}})
+			r.client.SetProtectedPaths(tc.protected)
+			if tc.failRemove {
+				store.shouldFail = true
+				store.failMsg = "permission denied"
+			}
+			state := secretTreeValue(schemaResp, nil, 1, []string{"a"})
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantRemaining == nil {
+				return
+			}
+			if got := treeContent(store); !reflect.DeepEqual(got, tc.wantRemaining) {
+				t.Errorf("remaining = %v, want %v", got, tc.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestSecretTreeResource_InvalidTimeouts(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp := testResource(&SecretTreeResource{EnvResource{client: clientWith(newMockStore())}})
+	s := schemaResp.Schema
+
+	raw := func(op string) tftypes.Value {
+		return schemaObjectValue(s, map[string]tftypes.Value{
+			"path":              tftypes.NewValue(tftypes.String, "teams/billing"),
+			"values_wo_version": tftypes.NewValue(tftypes.Number, 1),
+			"timeouts":          timeoutsRaw(s, op, "never"),
+		})
+	}
+
+	createResp := &resource.CreateResponse{State: tfsdk.State{Schema: s}}
+	r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: s, Raw: raw(timeoutCreate)}}, createResp)
+	assertInvalidTimeouts(t, createResp.Diagnostics.Errors())
+
+	readResp := &resource.ReadResponse{State: tfsdk.State{Schema: s}}
+	r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutRead)}}, readResp)
+	assertInvalidTimeouts(t, readResp.Diagnostics.Errors())
+
+	updateResp := &resource.UpdateResponse{State: tfsdk.State{Schema: s}}
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:  tfsdk.Plan{Schema: s, Raw: raw(timeoutUpdate)},
+		State: tfsdk.State{Schema: s, Raw: raw(timeoutUpdate)},
+	}, updateResp)
+	assertInvalidTimeouts(t, updateResp.Diagnostics.Errors())
+
+	deleteResp := &resource.DeleteResponse{State: tfsdk.State{Schema: s}}
+	r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutDelete)}}, deleteResp)
+	assertInvalidTimeouts(t, deleteResp.Diagnostics.Errors())
+}
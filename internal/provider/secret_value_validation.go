@@ -0,0 +1,105 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Modes of the normalize attribute of gopass_secret.
+const (
+	normalizeNone = "none"
+	normalizeTrim = "trim"
+)
+
+// validateValueConstraints adds errors to diags for a validate_regex that does not compile
+// or a negative min_length.
+func validateValueConstraints(diags *diag.Diagnostics, config *SecretResourceModel) {
+	if !config.ValidateRegex.IsNull() && !config.ValidateRegex.IsUnknown() {
+		if _, err := regexp.Compile(config.ValidateRegex.ValueString()); err != nil {
+			diags.AddAttributeError(
+				path.Root("validate_regex"),
+				"Invalid validate_regex",
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("validate_regex is not a valid regular expression: %s.", err.Error())),
+			)
+		}
+	}
+	if config.MinLength.ValueInt64() < 0 {
+		diags.AddAttributeError(
+			path.Root("min_length"),
+			"Invalid min_length",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("min_length must not be negative, got %d.", config.MinLength.ValueInt64())),
+		)
+	}
+}
+
+// validateNormalize adds an error to diags for an unknown normalize mode.
+func validateNormalize(diags *diag.Diagnostics, v types.String) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	switch v.ValueString() {
+	case normalizeNone, normalizeTrim:
+	default:
+		diags.AddAttributeError(
+			path.Root("normalize"),
+			"Invalid normalize",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("normalize must be %q or %q, got %q.", normalizeTrim, normalizeNone, v.ValueString())),
+		)
+	}
+}
+
+// normalizeValue canonicalizes value_wo as configured by the normalize attribute of data, so
+// values differing only by a trailing line break are written and compared as the same value.
+func normalizeValue(data *SecretResourceModel, value string) string {
+	if data.Normalize.ValueString() == normalizeTrim {
+		return strings.TrimRight(value, "\r\n")
+	}
+	return value
+}
+
+// checkValue returns an error describing how value violates the format constraints of data.
+// The error never contains the value itself.
+func checkValue(data *SecretResourceModel, value string) error {
+	var problems []string
+
+	if minLength := data.MinLength.ValueInt64(); int64(utf8.RuneCountInString(value)) < minLength {
+		problems = append(problems, fmt.Sprintf("it is shorter than min_length (%d characters)", minLength))
+	}
+	if data.ForbidWhitespace.ValueBool() && strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+		problems = append(problems, "it contains whitespace, e.g. a trailing newline")
+	}
+	if !data.ValidateRegex.IsNull() {
+		re, err := regexp.Compile(data.ValidateRegex.ValueString())
+		if err != nil {
+			return fmt.Errorf("invalid validate_regex: %w", err)
+		}
+		if !re.MatchString(value) {
+			problems = append(problems, fmt.Sprintf("it does not match validate_regex %q", re.String()))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// addInvalidValueError adds the error reported for a value_wo rejected by checkValue.
+func addInvalidValueError(diags *diag.Diagnostics, secretPath string, err error) {
+	diags.AddAttributeError(
+		path.Root("value_wo"),
+		"Invalid value_wo",
+		codedDetail(CodeInvalidConfig, fmt.Sprintf("The value for %q was not written: %s.", secretPath, err.Error())),
+	)
+}
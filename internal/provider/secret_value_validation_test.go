@@ -0,0 +1,320 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestCheckValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		regex     types.String
+		minLength types.Int64
+		forbidWS  types.Bool
+		value     string
+		wantErr   string
+	}{
+		{name: "no constraints", value: " anything\n"},
+		{name: "regex matches", regex: types.StringValue(`^sk_[a-z0-9]+$`), value: "sk_abc123"},
+		{name: "regex mismatch", regex: types.StringValue(`^sk_[a-z0-9]+$`), value: `{"key":"sk_abc"}`, wantErr: "does not match validate_regex"},
+		{name: "invalid regex", regex: types.StringValue(`(`), value: "secret42", wantErr: "invalid validate_regex"},
+		{name: "long enough", minLength: types.Int64Value(3), value: "äöü"},
+		{name: "too short", minLength: types.Int64Value(4), value: "äöü", wantErr: "shorter than min_length (4 characters)"},
+		{name: "no whitespace", forbidWS: types.BoolValue(true), value: "token"},
+		{name: "trailing newline", forbidWS: types.BoolValue(true), value: "token\n", wantErr: "contains whitespace"},
+		{name: "whitespace allowed", forbidWS: types.BoolValue(false), value: "two words"},
+		{
+			name:      "several problems",
+			minLength: types.Int64Value(10),
+			forbidWS:  types.BoolValue(true),
+			value:     "a b",
+			wantErr:   "shorter than min_length (10 characters); it contains whitespace",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data := &SecretResourceModel{ValidateRegex: tc.regex, MinLength: tc.minLength, ForbidWhitespace: tc.forbidWS}
+
+			err := checkValue(data, tc.value)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+			if tc.value != "" && strings.Contains(err.Error(), tc.value) {
+				t.Errorf("error must not contain the value, got %q", err.Error())
+			}
+		})
+	}
+}
+
+func TestSecretResource_ValidateConfig_ValueConstraints(t *testing.T) {
+	tests := []struct {
+		name      string
+		regex     any
+		minLength any
+		wantErr   string
+	}{
+		{name: "valid", regex: `^[A-Z]+$`, minLength: 8},
+		{name: "unknown regex", regex: tftypes.UnknownValue},
+		{name: "invalid regex", regex: `[`, wantErr: "Invalid validate_regex"},
+		{name: "negative min_length", minLength: -1, wantErr: "Invalid min_length"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":           tftypes.NewValue(tftypes.String, "test/secret"),
+				"validate_regex": tftypes.NewValue(tftypes.String, tc.regex),
+				"min_length":     tftypes.NewValue(tftypes.Number, tc.minLength),
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if tc.wantErr == "" {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != tc.wantErr {
+				t.Errorf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+// valueConstraintValues returns the attributes of a secret whose value must be at least 8 characters without whitespace.
+func valueConstraintValues(value string, version int) map[string]tftypes.Value {
+	return map[string]tftypes.Value{
+		"id":                tftypes.NewValue(tftypes.String, "test/validated"),
+		"path":              tftypes.NewValue(tftypes.String, "test/validated"),
+		"value_wo":          tftypes.NewValue(tftypes.String, value),
+		"value_wo_version":  tftypes.NewValue(tftypes.Number, version),
+		"delete_on_remove":  tftypes.NewValue(tftypes.Bool, true),
+		"min_length":        tftypes.NewValue(tftypes.Number, 8),
+		"forbid_whitespace": tftypes.NewValue(tftypes.Bool, true),
+	}
+}
+
+func TestSecretResource_Create_ValueConstraints(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "valid value written", value: "correct-horse"},
+		{name: "trailing newline rejected", value: "correct-horse\n", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockStore := newMockStore()
+			client := NewGopassClient("")
+			client.store = mockStore
+			r := &SecretResource{client: client}
+
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, valueConstraintValues(tc.value, 1))
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			_, written := mockStore.secrets["test/validated"]
+			if written == tc.wantErr {
+				t.Errorf("expected written=%v", !tc.wantErr)
+			}
+			if tc.wantErr && resp.Diagnostics.Errors()[0].Summary() != "Invalid value_wo" {
+				t.Errorf("expected invalid value error, got %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Update_ValueConstraints(t *testing.T) {
+	mockStore := newMockStore()
+	mockStore.secrets["test/validated"] = newMockSecret("correct-horse")
+	client := NewGopassClient("")
+	client.store = mockStore
+	r := &SecretResource{client: client}
+
+	ctx := context.Background()
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+	state := schemaObjectValue(schemaResp.Schema, valueConstraintValues("", 1))
+	raw := schemaObjectValue(schemaResp.Schema, valueConstraintValues("short", 2))
+	resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Update(ctx, resource.UpdateRequest{
+		Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: raw},
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw},
+		State:  tfsdk.State{Schema: schemaResp.Schema, Raw: state},
+	}, resp)
+
+	if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Invalid value_wo" {
+		t.Fatalf("expected invalid value error, got %v", resp.Diagnostics)
+	}
+	if got := mockStore.secrets["test/validated"].Password(); got != "correct-horse" {
+		t.Errorf("expected the secret to be unchanged, got %q", got)
+	}
+}
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize types.String
+		value     string
+		want      string
+	}{
+		{name: "default", normalize: types.StringNull(), value: "token\n", want: "token\n"},
+		{name: "none", normalize: types.StringValue(normalizeNone), value: "token\r\n", want: "token\r\n"},
+		{name: "trim newline", normalize: types.StringValue(normalizeTrim), value: "token\n", want: "token"},
+		{name: "trim crlf", normalize: types.StringValue(normalizeTrim), value: "token\r\n\n", want: "token"},
+		{name: "trim keeps other whitespace", normalize: types.StringValue(normalizeTrim), value: " two\nlines \n", want: " two\nlines "},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := normalizeValue(&SecretResourceModel{Normalize: tc.normalize}, tc.value); got != tc.want {
+				t.Errorf("normalizeValue(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretResource_ValidateConfig_Normalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		normalize any
+		wantErr   bool
+	}{
+		{name: "trim", normalize: normalizeTrim},
+		{name: "none", normalize: normalizeNone},
+		{name: "unknown", normalize: tftypes.UnknownValue},
+		{name: "invalid", normalize: "strip", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &SecretResource{}
+			ctx := context.Background()
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raw := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+				"path":      tftypes.NewValue(tftypes.String, "test/secret"),
+				"normalize": tftypes.NewValue(tftypes.String, tc.normalize),
+			})
+			resp := &resource.ValidateConfigResponse{}
+			r.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if !tc.wantErr {
+				if resp.Diagnostics.HasError() {
+					t.Errorf("unexpected error: %v", resp.Diagnostics)
+				}
+				return
+			}
+			if !resp.Diagnostics.HasError() || resp.Diagnostics.Errors()[0].Summary() != "Invalid normalize" {
+				t.Errorf("expected invalid normalize error, got %v", resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestSecretResource_Normalize(t *testing.T) {
+	tests := []struct {
+		name         string
+		normalize    string
+		wantPassword string
+		wantRewrite  bool
+	}{
+		{name: "trim", normalize: normalizeTrim, wantPassword: "s3cret"},
+		{name: "none", normalize: normalizeNone, wantPassword: "s3cret\n", wantRewrite: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMockStore()
+			client := NewGopassClient("")
+			client.store = store
+			var dir string
+			var args []string
+			client.execCommand = fakeGit(testGitLogLine, nil, &dir, &args)
+			r := &SecretResource{client: client}
+			schemaResp := &resource.SchemaResponse{}
+			r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+
+			raws := func(value string, version int) (tftypes.Value, tftypes.Value) {
+				values := map[string]tftypes.Value{
+					"path":              tftypes.NewValue(tftypes.String, "test/path"),
+					"value_wo_version":  tftypes.NewValue(tftypes.Number, version),
+					"delete_on_remove":  tftypes.NewValue(tftypes.Bool, true),
+					"forbid_whitespace": tftypes.NewValue(tftypes.Bool, tc.normalize == normalizeTrim),
+					"normalize":         tftypes.NewValue(tftypes.String, tc.normalize),
+				}
+				plan := schemaObjectValue(schemaResp.Schema, values)
+				values["value_wo"] = tftypes.NewValue(tftypes.String, value)
+				return plan, schemaObjectValue(schemaResp.Schema, values)
+			}
+
+			// A value copied with a trailing newline is written without it
+			plan, config := raws("s3cret\n", 1)
+			createResp := withPrivateData(&resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}})
+			r.Create(ctx, resource.CreateRequest{
+				Plan:   tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+			}, createResp)
+			if createResp.Diagnostics.HasError() {
+				t.Fatalf("Create() error: %v", createResp.Diagnostics)
+			}
+			if got := store.secrets["test/path"].Password(); got != tc.wantPassword {
+				t.Errorf("expected password %q, got %q", tc.wantPassword, got)
+			}
+			revisionsBefore := len(store.revisions["test/path"])
+
+			// The same value without the newline is not written again
+			plan, config = raws("s3cret", 2)
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}, Private: createResp.Private}
+			r.Update(ctx, resource.UpdateRequest{
+				Plan:    tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State:   createResp.State,
+				Config:  tfsdk.Config{Schema: schemaResp.Schema, Raw: config},
+				Private: createResp.Private,
+			}, resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("Update() error: %v", resp.Diagnostics)
+			}
+			if rewritten := len(store.revisions["test/path"]) > revisionsBefore; rewritten != tc.wantRewrite {
+				t.Errorf("rewritten = %v, want %v", rewritten, tc.wantRewrite)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+// wipe overwrites the plaintext in b with zeros, for buffers the provider owns and is done
+// with, so they do not keep secrets in the memory of the plugin process until the garbage
+// collector reuses it.
+func wipe(b []byte) {
+	clear(b)
+}
+
+// wipeValues wipes every value of values, see wipe.
+func wipeValues(values map[string][]byte) {
+	for _, v := range values {
+		wipe(v)
+	}
+}
+
+// appendWiped appends s to b like append, but wipes the array b is moved out of when it has
+// to grow, so building up plaintext leaves no stale copies behind.
+func appendWiped[S ~string | ~[]byte](b []byte, s S) []byte {
+	if cap(b)-len(b) < len(s) {
+		grown := make([]byte, len(b), 2*cap(b)+len(s))
+		copy(grown, b)
+		wipe(b)
+		b = grown
+	}
+	n := len(b)
+	b = b[:n+len(s)]
+	copy(b[n:], s)
+	return b
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+)
+
+// isWiped reports whether b holds no plaintext anymore.
+func isWiped(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// byteValues returns values as byte slices, as read by ReadEnvSecretBytesAt.
+func byteValues(values map[string]string) map[string][]byte {
+	result := make(map[string][]byte, len(values))
+	for key, value := range values {
+		result[key] = []byte(value)
+	}
+	return result
+}
+
+func TestWipe(t *testing.T) {
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{name: "nil"},
+		{name: "empty", b: []byte{}},
+		{name: "plaintext", b: []byte("hunter2\nusername: admin\n")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n := len(tc.b)
+			wipe(tc.b)
+			if len(tc.b) != n || !isWiped(tc.b) {
+				t.Errorf("expected %d zero bytes, got %q", n, tc.b)
+			}
+		})
+	}
+}
+
+func TestWipeValues(t *testing.T) {
+	values := byteValues(map[string]string{"a": "hunter2", "b": "", "c": "s3cret"})
+	wipeValues(values)
+	for key, value := range values {
+		if !isWiped(value) {
+			t.Errorf("expected %s to be wiped, got %q", key, value)
+		}
+	}
+}
+
+func TestAppendWiped(t *testing.T) {
+	b := make([]byte, 0, 4)
+	b = appendWiped(b, "pass")
+	old := b
+	b = appendWiped(b, []byte("word"))
+
+	if string(b) != "password" {
+		t.Errorf("expected %q, got %q", "password", b)
+	}
+	if !isWiped(old) {
+		t.Errorf("expected the outgrown array to be wiped, got %q", old)
+	}
+
+	// Appending within capacity keeps the array
+	grown := b
+	b = appendWiped(b[:0], "ab")
+	if string(b) != "ab" || &b[0] != &grown[0] {
+		t.Errorf("expected %q in the same array, got %q", "ab", b)
+	}
+}
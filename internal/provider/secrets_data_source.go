@@ -0,0 +1,190 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &SecretsDataSource{}
+	_ datasource.DataSourceWithConfigure = &SecretsDataSource{}
+)
+
+// SecretsDataSource lists the secret paths under a prefix, without reading any value.
+type SecretsDataSource struct {
+	client *GopassClient
+}
+
+// SecretsDataSourceModel describes the data source data model.
+type SecretsDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	Path      types.String `tfsdk:"path"`
+	Recursive types.Bool   `tfsdk:"recursive"`
+	Offset    types.Int64  `tfsdk:"offset"`
+	Limit     types.Int64  `tfsdk:"limit"`
+	Paths     types.List   `tfsdk:"paths"`
+	Total     types.Int64  `tfsdk:"total"`
+}
+
+// NewSecretsDataSource creates a new instance.
+func NewSecretsDataSource() datasource.DataSource {
+	return &SecretsDataSource{}
+}
+
+func (d *SecretsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_secrets"
+}
+
+func (d *SecretsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the paths of the secrets under a folder, in lexical order. No secret is decrypted.",
+		MarkdownDescription: `
+Lists the paths of the secrets under a folder, in lexical order. Only the store index is read;
+no secret is decrypted.
+
+The order is stable, so the result can drive ` + "`for_each`" + ` without spurious diffs. Very large
+folders can be read in pages with ` + "`offset`" + ` and ` + "`limit`" + `.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_secrets" "services" {
+  path      = "services"
+  recursive = true
+}
+
+ephemeral "gopass_secret" "service" {
+  for_each = toset(data.gopass_secrets.services.paths)
+  path     = each.value
+}
+
+# Second page of 100 entries
+data "gopass_secrets" "page2" {
+  path   = "archive"
+  offset = 100
+  limit  = 100
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The listed path.",
+				Computed:    true,
+			},
+			"path": schema.StringAttribute{
+				Description:         "Folder to list (e.g., 'services/api').",
+				MarkdownDescription: "Folder to list (e.g., `services/api`).",
+				Required:            true,
+				Validators:          []validator.String{validSecretPath()},
+			},
+			"recursive": schema.BoolAttribute{
+				Description:         "List secrets at any depth instead of only the immediate children. Defaults to false.",
+				MarkdownDescription: "List secrets at any depth instead of only the immediate children. Defaults to `false`.",
+				Optional:            true,
+			},
+			"offset": schema.Int64Attribute{
+				Description:         "Number of paths to skip. Defaults to 0.",
+				MarkdownDescription: "Number of paths to skip. Defaults to `0`.",
+				Optional:            true,
+			},
+			"limit": schema.Int64Attribute{
+				Description:         "Maximum number of paths to return. Defaults to all.",
+				MarkdownDescription: "Maximum number of paths to return. Defaults to all.",
+				Optional:            true,
+			},
+			"paths": schema.ListAttribute{
+				Description: "Full paths of the secrets, in lexical order.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+			"total": schema.Int64Attribute{
+				Description:         "Number of secrets under path, ignoring offset and limit.",
+				MarkdownDescription: "Number of secrets under `path`, ignoring `offset` and `limit`.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *SecretsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *SecretsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	var data SecretsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, attr := range []struct {
+		name  string
+		value types.Int64
+	}{{"offset", data.Offset}, {"limit", data.Limit}} {
+		if attr.value.ValueInt64() < 0 {
+			resp.Diagnostics.AddAttributeError(
+				path.Root(attr.name),
+				"Invalid "+attr.name,
+				codedDetail(CodeInvalidConfig, fmt.Sprintf("%s must not be negative, got %d.", attr.name, attr.value.ValueInt64())),
+			)
+		}
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	prefix := data.Path.ValueString()
+
+	paths, total, err := d.client.ListSecretsPage(ctx, prefix, data.Recursive.ValueBool(),
+		int(data.Offset.ValueInt64()), int(data.Limit.ValueInt64()))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to list secrets",
+			errorDetail(err, fmt.Sprintf("Could not list secrets under %q: %s", prefix, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Listed gopass secrets", map[string]interface{}{
+		"path":  prefix,
+		"count": len(paths),
+		"total": total,
+	})
+
+	list, diags := types.ListValueFrom(ctx, types.StringType, paths)
+	resp.Diagnostics.Append(diags...)
+
+	data.ID = types.StringValue(prefix)
+	data.Paths = list
+	data.Total = types.Int64Value(int64(total))
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
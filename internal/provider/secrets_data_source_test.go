@@ -0,0 +1,154 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readSecrets runs Read on the secrets data source with the given config values.
+func readSecrets(t *testing.T, client *GopassClient, values map[string]tftypes.Value) (*datasource.ReadResponse, SecretsDataSourceModel) {
+	t.Helper()
+
+	d := &SecretsDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{
+			Schema: schemaResp.Schema,
+			Raw:    schemaObjectValue(schemaResp.Schema, values),
+		},
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data SecretsDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func TestSecretsDataSource_Metadata(t *testing.T) {
+	d := NewSecretsDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_secrets" {
+		t.Errorf("expected type name 'gopass_secrets', got %q", resp.TypeName)
+	}
+}
+
+func TestSecretsDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &SecretsDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestSecretsDataSource_Read(t *testing.T) {
+	tests := []struct {
+		name      string
+		recursive interface{}
+		offset    interface{}
+		limit     interface{}
+		want      []string
+		wantTotal int64
+		wantErr   bool
+	}{
+		{name: "children", want: []string{"services/api", "services/db"}, wantTotal: 2},
+		{name: "recursive", recursive: true, want: []string{"services/api", "services/db", "services/legacy/ftp"}, wantTotal: 3},
+		{name: "paged", recursive: true, offset: 1, limit: 1, want: []string{"services/db"}, wantTotal: 3},
+		{name: "negative offset", offset: -1, wantErr: true},
+		{name: "negative limit", limit: -5, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("")
+			client.store = storeWith(map[string]string{
+				"services/db": "", "services/api": "", "services/legacy/ftp": "", "other/x": "",
+			})
+
+			resp, data := readSecrets(t, client, map[string]tftypes.Value{
+				"path":      tftypes.NewValue(tftypes.String, "services"),
+				"recursive": tftypes.NewValue(tftypes.Bool, tc.recursive),
+				"offset":    tftypes.NewValue(tftypes.Number, tc.offset),
+				"limit":     tftypes.NewValue(tftypes.Number, tc.limit),
+			})
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			var got []string
+			data.Paths.ElementsAs(context.Background(), &got, false)
+			if !reflect.DeepEqual(got, tc.want) || data.Total.ValueInt64() != tc.wantTotal {
+				t.Errorf("got (%v, %d), want (%v, %d)", got, data.Total.ValueInt64(), tc.want, tc.wantTotal)
+			}
+			if data.ID.ValueString() != "services" {
+				t.Errorf("expected id 'services', got %q", data.ID.ValueString())
+			}
+		})
+	}
+}
+
+func TestSecretsDataSource_Read_Errors(t *testing.T) {
+	store := newMockStore()
+	store.shouldFail = true
+	store.failMsg = "store locked"
+	client := NewGopassClient("")
+	client.store = store
+
+	resp, _ := readSecrets(t, client, map[string]tftypes.Value{
+		"path": tftypes.NewValue(tftypes.String, "services"),
+	})
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error when listing fails")
+	}
+
+	d := &SecretsDataSource{client: client}
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(context.Background(), datasource.SchemaRequest{}, schemaResp)
+	readResp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(context.Background(), datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw}}, readResp)
+	if !readResp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &StoreInfoDataSource{}
+	_ datasource.DataSourceWithConfigure = &StoreInfoDataSource{}
+)
+
+// StoreInfoDataSource reports the storage and crypto backends of the password store.
+type StoreInfoDataSource struct {
+	client *GopassClient
+}
+
+// StoreInfoDataSourceModel describes the data source data model.
+type StoreInfoDataSourceModel struct {
+	ID        types.String `tfsdk:"id"`
+	Root      types.String `tfsdk:"root"`
+	Storage   types.String `tfsdk:"storage"`
+	Crypto    types.String `tfsdk:"crypto"`
+	Versioned types.Bool   `tfsdk:"versioned"`
+}
+
+// NewStoreInfoDataSource creates a new instance.
+func NewStoreInfoDataSource() datasource.DataSource {
+	return &StoreInfoDataSource{}
+}
+
+func (d *StoreInfoDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_store_info"
+}
+
+func (d *StoreInfoDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Reports the storage and crypto backends and the root directory of the password store.",
+		MarkdownDescription: `
+Reports the storage and crypto backends and the root directory of the password store.
+
+The backends are detected from the files in the store root (` + "`.git`" + `, ` + "`.gpg-id`" + `,
+` + "`.age-recipients`" + `, ...). No secret is decrypted.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_store_info" "this" {}
+
+resource "gopass_secret" "api_key" {
+  path              = "services/api/key"
+  value_wo          = var.api_key
+  value_wo_version  = 1
+  revision_tracking = data.gopass_store_info.this.versioned ? "auto" : "off"
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The store root.",
+				Computed:    true,
+			},
+			"root": schema.StringAttribute{
+				Description: "Directory of the store on disk.",
+				Computed:    true,
+			},
+			"storage": schema.StringAttribute{
+				Description:         "Storage backend: 'gitfs', 'fossilfs' or 'fs'.",
+				MarkdownDescription: "Storage backend: `gitfs`, `fossilfs` or `fs`.",
+				Computed:            true,
+			},
+			"crypto": schema.StringAttribute{
+				Description:         "Crypto backend: 'gpg', 'age' or 'plain'. Null if the store root has no recipients file.",
+				MarkdownDescription: "Crypto backend: `gpg`, `age` or `plain`. `null` if the store root has no recipients file.",
+				Computed:            true,
+			},
+			"versioned": schema.BoolAttribute{
+				Description:         "Whether the storage backend keeps a history of secrets (gitfs or fossilfs).",
+				MarkdownDescription: "Whether the storage backend keeps a history of secrets (`gitfs` or `fossilfs`).",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (d *StoreInfoDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *StoreInfoDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	info, err := d.client.GetStoreInfo(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read store info",
+			errorDetail(err, fmt.Sprintf("Could not determine the backends of the password store: %s", err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Read gopass store info", map[string]interface{}{
+		"root":    info.Root,
+		"storage": info.Storage,
+		"crypto":  info.Crypto,
+	})
+
+	data := StoreInfoDataSourceModel{
+		ID:        types.StringValue(info.Root),
+		Root:      types.StringValue(info.Root),
+		Storage:   types.StringValue(info.Storage),
+		Crypto:    types.StringNull(),
+		Versioned: types.BoolValue(info.Versioned()),
+	}
+	if info.Crypto != "" {
+		data.Crypto = types.StringValue(info.Crypto)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
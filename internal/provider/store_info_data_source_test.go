@@ -0,0 +1,135 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+)
+
+// readStoreInfo runs Read on the store info data source.
+func readStoreInfo(t *testing.T, client *GopassClient) (*datasource.ReadResponse, StoreInfoDataSourceModel) {
+	t.Helper()
+
+	d := &StoreInfoDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data StoreInfoDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func TestStoreInfoDataSource_Metadata(t *testing.T) {
+	d := NewStoreInfoDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_store_info" {
+		t.Errorf("expected type name 'gopass_store_info', got %q", resp.TypeName)
+	}
+}
+
+func TestStoreInfoDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &StoreInfoDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestStoreInfoDataSource_Read(t *testing.T) {
+	tests := []struct {
+		name          string
+		git           bool
+		idFile        string
+		wantStorage   string
+		wantCrypto    string
+		wantVersioned bool
+	}{
+		{name: "git gpg", git: true, idFile: ".gpg-id", wantStorage: "gitfs", wantCrypto: "gpg", wantVersioned: true},
+		{name: "no recipients", wantStorage: "fs"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.git {
+				if err := os.Mkdir(filepath.Join(dir, ".git"), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.idFile != "" {
+				writeStoreFile(t, dir, tc.idFile, "0xROOT\n")
+			}
+
+			resp, data := readStoreInfo(t, NewGopassClient(dir))
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			if data.ID.ValueString() != dir || data.Root.ValueString() != dir {
+				t.Errorf("expected id and root %q, got %q and %q", dir, data.ID.ValueString(), data.Root.ValueString())
+			}
+			if data.Storage.ValueString() != tc.wantStorage || data.Versioned.ValueBool() != tc.wantVersioned {
+				t.Errorf("expected storage %q (versioned=%v), got %+v", tc.wantStorage, tc.wantVersioned, data)
+			}
+			if tc.wantCrypto == "" && !data.Crypto.IsNull() {
+				t.Errorf("expected null crypto, got %v", data.Crypto)
+			}
+			if tc.wantCrypto != "" && data.Crypto.ValueString() != tc.wantCrypto {
+				t.Errorf("expected crypto %q, got %v", tc.wantCrypto, data.Crypto)
+			}
+		})
+	}
+}
+
+func TestStoreInfoDataSource_Read_MissingStore(t *testing.T) {
+	resp, _ := readStoreInfo(t, NewGopassClient(filepath.Join(t.TempDir(), "missing")))
+
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected error for a missing store")
+	}
+	if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.HasPrefix(detail, "["+CodeStoreNotFound+"]") {
+		t.Errorf("expected store not found code, got %q", detail)
+	}
+}
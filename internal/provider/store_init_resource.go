@@ -0,0 +1,251 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource              = &StoreInitResource{}
+	_ resource.ResourceWithConfigure = &StoreInitResource{}
+)
+
+// StoreInitResource bootstraps a new pass/gopass store on disk.
+type StoreInitResource struct {
+	client *GopassClient
+}
+
+// StoreInitResourceModel describes the resource data model.
+type StoreInitResourceModel struct {
+	ID             types.String `tfsdk:"id"`
+	Path           types.String `tfsdk:"path"`
+	Recipients     types.List   `tfsdk:"recipients"`
+	GitRemote      types.String `tfsdk:"git_remote"`
+	DeleteOnRemove types.Bool   `tfsdk:"delete_on_remove"`
+}
+
+// NewStoreInitResource creates a new instance.
+func NewStoreInitResource() resource.Resource {
+	return &StoreInitResource{}
+}
+
+func (r *StoreInitResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_store_init"
+}
+
+func (r *StoreInitResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Initializes a new pass-compatible gopass store at a path, e.g. a disposable store for CI.",
+		MarkdownDescription: `
+Initializes a new pass-compatible gopass store at a path.
+
+This is useful for ephemeral CI environments that create a disposable store as part of
+` + "`terraform apply`" + `. The store directory is created, the recipients are written to
+` + "`.gpg-id`" + ` and a git repository is initialized (with an optional ` + "`origin`" + ` remote).
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_store_init" "ci" {
+  path             = "/tmp/ci-store"
+  recipients       = ["0xDEADBEEF"]
+  delete_on_remove = true
+}
+
+provider "gopass" {
+  alias      = "ci"
+  store_path = gopass_store_init.ci.path
+}
+` + "```" + `
+
+Changing any argument replaces the store. Existing stores are never overwritten:
+creation fails if ` + "`.gpg-id`" + ` already exists at ` + "`path`" + `.
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The path of the store (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Directory in which to create the store. A leading '~/' is expanded.",
+				MarkdownDescription: "Directory in which to create the store. A leading `~/` is expanded.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"recipients": schema.ListAttribute{
+				Description:         "GPG key IDs (or age recipients) the store is encrypted for, written to .gpg-id.",
+				MarkdownDescription: "GPG key IDs (or age recipients) the store is encrypted for, written to `.gpg-id`.",
+				ElementType:         types.StringType,
+				Required:            true,
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplace(),
+				},
+			},
+			"git_remote": schema.StringAttribute{
+				Description:         "Optional git remote URL registered as 'origin'.",
+				MarkdownDescription: "Optional git remote URL registered as `origin`.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"delete_on_remove": schema.BoolAttribute{
+				Description:         "Whether to delete the store directory when the resource is destroyed. Defaults to false.",
+				MarkdownDescription: "Whether to delete the store directory when the resource is destroyed. Defaults to `false`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+		},
+	}
+}
+
+func (r *StoreInitResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *StoreInitResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data StoreInitResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var recipients []string
+	resp.Diagnostics.Append(data.Recipients.ElementsAs(ctx, &recipients, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	storePath := data.Path.ValueString()
+
+	if err := r.client.InitStore(ctx, storePath, recipients, data.GitRemote.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to initialize store",
+			errorDetail(err, fmt.Sprintf("Could not initialize gopass store at %q: %s", storePath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Initialized gopass store", map[string]interface{}{
+		"path": storePath,
+	})
+
+	data.ID = data.Path
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *StoreInitResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data StoreInitResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	storePath := data.Path.ValueString()
+
+	initialized, err := r.client.StoreInitialized(storePath)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read store",
+			errorDetail(err, fmt.Sprintf("Could not check gopass store at %q: %s", storePath, err.Error())),
+		)
+		return
+	}
+
+	if !initialized {
+		// Store was removed outside of Terraform
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// Update only handles delete_on_remove; all other attributes require replacement.
+//
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *StoreInitResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data StoreInitResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *StoreInitResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data StoreInitResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	storePath := data.Path.ValueString()
+
+	if !data.DeleteOnRemove.ValueBool() {
+		tflog.Info(ctx, "Keeping gopass store (delete_on_remove=false)", map[string]interface{}{
+			"path": storePath,
+		})
+		return
+	}
+
+	if err := r.client.RemoveStore(storePath); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove store",
+			errorDetail(err, fmt.Sprintf("Could not remove gopass store at %q: %s", storePath, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass store", map[string]interface{}{
+		"path": storePath,
+	})
+}
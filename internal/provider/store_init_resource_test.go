@@ -0,0 +1,269 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// invalidRaw is a raw value that cannot be decoded into any resource model.
+var invalidRaw = tftypes.NewValue(tftypes.String, "invalid")
+
+// storeInitTestSetup returns a store init resource and the git commands it runs, none of
+// which fail.
+func storeInitTestSetup(t *testing.T) (*StoreInitResource, resource.SchemaResponse, *[][]string) {
+	t.Helper()
+
+	client := NewGopassClient("")
+	var calls [][]string
+	client.execCommand = recordingGit(&calls, "")
+	r, schemaResp := testResource(&StoreInitResource{client: client})
+	return r, schemaResp, &calls
+}
+
+func storeInitValue(t *testing.T, schemaResp resource.SchemaResponse, dir string, deleteOnRemove bool) tftypes.Value {
+	t.Helper()
+
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":   tftypes.NewValue(tftypes.String, dir),
+		"path": tftypes.NewValue(tftypes.String, dir),
+		"recipients": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+			tftypes.NewValue(tftypes.String, "0xAAAA"),
+		}),
+		"delete_on_remove": tftypes.NewValue(tftypes.Bool, deleteOnRemove),
+	})
+}
+
+func TestStoreInitResource_Metadata(t *testing.T) {
+	r := NewStoreInitResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_store_init" {
+		t.Errorf("expected 'gopass_store_init', got %q", resp.TypeName)
+	}
+}
+
+func TestStoreInitResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &StoreInitResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &StoreInitResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestStoreInitResource_Create(t *testing.T) {
+	r, schemaResp, calls := storeInitTestSetup(t)
+	dir := filepath.Join(t.TempDir(), "store")
+	plan := storeInitValue(t, schemaResp, dir, false)
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".gpg-id")); err != nil {
+		t.Errorf("expected .gpg-id to exist: %v", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("expected 'git init' to be called, got %v", *calls)
+	}
+
+	var state StoreInitResourceModel
+	resp.Diagnostics.Append(resp.State.Get(context.Background(), &state)...)
+	if state.ID.ValueString() != dir {
+		t.Errorf("expected id %q, got %q", dir, state.ID.ValueString())
+	}
+}
+
+func TestStoreInitResource_Create_Errors(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp, _ := storeInitTestSetup(t)
+
+	t.Run("plan", func(t *testing.T) {
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from Plan.Get")
+		}
+	})
+
+	t.Run("unknown recipient", func(t *testing.T) {
+		plan := schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path": tftypes.NewValue(tftypes.String, t.TempDir()),
+			"recipients": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+				tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			}),
+		})
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error for unknown recipient")
+		}
+	})
+
+	t.Run("already initialized", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte("0xAAAA\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Create(ctx, resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: storeInitValue(t, schemaResp, dir, false)}}, resp)
+		if !resp.Diagnostics.HasError() || resp.Diagnostics[0].Summary() != "Failed to initialize store" {
+			t.Errorf("expected init error, got %v", resp.Diagnostics)
+		}
+	})
+}
+
+func TestStoreInitResource_Read(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp, _ := storeInitTestSetup(t)
+
+	t.Run("exists", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ".gpg-id"), []byte("0xAAAA\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		state := storeInitValue(t, schemaResp, dir, false)
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}
+		r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+		if resp.Diagnostics.HasError() || resp.State.Raw.IsNull() {
+			t.Errorf("expected resource to be kept, got %v", resp.Diagnostics)
+		}
+	})
+
+	t.Run("removed externally", func(t *testing.T) {
+		state := storeInitValue(t, schemaResp, t.TempDir(), false)
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}
+		r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+		if resp.Diagnostics.HasError() || !resp.State.Raw.IsNull() {
+			t.Errorf("expected resource to be removed from state, got %v", resp.Diagnostics)
+		}
+	})
+
+	t.Run("check error", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		state := storeInitValue(t, schemaResp, file, false)
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}
+		r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error when the store path is a file")
+		}
+	})
+
+	t.Run("state", func(t *testing.T) {
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestStoreInitResource_Update(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp, _ := storeInitTestSetup(t)
+	plan := storeInitValue(t, schemaResp, "/store", true)
+
+	resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Update(ctx, resource.UpdateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan}}, resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var state StoreInitResourceModel
+	resp.Diagnostics.Append(resp.State.Get(ctx, &state)...)
+	if !state.DeleteOnRemove.ValueBool() {
+		t.Error("expected delete_on_remove to be updated")
+	}
+
+	resp = &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Update(ctx, resource.UpdateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Plan.Get")
+	}
+}
+
+func TestStoreInitResource_Delete(t *testing.T) {
+	ctx := context.Background()
+	r, schemaResp, _ := storeInitTestSetup(t)
+
+	t.Run("keep", func(t *testing.T) {
+		dir := t.TempDir()
+		state := storeInitValue(t, schemaResp, dir, false)
+		resp := &resource.DeleteResponse{}
+		r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("expected store to be kept: %v", err)
+		}
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "store")
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		state := storeInitValue(t, schemaResp, dir, true)
+		resp := &resource.DeleteResponse{}
+		r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected error: %v", resp.Diagnostics)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("expected store to be removed, got %v", err)
+		}
+	})
+
+	t.Run("remove error", func(t *testing.T) {
+		client := NewGopassClient("")
+		client.removeAll = func(string) error { return errors.New("busy") }
+		failing := &StoreInitResource{client: client}
+		state := storeInitValue(t, schemaResp, t.TempDir(), true)
+		resp := &resource.DeleteResponse{}
+		failing.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error when removal fails")
+		}
+	})
+
+	t.Run("state", func(t *testing.T) {
+		resp := &resource.DeleteResponse{}
+		r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &StoreStatsDataSource{}
+	_ datasource.DataSourceWithConfigure = &StoreStatsDataSource{}
+)
+
+// StoreStatsDataSource counts the secrets of the store, without reading any value.
+type StoreStatsDataSource struct {
+	client *GopassClient
+}
+
+// StoreStatsDataSourceModel describes the data source data model.
+type StoreStatsDataSourceModel struct {
+	ID           types.String `tfsdk:"id"`
+	Path         types.String `tfsdk:"path"`
+	Depth        types.Int64  `tfsdk:"depth"`
+	Total        types.Int64  `tfsdk:"total"`
+	PrefixCounts types.Map    `tfsdk:"prefix_counts"`
+	LastSync     types.String `tfsdk:"last_sync"`
+}
+
+// NewStoreStatsDataSource creates a new instance.
+func NewStoreStatsDataSource() datasource.DataSource {
+	return &StoreStatsDataSource{}
+}
+
+func (d *StoreStatsDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_store_stats"
+}
+
+func (d *StoreStatsDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Counts the secrets of the password store, in total and by folder, and reports when the store last synced. No secret is decrypted.",
+		MarkdownDescription: `
+Counts the secrets of the password store, in total and by folder, and reports when the store
+last synced with its remote. Only the store index is read; no secret is decrypted.
+
+Use it to watch the sprawl of a store from outputs or checks.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_store_stats" "this" {}
+
+output "secrets_per_team" {
+  value = data.gopass_store_stats.this.prefix_counts
+}
+
+check "store_synced" {
+  assert {
+    condition     = data.gopass_store_stats.this.last_sync != null && timecmp(timeadd(data.gopass_store_stats.this.last_sync, "24h"), plantimestamp()) > 0
+    error_message = "The password store has not synced with its remote for a day."
+  }
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The counted path, empty for the whole store.",
+				Computed:    true,
+			},
+			"path": schema.StringAttribute{
+				Description:         "Folder to count the secrets of (e.g., 'teams'). Defaults to the whole store.",
+				MarkdownDescription: "Folder to count the secrets of (e.g., `teams`). Defaults to the whole store.",
+				Optional:            true,
+				Validators:          []validator.String{validSecretPath()},
+			},
+			"depth": schema.Int64Attribute{
+				Description:         "Number of folder levels below path that make up the keys of prefix_counts. Defaults to 1.",
+				MarkdownDescription: "Number of folder levels below `path` that make up the keys of `prefix_counts`. Defaults to `1`.",
+				Optional:            true,
+			},
+			"total": schema.Int64Attribute{
+				Description:         "Number of secrets under path, at any depth.",
+				MarkdownDescription: "Number of secrets under `path`, at any depth.",
+				Computed:            true,
+			},
+			"prefix_counts": schema.MapAttribute{
+				Description: "Number of secrets by folder, at any depth below it. Folders are full paths depth levels below path; " +
+					"secrets in a folder less deep are counted under that folder, those directly in the store root under an empty key.",
+				MarkdownDescription: "Number of secrets by folder, at any depth below it. Folders are full paths `depth` levels below `path`; " +
+					"secrets in a folder less deep are counted under that folder, those directly in the store root under `\"\"`.",
+				ElementType: types.Int64Type,
+				Computed:    true,
+			},
+			"last_sync": schema.StringAttribute{
+				Description: "When the store last fetched from or pushed to its remote (RFC 3339), from the reflog of its upstream branch. " +
+					"Null if the store is not git-backed, has no upstream or never synced.",
+				MarkdownDescription: "When the store last fetched from or pushed to its remote (RFC 3339), from the reflog of its upstream branch. " +
+					"`null` if the store is not git-backed, has no upstream or never synced.",
+				Computed: true,
+			},
+		},
+	}
+}
+
+func (d *StoreStatsDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *StoreStatsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	var data StoreStatsDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	depth := int64(1)
+	if !data.Depth.IsNull() {
+		depth = data.Depth.ValueInt64()
+	}
+	if depth < 1 {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("depth"),
+			"Invalid depth",
+			codedDetail(CodeInvalidConfig, fmt.Sprintf("depth must be at least 1, got %d.", depth)),
+		)
+		return
+	}
+
+	prefix := data.Path.ValueString()
+
+	stats, err := d.client.GetStoreStats(ctx, prefix, int(depth))
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read store stats",
+			errorDetail(err, fmt.Sprintf("Could not count the secrets under %q: %s", prefix, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Read gopass store stats", map[string]interface{}{
+		"path":  prefix,
+		"total": stats.Total,
+	})
+
+	counts, diags := types.MapValueFrom(ctx, types.Int64Type, stats.Prefixes)
+	resp.Diagnostics.Append(diags...)
+
+	data.ID = types.StringValue(prefix)
+	data.Total = types.Int64Value(int64(stats.Total))
+	data.PrefixCounts = counts
+	data.LastSync = types.StringNull()
+	if stats.LastSync != "" {
+		data.LastSync = types.StringValue(stats.LastSync)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readStoreStats runs Read on the store stats data source with the given config values.
+func readStoreStats(t *testing.T, client *GopassClient, values map[string]tftypes.Value) (*datasource.ReadResponse, StoreStatsDataSourceModel) {
+	t.Helper()
+
+	d := &StoreStatsDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: schemaObjectValue(schemaResp.Schema, values)},
+	}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data StoreStatsDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func TestStoreStatsDataSource_Metadata(t *testing.T) {
+	d := NewStoreStatsDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_store_stats" {
+		t.Errorf("expected type name 'gopass_store_stats', got %q", resp.TypeName)
+	}
+}
+
+func TestStoreStatsDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &StoreStatsDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestStoreStatsDataSource_Read(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         any
+		depth        any
+		sync         string
+		wantTotal    int64
+		wantCounts   map[string]int64
+		wantLastSync types.String
+		wantErr      string
+	}{
+		{
+			name: "store", wantTotal: 3, wantCounts: map[string]int64{"teams": 2, "infra": 1},
+			sync: "origin/main@{2026-10-16T12:00:00Z}", wantLastSync: types.StringValue("2026-10-16T12:00:00Z"),
+		},
+		{
+			name: "folder", path: "teams", depth: 2, wantTotal: 2,
+			wantCounts: map[string]int64{"teams/a": 1, "teams/b": 1}, wantLastSync: types.StringNull(),
+		},
+		{name: "invalid depth", depth: 0, wantErr: "Invalid depth"},
+		{name: "missing store", wantErr: "[GOPASS_STORE_NOT_FOUND]"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewGopassClient("/store")
+			client.store = storeWith(map[string]string{"teams/a/db": "x", "teams/b/db": "x", "infra/dns": "x"})
+			client.caps = map[string]storeCapabilities{"": unknownCapabilities}
+			var gotDir string
+			var gotArgs []string
+			client.execCommand = fakeGit(tc.sync, nil, &gotDir, &gotArgs)
+			if tc.name == "missing store" {
+				client = NewGopassClient(filepath.Join(t.TempDir(), "missing"))
+			}
+
+			resp, data := readStoreStats(t, client, map[string]tftypes.Value{
+				"path":  tftypes.NewValue(tftypes.String, tc.path),
+				"depth": tftypes.NewValue(tftypes.Number, tc.depth),
+			})
+			if tc.wantErr != "" {
+				if !resp.Diagnostics.HasError() || !strings.Contains(resp.Diagnostics.Errors()[0].Summary()+resp.Diagnostics.Errors()[0].Detail(), tc.wantErr) {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, resp.Diagnostics)
+				}
+				return
+			}
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+
+			var counts map[string]int64
+			resp.Diagnostics.Append(data.PrefixCounts.ElementsAs(context.Background(), &counts, false)...)
+			if data.Total.ValueInt64() != tc.wantTotal || len(counts) != len(tc.wantCounts) {
+				t.Fatalf("expected %d secrets by %v, got %d by %v", tc.wantTotal, tc.wantCounts, data.Total.ValueInt64(), counts)
+			}
+			for prefix, want := range tc.wantCounts {
+				if counts[prefix] != want {
+					t.Errorf("expected %d secrets in %q, got %d", want, prefix, counts[prefix])
+				}
+			}
+			if !data.LastSync.Equal(tc.wantLastSync) {
+				t.Errorf("expected last_sync %v, got %v", tc.wantLastSync, data.LastSync)
+			}
+		})
+	}
+}
+
+func TestStoreStatsDataSource_Read_InvalidConfig(t *testing.T) {
+	d := &StoreStatsDataSource{client: NewGopassClient("")}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
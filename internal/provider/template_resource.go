@@ -0,0 +1,251 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ resource.Resource                = &TemplateResource{}
+	_ resource.ResourceWithConfigure   = &TemplateResource{}
+	_ resource.ResourceWithImportState = &TemplateResource{}
+)
+
+// TemplateResource manages the template gopass uses for new secrets in a store folder.
+type TemplateResource struct {
+	client *GopassClient
+}
+
+// TemplateResourceModel describes the resource data model.
+type TemplateResourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	Path    types.String `tfsdk:"path"`
+	Content types.String `tfsdk:"content"`
+}
+
+// NewTemplateResource creates a new instance.
+func NewTemplateResource() resource.Resource {
+	return &TemplateResource{}
+}
+
+func (r *TemplateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_template"
+}
+
+func (r *TemplateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages the template gopass uses for new secrets in a store folder (like `gopass templates edit`).",
+		MarkdownDescription: `
+Manages the template gopass uses for new secrets in a store folder (like ` + "`gopass templates edit`" + `),
+so teams can enforce the structure of their secrets.
+
+The template is written to ` + "`.pass-template`" + ` in the folder and committed if the store is a
+git repository. gopass applies the template closest to a new secret, walking up towards the
+store root. Templates are stored **unencrypted**, so they must not contain secret values.
+
+## Example Usage
+
+` + "```hcl" + `
+resource "gopass_template" "databases" {
+  path    = "databases"
+  content = <<-EOT
+    {{ .Content }}
+    user: {{ .Name | base }}
+    host:
+    port: 5432
+  EOT
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The folder of the template (same as path attribute).",
+				Computed:    true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"path": schema.StringAttribute{
+				Description:         "Store folder the template applies to (e.g., 'databases'). Defaults to the store root.",
+				MarkdownDescription: "Store folder the template applies to (e.g., `databases`). Defaults to the store root.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(""),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					validSecretPath(),
+				},
+			},
+			"content": schema.StringAttribute{
+				Description:         "Template content, using the gopass template syntax (e.g. '{{ .Content }}' for the generated password).",
+				MarkdownDescription: "Template content, using the gopass template syntax (e.g. `{{ .Content }}` for the generated password).",
+				Required:            true,
+			},
+		},
+	}
+}
+
+func (r *TemplateResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *TemplateResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data TemplateResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	if err := r.client.SetTemplate(ctx, dir, data.Content.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to create template",
+			errorDetail(err, fmt.Sprintf("Could not write template for %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Created gopass template", map[string]interface{}{
+		"path": dir,
+	})
+
+	data.ID = data.Path
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *TemplateResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data TemplateResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	content, found, err := r.client.GetTemplate(ctx, dir)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to read template",
+			errorDetail(err, fmt.Sprintf("Could not read template for %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	if !found {
+		// The template was removed outside of Terraform
+		tflog.Warn(ctx, "Template no longer exists, removing from state", map[string]interface{}{
+			"path": dir,
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Templates are not secret, so drift is detected by comparing the content
+	data.ID = data.Path
+	data.Content = types.StringValue(content)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *TemplateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data TemplateResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	if err := r.client.SetTemplate(ctx, dir, data.Content.ValueString()); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to update template",
+			errorDetail(err, fmt.Sprintf("Could not write template for %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Updated gopass template", map[string]interface{}{
+		"path": dir,
+	})
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (r *TemplateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx = r.client.logContext(ctx)
+
+	var data TemplateResourceModel
+
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	dir := data.Path.ValueString()
+
+	if err := r.client.RemoveTemplate(ctx, dir); err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to remove template",
+			errorDetail(err, fmt.Sprintf("Could not remove template for %q: %s", dir, err.Error())),
+		)
+		return
+	}
+
+	tflog.Info(ctx, "Removed gopass template", map[string]interface{}{
+		"path": dir,
+	})
+}
+
+// ImportState imports the template of a store folder. Use "." to import the template of the store root.
+func (r *TemplateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	ctx = r.client.logContext(ctx)
+
+	dir := req.ID
+	if dir == "." {
+		dir = ""
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("path"), dir)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), dir)...)
+}
@@ -0,0 +1,289 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func templateValue(schemaResp resource.SchemaResponse, dir, content string) tftypes.Value {
+	return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+		"id":      tftypes.NewValue(tftypes.String, dir),
+		"path":    tftypes.NewValue(tftypes.String, dir),
+		"content": tftypes.NewValue(tftypes.String, content),
+	})
+}
+
+// brokenStorePath returns a store path that is a regular file, so every template operation fails.
+func brokenStorePath(t *testing.T) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "store")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestTemplateResource_Metadata(t *testing.T) {
+	r := NewTemplateResource()
+	resp := &resource.MetadataResponse{}
+	r.Metadata(context.Background(), resource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_template" {
+		t.Errorf("expected 'gopass_template', got %q", resp.TypeName)
+	}
+}
+
+func TestTemplateResource_Configure(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+
+	r := &TemplateResource{}
+	resp := &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: client}, resp)
+	if resp.Diagnostics.HasError() || r.client != client {
+		t.Errorf("expected client to be set, got %v", resp.Diagnostics)
+	}
+
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{ProviderData: "invalid"}, resp)
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error for invalid provider data type")
+	}
+
+	r = &TemplateResource{}
+	resp = &resource.ConfigureResponse{}
+	r.Configure(ctx, resource.ConfigureRequest{}, resp)
+	if resp.Diagnostics.HasError() || r.client != nil {
+		t.Error("expected nil provider data to be ignored")
+	}
+}
+
+func TestTemplateResource_Create(t *testing.T) {
+	dir := t.TempDir()
+	r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(dir)})
+
+	resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(context.Background(), resource.CreateRequest{
+		Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: templateValue(schemaResp, "databases", "user:\n")},
+	}, resp)
+
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "databases", ".pass-template")); err != nil || string(data) != "user:\n" {
+		t.Errorf("expected template to be written, got %q (%v)", data, err)
+	}
+	var state TemplateResourceModel
+	resp.State.Get(context.Background(), &state)
+	if state.ID.ValueString() != "databases" {
+		t.Errorf("expected id 'databases', got %q", state.ID.ValueString())
+	}
+}
+
+func TestTemplateResource_Create_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  func(schemaResp resource.SchemaResponse) tftypes.Value
+	}{
+		{name: "write fails", raw: func(schemaResp resource.SchemaResponse) tftypes.Value {
+			return templateValue(schemaResp, "databases", "user:\n")
+		}},
+		{name: "invalid plan", raw: func(resource.SchemaResponse) tftypes.Value { return invalidRaw }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(brokenStorePath(t))})
+
+			resp := &resource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Create(context.Background(), resource.CreateRequest{Plan: tfsdk.Plan{Schema: schemaResp.Schema, Raw: tc.raw(schemaResp)}}, resp)
+
+			if !resp.Diagnostics.HasError() {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestTemplateResource_Read(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		broken      bool
+		wantContent string
+		wantRemoved bool
+		wantErr     bool
+	}{
+		{name: "unchanged", file: "user:\n", wantContent: "user:\n"},
+		{name: "changed externally", file: "user:\nhost:\n", wantContent: "user:\nhost:\n"},
+		{name: "removed externally", wantRemoved: true},
+		{name: "read fails", broken: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.file != "" {
+				writeStoreFile(t, dir, "databases/.pass-template", tc.file)
+			}
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(dir)})
+			raw := templateValue(schemaResp, "databases", "user:\n")
+
+			resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}
+			r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: raw}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if tc.wantErr {
+				return
+			}
+			if removed := resp.State.Raw.IsNull(); removed != tc.wantRemoved {
+				t.Fatalf("expected removed=%v, got %v", tc.wantRemoved, removed)
+			}
+			if !tc.wantRemoved {
+				var state TemplateResourceModel
+				resp.State.Get(context.Background(), &state)
+				if state.Content.ValueString() != tc.wantContent {
+					t.Errorf("expected content %q, got %q", tc.wantContent, state.Content.ValueString())
+				}
+			}
+		})
+	}
+
+	t.Run("invalid state", func(t *testing.T) {
+		r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(t.TempDir())})
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.Read(context.Background(), resource.ReadRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: invalidRaw}}, resp)
+		if !resp.Diagnostics.HasError() {
+			t.Error("expected error from State.Get")
+		}
+	})
+}
+
+func TestTemplateResource_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		broken  bool
+		invalid bool
+		wantErr bool
+	}{
+		{name: "content changed"},
+		{name: "write fails", broken: true, wantErr: true},
+		{name: "invalid plan", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeStoreFile(t, dir, "databases/.pass-template", "user:\n")
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(dir)})
+			plan := templateValue(schemaResp, "databases", "user:\nhost:\n")
+			if tc.invalid {
+				plan = invalidRaw
+			}
+
+			resp := &resource.UpdateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.Update(context.Background(), resource.UpdateRequest{
+				Plan:  tfsdk.Plan{Schema: schemaResp.Schema, Raw: plan},
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: templateValue(schemaResp, "databases", "user:\n")},
+			}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr {
+				data, _ := os.ReadFile(filepath.Join(dir, "databases", ".pass-template"))
+				if string(data) != "user:\nhost:\n" {
+					t.Errorf("expected updated template, got %q", data)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateResource_Delete(t *testing.T) {
+	tests := []struct {
+		name    string
+		broken  bool
+		invalid bool
+		wantErr bool
+	}{
+		{name: "removes template"},
+		{name: "remove fails", broken: true, wantErr: true},
+		{name: "invalid state", invalid: true, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeStoreFile(t, dir, "databases/.pass-template", "user:\n")
+			if tc.broken {
+				dir = brokenStorePath(t)
+			}
+			r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(dir)})
+			state := templateValue(schemaResp, "databases", "user:\n")
+			if tc.invalid {
+				state = invalidRaw
+			}
+
+			resp := &resource.DeleteResponse{}
+			r.Delete(context.Background(), resource.DeleteRequest{State: tfsdk.State{Schema: schemaResp.Schema, Raw: state}}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if !tc.wantErr {
+				if _, err := os.Stat(filepath.Join(dir, "databases", ".pass-template")); !os.IsNotExist(err) {
+					t.Errorf("expected template to be removed, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateResource_ImportState(t *testing.T) {
+	tests := []struct {
+		id       string
+		wantPath string
+	}{
+		{id: "databases", wantPath: "databases"},
+		{id: ".", wantPath: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.id, func(t *testing.T) {
+			r, schemaResp := testResource(&TemplateResource{client: NewGopassClient(t.TempDir())})
+
+			resp := &resource.ImportStateResponse{
+				State: tfsdk.State{Schema: schemaResp.Schema, Raw: schemaNullValue(schemaResp.Schema)},
+			}
+			r.ImportState(context.Background(), resource.ImportStateRequest{ID: tc.id}, resp)
+
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("unexpected error: %v", resp.Diagnostics)
+			}
+			var state TemplateResourceModel
+			resp.State.Get(context.Background(), &state)
+			if state.Path.ValueString() != tc.wantPath || state.ID.ValueString() != tc.wantPath {
+				t.Errorf("expected path and id %q, got %q and %q", tc.wantPath, state.Path.ValueString(), state.ID.ValueString())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ephemeralschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	resourceschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// defaultTimeout bounds gopass operations without a configured timeout. Decryption with a
+// hardware token blocks until the PIN is entered or the token is touched; without a
+// deadline a forgotten token would hang the whole operation.
+const defaultTimeout = 5 * time.Minute
+
+// Timeout operations of the timeouts attribute.
+const (
+	timeoutCreate = "create"
+	timeoutRead   = "read"
+	timeoutUpdate = "update"
+	timeoutDelete = "delete"
+	timeoutOpen   = "open"
+)
+
+// resourceTimeoutsAttribute returns the timeouts attribute of managed resources.
+func resourceTimeoutsAttribute() resourceschema.SingleNestedAttribute {
+	attributes := map[string]resourceschema.Attribute{}
+	for _, op := range []string{timeoutCreate, timeoutRead, timeoutUpdate, timeoutDelete} {
+		attributes[op] = resourceschema.StringAttribute{
+			Description:         timeoutDescription(op),
+			MarkdownDescription: timeoutDescription(op),
+			Optional:            true,
+		}
+	}
+
+	return resourceschema.SingleNestedAttribute{
+		Description:         "Timeouts for gopass operations, as durations like '30s' or '2m'.",
+		MarkdownDescription: "Timeouts for gopass operations, as durations like `30s` or `2m`.",
+		Optional:            true,
+		Attributes:          attributes,
+	}
+}
+
+// ephemeralTimeoutsAttribute returns the timeouts attribute of ephemeral resources.
+func ephemeralTimeoutsAttribute() ephemeralschema.SingleNestedAttribute {
+	return ephemeralschema.SingleNestedAttribute{
+		Description:         "Timeouts for gopass operations, as durations like '30s' or '2m'.",
+		MarkdownDescription: "Timeouts for gopass operations, as durations like `30s` or `2m`.",
+		Optional:            true,
+		Attributes: map[string]ephemeralschema.Attribute{
+			timeoutOpen: ephemeralschema.StringAttribute{
+				Description:         timeoutDescription(timeoutOpen) + " Also applies to renewals.",
+				MarkdownDescription: timeoutDescription(timeoutOpen) + " Also applies to renewals.",
+				Optional:            true,
+			},
+		},
+	}
+}
+
+func timeoutDescription(op string) string {
+	return fmt.Sprintf("Timeout for %s. Defaults to %s.", op, defaultTimeout)
+}
+
+// operationTimeout returns the timeout configured for op in a timeouts object,
+// or defaultTimeout if none is configured.
+func operationTimeout(timeouts types.Object, op string) (time.Duration, error) {
+	if timeouts.IsNull() || timeouts.IsUnknown() {
+		return defaultTimeout, nil
+	}
+
+	v, ok := timeouts.Attributes()[op].(types.String)
+	if !ok || v.IsNull() || v.IsUnknown() {
+		return defaultTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(v.ValueString())
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s timeout %q: %w", op, v.ValueString(), err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid %s timeout %q: duration must be positive", op, v.ValueString())
+	}
+	return timeout, nil
+}
+
+// withTimeout returns a context bounded by the timeout configured for op.
+// The returned cancel function must be called once the operation is done.
+func withTimeout(ctx context.Context, timeouts types.Object, op string) (context.Context, context.CancelFunc, error) {
+	timeout, err := operationTimeout(timeouts, op)
+	if err != nil {
+		return ctx, func() {}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// wrapTimeoutError adds guidance to err if it was caused by the context deadline passing,
+// which usually means gopass was waiting for a hardware token. Such errors get the timeout
+// diagnostic code even if gopass reported the interruption differently.
+func wrapTimeoutError(ctx context.Context, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return withCode(CodeTimeout, fmt.Errorf("%w\n\n"+
+		"The gopass operation timed out. If you use a hardware token (YubiKey, Nitrokey):\n"+
+		"- make sure it is connected\n"+
+		"- enter the PIN or touch the token when prompted\n"+
+		"If the operation legitimately takes longer, raise the timeouts of this resource.", err))
+}
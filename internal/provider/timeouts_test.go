@@ -0,0 +1,227 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// resourceTimeouts builds a timeouts object of a managed resource; missing operations are null.
+func resourceTimeouts(values map[string]string) types.Object {
+	attrTypes := resourceTimeoutsAttribute().GetType().(types.ObjectType).AttrTypes
+	attrs := make(map[string]attr.Value, len(attrTypes))
+	for op := range attrTypes {
+		attrs[op] = types.StringNull()
+		if v, ok := values[op]; ok {
+			attrs[op] = types.StringValue(v)
+		}
+	}
+	return types.ObjectValueMust(attrTypes, attrs)
+}
+
+// timeoutsRaw builds the raw timeouts value of a schema with op set to value.
+func timeoutsRaw(s schemaType, op, value string) tftypes.Value {
+	objectType := schemaObjectType(s).AttributeTypes["timeouts"].(tftypes.Object)
+	attrs := map[string]tftypes.Value{}
+	for name := range objectType.AttributeTypes {
+		attrs[name] = tftypes.NewValue(tftypes.String, nil)
+	}
+	attrs[op] = tftypes.NewValue(tftypes.String, value)
+	return tftypes.NewValue(objectType, attrs)
+}
+
+func TestOperationTimeout(t *testing.T) {
+	ephemeralTypes := ephemeralTimeoutsAttribute().GetType().(types.ObjectType).AttrTypes
+
+	testCases := []struct {
+		name     string
+		timeouts types.Object
+		op       string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{name: "null", timeouts: types.ObjectNull(ephemeralTypes), op: timeoutOpen, want: defaultTimeout},
+		{name: "unknown", timeouts: types.ObjectUnknown(ephemeralTypes), op: timeoutOpen, want: defaultTimeout},
+		{name: "operation not configured", timeouts: resourceTimeouts(map[string]string{timeoutRead: "1m"}), op: timeoutCreate, want: defaultTimeout},
+		{name: "unsupported operation", timeouts: resourceTimeouts(nil), op: timeoutOpen, want: defaultTimeout},
+		{name: "configured", timeouts: resourceTimeouts(map[string]string{timeoutCreate: "30s"}), op: timeoutCreate, want: 30 * time.Second},
+		{name: "invalid", timeouts: resourceTimeouts(map[string]string{timeoutCreate: "soon"}), op: timeoutCreate, wantErr: true},
+		{name: "zero", timeouts: resourceTimeouts(map[string]string{timeoutCreate: "0s"}), op: timeoutCreate, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := operationTimeout(tc.timeouts, tc.op)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error=%v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	timeoutCtx, cancel, err := withTimeout(ctx, resourceTimeouts(map[string]string{timeoutRead: "1m"}), timeoutRead)
+	defer cancel()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deadline, ok := timeoutCtx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("expected a deadline within 1m, got %v (set=%v)", deadline, ok)
+	}
+
+	invalidCtx, cancel, err := withTimeout(ctx, resourceTimeouts(map[string]string{timeoutRead: "x"}), timeoutRead)
+	cancel()
+	if err == nil || invalidCtx != ctx {
+		t.Errorf("expected error and unchanged context, got %v", err)
+	}
+}
+
+func TestWrapTimeoutError(t *testing.T) {
+	base := errors.New("gpg failed")
+
+	if got := wrapTimeoutError(context.Background(), base); got != base {
+		t.Errorf("expected error unchanged without deadline, got %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	got := wrapTimeoutError(ctx, base)
+	if !errors.Is(got, base) || !strings.Contains(got.Error(), "hardware token") {
+		t.Errorf("expected wrapped error with guidance, got %v", got)
+	}
+	if code := ErrorCode(got); code != CodeTimeout {
+		t.Errorf("expected code %q for a gpg error after the deadline, got %q", CodeTimeout, code)
+	}
+}
+
+func TestGopassClient_GetSecret_Timeout(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = failingStoreForTimeout()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	_, err := client.GetSecret(ctx, "test/secret")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout guidance, got %v", err)
+	}
+}
+
+// failingStoreForTimeout returns a store failing like a killed gpg process.
+func failingStoreForTimeout() *mockStore {
+	store := newMockStore()
+	store.shouldFail = true
+	store.failMsg = "signal: killed"
+	return store
+}
+
+func TestSecretResource_InvalidTimeouts(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = newMockStore()
+	r := &SecretResource{client: client}
+	schemaResp := &resource.SchemaResponse{}
+	r.Schema(ctx, resource.SchemaRequest{}, schemaResp)
+	s := schemaResp.Schema
+
+	raw := func(op string) tftypes.Value {
+		return schemaObjectValue(s, map[string]tftypes.Value{
+			"id":               tftypes.NewValue(tftypes.String, "test/secret"),
+			"path":             tftypes.NewValue(tftypes.String, "test/secret"),
+			"delete_on_remove": tftypes.NewValue(tftypes.Bool, true),
+			"timeouts":         timeoutsRaw(s, op, "never"),
+		})
+	}
+
+	t.Run("create", func(t *testing.T) {
+		resp := &resource.CreateResponse{State: tfsdk.State{Schema: s}}
+		r.Create(ctx, resource.CreateRequest{
+			Plan:   tfsdk.Plan{Schema: s, Raw: raw(timeoutCreate)},
+			Config: tfsdk.Config{Schema: s, Raw: raw(timeoutCreate)},
+		}, resp)
+		assertInvalidTimeouts(t, resp.Diagnostics.Errors())
+	})
+
+	t.Run("read", func(t *testing.T) {
+		resp := &resource.ReadResponse{State: tfsdk.State{Schema: s, Raw: raw(timeoutRead)}}
+		r.Read(ctx, resource.ReadRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutRead)}}, resp)
+		assertInvalidTimeouts(t, resp.Diagnostics.Errors())
+	})
+
+	t.Run("update", func(t *testing.T) {
+		resp := &resource.UpdateResponse{State: tfsdk.State{Schema: s}}
+		r.Update(ctx, resource.UpdateRequest{
+			Plan:   tfsdk.Plan{Schema: s, Raw: raw(timeoutUpdate)},
+			State:  tfsdk.State{Schema: s, Raw: raw(timeoutUpdate)},
+			Config: tfsdk.Config{Schema: s, Raw: raw(timeoutUpdate)},
+		}, resp)
+		assertInvalidTimeouts(t, resp.Diagnostics.Errors())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := &resource.DeleteResponse{State: tfsdk.State{Schema: s, Raw: raw(timeoutDelete)}}
+		r.Delete(ctx, resource.DeleteRequest{State: tfsdk.State{Schema: s, Raw: raw(timeoutDelete)}}, resp)
+		assertInvalidTimeouts(t, resp.Diagnostics.Errors())
+	})
+}
+
+func TestEphemeralResources_InvalidTimeouts(t *testing.T) {
+	ctx := context.Background()
+	client := NewGopassClient("")
+	client.store = newMockStore()
+
+	testCases := []struct {
+		name string
+		r    ephemeral.EphemeralResource
+	}{
+		{name: "gopass_secret", r: &SecretEphemeralResource{client: client}},
+		{name: "gopass_env", r: &EnvEphemeralResource{client: client}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schemaResp := &ephemeral.SchemaResponse{}
+			tc.r.Schema(ctx, ephemeral.SchemaRequest{}, schemaResp)
+			s := schemaResp.Schema
+
+			req := ephemeral.OpenRequest{Config: tfsdk.Config{Schema: s, Raw: schemaObjectValue(s, map[string]tftypes.Value{
+				"path":     tftypes.NewValue(tftypes.String, "test"),
+				"timeouts": timeoutsRaw(s, timeoutOpen, "never"),
+			})}}
+			resp := &ephemeral.OpenResponse{Result: tfsdk.EphemeralResultData{Schema: s, Raw: schemaNullValue(s)}}
+			tc.r.Open(ctx, req, resp)
+
+			assertInvalidTimeouts(t, resp.Diagnostics.Errors())
+		})
+	}
+}
+
+// assertInvalidTimeouts checks that the only error is the invalid timeouts diagnostic.
+func assertInvalidTimeouts(t *testing.T, errs diag.Diagnostics) {
+	t.Helper()
+	if len(errs) != 1 || errs[0].Summary() != "Invalid timeouts" {
+		t.Errorf("expected a single 'Invalid timeouts' error, got %v", errs)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure implementation satisfies interfaces.
+var (
+	_ datasource.DataSource              = &TreeDataSource{}
+	_ datasource.DataSourceWithConfigure = &TreeDataSource{}
+)
+
+// TreeDataSource walks a folder and reports each secret and subfolder below it.
+type TreeDataSource struct {
+	client *GopassClient
+}
+
+// TreeDataSourceModel describes the data source data model.
+type TreeDataSourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	Path    types.String `tfsdk:"path"`
+	Entries types.List   `tfsdk:"entries"`
+}
+
+// treeEntryModel describes an element of entries.
+type treeEntryModel struct {
+	Path        types.String `tfsdk:"path"`
+	Depth       types.Int64  `tfsdk:"depth"`
+	HasChildren types.Bool   `tfsdk:"has_children"`
+	IsSecret    types.Bool   `tfsdk:"is_secret"`
+}
+
+// treeEntryAttrTypes describes the entries nested attribute.
+var treeEntryAttrTypes = map[string]attr.Type{
+	"path":         types.StringType,
+	"depth":        types.Int64Type,
+	"has_children": types.BoolType,
+	"is_secret":    types.BoolType,
+}
+
+// NewTreeDataSource creates a new instance.
+func NewTreeDataSource() datasource.DataSource {
+	return &TreeDataSource{}
+}
+
+func (d *TreeDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_tree"
+}
+
+func (d *TreeDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Walks a folder and lists every secret and subfolder below it, with depth and structure. No secret is decrypted.",
+		MarkdownDescription: `
+Walks a folder and lists every secret and subfolder below it, in lexical order, with its depth
+and whether it has children. Only the store index is read; no secret is decrypted.
+
+Use it to bring an existing subtree under management, e.g. by generating ` + "`import`" + ` blocks
+and ` + "`for_each`" + ` resources.
+
+## Example Usage
+
+` + "```hcl" + `
+data "gopass_tree" "legacy" {
+  path = "legacy"
+}
+
+locals {
+  legacy_secrets = toset([for e in data.gopass_tree.legacy.entries : e.path if e.is_secret])
+}
+
+import {
+  for_each = local.legacy_secrets
+  to       = gopass_secret.legacy[each.value]
+  id       = each.value
+}
+
+resource "gopass_secret" "legacy" {
+  for_each         = local.legacy_secrets
+  path             = each.value
+  delete_on_remove = false
+}
+` + "```" + `
+`,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Description: "The walked path.",
+				Computed:    true,
+			},
+			"path": schema.StringAttribute{
+				Description:         "Folder to walk (e.g., 'services').",
+				MarkdownDescription: "Folder to walk (e.g., `services`).",
+				Required:            true,
+				Validators:          []validator.String{validSecretPath()},
+			},
+			"entries": schema.ListNestedAttribute{
+				Description: "Secrets and subfolders below path at any depth, in lexical order of their paths.",
+				Computed:    true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"path": schema.StringAttribute{
+							Description: "Full path of the entry.",
+							Computed:    true,
+						},
+						"depth": schema.Int64Attribute{
+							Description: "Number of path components below the walked folder, 1 for its immediate children.",
+							Computed:    true,
+						},
+						"has_children": schema.BoolAttribute{
+							Description: "Whether secrets are stored below the entry, i.e. it is a folder.",
+							Computed:    true,
+						},
+						"is_secret": schema.BoolAttribute{
+							Description: "Whether a secret is stored at the path. An entry can be both a secret and a folder.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *TreeDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*GopassClient)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			codedDetail(CodeInternal, fmt.Sprintf("Expected *GopassClient, got: %T", req.ProviderData)),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+//nolint:gocritic // hugeParam: Terraform framework interface requirement
+func (d *TreeDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx = d.client.logContext(ctx)
+
+	var data TreeDataSourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	prefix := data.Path.ValueString()
+
+	nodes, err := d.client.ListTree(ctx, prefix)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Failed to walk tree",
+			errorDetail(err, fmt.Sprintf("Could not list secrets under %q: %s", prefix, err.Error())),
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Walked gopass tree", map[string]interface{}{
+		"path":    prefix,
+		"entries": len(nodes),
+	})
+
+	entries := make([]treeEntryModel, 0, len(nodes))
+	for _, n := range nodes {
+		entries = append(entries, treeEntryModel{
+			Path:        types.StringValue(n.Path),
+			Depth:       types.Int64Value(int64(n.Depth)),
+			HasChildren: types.BoolValue(n.HasChildren),
+			IsSecret:    types.BoolValue(n.IsSecret),
+		})
+	}
+	list, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: treeEntryAttrTypes}, entries)
+	resp.Diagnostics.Append(diags...)
+
+	data.ID = types.StringValue(prefix)
+	data.Entries = list
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
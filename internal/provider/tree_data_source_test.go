@@ -0,0 +1,141 @@
+// Copyright (c) Ingo Struck
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// readTree runs Read on the tree data source with the given config values.
+func readTree(t *testing.T, client *GopassClient, raw func(schemaResp *datasource.SchemaResponse) tftypes.Value) (*datasource.ReadResponse, TreeDataSourceModel) {
+	t.Helper()
+
+	d := &TreeDataSource{client: client}
+	ctx := context.Background()
+	schemaResp := &datasource.SchemaResponse{}
+	d.Schema(ctx, datasource.SchemaRequest{}, schemaResp)
+
+	req := datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: raw(schemaResp)}}
+	resp := &datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	d.Read(ctx, req, resp)
+
+	var data TreeDataSourceModel
+	if !resp.Diagnostics.HasError() {
+		resp.Diagnostics.Append(resp.State.Get(ctx, &data)...)
+	}
+	return resp, data
+}
+
+func treePath(p string) func(schemaResp *datasource.SchemaResponse) tftypes.Value {
+	return func(schemaResp *datasource.SchemaResponse) tftypes.Value {
+		return schemaObjectValue(schemaResp.Schema, map[string]tftypes.Value{
+			"path": tftypes.NewValue(tftypes.String, p),
+		})
+	}
+}
+
+func TestTreeDataSource_Metadata(t *testing.T) {
+	d := NewTreeDataSource()
+	resp := &datasource.MetadataResponse{}
+
+	d.Metadata(context.Background(), datasource.MetadataRequest{ProviderTypeName: "gopass"}, resp)
+
+	if resp.TypeName != "gopass_tree" {
+		t.Errorf("expected type name 'gopass_tree', got %q", resp.TypeName)
+	}
+}
+
+func TestTreeDataSource_Configure(t *testing.T) {
+	client := NewGopassClient("")
+
+	tests := []struct {
+		name         string
+		providerData any
+		wantErr      bool
+		wantClient   *GopassClient
+	}{
+		{name: "client", providerData: client, wantClient: client},
+		{name: "nil", providerData: nil},
+		{name: "invalid type", providerData: "invalid", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &TreeDataSource{}
+			resp := &datasource.ConfigureResponse{}
+
+			d.Configure(context.Background(), datasource.ConfigureRequest{ProviderData: tc.providerData}, resp)
+
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Errorf("expected error=%v, got %v", tc.wantErr, resp.Diagnostics)
+			}
+			if d.client != tc.wantClient {
+				t.Errorf("expected client %p, got %p", tc.wantClient, d.client)
+			}
+		})
+	}
+}
+
+func TestTreeDataSource_Read(t *testing.T) {
+	client := NewGopassClient("")
+	client.store = storeWith(map[string]string{
+		"services/api": "", "services/db": "", "services/db/replica": "", "services/legacy/ftp": "", "other/x": "",
+	})
+
+	resp, data := readTree(t, client, treePath("services"))
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected error: %v", resp.Diagnostics)
+	}
+
+	var got []treeEntryModel
+	resp.Diagnostics.Append(data.Entries.ElementsAs(context.Background(), &got, false)...)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("failed to read entries: %v", resp.Diagnostics)
+	}
+
+	type entry struct {
+		path        string
+		depth       int64
+		hasChildren bool
+		isSecret    bool
+	}
+	var entries []entry
+	for _, e := range got {
+		entries = append(entries, entry{e.Path.ValueString(), e.Depth.ValueInt64(), e.HasChildren.ValueBool(), e.IsSecret.ValueBool()})
+	}
+	want := []entry{
+		{"services/api", 1, false, true},
+		{"services/db", 1, true, true},
+		{"services/db/replica", 2, false, true},
+		{"services/legacy", 1, true, false},
+		{"services/legacy/ftp", 2, false, true},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+	if data.ID.ValueString() != "services" {
+		t.Errorf("expected id 'services', got %q", data.ID.ValueString())
+	}
+}
+
+func TestTreeDataSource_Read_Errors(t *testing.T) {
+	client := NewGopassClient("")
+	failingStore("store locked")(client)
+
+	resp, _ := readTree(t, client, treePath("services"))
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error when listing fails")
+	}
+
+	resp, _ = readTree(t, client, func(*datasource.SchemaResponse) tftypes.Value { return invalidRaw })
+	if !resp.Diagnostics.HasError() {
+		t.Error("expected error from Config.Get")
+	}
+}
@@ -9,7 +9,8 @@ import (
 	"log"
 
 	"git.ingo-struck.com/opentofu/terraform-provider-gopass/internal/provider"
-	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
+	"github.com/hashicorp/terraform-plugin-log/tfsdklog"
 )
 
 // version is set via ldflags at build time
@@ -21,12 +22,21 @@ func main() {
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	opts := providerserver.ServeOpts{
-		Address: "registry.opentofu.org/istr/gopass",
-		Debug:   debug,
+	var opts []tf6server.ServeOpt
+	if debug {
+		opts = append(opts, tf6server.WithManagedDebug())
 	}
 
-	err := providerserver.Serve(context.Background(), provider.New(version), opts)
+	err := tf6server.Serve("registry.opentofu.org/istr/gopass", provider.NewServer(version), opts...)
+
+	// The server stopped, so the run is over; close the stores and remove temporary clones
+	logCtx := tfsdklog.NewRootProviderLogger(context.Background(),
+		tfsdklog.WithLogName("gopass"),
+		tfsdklog.WithLevelFromEnv("TF_LOG_PROVIDER"),
+	)
+	provider.CloseStores(logCtx)
+	provider.RemoveTemporaryClones(logCtx)
+
 	if err != nil {
 		log.Fatal(err.Error())
 	}